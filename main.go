@@ -1,7 +1,7 @@
 package main
 
 import (
-	"crypto/tls"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 
 	"github.com/YapealAG/wsdl2go/wsdl"
 	"github.com/YapealAG/wsdl2go/wsdlgo"
@@ -24,29 +25,75 @@ type options struct {
 	Insecure       bool
 	ClientCertFile string
 	ClientKeyFile  string
+	User           string
+	BearerToken    string
+	Headers        headers
+	NSPackages     nsPackages
+	Ports          names
+	EmbedSchema    bool
+	IgnorePolicy   bool
+	Nillable       bool
+	BigNumbers     bool
+	Optional       wsdlgo.OptionalStyle
+	Style          wsdlgo.ParameterStyle
+	Server         bool
+	MTOM           bool
+	StreamBinary   bool
+	LenientEnums   bool
+	Mock           bool
+	Context        bool
+	Catalog        string
+	CacheDir       string
 	Version        bool
+	Dir            bool     // whether Dst is a directory, split in files
+	Shared         string   // import path of the types shared by the WSDLs of Batch
+	Batch          []string // WSDLs generated at once, with Shared
 }
 
 func main() {
-	opts := options{}
+	opts := options{Context: true}
 
 	flag.StringVar(&opts.Src, "i", opts.Src, "input file, url, or '-' for stdin")
-	flag.StringVar(&opts.Dst, "o", opts.Dst, "output file, or '-' for stdout")
+	flag.StringVar(&opts.Dst, "o", opts.Dst, "output file, directory (existing or ending with /) to split the code in files, or '-' for stdout")
 	flag.StringVar(&opts.Namespace, "n", opts.Namespace, "override namespace")
 	flag.StringVar(&opts.Package, "p", opts.Package, "package name")
 	flag.BoolVar(&opts.Insecure, "yolo", opts.Insecure, "accept invalid https certificates")
+	flag.BoolVar(&opts.Insecure, "insecure", opts.Insecure, "accept invalid https certificates (same as -yolo)")
 	flag.StringVar(&opts.ClientCertFile, "cert", opts.ClientCertFile, "use client TLS cert file")
 	flag.StringVar(&opts.ClientKeyFile, "key", opts.ClientKeyFile, "use client TLS key file")
+	flag.StringVar(&opts.User, "user", opts.User, "basic auth user[:password] for fetching the WSDL, the password defaults to $WSDL2GO_PASSWORD")
+	flag.StringVar(&opts.BearerToken, "bearer", opts.BearerToken, "bearer token for fetching the WSDL, defaults to $WSDL2GO_TOKEN")
+	flag.Var(&opts.Headers, "H", "HTTP header 'Name: value' for fetching the WSDL, can be repeated")
+	flag.BoolVar(&opts.EmbedSchema, "schema", opts.EmbedSchema, "embed the XML schema for request and response validation")
+	flag.BoolVar(&opts.IgnorePolicy, "ignore-policy", opts.IgnorePolicy, "ignore the WS-Policy of the WSDL")
+	flag.BoolVar(&opts.Nillable, "nillable", opts.Nillable, "send nil nillable elements as xsi:nil instead of omitting them")
+	flag.BoolVar(&opts.BigNumbers, "bignum", opts.BigNumbers, "generate soap.Decimal and soap.Integer fields of arbitrary precision for xsd:decimal and xsd:integer instead of float64 and int64")
+	flag.Var(&opts.Optional, "optional", "fields of elements of simple types: pointer (unless of minOccurs given as 1 or more), value (omitted when zero if optional) or wrapper (soap.Optional if optional, telling absent from empty)")
+	flag.Var(&opts.Style, "style", "parameters of document/literal operations: auto (unwrap the operations following the wrapped convention), wrapped (unwrap whenever possible) or bare (element structs)")
+	flag.BoolVar(&opts.Server, "server", opts.Server, "generate the soap.Server glue to implement the service")
+	flag.BoolVar(&opts.MTOM, "mtom", opts.MTOM, "generate soap.Binary fields for base64Binary elements, sent as MTOM attachments")
+	flag.BoolVar(&opts.StreamBinary, "stream-binary", opts.StreamBinary, "generate soap.Base64Stream fields for base64Binary elements, encoded from and decoded to an io.Reader for large payloads")
+	flag.BoolVar(&opts.LenientEnums, "lenient-enums", opts.LenientEnums, "decode values of enumerations other than those of the schema instead of failing")
+	flag.BoolVar(&opts.Mock, "mock", opts.Mock, "generate a mock implementation of the service interface for tests")
+	flag.BoolVar(&opts.Context, "context", opts.Context, "generate operation methods taking a context.Context (-context=false for methods without)")
+	flag.StringVar(&opts.Catalog, "catalog", opts.Catalog, "XML catalog mapping the locations of imported documents to local files")
+	flag.StringVar(&opts.CacheDir, "cache", opts.CacheDir, "directory caching the remote documents the WSDL imports")
+	flag.Var(&opts.NSPackages, "ns-package", "generate the types of a namespace in their own package, as 'namespace=import/path', with -o dir/; can be repeated")
+	flag.Var(&opts.Ports, "port", "generate only the client of the port, or of the ports of the service, of this name; can be repeated")
+	flag.StringVar(&opts.Shared, "shared", opts.Shared, "import path of the package of the types shared by several WSDLs, given as arguments")
 	flag.BoolVar(&opts.Version, "version", opts.Version, "show version and exit")
 	flag.Parse()
+	opts.Batch = flag.Args()
 	if opts.Version {
 		fmt.Printf("wsdl2go %s\n", version)
 		return
 	}
 	var w io.Writer
-	switch opts.Dst {
-	case "", "-":
+	switch {
+	case opts.Dst == "" || opts.Dst == "-":
 		w = os.Stdout
+	case isDir(opts.Dst):
+		opts.Dir = true
 	default:
 		f, err := os.OpenFile(opts.Dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
 		if err != nil {
@@ -56,25 +103,81 @@ func main() {
 		w = f
 	}
 
-	cli := httpClient(opts.Insecure, opts.ClientCertFile, opts.ClientKeyFile)
+	cli, err := httpClient(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	err := codegen(w, opts, cli)
+	if len(opts.Batch) > 0 {
+		err = batchgen(opts, cli)
+	} else {
+		err = codegen(w, opts, cli)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
 }
 
 func codegen(w io.Writer, opts options, cli *http.Client) error {
+	catalog, err := loadCatalog(opts)
+	if err != nil {
+		return err
+	}
+	enc, d, err := newEncoder(w, opts, opts.Src, cli, catalog)
+	if err != nil {
+		return err
+	}
+	if opts.Dir {
+		return enc.EncodeDir(d, opts.Dst)
+	}
+	return enc.Encode(d)
+}
+
+// batchgen generates the packages of the WSDLs of opts.Batch in the
+// directory opts.Dst, with their common types in the package opts.Shared.
+func batchgen(opts options, cli *http.Client) error {
+	if !opts.Dir || opts.Shared == "" {
+		return errors.New("several WSDLs need -o dir/ and -shared import/path")
+	}
+	catalog, err := loadCatalog(opts)
+	if err != nil {
+		return err
+	}
+	b := wsdlgo.NewBatch(opts.Shared)
+	for _, src := range opts.Batch {
+		enc, d, err := newEncoder(nil, opts, src, cli, catalog)
+		if err != nil {
+			return fmt.Errorf("%s: %v", src, err)
+		}
+		if err := b.Add(enc, d); err != nil {
+			return err
+		}
+	}
+	return b.EncodeDir(opts.Dst)
+}
+
+func loadCatalog(opts options) (*wsdlgo.Catalog, error) {
+	if opts.Catalog == "" {
+		return nil, nil
+	}
+	return wsdlgo.LoadCatalog(opts.Catalog)
+}
+
+// newEncoder returns the WSDL at src, and the Encoder of opts for it.
+func newEncoder(w io.Writer, opts options, src string, cli *http.Client, catalog *wsdlgo.Catalog) (wsdlgo.Encoder, *wsdl.Definitions, error) {
 	var err error
 	var f io.ReadCloser
-	if opts.Src == "" || opts.Src == "-" {
+	if mapped, ok := catalog.Resolve(src); ok {
+		src = mapped
+	}
+	if src == "" || src == "-" {
 		f = os.Stdin
-	} else if f, err = open(opts.Src, cli); err != nil {
-		return err
+	} else if f, err = open(src, cli); err != nil {
+		return nil, nil, err
 	}
 	d, err := wsdl.Unmarshal(f)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	f.Close()
 
@@ -88,8 +191,69 @@ func codegen(w io.Writer, opts options, cli *http.Client) error {
 	if opts.Namespace != "" {
 		enc.SetLocalNamespace(opts.Namespace)
 	}
+	enc.SetEmbedSchema(opts.EmbedSchema)
+	enc.SetIgnorePolicy(opts.IgnorePolicy)
+	enc.SetNillable(opts.Nillable)
+	enc.SetBigNumbers(opts.BigNumbers)
+	enc.SetOptionalStyle(opts.Optional)
+	enc.SetParameterStyle(opts.Style)
+	if len(opts.Ports) > 0 {
+		enc.SetServicePorts(opts.Ports...)
+	}
+	enc.SetServer(opts.Server)
+	enc.SetMTOM(opts.MTOM)
+	enc.SetStreamBinary(opts.StreamBinary)
+	enc.SetLenientEnums(opts.LenientEnums)
+	enc.SetMock(opts.Mock)
+	enc.SetContext(opts.Context)
+	enc.SetCatalog(catalog)
+	enc.SetCacheDir(opts.CacheDir)
+	if src != "" && src != "-" {
+		enc.SetLocation(src)
+	}
 
-	return enc.Encode(d)
+	for _, p := range opts.NSPackages {
+		enc.SetNamespacePackage(p[0], p[1])
+	}
+	return enc, d, nil
+}
+
+// nsPackages are the namespace and import path of the -ns-package flags.
+type nsPackages [][2]string
+
+func (p *nsPackages) String() string {
+	return fmt.Sprint([][2]string(*p))
+}
+
+func (p *nsPackages) Set(v string) error {
+	i := strings.LastIndex(v, "=")
+	if i <= 0 || i == len(v)-1 {
+		return fmt.Errorf("invalid namespace package %q, want 'namespace=import/path'", v)
+	}
+	*p = append(*p, [2]string{v[:i], v[i+1:]})
+	return nil
+}
+
+// names are the names of a repeated flag, such as -port.
+type names []string
+
+func (n *names) String() string {
+	return strings.Join(*n, ",")
+}
+
+func (n *names) Set(v string) error {
+	*n = append(*n, v)
+	return nil
+}
+
+// isDir returns whether the output dst is a directory, existing or
+// ending with a slash.
+func isDir(dst string) bool {
+	if strings.HasSuffix(dst, "/") || strings.HasSuffix(dst, string(os.PathSeparator)) {
+		return true
+	}
+	fi, err := os.Stat(dst)
+	return err == nil && fi.IsDir()
 }
 
 func open(name string, cli *http.Client) (io.ReadCloser, error) {
@@ -101,35 +265,64 @@ func open(name string, cli *http.Client) (io.ReadCloser, error) {
 	if err != nil {
 		return nil, err
 	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", name, resp.Status)
+	}
 	return resp.Body, err
 }
 
-// httpClient returns http client with default options
-func httpClient(insecure bool, clientCertPath, clientKeyPath string) *http.Client {
-	tlsConfig := &tls.Config{InsecureSkipVerify: insecure}
+// headers are the HTTP headers of the -H flags.
+type headers http.Header
 
-	if clientCertPath != "" && clientKeyPath != "" {
-		clientCert, err := tls.LoadX509KeyPair(clientCertPath, clientKeyPath)
-		if err != nil {
-			log.Fatalln("Failed to load x509 client key pair:", err)
+func (h *headers) String() string {
+	return fmt.Sprint(map[string][]string(*h))
+}
+
+func (h *headers) Set(v string) error {
+	name, value, ok := strings.Cut(v, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("invalid header %q, want 'Name: value'", v)
+	}
+	if *h == nil {
+		*h = make(headers)
+	}
+	http.Header(*h).Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	return nil
+}
+
+// httpClient returns the http client fetching the WSDL and its imports
+// with the authentication of opts. Credentials and headers are only sent
+// to the hosts of the WSDLs, not to those of their imports.
+func httpClient(opts options) (*http.Client, error) {
+	var copts []wsdlgo.ClientOption
+	if opts.Insecure {
+		copts = append(copts, wsdlgo.WithInsecureTLS())
+	}
+	if opts.ClientCertFile != "" || opts.ClientKeyFile != "" {
+		copts = append(copts, wsdlgo.WithClientCertificate(opts.ClientCertFile, opts.ClientKeyFile))
+	}
+	if opts.User != "" {
+		user, password, ok := strings.Cut(opts.User, ":")
+		if !ok {
+			password = os.Getenv("WSDL2GO_PASSWORD")
+		}
+		copts = append(copts, wsdlgo.WithBasicAuth(user, password))
+	}
+	token := opts.BearerToken
+	if token == "" {
+		token = os.Getenv("WSDL2GO_TOKEN")
+	}
+	if token != "" {
+		copts = append(copts, wsdlgo.WithBearerToken(token))
+	}
+	if len(opts.Headers) > 0 {
+		copts = append(copts, wsdlgo.WithHeader(http.Header(opts.Headers)))
+	}
+	for _, src := range append([]string{opts.Src}, opts.Batch...) {
+		if u, err := url.Parse(src); err == nil && u.Host != "" {
+			copts = append(copts, wsdlgo.WithAuthHosts(u.Hostname()))
 		}
-		tlsConfig.Certificates = []tls.Certificate{clientCert}
-		tlsConfig.Renegotiation = tls.RenegotiateFreelyAsClient
-	} else if clientCertPath == "" && clientKeyPath != "" {
-		log.Fatalln("Certificate file is required when using key file")
-	} else if clientCertPath != "" && clientKeyPath == "" {
-		log.Fatalln("Key file is required when using certificate file")
-	}
-
-	defaultTransport := http.DefaultTransport.(*http.Transport)
-	transport := &http.Transport{
-		Proxy:                 defaultTransport.Proxy,
-		DialContext:           defaultTransport.DialContext,
-		MaxIdleConns:          defaultTransport.MaxIdleConns,
-		IdleConnTimeout:       defaultTransport.IdleConnTimeout,
-		ExpectContinueTimeout: defaultTransport.ExpectContinueTimeout,
-		TLSHandshakeTimeout:   defaultTransport.TLSHandshakeTimeout,
-		TLSClientConfig:       tlsConfig,
-	}
-	return &http.Client{Transport: transport}
+	}
+	return wsdlgo.NewHTTPClient(copts...)
 }
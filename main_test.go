@@ -0,0 +1,32 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, password, _ := r.BasicAuth(); password != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, "<definitions/>")
+	}))
+	defer s.Close()
+	if _, err := open(s.URL+"/service.wsdl", s.Client()); err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Fatalf("want an error of the 401 status, have %v", err)
+	}
+	u := strings.Replace(s.URL, "://", "://user:secret@", 1)
+	f, err := open(u+"/service.wsdl", s.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if b, _ := io.ReadAll(f); string(b) != "<definitions/>" {
+		t.Fatalf("unexpected WSDL %q", b)
+	}
+}
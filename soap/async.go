@@ -0,0 +1,98 @@
+package soap
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// RoundTripAsync performs RoundTripWithActionContext in a new goroutine.
+// The returned channel receives the error of the call, or nil, once out
+// is decoded; it is buffered so the result may be ignored.
+func (c *Client) RoundTripAsync(ctx context.Context, soapAction string, in, out Message) <-chan error {
+	ch := make(chan error, 1)
+	go func() {
+		ch <- c.RoundTripWithActionContext(ctx, soapAction, in, out)
+	}()
+	return ch
+}
+
+// CallGroup runs calls concurrently with bounded parallelism, for batch
+// jobs:
+//
+//	g := soap.NewCallGroup(ctx, 8)
+//	for _, id := range ids {
+//		req, reply := &GetRequest{ID: id}, &GetReply{}
+//		g.Go(func(ctx context.Context) error {
+//			return cli.RoundTripWithActionContext(ctx, "Get", req, reply)
+//		})
+//	}
+//	err := g.Wait()
+//
+// The zero value is not usable; create CallGroups with NewCallGroup.
+type CallGroup struct {
+	ctx  context.Context
+	sem  chan struct{}
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	errs []error
+}
+
+// NewCallGroup returns a CallGroup that passes ctx to its calls and runs
+// at most limit of them at a time. A limit of 0 or less means no limit.
+func NewCallGroup(ctx context.Context, limit int) *CallGroup {
+	g := &CallGroup{ctx: ctx}
+	if limit > 0 {
+		g.sem = make(chan struct{}, limit)
+	}
+	return g
+}
+
+// Go runs fn in a new goroutine, blocking while the group runs limit
+// calls. The returned channel receives the error of fn, or nil; it is
+// buffered so the result may be ignored. If the context of the group is
+// done before fn starts, fn is not called and the channel receives the
+// context error.
+func (g *CallGroup) Go(fn func(ctx context.Context) error) <-chan error {
+	ch := make(chan error, 1)
+	if err := g.ctx.Err(); err != nil {
+		g.done(ch, err)
+		return ch
+	}
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		case <-g.ctx.Done():
+			g.done(ch, g.ctx.Err())
+			return ch
+		}
+	}
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		err := fn(g.ctx)
+		if g.sem != nil {
+			<-g.sem
+		}
+		g.done(ch, err)
+	}()
+	return ch
+}
+
+func (g *CallGroup) done(ch chan error, err error) {
+	if err != nil {
+		g.mu.Lock()
+		g.errs = append(g.errs, err)
+		g.mu.Unlock()
+	}
+	ch <- err
+}
+
+// Wait blocks until all calls of the group have returned, and returns
+// their errors joined with errors.Join, or nil.
+func (g *CallGroup) Wait() error {
+	g.wg.Wait()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return errors.Join(g.errs...)
+}
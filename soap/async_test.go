@@ -0,0 +1,88 @@
+package soap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRoundTripAsync(t *testing.T) {
+	type msgT struct{ A, B string }
+	type envT struct{ msgT }
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	}))
+	defer s.Close()
+	c := NewClient(s.URL, WithNamespace("urn:test"))
+	out := &envT{}
+	if err := <-c.RoundTripAsync(context.Background(), "Echo", &msgT{A: "hello"}, out); err != nil {
+		t.Fatal(err)
+	}
+	if out.A != "hello" {
+		t.Fatalf("unexpected response %#v", out)
+	}
+}
+
+func TestCallGroup(t *testing.T) {
+	var running, peak int32
+	g := NewCallGroup(context.Background(), 2)
+	errFail := errors.New("fail")
+	var chans []<-chan error
+	for i := 0; i < 6; i++ {
+		i := i
+		chans = append(chans, g.Go(func(ctx context.Context) error {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			if i%3 == 0 {
+				return errFail
+			}
+			return nil
+		}))
+	}
+	err := g.Wait()
+	if !errors.Is(err, errFail) {
+		t.Fatalf("want joined errors, have %v", err)
+	}
+	if peak > 2 {
+		t.Fatalf("want at most 2 concurrent calls, have %d", peak)
+	}
+	for i, ch := range chans {
+		if err := <-ch; (err != nil) != (i%3 == 0) {
+			t.Errorf("call %d: unexpected error %v", i, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g = NewCallGroup(ctx, 1)
+	block := make(chan struct{})
+	g.Go(func(ctx context.Context) error { <-block; return nil })
+	cancel()
+	if err := <-g.Go(func(ctx context.Context) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Fatalf("want canceled, have %v", err)
+	}
+	close(block)
+	g.Wait()
+
+	for _, limit := range []int{0, 1} {
+		g = NewCallGroup(ctx, limit)
+		called := false
+		if err := <-g.Go(func(ctx context.Context) error { called = true; return nil }); !errors.Is(err, context.Canceled) || called {
+			t.Errorf("limit %d: want canceled without calling fn, have %v and called %v", limit, err, called)
+		}
+		if err := g.Wait(); !errors.Is(err, context.Canceled) {
+			t.Errorf("limit %d: want canceled, have %v", limit, err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package soap
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// An Authenticator adds credentials to outbound HTTP requests. It is
+// called for every request, after the SOAP headers are set and before
+// the Pre hook runs.
+type Authenticator interface {
+	Authenticate(r *http.Request) error
+}
+
+// AuthenticatorFunc is an adapter to allow the use of ordinary functions
+// as Authenticators.
+type AuthenticatorFunc func(r *http.Request) error
+
+// Authenticate calls f(r).
+func (f AuthenticatorFunc) Authenticate(r *http.Request) error {
+	return f(r)
+}
+
+// BasicAuth is an Authenticator for HTTP Basic authentication.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// Authenticate implements the Authenticator interface.
+func (a *BasicAuth) Authenticate(r *http.Request) error {
+	r.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+// SPNEGO is an Authenticator for HTTP Negotiate authentication (RFC 4559),
+// as used by Kerberos-protected endpoints in Active Directory domains.
+//
+// The package does not ship a Kerberos implementation; Token is expected
+// to obtain the initial SPNEGO token for the service principal from one,
+// e.g. with github.com/jcmturner/gokrb5:
+//
+//	Token: func(spn string) ([]byte, error) {
+//		s := spnego.SPNEGOClient(krb5Client, spn)
+//		if err := s.AcquireCred(); err != nil {
+//			return nil, err
+//		}
+//		tok, err := s.InitSecContext()
+//		if err != nil {
+//			return nil, err
+//		}
+//		return tok.Marshal()
+//	}
+type SPNEGO struct {
+	// SPN is the service principal name. Defaults to HTTP/<host>, where
+	// host is the host of the request URL without port.
+	SPN string
+
+	// Token returns the SPNEGO token for the given service principal.
+	Token func(spn string) ([]byte, error)
+}
+
+// Authenticate implements the Authenticator interface.
+func (a *SPNEGO) Authenticate(r *http.Request) error {
+	if a.Token == nil {
+		return errors.New("soap: SPNEGO authenticator has no Token func")
+	}
+	spn := a.SPN
+	if spn == "" {
+		host := r.URL.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		spn = "HTTP/" + strings.ToLower(host)
+	}
+	tok, err := a.Token(spn)
+	if err != nil {
+		return fmt.Errorf("soap: SPNEGO token for %q: %v", spn, err)
+	}
+	r.Header.Set("Authorization", "Negotiate "+base64.StdEncoding.EncodeToString(tok))
+	return nil
+}
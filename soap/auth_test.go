@@ -0,0 +1,98 @@
+package soap
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestAuthenticators(t *testing.T) {
+	type msgT struct{ A, B string }
+	type envT struct{ msgT }
+	var authz string
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authz = r.Header.Get("Authorization")
+		io.Copy(w, r.Body)
+	})
+	s := httptest.NewServer(echo)
+	defer s.Close()
+	var spn string
+	token := func(v string) ([]byte, error) {
+		spn = v
+		return []byte("ticket"), nil
+	}
+	cases := []struct {
+		Auth Authenticator
+		Want string
+		Fail bool
+	}{
+		{
+			Auth: &BasicAuth{Username: "foo", Password: "bar"},
+			Want: "Basic " + base64.StdEncoding.EncodeToString([]byte("foo:bar")),
+		},
+		{
+			Auth: &SPNEGO{Token: token},
+			Want: "Negotiate " + base64.StdEncoding.EncodeToString([]byte("ticket")),
+		},
+		{
+			Auth: AuthenticatorFunc(func(r *http.Request) error {
+				return errors.New("no credentials")
+			}),
+			Fail: true,
+		},
+	}
+	for i, tc := range cases {
+		authz = ""
+		c := &Client{URL: s.URL, Auth: tc.Auth}
+		err := c.RoundTrip(&msgT{A: "hello", B: "world"}, &envT{})
+		if tc.Fail {
+			if err == nil {
+				t.Errorf("test %d: expected error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if authz != tc.Want {
+			t.Errorf("test %d: want %q, have %q", i, tc.Want, authz)
+		}
+	}
+	if spn != "HTTP/127.0.0.1" {
+		t.Errorf("unexpected SPN %q", spn)
+	}
+}
+
+type countingTokenSource struct{ n int }
+
+func (ts *countingTokenSource) Token() (*oauth2.Token, error) {
+	ts.n++
+	return &oauth2.Token{AccessToken: "t" + string(rune('0'+ts.n)), TokenType: "Bearer"}, nil
+}
+
+func TestTokenSource(t *testing.T) {
+	type msgT struct{ A, B string }
+	type envT struct{ msgT }
+	var authz []string
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authz = append(authz, r.Header.Get("Authorization"))
+		io.Copy(w, r.Body)
+	})
+	s := httptest.NewServer(echo)
+	defer s.Close()
+	c := &Client{URL: s.URL, TokenSource: &countingTokenSource{}}
+	for i := 0; i < 2; i++ {
+		if err := c.RoundTrip(&msgT{A: "hello"}, &envT{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(authz) != 2 || authz[0] != "Bearer t1" || authz[1] != "Bearer t2" {
+		t.Fatalf("unexpected Authorization headers: %q", authz)
+	}
+}
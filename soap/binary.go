@@ -0,0 +1,203 @@
+package soap
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Base64Binary is a value of xsd:base64Binary, the bytes encoded in
+// base64 as the text of its element or attribute. The line breaks and
+// spaces of the text are ignored when decoding it.
+type Base64Binary []byte
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (b Base64Binary) MarshalText() ([]byte, error) {
+	text := make([]byte, base64.StdEncoding.EncodedLen(len(b)))
+	base64.StdEncoding.Encode(text, b)
+	return text, nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (b *Base64Binary) UnmarshalText(text []byte) error {
+	text = bytes.Join(bytes.Fields(text), nil)
+	v := make([]byte, base64.StdEncoding.DecodedLen(len(text)))
+	n, err := base64.StdEncoding.Decode(v, text)
+	if err != nil {
+		return err
+	}
+	*b = v[:n]
+	return nil
+}
+
+// HexBinary is a value of xsd:hexBinary, the bytes encoded as pairs
+// of hexadecimal digits, upper case as in the canonical form of the
+// type, or either case when decoding them.
+type HexBinary []byte
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (b HexBinary) MarshalText() ([]byte, error) {
+	return []byte(strings.ToUpper(hex.EncodeToString(b))), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (b *HexBinary) UnmarshalText(text []byte) error {
+	text = bytes.TrimSpace(text)
+	v := make([]byte, hex.DecodedLen(len(text)))
+	if _, err := hex.Decode(v, text); err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+// base64Chunk is the number of bytes of the Reader of a Base64Stream
+// encoded at once, a multiple of 3 so that only the last chunk is
+// padded.
+const base64Chunk = 3 << 12
+
+// Base64Stream is a value of xsd:base64Binary of a Reader, for payloads
+// too large to be held in a []byte, such as files: it is encoded by
+// reading its Reader while the element is written, and decoded into a
+// temporary file that its Reader then reads, until Close removes it.
+//
+//	f, err := os.Open("scan.pdf")
+//	...
+//	req.Document = soap.NewBase64Stream(f)
+//
+// Its Reader is read once, when it is encoded. The base64 text of the
+// payload is still in the envelope of the message, encoded in memory
+// like any other, and in the buffer of the xml.Decoder decoding an
+// element, which reads its text at once, but the payload never is.
+type Base64Stream struct {
+	io.Reader
+	file *os.File // temporary file of the value decoded
+}
+
+// NewBase64Stream returns the Base64Stream of r.
+func NewBase64Stream(r io.Reader) *Base64Stream {
+	return &Base64Stream{Reader: r}
+}
+
+// MarshalXML implements the xml.Marshaler interface, encoding the bytes
+// of Reader in chunks.
+func (s *Base64Stream) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if s.Reader != nil {
+		buf := make([]byte, base64Chunk)
+		text := make([]byte, base64.StdEncoding.EncodedLen(base64Chunk))
+		for {
+			n, err := io.ReadFull(s.Reader, buf)
+			if n > 0 {
+				base64.StdEncoding.Encode(text, buf[:n])
+				if err := e.EncodeToken(xml.CharData(text[:base64.StdEncoding.EncodedLen(n)])); err != nil {
+					return err
+				}
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// UnmarshalXML implements the xml.Unmarshaler interface, decoding the
+// element into a temporary file, which replaces that of a value decoded
+// before.
+func (s *Base64Stream) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	f, err := os.CreateTemp("", "soap-base64-*")
+	if err != nil {
+		return err
+	}
+	if err := decodeBase64(d, f); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if s.file != nil {
+		s.Close()
+	}
+	s.Reader, s.file = f, f
+	return nil
+}
+
+// decodeBase64 writes to w the bytes of the base64 text of the element
+// d is decoding, up to its end.
+func decodeBase64(d *xml.Decoder, w io.Writer) error {
+	var pending, buf []byte
+	flush := func(all bool) error {
+		n := len(pending)
+		if !all {
+			n -= n % 4
+		}
+		if n == 0 {
+			return nil
+		}
+		if cap(buf) < base64.StdEncoding.DecodedLen(n) {
+			buf = make([]byte, base64.StdEncoding.DecodedLen(n))
+		}
+		m, err := base64.StdEncoding.Decode(buf[:cap(buf)], pending[:n])
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(buf[:m]); err != nil {
+			return err
+		}
+		pending = append(pending[:0], pending[n:]...)
+		return nil
+	}
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := t.(type) {
+		case xml.CharData:
+			for _, c := range t {
+				if !unicode.IsSpace(rune(c)) {
+					pending = append(pending, c)
+				}
+			}
+			if err := flush(false); err != nil {
+				return err
+			}
+		case xml.StartElement:
+			if err := d.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return flush(true)
+		}
+	}
+}
+
+// Close closes the Reader of s, removing the temporary file of a value
+// decoded.
+func (s *Base64Stream) Close() error {
+	if s.file != nil {
+		f := s.file
+		s.Reader, s.file = nil, nil
+		return errors.Join(f.Close(), os.Remove(f.Name()))
+	}
+	if c, ok := s.Reader.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
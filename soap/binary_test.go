@@ -0,0 +1,91 @@
+package soap
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBinaryText(t *testing.T) {
+	type doc struct {
+		XMLName xml.Name     `xml:"doc"`
+		Data    Base64Binary `xml:"data"`
+		Hash    HexBinary    `xml:"hash,attr"`
+	}
+	var v doc
+	err := xml.Unmarshal([]byte("<doc hash=\"0fa1\"><data>\n  aGVs\n  bG8=\n</data></doc>"), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(v.Data) != "hello" || !bytes.Equal(v.Hash, []byte{0x0f, 0xa1}) {
+		t.Fatalf("want hello and 0fa1, have %q and %x", v.Data, v.Hash)
+	}
+	b, err := xml.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<doc hash="0FA1"><data>aGVsbG8=</data></doc>`; string(b) != want {
+		t.Errorf("want %s, have %s", want, b)
+	}
+	b, err = json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"XMLName":{"Space":"","Local":"doc"},"Data":"aGVsbG8=","Hash":"0FA1"}`; string(b) != want {
+		t.Errorf("want %s, have %s", want, b)
+	}
+	for _, bad := range []string{`<doc><data>a$==</data></doc>`, `<doc hash="abc"/>`} {
+		if err := xml.Unmarshal([]byte(bad), &v); err == nil {
+			t.Errorf("%s accepted", bad)
+		}
+	}
+}
+
+func TestBase64Stream(t *testing.T) {
+	type doc struct {
+		XMLName xml.Name      `xml:"doc"`
+		Data    *Base64Stream `xml:"data,omitempty"`
+	}
+	payload := bytes.Repeat([]byte("0123456789"), 5000) // several chunks
+	var b bytes.Buffer
+	if err := xml.NewEncoder(&b).Encode(doc{Data: NewBase64Stream(bytes.NewReader(payload))}); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := Base64Binary(payload).MarshalText()
+	if have := b.String(); have != "<doc><data>"+string(want)+"</data></doc>" {
+		t.Fatalf("want base64 of the payload, have %.80s...", have)
+	}
+
+	// Line breaks may split the quanta of the text.
+	text := string(want)
+	var wrapped strings.Builder
+	for i := 0; i < len(text); i += 76 {
+		wrapped.WriteString(text[i:min(i+76, len(text))] + "\r\n ")
+	}
+	var v doc
+	if err := xml.Unmarshal([]byte("<doc><data>"+wrapped.String()+"</data></doc>"), &v); err != nil {
+		t.Fatal(err)
+	}
+	name := v.Data.file.Name()
+	have, err := io.ReadAll(v.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(have, payload) {
+		t.Errorf("want the payload of %d bytes, have %d bytes", len(payload), len(have))
+	}
+	if err := v.Data.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("temporary file %s not removed: %v", name, err)
+	}
+
+	if err := xml.Unmarshal([]byte("<doc><data>aGVsbG8=x</data></doc>"), &v); err == nil {
+		t.Error("invalid base64 accepted")
+	}
+}
@@ -0,0 +1,147 @@
+package soap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CircuitOpenError is returned without calling the server while a
+// CircuitBreaker is open.
+type CircuitOpenError struct {
+	Failures int       // Consecutive failures that opened the circuit
+	Until    time.Time // When the next call is let through to probe the server
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("soap: circuit open after %d consecutive failures, retry after %s",
+		e.Failures, e.Until.Format(time.RFC3339))
+}
+
+// CircuitBreaker fails calls fast after the server failed Threshold
+// consecutive times. While open, calls return *CircuitOpenError; after
+// OpenTimeout one call is let through, and the circuit closes again if
+// the server answers it. It is safe for concurrent use.
+//
+//	cb := &soap.CircuitBreaker{Threshold: 5, OpenTimeout: time.Minute}
+//	cli.Middleware = append(cli.Middleware, cb.Middleware())
+type CircuitBreaker struct {
+	Threshold   int           // Consecutive failures that open the circuit (default 5)
+	OpenTimeout time.Duration // How long the circuit stays open (default 30s)
+
+	// Failure reports whether err counts as a failure of the server.
+	// The default counts connection errors, timeouts and HTTP 5xx
+	// responses without a SOAP fault; faults are answers of a healthy
+	// server and don't count.
+	Failure func(err error) bool
+
+	mu       sync.Mutex
+	failures int
+	until    time.Time
+	probing  bool
+	now      func() time.Time
+}
+
+// Middleware returns the Middleware that guards calls with the breaker.
+func (cb *CircuitBreaker) Middleware() Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(ctx context.Context, call *Call) error {
+			probe, err := cb.allow()
+			if err != nil {
+				return err
+			}
+			if probe {
+				defer cb.endProbe()
+			}
+			err = next(ctx, call)
+			cb.record(err)
+			return err
+		}
+	}
+}
+
+// Open reports whether the circuit is open.
+func (cb *CircuitBreaker) Open() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.clock().Before(cb.until)
+}
+
+// allow returns a *CircuitOpenError if the call may not go through, or
+// true if it probes the server.
+func (cb *CircuitBreaker) allow() (bool, error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.until.IsZero() {
+		return false, nil
+	}
+	if cb.clock().Before(cb.until) || cb.probing {
+		return false, &CircuitOpenError{Failures: cb.failures, Until: cb.until}
+	}
+	cb.probing = true
+	return true, nil
+}
+
+// endProbe lets the next call probe the server, however the probe ended.
+func (cb *CircuitBreaker) endProbe() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.probing = false
+}
+
+// record counts the failure err, closes the circuit if the server
+// answered, or else leaves it as it is.
+func (cb *CircuitBreaker) record(err error) {
+	failure := cb.Failure
+	if failure == nil {
+		failure = serverFailure
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	var fault *Fault
+	switch {
+	case err == nil || !failure(err) && errors.As(err, &fault):
+		cb.failures = 0
+		cb.until = time.Time{}
+		return
+	case !failure(err):
+		// Errors such as the cancelation of the call tell nothing of
+		// the server.
+		return
+	}
+	cb.failures++
+	threshold := cb.Threshold
+	if threshold <= 0 {
+		threshold = 5
+	}
+	if cb.failures >= threshold {
+		timeout := cb.OpenTimeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		cb.until = cb.clock().Add(timeout)
+	}
+}
+
+func (cb *CircuitBreaker) clock() time.Time {
+	if cb.now != nil {
+		return cb.now()
+	}
+	return time.Now()
+}
+
+// serverFailure is the default CircuitBreaker.Failure.
+func serverFailure(err error) bool {
+	var fault *Fault
+	if errors.As(err, &fault) {
+		return false
+	}
+	var herr *HTTPError
+	if errors.As(err, &herr) {
+		return herr.StatusCode >= http.StatusInternalServerError
+	}
+	return errors.Is(err, context.DeadlineExceeded) || shouldFailover(err)
+}
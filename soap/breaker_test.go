@@ -0,0 +1,101 @@
+package soap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	cb := &CircuitBreaker{Threshold: 2, OpenTimeout: time.Minute}
+	cb.now = func() time.Time { return now }
+	var result error
+	var calls int
+	rt := cb.Middleware()(func(ctx context.Context, call *Call) error {
+		calls++
+		return result
+	})
+	down := &HTTPError{StatusCode: http.StatusServiceUnavailable}
+	fault := &HTTPError{StatusCode: http.StatusInternalServerError, Fault: &Fault{Code: "Server"}}
+	testCases := []struct {
+		advance time.Duration
+		result  error
+		called  bool
+		open    bool
+	}{
+		{0, down, true, false},
+		{0, fault, true, false},
+		{0, down, true, false},
+		{0, context.DeadlineExceeded, true, true},
+		{time.Second, nil, false, true},
+		{time.Minute, down, true, true},
+		{time.Minute, nil, true, false},
+		{0, down, true, false},
+	}
+	for i, tc := range testCases {
+		now = now.Add(tc.advance)
+		result, calls = tc.result, 0
+		err := rt(context.Background(), &Call{})
+		if have := calls == 1; have != tc.called {
+			t.Errorf("test %d: want called %v, have %v", i, tc.called, have)
+		}
+		var open *CircuitOpenError
+		if !tc.called && !errors.As(err, &open) {
+			t.Errorf("test %d: want CircuitOpenError, have %v", i, err)
+		}
+		if have := cb.Open(); have != tc.open {
+			t.Errorf("test %d: want open %v, have %v", i, tc.open, have)
+		}
+	}
+}
+
+func TestCircuitBreakerProbe(t *testing.T) {
+	now := time.Now()
+	cb := &CircuitBreaker{Threshold: 2, OpenTimeout: time.Minute}
+	cb.now = func() time.Time { return now }
+	var result error
+	var calls int
+	rt := cb.Middleware()(func(ctx context.Context, call *Call) error {
+		calls++
+		if result == nil {
+			panic("probe")
+		}
+		return result
+	})
+	call := func() (err error) {
+		defer func() { recover() }()
+		return rt(context.Background(), &Call{})
+	}
+	down := &HTTPError{StatusCode: http.StatusServiceUnavailable}
+	testCases := []struct {
+		advance time.Duration
+		result  error
+		called  bool
+		open    bool
+	}{
+		{0, down, true, false},
+		{0, down, true, true},
+		// A probe canceled by the caller leaves the circuit as it is, so
+		// the next failure opens it again.
+		{time.Minute, context.Canceled, true, false},
+		{0, down, true, true},
+		// So does a panic of the probe, letting another one through.
+		{time.Minute, nil, true, false},
+		{0, &HTTPError{StatusCode: http.StatusBadRequest}, true, false},
+		{0, down, true, true},
+	}
+	for i, tc := range testCases {
+		now = now.Add(tc.advance)
+		result, calls = tc.result, 0
+		err := call()
+		if have := calls == 1; have != tc.called {
+			t.Errorf("test %d: want called %v, have %v (%v)", i, tc.called, have, err)
+		}
+		if have := cb.Open(); have != tc.open {
+			t.Errorf("test %d: want open %v, have %v", i, tc.open, have)
+		}
+	}
+}
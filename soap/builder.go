@@ -0,0 +1,48 @@
+package soap
+
+import (
+	"bytes"
+	"io"
+)
+
+// An EnvelopeBuilder builds the request envelopes of a client, for
+// services that need control over the envelope beyond the client's
+// settings, such as custom attributes, comments or vendor-specific
+// wrappers. The client's transport, HTTP headers, request filters and
+// response decoding apply as usual.
+type EnvelopeBuilder interface {
+	// BuildEnvelope writes the request envelope of call to w. env is
+	// the envelope the client would send, for builders that adjust it
+	// with xml.Encoder rather than write their own.
+	BuildEnvelope(w io.Writer, call *Call, env *Envelope) error
+}
+
+// EnvelopeBuilderFunc is an EnvelopeBuilder function.
+type EnvelopeBuilderFunc func(w io.Writer, call *Call, env *Envelope) error
+
+// BuildEnvelope calls f(w, call, env).
+func (f EnvelopeBuilderFunc) BuildEnvelope(w io.Writer, call *Call, env *Envelope) error {
+	return f(w, call, env)
+}
+
+// WithEnvelopeBuilder builds request envelopes with b. The envelopes it
+// writes are sent as they are: the client's XMLDeclaration and Encoding
+// settings don't apply to them, other than the charset of the
+// Content-Type header.
+func WithEnvelopeBuilder(b EnvelopeBuilder) Option {
+	return func(c *Client) { c.EnvelopeBuilder = b }
+}
+
+// buildEnvelope encodes env, the envelope of call, or has the client's
+// EnvelopeBuilder write the envelope, into a pooled buffer.
+func (c *Client) buildEnvelope(call *Call, env *Envelope) (*bytes.Buffer, error) {
+	if c.EnvelopeBuilder == nil {
+		return c.encodeEnvelope(env)
+	}
+	b := getBuffer()
+	if err := c.EnvelopeBuilder.BuildEnvelope(b, call, env); err != nil {
+		putBuffer(b)
+		return nil, err
+	}
+	return b, nil
+}
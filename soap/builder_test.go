@@ -0,0 +1,55 @@
+package soap
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEnvelopeBuilder(t *testing.T) {
+	type msgT struct{ A string }
+	var body, action string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body, action = string(b), r.Header.Get("SOAPAction")
+		io.WriteString(w, `<Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Body><msgT><A>ok</A></msgT></Body></Envelope>`)
+	}))
+	defer s.Close()
+
+	c := NewClient(s.URL, WithNamespace("urn:test"), WithEnvelopeBuilder(EnvelopeBuilderFunc(
+		func(w io.Writer, call *Call, env *Envelope) error {
+			io.WriteString(w, "<!-- "+OperationName(call.Action)+" -->")
+			env.Declarations = map[string]string{"vnd": "urn:vendor"}
+			return xml.NewEncoder(w).Encode(env)
+		})))
+	var out struct {
+		Msg msgT `xml:"msgT"`
+	}
+	if err := c.RoundTrip(&msgT{A: "hello"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(body, "<!-- msgT --><soapenv:Envelope ") || !strings.Contains(body, `xmlns:vnd="urn:vendor"`) ||
+		!strings.Contains(body, "<A>hello</A>") {
+		t.Fatalf("unexpected envelope %s", body)
+	}
+	if action != "urn:test/msgT" {
+		t.Fatalf("unexpected SOAPAction %q", action)
+	}
+	if out.Msg.A != "ok" {
+		t.Fatalf("response not decoded: %+v", out)
+	}
+
+	errBuild := errors.New("cannot build")
+	c.EnvelopeBuilder = EnvelopeBuilderFunc(func(io.Writer, *Call, *Envelope) error { return errBuild })
+	body = ""
+	if err := c.RoundTrip(&msgT{}, &out); err != errBuild {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if body != "" {
+		t.Fatal("request sent despite the builder error")
+	}
+}
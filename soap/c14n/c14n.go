@@ -0,0 +1,297 @@
+// Package c14n implements XML canonicalization, the serialization of
+// XML documents to a canonical form that XML digital signatures compute
+// their digests of.
+//
+// Both Canonical XML 1.0 and Exclusive XML Canonicalization 1.0 are
+// supported, with and without comments, for elements and their subtrees
+// as selected by same-document references of signatures:
+//
+//	root, err := c14n.Parse(envelope)
+//	...
+//	body := root.Child(soap.EnvelopeNamespace11, "Body")
+//	b := c14n.Canonicalizer{Exclusive: true}.Canonicalize(body)
+//
+// https://www.w3.org/TR/xml-c14n/ and https://www.w3.org/TR/xml-exc-c14n/
+package c14n
+
+import (
+	"bytes"
+	"encoding/xml"
+	"sort"
+	"strings"
+)
+
+// Canonicalization algorithm URIs.
+const (
+	C14N10                      = "http://www.w3.org/TR/2001/REC-xml-c14n-20010315"
+	C14N10WithComments          = C14N10 + "#WithComments"
+	ExclusiveC14N10             = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	ExclusiveC14N10WithComments = ExclusiveC14N10 + "WithComments"
+)
+
+// Canonicalizer serializes elements to their canonical form.
+type Canonicalizer struct {
+	Exclusive bool // Exclusive canonicalization, rendering only the namespaces used
+	Comments  bool // Keep comments
+
+	// InclusiveNamespaces are the prefixes whose namespaces are rendered
+	// as by inclusive canonicalization, in exclusive canonicalization;
+	// "#default" is the default namespace.
+	InclusiveNamespaces []string
+
+	// Omit is an element left out with its subtree, such as the
+	// signature of the enveloped signature transform.
+	Omit *Element
+}
+
+// Algorithm returns the Canonicalizer of the algorithm uri, and whether
+// it is supported.
+func Algorithm(uri string) (Canonicalizer, bool) {
+	switch uri {
+	case C14N10:
+		return Canonicalizer{}, true
+	case C14N10WithComments:
+		return Canonicalizer{Comments: true}, true
+	case ExclusiveC14N10:
+		return Canonicalizer{Exclusive: true}, true
+	case ExclusiveC14N10WithComments:
+		return Canonicalizer{Exclusive: true, Comments: true}, true
+	}
+	return Canonicalizer{}, false
+}
+
+// Canonicalize returns the canonical form of e and its subtree.
+func (c Canonicalizer) Canonicalize(e *Element) []byte {
+	w := &writer{c: c}
+	for _, p := range c.InclusiveNamespaces {
+		if p == "#default" {
+			p = ""
+		}
+		w.inclusive = append(w.inclusive, p)
+	}
+	if e != c.Omit {
+		w.element(e, map[string]string{"": ""}, true)
+	}
+	return w.buf.Bytes()
+}
+
+// CanonicalizeContent returns the canonical form of the content of e,
+// its character data and child elements, each element canonicalized as
+// the apex of a subtree. With inclusive canonicalization, the content
+// thus carries the namespace declarations it needs, so it can be moved
+// out of its document, e.g. to be encrypted.
+func (c Canonicalizer) CanonicalizeContent(e *Element) []byte {
+	w := &writer{c: c}
+	for _, p := range c.InclusiveNamespaces {
+		if p == "#default" {
+			p = ""
+		}
+		w.inclusive = append(w.inclusive, p)
+	}
+	w.content(e, map[string]string{"": ""}, true)
+	return w.buf.Bytes()
+}
+
+// Canonicalize parses the document b and returns the canonical form of
+// its root element with the algorithm uri. Content outside of the root
+// element is left out.
+func Canonicalize(b []byte, uri string) ([]byte, error) {
+	c, ok := Algorithm(uri)
+	if !ok {
+		return nil, &UnsupportedAlgorithmError{URI: uri}
+	}
+	root, err := Parse(b)
+	if err != nil {
+		return nil, err
+	}
+	return c.Canonicalize(root), nil
+}
+
+// UnsupportedAlgorithmError is returned for unknown canonicalization
+// algorithms.
+type UnsupportedAlgorithmError struct {
+	URI string
+}
+
+func (e *UnsupportedAlgorithmError) Error() string {
+	return "c14n: unsupported algorithm " + e.URI
+}
+
+type writer struct {
+	c         Canonicalizer
+	buf       bytes.Buffer
+	inclusive []string
+}
+
+type nsDecl struct{ prefix, uri string }
+
+type canonicalAttr struct {
+	space string
+	attr  xml.Attr
+}
+
+// element writes e, given the namespaces rendered by its output
+// ancestors. The apex of the canonicalized subtree inherits the xml:
+// attributes of its ancestors in inclusive canonicalization.
+func (w *writer) element(e *Element, rendered map[string]string, apex bool) {
+	var decls []nsDecl
+	for _, p := range w.prefixes(e) {
+		if p == "xml" || containsDecl(decls, p) {
+			continue
+		}
+		uri, ok := e.Lookup(p)
+		if !ok || p != "" && uri == "" {
+			continue
+		}
+		if have, ok := rendered[p]; ok && have == uri {
+			continue
+		}
+		decls = append(decls, nsDecl{p, uri})
+	}
+	if len(decls) > 0 {
+		scope := make(map[string]string, len(rendered)+len(decls))
+		for p, uri := range rendered {
+			scope[p] = uri
+		}
+		for _, d := range decls {
+			scope[d.prefix] = d.uri
+		}
+		rendered = scope
+	}
+	sort.Slice(decls, func(i, j int) bool { return decls[i].prefix < decls[j].prefix })
+
+	attrs := make([]canonicalAttr, 0, len(e.attr))
+	for _, a := range e.attr {
+		ca := canonicalAttr{attr: a}
+		if a.Name.Space != "" {
+			ca.space, _ = e.Lookup(a.Name.Space)
+		}
+		attrs = append(attrs, ca)
+	}
+	if apex && !w.c.Exclusive {
+		attrs = append(attrs, inheritedXMLAttrs(e)...)
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		if attrs[i].space != attrs[j].space {
+			return attrs[i].space < attrs[j].space
+		}
+		return attrs[i].attr.Name.Local < attrs[j].attr.Name.Local
+	})
+
+	w.buf.WriteByte('<')
+	w.buf.WriteString(e.QName())
+	for _, d := range decls {
+		if d.prefix == "" {
+			w.buf.WriteString(` xmlns="`)
+		} else {
+			w.buf.WriteString(` xmlns:` + d.prefix + `="`)
+		}
+		attrEscaper.WriteString(&w.buf, d.uri)
+		w.buf.WriteByte('"')
+	}
+	for _, a := range attrs {
+		w.buf.WriteString(" " + qname(a.attr.Name) + `="`)
+		attrEscaper.WriteString(&w.buf, a.attr.Value)
+		w.buf.WriteByte('"')
+	}
+	w.buf.WriteByte('>')
+	w.content(e, rendered, false)
+	w.buf.WriteString("</" + e.QName() + ">")
+}
+
+// content writes the children of e; apex tells whether child elements
+// are the apexes of subtrees.
+func (w *writer) content(e *Element, rendered map[string]string, apex bool) {
+	for _, child := range e.children {
+		switch child := child.(type) {
+		case *Element:
+			if child != w.c.Omit {
+				w.element(child, rendered, apex)
+			}
+		case xml.CharData:
+			textEscaper.WriteString(&w.buf, string(child))
+		case xml.Comment:
+			if w.c.Comments {
+				w.buf.WriteString("<!--")
+				w.buf.Write(child)
+				w.buf.WriteString("-->")
+			}
+		case xml.ProcInst:
+			w.buf.WriteString("<?" + child.Target)
+			if len(child.Inst) > 0 {
+				w.buf.WriteByte(' ')
+				w.buf.Write(child.Inst)
+			}
+			w.buf.WriteString("?>")
+		}
+	}
+}
+
+// prefixes returns the prefixes whose namespaces may be rendered on e:
+// all in scope for inclusive canonicalization, and those visibly used by
+// the element and its attributes, or listed as inclusive, for exclusive
+// canonicalization.
+func (w *writer) prefixes(e *Element) []string {
+	if !w.c.Exclusive {
+		ps := []string{""}
+		for n := e; n != nil; n = n.parent {
+			for p := range n.ns {
+				ps = append(ps, p)
+			}
+		}
+		return ps
+	}
+	ps := []string{e.name.Space}
+	for _, a := range e.attr {
+		if a.Name.Space != "" {
+			ps = append(ps, a.Name.Space)
+		}
+	}
+	return append(ps, w.inclusive...)
+}
+
+// inheritedXMLAttrs returns the xml: attributes of the ancestors of e
+// that e does not override.
+func inheritedXMLAttrs(e *Element) []canonicalAttr {
+	var attrs []canonicalAttr
+	for n := e.parent; n != nil; n = n.parent {
+		for _, a := range n.attr {
+			if a.Name.Space == "xml" && !hasAttr(e, a.Name) && !hasCanonicalAttr(attrs, a.Name) {
+				attrs = append(attrs, canonicalAttr{space: XMLNamespace, attr: a})
+			}
+		}
+	}
+	return attrs
+}
+
+func hasAttr(e *Element, name xml.Name) bool {
+	for _, a := range e.attr {
+		if a.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasCanonicalAttr(attrs []canonicalAttr, name xml.Name) bool {
+	for _, a := range attrs {
+		if a.attr.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func containsDecl(decls []nsDecl, prefix string) bool {
+	for _, d := range decls {
+		if d.prefix == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
@@ -0,0 +1,144 @@
+package c14n
+
+import (
+	"errors"
+	"testing"
+)
+
+// https://www.w3.org/TR/xml-exc-c14n/#sec-Enveloping
+const (
+	enveloping1 = `<n0:local xmlns:n0="foo:bar" xmlns:n3="ftp://example.org"><n1:elem2 xmlns:n1="http://example.net" xml:lang="en">
+    <n3:stuff xmlns:n3="ftp://example.org"/>
+  </n1:elem2></n0:local>`
+	enveloping2 = `<n2:pdu xmlns:n1="http://example.com" xmlns:n2="http://foo.example" xml:lang="fr" xml:space="retain"><n1:elem2 xmlns:n1="http://example.net" xml:lang="en">
+    <n3:stuff xmlns:n3="ftp://example.org"/>
+  </n1:elem2></n2:pdu>`
+)
+
+func TestCanonicalizer(t *testing.T) {
+	cases := []struct {
+		doc  string
+		path []string // Local names of the canonicalized element
+		omit string   // Local name of a child of the root to omit
+		c    Canonicalizer
+		want string
+	}{
+		{
+			doc:  enveloping1,
+			path: []string{"elem2"},
+			want: `<n1:elem2 xmlns:n0="foo:bar" xmlns:n1="http://example.net" xmlns:n3="ftp://example.org" xml:lang="en">
+    <n3:stuff></n3:stuff>
+  </n1:elem2>`,
+		},
+		{
+			doc:  enveloping2,
+			path: []string{"elem2"},
+			want: `<n1:elem2 xmlns:n1="http://example.net" xmlns:n2="http://foo.example" xml:lang="en" xml:space="retain">
+    <n3:stuff xmlns:n3="ftp://example.org"></n3:stuff>
+  </n1:elem2>`,
+		},
+		{
+			doc:  enveloping1,
+			path: []string{"elem2"},
+			c:    Canonicalizer{Exclusive: true},
+			want: `<n1:elem2 xmlns:n1="http://example.net" xml:lang="en">
+    <n3:stuff xmlns:n3="ftp://example.org"></n3:stuff>
+  </n1:elem2>`,
+		},
+		{
+			doc:  enveloping2,
+			path: []string{"elem2"},
+			c:    Canonicalizer{Exclusive: true},
+			want: `<n1:elem2 xmlns:n1="http://example.net" xml:lang="en">
+    <n3:stuff xmlns:n3="ftp://example.org"></n3:stuff>
+  </n1:elem2>`,
+		},
+		{
+			doc:  `<a xmlns="urn:a" xmlns:b="urn:b" z="1" b:y="2" a="3"><b:c>x &amp; y &lt; &#x3E;&#13;</b:c><d xmlns=""/></a>`,
+			c:    Canonicalizer{Exclusive: true},
+			want: `<a xmlns="urn:a" xmlns:b="urn:b" a="3" z="1" b:y="2"><b:c>x &amp; y &lt; &gt;&#xD;</b:c><d xmlns=""></d></a>`,
+		},
+		{
+			doc:  `<a xmlns="urn:a"><b xmlns="urn:a"><c xmlns=""/></b></a>`,
+			want: `<a xmlns="urn:a"><b><c xmlns=""></c></b></a>`,
+		},
+		{
+			doc:  `<a xmlns:b="urn:b" xmlns:c="urn:c"><e attr="&quot;&#9;&#10;"><!-- comment --><?pi data?></e></a>`,
+			path: []string{"e"},
+			c:    Canonicalizer{Exclusive: true, InclusiveNamespaces: []string{"c", "#default"}},
+			want: `<e xmlns:c="urn:c" attr="&quot;&#x9;&#xA;"><?pi data?></e>`,
+		},
+		{
+			doc:  `<a><!-- comment --><b/></a>`,
+			c:    Canonicalizer{Comments: true},
+			want: `<a><!-- comment --><b></b></a>`,
+		},
+		{
+			doc:  `<a xmlns:ds="urn:ds">x<ds:Signature><ds:Value/></ds:Signature>y</a>`,
+			omit: "Signature",
+			c:    Canonicalizer{Exclusive: true},
+			want: `<a>xy</a>`,
+		},
+	}
+	for i, tc := range cases {
+		root, err := Parse([]byte(tc.doc))
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		e := root
+		for _, local := range tc.path {
+			for _, c := range e.Elements() {
+				if c.Name().Local == local {
+					e = c
+				}
+			}
+		}
+		if tc.omit != "" {
+			tc.c.Omit = root.Child("urn:ds", tc.omit)
+		}
+		if have := string(tc.c.Canonicalize(e)); have != tc.want {
+			t.Errorf("test %d:\nwant %s\nhave %s", i, tc.want, have)
+		}
+	}
+}
+
+func TestCanonicalizeContent(t *testing.T) {
+	root, err := Parse([]byte(`<s:E xmlns:s="urn:s" xmlns="urn:d"><s:B> <m xml:lang="en">1</m><n/> </s:B></s:E>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := root.Child("urn:s", "B")
+	want := ` <m xmlns="urn:d" xmlns:s="urn:s" xml:lang="en">1</m><n xmlns="urn:d" xmlns:s="urn:s"></n> `
+	if have := string(Canonicalizer{}.CanonicalizeContent(body)); have != want {
+		t.Errorf("want %s\nhave %s", want, have)
+	}
+	want = ` <m xmlns="urn:d" xml:lang="en">1</m><n xmlns="urn:d"></n> `
+	if have := string(Canonicalizer{Exclusive: true}.CanonicalizeContent(body)); have != want {
+		t.Errorf("want %s\nhave %s", want, have)
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	cases := []struct {
+		uri  string
+		want string
+	}{
+		{C14N10, `<a xmlns:b="urn:b"><c>1</c></a>`},
+		{C14N10WithComments, `<a xmlns:b="urn:b"><!--x--><c>1</c></a>`},
+		{ExclusiveC14N10, `<a><c>1</c></a>`},
+		{ExclusiveC14N10WithComments, `<a><!--x--><c>1</c></a>`},
+	}
+	for _, tc := range cases {
+		have, err := Canonicalize([]byte(`<?xml version="1.0"?><a xmlns:b="urn:b"><!--x--><c>1</c></a>`), tc.uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(have) != tc.want {
+			t.Errorf("%s:\nwant %s\nhave %s", tc.uri, tc.want, have)
+		}
+	}
+	var ue *UnsupportedAlgorithmError
+	if _, err := Canonicalize([]byte(`<a/>`), "urn:unknown"); !errors.As(err, &ue) {
+		t.Errorf("want unsupported algorithm error, have %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+package c14n
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// XMLNamespace is the namespace bound to the xml prefix.
+const XMLNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// Element is an element of a parsed document. Unlike encoding/xml, it
+// keeps the prefixes and namespace declarations the document was
+// written with, as canonicalization needs them.
+type Element struct {
+	parent   *Element
+	name     xml.Name          // Space is the prefix
+	ns       map[string]string // Namespaces declared on the element, by prefix
+	attr     []xml.Attr        // Other attributes, Space is the prefix
+	children []any             // *Element, xml.CharData, xml.Comment or xml.ProcInst
+	offsets  [4]int64          // See Offsets
+}
+
+// Parse parses the XML document b and returns its root element.
+// Documents with a DTD are rejected.
+func Parse(b []byte) (*Element, error) {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	d.CharsetReader = charset.NewReaderLabel
+	doc := &Element{}
+	cur := doc
+	for {
+		offset := d.InputOffset()
+		t, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := t.(type) {
+		case xml.StartElement:
+			if cur == doc && len(doc.children) > 0 {
+				return nil, errors.New("c14n: document has several root elements")
+			}
+			e := &Element{parent: cur, name: t.Name}
+			e.offsets[0], e.offsets[1] = offset, d.InputOffset()
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "xmlns":
+					e.declare(a.Name.Local, a.Value)
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					e.declare("", a.Value)
+				default:
+					e.attr = append(e.attr, a)
+				}
+			}
+			cur.children = append(cur.children, e)
+			cur = e
+		case xml.EndElement:
+			if cur == doc || t.Name != cur.name {
+				return nil, fmt.Errorf("c14n: unexpected end element </%s>", qname(t.Name))
+			}
+			cur.offsets[2], cur.offsets[3] = offset, d.InputOffset()
+			cur = cur.parent
+		case xml.CharData:
+			if cur != doc {
+				cur.children = append(cur.children, t.Copy())
+			}
+		case xml.Comment:
+			if cur != doc {
+				cur.children = append(cur.children, t.Copy())
+			}
+		case xml.ProcInst:
+			if cur != doc {
+				cur.children = append(cur.children, t.Copy())
+			}
+		case xml.Directive:
+			return nil, errors.New("c14n: DTDs are not supported")
+		}
+	}
+	if cur != doc {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if len(doc.children) == 0 {
+		return nil, errors.New("c14n: document has no root element")
+	}
+	root := doc.children[0].(*Element)
+	root.parent = nil
+	return root, nil
+}
+
+func (e *Element) declare(prefix, uri string) {
+	if e.ns == nil {
+		e.ns = make(map[string]string)
+	}
+	e.ns[prefix] = uri
+}
+
+// Lookup returns the namespace bound to prefix in the scope of e, ""
+// for the default namespace.
+func (e *Element) Lookup(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return XMLNamespace, true
+	}
+	for ; e != nil; e = e.parent {
+		if uri, ok := e.ns[prefix]; ok {
+			return uri, true
+		}
+	}
+	return "", prefix == ""
+}
+
+// Name returns the name of the element, with the namespace it is in.
+func (e *Element) Name() xml.Name {
+	uri, _ := e.Lookup(e.name.Space)
+	return xml.Name{Space: uri, Local: e.name.Local}
+}
+
+// QName returns the name of the element as written, such as "soap:Body".
+func (e *Element) QName() string {
+	return qname(e.name)
+}
+
+// Is reports whether the element has the namespace space and name local.
+func (e *Element) Is(space, local string) bool {
+	return e != nil && e.name.Local == local && e.Name().Space == space
+}
+
+// Parent returns the parent element of e, or nil for the root.
+func (e *Element) Parent() *Element {
+	return e.parent
+}
+
+// Elements returns the child elements of e.
+//
+// Like the other accessors, Elements accepts a nil e, so lookups can be
+// chained without checking each step.
+func (e *Element) Elements() []*Element {
+	if e == nil {
+		return nil
+	}
+	var els []*Element
+	for _, c := range e.children {
+		if c, ok := c.(*Element); ok {
+			els = append(els, c)
+		}
+	}
+	return els
+}
+
+// Child returns the first child element of e with the namespace space
+// and name local, or nil.
+func (e *Element) Child(space, local string) *Element {
+	for _, c := range e.Elements() {
+		if c.Is(space, local) {
+			return c
+		}
+	}
+	return nil
+}
+
+// Attr returns the value of the attribute with the namespace space and
+// name local; unprefixed attributes have no namespace.
+func (e *Element) Attr(space, local string) (string, bool) {
+	if e == nil {
+		return "", false
+	}
+	for _, a := range e.attr {
+		if a.Name.Local != local {
+			continue
+		}
+		uri := ""
+		if a.Name.Space != "" {
+			uri, _ = e.Lookup(a.Name.Space)
+		}
+		if uri == space {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// Text returns the character data of e, without surrounding space.
+func (e *Element) Text() string {
+	if e == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, c := range e.children {
+		if c, ok := c.(xml.CharData); ok {
+			b.Write(c)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Offsets returns the byte offsets in the parsed document of the start
+// tag, the content, and the end of the element. The content of empty
+// element tags is at their end. Offsets are only meaningful
+// for UTF-8 documents, as others are read after decoding.
+func (e *Element) Offsets() (start, contentStart, contentEnd, end int64) {
+	if e == nil {
+		return 0, 0, 0, 0
+	}
+	return e.offsets[0], e.offsets[1], e.offsets[2], e.offsets[3]
+}
+
+// Walk calls fn for e and all elements below it, in document order.
+func (e *Element) Walk(fn func(*Element)) {
+	if e == nil {
+		return
+	}
+	fn(e)
+	for _, c := range e.Elements() {
+		c.Walk(fn)
+	}
+}
+
+func qname(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
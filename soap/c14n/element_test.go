@@ -0,0 +1,60 @@
+package c14n
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	root, err := Parse([]byte(`<?xml version="1.0" encoding="ISO-8859-1"?>
+<s:Envelope xmlns:s="urn:s" xmlns:u="urn:u"><s:Body u:Id="b" Id="c"> caf` + "\xe9" + ` <x/></s:Body></s:Envelope>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := root.Child("urn:s", "Body")
+	if !root.Is("urn:s", "Envelope") || body.Parent() != root || body.QName() != "s:Body" {
+		t.Fatalf("unexpected elements %+v", root)
+	}
+	if id, ok := body.Attr("urn:u", "Id"); !ok || id != "b" {
+		t.Errorf("unexpected wsu:Id %q", id)
+	}
+	if id, ok := body.Attr("", "Id"); !ok || id != "c" {
+		t.Errorf("unexpected Id %q", id)
+	}
+	if text := body.Text(); text != "café" {
+		t.Errorf("unexpected text %q", text)
+	}
+	if c := root.Child("urn:s", "Header").Child("urn:s", "Security"); c != nil {
+		t.Errorf("unexpected child %+v", c)
+	}
+	var names []string
+	root.Walk(func(e *Element) { names = append(names, e.Name().Local) })
+	if have := strings.Join(names, ","); have != "Envelope,Body,x" {
+		t.Errorf("unexpected walk %s", have)
+	}
+
+	doc := `<a><b x="1">text</b><c/></a>`
+	root, err = Parse([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, c := root.Elements()[0], root.Elements()[1]
+	if start, cs, ce, end := b.Offsets(); doc[start:cs] != `<b x="1">` || doc[cs:ce] != "text" || doc[ce:end] != "</b>" {
+		t.Errorf("unexpected offsets %d %d %d %d", start, cs, ce, end)
+	}
+	if start, cs, ce, end := c.Offsets(); doc[start:end] != "<c/>" || cs != end || ce != end {
+		t.Errorf("unexpected offsets %d %d %d %d", start, cs, ce, end)
+	}
+
+	for _, doc := range []string{
+		``,
+		`<a>`,
+		`<a></b>`,
+		`<a/><b/>`,
+		`<!DOCTYPE a [<!ENTITY x "y">]><a/>`,
+	} {
+		if _, err := Parse([]byte(doc)); err == nil {
+			t.Errorf("%q: want error", doc)
+		}
+	}
+}
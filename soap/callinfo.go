@@ -0,0 +1,67 @@
+package soap
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// CallInfo is the HTTP metadata of a round trip, such as rate limit or
+// request ID headers of the response:
+//
+//	var info soap.CallInfo
+//	err := cli.RoundTripWithActionContext(soap.ContextWithCallInfo(ctx, &info), "Echo", in, out)
+//	log.Println(info.Header.Get("X-Request-Id"), info.Duration)
+//
+// Calls that are retried, e.g. by failover, report their last attempt.
+type CallInfo struct {
+	URL        string      // Final URL of the request, after redirects
+	StatusCode int         // HTTP status code of the response
+	Status     string      // HTTP status line of the response
+	Header     http.Header // Response headers
+	Trailer    http.Header // Response trailers, set once the body is read
+
+	CorrelationID string // Correlation ID of the call, set by the CorrelationID middleware
+
+	Start         time.Time     // When the request was sent
+	TimeToHeaders time.Duration // Time until the response headers were received
+	Duration      time.Duration // Time until the response was decoded
+}
+
+// ContextWithCallInfo returns a copy of ctx that makes the calls made
+// with it, through the client's *Context methods, fill in info. The
+// same info must not be used by concurrent calls.
+func ContextWithCallInfo(ctx context.Context, info *CallInfo) context.Context {
+	return context.WithValue(ctx, callInfoKey, info)
+}
+
+// CallInfoFromContext returns the CallInfo stored in ctx by
+// ContextWithCallInfo, or nil.
+func CallInfoFromContext(ctx context.Context) *CallInfo {
+	info, _ := ctx.Value(callInfoKey).(*CallInfo)
+	return info
+}
+
+// setResponse records the response resp of the request r sent at start.
+func (info *CallInfo) setResponse(r *http.Request, resp *http.Response, start time.Time) {
+	*info = CallInfo{
+		StatusCode:    resp.StatusCode,
+		Status:        resp.Status,
+		Header:        resp.Header,
+		Start:         start,
+		TimeToHeaders: time.Since(start),
+	}
+	if resp.Request != nil {
+		r = resp.Request
+	}
+	info.URL = r.URL.String()
+}
+
+// finish records the trailers of resp, reading what is left of its body
+// after the envelope so they arrive.
+func (info *CallInfo) finish(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	info.Trailer = resp.Trailer
+	info.Duration = time.Since(info.Start)
+}
@@ -0,0 +1,39 @@
+package soap
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCallInfo(t *testing.T) {
+	type msgT struct{ A, B string }
+	type envT struct{ msgT }
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "X-Server-Timing")
+		w.Header().Set("X-Request-Id", "42")
+		io.Copy(w, r.Body)
+		w.Header().Set("X-Server-Timing", "db;dur=3")
+	}))
+	defer s.Close()
+	c := NewClient(s.URL, WithNamespace("urn:test"))
+	var info CallInfo
+	ctx := ContextWithCallInfo(context.Background(), &info)
+	if err := c.RoundTripWithActionContext(ctx, "Echo", &msgT{A: "hello"}, &envT{}); err != nil {
+		t.Fatal(err)
+	}
+	if info.StatusCode != http.StatusOK || info.URL != s.URL {
+		t.Errorf("unexpected status %d of %q", info.StatusCode, info.URL)
+	}
+	if v := info.Header.Get("X-Request-Id"); v != "42" {
+		t.Errorf("unexpected request id %q", v)
+	}
+	if v := info.Trailer.Get("X-Server-Timing"); v != "db;dur=3" {
+		t.Errorf("unexpected trailer %q", v)
+	}
+	if info.Start.IsZero() || info.Duration < info.TimeToHeaders {
+		t.Errorf("unexpected timing %+v", info)
+	}
+}
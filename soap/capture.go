@@ -0,0 +1,60 @@
+package soap
+
+import (
+	"context"
+	"sync"
+)
+
+// CaptureMiddleware returns a Middleware that calls fn with the raw
+// request and response envelopes of every call, once it completes. The
+// call is passed so fn can inspect its action, URL and status; fn must
+// not retain the call after it returns.
+func CaptureMiddleware(fn func(ctx context.Context, call *Call, err error)) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(ctx context.Context, call *Call) error {
+			call.Capture = true
+			err := next(ctx, call)
+			fn(ctx, call, err)
+			return err
+		}
+	}
+}
+
+// EnvelopeRecorder records the raw envelopes of the last call made
+// through its middleware, for debugging interoperability problems.
+//
+//	rec := &soap.EnvelopeRecorder{}
+//	cli.Middleware = append(cli.Middleware, rec.Middleware())
+//	...
+//	log.Printf("sent:\n%s\nreceived:\n%s", rec.LastRequest(), rec.LastResponse())
+//
+// It is safe for concurrent use, although with concurrent calls "last"
+// is the last call to complete.
+type EnvelopeRecorder struct {
+	mu   sync.Mutex
+	req  []byte
+	resp []byte
+}
+
+// Middleware returns the Middleware that records envelopes.
+func (r *EnvelopeRecorder) Middleware() Middleware {
+	return CaptureMiddleware(func(ctx context.Context, call *Call, err error) {
+		r.mu.Lock()
+		r.req, r.resp = call.RequestEnvelope, call.ResponseEnvelope
+		r.mu.Unlock()
+	})
+}
+
+// LastRequest returns the request envelope of the last call.
+func (r *EnvelopeRecorder) LastRequest() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.req
+}
+
+// LastResponse returns the response envelope of the last call.
+func (r *EnvelopeRecorder) LastResponse() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.resp
+}
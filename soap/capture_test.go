@@ -0,0 +1,100 @@
+package soap
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEnvelopeRecorder(t *testing.T) {
+	type msgT struct{ A, B string }
+	type envT struct{ msgT }
+	reply := []byte(`<Envelope><Body><A>reply</A></Body></Envelope>`)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			http.Error(w, "gateway error", http.StatusBadGateway)
+			return
+		}
+		w.Write(reply)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	rec := &EnvelopeRecorder{}
+	var errs []error
+	capture := CaptureMiddleware(func(ctx context.Context, call *Call, err error) {
+		errs = append(errs, err)
+	})
+	c := &Client{URL: s.URL, Middleware: []Middleware{rec.Middleware(), capture}}
+	if err := c.RoundTrip(&msgT{A: "hello"}, &envT{}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(rec.LastRequest(), []byte("<A>hello</A>")) {
+		t.Errorf("unexpected request envelope %s", rec.LastRequest())
+	}
+	if !bytes.Equal(rec.LastResponse(), reply) {
+		t.Errorf("unexpected response envelope %s", rec.LastResponse())
+	}
+	c.URL = s.URL + "/fail"
+	if err := c.RoundTrip(&msgT{A: "hello"}, &envT{}); err == nil {
+		t.Fatal("expected error")
+	}
+	if string(rec.LastResponse()) != "gateway error\n" {
+		t.Errorf("unexpected error body %q", rec.LastResponse())
+	}
+	if len(errs) != 2 || errs[0] != nil || errs[1] == nil {
+		t.Errorf("unexpected capture errors %v", errs)
+	}
+}
+
+func TestEnvelopeFilters(t *testing.T) {
+	type msgT struct{ A string }
+	var received []byte
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		w.Write([]byte(`<Envelope><Body><msgT><A>olleh</A></msgT></Body></Envelope>`))
+	}))
+	defer s.Close()
+	replace := func(old, new string) EnvelopeFilter {
+		return func(env []byte) ([]byte, error) {
+			return bytes.Replace(env, []byte(old), []byte(new), 1), nil
+		}
+	}
+	var call *Call
+	filters := func(next RoundTripFunc) RoundTripFunc {
+		return func(ctx context.Context, c *Call) error {
+			call = c
+			c.Capture = true
+			c.RequestFilters = []EnvelopeFilter{replace("hello", "hallo"), replace("hallo", "hullo")}
+			c.ResponseFilters = []EnvelopeFilter{replace("olleX", "reply"), replace("olleh", "olleX")}
+			return next(ctx, c)
+		}
+	}
+	c := &Client{URL: s.URL, Middleware: []Middleware{filters}}
+	var out struct {
+		Msg msgT `xml:"msgT"`
+	}
+	if err := c.RoundTrip(&msgT{A: "hello"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(received, []byte("<A>hullo</A>")) || !bytes.Equal(call.RequestEnvelope, received) {
+		t.Errorf("unexpected request %s, captured %s", received, call.RequestEnvelope)
+	}
+	if out.Msg.A != "reply" || !bytes.Contains(call.ResponseEnvelope, []byte("<A>reply</A>")) {
+		t.Errorf("unexpected response %+v, captured %s", out, call.ResponseEnvelope)
+	}
+
+	fail := errors.New("filter failed")
+	c.Middleware = append(c.Middleware, func(next RoundTripFunc) RoundTripFunc {
+		return func(ctx context.Context, c *Call) error {
+			c.RequestFilters = append(c.RequestFilters, func([]byte) ([]byte, error) { return nil, fail })
+			return next(ctx, c)
+		}
+	})
+	if err := c.RoundTrip(&msgT{A: "hello"}, &out); !errors.Is(err, fail) {
+		t.Errorf("want filter error, have %v", err)
+	}
+}
@@ -0,0 +1,253 @@
+// Package cassette provides a record-and-replay HTTP transport for
+// testing code that uses soap.Client.
+//
+// In Record mode, requests are sent to the real server and the exchanges
+// are saved to a cassette file. In Replay mode, responses are served
+// from the cassette, matching requests on their SOAP action and body, so
+// tests run offline and deterministically:
+//
+//	tr, err := cassette.New("testdata/echo.json", cassette.Replay)
+//	...
+//	cli := &soap.Client{URL: url, Config: &http.Client{Transport: tr}}
+package cassette
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Mode selects whether the transport records or replays.
+type Mode int
+
+// Transport modes.
+const (
+	Replay         Mode = iota // serve responses from the cassette only
+	Record                     // forward requests and record all exchanges
+	ReplayOrRecord             // replay known requests, record new ones
+)
+
+// Interaction is a recorded request/response exchange.
+type Interaction struct {
+	Action     string      `json:"action"`
+	URL        string      `json:"url"`
+	Request    string      `json:"request"`
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Response   string      `json:"response"`
+}
+
+// Cassette is the content of a cassette file.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// A Matcher reports whether the recorded interaction i matches the
+// request r, whose decompressed body is given.
+type Matcher func(r *http.Request, body []byte, i *Interaction) bool
+
+// DefaultMatcher matches requests with the same SOAP action and the same
+// body, ignoring whitespace between elements.
+func DefaultMatcher(r *http.Request, body []byte, i *Interaction) bool {
+	return Action(r) == i.Action && normalize(body) == normalize([]byte(i.Request))
+}
+
+// Transport is an http.RoundTripper that records or replays exchanges.
+type Transport struct {
+	Path    string            // cassette file
+	Mode    Mode              // record or replay
+	Next    http.RoundTripper // transport used to record (default http.DefaultTransport)
+	Matcher Matcher           // request matching (default DefaultMatcher)
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     map[*Interaction]bool
+}
+
+// New creates a Transport for the cassette at path. The cassette is
+// loaded, if it exists; it is required in Replay mode.
+func New(path string, mode Mode) (*Transport, error) {
+	t := &Transport{Path: path, Mode: mode, used: make(map[*Interaction]bool)}
+	b, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err = json.Unmarshal(b, &t.cassette); err != nil {
+			return nil, fmt.Errorf("cassette %s: %v", path, err)
+		}
+	case os.IsNotExist(err) && mode != Replay:
+	default:
+		return nil, err
+	}
+	if mode == Record {
+		t.cassette.Interactions = nil
+	}
+	return t, nil
+}
+
+// Interactions returns the interactions of the cassette.
+func (t *Transport) Interactions() []*Interaction {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*Interaction(nil), t.cassette.Interactions...)
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, err := requestBody(r)
+	if err != nil {
+		return nil, err
+	}
+	if t.Mode != Record {
+		if i := t.find(r, body); i != nil {
+			return response(r, i), nil
+		}
+		if t.Mode == Replay {
+			return nil, fmt.Errorf("cassette %s: no interaction for action %q", t.Path, Action(r))
+		}
+	}
+	return t.record(r, body)
+}
+
+// find returns the first unused matching interaction, or the last used
+// one so repeated identical requests can be replayed.
+func (t *Transport) find(r *http.Request, body []byte) *Interaction {
+	match := t.Matcher
+	if match == nil {
+		match = DefaultMatcher
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var found *Interaction
+	for _, i := range t.cassette.Interactions {
+		if !match(r, body, i) {
+			continue
+		}
+		if !t.used[i] {
+			t.used[i] = true
+			return i
+		}
+		found = i
+	}
+	return found
+}
+
+func (t *Transport) record(r *http.Request, body []byte) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	resp, err := next.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var rb io.Reader = resp.Body
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		if rb, err = gzip.NewReader(resp.Body); err != nil {
+			return nil, err
+		}
+	}
+	b, err := io.ReadAll(rb)
+	if err != nil {
+		return nil, err
+	}
+	header := resp.Header.Clone()
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+	header.Del("Date")
+	i := &Interaction{
+		Action:     Action(r),
+		URL:        r.URL.String(),
+		Request:    string(body),
+		StatusCode: resp.StatusCode,
+		Header:     header,
+		Response:   string(b),
+	}
+	t.mu.Lock()
+	t.cassette.Interactions = append(t.cassette.Interactions, i)
+	t.used[i] = true
+	err = t.save()
+	t.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return response(r, i), nil
+}
+
+func (t *Transport) save() error {
+	b, err := json.MarshalIndent(&t.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(t.Path, b, 0o644)
+}
+
+// Action returns the SOAP action of r, from the SOAPAction header or the
+// action parameter of a SOAP 1.2 Content-Type.
+func Action(r *http.Request) string {
+	if v := r.Header.Get("SOAPAction"); v != "" {
+		return strings.Trim(v, `"`)
+	}
+	for _, p := range strings.Split(r.Header.Get("Content-Type"), ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+		if ok && strings.EqualFold(k, "action") {
+			return strings.Trim(v, `"`)
+		}
+	}
+	return ""
+}
+
+func requestBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	b, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(b))
+	if !strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		return b, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(zr)
+}
+
+func response(r *http.Request, i *Interaction) *http.Response {
+	header := i.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.StatusCode, http.StatusText(i.StatusCode)),
+		StatusCode:    i.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(i.Response)),
+		ContentLength: int64(len(i.Response)),
+		Request:       r,
+	}
+}
+
+var interElementSpace = regexp.MustCompile(`>\s+<`)
+
+// normalize removes insignificant whitespace from an XML document.
+func normalize(b []byte) string {
+	b = bytes.TrimSpace(b)
+	b = bytes.TrimPrefix(b, []byte(xml.Header[:len(xml.Header)-1]))
+	return interElementSpace.ReplaceAllString(string(bytes.TrimSpace(b)), "><")
+}
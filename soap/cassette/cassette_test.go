@@ -0,0 +1,76 @@
+package cassette
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/YapealAG/wsdl2go/soap"
+)
+
+type msgT struct{ A, B string }
+type envT struct{ msgT }
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "echo.json")
+	calls := 0
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		defer zw.Close()
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		io.Copy(zw, zr)
+	})
+	s := httptest.NewServer(h)
+
+	rec, err := New(path, Record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &soap.Client{URL: s.URL, Namespace: "urn:test", Compress: true, Config: &http.Client{Transport: rec}}
+	for _, v := range []string{"hello", "world"} {
+		if err := c.RoundTripWithAction("Echo", &msgT{A: v}, &envT{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Close()
+	if calls != 2 || len(rec.Interactions()) != 2 {
+		t.Fatalf("want 2 recorded calls, have %d/%d", calls, len(rec.Interactions()))
+	}
+
+	play, err := New(path, Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Config = &http.Client{Transport: play}
+	c.Compress = false
+	for _, v := range []string{"world", "hello", "hello"} {
+		out := &envT{}
+		if err := c.RoundTripWithAction("Echo", &msgT{A: v}, out); err != nil {
+			t.Fatal(err)
+		}
+		if out.A != v {
+			t.Errorf("want %q, have %q", v, out.A)
+		}
+	}
+	if err := c.RoundTripWithAction("Other", &msgT{A: "hello"}, &envT{}); err == nil {
+		t.Fatal("replayed a request with another action")
+	}
+	if err := c.RoundTripWithAction("Echo", &msgT{A: "new"}, &envT{}); err == nil {
+		t.Fatal("replayed an unknown request")
+	}
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	if _, err := New(filepath.Join(t.TempDir(), "missing.json"), Replay); err == nil {
+		t.Fatal("expected error")
+	}
+}
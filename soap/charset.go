@@ -0,0 +1,95 @@
+package soap
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// declarationLen is the number of bytes of a response inspected for its
+// byte order mark and XML declaration.
+const declarationLen = 1024
+
+var (
+	bomUTF8          = []byte("\xef\xbb\xbf")
+	declEncodingExpr = regexp.MustCompile(`encoding\s*=\s*("[^"]*"|'[^']*')`)
+)
+
+// utf8Body returns body as UTF-8, for servers whose responses the XML
+// decoder can't read as they are: a UTF-8 byte order mark is stripped,
+// UTF-16 documents, with or without byte order mark, are transcoded and
+// their XML declaration changed to match, as is that of 8-bit documents
+// that claim to be UTF-16, and documents without an encoding declaration
+// are transcoded from the charset of the Content-Type. .NET services are
+// prone to send all of these.
+func utf8Body(resp *http.Response, body io.Reader) (io.Reader, error) {
+	br := bufio.NewReaderSize(body, declarationLen)
+	head, err := br.Peek(declarationLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(head, bomUTF8):
+		br.Discard(len(bomUTF8))
+		return br, nil
+	case bytes.HasPrefix(head, []byte{0xfe, 0xff}), bytes.HasPrefix(head, []byte{0, '<'}):
+		return utf8Declaration(transform.NewReader(br, unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewDecoder()))
+	case bytes.HasPrefix(head, []byte{0xff, 0xfe}), bytes.HasPrefix(head, []byte{'<', 0}):
+		return utf8Declaration(transform.NewReader(br, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder()))
+	}
+	if m := declEncodingExpr.FindSubmatch(declaration(head)); m != nil {
+		if strings.HasPrefix(strings.ToLower(string(m[1][1:])), "utf-16") {
+			// The document is not UTF-16, whatever it declares.
+			return utf8Declaration(br)
+		}
+		return br, nil
+	}
+	_, params, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch cs := strings.ToLower(params["charset"]); cs {
+	case "", "utf-8", "utf8", "us-ascii":
+		return br, nil
+	default:
+		r, err := charset.NewReaderLabel(cs, br)
+		if err != nil {
+			return br, nil // left to the decoder
+		}
+		return utf8Declaration(r)
+	}
+}
+
+// declaration returns the XML declaration at the start of b, or nil.
+func declaration(b []byte) []byte {
+	if !bytes.HasPrefix(b, []byte("<?xml")) {
+		return nil
+	}
+	if i := bytes.Index(b, []byte("?>")); i >= 0 {
+		return b[:i+2]
+	}
+	return nil
+}
+
+// utf8Declaration returns the document read from r, which is UTF-8,
+// with the encoding of its XML declaration, if any, set to UTF-8, so
+// that the decoder doesn't transcode it again.
+func utf8Declaration(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReaderSize(r, declarationLen)
+	head, err := br.Peek(declarationLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	decl := declaration(head)
+	if decl == nil {
+		return br, nil
+	}
+	br.Discard(len(decl))
+	decl = declEncodingExpr.ReplaceAll(decl, []byte(`encoding="UTF-8"`))
+	return io.MultiReader(bytes.NewReader(decl), br), nil
+}
@@ -0,0 +1,60 @@
+package soap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestRoundTripResponseCharset(t *testing.T) {
+	type msgT struct{ A string }
+	const env = `<Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Body><msgT><A>Zürich</A></msgT></Body></Envelope>`
+	utf16 := func(e unicode.Endianness, bom unicode.BOMPolicy, s string) string {
+		b, err := unicode.UTF16(e, bom).NewEncoder().String(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	latin1, err := charmap.ISO8859_1.NewEncoder().String(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		Name        string
+		ContentType string
+		Body        string
+	}{
+		{"utf-8", "text/xml", env},
+		{"utf-8 bom", "text/xml; charset=utf-8", "\xef\xbb\xbf" + env},
+		{"utf-8 bom declaration", "text/xml", "\xef\xbb\xbf" + `<?xml version="1.0" encoding="utf-8"?>` + env},
+		{"utf-16le bom", "text/xml; charset=utf-16", utf16(unicode.LittleEndian, unicode.UseBOM, `<?xml version="1.0" encoding="utf-16"?>`+env)},
+		{"utf-16be bom", "text/xml", utf16(unicode.BigEndian, unicode.UseBOM, `<?xml version='1.0' encoding='UTF-16'?>`+env)},
+		{"utf-16le", "text/xml", utf16(unicode.LittleEndian, unicode.IgnoreBOM, env)},
+		{"utf-16be", "text/xml", utf16(unicode.BigEndian, unicode.IgnoreBOM, `<?xml version="1.0"?>`+env)},
+		{"utf-8 declared utf-16", "text/xml; charset=utf-8", `<?xml version="1.0" encoding="utf-16"?>` + env},
+		{"latin-1 content type", "text/xml; charset=ISO-8859-1", latin1},
+		{"latin-1 declaration", "text/xml", `<?xml version="1.0" encoding="ISO-8859-1"?>` + latin1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.ContentType)
+				w.Write([]byte(tc.Body))
+			}))
+			defer s.Close()
+			var out struct {
+				Msg msgT `xml:"msgT"`
+			}
+			if err := NewClient(s.URL).RoundTrip(&msgT{}, &out); err != nil {
+				t.Fatal(err)
+			}
+			if out.Msg.A != "Zürich" {
+				t.Fatalf("unexpected value %q", out.Msg.A)
+			}
+		})
+	}
+}
@@ -1,22 +1,45 @@
-// Package soap provides a SOAP HTTP client.
+// Package soap provides a SOAP HTTP client and server.
 package soap
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"reflect"
+	"strings"
+	"time"
 
 	"golang.org/x/net/html/charset"
+	"golang.org/x/oauth2"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/unicode"
 )
 
 // XSINamespace is a link to the XML Schema instance namespace.
 const XSINamespace = "http://www.w3.org/2001/XMLSchema-instance"
 
+// SOAP envelope namespaces.
+const (
+	EnvelopeNamespace11 = "http://schemas.xmlsoap.org/soap/envelope/"
+	EnvelopeNamespace12 = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+// Version is a SOAP protocol version.
+type Version int
+
+// SOAP protocol versions.
+const (
+	SOAP11 Version = iota // SOAP 1.1, the default
+	SOAP12                // SOAP 1.2
+)
+
 var xmlTyperType reflect.Type = reflect.TypeOf((*XMLTyper)(nil)).Elem()
 
 // A RoundTripper executes a request passing the given req as the SOAP
@@ -52,35 +75,97 @@ type Client struct {
 	ThisNamespace          string               // SOAP This-Namespace (tns)
 	TNSAttr                string               // SOAP This-Namespace (tns)
 	XSIAttr                string               // SOAP This-Namespace (xsi)
+	Version                Version              // SOAP version of requests (default SOAP11)
+	Prefixes               Prefixes             // Optional namespace prefixes of the envelope
+	ResolveMultiRefs       bool                 // Inline multiRef href="#id" references in responses (RPC/encoded)
+	CollectNamespaces      bool                 // Declare the namespaces used in request bodies on the envelope
+	Namespaces             map[string]string    // Optional prefix to namespace map, e.g. the generated UsedNamespaces
+	XMLDeclaration         bool                 // Send the XML declaration before the envelope
+	Encoding               string               // Optional charset of requests (default UTF-8)
 	ExcludeActionNamespace bool                 // Include Namespace to SOAP Action header
+	ActionFormat           string               // Optional SOAPAction format of namespace and action (default "%s/%s")
+	ActionQuoted           bool                 // Send the SOAPAction header in double quotes
+	EmptyAction            bool                 // Send an empty SOAPAction header
+	OmitAction             bool                 // Send no SOAPAction header
 	Envelope               string               // Optional SOAP Envelope
+	EnvelopeBuilder        EnvelopeBuilder      // Optional builder of request envelopes
 	Header                 Header               // Optional SOAP Header
 	ContentType            string               // Optional Content-Type (default text/xml)
 	Config                 *http.Client         // Optional HTTP client
+	Transport              Transport            // Optional transport, used instead of Config
+	Redirect               RedirectPolicy       // Optional handling of HTTP redirects (default left to the transport)
 	Pre                    func(*http.Request)  // Optional hook to modify outbound requests
 	Post                   func(*http.Response) // Optional hook to snoop inbound responses
 	Ctx                    context.Context      // Optional variable to allow Context Tracking.
+	Timeout                time.Duration        // Optional limit on the duration of each round trip, see TimeoutError
+	MaxResponseBytes       int64                // Optional limit on the size of response bodies
+	AllowDTD               bool                 // Accept responses containing DTD directives (unsafe)
+	LenientXML             bool                 // Decode responses with xml.Decoder.Strict disabled
+	StrictDecode           bool                 // Fail with *UnknownElementsError on unmapped response elements
+	Validator              Validator            // Optional validation of response messages, e.g. a *wsdl.Schema
+	RequestValidator       Validator            // Optional validation of request messages before they are sent
+	ValidateRequests       bool                 // Check request bodies with Validate before they are encoded
+	Entities               map[string]string    // Optional entity map for the response decoder
+	Compress               bool                 // Gzip requests and accept gzip/deflate responses
+	ExpectContinue         int64                // Optional size above which requests are sent with "Expect: 100-continue"
+	Auth                   Authenticator        // Optional HTTP authentication provider
+	TokenSource            oauth2.TokenSource   // Optional OAuth2 bearer token source
+	Jar                    http.CookieJar       // Optional cookie jar for session cookies
+	Session                Session              // Optional hook to carry session state across calls
+	HTTPHeader             http.Header          // Optional HTTP headers added to each request
+	Middleware             []Middleware         // Optional middleware chain, outermost first
+
+	responseHeader Message // decoded from the SOAP Header of responses, see WithHeaders
 }
 
+// WithHeaders returns a copy of c sending the SOAP Header element in,
+// if not nil, instead of the client's Header, and decoding the Header
+// element of responses onto out, if not nil, as the methods of generated
+// code for operations with soap:header parts do.
+func (c *Client) WithHeaders(in, out Message) *Client {
+	cc := *c
+	if in != nil {
+		cc.Header = in
+	}
+	cc.responseHeader = out
+	return &cc
+}
+
+// ErrResponseTooLarge is returned when a response body exceeds the
+// client's MaxResponseBytes.
+var ErrResponseTooLarge = errors.New("soap: response exceeds MaxResponseBytes")
+
+// ErrDTDNotAllowed is returned when a response contains a DTD directive
+// (<!DOCTYPE ...>, <!ENTITY ...>) and the client does not allow them.
+// Entities declared in a DTD are never expanded by the decoder, but
+// rejecting the document outright avoids surprises with servers or
+// proxies that rely on them.
+var ErrDTDNotAllowed = errors.New("soap: DTD directives are not allowed in responses")
+
 // XMLTyper is an abstract interface for types that can set an XML type.
 type XMLTyper interface {
 	SetXMLType()
 }
 
+// setXMLType calls SetXMLType on the XMLTypers reachable from v,
+// following the plan of each type.
 func setXMLType(v reflect.Value) {
 	if !v.IsValid() {
 		return
 	}
-	switch v.Type().Kind() {
+	p := xmlTypePlanOf(v.Type())
+	if p == nil {
+		return
+	}
+	switch v.Kind() {
 	case reflect.Interface:
 		setXMLType(v.Elem())
 	case reflect.Ptr:
 		if v.IsNil() {
 			break
 		}
-		ok := v.Type().Implements(xmlTyperType)
-		if ok {
-			v.MethodByName("SetXMLType").Call(nil)
+		if p.typer && v.CanInterface() {
+			v.Interface().(XMLTyper).SetXMLType()
 		}
 		setXMLType(v.Elem())
 	case reflect.Slice:
@@ -88,17 +173,18 @@ func setXMLType(v reflect.Value) {
 			setXMLType(v.Index(i))
 		}
 	case reflect.Struct:
-		for i := 0; i < v.NumField(); i++ {
-			if v.Field(i).CanAddr() {
-				setXMLType(v.Field(i).Addr())
+		for _, i := range p.fields {
+			if f := v.Field(i); f.CanAddr() {
+				setXMLType(f.Addr())
 			} else {
-				setXMLType(v.Field(i))
+				setXMLType(f)
 			}
 		}
 	}
 }
 
-func doRoundTrip(c *Client, setHeaders func(*http.Request), in, out Message) error {
+func doRoundTrip(ctx context.Context, c *Client, call *Call, setHeaders func(*http.Request)) error {
+	in := call.In
 	setXMLType(reflect.ValueOf(in))
 	req := &Envelope{
 		EnvelopeAttr: c.Envelope,
@@ -106,126 +192,510 @@ func doRoundTrip(c *Client, setHeaders func(*http.Request), in, out Message) err
 		NSAttr:       c.Namespace,
 		TNSAttr:      c.TNSAttr,
 		XSIAttr:      c.XSIAttr,
-		Header:       c.Header,
+		Header:       call.Header,
 		Body:         in,
+		Prefixes:     c.Prefixes,
 	}
 
 	if req.EnvelopeAttr == "" {
-		req.EnvelopeAttr = "http://schemas.xmlsoap.org/soap/envelope/"
+		req.EnvelopeAttr = EnvelopeNamespace11
+		if c.Version == SOAP12 {
+			req.EnvelopeAttr = EnvelopeNamespace12
+		}
 	}
 	if req.NSAttr == "" {
-		req.NSAttr = c.URL
+		req.NSAttr = call.URL
+	}
+	if c.CollectNamespaces {
+		req.Declarations = c.namespaceDeclarations(req, in)
 	}
 
-	var b bytes.Buffer
-	err := xml.NewEncoder(&b).Encode(req)
+	if c.ValidateRequests {
+		if err := Validate(in); err != nil {
+			return fmt.Errorf("soap: invalid request: %w", err)
+		}
+	}
+	b, err := c.buildEnvelope(call, req)
 	if err != nil {
 		return err
 	}
-	cli := c.Config
-	if cli == nil {
-		cli = http.DefaultClient
+	if c.RequestValidator != nil {
+		if err := validateBody(c.RequestValidator, b.Bytes()); err != nil {
+			putBuffer(b)
+			return fmt.Errorf("soap: invalid request: %w", err)
+		}
+	}
+	for _, filter := range call.RequestFilters {
+		env, err := filter(b.Bytes())
+		if err != nil {
+			putBuffer(b)
+			return err
+		}
+		b.Reset()
+		b.Write(env)
 	}
-	r, err := http.NewRequest("POST", c.URL, &b)
+	if call.Capture {
+		call.RequestEnvelope = append([]byte(nil), b.Bytes()...)
+	}
+	if c.Compress {
+		z, err := gzipBody(b)
+		putBuffer(b)
+		if err != nil {
+			return err
+		}
+		b = z
+	}
+	call.RequestSize = int64(b.Len())
+	reqBody := newPooledBody(b)
+	defer reqBody.release()
+	r, err := http.NewRequestWithContext(ctx, "POST", call.URL, reqBody.reader())
 	if err != nil {
 		return err
 	}
+	// Requests are sent with their length rather than chunked, which
+	// many SOAP servers reject.
+	r.ContentLength = call.RequestSize
+	r.GetBody = func() (io.ReadCloser, error) { return reqBody.reader(), nil }
 	setHeaders(r)
-	if c.Pre != nil {
-		c.Pre(r)
+	if c.Compress {
+		r.Header.Set("Content-Encoding", "gzip")
 	}
+	return c.exchange(ctx, call, r, func(body io.Reader) error {
+		return c.decodeEnvelope(call, body)
+	})
+}
 
-	if c.Ctx != nil {
-		r = r.WithContext(c.Ctx)
+// exchange sends the request r of call with the client's headers,
+// authentication and hooks, and passes the body of successful responses
+// to decode. Other responses fail with an *HTTPError.
+func (c *Client) exchange(ctx context.Context, call *Call, r *http.Request, decode func(io.Reader) error) error {
+	for k, v := range c.HTTPHeader {
+		r.Header[k] = append([]string(nil), v...)
+	}
+	if c.Compress {
+		r.Header.Set("Accept-Encoding", "gzip, deflate")
+	}
+	if c.Auth != nil {
+		if err := c.Auth.Authenticate(r); err != nil {
+			return err
+		}
+	}
+	if c.TokenSource != nil {
+		tok, err := c.TokenSource.Token()
+		if err != nil {
+			return fmt.Errorf("soap: oauth2 token: %v", err)
+		}
+		tok.SetAuthHeader(r)
+	}
+	if c.Jar != nil {
+		injectCookies(c.Jar, r)
+	}
+	if c.Session != nil {
+		c.Session.Inject(r)
+	}
+	setContextHeaders(ctx, r)
+	if c.Pre != nil {
+		c.Pre(r)
 	}
 
-	resp, err := cli.Do(r)
+	start := time.Now()
+	resp, err := c.sendExpect(r)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	if info := CallInfoFromContext(ctx); info != nil {
+		info.setResponse(r, resp, start)
+		defer info.finish(resp)
+	}
+	call.StatusCode = resp.StatusCode
+	resp.Body = &countingReader{ReadCloser: resp.Body, n: &call.ResponseSize}
+	if c.Jar != nil {
+		c.Jar.SetCookies(r.URL, resp.Cookies())
+	}
+	if c.Session != nil {
+		c.Session.Extract(resp)
+	}
 	if c.Post != nil {
 		c.Post(resp)
 	}
+	body, err := responseBody(resp)
+	if err != nil {
+		return err
+	}
+	if body, err = utf8Body(resp, body); err != nil {
+		return err
+	}
+	if c.MaxResponseBytes > 0 {
+		// The limit applies to MTOM responses as a whole, read before
+		// their envelopes are decoded.
+		body = &maxBytesReader{r: body, n: c.MaxResponseBytes}
+	}
 	if resp.StatusCode != http.StatusOK {
 		// read only the first MiB of the body in error case
-		limReader := io.LimitReader(resp.Body, 1024*1024)
-		body, _ := ioutil.ReadAll(limReader)
+		limReader := io.LimitReader(body, 1024*1024)
+		msg, _ := ioutil.ReadAll(limReader)
+		if call.Capture {
+			call.ResponseEnvelope = msg
+		}
 		return &HTTPError{
 			StatusCode: resp.StatusCode,
 			Status:     resp.Status,
-			Msg:        string(body),
+			Msg:        string(msg),
+			Fault:      parseFault(msg),
 		}
 	}
 
+	if body, err = mtomBody(resp, body); err != nil {
+		return err
+	}
+	if body, err = sniffXML(resp, body); err != nil {
+		return err
+	}
+	return decode(body)
+}
+
+// decodeEnvelope decodes the response envelope read from body onto the
+// Out message of call, applying its response filters.
+func (c *Client) decodeEnvelope(call *Call, body io.Reader) error {
+	out := call.Out
+	if out == nil {
+		// Operations without output, such as logouts, may return no
+		// content at all.
+		br := bufio.NewReader(body)
+		if _, err := br.Peek(1); err == io.EOF {
+			return nil
+		}
+		body = br
+	}
 	marshalStructure := struct {
 		XMLName xml.Name
+		Header  Message `xml:"Header"`
 		Body    Message
-	}{Body: out}
+	}{Header: call.ResponseHeader, Body: out}
 
-	decoder := xml.NewDecoder(resp.Body)
-	decoder.CharsetReader = charset.NewReaderLabel
-	return decoder.Decode(&marshalStructure)
+	if len(call.ResponseFilters) > 0 {
+		env, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		for i := len(call.ResponseFilters) - 1; i >= 0; i-- {
+			if env, err = call.ResponseFilters[i](env); err != nil {
+				return err
+			}
+		}
+		body = bytes.NewReader(env)
+	}
+	if call.Capture {
+		var captured bytes.Buffer
+		body = io.TeeReader(body, &captured)
+		defer func() { call.ResponseEnvelope = captured.Bytes() }()
+	}
+	// QName values of attributes, and of elements whose prefix is
+	// declared by an ancestor, are resolved against the whole document.
+	qnames := messagesHaveQNames(call.ResponseHeader, out)
+	if !c.ResolveMultiRefs && !c.StrictDecode && c.Validator == nil && !qnames {
+		return c.decodeResponse(body, &marshalStructure)
+	}
+	doc, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if c.ResolveMultiRefs {
+		if doc, err = resolveMultiRefs(doc); err != nil {
+			return err
+		}
+	}
+	if err = c.decodeResponse(bytes.NewReader(doc), &marshalStructure); err != nil {
+		return err
+	}
+	if qnames {
+		resolveQNames(call.ResponseHeader, doc)
+		resolveQNames(out, doc)
+	}
+	if c.StrictDecode {
+		unknown, err := unknownElements(doc, out)
+		if err != nil {
+			return err
+		}
+		if len(unknown) > 0 {
+			return &UnknownElementsError{Paths: unknown}
+		}
+	}
+	if c.Validator != nil {
+		return validateBody(c.Validator, doc)
+	}
+	return nil
 }
 
-// RoundTrip implements the RoundTripper interface.
-func (c *Client) RoundTrip(in, out Message) error {
-	headerFunc := func(r *http.Request) {
-		if c.UserAgent != "" {
-			r.Header.Add("User-Agent", c.UserAgent)
-		}
-		var actionName, soapAction string
-		if in != nil {
-			soapAction = reflect.TypeOf(in).Elem().Name()
+// encodeEnvelope marshals the request envelope, preceded by the XML
+// declaration if XMLDeclaration or Encoding are set, and transcodes it to
+// Encoding. Characters that Encoding cannot represent are sent as
+// character references.
+func (c *Client) encodeEnvelope(env *Envelope) (*bytes.Buffer, error) {
+	b := getBuffer()
+	if c.XMLDeclaration || c.Encoding != "" {
+		cs := c.Encoding
+		if cs == "" {
+			cs = "UTF-8"
 		}
-		ct := c.ContentType
-		if ct == "" {
-			ct = "text/xml"
+		fmt.Fprintf(b, "<?xml version=\"1.0\" encoding=%q?>\n", cs)
+	}
+	if err := xml.NewEncoder(b).Encode(env); err != nil {
+		putBuffer(b)
+		return nil, err
+	}
+	if c.Encoding == "" {
+		return b, nil
+	}
+	e, err := ianaindex.IANA.Encoding(c.Encoding)
+	if err != nil || e == nil {
+		putBuffer(b)
+		return nil, fmt.Errorf("soap: unsupported encoding %q", c.Encoding)
+	}
+	if e == unicode.UTF8 {
+		return b, nil
+	}
+	p, err := encoding.HTMLEscapeUnsupported(e.NewEncoder()).Bytes(b.Bytes())
+	putBuffer(b)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(p), nil
+}
+
+// decodeResponse decodes the XML document read from r onto v, applying
+// the client's safeguards. DTD directives can only appear in the prolog,
+// which is checked before decoding the root element.
+func (c *Client) decodeResponse(r io.Reader, v any) error {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = !c.LenientXML
+	decoder.Entity = c.Entities
+	for {
+		t, err := decoder.Token()
+		if err != nil {
+			return err
 		}
-		r.Header.Set("Content-Type", ct)
-		if in != nil {
-			if c.ExcludeActionNamespace {
-				actionName = soapAction
-			} else {
-				actionName = fmt.Sprintf("%s/%s", c.Namespace, soapAction)
+		switch t := t.(type) {
+		case xml.Directive:
+			if !c.AllowDTD {
+				return ErrDTDNotAllowed
 			}
-			r.Header.Add("SOAPAction", actionName)
+		case xml.StartElement:
+			return decoder.DecodeElement(v, &t)
 		}
 	}
-	return doRoundTrip(c, headerFunc, in, out)
+}
+
+// maxBytesReader reads from r and fails with ErrResponseTooLarge once
+// more than n bytes have been read, rather than silently truncating
+// the document like io.LimitReader.
+type maxBytesReader struct {
+	r io.Reader
+	n int64
+}
+
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if m.n < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	if int64(len(p)) > m.n+1 {
+		p = p[:m.n+1]
+	}
+	n, err := m.r.Read(p)
+	m.n -= int64(n)
+	if m.n < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	return n, err
+}
+
+// context returns the context for calls made without an explicit one.
+func (c *Client) context() context.Context {
+	if c.Ctx != nil {
+		return c.Ctx
+	}
+	return context.Background()
+}
+
+// countingReader counts the bytes read into n.
+type countingReader struct {
+	io.ReadCloser
+	n *int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.ReadCloser.Read(p)
+	*cr.n += int64(n)
+	return n, err
+}
+
+// RoundTrip implements the RoundTripper interface.
+func (c *Client) RoundTrip(in, out Message) error {
+	return c.RoundTripContext(c.context(), in, out)
+}
+
+// RoundTripContext is like RoundTrip, using ctx for the HTTP request
+// instead of the client's Ctx.
+func (c *Client) RoundTripContext(ctx context.Context, in, out Message) error {
+	var soapAction string
+	if in != nil {
+		soapAction = reflect.TypeOf(in).Elem().Name()
+	}
+	return c.RoundTripWithActionContext(ctx, soapAction, in, out)
 }
 
 // RoundTripWithAction implements the RoundTripper interface for SOAP clients
 // that need to set the SOAPAction header.
+//
+// in may be nil for operations without a body element, such as
+// keepalives or logouts, which are sent with an empty Body and the
+// client's Header, if any; out may be nil for operations whose responses
+// have no content.
 func (c *Client) RoundTripWithAction(soapAction string, in, out Message) error {
+	return c.RoundTripWithActionContext(c.context(), soapAction, in, out)
+}
+
+// RoundTripWithActionContext is like RoundTripWithAction, using ctx for
+// the HTTP request instead of the client's Ctx.
+func (c *Client) RoundTripWithActionContext(ctx context.Context, soapAction string, in, out Message) error {
+	var actionName string
+	if in != nil || soapAction != "" {
+		actionName = c.actionName(soapAction)
+	}
+	return c.roundTripSoap11(ctx, actionName, in, out)
+}
+
+// RoundTripWithSOAPAction is like RoundTripWithAction, but sends
+// soapAction as is, without the client's Namespace or ActionFormat, as
+// for the soapAction values declared in WSDL bindings.
+func (c *Client) RoundTripWithSOAPAction(soapAction string, in, out Message) error {
+	return c.RoundTripWithSOAPActionContext(c.context(), soapAction, in, out)
+}
+
+// RoundTripWithSOAPActionContext is like RoundTripWithSOAPAction, using
+// ctx for the HTTP request instead of the client's Ctx.
+func (c *Client) RoundTripWithSOAPActionContext(ctx context.Context, soapAction string, in, out Message) error {
+	return c.roundTripSoap11(ctx, soapAction, in, out)
+}
+
+// roundTripSoap11 sends in with the SOAPAction actionName, or none if it
+// is empty.
+func (c *Client) roundTripSoap11(ctx context.Context, actionName string, in, out Message) error {
 	headerFunc := func(r *http.Request) {
 		if c.UserAgent != "" {
 			r.Header.Add("User-Agent", c.UserAgent)
 		}
-		var actionName string
-		ct := c.ContentType
-		if ct == "" {
-			ct = "text/xml"
+		if actionName == "" {
+			r.Header.Set("Content-Type", c.contentType(""))
+			return
 		}
-		r.Header.Set("Content-Type", ct)
-		if in != nil {
-			if c.ExcludeActionNamespace {
-				actionName = soapAction
-			} else {
-				actionName = fmt.Sprintf("%s/%s", c.Namespace, soapAction)
+		v := actionName
+		if c.EmptyAction || c.OmitAction {
+			v = ""
+		}
+		if c.Version == SOAP12 {
+			r.Header.Set("Content-Type", c.contentType(v))
+			return
+		}
+		r.Header.Set("Content-Type", c.contentType(""))
+		if !c.OmitAction {
+			if c.ActionQuoted {
+				v = `"` + v + `"`
 			}
-			r.Header.Add("SOAPAction", actionName)
+			r.Header.Set("SOAPAction", v)
 		}
 	}
-	return doRoundTrip(c, headerFunc, in, out)
+	return c.do(ctx, actionName, headerFunc, in, out)
+}
+
+// contentType returns the Content-Type of requests. For SOAP 1.2 the
+// action, if any, is sent as a parameter of the media type.
+func (c *Client) contentType(action string) string {
+	if c.Version != SOAP12 {
+		if c.ContentType != "" {
+			return c.ContentType
+		}
+		if c.Encoding != "" {
+			return "text/xml; charset=" + c.Encoding
+		}
+		return "text/xml"
+	}
+	return soap12ContentType(c.soap12MediaType(), action)
+}
+
+// soap12MediaType returns the SOAP 1.2 Content-Type without the action
+// parameter.
+func (c *Client) soap12MediaType() string {
+	if c.Version == SOAP12 && c.ContentType != "" {
+		return c.ContentType
+	}
+	cs := c.Encoding
+	if cs == "" {
+		cs = "utf-8"
+	}
+	return "application/soap+xml; charset=" + cs
+}
+
+// soap12ContentType adds the action parameter to the media type ct.
+func soap12ContentType(ct, action string) string {
+	if action == "" {
+		return ct
+	}
+	return ct + `; action="` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(action) + `"`
+}
+
+// actionName returns the SOAP action for the given operation, qualified
+// with the client's Namespace using ActionFormat unless
+// ExcludeActionNamespace is set.
+func (c *Client) actionName(action string) string {
+	if c.ExcludeActionNamespace {
+		return action
+	}
+	format := c.ActionFormat
+	if format == "" {
+		format = "%s/%s"
+	}
+	return fmt.Sprintf(format, c.Namespace, action)
 }
 
 // RoundTripSoap12 implements the RoundTripper interface for SOAP 1.2.
+// The action is sent as is, as a parameter of the Content-Type. Unless
+// Version is SOAP12, the envelope still uses the SOAP 1.1 namespace.
 func (c *Client) RoundTripSoap12(action string, in, out Message) error {
+	return c.RoundTripSoap12Context(c.context(), action, in, out)
+}
+
+// RoundTripSoap12Context is like RoundTripSoap12, using ctx for the HTTP
+// request instead of the client's Ctx.
+func (c *Client) RoundTripSoap12Context(ctx context.Context, action string, in, out Message) error {
 	headerFunc := func(r *http.Request) {
-		r.Header.Add("Content-Type", fmt.Sprintf("application/soap+xml; charset=utf-8; action=\"%s\"", action))
+		if c.UserAgent != "" {
+			r.Header.Add("User-Agent", c.UserAgent)
+		}
+		r.Header.Set("Content-Type", soap12ContentType(c.soap12MediaType(), action))
 	}
-	return doRoundTrip(c, headerFunc, in, out)
+	return c.do(ctx, action, headerFunc, in, out)
+}
+
+// do runs the call through the client's middleware chain.
+func (c *Client) do(ctx context.Context, action string, setHeaders func(*http.Request), in, out Message) error {
+	call := &Call{Action: action, URL: c.URL, Header: c.Header, ResponseHeader: c.responseHeader, In: in, Out: out}
+	return c.chain(func(ctx context.Context, call *Call) error {
+		return doRoundTrip(ctx, c, call, setHeaders)
+	})(ctx, call)
+}
+
+// chain wraps rt with the client's timeout and middleware.
+func (c *Client) chain(rt RoundTripFunc) RoundTripFunc {
+	if c.Timeout > 0 {
+		rt = TimeoutMiddleware(c.Timeout)(rt)
+	}
+	for i := len(c.Middleware) - 1; i >= 0; i-- {
+		rt = c.Middleware[i](rt)
+	}
+	return rt
 }
 
 // HTTPError is detailed soap http error
@@ -233,20 +703,32 @@ type HTTPError struct {
 	StatusCode int
 	Status     string
 	Msg        string
+	Fault      *Fault // SOAP fault in Msg, if any
 }
 
 func (e *HTTPError) Error() string {
 	return fmt.Sprintf("%q: %q", e.Status, e.Msg)
 }
 
+// Unwrap returns the SOAP fault of the response, so it can be matched
+// with errors.As.
+func (e *HTTPError) Unwrap() error {
+	if e.Fault == nil {
+		return nil
+	}
+	return e.Fault
+}
+
 // Envelope is a SOAP envelope.
 type Envelope struct {
-	XMLName      xml.Name `xml:"soapenv:Envelope"` // default name
-	EnvelopeAttr string   `xml:"xmlns:soapenv,attr"`
-	NSAttr       string   `xml:"xmlns,attr"` // use default names space
-	TNSAttr      string   `xml:"xmlns:tns,attr,omitempty"`
-	URNAttr      string   `xml:"xmlns:urn,attr,omitempty"`
-	XSIAttr      string   `xml:"xmlns:xsi,attr,omitempty"`
-	Header       Message  `xml:"soapenv:Header"`
-	Body         Message  `xml:"soapenv:Body"`
+	XMLName      xml.Name          `xml:"soapenv:Envelope"` // default name
+	EnvelopeAttr string            `xml:"xmlns:soapenv,attr"`
+	NSAttr       string            `xml:"xmlns,attr"` // use default names space
+	TNSAttr      string            `xml:"xmlns:tns,attr,omitempty"`
+	URNAttr      string            `xml:"xmlns:urn,attr,omitempty"`
+	XSIAttr      string            `xml:"xmlns:xsi,attr,omitempty"`
+	Header       Message           `xml:"soapenv:Header"`
+	Body         Message           `xml:"soapenv:Body"`
+	Prefixes     Prefixes          `xml:"-"` // Optional namespace prefixes
+	Declarations map[string]string `xml:"-"` // Optional extra xmlns declarations by prefix
 }
@@ -1,11 +1,13 @@
 package soap
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -194,6 +196,92 @@ func TestRoundTripWithAction(t *testing.T) {
 	}
 }
 
+func TestRoundTripEmptyBody(t *testing.T) {
+	type sessionT struct {
+		Token string `xml:"urn:session Token"`
+	}
+	var body, action string
+	var resp string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body, action = string(b), r.Header.Get("SOAPAction")
+		io.WriteString(w, resp)
+	}))
+	defer s.Close()
+	c := NewClient(s.URL, WithNamespace("urn:test"), WithSOAPHeader(&sessionT{Token: "t1"}))
+
+	if err := c.RoundTripWithAction("Logout", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := `<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns="urn:test">` +
+		`<soapenv:Header><Token xmlns="urn:session">t1</Token></soapenv:Header><soapenv:Body></soapenv:Body></soapenv:Envelope>`
+	if body != want {
+		t.Fatalf("unexpected envelope\nwant: %s\nhave: %s", want, body)
+	}
+	if action != "urn:test/Logout" {
+		t.Fatalf("unexpected SOAPAction %q", action)
+	}
+
+	resp = `<Envelope><Body/></Envelope>`
+	if err := c.RoundTripWithAction("KeepAlive", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	c.Header = nil
+	if err := c.RoundTrip(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if action != "" || !strings.Contains(body, "<soapenv:Body></soapenv:Body>") {
+		t.Fatalf("unexpected request %q: %s", action, body)
+	}
+}
+
+func TestRoundTripActionFormat(t *testing.T) {
+	type msgT struct{ A, B string }
+	var action []string
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action = r.Header.Values("SOAPAction")
+		io.Copy(w, r.Body)
+	})
+	s := httptest.NewServer(echo)
+	defer s.Close()
+	cases := []struct {
+		Action   string
+		Verbatim bool
+		Opts     []Option
+		Want     []string
+	}{
+		{Want: []string{"urn:test/Echo"}},
+		{Opts: []Option{WithActionQuoted()}, Want: []string{`"urn:test/Echo"`}},
+		{Opts: []Option{WithActionFormat("%s#%s")}, Want: []string{"urn:test#Echo"}},
+		{Opts: []Option{WithActionFormat("%[2]s")}, Want: []string{"Echo"}},
+		{Opts: []Option{WithExcludeActionNamespace(), WithActionQuoted()}, Want: []string{`"Echo"`}},
+		{Opts: []Option{WithEmptyAction()}, Want: []string{""}},
+		{Opts: []Option{WithEmptyAction(), WithActionQuoted()}, Want: []string{`""`}},
+		{Opts: []Option{WithOmitAction()}},
+		{Action: "http://example.com/Echo", Want: []string{"urn:test/http://example.com/Echo"}},
+		{Action: "http://example.com/Echo", Verbatim: true, Want: []string{"http://example.com/Echo"}},
+		{Action: "urn:other:Echo", Verbatim: true, Opts: []Option{WithActionQuoted()}, Want: []string{`"urn:other:Echo"`}},
+		{Action: "Echo", Verbatim: true, Opts: []Option{WithActionFormat("%s#%s")}, Want: []string{"Echo"}},
+	}
+	for i, tc := range cases {
+		c := NewClient(s.URL, append([]Option{WithNamespace("urn:test")}, tc.Opts...)...)
+		if tc.Action == "" {
+			tc.Action = "Echo"
+		}
+		roundTrip := c.RoundTripWithAction
+		if tc.Verbatim {
+			roundTrip = c.RoundTripWithSOAPAction
+		}
+		if err := roundTrip(tc.Action, &msgT{A: "a"}, &struct{ msgT }{}); err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(action, tc.Want) {
+			t.Errorf("test %d: unexpected SOAPAction\nwant: %q\nhave: %q", i, tc.Want, action)
+		}
+	}
+}
+
 func TestRoundTripSoap12(t *testing.T) {
 	type msgT struct{ A, B string }
 	type envT struct{ msgT }
@@ -261,3 +349,165 @@ func TestRoundTripSoap12(t *testing.T) {
 		}
 	}
 }
+
+func TestRoundTripVersion12(t *testing.T) {
+	type msgT struct{ A, B string }
+	var ct string
+	var env []byte
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ct = r.Header.Get("Content-Type")
+		if r.Header.Get("SOAPAction") != "" {
+			http.Error(w, "unexpected SOAPAction", http.StatusBadRequest)
+			return
+		}
+		env, _ = io.ReadAll(r.Body)
+		w.Write(env)
+	})
+	s := httptest.NewServer(echo)
+	defer s.Close()
+	cases := []struct {
+		Opts []Option
+		Want string
+	}{
+		{Want: `application/soap+xml; charset=utf-8; action="urn:test/Echo"`},
+		{Opts: []Option{WithOmitAction()}, Want: `application/soap+xml; charset=utf-8`},
+		{Opts: []Option{WithContentType("application/soap+xml; charset=iso-8859-1")}, Want: `application/soap+xml; charset=iso-8859-1; action="urn:test/Echo"`},
+	}
+	for i, tc := range cases {
+		c := NewClient(s.URL, append([]Option{WithNamespace("urn:test"), WithVersion(SOAP12)}, tc.Opts...)...)
+		out := &struct{ msgT }{}
+		if err := c.RoundTripWithAction("Echo", &msgT{A: "a"}, out); err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if ct != tc.Want {
+			t.Errorf("test %d: unexpected Content-Type\nwant: %q\nhave: %q", i, tc.Want, ct)
+		}
+		if !bytes.Contains(env, []byte(EnvelopeNamespace12)) {
+			t.Errorf("test %d: envelope is not SOAP 1.2: %s", i, env)
+		}
+		if out.A != "a" {
+			t.Errorf("test %d: unexpected response %#v", i, out)
+		}
+	}
+	if v := soap12ContentType("application/soap+xml; charset=utf-8", `urn:"a"`); v != `application/soap+xml; charset=utf-8; action="urn:\"a\""` {
+		t.Errorf("unexpected escaping: %s", v)
+	}
+}
+
+func TestRoundTripEncoding(t *testing.T) {
+	type msgT struct{ A, B string }
+	var ct string
+	var env []byte
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ct = r.Header.Get("Content-Type")
+		env, _ = io.ReadAll(r.Body)
+		w.Write(env)
+	})
+	s := httptest.NewServer(echo)
+	defer s.Close()
+	cases := []struct {
+		Opts   []Option
+		CT     string
+		Prefix string
+		Body   string
+	}{
+		{CT: "text/xml", Prefix: "<soapenv:Envelope", Body: "Grüße €"},
+		{Opts: []Option{WithXMLDeclaration()}, CT: "text/xml", Prefix: `<?xml version="1.0" encoding="UTF-8"?>`, Body: "Grüße €"},
+		{Opts: []Option{WithEncoding("ISO-8859-1")}, CT: "text/xml; charset=ISO-8859-1", Prefix: `<?xml version="1.0" encoding="ISO-8859-1"?>`, Body: "Gr\xfc\xdfe &#8364;"},
+		{Opts: []Option{WithEncoding("ISO-8859-1"), WithVersion(SOAP12)}, CT: `application/soap+xml; charset=ISO-8859-1; action="urn:test/Echo"`, Prefix: `<?xml`, Body: "Gr\xfc\xdfe"},
+	}
+	for i, tc := range cases {
+		c := NewClient(s.URL, append([]Option{WithNamespace("urn:test")}, tc.Opts...)...)
+		out := &struct{ msgT }{}
+		if err := c.RoundTripWithAction("Echo", &msgT{A: "Grüße €"}, out); err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if ct != tc.CT {
+			t.Errorf("test %d: unexpected Content-Type\nwant: %q\nhave: %q", i, tc.CT, ct)
+		}
+		if !bytes.HasPrefix(env, []byte(tc.Prefix)) || !bytes.Contains(env, []byte(tc.Body)) {
+			t.Errorf("test %d: unexpected envelope %q", i, env)
+		}
+		if out.A != "Grüße €" {
+			t.Errorf("test %d: unexpected response %q", i, out.A)
+		}
+	}
+	c := NewClient(s.URL, WithEncoding("bogus"))
+	if err := c.RoundTrip(&msgT{}, &msgT{}); err == nil {
+		t.Error("expected error for unsupported encoding")
+	}
+}
+
+func TestRoundTripMaxResponseBytes(t *testing.T) {
+	type msgT struct{ A, B string }
+	type envT struct{ msgT }
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	})
+	s := httptest.NewServer(echo)
+	defer s.Close()
+	cases := []struct {
+		Max  int64
+		Fail bool
+	}{
+		{Max: 0},
+		{Max: 1 << 20},
+		{Max: 16, Fail: true},
+	}
+	for i, tc := range cases {
+		c := &Client{URL: s.URL, MaxResponseBytes: tc.Max}
+		err := c.RoundTrip(&msgT{A: "hello", B: "world"}, &envT{})
+		if tc.Fail {
+			if err != ErrResponseTooLarge {
+				t.Errorf("test %d: want %v, have %v", i, ErrResponseTooLarge, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+		}
+	}
+}
+
+func TestRoundTripDTD(t *testing.T) {
+	type envT struct{ A string }
+	doc := `<?xml version="1.0"?>
+<!DOCTYPE foo [<!ENTITY xxe SYSTEM "file:///etc/passwd">]>
+<Envelope><Body><A>hello</A></Body></Envelope>`
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, doc)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	c := &Client{URL: s.URL}
+	if err := c.RoundTrip(nil, &envT{}); err != ErrDTDNotAllowed {
+		t.Fatalf("want %v, have %v", ErrDTDNotAllowed, err)
+	}
+	c.AllowDTD = true
+	if err := c.RoundTrip(nil, &envT{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRoundTripEntities(t *testing.T) {
+	type envT struct{ A string }
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<Envelope><Body><A>&copy;</A></Body></Envelope>`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	c := &Client{URL: s.URL}
+	if err := c.RoundTrip(nil, &envT{}); err == nil {
+		t.Fatal("unknown entity accepted in strict mode")
+	}
+	c.Entities = map[string]string{"copy": "(c)"}
+	out := &envT{}
+	if err := c.RoundTrip(nil, out); err != nil {
+		t.Fatal(err)
+	}
+	if out.A != "(c)" {
+		t.Fatalf("want %q, have %q", "(c)", out.A)
+	}
+}
@@ -0,0 +1,61 @@
+package soap
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// gzipBody returns the envelope in b compressed, in a buffer of the
+// pool. b is left to the caller to return to the pool.
+func gzipBody(b *bytes.Buffer) (*bytes.Buffer, error) {
+	z := getBuffer()
+	w := gzipWriterPool.Get().(*gzip.Writer)
+	defer gzipWriterPool.Put(w)
+	w.Reset(z)
+	if _, err := b.WriteTo(w); err != nil {
+		putBuffer(z)
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		putBuffer(z)
+		return nil, err
+	}
+	return z, nil
+}
+
+// responseBody returns the body of resp, decompressed according to its
+// Content-Encoding. Responses already decompressed by net/http are
+// returned as is.
+func responseBody(resp *http.Response) (io.Reader, error) {
+	if resp.Uncompressed {
+		return resp.Body, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(resp.Body)
+	case "deflate":
+		return newDeflateReader(resp.Body)
+	default:
+		return resp.Body, nil
+	}
+}
+
+// newDeflateReader handles both zlib-wrapped deflate (RFC 1950), as the
+// HTTP spec mandates, and raw deflate (RFC 1951) that some servers send.
+func newDeflateReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	h, err := br.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+	if h[0]&0x0f == 8 && (uint16(h[0])<<8|uint16(h[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
@@ -0,0 +1,76 @@
+package soap
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRoundTripCompress(t *testing.T) {
+	type msgT struct{ A, B string }
+	type envT struct{ msgT }
+	encoders := map[string]func(io.Writer) io.WriteCloser{
+		"gzip":    func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+		"raw": func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		},
+	}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "gzip" {
+			http.Error(w, "expected gzip request", http.StatusBadRequest)
+			return
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		enc := r.URL.Query().Get("enc")
+		ce := enc
+		if enc == "raw" {
+			ce = "deflate"
+		}
+		w.Header().Set("Content-Encoding", ce)
+		zw := encoders[enc](w)
+		io.Copy(zw, zr)
+		zw.Close()
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	for enc := range encoders {
+		c := &Client{URL: s.URL + "?enc=" + enc, Compress: true}
+		in := &msgT{A: "hello", B: "world"}
+		out := &envT{}
+		if err := c.RoundTrip(in, out); err != nil {
+			t.Errorf("%s: %v", enc, err)
+			continue
+		}
+		if !reflect.DeepEqual(out.msgT, *in) {
+			t.Errorf("%s: message mismatch\nwant: %#v\nhave: %#v", enc, in, &out.msgT)
+		}
+	}
+}
+
+func TestGzipBody(t *testing.T) {
+	want := []byte("<Envelope></Envelope>")
+	z, err := gzipBody(bytes.NewBuffer(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(z)
+	if err != nil {
+		t.Fatal(err)
+	}
+	have, _ := io.ReadAll(zr)
+	if !bytes.Equal(want, have) {
+		t.Fatalf("want %q, have %q", want, have)
+	}
+}
@@ -0,0 +1,43 @@
+package soap
+
+import (
+	"context"
+	"net/http"
+)
+
+type contextKey int
+
+const (
+	httpHeaderKey contextKey = iota
+	callInfoKey
+	correlationIDKey
+)
+
+// ContextWithHTTPHeader returns a copy of ctx carrying HTTP headers for the
+// calls made with it, through the client's *Context methods. They are
+// set on the request after the client's own headers, so they take
+// precedence, and before the Pre hook. Headers from enclosing contexts
+// are kept unless overridden.
+func ContextWithHTTPHeader(ctx context.Context, h http.Header) context.Context {
+	merged := make(http.Header)
+	for k, v := range HTTPHeaderFromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range h {
+		merged[http.CanonicalHeaderKey(k)] = v
+	}
+	return context.WithValue(ctx, httpHeaderKey, merged)
+}
+
+// HTTPHeaderFromContext returns the HTTP headers stored in ctx by
+// ContextWithHTTPHeader, or nil.
+func HTTPHeaderFromContext(ctx context.Context) http.Header {
+	h, _ := ctx.Value(httpHeaderKey).(http.Header)
+	return h
+}
+
+func setContextHeaders(ctx context.Context, r *http.Request) {
+	for k, v := range HTTPHeaderFromContext(ctx) {
+		r.Header[k] = append([]string(nil), v...)
+	}
+}
@@ -0,0 +1,54 @@
+package soap
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextWithHTTPHeader(t *testing.T) {
+	type msgT struct{ A, B string }
+	type envT struct{ msgT }
+	var tenants []string
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "key" {
+			http.Error(w, "missing api key", http.StatusUnauthorized)
+			return
+		}
+		tenants = append(tenants, r.Header.Get("X-Tenant"))
+		io.Copy(w, r.Body)
+	})
+	s := httptest.NewServer(echo)
+	defer s.Close()
+	c := &Client{URL: s.URL}
+	base := ContextWithHTTPHeader(context.Background(), http.Header{"x-api-key": {"key"}})
+	for _, tenant := range []string{"a", "b"} {
+		ctx := ContextWithHTTPHeader(base, http.Header{"X-Tenant": {tenant}})
+		if err := c.RoundTripWithActionContext(ctx, "Echo", &msgT{A: tenant}, &envT{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(tenants) != 2 || tenants[0] != "a" || tenants[1] != "b" {
+		t.Fatalf("unexpected tenants: %q", tenants)
+	}
+	if err := c.RoundTrip(&msgT{}, &envT{}); err == nil {
+		t.Fatal("headers leaked into calls without context")
+	}
+}
+
+func TestRoundTripContextCanceled(t *testing.T) {
+	type msgT struct{ A, B string }
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	})
+	s := httptest.NewServer(echo)
+	defer s.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := &Client{URL: s.URL}
+	if err := c.RoundTripSoap12Context(ctx, "Echo", &msgT{}, &msgT{}); err == nil {
+		t.Fatal("expected error on canceled context")
+	}
+}
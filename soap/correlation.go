@@ -0,0 +1,180 @@
+package soap
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/YapealAG/wsdl2go/soap/c14n"
+)
+
+// DefaultCorrelationHeader is the HTTP header of correlation IDs, unless
+// configured otherwise.
+const DefaultCorrelationHeader = "X-Correlation-ID"
+
+// ContextWithCorrelationID returns a copy of ctx carrying the correlation
+// ID id, which a CorrelationID middleware sends with the calls made with
+// it, through the client's *Context methods.
+func ContextWithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey, id)
+}
+
+// CorrelationIDFromContext returns the correlation ID stored in ctx by
+// ContextWithCorrelationID, or "".
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey).(string)
+	return id
+}
+
+// CorrelationID propagates the correlation IDs of calls, which tie the
+// logs of the client and the server together. The ID of a call is taken
+// from its context, see ContextWithCorrelationID, or generated, and sent
+// in an HTTP header, a SOAP header element, or both:
+//
+//	cid := &soap.CorrelationID{
+//		SOAPHeader: xml.Name{Space: "urn:acme:tracking", Local: "CorrelationId"},
+//		Generate:   soap.NewCorrelationID,
+//	}
+//	cli.Middleware = append(cli.Middleware, cid.Middleware())
+//
+// The correlation ID of the server, from the ResponseHeader HTTP header
+// or else the SOAPHeader element of the response, is set in the CallInfo
+// of the call, if any; when the server returns none, it is the ID sent.
+type CorrelationID struct {
+	HTTPHeader     string        // HTTP header of the ID (default DefaultCorrelationHeader)
+	NoHTTPHeader   bool          // Send the ID only in the SOAP header
+	SOAPHeader     xml.Name      // Optional SOAP header element of the ID
+	ResponseHeader string        // Optional HTTP header of the server's ID (default HTTPHeader)
+	Generate       func() string // Optional generator of IDs for calls without one (default none, sent without ID)
+}
+
+// Middleware returns the Middleware that propagates correlation IDs.
+func (c *CorrelationID) Middleware() Middleware {
+	header := c.HTTPHeader
+	if header == "" {
+		header = DefaultCorrelationHeader
+	}
+	respHeader := c.ResponseHeader
+	if respHeader == "" {
+		respHeader = header
+	}
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(ctx context.Context, call *Call) error {
+			id := CorrelationIDFromContext(ctx)
+			if id == "" && c.Generate != nil {
+				id = c.Generate()
+			}
+			if id == "" {
+				return next(ctx, call)
+			}
+			if !c.NoHTTPHeader {
+				ctx = ContextWithHTTPHeader(ctx, http.Header{header: {id}})
+			}
+			info := CallInfoFromContext(ctx)
+			filters, capture := call.RequestFilters, call.Capture
+			if c.SOAPHeader.Local != "" {
+				call.RequestFilters = append(filters[:len(filters):len(filters)], func(env []byte) ([]byte, error) {
+					return insertHeaderElement(env, c.SOAPHeader, id)
+				})
+				if info != nil {
+					call.Capture = true
+				}
+			}
+			err := next(ctx, call)
+			call.RequestFilters = filters
+			if info != nil {
+				info.CorrelationID = id
+				if v := info.Header.Get(respHeader); v != "" {
+					info.CorrelationID = v
+				} else if v := responseHeaderElement(call.ResponseEnvelope, c.SOAPHeader); v != "" {
+					info.CorrelationID = v
+				}
+			}
+			if !capture {
+				call.Capture = false
+				call.RequestEnvelope, call.ResponseEnvelope = nil, nil
+			}
+			return err
+		}
+	}
+}
+
+// WithCorrelationID sends the correlation IDs of calls in the HTTP
+// header header, or DefaultCorrelationHeader if empty, generating IDs
+// for calls whose context has none.
+func WithCorrelationID(header string) Option {
+	return func(c *Client) {
+		cid := &CorrelationID{HTTPHeader: header, Generate: NewCorrelationID}
+		WithMiddleware(cid.Middleware())(c)
+	}
+}
+
+// NewCorrelationID returns a random UUID.
+func NewCorrelationID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// insertHeaderElement adds the element name with the text value to the
+// SOAP header of env, adding a header if there is none.
+func insertHeaderElement(env []byte, name xml.Name, value string) ([]byte, error) {
+	if !utf8.Valid(env) {
+		return nil, errors.New("soap: cannot add header elements to non-UTF-8 envelopes")
+	}
+	root, err := c14n.Parse(env)
+	if err != nil {
+		return nil, err
+	}
+	var el bytes.Buffer
+	el.WriteString("<" + name.Local + ` xmlns="`)
+	xml.EscapeText(&el, []byte(name.Space))
+	el.WriteString(`">`)
+	xml.EscapeText(&el, []byte(value))
+	el.WriteString("</" + name.Local + ">")
+	ins := el.String()
+
+	// The element goes at the end of the header, or in a new header
+	// before the body; an empty element tag <Header/> is replaced.
+	var from, to int64
+	space := root.Name().Space
+	if h := root.Child(space, "Header"); h != nil {
+		start, contentStart, contentEnd, end := h.Offsets()
+		from, to = contentEnd, contentEnd
+		if contentStart == end {
+			from, to = start, end
+			ins = "<" + h.QName() + ">" + ins + "</" + h.QName() + ">"
+		}
+	} else if b := root.Child(space, "Body"); b != nil {
+		prefix := strings.TrimSuffix(b.QName(), "Body")
+		from, _, _, _ = b.Offsets()
+		to = from
+		ins = "<" + prefix + "Header>" + ins + "</" + prefix + "Header>"
+	} else {
+		return nil, errors.New("soap: envelope has no body")
+	}
+	out := append([]byte(nil), env[:from]...)
+	out = append(out, ins...)
+	return append(out, env[to:]...), nil
+}
+
+// responseHeaderElement returns the text of the SOAP header element name
+// of the response envelope env, or "".
+func responseHeaderElement(env []byte, name xml.Name) string {
+	if len(env) == 0 || name.Local == "" {
+		return ""
+	}
+	root, err := c14n.Parse(env)
+	if err != nil {
+		return ""
+	}
+	return root.Child(root.Name().Space, "Header").Child(name.Space, name.Local).Text()
+}
@@ -0,0 +1,115 @@
+package soap
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCorrelationID(t *testing.T) {
+	var gotHeader, gotBody string
+	echo := ""
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotHeader, gotBody = r.Header.Get("X-Request-Id"), string(b)
+		if echo != "" {
+			w.Header().Set("X-Server-Id", echo)
+		}
+		io.WriteString(w, `<Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Header><Cid xmlns="urn:track">server-1</Cid></Header><Body><msgT><A>ok</A></msgT></Body></Envelope>`)
+	}))
+	defer s.Close()
+
+	type msgT struct{ A string }
+	var out struct {
+		Msg msgT `xml:"msgT"`
+	}
+	cid := &CorrelationID{HTTPHeader: "X-Request-Id", ResponseHeader: "X-Server-Id"}
+	c := NewClient(s.URL)
+	c.Middleware = append(c.Middleware, cid.Middleware())
+
+	var info CallInfo
+	ctx := ContextWithCallInfo(ContextWithCorrelationID(context.Background(), "abc"), &info)
+	echo = "srv-7"
+	if err := c.RoundTripContext(ctx, &msgT{}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if gotHeader != "abc" || info.CorrelationID != "srv-7" {
+		t.Errorf("sent %q, have %q", gotHeader, info.CorrelationID)
+	}
+	echo = ""
+	if err := c.RoundTripContext(ctx, &msgT{}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if info.CorrelationID != "abc" {
+		t.Errorf("want sent ID without server ID, have %q", info.CorrelationID)
+	}
+
+	// Without ID nor generator, nothing is sent.
+	if err := c.RoundTrip(&msgT{}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if gotHeader != "" {
+		t.Errorf("unexpected ID %q", gotHeader)
+	}
+
+	// SOAP header elements, with the ID of the server from the response header.
+	cid = &CorrelationID{
+		NoHTTPHeader: true,
+		SOAPHeader:   xml.Name{Space: "urn:track", Local: "Cid"},
+		Generate:     func() string { return "gen<1>" },
+	}
+	c = NewClient(s.URL)
+	c.Middleware = append(c.Middleware, cid.Middleware())
+	info = CallInfo{}
+	if err := c.RoundTripContext(ContextWithCallInfo(context.Background(), &info), &msgT{}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(gotBody, `<soapenv:Header><Cid xmlns="urn:track">gen&lt;1&gt;</Cid></soapenv:Header><soapenv:Body>`) {
+		t.Errorf("header element not added:\n%s", gotBody)
+	}
+	if info.CorrelationID != "server-1" {
+		t.Errorf("have server ID %q", info.CorrelationID)
+	}
+	if out.Msg.A != "ok" {
+		t.Errorf("unexpected response %+v", out)
+	}
+}
+
+func TestInsertHeaderElement(t *testing.T) {
+	name := xml.Name{Space: "urn:t", Local: "Id"}
+	cases := []struct {
+		env, want string
+	}{
+		{
+			`<s:Envelope xmlns:s="urn:env"><s:Header><X/></s:Header><s:Body/></s:Envelope>`,
+			`<s:Envelope xmlns:s="urn:env"><s:Header><X/><Id xmlns="urn:t">1</Id></s:Header><s:Body/></s:Envelope>`,
+		},
+		{
+			`<s:Envelope xmlns:s="urn:env"><s:Header/><s:Body/></s:Envelope>`,
+			`<s:Envelope xmlns:s="urn:env"><s:Header><Id xmlns="urn:t">1</Id></s:Header><s:Body/></s:Envelope>`,
+		},
+		{
+			`<Envelope xmlns="urn:env"><Body/></Envelope>`,
+			`<Envelope xmlns="urn:env"><Header><Id xmlns="urn:t">1</Id></Header><Body/></Envelope>`,
+		},
+		{`<Envelope xmlns="urn:env"/>`, ""},
+	}
+	for i, tc := range cases {
+		b, err := insertHeaderElement([]byte(tc.env), name, "1")
+		if tc.want == "" {
+			if err == nil {
+				t.Errorf("test %d: want error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+		} else if string(b) != tc.want {
+			t.Errorf("test %d:\nwant %s\nhave %s", i, tc.want, b)
+		}
+	}
+}
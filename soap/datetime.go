@@ -0,0 +1,435 @@
+package soap
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+// The date and time types of XML schemas are time.Time values of their
+// lexical format, which may or may not have a timezone offset: Z for
+// UTC or an offset such as +01:00. Values without one are in UTC, of
+// NoZone true, and are encoded without one again, whatever the location
+// of their Time.
+
+// timeLayout is the lexical format of a date or time type.
+type timeLayout struct {
+	layout   string // of time.Parse, without the timezone
+	fraction bool   // whether the seconds may have a fraction
+}
+
+// format returns the text of t, of its timezone offset unless noZone.
+func (l timeLayout) format(t time.Time, noZone bool) string {
+	layout := l.layout
+	if l.fraction {
+		layout += ".999999999"
+	}
+	if !noZone {
+		layout += "Z07:00"
+	}
+	return t.Format(layout)
+}
+
+// parse returns the time of s, in UTC and noZone if s has no timezone
+// offset. The time 24:00:00 is the start of the next day.
+func (l timeLayout) parse(s string) (t time.Time, noZone bool, err error) {
+	s = strings.TrimSpace(s)
+	layout := l.layout
+	if n := len(s); strings.HasSuffix(s, "Z") || n > 6 && (s[n-6] == '+' || s[n-6] == '-') && s[n-3] == ':' {
+		layout += "Z07:00"
+	} else {
+		noZone = true
+	}
+	end := false
+	if i := strings.Index(s, "24:00:00"); l.fraction && i >= 0 && (i == 0 || s[i-1] == 'T') {
+		s, end = s[:i]+"00:00:00"+s[i+len("24:00:00"):], true
+	}
+	t, err = time.Parse(layout, s)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, noZone, nil
+}
+
+var (
+	layoutDateTime   = timeLayout{"2006-01-02T15:04:05", true}
+	layoutDate       = timeLayout{"2006-01-02", false}
+	layoutTime       = timeLayout{"15:04:05", true}
+	layoutGYear      = timeLayout{"2006", false}
+	layoutGYearMonth = timeLayout{"2006-01", false}
+	layoutGMonth     = timeLayout{"--01", false}
+	layoutGMonthDay  = timeLayout{"--01-02", false}
+	layoutGDay       = timeLayout{"---02", false}
+)
+
+// DateTime is a value of xsd:dateTime, such as 2024-03-01T14:30:00.5+01:00.
+type DateTime struct {
+	time.Time
+	NoZone bool // whether the text has no timezone offset
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (v DateTime) MarshalText() ([]byte, error) {
+	return []byte(layoutDateTime.format(v.Time, v.NoZone)), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (v *DateTime) UnmarshalText(text []byte) (err error) {
+	v.Time, v.NoZone, err = layoutDateTime.parse(string(text))
+	return err
+}
+
+// MarshalXMLAttr implements the xml.MarshalerAttr interface, omitting
+// the attribute of the zero time.
+func (v DateTime) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if v.IsZero() {
+		return xml.Attr{}, nil
+	}
+	return xml.Attr{Name: name, Value: v.String()}, nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding v as
+// the string of its text.
+func (v DateTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (v *DateTime) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return v.UnmarshalText([]byte(s))
+}
+
+// String returns the text of v.
+func (v DateTime) String() string {
+	return layoutDateTime.format(v.Time, v.NoZone)
+}
+
+// Date is a value of xsd:date, such as 2024-03-01 or 2024-03-01Z.
+type Date struct {
+	time.Time
+	NoZone bool // whether the text has no timezone offset
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (v Date) MarshalText() ([]byte, error) {
+	return []byte(layoutDate.format(v.Time, v.NoZone)), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (v *Date) UnmarshalText(text []byte) (err error) {
+	v.Time, v.NoZone, err = layoutDate.parse(string(text))
+	return err
+}
+
+// MarshalXMLAttr implements the xml.MarshalerAttr interface, omitting
+// the attribute of the zero time.
+func (v Date) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if v.IsZero() {
+		return xml.Attr{}, nil
+	}
+	return xml.Attr{Name: name, Value: v.String()}, nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding v as
+// the string of its text.
+func (v Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (v *Date) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return v.UnmarshalText([]byte(s))
+}
+
+// String returns the text of v.
+func (v Date) String() string {
+	return layoutDate.format(v.Time, v.NoZone)
+}
+
+// Time is a value of xsd:time, such as 14:30:00-05:00, on January 1 of year 0.
+type Time struct {
+	time.Time
+	NoZone bool // whether the text has no timezone offset
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (v Time) MarshalText() ([]byte, error) {
+	return []byte(layoutTime.format(v.Time, v.NoZone)), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (v *Time) UnmarshalText(text []byte) (err error) {
+	v.Time, v.NoZone, err = layoutTime.parse(string(text))
+	return err
+}
+
+// MarshalXMLAttr implements the xml.MarshalerAttr interface, omitting
+// the attribute of the zero time.
+func (v Time) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if v.IsZero() {
+		return xml.Attr{}, nil
+	}
+	return xml.Attr{Name: name, Value: v.String()}, nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding v as
+// the string of its text.
+func (v Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (v *Time) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return v.UnmarshalText([]byte(s))
+}
+
+// String returns the text of v.
+func (v Time) String() string {
+	return layoutTime.format(v.Time, v.NoZone)
+}
+
+// GYear is a value of xsd:gYear, such as 2024, on January 1.
+type GYear struct {
+	time.Time
+	NoZone bool // whether the text has no timezone offset
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (v GYear) MarshalText() ([]byte, error) {
+	return []byte(layoutGYear.format(v.Time, v.NoZone)), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (v *GYear) UnmarshalText(text []byte) (err error) {
+	v.Time, v.NoZone, err = layoutGYear.parse(string(text))
+	return err
+}
+
+// MarshalXMLAttr implements the xml.MarshalerAttr interface, omitting
+// the attribute of the zero time.
+func (v GYear) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if v.IsZero() {
+		return xml.Attr{}, nil
+	}
+	return xml.Attr{Name: name, Value: v.String()}, nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding v as
+// the string of its text.
+func (v GYear) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (v *GYear) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return v.UnmarshalText([]byte(s))
+}
+
+// String returns the text of v.
+func (v GYear) String() string {
+	return layoutGYear.format(v.Time, v.NoZone)
+}
+
+// GYearMonth is a value of xsd:gYearMonth, such as 2024-03, on its first day.
+type GYearMonth struct {
+	time.Time
+	NoZone bool // whether the text has no timezone offset
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (v GYearMonth) MarshalText() ([]byte, error) {
+	return []byte(layoutGYearMonth.format(v.Time, v.NoZone)), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (v *GYearMonth) UnmarshalText(text []byte) (err error) {
+	v.Time, v.NoZone, err = layoutGYearMonth.parse(string(text))
+	return err
+}
+
+// MarshalXMLAttr implements the xml.MarshalerAttr interface, omitting
+// the attribute of the zero time.
+func (v GYearMonth) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if v.IsZero() {
+		return xml.Attr{}, nil
+	}
+	return xml.Attr{Name: name, Value: v.String()}, nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding v as
+// the string of its text.
+func (v GYearMonth) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (v *GYearMonth) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return v.UnmarshalText([]byte(s))
+}
+
+// String returns the text of v.
+func (v GYearMonth) String() string {
+	return layoutGYearMonth.format(v.Time, v.NoZone)
+}
+
+// GMonth is a value of xsd:gMonth, such as --03, on its first day of year 0.
+type GMonth struct {
+	time.Time
+	NoZone bool // whether the text has no timezone offset
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (v GMonth) MarshalText() ([]byte, error) {
+	return []byte(layoutGMonth.format(v.Time, v.NoZone)), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (v *GMonth) UnmarshalText(text []byte) (err error) {
+	v.Time, v.NoZone, err = layoutGMonth.parse(string(text))
+	return err
+}
+
+// MarshalXMLAttr implements the xml.MarshalerAttr interface, omitting
+// the attribute of the zero time.
+func (v GMonth) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if v.IsZero() {
+		return xml.Attr{}, nil
+	}
+	return xml.Attr{Name: name, Value: v.String()}, nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding v as
+// the string of its text.
+func (v GMonth) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (v *GMonth) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return v.UnmarshalText([]byte(s))
+}
+
+// String returns the text of v.
+func (v GMonth) String() string {
+	return layoutGMonth.format(v.Time, v.NoZone)
+}
+
+// GMonthDay is a value of xsd:gMonthDay, such as --03-01, of year 0.
+type GMonthDay struct {
+	time.Time
+	NoZone bool // whether the text has no timezone offset
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (v GMonthDay) MarshalText() ([]byte, error) {
+	return []byte(layoutGMonthDay.format(v.Time, v.NoZone)), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (v *GMonthDay) UnmarshalText(text []byte) (err error) {
+	v.Time, v.NoZone, err = layoutGMonthDay.parse(string(text))
+	return err
+}
+
+// MarshalXMLAttr implements the xml.MarshalerAttr interface, omitting
+// the attribute of the zero time.
+func (v GMonthDay) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if v.IsZero() {
+		return xml.Attr{}, nil
+	}
+	return xml.Attr{Name: name, Value: v.String()}, nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding v as
+// the string of its text.
+func (v GMonthDay) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (v *GMonthDay) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return v.UnmarshalText([]byte(s))
+}
+
+// String returns the text of v.
+func (v GMonthDay) String() string {
+	return layoutGMonthDay.format(v.Time, v.NoZone)
+}
+
+// GDay is a value of xsd:gDay, such as ---01, of January of year 0.
+type GDay struct {
+	time.Time
+	NoZone bool // whether the text has no timezone offset
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (v GDay) MarshalText() ([]byte, error) {
+	return []byte(layoutGDay.format(v.Time, v.NoZone)), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (v *GDay) UnmarshalText(text []byte) (err error) {
+	v.Time, v.NoZone, err = layoutGDay.parse(string(text))
+	return err
+}
+
+// MarshalXMLAttr implements the xml.MarshalerAttr interface, omitting
+// the attribute of the zero time.
+func (v GDay) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if v.IsZero() {
+		return xml.Attr{}, nil
+	}
+	return xml.Attr{Name: name, Value: v.String()}, nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding v as
+// the string of its text.
+func (v GDay) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (v *GDay) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return v.UnmarshalText([]byte(s))
+}
+
+// String returns the text of v.
+func (v GDay) String() string {
+	return layoutGDay.format(v.Time, v.NoZone)
+}
@@ -0,0 +1,104 @@
+package soap
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+func TestDateTimeTypes(t *testing.T) {
+	type value interface {
+		encoding.TextMarshaler
+		encoding.TextUnmarshaler
+	}
+	cases := []struct {
+		V    value
+		Text string
+		Want string
+	}{
+		{&DateTime{}, "2024-03-01T14:30:00+01:00", "2024-03-01T14:30:00+01:00"},
+		{&DateTime{}, "2024-03-01T14:30:00.500Z", "2024-03-01T14:30:00.5Z"},
+		{&DateTime{}, " 2024-03-01T14:30:00 ", "2024-03-01T14:30:00"},
+		{&DateTime{}, "2024-02-29T24:00:00-05:00", "2024-03-01T00:00:00-05:00"},
+		{&Date{}, "2024-03-01", "2024-03-01"},
+		{&Date{}, "2024-03-01Z", "2024-03-01Z"},
+		{&Date{}, "2024-03-01-02:00", "2024-03-01-02:00"},
+		{&Time{}, "14:30:00.25", "14:30:00.25"},
+		{&Time{}, "09:00:00+05:30", "09:00:00+05:30"},
+		{&GYear{}, "2024", "2024"},
+		{&GYearMonth{}, "2024-03Z", "2024-03Z"},
+		{&GMonth{}, "--03", "--03"},
+		{&GMonthDay{}, "--02-29", "--02-29"},
+		{&GDay{}, "---31+01:00", "---31+01:00"},
+	}
+	for i, tc := range cases {
+		if err := tc.V.UnmarshalText([]byte(tc.Text)); err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		b, err := tc.V.MarshalText()
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if string(b) != tc.Want {
+			t.Errorf("test %d: want %s, have %s", i, tc.Want, b)
+		}
+	}
+	for _, s := range []string{"2024-13-01", "01.03.2024", "2024-03-01T25:00:00"} {
+		var v DateTime
+		if err := v.UnmarshalText([]byte(s)); err == nil {
+			t.Errorf("%s accepted", s)
+		}
+	}
+
+	var d DateTime
+	d.UnmarshalText([]byte("2024-03-01T14:30:00+01:00"))
+	if want := time.Date(2024, 3, 1, 13, 30, 0, 0, time.UTC); !d.Equal(want) || d.NoZone {
+		t.Errorf("want %v, have %v of NoZone %v", want, d.Time, d.NoZone)
+	}
+}
+
+func TestDateTimeXML(t *testing.T) {
+	type order struct {
+		XMLName xml.Name `xml:"order"`
+		Placed  DateTime `xml:"placed,attr,omitempty"`
+		Shipped Date     `xml:"shipped,attr,omitempty"`
+		Due     *Date    `xml:"due,omitempty"`
+		Slot    *Time    `xml:"slot,omitempty"`
+		Year    GYear    `xml:"year"`
+	}
+	due := Date{Time: time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC), NoZone: true}
+	in := order{
+		Placed: DateTime{Time: time.Date(2024, 3, 1, 14, 30, 0, 0, time.FixedZone("", 3600))},
+		Due:    &due,
+		Year:   GYear{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	b, err := xml.Marshal(&in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<order placed="2024-03-01T14:30:00+01:00"><due>2024-03-08</due><year>2024Z</year></order>`
+	if string(b) != want {
+		t.Fatalf("want %s, have %s", want, b)
+	}
+	var out order
+	if err := xml.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.Placed.Equal(in.Placed.Time) || !out.Shipped.IsZero() || out.Due.String() != "2024-03-08" || out.Slot != nil {
+		t.Fatalf("unexpected %+v", out)
+	}
+
+	j, err := json.Marshal(&in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back order
+	if err := json.Unmarshal(j, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Placed.String() != "2024-03-01T14:30:00+01:00" || back.Due.String() != "2024-03-08" {
+		t.Fatalf("unexpected JSON %s decoded as %+v", j, back)
+	}
+}
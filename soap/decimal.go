@@ -0,0 +1,269 @@
+package soap
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Decimal is a value of xsd:decimal of arbitrary precision, such as
+// 10.50: the integer of its digits, 1050, times ten to the power of
+// minus its scale, 2, so that its fraction digits are kept, trailing
+// zeros included, as they are given.
+//
+// The zero Decimal is 0, but unset: an attribute of it is omitted,
+// unlike one of 0 parsed or made by NewDecimal.
+type Decimal struct {
+	unscaled *big.Int // nil if unset
+	scale    int32
+}
+
+// NewDecimal returns the Decimal of unscaled times ten to the power of
+// -scale, of a copy of unscaled.
+func NewDecimal(unscaled *big.Int, scale int32) Decimal {
+	return Decimal{new(big.Int).Set(unscaled), scale}
+}
+
+// ParseDecimal returns the Decimal of the text s, of an optional sign,
+// digits and an optional fraction, such as -1.23, +100 or .5.
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	digits := strings.TrimLeft(s, "+-")
+	whole, frac, _ := strings.Cut(digits, ".")
+	if len(s)-len(digits) > 1 || whole+frac == "" || strings.Trim(whole+frac, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("soap: invalid decimal %q", s)
+	}
+	unscaled, _ := new(big.Int).SetString(whole+frac, 10)
+	if s[0] == '-' {
+		unscaled.Neg(unscaled)
+	}
+	return Decimal{unscaled, int32(len(frac))}, nil
+}
+
+// Unscaled returns the integer of the digits of d.
+func (d Decimal) Unscaled() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(d.unscaled)
+}
+
+// Scale returns the number of fraction digits of d, or minus that of the
+// zeros following its digits if NewDecimal was given a negative scale.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Rat returns the value of d.
+func (d Decimal) Rat() *big.Rat {
+	r := new(big.Rat).SetInt(d.Unscaled())
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs32(d.scale))), nil)
+	if d.scale < 0 {
+		return r.Mul(r, new(big.Rat).SetInt(pow))
+	}
+	return r.Quo(r, new(big.Rat).SetInt(pow))
+}
+
+// Float64 returns the float64 nearest to d, and whether it is exact.
+func (d Decimal) Float64() (float64, bool) {
+	return d.Rat().Float64()
+}
+
+// Cmp compares d and e, returning -1, 0 or +1 if d is less than, equal
+// to or greater than e, whatever their scales.
+func (d Decimal) Cmp(e Decimal) int {
+	return d.Rat().Cmp(e.Rat())
+}
+
+// Sign returns -1, 0 or +1 if d is negative, zero or positive.
+func (d Decimal) Sign() int {
+	if d.unscaled == nil {
+		return 0
+	}
+	return d.unscaled.Sign()
+}
+
+// String returns the text of d, of the fraction digits of its scale.
+func (d Decimal) String() string {
+	u := d.Unscaled()
+	digits := new(big.Int).Abs(u).String()
+	switch {
+	case d.scale < 0 && u.Sign() != 0:
+		digits += strings.Repeat("0", int(-d.scale))
+	case d.scale > 0:
+		if n := int(d.scale) + 1 - len(digits); n > 0 {
+			digits = strings.Repeat("0", n) + digits
+		}
+		digits = digits[:len(digits)-int(d.scale)] + "." + digits[len(digits)-int(d.scale):]
+	}
+	if u.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (d *Decimal) UnmarshalText(text []byte) error {
+	v, err := ParseDecimal(string(text))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// MarshalXMLAttr implements the xml.MarshalerAttr interface, omitting
+// the attribute of the unset Decimal.
+func (d Decimal) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if d.unscaled == nil {
+		return xml.Attr{}, nil
+	}
+	return xml.Attr{Name: name, Value: d.String()}, nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding d as a
+// number of all its digits.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, of a number
+// or a string of its text.
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	text, err := jsonNumber(b)
+	if err != nil || text == nil {
+		return err
+	}
+	return d.UnmarshalText(text)
+}
+
+// Integer is a value of xsd:integer of arbitrary precision, or of the
+// types restricting it to signs, such as xsd:nonNegativeInteger.
+//
+// The zero Integer is 0, but unset: an attribute of it is omitted,
+// unlike one of 0 parsed or made by NewInteger.
+type Integer struct {
+	i *big.Int // nil if unset
+}
+
+// NewInteger returns the Integer of a copy of x.
+func NewInteger(x *big.Int) Integer {
+	return Integer{new(big.Int).Set(x)}
+}
+
+// ParseInteger returns the Integer of the text s, of decimal digits and
+// an optional sign; leading zeros do not make it octal.
+func ParseInteger(s string) (Integer, error) {
+	s = strings.TrimSpace(s)
+	digits := strings.TrimLeft(s, "+-")
+	if len(s)-len(digits) > 1 || digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Integer{}, fmt.Errorf("soap: invalid integer %q", s)
+	}
+	i, _ := new(big.Int).SetString(digits, 10)
+	if s[0] == '-' {
+		i.Neg(i)
+	}
+	return Integer{i}, nil
+}
+
+// Int returns the value of v.
+func (v Integer) Int() *big.Int {
+	if v.i == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(v.i)
+}
+
+// Int64 returns the int64 of v, or false if v overflows it.
+func (v Integer) Int64() (int64, bool) {
+	i := v.Int()
+	return i.Int64(), i.IsInt64()
+}
+
+// Cmp compares v and w, returning -1, 0 or +1 if v is less than, equal
+// to or greater than w.
+func (v Integer) Cmp(w Integer) int {
+	return v.Int().Cmp(w.Int())
+}
+
+// Sign returns -1, 0 or +1 if v is negative, zero or positive.
+func (v Integer) Sign() int {
+	return v.Int().Sign()
+}
+
+// String returns the decimal text of v.
+func (v Integer) String() string {
+	return v.Int().String()
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (v Integer) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (v *Integer) UnmarshalText(text []byte) error {
+	i, err := ParseInteger(string(text))
+	if err != nil {
+		return err
+	}
+	*v = i
+	return nil
+}
+
+// MarshalXMLAttr implements the xml.MarshalerAttr interface, omitting
+// the attribute of the unset Integer.
+func (v Integer) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if v.i == nil {
+		return xml.Attr{}, nil
+	}
+	return xml.Attr{Name: name, Value: v.String()}, nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding v as a
+// number of all its digits.
+func (v Integer) MarshalJSON() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, of a number
+// or a string of its text.
+func (v *Integer) UnmarshalJSON(b []byte) error {
+	text, err := jsonNumber(b)
+	if err != nil || text == nil {
+		return err
+	}
+	return v.UnmarshalText(text)
+}
+
+// jsonNumber returns the text of the JSON number or string b, or nil if
+// b is null.
+func jsonNumber(b []byte) ([]byte, error) {
+	b = bytes.TrimSpace(b)
+	switch {
+	case string(b) == "null":
+		return nil, nil
+	case len(b) > 0 && b[0] == '"':
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return nil, err
+		}
+		return []byte(s), nil
+	}
+	return b, nil
+}
+
+func abs32(n int32) int64 {
+	if n < 0 {
+		return -int64(n)
+	}
+	return int64(n)
+}
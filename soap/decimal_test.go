@@ -0,0 +1,133 @@
+package soap
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"math/big"
+	"testing"
+)
+
+func TestDecimal(t *testing.T) {
+	cases := []struct {
+		Text     string
+		Unscaled string
+		Scale    int32
+		Str      string
+	}{
+		{"10.50", "1050", 2, "10.50"},
+		{" -0.005 ", "-5", 3, "-0.005"},
+		{"+100", "100", 0, "100"},
+		{".5", "5", 1, "0.5"},
+		{"123456789012345678901234567890.123456789", "123456789012345678901234567890123456789", 9, "123456789012345678901234567890.123456789"},
+		{"007", "7", 0, "7"},
+		{"1e3", "", 0, ""},
+		{"--1", "", 0, ""},
+		{".", "", 0, ""},
+		{"", "", 0, ""},
+	}
+	for i, tc := range cases {
+		d, err := ParseDecimal(tc.Text)
+		if tc.Str == "" {
+			if err == nil {
+				t.Errorf("test %d: %q accepted", i, tc.Text)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if d.Unscaled().String() != tc.Unscaled || d.Scale() != tc.Scale || d.String() != tc.Str {
+			t.Errorf("test %d: want %s of scale %d, %s, have %s of scale %d, %s", i, tc.Unscaled, tc.Scale, tc.Str, d.Unscaled(), d.Scale(), d)
+		}
+	}
+	if d := NewDecimal(big.NewInt(-12), -3); d.String() != "-12000" {
+		t.Errorf("want -12000 of a negative scale, have %s", d)
+	}
+}
+
+func TestDecimalConversion(t *testing.T) {
+	d, _ := ParseDecimal("0.10")
+	if r := d.Rat(); r.Cmp(big.NewRat(1, 10)) != 0 {
+		t.Errorf("want 1/10, have %s", r)
+	}
+	if f, exact := d.Float64(); f != 0.1 || exact {
+		t.Errorf("want 0.1, inexact, have %v, %v", f, exact)
+	}
+	e, _ := ParseDecimal("0.1")
+	if d.Cmp(e) != 0 || d.String() == e.String() {
+		t.Errorf("want equal decimals of different scales, have %s and %s", d, e)
+	}
+	if (Decimal{}).Sign() != 0 || (Decimal{}).String() != "0" {
+		t.Errorf("want zero decimal 0, have %s", Decimal{})
+	}
+}
+
+func TestInteger(t *testing.T) {
+	cases := []struct {
+		Text string
+		Str  string
+	}{
+		{"12345678901234567890123", "12345678901234567890123"},
+		{" -010 ", "-10"},
+		{"+0", "0"},
+		{"0x10", ""},
+		{"1.0", ""},
+		{"-", ""},
+	}
+	for i, tc := range cases {
+		v, err := ParseInteger(tc.Text)
+		if tc.Str == "" {
+			if err == nil {
+				t.Errorf("test %d: %q accepted", i, tc.Text)
+			}
+			continue
+		}
+		if err != nil || v.String() != tc.Str {
+			t.Errorf("test %d: want %s, have %s, %v", i, tc.Str, v, err)
+		}
+	}
+	v, _ := ParseInteger("9223372036854775808")
+	if _, ok := v.Int64(); ok {
+		t.Errorf("%s fits an int64", v)
+	}
+	v, _ = ParseInteger("-42")
+	if n, ok := v.Int64(); n != -42 || !ok {
+		t.Errorf("want -42, have %d, %v", n, ok)
+	}
+}
+
+func TestBigNumbersXML(t *testing.T) {
+	type price struct {
+		XMLName  xml.Name `xml:"price"`
+		Amount   Decimal  `xml:"amount"`
+		Units    Integer  `xml:"units,attr,omitempty"`
+		Discount Decimal  `xml:"discount,attr,omitempty"`
+	}
+	var v price
+	err := xml.Unmarshal([]byte(`<price units="0"><amount> 19.90 </amount></price>`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := xml.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<price units="0"><amount>19.90</amount></price>`; string(b) != want {
+		t.Errorf("want %s, have %s", want, b)
+	}
+	b, err = json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"XMLName":{"Space":"","Local":"price"},"Amount":19.90,"Units":0,"Discount":0}`; string(b) != want {
+		t.Errorf("want %s, have %s", want, b)
+	}
+	var w price
+	if err := json.Unmarshal([]byte(`{"Amount":"19.90","Units":3}`), &w); err != nil {
+		t.Fatal(err)
+	}
+	if w.Amount.String() != "19.90" || w.Units.String() != "3" {
+		t.Errorf("want 19.90 and 3, have %s and %s", w.Amount, w.Units)
+	}
+}
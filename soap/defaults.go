@@ -0,0 +1,17 @@
+package soap
+
+// defaulter is implemented by the generated structs of elements and
+// attributes of default or fixed values.
+type defaulter interface{ ApplyDefaults() }
+
+// ApplyDefaults calls the ApplyDefaults methods of the structs v points
+// to and refers to, such as the input of an operation, which set their
+// omitted fields to the default and fixed values of the schema.
+func ApplyDefaults(v any) {
+	walkValues(v, func(x any, path string) error {
+		if d, ok := x.(defaulter); ok {
+			d.ApplyDefaults()
+		}
+		return nil
+	})
+}
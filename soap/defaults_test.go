@@ -0,0 +1,47 @@
+package soap
+
+import (
+	"testing"
+)
+
+type testLine struct {
+	Unit     *string `xml:"unit,omitempty"`
+	Quantity int     `xml:"quantity,attr,omitempty"`
+}
+
+func (v *testLine) ApplyDefaults() {
+	if v.Unit == nil {
+		x := "pcs"
+		v.Unit = &x
+	}
+	if v.Quantity == 0 {
+		v.Quantity = 1
+	}
+}
+
+type testInvoice struct {
+	Lines    []*testLine `xml:"line"`
+	Currency string      `xml:"currency,attr,omitempty"`
+}
+
+func (v *testInvoice) ApplyDefaults() {
+	if v.Currency == "" {
+		v.Currency = "CHF"
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	kg := "kg"
+	v := &testInvoice{Lines: []*testLine{{}, {Unit: &kg, Quantity: 3}}}
+	ApplyDefaults(v)
+	if v.Currency != "CHF" {
+		t.Errorf("unexpected currency %q", v.Currency)
+	}
+	if l := v.Lines[0]; l.Unit == nil || *l.Unit != "pcs" || l.Quantity != 1 {
+		t.Errorf("defaults not applied to %+v", l)
+	}
+	if l := v.Lines[1]; *l.Unit != "kg" || l.Quantity != 3 {
+		t.Errorf("values replaced in %+v", l)
+	}
+	ApplyDefaults(nil)
+}
@@ -0,0 +1,93 @@
+package soap
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	addressLocationExpr  = regexp.MustCompile(`(<(?:[\w.-]+:)?address\b[^>]*?\blocation\s*=\s*)("[^"]*"|'[^']*')`)
+	documentLocationExpr = regexp.MustCompile(`(\b(?:schemaLocation|location)\s*=\s*)("[^"]*"|'[^']*')`)
+)
+
+// serveDocument writes the WSDL, for ?wsdl requests, or the document of
+// the location of ?xsd=location requests, and reports whether r was
+// such a request.
+func (s *Server) serveDocument(w http.ResponseWriter, r *http.Request) bool {
+	q := r.URL.Query()
+	var doc []byte
+	switch {
+	case s.WSDL != nil && hasQueryKey(q, "wsdl"):
+		doc = s.WSDL
+	case q.Has("xsd"):
+		var ok bool
+		if doc, ok = s.Documents[q.Get("xsd")]; !ok {
+			http.NotFound(w, r)
+			return true
+		}
+	default:
+		return false
+	}
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.Write(s.rewriteLocations(doc, requestURL(r)))
+	return true
+}
+
+// hasQueryKey reports whether q has the key, in any case, as in ?WSDL.
+func hasQueryKey(q url.Values, key string) bool {
+	for k := range q {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestURL returns the URL of the endpoint r was sent to, without
+// query.
+func requestURL(r *http.Request) *url.URL {
+	u := &url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path}
+	if r.TLS != nil {
+		u.Scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		u.Scheme = proto
+	}
+	return u
+}
+
+// rewriteLocations returns doc with the locations of service addresses
+// set to the endpoint, and those of the imported Documents to their URL
+// at the endpoint, so clients discover the service where it is served
+// rather than where it was described.
+func (s *Server) rewriteLocations(doc []byte, endpoint *url.URL) []byte {
+	doc = addressLocationExpr.ReplaceAllFunc(doc, func(m []byte) []byte {
+		sub := addressLocationExpr.FindSubmatch(m)
+		return append(append([]byte{}, sub[1]...), quoteAttr(endpoint.String())...)
+	})
+	if len(s.Documents) == 0 {
+		return doc
+	}
+	return documentLocationExpr.ReplaceAllFunc(doc, func(m []byte) []byte {
+		sub := documentLocationExpr.FindSubmatch(m)
+		loc := string(sub[2][1 : len(sub[2])-1])
+		if _, ok := s.Documents[loc]; !ok {
+			return m
+		}
+		u := *endpoint
+		u.RawQuery = "xsd=" + url.QueryEscape(loc)
+		return append(append([]byte{}, sub[1]...), quoteAttr(u.String())...)
+	})
+}
+
+// quoteAttr returns v as a quoted attribute value.
+func quoteAttr(v string) []byte {
+	var b bytes.Buffer
+	b.WriteByte('"')
+	b.WriteString(strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;").Replace(v))
+	b.WriteByte('"')
+	return b.Bytes()
+}
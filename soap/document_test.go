@@ -0,0 +1,52 @@
+package soap
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServerDocuments(t *testing.T) {
+	srv := NewServer()
+	srv.WSDL = []byte(`<definitions><types><xs:schema><xs:import namespace="urn:t" schemaLocation="types.xsd"/></xs:schema></types>` +
+		`<service><port><soap:address location="http://legacy.example.com/svc.asmx"/></port></service></definitions>`)
+	srv.Documents = map[string][]byte{"types.xsd": []byte(`<schema><include schemaLocation='more types.xsd'/></schema>`)}
+	s := httptest.NewServer(srv)
+	defer s.Close()
+
+	cases := []struct {
+		Query  string
+		Status int
+		Want   []string
+	}{
+		{"?wsdl", http.StatusOK, []string{
+			`<soap:address location="` + s.URL + `/svc"/>`,
+			`schemaLocation="` + s.URL + `/svc?xsd=types.xsd"`,
+		}},
+		{"?WSDL", http.StatusOK, []string{`<definitions>`}},
+		{"?xsd=types.xsd", http.StatusOK, []string{`<include schemaLocation='more types.xsd'/>`}},
+		{"?xsd=other.xsd", http.StatusNotFound, nil},
+		{"", http.StatusMethodNotAllowed, nil},
+	}
+	for _, tc := range cases {
+		resp, err := http.Get(s.URL + "/svc" + tc.Query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tc.Status {
+			t.Fatalf("%q: status %d: %s", tc.Query, resp.StatusCode, b)
+		}
+		for _, want := range tc.Want {
+			if !strings.Contains(string(b), want) {
+				t.Errorf("%q: %s does not contain %s", tc.Query, b, want)
+			}
+		}
+		if tc.Status == http.StatusMethodNotAllowed && resp.Header.Get("Allow") != "GET, POST" {
+			t.Errorf("Allow: %q", resp.Header.Get("Allow"))
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package soap
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dumpTimeFormat is the time prefix of dump files, which sorts in
+// chronological order.
+const dumpTimeFormat = "20060102T150405.000000000Z"
+
+// EnvelopeDumper writes the request and response envelopes of every call
+// to files in a directory, for capturing evidence of interoperability
+// problems, e.g. to escalate them to the vendor of a service:
+//
+//	cli.Middleware = append(cli.Middleware, (&soap.EnvelopeDumper{
+//		Dir:      "/var/tmp/soap",
+//		MaxFiles: 1000,
+//		Redact:   soap.DefaultRedact,
+//	}).Middleware())
+//
+// The files of a call are named after the time it completed, a sequence
+// number and the operation, such as
+// 20240501T120000.000000000Z-000001-GetQuote-request.xml, with
+// -response.xml for the response and -error.txt for the error of failed
+// calls.
+//
+// Errors writing the files don't fail the call; they are passed to
+// OnError, if set.
+type EnvelopeDumper struct {
+	Dir      string              // Directory of the files, created if missing
+	MaxBytes int64               // Optional limit on the size of each file; longer envelopes are truncated
+	MaxFiles int                 // Optional number of files kept in Dir; the oldest are removed
+	Redact   func([]byte) []byte // Optional redaction of envelopes, e.g. DefaultRedact
+	OnError  func(error)         // Optional handler of errors writing the files
+
+	mu  sync.Mutex
+	seq int
+	now func() time.Time
+}
+
+// Middleware returns the Middleware that dumps envelopes.
+func (d *EnvelopeDumper) Middleware() Middleware {
+	return CaptureMiddleware(func(ctx context.Context, call *Call, err error) {
+		if werr := d.dump(call, err); werr != nil && d.OnError != nil {
+			d.OnError(werr)
+		}
+	})
+}
+
+// dump writes the files of call.
+func (d *EnvelopeDumper) dump(call *Call, callErr error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now
+	if d.now != nil {
+		now = d.now
+	}
+	d.seq++
+	op := dumpName(OperationName(call.Action))
+	if op == "" {
+		op = "call"
+	}
+	prefix := fmt.Sprintf("%s-%06d-%s-", now().UTC().Format(dumpTimeFormat), d.seq, op)
+	if err := os.MkdirAll(d.Dir, 0o700); err != nil {
+		return err
+	}
+	files := []dumpFile{
+		{"request.xml", redactEnvelope(d.Redact, call.RequestEnvelope)},
+		{"response.xml", redactEnvelope(d.Redact, call.ResponseEnvelope)},
+	}
+	if callErr != nil {
+		files = append(files, dumpFile{"error.txt", []byte(callErr.Error() + "\n")})
+	}
+	for _, f := range files {
+		if len(f.b) == 0 {
+			continue
+		}
+		b := f.b
+		if d.MaxBytes > 0 && int64(len(b)) > d.MaxBytes {
+			b = b[:d.MaxBytes]
+		}
+		if err := os.WriteFile(filepath.Join(d.Dir, prefix+f.name), b, 0o600); err != nil {
+			return err
+		}
+	}
+	return d.rotate()
+}
+
+type dumpFile struct {
+	name string
+	b    []byte
+}
+
+// rotate removes the oldest dump files beyond MaxFiles.
+func (d *EnvelopeDumper) rotate() error {
+	if d.MaxFiles <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(d.Dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, e := range entries {
+		if n := e.Name(); !e.IsDir() && isDumpFile(n) {
+			names = append(names, n)
+		}
+	}
+	if len(names) <= d.MaxFiles {
+		return nil
+	}
+	sort.Strings(names)
+	for _, n := range names[:len(names)-d.MaxFiles] {
+		if err := os.Remove(filepath.Join(d.Dir, n)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// isDumpFile reports whether name is the name of a dump file, so that
+// rotation leaves other files in the directory alone.
+func isDumpFile(name string) bool {
+	if len(name) <= len(dumpTimeFormat) {
+		return false
+	}
+	if _, err := time.Parse(dumpTimeFormat, name[:len(dumpTimeFormat)]); err != nil {
+		return false
+	}
+	for _, suffix := range []string{"-request.xml", "-response.xml", "-error.txt"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// dumpName returns s with the characters that are not safe in file
+// names replaced by underscores.
+func dumpName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '.', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// WithDumpDir writes the envelopes of all calls of the client to files
+// in dir, keeping the last maxFiles files, or all if maxFiles is 0.
+// Passwords and WS-Security headers are redacted with DefaultRedact.
+func WithDumpDir(dir string, maxFiles int) Option {
+	return func(c *Client) {
+		d := &EnvelopeDumper{Dir: dir, MaxFiles: maxFiles, Redact: DefaultRedact}
+		WithMiddleware(d.Middleware())(c)
+	}
+}
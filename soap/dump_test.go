@@ -0,0 +1,95 @@
+package soap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvelopeDumper(t *testing.T) {
+	fail := false
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`<Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Body><msgT><A>ok</A></msgT></Body></Envelope>`))
+	}))
+	defer s.Close()
+
+	dir := filepath.Join(t.TempDir(), "dumps")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("keep"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	d := &EnvelopeDumper{Dir: dir, MaxBytes: 40, MaxFiles: 3, Redact: RedactElements("A")}
+	d.now = func() time.Time { return now }
+	c := NewClient(s.URL)
+	c.Middleware = append(c.Middleware, d.Middleware())
+
+	type msgT struct{ A string }
+	var out struct {
+		Msg msgT `xml:"msgT"`
+	}
+	if err := c.RoundTrip(&msgT{A: "secret"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "20240501T120000.000000000Z-000001-msgT-response.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != 40 {
+		t.Errorf("response not truncated to MaxBytes: %q", b)
+	}
+	b, err = os.ReadFile(filepath.Join(dir, "20240501T120000.000000000Z-000001-msgT-request.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "secret") {
+		t.Errorf("request not redacted: %q", b)
+	}
+
+	fail = true
+	now = now.Add(time.Second)
+	if err := c.RoundTripWithAction("urn:svc/Get Quote", &msgT{}, &out); err == nil {
+		t.Fatal("want error")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	want := []string{
+		"20240501T120001.000000000Z-000002-Get_Quote-error.txt",
+		"20240501T120001.000000000Z-000002-Get_Quote-request.xml",
+		"20240501T120001.000000000Z-000002-Get_Quote-response.xml",
+		"README",
+	}
+	if strings.Join(names, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected files after rotation:\n%s", strings.Join(names, "\n"))
+	}
+
+	// Write errors are reported, and don't fail the call.
+	fail = false
+	var werr error
+	d.Dir = filepath.Join(dir, "README", "sub")
+	d.OnError = func(err error) { werr = err }
+	if err := c.RoundTrip(&msgT{}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if werr == nil {
+		t.Error("want write error")
+	}
+}
@@ -0,0 +1,180 @@
+package soap
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is a value of xsd:duration, an ISO 8601 duration such as
+// P1Y2M3DT4H30M or -PT1.5S, of its components as given: months and years
+// of varying lengths are not normalized to days or converted to seconds.
+type Duration struct {
+	Negative    bool
+	Years       int
+	Months      int
+	Days        int
+	Hours       int
+	Minutes     int
+	Seconds     int
+	Nanoseconds int // fraction of Seconds
+}
+
+// NewDuration returns the Duration of d, of hours, minutes and seconds.
+func NewDuration(d time.Duration) Duration {
+	var v Duration
+	if d < 0 {
+		v.Negative = true
+	}
+	abs := func(n time.Duration) int {
+		if n < 0 {
+			return int(-n)
+		}
+		return int(n)
+	}
+	v.Hours = abs(d / time.Hour)
+	v.Minutes = abs(d % time.Hour / time.Minute)
+	v.Seconds = abs(d % time.Minute / time.Second)
+	v.Nanoseconds = abs(d % time.Second)
+	return v
+}
+
+// Duration returns the time.Duration of v, of days of 24 hours, or false
+// if v has years or months, whose lengths vary, or overflows it.
+func (v Duration) Duration() (time.Duration, bool) {
+	if v.Years != 0 || v.Months != 0 {
+		return 0, false
+	}
+	var d time.Duration
+	for _, c := range []struct {
+		n    int
+		unit time.Duration
+	}{
+		{v.Days, 24 * time.Hour},
+		{v.Hours, time.Hour},
+		{v.Minutes, time.Minute},
+		{v.Seconds, time.Second},
+		{v.Nanoseconds, time.Nanosecond},
+	} {
+		if c.n < 0 || time.Duration(c.n) > (math.MaxInt64-d)/c.unit {
+			return 0, false
+		}
+		d += time.Duration(c.n) * c.unit
+	}
+	if v.Negative {
+		d = -d
+	}
+	return d, true
+}
+
+// IsZero reports whether v is a duration of zero length.
+func (v Duration) IsZero() bool {
+	return v.Years == 0 && v.Months == 0 && v.Days == 0 && v.Hours == 0 && v.Minutes == 0 && v.Seconds == 0 && v.Nanoseconds == 0
+}
+
+// String returns the text of v, PT0S if it is zero.
+func (v Duration) String() string {
+	if v.IsZero() {
+		return "PT0S"
+	}
+	var b strings.Builder
+	if v.Negative {
+		b.WriteByte('-')
+	}
+	b.WriteByte('P')
+	write := func(n int, designator byte) {
+		if n != 0 {
+			b.WriteString(strconv.Itoa(n))
+			b.WriteByte(designator)
+		}
+	}
+	write(v.Years, 'Y')
+	write(v.Months, 'M')
+	write(v.Days, 'D')
+	if v.Hours != 0 || v.Minutes != 0 || v.Seconds != 0 || v.Nanoseconds != 0 {
+		b.WriteByte('T')
+		write(v.Hours, 'H')
+		write(v.Minutes, 'M')
+		if v.Nanoseconds != 0 {
+			fmt.Fprintf(&b, "%d.%s", v.Seconds, strings.TrimRight(fmt.Sprintf("%09d", v.Nanoseconds), "0"))
+			b.WriteByte('S')
+		} else {
+			write(v.Seconds, 'S')
+		}
+	}
+	return b.String()
+}
+
+var durationRE = regexp.MustCompile(`^(-)?P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)(?:\.(\d+))?S)?)?$`)
+
+// ParseDuration returns the Duration of the text s.
+func ParseDuration(s string) (Duration, error) {
+	s = strings.TrimSpace(s)
+	m := durationRE.FindStringSubmatch(s)
+	if m == nil || strings.HasSuffix(s, "P") || strings.HasSuffix(s, "T") {
+		return Duration{}, fmt.Errorf("soap: invalid duration %q", s)
+	}
+	v := Duration{Negative: m[1] != ""}
+	for i, p := range []*int{&v.Years, &v.Months, &v.Days, &v.Hours, &v.Minutes, &v.Seconds} {
+		if m[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+2])
+		if err != nil {
+			return Duration{}, fmt.Errorf("soap: invalid duration %q: %w", s, err)
+		}
+		*p = n
+	}
+	if frac := m[8]; frac != "" {
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		n, _ := strconv.Atoi(frac + strings.Repeat("0", 9-len(frac)))
+		v.Nanoseconds = n
+	}
+	return v, nil
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (v Duration) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (v *Duration) UnmarshalText(text []byte) error {
+	d, err := ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*v = d
+	return nil
+}
+
+// MarshalXMLAttr implements the xml.MarshalerAttr interface, omitting
+// the attribute of the zero Duration.
+func (v Duration) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if v == (Duration{}) {
+		return xml.Attr{}, nil
+	}
+	return xml.Attr{Name: name, Value: v.String()}, nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding v as
+// the string of its text.
+func (v Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (v *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return v.UnmarshalText([]byte(s))
+}
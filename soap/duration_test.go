@@ -0,0 +1,105 @@
+package soap
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	cases := []struct {
+		Text string
+		Want Duration
+		Str  string
+	}{
+		{"P1Y2M3DT4H", Duration{Years: 1, Months: 2, Days: 3, Hours: 4}, "P1Y2M3DT4H"},
+		{"-PT1.50S", Duration{Negative: true, Seconds: 1, Nanoseconds: 500000000}, "-PT1.5S"},
+		{" PT90M ", Duration{Minutes: 90}, "PT90M"},
+		{"P0D", Duration{}, "PT0S"},
+		{"P2W", Duration{}, ""},
+		{"P", Duration{}, ""},
+		{"P1DT", Duration{}, ""},
+		{"PT1.5H", Duration{}, ""},
+		{"1D", Duration{}, ""},
+	}
+	for i, tc := range cases {
+		v, err := ParseDuration(tc.Text)
+		if tc.Str == "" {
+			if err == nil {
+				t.Errorf("test %d: %s accepted", i, tc.Text)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if v != tc.Want || v.String() != tc.Str {
+			t.Errorf("test %d: want %+v of %s, have %+v of %s", i, tc.Want, tc.Str, v, v)
+		}
+	}
+}
+
+func TestDurationConversion(t *testing.T) {
+	cases := []struct {
+		D    Duration
+		Want time.Duration
+		OK   bool
+	}{
+		{Duration{Days: 1, Hours: 2, Seconds: 3, Nanoseconds: 4}, 26*time.Hour + 3*time.Second + 4, true},
+		{Duration{Negative: true, Minutes: 30}, -30 * time.Minute, true},
+		{Duration{Months: 1}, 0, false},
+		{Duration{Days: 1 << 40}, 0, false},
+	}
+	for i, tc := range cases {
+		d, ok := tc.D.Duration()
+		if d != tc.Want || ok != tc.OK {
+			t.Errorf("test %d: want %v, %v, have %v, %v", i, tc.Want, tc.OK, d, ok)
+		}
+	}
+	for _, d := range []time.Duration{0, 90 * time.Minute, -1500 * time.Millisecond, 49*time.Hour + 1} {
+		v := NewDuration(d)
+		if back, ok := v.Duration(); !ok || back != d {
+			t.Errorf("%v: have %v of %s", d, back, v)
+		}
+	}
+	if s := NewDuration(-1500 * time.Millisecond).String(); s != "-PT1.5S" {
+		t.Errorf("unexpected %s", s)
+	}
+}
+
+func TestDurationXML(t *testing.T) {
+	type slot struct {
+		XMLName xml.Name  `xml:"slot"`
+		Length  Duration  `xml:"length"`
+		Buffer  Duration  `xml:"buffer,attr,omitempty"`
+		Grace   *Duration `xml:"grace,omitempty"`
+	}
+	in := slot{Length: Duration{Hours: 1, Minutes: 30}}
+	b, err := xml.Marshal(&in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<slot><length>PT1H30M</length></slot>`; string(b) != want {
+		t.Fatalf("want %s, have %s", want, b)
+	}
+	var out slot
+	if err := xml.Unmarshal([]byte(`<slot buffer="PT5M"><length>P1D</length></slot>`), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Length != (Duration{Days: 1}) || out.Buffer != (Duration{Minutes: 5}) || out.Grace != nil {
+		t.Fatalf("unexpected %+v", out)
+	}
+	if err := xml.Unmarshal([]byte(`<slot><length>1 hour</length></slot>`), &out); err == nil {
+		t.Fatal("invalid duration decoded")
+	}
+	j, err := json.Marshal(in.Length)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d Duration
+	if err := json.Unmarshal(j, &d); err != nil || string(j) != `"PT1H30M"` || d != in.Length {
+		t.Fatalf("unexpected JSON %s decoded as %+v: %v", j, d, err)
+	}
+}
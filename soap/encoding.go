@@ -0,0 +1,85 @@
+package soap
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// EncodingNamespace is the namespace of the SOAP 1.1 encoding of
+// RPC/encoded services.
+const EncodingNamespace = "http://schemas.xmlsoap.org/soap/encoding/"
+
+// XSDNamespace is the namespace of the XML Schema built-in types.
+const XSDNamespace = "http://www.w3.org/2001/XMLSchema"
+
+// Array is a SOAP-ENC array of RPC/encoded services, such as those of
+// Apache Axis 1.x. It is encoded with the soapenc:arrayType attribute
+// declaring ItemType and the number of items, each an item element, and
+// decoded from items of any element name:
+//
+//	<tickers xsi:type="soapenc:Array" soapenc:arrayType="xsd:string[2]">
+//		<item>ACME</item>
+//		<item>INIT</item>
+//	</tickers>
+//
+// Code generated by wsdl2go embeds it in the types restricting
+// soapenc:Array, setting ItemType in their SetXMLType method.
+type Array[T any] struct {
+	ItemType xml.Name `json:"-"` // XML type of the items (default xsd:anyType)
+	Items    []T
+}
+
+// MarshalXML implements the xml.Marshaler interface.
+func (a Array[T]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	typ := a.ItemType
+	if typ.Local == "" {
+		typ = xml.Name{Space: XSDNamespace, Local: "anyType"}
+	}
+	prefix := "xsd"
+	if typ.Space != XSDNamespace {
+		prefix = "ns1"
+	}
+	start.Attr = append(start.Attr,
+		xml.Attr{Name: xml.Name{Local: "xmlns:soapenc"}, Value: EncodingNamespace},
+		xml.Attr{Name: xml.Name{Local: "xmlns:xsi"}, Value: XSINamespace},
+		xml.Attr{Name: xml.Name{Local: "xmlns:" + prefix}, Value: typ.Space},
+		xml.Attr{Name: xml.Name{Local: "xsi:type"}, Value: "soapenc:Array"},
+		xml.Attr{Name: xml.Name{Local: "soapenc:arrayType"}, Value: fmt.Sprintf("%s:%s[%d]", prefix, typ.Local, len(a.Items))},
+	)
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	item := xml.StartElement{Name: xml.Name{Local: "item"}}
+	for _, v := range a.Items {
+		if err := e.EncodeElement(v, item); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// UnmarshalXML implements the xml.Unmarshaler interface. Nil items, with
+// xsi:nil="true", are decoded as the zero value of T.
+func (a *Array[T]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	a.Items = nil
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			var v T
+			if IsNil(t) {
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			} else if err := d.DecodeElement(&v, &t); err != nil {
+				return err
+			}
+			a.Items = append(a.Items, v)
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package soap
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type arrayOfString struct {
+	Array[string]
+}
+
+func (a *arrayOfString) SetXMLType() {
+	a.ItemType = xml.Name{Space: XSDNamespace, Local: "string"}
+}
+
+func TestArray(t *testing.T) {
+	type quote struct {
+		XMLName xml.Name       `xml:"getQuotes"`
+		Tickers *arrayOfString `xml:"tickers"`
+		Prices  Array[float64] `xml:"prices"`
+	}
+	in := &quote{Tickers: &arrayOfString{Array[string]{Items: []string{"ACME", "INIT"}}}}
+	setXMLType(reflect.ValueOf(in))
+	b, err := xml.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<getQuotes><tickers xmlns:soapenc="http://schemas.xmlsoap.org/soap/encoding/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:xsd="http://www.w3.org/2001/XMLSchema" xsi:type="soapenc:Array" soapenc:arrayType="xsd:string[2]"><item>ACME</item><item>INIT</item></tickers>` +
+		`<prices xmlns:soapenc="http://schemas.xmlsoap.org/soap/encoding/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:xsd="http://www.w3.org/2001/XMLSchema" xsi:type="soapenc:Array" soapenc:arrayType="xsd:anyType[0]"></prices></getQuotes>`
+	if string(b) != want {
+		t.Fatalf("want %s\nhave %s", want, b)
+	}
+
+	var out quote
+	err = xml.Unmarshal([]byte(`<getQuotes xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+		<tickers><item>ACME</item><ticker>INIT</ticker></tickers>
+		<prices><item>1.5</item><item xsi:nil="true"/><item>2</item></prices>
+	</getQuotes>`), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out.Tickers.Items, []string{"ACME", "INIT"}) {
+		t.Errorf("unexpected tickers: %q", out.Tickers.Items)
+	}
+	if !reflect.DeepEqual(out.Prices.Items, []float64{1.5, 0, 2}) {
+		t.Errorf("unexpected prices: %v", out.Prices.Items)
+	}
+}
+
+func TestRoundTripArrayMultiRefs(t *testing.T) {
+	type user struct {
+		Name string `xml:"name"`
+	}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:soapenc="http://schemas.xmlsoap.org/soap/encoding/"><soapenv:Body>
+<ns1:getUsersResponse xmlns:ns1="urn:users"><getUsersReturn soapenc:arrayType="ns1:User[2]"><getUsersReturn href="#id0"/><getUsersReturn href="#id1"/></getUsersReturn></ns1:getUsersResponse>
+<multiRef id="id0"><name>Ann</name></multiRef><multiRef id="id1"><name>Bob</name></multiRef>
+</soapenv:Body></soapenv:Envelope>`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	c := NewClient(s.URL, WithResolveMultiRefs())
+	out := struct {
+		M struct {
+			Users Array[*user] `xml:"getUsersReturn"`
+		} `xml:"getUsersResponse"`
+	}{}
+	if err := c.RoundTrip(&struct{}{}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if u := out.M.Users.Items; len(u) != 2 || u[0].Name != "Ann" || u[1].Name != "Bob" {
+		t.Fatalf("unexpected users %#v", u)
+	}
+}
@@ -0,0 +1,30 @@
+package soap
+
+import (
+	"io"
+	"net/http"
+)
+
+// sendExpect sends r, asking the server with "Expect: 100-continue"
+// whether it accepts requests larger than ExpectContinue before sending
+// their body. Requests that fail with 417 Expectation Failed, from
+// servers or proxies that don't support the expectation, are sent
+// again without it.
+func (c *Client) sendExpect(r *http.Request) (*http.Response, error) {
+	if c.ExpectContinue <= 0 || r.ContentLength <= c.ExpectContinue {
+		return c.send(r)
+	}
+	r.Header.Set("Expect", "100-continue")
+	resp, err := c.send(r)
+	if err != nil || resp.StatusCode != http.StatusExpectationFailed || r.GetBody == nil {
+		return resp, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+	next := r.Clone(r.Context())
+	next.Header.Del("Expect")
+	if next.Body, err = r.GetBody(); err != nil {
+		return nil, err
+	}
+	return c.send(next)
+}
@@ -0,0 +1,76 @@
+package soap
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// headerRecorder records the headers of the requests it sends.
+type headerRecorder struct {
+	mu      sync.Mutex
+	headers []http.Header
+}
+
+func (h *headerRecorder) RoundTrip(r *http.Request) (*http.Response, error) {
+	h.mu.Lock()
+	h.headers = append(h.headers, r.Header.Clone())
+	h.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestExpectContinue(t *testing.T) {
+	reject := 0
+	var lengths []int64
+	var chunked bool
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		lengths = append(lengths, int64(len(b)))
+		chunked = chunked || len(r.TransferEncoding) > 0
+		if reject > 0 {
+			reject--
+			w.WriteHeader(http.StatusExpectationFailed)
+			return
+		}
+		io.WriteString(w, `<Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Body><msgT><A>ok</A></msgT></Body></Envelope>`)
+	}))
+	defer s.Close()
+
+	type msgT struct{ A string }
+	rec := &headerRecorder{}
+	c := NewClient(s.URL, WithHTTPClient(&http.Client{Transport: rec}), WithExpectContinue(1024))
+	call := func(a string) {
+		t.Helper()
+		var out struct {
+			Msg msgT `xml:"msgT"`
+		}
+		if err := c.RoundTrip(&msgT{A: a}, &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.Msg.A != "ok" {
+			t.Errorf("unexpected response %+v", out)
+		}
+	}
+	call("small")
+	call(strings.Repeat("x", 2048))
+	reject = 1
+	call(strings.Repeat("x", 2048))
+
+	var expects []string
+	for _, h := range rec.headers {
+		expects = append(expects, h.Get("Expect"))
+	}
+	want := []string{"", "100-continue", "100-continue", ""}
+	if strings.Join(expects, ",") != strings.Join(want, ",") {
+		t.Errorf("want Expect headers %q, have %q", want, expects)
+	}
+	if chunked {
+		t.Error("request sent chunked")
+	}
+	if len(lengths) != 4 || lengths[2] != lengths[3] {
+		t.Errorf("unexpected request lengths %v", lengths)
+	}
+}
@@ -0,0 +1,266 @@
+package soap
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Facets are the constraining facets of a simple type of an XML schema,
+// which the generated Validate methods check values against. Lengths,
+// bounds and digits are given as in the schema, unset if empty; bounds
+// and digits constrain numeric values only.
+type Facets struct {
+	Enumeration    []string         // values allowed, if any
+	Patterns       []*regexp.Regexp // of which values must match one, if any
+	Length         string
+	MinLength      string
+	MaxLength      string
+	MinInclusive   string
+	MaxInclusive   string
+	MinExclusive   string
+	MaxExclusive   string
+	TotalDigits    string
+	FractionDigits string
+	Binary         string // hex or base64, of binary values whose lengths count octets
+}
+
+// FacetError is the error of a value that violates a facet.
+type FacetError struct {
+	Value   string
+	Facet   string // e.g. maxLength, or memberTypes for a union
+	Limit   string // value of the facet, empty for enumerations, patterns and unions
+	Element string // path of the value, such as Order.Zip, if known
+}
+
+func (e *FacetError) Error() string {
+	var s string
+	switch e.Facet {
+	case "enumeration":
+		s = fmt.Sprintf("value %q is not in the enumeration", e.Value)
+	case "pattern":
+		s = fmt.Sprintf("value %q does not match the pattern", e.Value)
+	case "memberTypes":
+		s = fmt.Sprintf("value %q is of none of the member types", e.Value)
+	default:
+		s = fmt.Sprintf("value %q violates %s=%s", e.Value, e.Facet, e.Limit)
+	}
+	if e.Element != "" {
+		s += " in " + e.Element
+	}
+	return s
+}
+
+// EnumerationError returns the *FacetError of v, a value of an
+// enumerated type that is none of its values.
+func EnumerationError(v any) error {
+	s, _ := FormatSimple(v)
+	return &FacetError{Value: s, Facet: "enumeration"}
+}
+
+// MemberTypesError returns the *FacetError of v, a value of a union
+// that is of none of its member types.
+func MemberTypesError(v any) error {
+	s, _ := FormatSimple(v)
+	return &FacetError{Value: s, Facet: "memberTypes"}
+}
+
+// Check returns a *FacetError if the text s of a value violates one of
+// the facets f.
+func (f *Facets) Check(s string) error {
+	return f.CheckElement(s, "")
+}
+
+// CheckElement is like Check, for the value of element, the path of
+// the value such as Order.Zip, which the *FacetError names.
+func (f *Facets) CheckElement(s, element string) error {
+	fail := func(facet, limit string) error {
+		return &FacetError{Value: s, Facet: facet, Limit: limit, Element: element}
+	}
+	if len(f.Enumeration) > 0 && !slices.Contains(f.Enumeration, s) {
+		return fail("enumeration", "")
+	}
+	if len(f.Patterns) > 0 && !slices.ContainsFunc(f.Patterns, func(re *regexp.Regexp) bool { return re.MatchString(s) }) {
+		return fail("pattern", "")
+	}
+	n := utf8.RuneCountInString(s)
+	switch f.Binary {
+	case "hex":
+		n = len(strings.TrimSpace(s)) / 2
+	case "base64":
+		b, _ := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+		n = len(b)
+	}
+	lengths := []struct {
+		facet, limit string
+		fails        func(limit int) bool
+	}{
+		{"length", f.Length, func(limit int) bool { return n != limit }},
+		{"minLength", f.MinLength, func(limit int) bool { return n < limit }},
+		{"maxLength", f.MaxLength, func(limit int) bool { return n > limit }},
+	}
+	for _, l := range lengths {
+		if limit, err := strconv.Atoi(l.limit); err == nil && l.fails(limit) {
+			return fail(l.facet, l.limit)
+		}
+	}
+	value := strings.TrimSpace(s)
+	x, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil
+	}
+	bounds := []struct {
+		facet, limit string
+		fails        func(cmp int) bool
+	}{
+		{"minInclusive", f.MinInclusive, func(cmp int) bool { return cmp < 0 }},
+		{"maxInclusive", f.MaxInclusive, func(cmp int) bool { return cmp > 0 }},
+		{"minExclusive", f.MinExclusive, func(cmp int) bool { return cmp <= 0 }},
+		{"maxExclusive", f.MaxExclusive, func(cmp int) bool { return cmp >= 0 }},
+	}
+	for _, b := range bounds {
+		if limit, ok := new(big.Rat).SetString(b.limit); ok && b.fails(x.Cmp(limit)) {
+			return fail(b.facet, b.limit)
+		}
+	}
+	total, fraction, ok := decimalDigits(value)
+	if !ok {
+		return nil
+	}
+	if limit, err := strconv.Atoi(f.TotalDigits); err == nil && total > limit {
+		return fail("totalDigits", f.TotalDigits)
+	}
+	if limit, err := strconv.Atoi(f.FractionDigits); err == nil && fraction > limit {
+		return fail("fractionDigits", f.FractionDigits)
+	}
+	return nil
+}
+
+// decimalDigits returns the number of significant digits of the decimal
+// s, and that of its fraction digits, or false if s is not a decimal,
+// such as a float of an exponent.
+func decimalDigits(s string) (total, fraction int, ok bool) {
+	s = strings.TrimLeft(s, "+-")
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole+frac == "" || strings.Trim(whole+frac, "0123456789") != "" {
+		return 0, 0, false
+	}
+	whole = strings.TrimLeft(whole, "0")
+	frac = strings.TrimRight(frac, "0")
+	return len(whole) + len(frac), len(frac), true
+}
+
+// Validate validates v, such as the input of an operation, and the
+// values it refers to: it returns the error of the first Validate
+// method of a struct, or value of a simple type, that fails. The
+// *FacetError of a value is given its path as Element, unless it names
+// one already; other errors are prefixed with the path. Omitted values,
+// nil or zero if their fields have the omitempty option, are not
+// validated.
+func Validate(v any) error {
+	return walkValues(v, func(x any, path string) error {
+		val, ok := x.(validator)
+		if !ok {
+			return nil
+		}
+		err := val.Validate()
+		if err == nil {
+			return nil
+		}
+		var ferr *FacetError
+		if !errors.As(err, &ferr) {
+			return fmt.Errorf("soap: %s: %w", path, err)
+		}
+		if ferr.Element == "" {
+			e := *ferr
+			e.Element = path
+			return fmt.Errorf("soap: %w", &e)
+		}
+		return fmt.Errorf("soap: %w", err)
+	})
+}
+
+type validator interface{ Validate() error }
+
+// walkValues calls fn with the values of v and those it refers to, the
+// omitted ones excepted, addressed if possible, and their paths, until
+// it returns an error.
+func walkValues(v any, fn func(x any, path string) error) error {
+	if v == nil {
+		return nil
+	}
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer {
+		// A copy is addressable, for the methods of pointers.
+		p := reflect.New(rv.Type())
+		p.Elem().Set(rv)
+		rv = p.Elem()
+	}
+	return walkValue(rv, t.Name(), make(map[uintptr]bool), fn)
+}
+
+func walkValue(v reflect.Value, path string, seen map[uintptr]bool, fn func(x any, path string) error) error {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Pointer:
+		if v.IsNil() || seen[v.Pointer()] {
+			return nil
+		}
+		seen[v.Pointer()] = true
+		return walkValue(v.Elem(), path, seen, fn)
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return walkValue(v.Elem(), path, seen, fn)
+	}
+	x := v
+	if v.CanAddr() {
+		x = v.Addr()
+	}
+	if x.CanInterface() {
+		if err := fn(x.Interface(), path); err != nil {
+			return err
+		}
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			tag := sf.Tag.Get("xml")
+			if !sf.IsExported() || tag == "-" {
+				continue
+			}
+			f := v.Field(i)
+			if strings.Contains(tag, ",omitempty") && f.IsZero() {
+				continue
+			}
+			if err := walkValue(f, path+"."+sf.Name, seen, fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := walkValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), seen, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package soap
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestFacets(t *testing.T) {
+	cases := []struct {
+		Facets Facets
+		Value  string
+		Want   string
+	}{
+		{Facets{Enumeration: []string{"S", "M"}}, "M", ""},
+		{Facets{Enumeration: []string{"S", "M"}}, "L", `value "L" is not in the enumeration`},
+		{Facets{Patterns: []*regexp.Regexp{regexp.MustCompile(`^(?:\d{4})$`)}}, "80a0", `value "80a0" does not match the pattern`},
+		{Facets{Length: "4"}, "Zürich", `value "Zürich" violates length=4`},
+		{Facets{MaxLength: "6"}, "Zürich", ""},
+		{Facets{Length: "2", Binary: "hex"}, "beef", ""},
+		{Facets{Length: "2", Binary: "hex"}, "be", `value "be" violates length=2`},
+		{Facets{MaxLength: "3", Binary: "base64"}, "3q2+7w==", `value "3q2+7w==" violates maxLength=3`},
+		{Facets{MinLength: "1"}, "", `value "" violates minLength=1`},
+		{Facets{MinInclusive: "1", MaxInclusive: "12"}, "12", ""},
+		{Facets{MinInclusive: "1", MaxInclusive: "12"}, "0", `value "0" violates minInclusive=1`},
+		{Facets{MaxExclusive: "100.5"}, "100.5", `value "100.5" violates maxExclusive=100.5`},
+		{Facets{MinExclusive: "-1"}, "-0.5", ""},
+		{Facets{TotalDigits: "5", FractionDigits: "2"}, "-012.340", ""},
+		{Facets{TotalDigits: "5", FractionDigits: "2"}, "1234.5", ""},
+		{Facets{TotalDigits: "5", FractionDigits: "2"}, "12345.6", `value "12345.6" violates totalDigits=5`},
+		{Facets{TotalDigits: "5", FractionDigits: "2"}, "1.234", `value "1.234" violates fractionDigits=2`},
+		{Facets{TotalDigits: "2"}, "1e+21", ""},
+		{Facets{MaxInclusive: "10"}, "2024-01-01", ""},
+	}
+	for i, tc := range cases {
+		var have string
+		if err := tc.Facets.Check(tc.Value); err != nil {
+			var ferr *FacetError
+			if !errors.As(err, &ferr) {
+				t.Errorf("test %d: unexpected error type %T", i, err)
+			}
+			have = err.Error()
+		}
+		if have != tc.Want {
+			t.Errorf("test %d: want %q, have %q", i, tc.Want, have)
+		}
+	}
+	f := &Facets{MaxLength: "2"}
+	if err := f.CheckElement("abc", "Order.Code"); err == nil || err.Error() != `value "abc" violates maxLength=2 in Order.Code` {
+		t.Errorf("unexpected error of the element: %v", err)
+	}
+	for _, err := range []error{EnumerationError(testZip("L")), MemberTypesError("x")} {
+		var ferr *FacetError
+		if !errors.As(err, &ferr) || ferr.Value == "" {
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+}
+
+var testZipFacets = &Facets{Length: "4"}
+
+type testZip string
+
+func (v testZip) Validate() error { return testZipFacets.Check(string(v)) }
+
+type testAddress struct {
+	Zip  testZip  `xml:"zip"`
+	City *string  `xml:"city,omitempty"`
+	Old  *testZip `xml:"old,omitempty"`
+}
+
+type testCustomer struct {
+	Name      string         `xml:"name"`
+	Nick      testZip        `xml:"nick,omitempty"`
+	Addresses []*testAddress `xml:"address"`
+	Self      *testCustomer  `xml:"self,omitempty"`
+}
+
+func (v *testCustomer) Validate() error {
+	if v.Name == "" {
+		return errors.New("testCustomer: missing name")
+	}
+	return nil
+}
+
+func TestValidateValue(t *testing.T) {
+	old := testZip("800")
+	c := &testCustomer{
+		Name:      "Moser",
+		Addresses: []*testAddress{{Zip: "8000"}, {Zip: "3000", Old: &old}},
+	}
+	c.Self = c
+	cases := []struct {
+		V    any
+		Want string
+	}{
+		{nil, ""},
+		{&testCustomer{Name: "Moser"}, ""},
+		{testCustomer{}, "soap: testCustomer: testCustomer: missing name"},
+		{c, `soap: value "800" violates length=4 in testCustomer.Addresses[1].Old`},
+		{&testAddress{Zip: "1"}, `soap: value "1" violates length=4 in testAddress.Zip`},
+		{[]testZip{"1234", "12"}, `soap: value "12" violates length=4 in [1]`},
+	}
+	for i, tc := range cases {
+		var have string
+		if err := Validate(tc.V); err != nil {
+			have = err.Error()
+		}
+		if have != tc.Want {
+			t.Errorf("test %d: want %q, have %q", i, tc.Want, have)
+		}
+	}
+}
+
+func TestClientValidateRequests(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, `<Envelope><Body/></Envelope>`)
+	}))
+	defer srv.Close()
+	type in struct {
+		XMLName xml.Name `xml:"in"`
+		Zip     testZip  `xml:"zip"`
+	}
+	c := NewClient(srv.URL, WithValidateRequests())
+	err := c.RoundTrip(&in{Zip: "12"}, nil)
+	if err == nil || !strings.Contains(err.Error(), `invalid request: soap: value "12" violates length=4 in in.Zip`) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if calls != 0 {
+		t.Fatal("invalid request sent")
+	}
+	if err := c.RoundTrip(&in{Zip: "1234"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Fatal("valid request not sent")
+	}
+}
@@ -0,0 +1,149 @@
+package soap
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Endpoints is a list of URLs of the same service, such as its primary
+// and disaster recovery sites. Its middleware sends each call to the
+// first healthy endpoint and fails over to the next one when the
+// connection fails or the server responds with 503 Service Unavailable.
+// Failed endpoints are skipped for Cooldown, and only tried as a last
+// resort until then.
+//
+//	eps := soap.NewEndpoints("https://primary/svc", "https://dr/svc")
+//	cli.Middleware = append(cli.Middleware, eps.Middleware())
+//
+// It is safe for concurrent use.
+type Endpoints struct {
+	URLs       []string
+	Cooldown   time.Duration // How long failed endpoints are skipped (default 30s)
+	RoundRobin bool          // Spread calls across healthy endpoints instead of preferring the first
+
+	mu   sync.Mutex
+	down map[string]time.Time
+	next int
+	now  func() time.Time
+}
+
+// NewEndpoints returns the Endpoints of urls, in order of preference.
+func NewEndpoints(urls ...string) *Endpoints {
+	return &Endpoints{URLs: urls}
+}
+
+// WithEndpoints sends requests to urls with failover between them, as
+// described by Endpoints.
+func WithEndpoints(urls ...string) Option {
+	return func(c *Client) {
+		if len(urls) > 0 {
+			c.URL = urls[0]
+		}
+		WithMiddleware(NewEndpoints(urls...).Middleware())(c)
+	}
+}
+
+// Middleware returns the Middleware that sets the URL of calls.
+func (e *Endpoints) Middleware() Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(ctx context.Context, call *Call) error {
+			var err error
+			for _, u := range e.order() {
+				call.URL = u
+				err = next(ctx, call)
+				if !shouldFailover(err) {
+					e.markUp(u)
+					return err
+				}
+				e.markDown(u)
+				if ctx.Err() != nil {
+					return err
+				}
+			}
+			return err
+		}
+	}
+}
+
+// Healthy returns the endpoints that are not cooling down after a
+// failure.
+func (e *Endpoints) Healthy() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var urls []string
+	now := e.clock()
+	for _, u := range e.URLs {
+		if !now.Before(e.down[u]) {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// order returns the endpoints to try for a call: the healthy ones first,
+// rotated if RoundRobin is set, then the failed ones.
+func (e *Endpoints) order() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.clock()
+	var up, down []string
+	for _, u := range e.URLs {
+		if now.Before(e.down[u]) {
+			down = append(down, u)
+		} else {
+			up = append(up, u)
+		}
+	}
+	if e.RoundRobin && len(up) > 1 {
+		i := e.next % len(up)
+		e.next++
+		up = append(append([]string(nil), up[i:]...), up[:i]...)
+	}
+	return append(up, down...)
+}
+
+func (e *Endpoints) markDown(u string) {
+	cooldown := e.Cooldown
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.down == nil {
+		e.down = make(map[string]time.Time)
+	}
+	e.down[u] = e.clock().Add(cooldown)
+}
+
+func (e *Endpoints) markUp(u string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.down, u)
+}
+
+func (e *Endpoints) clock() time.Time {
+	if e.now != nil {
+		return e.now()
+	}
+	return time.Now()
+}
+
+// shouldFailover reports whether err means the endpoint is unavailable:
+// a connection error, or an HTTP 503 response.
+func shouldFailover(err error) bool {
+	if err == nil {
+		return false
+	}
+	var herr *HTTPError
+	if errors.As(err, &herr) {
+		return herr.StatusCode == http.StatusServiceUnavailable
+	}
+	var uerr *url.Error
+	var nerr net.Error
+	return errors.As(err, &uerr) || errors.As(err, &nerr)
+}
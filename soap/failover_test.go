@@ -0,0 +1,88 @@
+package soap
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEndpoints(t *testing.T) {
+	type msgT struct{ A, B string }
+	type envT struct{ msgT }
+	var hits []string
+	handler := func(name string, status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits = append(hits, name)
+			if status != http.StatusOK {
+				w.WriteHeader(status)
+				return
+			}
+			io.Copy(w, r.Body)
+		}))
+	}
+	closed := handler("closed", http.StatusOK)
+	closed.Close()
+	busy := handler("busy", http.StatusServiceUnavailable)
+	defer busy.Close()
+	ok := handler("ok", http.StatusOK)
+	defer ok.Close()
+	broken := handler("broken", http.StatusInternalServerError)
+	defer broken.Close()
+
+	now := time.Now()
+	eps := NewEndpoints(closed.URL, busy.URL, ok.URL)
+	eps.now = func() time.Time { return now }
+	c := NewClient(closed.URL, WithNamespace("urn:test"))
+	c.Middleware = []Middleware{eps.Middleware()}
+
+	testCases := []struct {
+		advance time.Duration
+		want    []string
+		healthy []string
+	}{
+		{0, []string{"busy", "ok"}, []string{ok.URL}},
+		{time.Second, []string{"ok"}, []string{ok.URL}},
+		{time.Minute, []string{"busy", "ok"}, []string{ok.URL}},
+	}
+	for i, tc := range testCases {
+		now = now.Add(tc.advance)
+		hits = nil
+		out := &envT{}
+		if err := c.RoundTrip(&msgT{A: "hello"}, out); err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if out.A != "hello" {
+			t.Errorf("test %d: unexpected response %#v", i, out)
+		}
+		if !reflect.DeepEqual(hits, tc.want) {
+			t.Errorf("test %d: want hits %q, have %q", i, tc.want, hits)
+		}
+		if have := eps.Healthy(); !reflect.DeepEqual(have, tc.healthy) {
+			t.Errorf("test %d: want healthy %q, have %q", i, tc.healthy, have)
+		}
+	}
+
+	// Other errors are returned without failover.
+	hits = nil
+	c = NewClient(broken.URL, WithEndpoints(broken.URL, ok.URL))
+	if err := c.RoundTrip(&msgT{A: "hello"}, &envT{}); err == nil {
+		t.Fatal("want error")
+	}
+	if !reflect.DeepEqual(hits, []string{"broken"}) {
+		t.Errorf("unexpected hits %q", hits)
+	}
+}
+
+func TestEndpointsRoundRobin(t *testing.T) {
+	eps := &Endpoints{URLs: []string{"a", "b", "c"}, RoundRobin: true}
+	var have []string
+	for i := 0; i < 4; i++ {
+		have = append(have, eps.order()[0])
+	}
+	if want := []string{"a", "b", "c", "a"}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("want %q, have %q", want, have)
+	}
+}
@@ -0,0 +1,236 @@
+package soap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+)
+
+// Fault is a SOAP fault returned by the server. SOAP 1.2 faults are
+// mapped onto the SOAP 1.1 fields: Code holds the Code/Value, String
+// the first Reason/Text and Actor the Role.
+type Fault struct {
+	XMLName xml.Name `xml:"Fault"`
+	Code    string   `xml:"faultcode"`
+	String  string   `xml:"faultstring"`
+	Actor   string   `xml:"faultactor,omitempty"`
+	Detail  *Detail  `xml:"detail,omitempty"`
+	Subcode string   `xml:"-"` // SOAP 1.2 Code/Subcode/Value
+	Node    string   `xml:"-"` // SOAP 1.2 Node
+
+	// CodeNamespace is the namespace of the prefix of Code or Subcode,
+	// other than soapenv, declared on the faults sent by a Server.
+	CodeNamespace string `xml:"-"`
+
+	err error // error encoding the detail of NewFault
+}
+
+// Detail holds the application specific fault detail as raw XML.
+type Detail struct {
+	Content []byte `xml:",innerxml"`
+}
+
+// NewDetail returns the Detail holding v encoded as the element name,
+// for faults with typed detail returned by servers.
+func NewDetail(name xml.Name, v any) (*Detail, error) {
+	var b bytes.Buffer
+	if err := xml.NewEncoder(&b).EncodeElement(v, xml.StartElement{Name: name}); err != nil {
+		return nil, err
+	}
+	return &Detail{Content: b.Bytes()}, nil
+}
+
+// decodeElement unmarshals the element of the detail named local onto
+// v, and returns whether the detail holds it.
+func (d *Detail) decodeElement(local string, v any) (bool, error) {
+	dec := xml.NewDecoder(bytes.NewReader(d.Content))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return false, nil
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			if se.Name.Local != local {
+				return false, nil
+			}
+			return true, dec.DecodeElement(v, &se)
+		}
+	}
+}
+
+// DetailError is the error of a SOAP fault whose detail holds the
+// element of a wsdl:fault, decoded onto Detail, as returned by the
+// methods of generated code for the faults of their operation.
+type DetailError[T any] struct {
+	Detail *T
+	Fault  *Fault
+	err    error // of the call, such as an *HTTPError
+}
+
+func (e *DetailError[T]) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the error of the call, so that the HTTPError and the
+// Fault of the response can still be matched with errors.As.
+func (e *DetailError[T]) Unwrap() error {
+	return e.err
+}
+
+// FaultDecoder returns the typed error of the SOAP fault f of the error
+// err of a call, or nil if f is not one of its faults.
+type FaultDecoder func(err error, f *Fault) error
+
+// DetailDecoder returns the FaultDecoder of the faults whose detail
+// holds the element named local, as a *DetailError[T].
+func DetailDecoder[T any](local string) FaultDecoder {
+	return func(err error, f *Fault) error {
+		v := new(T)
+		if ok, derr := f.Detail.decodeElement(local, v); !ok || derr != nil {
+			return nil
+		}
+		return &DetailError[T]{Detail: v, Fault: f, err: err}
+	}
+}
+
+// DecodeFault returns the error of the first of decoders decoding the
+// SOAP fault of err, if it has a detail, or err.
+func DecodeFault(err error, decoders ...FaultDecoder) error {
+	var f *Fault
+	if !errors.As(err, &f) || f.Detail == nil {
+		return err
+	}
+	for _, decode := range decoders {
+		if derr := decode(err, f); derr != nil {
+			return derr
+		}
+	}
+	return err
+}
+
+func (f *Fault) Error() string {
+	return fmt.Sprintf("soap fault %s: %s", f.Code, f.String)
+}
+
+// SOAPFault implements the FaultError interface.
+func (f *Fault) SOAPFault() *Fault {
+	return f
+}
+
+// Codes of the faults sent by servers, which send them as the code of
+// the SOAP version of the request, such as soapenv:Sender for
+// FaultClient in SOAP 1.2.
+const (
+	FaultClient          = "soapenv:Client"
+	FaultServer          = "soapenv:Server"
+	FaultVersionMismatch = "soapenv:VersionMismatch"
+	FaultMustUnderstand  = "soapenv:MustUnderstand"
+)
+
+// FaultError is an error sent by servers as a SOAP fault, for
+// application errors that are faults of the service. A *Fault is a
+// FaultError.
+type FaultError interface {
+	error
+	SOAPFault() *Fault
+}
+
+// FaultOption configures the faults of NewFault.
+type FaultOption func(*Fault)
+
+// WithFaultActor sets the faultactor (the SOAP 1.2 Role) of the fault.
+func WithFaultActor(actor string) FaultOption {
+	return func(f *Fault) {
+		f.Actor = actor
+	}
+}
+
+// WithFaultSubcode sets the SOAP 1.2 Subcode of the fault, whose prefix,
+// if any, is declared for namespace.
+func WithFaultSubcode(subcode, namespace string) FaultOption {
+	return func(f *Fault) {
+		f.Subcode = subcode
+		f.CodeNamespace = namespace
+	}
+}
+
+// WithFaultDetail sets the detail of the fault to v, encoded as
+// xml.Marshal does: as the element of its XMLName field or type name.
+func WithFaultDetail(v any) FaultOption {
+	return func(f *Fault) {
+		b, err := xml.Marshal(v)
+		if err != nil {
+			f.err = err
+			return
+		}
+		f.Detail = &Detail{Content: b}
+	}
+}
+
+// NewFault returns the fault with code, one of the Fault constants or a
+// code of the service, and reason as its faultstring, for handlers of
+// a Server to return as error. A detail that cannot be encoded makes
+// the server send a server fault instead.
+func NewFault(code, reason string, opts ...FaultOption) *Fault {
+	f := &Fault{Code: code, String: reason}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+type faultCode12 struct {
+	Value   string       `xml:"Value"`
+	Subcode *faultCode12 `xml:"Subcode"`
+}
+
+// UnmarshalXML implements the xml.Unmarshaler interface, decoding SOAP 1.1
+// and SOAP 1.2 faults.
+func (f *Fault) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var v struct {
+		Code     string       `xml:"faultcode"`
+		String   string       `xml:"faultstring"`
+		Actor    string       `xml:"faultactor"`
+		Detail   *Detail      `xml:"detail"`
+		Code12   *faultCode12 `xml:"Code"`
+		Reason   []string     `xml:"Reason>Text"`
+		Node     string       `xml:"Node"`
+		Role     string       `xml:"Role"`
+		Detail12 *Detail      `xml:"Detail"`
+	}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*f = Fault{XMLName: start.Name, Code: v.Code, String: v.String, Actor: v.Actor, Detail: v.Detail}
+	if v.Code12 != nil {
+		f.Code = v.Code12.Value
+		if v.Code12.Subcode != nil {
+			f.Subcode = v.Code12.Subcode.Value
+		}
+	}
+	if len(v.Reason) > 0 {
+		f.String = v.Reason[0]
+	}
+	if v.Role != "" {
+		f.Actor = v.Role
+	}
+	if v.Detail12 != nil {
+		f.Detail = v.Detail12
+	}
+	f.Node = v.Node
+	return nil
+}
+
+// parseFault returns the fault in the envelope b, or nil.
+func parseFault(b []byte) *Fault {
+	var env struct {
+		Body struct {
+			Fault *Fault `xml:"Fault"`
+		} `xml:"Body"`
+	}
+	if err := xml.Unmarshal(b, &env); err != nil {
+		return nil
+	}
+	return env.Body.Fault
+}
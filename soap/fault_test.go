@@ -0,0 +1,196 @@
+package soap
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRoundTripFault(t *testing.T) {
+	type msgT struct{ A, B string }
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(w, `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+<soap:Body><soap:Fault>
+<faultcode>soap:Client</faultcode>
+<faultstring>invalid request</faultstring>
+<detail><code>42</code></detail>
+</soap:Fault></soap:Body></soap:Envelope>`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	c := &Client{URL: s.URL}
+	err := c.RoundTrip(&msgT{}, &msgT{})
+	var herr *HTTPError
+	if !errors.As(err, &herr) || herr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("unexpected error %#v", err)
+	}
+	var fault *Fault
+	if !errors.As(err, &fault) {
+		t.Fatalf("fault not found in %v", err)
+	}
+	if fault.Code != "soap:Client" || fault.String != "invalid request" {
+		t.Fatalf("unexpected fault %#v", fault)
+	}
+	if fault.Detail == nil || string(fault.Detail.Content) != "<code>42</code>" {
+		t.Fatalf("unexpected fault detail %#v", fault.Detail)
+	}
+}
+
+func TestFaultSOAP12(t *testing.T) {
+	f := parseFault([]byte(`<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope">
+<env:Body><env:Fault>
+<env:Code><env:Value>env:Sender</env:Value><env:Subcode><env:Value>m:BadRequest</env:Value></env:Subcode></env:Code>
+<env:Reason><env:Text xml:lang="en">invalid request</env:Text><env:Text xml:lang="de">ungültige Anfrage</env:Text></env:Reason>
+<env:Node>http://node</env:Node>
+<env:Role>http://role</env:Role>
+<env:Detail><code>42</code></env:Detail>
+</env:Fault></env:Body></env:Envelope>`))
+	if f == nil {
+		t.Fatal("fault not found")
+	}
+	want := Fault{Code: "env:Sender", Subcode: "m:BadRequest", String: "invalid request", Actor: "http://role", Node: "http://node"}
+	if f.Code != want.Code || f.Subcode != want.Subcode || f.String != want.String || f.Actor != want.Actor || f.Node != want.Node {
+		t.Fatalf("unexpected fault %#v", f)
+	}
+	if f.Detail == nil || string(f.Detail.Content) != "<code>42</code>" {
+		t.Fatalf("unexpected fault detail %#v", f.Detail)
+	}
+}
+
+type quotaError struct{ Limit int }
+
+func (e *quotaError) Error() string { return fmt.Sprintf("quota of %d exceeded", e.Limit) }
+
+func (e *quotaError) SOAPFault() *Fault {
+	type quota struct {
+		XMLName xml.Name `xml:"urn:quota QuotaExceeded"`
+		Limit   int      `xml:"limit"`
+	}
+	return NewFault(FaultClient, e.Error(), WithFaultActor("urn:quota"), WithFaultDetail(&quota{Limit: e.Limit}))
+}
+
+func TestNewFault(t *testing.T) {
+	srv := NewServer()
+	var fail error
+	srv.HandleFunc("Op", "", xml.Name{Local: "op"}, func(ctx context.Context, r *Request) (Message, error) {
+		return nil, fail
+	})
+	s := httptest.NewServer(srv)
+	defer s.Close()
+
+	cases := []struct {
+		Name    string
+		Err     error
+		Version Version
+		Status  int
+		Code    string
+		Subcode string
+		Actor   string
+		Detail  string
+	}{
+		{
+			Name:    "typed detail",
+			Err:     fmt.Errorf("upload: %w", &quotaError{Limit: 3}),
+			Version: SOAP11,
+			Status:  http.StatusInternalServerError,
+			Code:    "soapenv:Client",
+			Actor:   "urn:quota",
+			Detail:  `<QuotaExceeded xmlns="urn:quota"><limit>3</limit></QuotaExceeded>`,
+		},
+		{
+			Name:    "sender",
+			Err:     &quotaError{Limit: 3},
+			Version: SOAP12,
+			Status:  http.StatusBadRequest,
+			Code:    "soapenv:Sender",
+			Actor:   "urn:quota",
+			Detail:  `<QuotaExceeded xmlns="urn:quota"><limit>3</limit></QuotaExceeded>`,
+		},
+		{
+			Name:    "subcode",
+			Err:     NewFault(FaultServer, "unavailable", WithFaultSubcode("app:Maintenance", "urn:app")),
+			Version: SOAP12,
+			Status:  http.StatusInternalServerError,
+			Code:    "soapenv:Receiver",
+			Subcode: "app:Maintenance",
+		},
+		{
+			Name:    "bad detail",
+			Err:     NewFault(FaultClient, "bad", WithFaultDetail(make(chan int))),
+			Version: SOAP11,
+			Status:  http.StatusInternalServerError,
+			Code:    "soapenv:Server",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			fail = tc.Err
+			c := NewClient(s.URL, WithVersion(tc.Version))
+			err := c.RoundTrip(&struct {
+				M struct{} `xml:"op"`
+			}{}, nil)
+			var fault *Fault
+			if !errors.As(err, &fault) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			var herr *HTTPError
+			if !errors.As(err, &herr) || herr.StatusCode != tc.Status {
+				t.Errorf("error %v, want status %d", err, tc.Status)
+			}
+			if fault.Code != tc.Code || fault.Subcode != tc.Subcode || fault.Actor != tc.Actor {
+				t.Errorf("unexpected fault %+v", fault)
+			}
+			detail := ""
+			if fault.Detail != nil {
+				detail = strings.TrimSpace(string(fault.Detail.Content))
+			}
+			if detail != tc.Detail {
+				t.Errorf("detail %q, want %q", detail, tc.Detail)
+			}
+		})
+	}
+}
+
+func TestDecodeFault(t *testing.T) {
+	type notFound struct {
+		Name string `xml:"name"`
+	}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(w, `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+<soap:Body><soap:Fault>
+<faultcode>soap:Server</faultcode>
+<faultstring>no such person</faultstring>
+<detail>
+	<NotFound xmlns="urn:directory"><name>Ann</name></NotFound>
+</detail>
+</soap:Fault></soap:Body></soap:Envelope>`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	c := &Client{URL: s.URL}
+	err := c.RoundTrip(&struct{}{}, &struct{}{})
+
+	if e := DecodeFault(err, DetailDecoder[notFound]("Other")); e != err {
+		t.Fatalf("unexpected error %#v", e)
+	}
+	err = DecodeFault(err, DetailDecoder[notFound]("Other"), DetailDecoder[notFound]("NotFound"))
+	var derr *DetailError[notFound]
+	if !errors.As(err, &derr) || derr.Detail.Name != "Ann" || derr.Fault.String != "no such person" {
+		t.Fatalf("unexpected error %#v", err)
+	}
+	var herr *HTTPError
+	if !errors.As(err, &herr) || herr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("HTTPError not found in %v", err)
+	}
+	if e := DecodeFault(errors.New("boom"), DetailDecoder[notFound]("NotFound")); e.Error() != "boom" {
+		t.Fatalf("unexpected error %v", e)
+	}
+}
@@ -0,0 +1,222 @@
+package soap
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// RoundTripHTTPGet calls an operation of a WSDL HTTP GET binding: the
+// fields of in are sent as URL-encoded query parameters to location,
+// relative to the client's URL as the http:operation location is to the
+// http:address, and the XML document of the response is decoded onto
+// out. The root element of the response is matched against the fields
+// of out, as the content of the Body of SOAP responses is, so generated
+// response types can be used for both bindings.
+//
+// Fields are named by their xml tags, or else their Go names; fields of
+// nested structs are added as if they were fields of in, and slices are
+// sent as repeated parameters.
+func (c *Client) RoundTripHTTPGet(location string, in, out Message) error {
+	return c.RoundTripHTTPGetContext(c.context(), location, in, out)
+}
+
+// RoundTripHTTPGetContext is like RoundTripHTTPGet, using ctx for the
+// HTTP request instead of the client's Ctx.
+func (c *Client) RoundTripHTTPGetContext(ctx context.Context, location string, in, out Message) error {
+	call := &Call{Action: location, URL: httpOperationURL(c.URL, location), In: in, Out: out}
+	return c.chain(func(ctx context.Context, call *Call) error {
+		return doHTTPGet(ctx, c, call)
+	})(ctx, call)
+}
+
+// httpOperationURL returns the URL of the operation at location of the
+// service at base.
+func httpOperationURL(base, location string) string {
+	if location == "" {
+		return base
+	}
+	if u, err := url.Parse(location); err == nil && u.IsAbs() {
+		return location
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(location, "/")
+}
+
+func doHTTPGet(ctx context.Context, c *Client, call *Call) error {
+	q, err := queryValues(call.In)
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(call.URL)
+	if err != nil {
+		return err
+	}
+	if len(q) > 0 {
+		if u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+		u.RawQuery += q.Encode()
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Accept", "text/xml, application/xml")
+	return c.exchange(ctx, call, r, func(body io.Reader) error {
+		if call.Capture {
+			var captured bytes.Buffer
+			body = io.TeeReader(body, &captured)
+			defer func() { call.ResponseEnvelope = captured.Bytes() }()
+		}
+		if call.Out == nil {
+			_, err := io.Copy(io.Discard, body)
+			return err
+		}
+		return c.decodeResponse(body, &documentBody{call.Out})
+	})
+}
+
+// documentBody decodes an XML document onto out as if its root element
+// were the content of a SOAP Body.
+type documentBody struct{ out Message }
+
+// UnmarshalXML implements the xml.Unmarshaler interface.
+func (b *documentBody) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	body := xml.Name{Local: "Body"}
+	tr := &bodyTokens{d: d, queue: []xml.Token{xml.StartElement{Name: body}, start}, depth: 1}
+	return xml.NewTokenDecoder(tr).Decode(b.out)
+}
+
+// bodyTokens reads the tokens of an element from d, wrapped in a Body
+// element.
+type bodyTokens struct {
+	d     *xml.Decoder
+	queue []xml.Token
+	depth int // of the element in d
+	done  bool
+}
+
+func (t *bodyTokens) Token() (xml.Token, error) {
+	if len(t.queue) > 0 {
+		tok := t.queue[0]
+		t.queue = t.queue[1:]
+		return tok, nil
+	}
+	if t.depth == 0 {
+		if t.done {
+			return nil, io.EOF
+		}
+		t.done = true
+		return xml.EndElement{Name: xml.Name{Local: "Body"}}, nil
+	}
+	tok, err := t.d.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok.(type) {
+	case xml.StartElement:
+		t.depth++
+	case xml.EndElement:
+		t.depth--
+	}
+	return tok, nil
+}
+
+// queryValues returns the URL-encoded parameters of the fields of v.
+func queryValues(v Message) (url.Values, error) {
+	q := make(url.Values)
+	if v == nil {
+		return q, nil
+	}
+	return q, addQueryValues(q, "", reflect.ValueOf(v))
+}
+
+func addQueryValues(q url.Values, name string, v reflect.Value) error {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if m, ok := textMarshaler(v); ok {
+		b, err := m.MarshalText()
+		if err != nil {
+			return err
+		}
+		q.Add(name, string(b))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() || f.Name == "XMLName" {
+				continue
+			}
+			fname, opts, _ := strings.Cut(f.Tag.Get("xml"), ",")
+			if fname == "-" || strings.Contains(opts, "innerxml") || strings.Contains(opts, "comment") ||
+				strings.Contains(opts, "omitempty") && v.Field(i).IsZero() {
+				continue
+			}
+			if i := strings.LastIndexAny(fname, " :"); i >= 0 {
+				fname = fname[i+1:]
+			}
+			if fname == "" {
+				fname = f.Name
+			}
+			if strings.Contains(opts, "chardata") {
+				fname = name
+			}
+			if err := addQueryValues(q, fname, v.Field(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			q.Add(name, string(v.Bytes()))
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := addQueryValues(q, name, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.String:
+		q.Add(name, v.String())
+	case reflect.Bool:
+		q.Add(name, strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		q.Add(name, strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		q.Add(name, strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		q.Add(name, strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()))
+	default:
+		return fmt.Errorf("soap: cannot encode %s parameter %q in a query", v.Type(), name)
+	}
+	return nil
+}
+
+// textMarshaler returns v, or a pointer to it, if it implements
+// encoding.TextMarshaler, like time.Time.
+func textMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		return m, true
+	}
+	if v.CanAddr() {
+		m, ok := v.Addr().Interface().(encoding.TextMarshaler)
+		return m, ok
+	}
+	return nil, false
+}
@@ -0,0 +1,76 @@
+package soap
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRoundTripHTTPGet(t *testing.T) {
+	var query url.Values
+	var path string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "want GET", http.StatusMethodNotAllowed)
+			return
+		}
+		path, query = r.URL.Path, r.URL.Query()
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		io.WriteString(w, `<?xml version="1.0" encoding="utf-8"?>`+"\n"+`<QuoteResult xmlns="urn:quotes"><Price>12.5</Price><Currency>CHF</Currency></QuoteResult>`)
+	}))
+	defer s.Close()
+
+	type getQuote struct {
+		Symbol  string    `xml:"urn:quotes symbol"`
+		Symbols []string  `xml:"tns:alt,omitempty"`
+		Count   int       `xml:"count"`
+		Exact   bool      `xml:",omitempty"`
+		Since   time.Time `xml:"since"`
+		secret  string
+	}
+	type quoteResult struct {
+		Price    float64
+		Currency string
+	}
+	var out struct {
+		Result quoteResult `xml:"QuoteResult"`
+	}
+	c := NewClient(s.URL + "/Quotes.asmx")
+	in := &struct {
+		M getQuote `xml:"GetQuote"`
+	}{getQuote{Symbol: "YPL", Symbols: []string{"A", "B"}, Count: 3, Since: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}}
+	if err := c.RoundTripHTTPGet("/GetQuote", in, &out); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/Quotes.asmx/GetQuote" {
+		t.Errorf("unexpected path %q", path)
+	}
+	want := url.Values{"symbol": {"YPL"}, "alt": {"A", "B"}, "count": {"3"}, "since": {"2024-05-01T00:00:00Z"}}
+	if query.Encode() != want.Encode() {
+		t.Errorf("want query %s, have %s", want.Encode(), query.Encode())
+	}
+	if out.Result.Price != 12.5 || out.Result.Currency != "CHF" {
+		t.Errorf("unexpected response %+v", out)
+	}
+
+	if err := c.RoundTripHTTPGet("/GetQuote", struct{ C chan int }{}, &out); err == nil {
+		t.Error("want error for unsupported parameter type")
+	}
+}
+
+func TestHTTPOperationURL(t *testing.T) {
+	cases := []struct{ base, location, want string }{
+		{"http://h/svc.asmx", "/Op", "http://h/svc.asmx/Op"},
+		{"http://h/svc/", "Op", "http://h/svc/Op"},
+		{"http://h/svc", "", "http://h/svc"},
+		{"http://h/svc", "https://other/Op", "https://other/Op"},
+	}
+	for _, tc := range cases {
+		if have := httpOperationURL(tc.base, tc.location); have != tc.want {
+			t.Errorf("%s + %s: want %s, have %s", tc.base, tc.location, tc.want, have)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package soap
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MarshalList returns the text of the xsd:list of items, their text
+// separated by spaces, for the MarshalText methods of list types.
+func MarshalList[S ~[]T, T any](items S) ([]byte, error) {
+	var b bytes.Buffer
+	for i, item := range items {
+		s, err := FormatSimple(item)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(s)
+	}
+	return b.Bytes(), nil
+}
+
+// UnmarshalList sets items to the values of the xsd:list text, separated
+// by white space, for the UnmarshalText methods of list types.
+func UnmarshalList[S ~[]T, T any](text []byte, items *S) error {
+	fields := strings.Fields(string(text))
+	list := make(S, len(fields))
+	for i, f := range fields {
+		v, err := ParseSimple[T](f)
+		if err != nil {
+			return err
+		}
+		list[i] = v
+	}
+	*items = list
+	return nil
+}
+
+// FormatSimple returns the text of v, a value of a simple type: that of
+// its MarshalText method if it has one, else of its basic kind.
+func FormatSimple[T any](v T) (string, error) {
+	if m, ok := any(v).(encoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+		return string(b), err
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, rv.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("soap: cannot format %T as simple type", v)
+}
+
+// ParseSimple returns the value of type T of s, the text of a simple
+// type: parsed by its UnmarshalText method if it has one, else by its
+// basic kind.
+func ParseSimple[T any](s string) (T, error) {
+	var v T
+	if u, ok := any(&v).(encoding.TextUnmarshaler); ok {
+		err := u.UnmarshalText([]byte(s))
+		return v, err
+	}
+	rv := reflect.ValueOf(&v).Elem()
+	s = strings.TrimSpace(s)
+	var err error
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(s)
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		i, err = strconv.ParseInt(s, 10, rv.Type().Bits())
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		u, err = strconv.ParseUint(s, 10, rv.Type().Bits())
+		rv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(s, rv.Type().Bits())
+		rv.SetFloat(f)
+	default:
+		err = fmt.Errorf("soap: cannot parse %T as simple type", v)
+	}
+	return v, err
+}
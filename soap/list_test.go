@@ -0,0 +1,73 @@
+package soap
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+type testSize string
+
+type testSizes []testSize
+
+func (v testSizes) MarshalText() ([]byte, error) { return MarshalList(v) }
+
+func (v *testSizes) UnmarshalText(b []byte) error { return UnmarshalList(b, v) }
+
+type testCounts []int
+
+func (v testCounts) MarshalText() ([]byte, error) { return MarshalList(v) }
+
+func (v *testCounts) UnmarshalText(b []byte) error { return UnmarshalList(b, v) }
+
+func TestList(t *testing.T) {
+	type shirt struct {
+		XMLName xml.Name   `xml:"shirt"`
+		Sizes   testSizes  `xml:"sizes,attr"`
+		Counts  testCounts `xml:"counts"`
+	}
+	doc := `<shirt sizes="S M  L"><counts> 1 2
+	3 </counts></shirt>`
+	var v shirt
+	if err := xml.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := (testSizes{"S", "M", "L"}); !reflect.DeepEqual(v.Sizes, want) {
+		t.Errorf("unexpected sizes %q", v.Sizes)
+	}
+	if want := (testCounts{1, 2, 3}); !reflect.DeepEqual(v.Counts, want) {
+		t.Errorf("unexpected counts %v", v.Counts)
+	}
+	b, err := xml.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<shirt sizes="S M L"><counts>1 2 3</counts></shirt>`; string(b) != want {
+		t.Errorf("unexpected marshal\nwant: %s\nhave: %s", want, b)
+	}
+
+	if err := xml.Unmarshal([]byte(`<shirt><counts>1 two</counts></shirt>`), &v); err == nil {
+		t.Error("invalid item decoded")
+	}
+}
+
+func TestParseSimple(t *testing.T) {
+	if v, err := ParseSimple[float64]("2.5"); err != nil || v != 2.5 {
+		t.Errorf("unexpected float %v, %v", v, err)
+	}
+	if v, err := ParseSimple[bool]("1"); err != nil || !v {
+		t.Errorf("unexpected bool %v, %v", v, err)
+	}
+	if v, err := ParseSimple[testSizes]("S XL"); err != nil || len(v) != 2 {
+		t.Errorf("unexpected list %v, %v", v, err)
+	}
+	if _, err := ParseSimple[uint]("-1"); err == nil {
+		t.Error("negative uint parsed")
+	}
+	if s, err := FormatSimple(testSizes{"S", "XL"}); err != nil || s != "S XL" {
+		t.Errorf("unexpected text %q, %v", s, err)
+	}
+	if _, err := FormatSimple(struct{}{}); err == nil {
+		t.Error("struct formatted")
+	}
+}
@@ -0,0 +1,110 @@
+package soap
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// LoggingMiddleware returns a Middleware that logs every call to logger:
+// a summary at Info level, or Error level when the call fails, and the
+// request and response envelopes at Debug level. Envelopes are passed
+// through redact, if set, before being logged.
+func LoggingMiddleware(logger *slog.Logger, redact func([]byte) []byte) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(ctx context.Context, call *Call) error {
+			debug := logger.Enabled(ctx, slog.LevelDebug)
+			if debug {
+				call.Capture = true
+			}
+			start := time.Now()
+			err := next(ctx, call)
+			attrs := []slog.Attr{
+				slog.String("action", call.Action),
+				slog.String("url", call.URL),
+				slog.Int("status", call.StatusCode),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if debug {
+				logger.LogAttrs(ctx, slog.LevelDebug, "soap request",
+					slog.String("action", call.Action),
+					slog.String("envelope", string(redactEnvelope(redact, call.RequestEnvelope))))
+				logger.LogAttrs(ctx, slog.LevelDebug, "soap response",
+					slog.String("action", call.Action),
+					slog.String("envelope", string(redactEnvelope(redact, call.ResponseEnvelope))))
+			}
+			if err != nil {
+				logger.LogAttrs(ctx, slog.LevelError, "soap call failed",
+					append(attrs, slog.String("error", err.Error()))...)
+				return err
+			}
+			logger.LogAttrs(ctx, slog.LevelInfo, "soap call", attrs...)
+			return nil
+		}
+	}
+}
+
+func redactEnvelope(redact func([]byte) []byte, b []byte) []byte {
+	if redact == nil || len(b) == 0 {
+		return b
+	}
+	return redact(b)
+}
+
+// DefaultRedact redacts passwords and WS-Security headers.
+var DefaultRedact = RedactElements("Password", "Security")
+
+// RedactElements returns a redaction func that replaces the content of
+// all elements with the given local names, in any namespace, with
+// "***". Documents that are not well-formed are redacted entirely.
+func RedactElements(names ...string) func([]byte) []byte {
+	redacted := make(map[string]bool, len(names))
+	for _, name := range names {
+		redacted[name] = true
+	}
+	return func(b []byte) []byte {
+		var out bytes.Buffer
+		d := xml.NewDecoder(bytes.NewReader(b))
+		d.Strict = false
+		var last int64  // end of the output copied so far
+		var depth int   // nesting level within a redacted element
+		var from int64  // start of the redacted content
+		var name string // local name of the redacted element
+		for {
+			offset := d.InputOffset()
+			t, err := d.RawToken()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return []byte("***")
+			}
+			switch t := t.(type) {
+			case xml.StartElement:
+				if depth > 0 {
+					if t.Name.Local == name {
+						depth++
+					}
+					continue
+				}
+				if redacted[t.Name.Local] {
+					name, depth, from = t.Name.Local, 1, d.InputOffset()
+				}
+			case xml.EndElement:
+				if depth == 0 || t.Name.Local != name {
+					continue
+				}
+				if depth--; depth == 0 && offset > from {
+					out.Write(b[last:from])
+					out.WriteString("***")
+					last = offset
+				}
+			}
+		}
+		out.Write(b[last:])
+		return out.Bytes()
+	}
+}
@@ -0,0 +1,55 @@
+package soap
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoggingMiddleware(t *testing.T) {
+	type msgT struct{ A, Password string }
+	type envT struct{ msgT }
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	})
+	s := httptest.NewServer(echo)
+	defer s.Close()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := &Client{URL: s.URL, Middleware: []Middleware{LoggingMiddleware(logger, DefaultRedact)}}
+	out := &envT{}
+	if err := c.RoundTrip(&msgT{A: "hello", Password: "secret"}, out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Password != "secret" {
+		t.Fatal("redaction modified the response")
+	}
+	logs := buf.String()
+	if strings.Contains(logs, "secret") {
+		t.Fatalf("password was logged:\n%s", logs)
+	}
+	for _, want := range []string{"soap request", "soap response", "<Password>***</Password>", "msg=\"soap call\""} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("missing %q in logs:\n%s", want, logs)
+		}
+	}
+}
+
+func TestRedactElements(t *testing.T) {
+	redact := RedactElements("Password", "Security")
+	cases := map[string]string{
+		`<a><Password>x</Password></a>`:                                    `<a><Password>***</Password></a>`,
+		`<a><ns:Password xmlns:ns="urn:x">x</ns:Password><b>y</b></a>`:     `<a><ns:Password xmlns:ns="urn:x">***</ns:Password><b>y</b></a>`,
+		`<h><wsse:Security><p:Security>a</p:Security><t/></wsse:Security>`: `<h><wsse:Security>***</wsse:Security>`,
+		`<a><Password/></a>`: `<a><Password/></a>`,
+	}
+	for in, want := range cases {
+		if have := string(redact([]byte(in))); have != want {
+			t.Errorf("%s\nwant: %s\nhave: %s", in, want, have)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package soap
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"testing"
+)
+
+func TestLoopbackClient(t *testing.T) {
+	type echoRequest struct {
+		XMLName xml.Name `xml:"Echo"`
+		Data    string
+	}
+	type echoResponse struct {
+		XMLName xml.Name `xml:"EchoResponse"`
+		Data    string
+	}
+	c := NewLoopbackClient(func(ctx context.Context, req *LoopbackRequest) (Message, error) {
+		if req.Action != "urn:test/Echo" {
+			return nil, &Fault{Code: "soapenv:Client", String: "unknown action " + req.Action}
+		}
+		var in echoRequest
+		if err := req.Decode(&in); err != nil {
+			return nil, err
+		}
+		if in.Data == "" {
+			return nil, errors.New("no data")
+		}
+		return &echoResponse{Data: in.Data}, nil
+	}, WithNamespace("urn:test"), WithCompression())
+
+	out := &struct {
+		R *echoResponse `xml:"EchoResponse"`
+	}{}
+	if err := c.RoundTripWithAction("Echo", &echoRequest{Data: "hello"}, out); err != nil {
+		t.Fatal(err)
+	}
+	if out.R == nil || out.R.Data != "hello" {
+		t.Fatalf("unexpected response %#v", out.R)
+	}
+
+	var fault *Fault
+	err := c.RoundTripWithAction("Other", &echoRequest{Data: "hello"}, out)
+	if !errors.As(err, &fault) || fault.Code != "soapenv:Client" {
+		t.Fatalf("unexpected error %v", err)
+	}
+	err = c.RoundTripWithAction("Echo", &echoRequest{}, out)
+	if !errors.As(err, &fault) || fault.String != "no data" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
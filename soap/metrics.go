@@ -0,0 +1,128 @@
+package soap
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outcome classifies the result of a call.
+type Outcome string
+
+// Call outcomes reported to Metrics.
+const (
+	OutcomeSuccess   Outcome = "success"
+	OutcomeFault     Outcome = "fault"
+	OutcomeHTTPError Outcome = "http_error"
+	OutcomeError     Outcome = "error"
+)
+
+// CallMetrics are the measurements of a completed call.
+type CallMetrics struct {
+	Operation  string
+	Duration   time.Duration
+	BytesOut   int64
+	BytesIn    int64
+	StatusCode int
+	Outcome    Outcome
+}
+
+// Metrics receives the measurements of every call made through the
+// middleware returned by MetricsMiddleware. Implementations must be
+// safe for concurrent use.
+type Metrics interface {
+	ObserveCall(ctx context.Context, m *CallMetrics)
+}
+
+// MetricsFunc is an adapter to allow the use of ordinary functions as
+// Metrics.
+type MetricsFunc func(ctx context.Context, m *CallMetrics)
+
+// ObserveCall calls f(ctx, m).
+func (f MetricsFunc) ObserveCall(ctx context.Context, m *CallMetrics) {
+	f(ctx, m)
+}
+
+// MetricsMiddleware returns a Middleware that reports calls to m.
+func MetricsMiddleware(m Metrics) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(ctx context.Context, call *Call) error {
+			start := time.Now()
+			err := next(ctx, call)
+			m.ObserveCall(ctx, &CallMetrics{
+				Operation:  OperationName(call.Action),
+				Duration:   time.Since(start),
+				BytesOut:   call.RequestSize,
+				BytesIn:    call.ResponseSize,
+				StatusCode: call.StatusCode,
+				Outcome:    outcome(err),
+			})
+			return err
+		}
+	}
+}
+
+// OperationName returns the operation name of a SOAP action, which is
+// its last path or URN segment.
+func OperationName(action string) string {
+	action = strings.Trim(action, `"`)
+	if i := strings.LastIndexAny(action, "/:#"); i >= 0 {
+		return action[i+1:]
+	}
+	return action
+}
+
+func outcome(err error) Outcome {
+	var fault *Fault
+	var herr *HTTPError
+	switch {
+	case err == nil:
+		return OutcomeSuccess
+	case errors.As(err, &fault):
+		return OutcomeFault
+	case errors.As(err, &herr):
+		return OutcomeHTTPError
+	default:
+		return OutcomeError
+	}
+}
+
+// ExpvarMetrics is a Metrics implementation that publishes per-operation
+// counters with the expvar package, as a map of "operation.outcome"
+// call counts and "operation.{duration_ms,bytes_in,bytes_out}" totals.
+type ExpvarMetrics struct {
+	m *expvar.Map
+}
+
+var expvarMu sync.Mutex
+
+// NewExpvarMetrics returns an ExpvarMetrics publishing the map name. If
+// a map with that name is already published, it is reused.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	if m, ok := expvar.Get(name).(*expvar.Map); ok {
+		return &ExpvarMetrics{m: m}
+	}
+	return &ExpvarMetrics{m: expvar.NewMap(name)}
+}
+
+// ObserveCall implements the Metrics interface.
+func (e *ExpvarMetrics) ObserveCall(ctx context.Context, m *CallMetrics) {
+	op := m.Operation
+	if op == "" {
+		op = "unknown"
+	}
+	e.m.Add(op+"."+string(m.Outcome), 1)
+	e.m.Add(op+".duration_ms", m.Duration.Milliseconds())
+	e.m.Add(op+".bytes_out", m.BytesOut)
+	e.m.Add(op+".bytes_in", m.BytesIn)
+}
+
+// Map returns the published expvar map.
+func (e *ExpvarMetrics) Map() *expvar.Map {
+	return e.m
+}
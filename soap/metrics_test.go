@@ -0,0 +1,70 @@
+package soap
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	type msgT struct{ A, B string }
+	type envT struct{ msgT }
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("SOAPAction") {
+		case "urn:test/Fault":
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, `<Envelope><Body><Fault><faultcode>Server</faultcode></Fault></Body></Envelope>`)
+		case "urn:test/Down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			io.Copy(w, r.Body)
+		}
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	var mu sync.Mutex
+	var calls []*CallMetrics
+	m := MetricsFunc(func(ctx context.Context, m *CallMetrics) {
+		mu.Lock()
+		calls = append(calls, m)
+		mu.Unlock()
+	})
+	ev := NewExpvarMetrics("soap_test_calls")
+	c := NewClient(s.URL, WithNamespace("urn:test"))
+	c.Middleware = []Middleware{MetricsMiddleware(m), MetricsMiddleware(ev)}
+	want := []Outcome{OutcomeSuccess, OutcomeFault, OutcomeHTTPError}
+	for _, action := range []string{"Echo", "Fault", "Down"} {
+		c.RoundTripWithAction(action, &msgT{A: "hello"}, &envT{})
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("want %d calls, have %d", len(want), len(calls))
+	}
+	for i, m := range calls {
+		if m.Outcome != want[i] {
+			t.Errorf("call %d: want %s, have %s", i, want[i], m.Outcome)
+		}
+	}
+	if calls[0].Operation != "Echo" || calls[0].BytesOut == 0 || calls[0].BytesIn != calls[0].BytesOut {
+		t.Errorf("unexpected metrics %#v", calls[0])
+	}
+	if v := ev.Map().Get("Fault.fault"); v == nil || v.String() != "1" {
+		t.Errorf("unexpected expvar counter %v", v)
+	}
+}
+
+func TestOperationName(t *testing.T) {
+	cases := map[string]string{
+		"http://tempuri.org/IService/GetData": "GetData",
+		"urn:example:Echo":                    "Echo",
+		`"Echo"`:                              "Echo",
+		"":                                    "",
+	}
+	for action, want := range cases {
+		if have := OperationName(action); have != want {
+			t.Errorf("%q: want %q, have %q", action, want, have)
+		}
+	}
+}
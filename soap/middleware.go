@@ -0,0 +1,52 @@
+package soap
+
+import "context"
+
+// Call describes a single SOAP round trip as it passes through the
+// client's middleware chain. Middleware may modify the request fields
+// before calling the next handler.
+type Call struct {
+	Action string  // SOAPAction header, or the SOAP 1.2 action parameter
+	URL    string  // Endpoint URL
+	Header Message // SOAP Header element, initially the client's Header
+	In     Message
+	Out    Message
+
+	// ResponseHeader, if not nil, is decoded from the SOAP Header
+	// element of the response, as Out is from the Body.
+	ResponseHeader Message
+
+	// Capture asks the client to record the raw envelopes of the
+	// exchange, before compression, in RequestEnvelope and
+	// ResponseEnvelope. The response envelope holds the bytes read by
+	// the decoder, or the error body of non-200 responses.
+	Capture          bool
+	RequestEnvelope  []byte
+	ResponseEnvelope []byte
+
+	// RequestFilters rewrite the encoded request envelope, in order,
+	// before it is sent. ResponseFilters rewrite the envelopes of
+	// successful responses, in reverse order, before they are decoded.
+	// Captured envelopes are those on the wire for requests, and those
+	// decoded for responses.
+	RequestFilters  []EnvelopeFilter
+	ResponseFilters []EnvelopeFilter
+
+	// Set by the client during the HTTP exchange. Sizes are the number
+	// of bytes on the wire, after compression.
+	StatusCode   int
+	RequestSize  int64
+	ResponseSize int64
+}
+
+// An EnvelopeFilter rewrites a raw SOAP envelope, e.g. to encrypt or
+// decrypt its body.
+type EnvelopeFilter func(env []byte) ([]byte, error)
+
+// A RoundTripFunc performs the SOAP call described by call.
+type RoundTripFunc func(ctx context.Context, call *Call) error
+
+// Middleware wraps a RoundTripFunc with additional behavior, such as
+// tracing or metrics. To add HTTP headers to the request, pass them
+// down with ContextWithHTTPHeader.
+type Middleware func(next RoundTripFunc) RoundTripFunc
@@ -0,0 +1,212 @@
+package soap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// XOPNamespace is the namespace of the xop:Include elements referring
+// to the attachments of MTOM messages.
+const XOPNamespace = "http://www.w3.org/2004/08/xop/include"
+
+// Binary is the value of a base64Binary element. It is encoded in
+// base64, or sent as an MTOM attachment in the responses of a Server
+// answering with MTOM. Attachments of MTOM messages received by clients
+// and servers are decoded into it, and into []byte, alike.
+type Binary []byte
+
+// mtomEncoders holds the attachments of the MTOM messages being encoded,
+// by their *xml.Encoder.
+var mtomEncoders sync.Map
+
+// MarshalXML implements the xml.Marshaler interface.
+func (b Binary) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	parts, ok := mtomEncoders.Load(e)
+	if !ok {
+		return e.EncodeElement(base64.StdEncoding.EncodeToString(b), start)
+	}
+	include := xml.StartElement{
+		Name: xml.Name{Local: "xop:Include"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "xmlns:xop"}, Value: XOPNamespace},
+			{Name: xml.Name{Local: "href"}, Value: "cid:" + url.PathEscape(parts.(*mtomParts).add(b))},
+		},
+	}
+	for _, t := range []xml.Token{start, include, include.End(), start.End()} {
+		if err := e.EncodeToken(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalXML implements the xml.Unmarshaler interface.
+func (b *Binary) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var s string
+	if err := d.DecodeElement(&s, &start); err != nil {
+		return err
+	}
+	v, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+// mtomPart is an attachment of an MTOM message.
+type mtomPart struct {
+	id   string
+	data []byte
+}
+
+// mtomParts collects the attachments of an MTOM message.
+type mtomParts struct {
+	prefix string
+	parts  []mtomPart
+}
+
+func newMTOMParts() *mtomParts {
+	var b [8]byte
+	rand.Read(b[:])
+	return &mtomParts{prefix: hex.EncodeToString(b[:])}
+}
+
+// add adds the attachment data, and returns its content ID.
+func (p *mtomParts) add(data []byte) string {
+	id := fmt.Sprintf("%d.%s@wsdl2go", len(p.parts)+1, p.prefix)
+	p.parts = append(p.parts, mtomPart{id: id, data: data})
+	return id
+}
+
+// encodeMTOM encodes v, whose Binary values are sent as attachments,
+// and returns the MTOM message and its content type. The root part has
+// the type of the SOAP version, such as text/xml.
+func encodeMTOM(v any, soapType string) ([]byte, string, error) {
+	var root bytes.Buffer
+	root.WriteString(xml.Header)
+	e := xml.NewEncoder(&root)
+	parts := newMTOMParts()
+	mtomEncoders.Store(e, parts)
+	defer mtomEncoders.Delete(e)
+	if err := e.Encode(v); err != nil {
+		return nil, "", err
+	}
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	rootID := "<root." + parts.prefix + "@wsdl2go>"
+	pw, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {fmt.Sprintf("application/xop+xml; charset=UTF-8; type=%q", soapType)},
+		"Content-Transfer-Encoding": {"8bit"},
+		"Content-Id":                {rootID},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	pw.Write(root.Bytes())
+	for _, p := range parts.parts {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {"application/octet-stream"},
+			"Content-Transfer-Encoding": {"binary"},
+			"Content-Id":                {"<" + p.id + ">"},
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		pw.Write(p.data)
+	}
+	if err := mw.Close(); err != nil {
+		return nil, "", err
+	}
+	ct := mime.FormatMediaType("multipart/related", map[string]string{
+		"type":       "application/xop+xml",
+		"boundary":   mw.Boundary(),
+		"start":      rootID,
+		"start-info": soapType,
+	})
+	return b.Bytes(), ct, nil
+}
+
+var xopIncludeExpr = regexp.MustCompile(`<([\w.-]+:)?Include\b[^>]*?\bhref\s*=\s*["']cid:([^"']*)["'][^>]*?(?:/>|>\s*</([\w.-]+:)?Include\s*>)`)
+
+// decodeMTOM returns the envelope of the MTOM message b of the
+// multipart/related content type ct, with the xop:Include elements
+// replaced by the base64 of the attachments they refer to.
+func decodeMTOM(ct string, b []byte) ([]byte, error) {
+	_, params, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return nil, err
+	}
+	mr := multipart.NewReader(bytes.NewReader(b), params["boundary"])
+	start := strings.Trim(params["start"], "<>")
+	var root []byte
+	parts := make(map[string][]byte)
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(p)
+		if err != nil {
+			return nil, err
+		}
+		id := strings.Trim(p.Header.Get("Content-Id"), "<>")
+		if root == nil && (start == "" || id == start) {
+			root = data
+			continue
+		}
+		parts[id] = data
+	}
+	if root == nil {
+		return nil, errors.New("soap: MTOM message without root part")
+	}
+	var missing error
+	env := xopIncludeExpr.ReplaceAllFunc(root, func(m []byte) []byte {
+		ref := string(xopIncludeExpr.FindSubmatch(m)[2])
+		if id, err := url.PathUnescape(ref); err == nil {
+			ref = id
+		}
+		data, ok := parts[ref]
+		if !ok {
+			missing = fmt.Errorf("soap: MTOM attachment %q not found", ref)
+			return m
+		}
+		return []byte(base64.StdEncoding.EncodeToString(data))
+	})
+	return env, missing
+}
+
+// mtomBody returns the envelope of MTOM responses, with the attachments
+// inlined, or else body.
+func mtomBody(resp *http.Response, body io.Reader) (io.Reader, error) {
+	ct := resp.Header.Get("Content-Type")
+	if mt, _, _ := mime.ParseMediaType(ct); mt != "multipart/related" {
+		return body, nil
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	env, err := decodeMTOM(ct, b)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(env), nil
+}
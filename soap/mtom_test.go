@@ -0,0 +1,164 @@
+package soap
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fileRequest struct {
+	Name string `xml:"name"`
+	Data Binary `xml:"data"`
+}
+
+type fileResponse struct {
+	Size int    `xml:"size"`
+	Data Binary `xml:"data"`
+}
+
+func TestServerMTOM(t *testing.T) {
+	srv := NewServer()
+	srv.HandleFunc("Upload", "", xml.Name{Local: "fileRequest"}, func(ctx context.Context, r *Request) (Message, error) {
+		var in fileRequest
+		if err := r.Decode(&in); err != nil {
+			return nil, err
+		}
+		return &fileResponse{Size: len(in.Data), Data: in.Data}, nil
+	})
+	s := httptest.NewServer(srv)
+	defer s.Close()
+
+	data := []byte("\x00\x01binary\xff")
+	const root = `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><fileRequest>` +
+		`<name>f</name><data><xop:Include xmlns:xop="http://www.w3.org/2004/08/xop/include" href="cid:file%401"/></data>` +
+		`</fileRequest></s:Body></s:Envelope>`
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	pw, _ := mw.CreatePart(map[string][]string{
+		"Content-Type": {`application/xop+xml; charset=UTF-8; type="text/xml"`},
+		"Content-Id":   {"<root@1>"},
+	})
+	pw.Write([]byte(root))
+	pw, _ = mw.CreatePart(map[string][]string{"Content-Id": {"<file@1>"}})
+	pw.Write(data)
+	mw.Close()
+	ct := mime.FormatMediaType("multipart/related", map[string]string{
+		"type": "application/xop+xml", "boundary": mw.Boundary(), "start": "<root@1>", "start-info": "text/xml",
+	})
+	resp, err := http.Post(s.URL, ct, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	mt, params, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if resp.StatusCode != http.StatusOK || mt != "multipart/related" || params["start-info"] != "text/xml" {
+		t.Fatalf("unexpected response %d %s: %s", resp.StatusCode, resp.Header.Get("Content-Type"), body)
+	}
+	if !strings.Contains(string(body), "<size>9</size>") || !strings.Contains(string(body), "<xop:Include") {
+		t.Fatalf("attachment not sent as xop:Include: %s", body)
+	}
+	env, err := decodeMTOM(resp.Header.Get("Content-Type"), body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Body struct {
+			Response fileResponse `xml:"fileResponse"`
+		}
+	}
+	if err := xml.Unmarshal(env, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Body.Response.Data, data) {
+		t.Fatalf("attachment %q, want %q", out.Body.Response.Data, data)
+	}
+
+	c := NewClient(s.URL)
+	var reply struct {
+		Msg fileResponse `xml:"fileResponse"`
+	}
+	if err := c.RoundTrip(&struct {
+		M fileRequest `xml:"fileRequest"`
+	}{fileRequest{Name: "f", Data: data}}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(reply.Msg.Data, data) {
+		t.Fatalf("base64 request: response %q, want %q", reply.Msg.Data, data)
+	}
+
+	srv.MTOM = true
+	reply.Msg = fileResponse{}
+	if err := c.RoundTrip(&struct {
+		M fileRequest `xml:"fileRequest"`
+	}{fileRequest{Name: "f", Data: data}}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(reply.Msg.Data, data) {
+		t.Fatalf("MTOM response: %q, want %q", reply.Msg.Data, data)
+	}
+}
+
+func TestDecodeMTOM(t *testing.T) {
+	const ct = `multipart/related; boundary=b; type="application/xop+xml"`
+	msg := "--b\r\nContent-Id: <root>\r\n\r\n<a><xop:Include href=\"cid:missing\"/></a>\r\n--b--\r\n"
+	if _, err := decodeMTOM(ct, []byte(msg)); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestClientMTOMMaxResponseBytes(t *testing.T) {
+	// The envelope is small, the response large of an attachment it
+	// doesn't refer to.
+	const root = `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>` +
+		`<fileResponse><size>1</size></fileResponse></s:Body></s:Envelope>`
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mw := multipart.NewWriter(w)
+		w.Header().Set("Content-Type", mime.FormatMediaType("multipart/related", map[string]string{
+			"type": "application/xop+xml", "boundary": mw.Boundary(), "start": "<root@1>", "start-info": "text/xml",
+		}))
+		pw, _ := mw.CreatePart(map[string][]string{
+			"Content-Type": {`application/xop+xml; charset=UTF-8; type="text/xml"`},
+			"Content-Id":   {"<root@1>"},
+		})
+		io.WriteString(pw, root)
+		pw, _ = mw.CreatePart(map[string][]string{"Content-Id": {"<file@1>"}})
+		pw.Write(make([]byte, 64<<10))
+		mw.Close()
+	}))
+	defer s.Close()
+	cases := []struct {
+		Max  int64
+		Fail bool
+	}{
+		{Max: 0},
+		{Max: 1 << 20},
+		{Max: 4096, Fail: true},
+	}
+	for i, tc := range cases {
+		c := &Client{URL: s.URL, MaxResponseBytes: tc.Max}
+		var reply struct {
+			Msg fileResponse `xml:"fileResponse"`
+		}
+		err := c.RoundTrip(&struct {
+			M fileRequest `xml:"fileRequest"`
+		}{fileRequest{Name: "f"}}, &reply)
+		if tc.Fail {
+			if !errors.Is(err, ErrResponseTooLarge) {
+				t.Errorf("test %d: want %v, have %v", i, ErrResponseTooLarge, err)
+			}
+			continue
+		}
+		if err != nil || reply.Msg.Size != 1 {
+			t.Errorf("test %d: size %d, %v", i, reply.Msg.Size, err)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package soap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// errMultiRefDepth is returned for multiRef elements that reference each
+// other in a cycle.
+var errMultiRefDepth = errors.New("soap: multiRef references nested too deeply")
+
+const maxMultiRefDepth = 64
+
+// refNode is an element of a response envelope, with its byte offsets.
+type refNode struct {
+	name       xml.Name // raw name, with prefix
+	attr       []xml.Attr
+	start      int64 // offset of the start tag
+	innerStart int64 // offset after the start tag
+	innerEnd   int64 // offset of the end tag
+	end        int64 // offset after the end tag
+	children   []*refNode
+}
+
+func (n *refNode) attrValue(local string) (string, bool) {
+	for _, a := range n.attr {
+		if a.Name.Space == "" && a.Name.Local == local {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// resolveMultiRefs inlines the elements referenced by href="#id"
+// attributes, as sent by SOAP RPC/encoded services such as Apache Axis,
+// and drops the top-level multiRef elements of the Body. Documents
+// without references are returned unchanged.
+func resolveMultiRefs(b []byte) ([]byte, error) {
+	if !bytes.Contains(b, []byte("href")) {
+		return b, nil
+	}
+	root, err := parseRefTree(b)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]*refNode)
+	var index func(n *refNode)
+	index = func(n *refNode) {
+		if id, ok := n.attrValue("id"); ok {
+			ids[id] = n
+		}
+		for _, c := range n.children {
+			index(c)
+		}
+	}
+	index(root)
+	r := &refResolver{b: b, ids: ids}
+	// The multiRef targets are children of Body; they are dropped from
+	// the output once inlined.
+	for _, body := range root.children {
+		if body.name.Local != "Body" {
+			continue
+		}
+		for _, c := range body.children {
+			if _, ok := c.attrValue("id"); ok {
+				r.drop = append(r.drop, c)
+			}
+		}
+	}
+	var out bytes.Buffer
+	out.Write(b[:root.start])
+	if err := r.render(&out, root, 0); err != nil {
+		return nil, err
+	}
+	out.Write(b[root.end:])
+	return out.Bytes(), nil
+}
+
+func parseRefTree(b []byte) (*refNode, error) {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	d.Strict = false
+	var stack []*refNode
+	var root *refNode
+	for {
+		off := d.InputOffset()
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &refNode{name: t.Name, attr: t.Copy().Attr, start: off, innerStart: d.InputOffset()}
+			if len(stack) > 0 {
+				p := stack[len(stack)-1]
+				p.children = append(p.children, n)
+			} else if root == nil {
+				root = n
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return nil, errors.New("soap: unexpected end element in response")
+			}
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			n.innerEnd, n.end = off, d.InputOffset()
+			if n.end == n.innerStart {
+				// self-closing element
+				n.innerEnd = n.innerStart
+			}
+		}
+	}
+	if root == nil || len(stack) > 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return root, nil
+}
+
+type refResolver struct {
+	b    []byte
+	ids  map[string]*refNode
+	drop []*refNode
+}
+
+func (r *refResolver) dropped(n *refNode) bool {
+	for _, d := range r.drop {
+		if d == n {
+			return true
+		}
+	}
+	return false
+}
+
+// render writes n, inlining referenced elements.
+func (r *refResolver) render(w *bytes.Buffer, n *refNode, depth int) error {
+	if depth > maxMultiRefDepth {
+		return errMultiRefDepth
+	}
+	if href, ok := n.attrValue("href"); ok && strings.HasPrefix(href, "#") {
+		if target, ok := r.ids[href[1:]]; ok {
+			return r.renderRef(w, n, target, depth)
+		}
+	}
+	w.Write(r.b[n.start:n.innerStart])
+	if err := r.renderInner(w, n, depth); err != nil {
+		return err
+	}
+	w.Write(r.b[n.innerEnd:n.end])
+	return nil
+}
+
+func (r *refResolver) renderInner(w *bytes.Buffer, n *refNode, depth int) error {
+	pos := n.innerStart
+	for _, c := range n.children {
+		w.Write(r.b[pos:c.start])
+		pos = c.end
+		if r.dropped(c) {
+			continue
+		}
+		if err := r.render(w, c, depth+1); err != nil {
+			return err
+		}
+	}
+	w.Write(r.b[pos:n.innerEnd])
+	return nil
+}
+
+// renderRef writes the referencing element n with the attributes and
+// content of target.
+func (r *refResolver) renderRef(w *bytes.Buffer, n, target *refNode, depth int) error {
+	name := rawName(n.name)
+	w.WriteString("<" + name)
+	seen := make(map[xml.Name]bool)
+	for _, attrs := range [][]xml.Attr{n.attr, target.attr} {
+		for _, a := range attrs {
+			if (a.Name.Space == "" && (a.Name.Local == "href" || a.Name.Local == "id")) || seen[a.Name] {
+				continue
+			}
+			seen[a.Name] = true
+			w.WriteString(" " + rawName(a.Name) + `="`)
+			xml.EscapeText(w, []byte(a.Value))
+			w.WriteString(`"`)
+		}
+	}
+	w.WriteString(">")
+	if err := r.renderInner(w, target, depth+1); err != nil {
+		return err
+	}
+	w.WriteString("</" + name + ">")
+	return nil
+}
+
+func rawName(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
@@ -0,0 +1,75 @@
+package soap
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const axisResponse = `<?xml version="1.0" encoding="utf-8"?>
+<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+<soapenv:Body>
+<ns1:getUserResponse xmlns:ns1="urn:users"><getUserReturn href="#id0"/></ns1:getUserResponse>
+<multiRef id="id0" xsi:type="ns2:User" xmlns:ns2="urn:users"><name>Ann</name><address href="#id1"/><tags><tag>a</tag><tag>b</tag></tags></multiRef>
+<multiRef id="id1" xsi:type="ns3:Address" xmlns:ns3="urn:users"><city>Zürich &amp; Bern</city></multiRef>
+</soapenv:Body>
+</soapenv:Envelope>`
+
+func TestResolveMultiRefs(t *testing.T) {
+	have, err := resolveMultiRefs([]byte(axisResponse))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<?xml version="1.0" encoding="utf-8"?>
+<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+<soapenv:Body>
+<ns1:getUserResponse xmlns:ns1="urn:users"><getUserReturn xsi:type="ns2:User" xmlns:ns2="urn:users"><name>Ann</name><address xsi:type="ns3:Address" xmlns:ns3="urn:users"><city>Zürich &amp; Bern</city></address><tags><tag>a</tag><tag>b</tag></tags></getUserReturn></ns1:getUserResponse>
+
+
+</soapenv:Body>
+</soapenv:Envelope>`
+	if string(have) != want {
+		t.Fatalf("unexpected document\nwant: %s\nhave: %s", want, have)
+	}
+
+	same := []byte(`<Envelope><Body><a>1</a></Body></Envelope>`)
+	if have, _ := resolveMultiRefs(same); string(have) != string(same) {
+		t.Fatalf("document without references changed: %s", have)
+	}
+
+	cycle := `<Envelope><Body><r href="#a"/><multiRef id="a"><x href="#a"/></multiRef></Body></Envelope>`
+	if _, err := resolveMultiRefs([]byte(cycle)); !errors.Is(err, errMultiRefDepth) {
+		t.Fatalf("unexpected error for cycle: %v", err)
+	}
+}
+
+func TestRoundTripResolveMultiRefs(t *testing.T) {
+	type address struct {
+		City string `xml:"city"`
+	}
+	type user struct {
+		Name    string   `xml:"name"`
+		Address *address `xml:"address"`
+		Tags    []string `xml:"tags>tag"`
+	}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, axisResponse)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	c := NewClient(s.URL, WithResolveMultiRefs())
+	out := struct {
+		M struct {
+			User *user `xml:"getUserReturn"`
+		} `xml:"getUserResponse"`
+	}{}
+	if err := c.RoundTrip(&struct{}{}, &out); err != nil {
+		t.Fatal(err)
+	}
+	u := out.M.User
+	if u == nil || u.Name != "Ann" || u.Address == nil || u.Address.City != "Zürich & Bern" || len(u.Tags) != 2 {
+		t.Fatalf("unexpected response %#v", u)
+	}
+}
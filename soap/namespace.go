@@ -0,0 +1,135 @@
+package soap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// NamespaceCollector is implemented by messages that reference namespaces
+// not visible in their struct tags, such as in raw XML or QName values.
+type NamespaceCollector interface {
+	XMLNamespaces() []string
+}
+
+var xmlNameType = reflect.TypeOf(xml.Name{})
+
+// collectNamespaces returns the namespace URIs referenced by the struct
+// tags and XMLName values of v, or reported by NamespaceCollector
+// implementations within v, sorted.
+func collectNamespaces(v any) []string {
+	seen := make(map[string]bool)
+	visited := make(map[uintptr]bool)
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		if !v.IsValid() {
+			return
+		}
+		if v.CanInterface() {
+			if nc, ok := v.Interface().(NamespaceCollector); ok && (v.Kind() != reflect.Pointer || !v.IsNil()) {
+				for _, ns := range nc.XMLNamespaces() {
+					seen[ns] = true
+				}
+			}
+		}
+		switch v.Kind() {
+		case reflect.Interface:
+			walk(v.Elem())
+		case reflect.Pointer:
+			if v.IsNil() || visited[v.Pointer()] {
+				return
+			}
+			visited[v.Pointer()] = true
+			walk(v.Elem())
+		case reflect.Slice, reflect.Array:
+			if v.Type().Elem().Kind() == reflect.Uint8 {
+				return
+			}
+			for i := 0; i < v.Len(); i++ {
+				walk(v.Index(i))
+			}
+		case reflect.Struct:
+			t := v.Type()
+			for i := 0; i < t.NumField(); i++ {
+				f := t.Field(i)
+				if ns := tagNamespace(f.Tag.Get("xml")); ns != "" {
+					seen[ns] = true
+				}
+				if f.Type == xmlNameType {
+					if ns := v.Field(i).Interface().(xml.Name).Space; ns != "" {
+						seen[ns] = true
+					}
+					continue
+				}
+				walk(v.Field(i))
+			}
+		}
+	}
+	walk(reflect.ValueOf(v))
+	nss := make([]string, 0, len(seen))
+	for ns := range seen {
+		nss = append(nss, ns)
+	}
+	sort.Strings(nss)
+	return nss
+}
+
+// tagNamespace returns the namespace of an xml struct tag of the form
+// "namespace-URL name,flags".
+func tagNamespace(tag string) string {
+	name, _, _ := strings.Cut(tag, ",")
+	if i := strings.LastIndex(name, " "); i > 0 {
+		return strings.TrimSpace(name[:i])
+	}
+	return ""
+}
+
+// namespaceDeclarations returns the xmlns declarations for the
+// namespaces referenced by in that are not already declared on env,
+// keyed by prefix. Prefixes are taken from the client's Namespaces, or
+// assigned as ns1, ns2, ... in the order of the sorted URIs.
+func (c *Client) namespaceDeclarations(env *Envelope, in Message) map[string]string {
+	p := env.Prefixes.withDefaults()
+	declared := map[string]bool{env.EnvelopeAttr: true, env.NSAttr: true}
+	used := map[string]bool{p.Envelope: true, p.This: true, p.URN: true, p.XSI: true}
+	for _, ns := range []string{env.TNSAttr, env.URNAttr, env.XSIAttr} {
+		if ns != "" {
+			declared[ns] = true
+		}
+	}
+	known := make(map[string]string)
+	for prefix, ns := range c.Namespaces {
+		if p, ok := known[ns]; !ok || prefix < p {
+			known[ns] = prefix
+		}
+	}
+	decls := make(map[string]string)
+	var pending []string
+	for _, ns := range collectNamespaces(in) {
+		if declared[ns] {
+			continue
+		}
+		if prefix, ok := known[ns]; ok && !used[prefix] {
+			decls[prefix] = ns
+			used[prefix] = true
+			continue
+		}
+		pending = append(pending, ns)
+	}
+	n := 0
+	for _, ns := range pending {
+		var prefix string
+		for {
+			n++
+			prefix = fmt.Sprintf("ns%d", n)
+			if !used[prefix] {
+				break
+			}
+		}
+		decls[prefix] = ns
+		used[prefix] = true
+	}
+	return decls
+}
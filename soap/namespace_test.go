@@ -0,0 +1,60 @@
+package soap
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type nsItem struct {
+	Value string `xml:"urn:b Value"`
+}
+
+type nsRequest struct {
+	XMLName xml.Name `xml:"urn:a Request"`
+	Items   []*nsItem
+	Any     any
+	Self    *nsRequest
+}
+
+type qnameValue string
+
+func (qnameValue) XMLNamespaces() []string { return []string{"urn:q"} }
+
+func TestCollectNamespaces(t *testing.T) {
+	req := &nsRequest{
+		Items: []*nsItem{{Value: "x"}},
+		Any:   struct{ Q qnameValue }{Q: "q:v"},
+	}
+	req.Self = req
+	want := []string{"urn:a", "urn:b", "urn:q"}
+	if have := collectNamespaces(req); !reflect.DeepEqual(have, want) {
+		t.Fatalf("unexpected namespaces\nwant: %q\nhave: %q", want, have)
+	}
+}
+
+func TestRoundTripCollectNamespaces(t *testing.T) {
+	var env string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		env = string(b)
+		io.WriteString(w, `<Envelope><Body/></Envelope>`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	c := NewClient(s.URL,
+		WithNamespace("urn:a"),
+		WithCollectNamespaces(map[string]string{"tns1": "urn:q"}),
+	)
+	req := &nsRequest{Items: []*nsItem{{Value: "x"}}, Any: qnameValue("tns1:v")}
+	if err := c.RoundTrip(req, &struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(env, `xmlns:ns1="urn:b" xmlns:tns1="urn:q">`) || strings.Contains(env, `xmlns:ns2`) {
+		t.Fatalf("unexpected envelope %s", env)
+	}
+}
@@ -0,0 +1,93 @@
+package soap
+
+import (
+	"encoding/json"
+	"encoding/xml"
+)
+
+// Nillable is the value of a nillable element, for servers that tell an
+// absent element from a nil one. A nil Value is sent as the empty element
+// <elem xsi:nil="true"/>, rather than omitted as nil pointers are, and
+// elements with xsi:nil="true" are decoded as a nil Value:
+//
+//	type Person struct {
+//		Name     string                 `xml:"name"`
+//		Birthday soap.Nillable[string]  `xml:"birthday"`         // nil if unknown
+//		Spouse   *soap.Nillable[string] `xml:"spouse,omitempty"` // absent if nil
+//	}
+//
+// Code generated by wsdl2go with the -nillable flag uses it for the
+// elements declared nillable.
+type Nillable[T any] struct {
+	Value *T
+}
+
+// NewNillable returns the Nillable of v.
+func NewNillable[T any](v T) Nillable[T] {
+	return Nillable[T]{Value: &v}
+}
+
+// IsNil reports whether Value is nil.
+func (n Nillable[T]) IsNil() bool {
+	return n.Value == nil
+}
+
+// MarshalXML implements the xml.Marshaler interface.
+func (n Nillable[T]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if n.Value != nil {
+		return e.EncodeElement(n.Value, start)
+	}
+	return EncodeNil(e, start)
+}
+
+// UnmarshalXML implements the xml.Unmarshaler interface.
+func (n *Nillable[T]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if IsNil(start) {
+		n.Value = nil
+		return d.Skip()
+	}
+	v := new(T)
+	if err := d.DecodeElement(v, &start); err != nil {
+		return err
+	}
+	n.Value = v
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding a nil
+// Value as null.
+func (n Nillable[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.Value)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (n *Nillable[T]) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, &n.Value)
+}
+
+// EncodeNil encodes the element start as nil, with xsi:nil="true", for
+// custom marshalers of nillable elements. The xsi prefix is declared on
+// the element itself.
+func EncodeNil(e *xml.Encoder, start xml.StartElement) error {
+	start.Attr = append(start.Attr,
+		xml.Attr{Name: xml.Name{Local: "xmlns:xsi"}, Value: XSINamespace},
+		xml.Attr{Name: xml.Name{Local: "xsi:nil"}, Value: "true"},
+	)
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	return e.EncodeToken(start.End())
+}
+
+// IsNil reports whether the element start has the attribute
+// xsi:nil="true", for custom unmarshalers of nillable elements. The
+// attribute is also recognized with an undeclared xsi prefix, as
+// decoded with LenientXML.
+func IsNil(start xml.StartElement) bool {
+	for _, a := range start.Attr {
+		if a.Name.Local == "nil" && (a.Name.Space == XSINamespace || a.Name.Space == "xsi") {
+			return a.Value == "true" || a.Value == "1"
+		}
+	}
+	return false
+}
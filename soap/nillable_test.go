@@ -0,0 +1,106 @@
+package soap
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestNillable(t *testing.T) {
+	type person struct {
+		XMLName  xml.Name                        `xml:"person"`
+		Name     string                          `xml:"name"`
+		Age      Nillable[int]                   `xml:"age"`
+		Spouse   *Nillable[string]               `xml:"spouse,omitempty"`
+		Children []Nillable[string]              `xml:"child"`
+		Address  Nillable[struct{ City string }] `xml:"address"`
+	}
+	cases := []struct {
+		In   person
+		Want string
+	}{
+		{
+			In:   person{Name: "a"},
+			Want: `<person><name>a</name><age xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:nil="true"></age><address xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:nil="true"></address></person>`,
+		},
+		{
+			In: person{
+				Name:     "b",
+				Age:      NewNillable(42),
+				Spouse:   &Nillable[string]{},
+				Children: []Nillable[string]{NewNillable("c"), {}},
+				Address:  NewNillable(struct{ City string }{"Zurich"}),
+			},
+			Want: `<person><name>b</name><age>42</age><spouse xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:nil="true"></spouse>` +
+				`<child>c</child><child xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:nil="true"></child><address><City>Zurich</City></address></person>`,
+		},
+	}
+	for i, tc := range cases {
+		b, err := xml.Marshal(&tc.In)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if string(b) != tc.Want {
+			t.Fatalf("test %d: unexpected XML\nwant: %s\nhave: %s", i, tc.Want, b)
+		}
+		var out person
+		if err := xml.Unmarshal(b, &out); err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if out.Age.IsNil() != tc.In.Age.IsNil() || (out.Spouse == nil) != (tc.In.Spouse == nil) ||
+			len(out.Children) != len(tc.In.Children) || out.Address.IsNil() != tc.In.Address.IsNil() {
+			t.Fatalf("test %d: decoded %+v, want %+v", i, out, tc.In)
+		}
+	}
+	var out person
+	if err := xml.Unmarshal([]byte(`<person><age xsi:nil="1"/><child>x</child><child xsi:nil="true"/></person>`), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.Age.IsNil() || len(out.Children) != 2 || *out.Children[0].Value != "x" || !out.Children[1].IsNil() {
+		t.Fatalf("unexpected %+v", out)
+	}
+}
+
+func TestIsNil(t *testing.T) {
+	cases := []struct {
+		Doc  string
+		Want bool
+	}{
+		{`<a xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:nil="true"/>`, true},
+		{`<a xmlns:i="http://www.w3.org/2001/XMLSchema-instance" i:nil="1"/>`, true},
+		{`<a xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:nil="false"/>`, false},
+		{`<a nil="true"/>`, false},
+		{`<a/>`, false},
+	}
+	for i, tc := range cases {
+		tok, err := xml.NewDecoder(strings.NewReader(tc.Doc)).Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have := IsNil(tok.(xml.StartElement)); have != tc.Want {
+			t.Errorf("test %d: IsNil = %v, want %v", i, have, tc.Want)
+		}
+	}
+}
+
+func TestNillableJSON(t *testing.T) {
+	type person struct {
+		Age  Nillable[int]
+		Name Nillable[string]
+	}
+	b, err := json.Marshal(person{Age: NewNillable(3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"Age":3,"Name":null}`; string(b) != want {
+		t.Fatalf("have %s, want %s", b, want)
+	}
+	var p person
+	if err := json.Unmarshal([]byte(`{"Age":null,"Name":"x"}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Age.IsNil() || p.Name.IsNil() || *p.Name.Value != "x" {
+		t.Fatalf("unexpected %+v", p)
+	}
+}
@@ -0,0 +1,70 @@
+package soap
+
+import (
+	"encoding/json"
+	"encoding/xml"
+)
+
+// Optional is the value of an optional element, of minOccurs="0", which
+// tells an absent element from an empty one. The element is omitted
+// unless Present, even if Value is its zero value:
+//
+//	type Person struct {
+//		Name  string                `xml:"name"`
+//		Title soap.Optional[string] `xml:"title,omitempty"` // absent unless Present
+//	}
+//
+// Code generated by wsdl2go with -optional=wrapper uses it for the
+// optional elements of simple types.
+type Optional[T any] struct {
+	Value   T
+	Present bool
+}
+
+// NewOptional returns the Optional of v, present.
+func NewOptional[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, Present: true}
+}
+
+// MarshalXML implements the xml.Marshaler interface, encoding nothing
+// unless o is present.
+func (o Optional[T]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if !o.Present {
+		return nil
+	}
+	return e.EncodeElement(o.Value, start)
+}
+
+// UnmarshalXML implements the xml.Unmarshaler interface.
+func (o *Optional[T]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var v T
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	o.Value, o.Present = v, true
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding o as
+// null unless it is present.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Present {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, null being
+// absent.
+func (o *Optional[T]) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*o = Optional[T]{}
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	o.Value, o.Present = v, true
+	return nil
+}
@@ -0,0 +1,69 @@
+package soap
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+)
+
+func TestOptional(t *testing.T) {
+	type person struct {
+		XMLName xml.Name                        `xml:"person"`
+		Title   Optional[string]                `xml:"title,omitempty"`
+		Age     Optional[int]                   `xml:"age,omitempty"`
+		Spouse  Optional[Nillable[string]]      `xml:"spouse,omitempty"`
+		Address Optional[struct{ City string }] `xml:"address,omitempty"`
+	}
+	cases := []struct {
+		In   person
+		Want string
+	}{
+		{person{}, `<person></person>`},
+		{
+			person{Title: NewOptional(""), Age: NewOptional(0), Spouse: NewOptional(Nillable[string]{})},
+			`<person><title></title><age>0</age><spouse xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:nil="true"></spouse></person>`,
+		},
+		{
+			person{Age: NewOptional(42), Address: NewOptional(struct{ City string }{"Zurich"})},
+			`<person><age>42</age><address><City>Zurich</City></address></person>`,
+		},
+	}
+	for i, tc := range cases {
+		b, err := xml.Marshal(&tc.In)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if string(b) != tc.Want {
+			t.Fatalf("test %d: unexpected XML\nwant: %s\nhave: %s", i, tc.Want, b)
+		}
+		var out person
+		if err := xml.Unmarshal(b, &out); err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		out.XMLName = tc.In.XMLName
+		if out != tc.In {
+			t.Fatalf("test %d: decoded %+v, want %+v", i, out, tc.In)
+		}
+	}
+}
+
+func TestOptionalJSON(t *testing.T) {
+	type person struct {
+		Age  Optional[int]
+		Name Optional[string]
+	}
+	b, err := json.Marshal(person{Age: NewOptional(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"Age":0,"Name":null}`; string(b) != want {
+		t.Fatalf("have %s, want %s", b, want)
+	}
+	var p person
+	if err := json.Unmarshal([]byte(`{"Age":null,"Name":""}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Age.Present || !p.Name.Present || p.Name.Value != "" {
+		t.Fatalf("unexpected %+v", p)
+	}
+}
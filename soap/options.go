@@ -0,0 +1,260 @@
+package soap
+
+import (
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+// An Option configures a Client created by NewClient.
+type Option func(*Client)
+
+// NewClient creates a Client for the server at url, configured with opts.
+//
+// Option values are copied where possible, so later changes to them by
+// the caller don't affect the client. The returned client must not be
+// modified after it is first used; per-call settings are passed through
+// the context given to the *Context round trip methods, and derived
+// configurations are created from copies of the client instead.
+func NewClient(url string, opts ...Option) *Client {
+	c := &Client{URL: url}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithNamespace sets the SOAP namespace of the client.
+func WithNamespace(ns string) Option {
+	return func(c *Client) { c.Namespace = ns }
+}
+
+// WithEnvelope sets the SOAP envelope namespace of the client.
+func WithEnvelope(ns string) Option {
+	return func(c *Client) { c.Envelope = ns }
+}
+
+// WithVersion sets the SOAP version of the client.
+func WithVersion(v Version) Option {
+	return func(c *Client) { c.Version = v }
+}
+
+// WithPrefixes sets the namespace prefixes of request envelopes.
+func WithPrefixes(p Prefixes) Option {
+	return func(c *Client) { c.Prefixes = p }
+}
+
+// WithCollectNamespaces declares the namespaces used in request bodies
+// on the envelope, using the prefixes in nss (prefix to namespace, such
+// as the generated UsedNamespaces) where given.
+func WithCollectNamespaces(nss map[string]string) Option {
+	return func(c *Client) {
+		c.CollectNamespaces = true
+		c.Namespaces = nss
+	}
+}
+
+// WithStrictDecode fails calls whose responses contain elements that do
+// not map to any field of the response message.
+func WithStrictDecode() Option {
+	return func(c *Client) { c.StrictDecode = true }
+}
+
+// WithRequestValidator validates request messages with v before sending
+// them, e.g. against the generated Schema parsed with wsdl.ParseSchema.
+// Invalid requests fail with an error wrapping that of v.
+func WithRequestValidator(v Validator) Option {
+	return func(c *Client) { c.RequestValidator = v }
+}
+
+// WithValidateRequests checks the bodies of requests with Validate
+// before encoding them, calling the generated Validate methods.
+func WithValidateRequests() Option {
+	return func(c *Client) { c.ValidateRequests = true }
+}
+
+// WithResolveMultiRefs inlines multiRef elements referenced by
+// href="#id" attributes in responses before decoding them.
+func WithResolveMultiRefs() Option {
+	return func(c *Client) { c.ResolveMultiRefs = true }
+}
+
+// WithXMLDeclaration sends the XML declaration before the envelope.
+func WithXMLDeclaration() Option {
+	return func(c *Client) { c.XMLDeclaration = true }
+}
+
+// WithEncoding sends requests in the given charset, such as ISO-8859-1.
+func WithEncoding(cs string) Option {
+	return func(c *Client) { c.Encoding = cs }
+}
+
+// WithExcludeActionNamespace sends SOAPAction headers without the
+// namespace prefix.
+func WithExcludeActionNamespace() Option {
+	return func(c *Client) { c.ExcludeActionNamespace = true }
+}
+
+// WithActionFormat sets the format of SOAPAction headers. The format
+// receives the namespace and the action, e.g. "%s#%s" or "%[2]s".
+func WithActionFormat(format string) Option {
+	return func(c *Client) { c.ActionFormat = format }
+}
+
+// WithActionQuoted sends SOAPAction headers in double quotes.
+func WithActionQuoted() Option {
+	return func(c *Client) { c.ActionQuoted = true }
+}
+
+// WithEmptyAction sends an empty SOAPAction header.
+func WithEmptyAction() Option {
+	return func(c *Client) { c.EmptyAction = true }
+}
+
+// WithOmitAction sends no SOAPAction header.
+func WithOmitAction() Option {
+	return func(c *Client) { c.OmitAction = true }
+}
+
+// WithSOAPHeader sets the SOAP Header element sent with every request.
+func WithSOAPHeader(h Header) Option {
+	return func(c *Client) { c.Header = h }
+}
+
+// WithHTTPClient sets the HTTP client used to send requests.
+func WithHTTPClient(cli *http.Client) Option {
+	return func(c *Client) { c.Config = cli }
+}
+
+// WithTransport sets the transport used to send requests, instead of an
+// HTTP client.
+func WithTransport(t Transport) Option {
+	return func(c *Client) { c.Transport = t }
+}
+
+// WithRoundTripper sends requests with rt directly.
+func WithRoundTripper(rt http.RoundTripper) Option {
+	return WithTransport(RoundTripperTransport(rt))
+}
+
+// WithRedirect sets how HTTP redirects of requests are handled.
+func WithRedirect(p RedirectPolicy) Option {
+	return func(c *Client) { c.Redirect = p }
+}
+
+// WithHTTPHeader adds HTTP headers to every request.
+func WithHTTPHeader(h http.Header) Option {
+	return func(c *Client) {
+		if c.HTTPHeader == nil {
+			c.HTTPHeader = make(http.Header)
+		}
+		for k, v := range h {
+			c.HTTPHeader[http.CanonicalHeaderKey(k)] = append([]string(nil), v...)
+		}
+	}
+}
+
+// WithUserAgent sets the User-Agent header of requests.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.UserAgent = ua }
+}
+
+// WithContentType sets the Content-Type header of SOAP 1.1 requests.
+func WithContentType(ct string) Option {
+	return func(c *Client) { c.ContentType = ct }
+}
+
+// WithAuth sets the HTTP authentication provider.
+func WithAuth(a Authenticator) Option {
+	return func(c *Client) { c.Auth = a }
+}
+
+// WithTokenSource sets the OAuth2 bearer token source.
+func WithTokenSource(ts oauth2.TokenSource) Option {
+	return func(c *Client) { c.TokenSource = ts }
+}
+
+// WithCookieJar sets the cookie jar for session cookies.
+func WithCookieJar(jar http.CookieJar) Option {
+	return func(c *Client) { c.Jar = jar }
+}
+
+// WithSession sets the session hook.
+func WithSession(s Session) Option {
+	return func(c *Client) { c.Session = s }
+}
+
+// WithCompression gzips requests and accepts compressed responses.
+func WithCompression() Option {
+	return func(c *Client) { c.Compress = true }
+}
+
+// WithExpectContinue sends requests larger than n bytes with
+// "Expect: 100-continue", so that servers can reject them, e.g. for
+// authentication, before their body is sent. The transport must wait
+// for the server's response, see http.Transport.ExpectContinueTimeout,
+// as http.DefaultTransport does.
+func WithExpectContinue(n int64) Option {
+	return func(c *Client) { c.ExpectContinue = n }
+}
+
+// WithMaxResponseBytes limits the size of response bodies.
+func WithMaxResponseBytes(n int64) Option {
+	return func(c *Client) { c.MaxResponseBytes = n }
+}
+
+// WithPre sets the hook to modify outbound requests.
+func WithPre(fn func(*http.Request)) Option {
+	return func(c *Client) { c.Pre = fn }
+}
+
+// WithPost sets the hook to snoop inbound responses.
+func WithPost(fn func(*http.Response)) Option {
+	return func(c *Client) { c.Post = fn }
+}
+
+// WithMiddleware appends mw to the middleware chain of the client, the
+// first outermost.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *Client) {
+		c.Middleware = append(c.Middleware[:len(c.Middleware):len(c.Middleware)], mw...)
+	}
+}
+
+// Clone returns a shallow copy of the client. The HTTPHeader map is
+// copied, so headers can be added to the clone without affecting c;
+// other references, such as the HTTP client and hooks, are shared.
+func (c *Client) Clone() *Client {
+	cc := *c
+	if c.HTTPHeader != nil {
+		cc.HTTPHeader = c.HTTPHeader.Clone()
+	}
+	return &cc
+}
+
+// With returns a copy of the client with opts applied.
+func (c *Client) With(opts ...Option) *Client {
+	cc := c.Clone()
+	for _, opt := range opts {
+		opt(cc)
+	}
+	return cc
+}
+
+// WithURL returns a copy of the client for the server at url.
+func (c *Client) WithURL(url string) *Client {
+	cc := c.Clone()
+	cc.URL = url
+	return cc
+}
+
+// WithHeader returns a copy of the client with the SOAP Header set to h.
+func (c *Client) WithHeader(h Header) *Client {
+	return c.With(WithSOAPHeader(h))
+}
+
+// WithContentType returns a copy of the client with the Content-Type set
+// to ct.
+func (c *Client) WithContentType(ct string) *Client {
+	return c.With(WithContentType(ct))
+}
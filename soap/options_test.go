@@ -0,0 +1,141 @@
+package soap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewClient(t *testing.T) {
+	type msgT struct{ A, B string }
+	type envT struct{ msgT }
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "key" {
+			http.Error(w, "missing api key", http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("User-Agent") != "test" {
+			http.Error(w, "unexpected user agent", http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("SOAPAction") != "urn:test/Echo" {
+			http.Error(w, "unexpected action", http.StatusBadRequest)
+			return
+		}
+		io.Copy(w, r.Body)
+	})
+	s := httptest.NewServer(echo)
+	defer s.Close()
+	h := http.Header{"x-api-key": {"key"}}
+	c := NewClient(s.URL,
+		WithNamespace("urn:test"),
+		WithUserAgent("test"),
+		WithHTTPHeader(h),
+	)
+	h.Set("x-api-key", "changed")
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- c.RoundTripWithAction("Echo", &msgT{A: "hello"}, &envT{})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWithMiddleware(t *testing.T) {
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next RoundTripFunc) RoundTripFunc {
+			return func(ctx context.Context, call *Call) error {
+				order = append(order, name)
+				return next(ctx, call)
+			}
+		}
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	}))
+	defer s.Close()
+	type msgT struct{ A string }
+	type envT struct{ msgT }
+	c := NewClient(s.URL, WithMiddleware(mw("a"), mw("b")), WithMiddleware(mw("c")))
+	if err := c.RoundTrip(&msgT{A: "hello"}, &envT{}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(order, ",") != "a,b,c" {
+		t.Fatalf("middleware called in order %v", order)
+	}
+}
+
+func TestClientClone(t *testing.T) {
+	base := NewClient("http://a", WithNamespace("urn:test"), WithHTTPHeader(http.Header{"X-A": {"1"}}))
+	c := base.WithURL("http://b").WithContentType("application/xml").WithHeader(&AuthHeader{Username: "u"})
+	c.HTTPHeader.Set("X-B", "2")
+	if base.URL != "http://a" || base.ContentType != "" || base.Header != nil {
+		t.Fatalf("base client modified: %#v", base)
+	}
+	if base.HTTPHeader.Get("X-B") != "" {
+		t.Fatal("clone shares HTTP headers with base client")
+	}
+	if c.URL != "http://b" || c.Namespace != "urn:test" || c.ContentType != "application/xml" {
+		t.Fatalf("unexpected clone: %#v", c)
+	}
+	if h, ok := c.Header.(*AuthHeader); !ok || h.Username != "u" {
+		t.Fatalf("unexpected SOAP header: %#v", c.Header)
+	}
+	d := base.With(WithUserAgent("ua"))
+	if d.UserAgent != "ua" || base.UserAgent != "" {
+		t.Fatal("With modified the base client")
+	}
+}
+
+func TestClientWithMiddleware(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	}))
+	defer s.Close()
+	type msgT struct{ A string }
+	type envT struct{ msgT }
+	// The chain of base has room for more middleware, which the clients
+	// derived from it must not share.
+	base := NewClient(s.URL, WithEndpoints(s.URL), WithRateLimit(0, 1), WithOperationTimeouts(nil))
+	a := base.With(WithOperationTimeouts(nil))
+	b := base.With(WithOperationTimeouts(map[string]time.Duration{"": time.Nanosecond}))
+	if err := a.RoundTrip(&msgT{A: "hello"}, &envT{}); err != nil {
+		t.Fatalf("client runs the middleware of another one: %v", err)
+	}
+	var terr *TimeoutError
+	if err := b.RoundTrip(&msgT{A: "hello"}, &envT{}); !errors.As(err, &terr) {
+		t.Fatalf("want a *TimeoutError, have %v", err)
+	}
+
+	opts := map[string]func() Option{
+		"WithEndpoints":         func() Option { return WithEndpoints(s.URL) },
+		"WithRateLimit":         func() Option { return WithRateLimit(0, 1) },
+		"WithDumpDir":           func() Option { return WithDumpDir(t.TempDir(), 0) },
+		"WithCorrelationID":     func() Option { return WithCorrelationID("") },
+		"WithOperationTimeouts": func() Option { return WithOperationTimeouts(nil) },
+	}
+	n := len(base.Middleware)
+	for name, opt := range opts {
+		a, b := base.With(opt()), base.With(opt())
+		if len(a.Middleware) != n+1 || len(b.Middleware) != n+1 || &a.Middleware[n] == &b.Middleware[n] {
+			t.Errorf("%s: clients derived from one share its middleware", name)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Package otelsoap provides OpenTelemetry tracing for soap.Client.
+//
+// Add the middleware to the client to start a client span per round
+// trip and propagate the trace context in the HTTP request headers:
+//
+//	cli.Middleware = append(cli.Middleware, otelsoap.Middleware())
+package otelsoap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/YapealAG/wsdl2go/soap"
+)
+
+const instrumentationName = "github.com/YapealAG/wsdl2go/soap/otelsoap"
+
+// Span attribute keys.
+const (
+	ActionKey      = attribute.Key("soap.action")
+	FaultCodeKey   = attribute.Key("soap.fault.code")
+	RPCSystemKey   = attribute.Key("rpc.system")
+	ServerAddrKey  = attribute.Key("server.address")
+	URLFullKey     = attribute.Key("url.full")
+	HTTPStatusKey  = attribute.Key("http.response.status_code")
+	rpcSystemValue = "soap"
+)
+
+type config struct {
+	provider    trace.TracerProvider
+	propagators propagation.TextMapPropagator
+}
+
+// An Option configures the middleware.
+type Option func(*config)
+
+// WithTracerProvider sets the tracer provider. Defaults to the global one.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) { c.provider = tp }
+}
+
+// WithPropagators sets the propagators used to inject the trace context
+// into requests. Defaults to the global ones.
+func WithPropagators(p propagation.TextMapPropagator) Option {
+	return func(c *config) { c.propagators = p }
+}
+
+// Middleware returns a soap.Middleware that traces round trips.
+func Middleware(opts ...Option) soap.Middleware {
+	cfg := &config{
+		provider:    otel.GetTracerProvider(),
+		propagators: otel.GetTextMapPropagator(),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	tracer := cfg.provider.Tracer(instrumentationName)
+	return func(next soap.RoundTripFunc) soap.RoundTripFunc {
+		return func(ctx context.Context, call *soap.Call) error {
+			attrs := []attribute.KeyValue{
+				RPCSystemKey.String(rpcSystemValue),
+				ActionKey.String(call.Action),
+				URLFullKey.String(call.URL),
+			}
+			if u, err := url.Parse(call.URL); err == nil {
+				attrs = append(attrs, ServerAddrKey.String(u.Hostname()))
+			}
+			ctx, span := tracer.Start(ctx, spanName(call),
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attrs...),
+			)
+			defer span.End()
+
+			h := make(http.Header)
+			cfg.propagators.Inject(ctx, propagation.HeaderCarrier(h))
+			ctx = soap.ContextWithHTTPHeader(ctx, h)
+
+			err := next(ctx, call)
+			if call.StatusCode != 0 {
+				span.SetAttributes(HTTPStatusKey.Int(call.StatusCode))
+			}
+			if err != nil {
+				var fault *soap.Fault
+				if errors.As(err, &fault) {
+					span.SetAttributes(FaultCodeKey.String(fault.Code))
+				}
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		}
+	}
+}
+
+func spanName(call *soap.Call) string {
+	if call.Action == "" {
+		return "SOAP"
+	}
+	return "SOAP " + call.Action
+}
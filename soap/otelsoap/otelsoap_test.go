@@ -0,0 +1,81 @@
+package otelsoap
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/YapealAG/wsdl2go/soap"
+)
+
+const faultXML = `<Envelope><Body><Fault>
+<faultcode>soap:Server</faultcode><faultstring>boom</faultstring>
+</Fault></Body></Envelope>`
+
+func TestMiddleware(t *testing.T) {
+	type msgT struct{ A, B string }
+	type envT struct{ msgT }
+	var traceparent string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+		if r.Header.Get("SOAPAction") == "urn:test/Fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, faultXML)
+			return
+		}
+		io.Copy(w, r.Body)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	c := soap.NewClient(s.URL, soap.WithNamespace("urn:test"))
+	c.Middleware = []soap.Middleware{
+		Middleware(WithTracerProvider(tp), WithPropagators(propagation.TraceContext{})),
+	}
+	if err := c.RoundTripWithAction("Echo", &msgT{A: "hello"}, &envT{}); err != nil {
+		t.Fatal(err)
+	}
+	if traceparent == "" {
+		t.Fatal("trace context not propagated")
+	}
+	if err := c.RoundTripWithAction("Fail", &msgT{}, &envT{}); err == nil {
+		t.Fatal("expected fault")
+	}
+
+	spans := sr.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("want 2 spans, have %d", len(spans))
+	}
+	if spans[0].Name() != "SOAP urn:test/Echo" {
+		t.Errorf("unexpected span name %q", spans[0].Name())
+	}
+	if traceparent[3:35] != spans[1].SpanContext().TraceID().String() {
+		t.Errorf("traceparent %q does not match span", traceparent)
+	}
+	want := map[attribute.Key]attribute.Value{
+		ActionKey:     attribute.StringValue("urn:test/Fail"),
+		HTTPStatusKey: attribute.IntValue(500),
+		FaultCodeKey:  attribute.StringValue("soap:Server"),
+	}
+	have := map[attribute.Key]attribute.Value{}
+	for _, kv := range spans[1].Attributes() {
+		have[kv.Key] = kv.Value
+	}
+	for k, v := range want {
+		if have[k] != v {
+			t.Errorf("attribute %s: want %v, have %v", k, v.Emit(), have[k].Emit())
+		}
+	}
+	if spans[1].Status().Code != codes.Error {
+		t.Errorf("unexpected span status %v", spans[1].Status())
+	}
+}
@@ -0,0 +1,67 @@
+package soap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// maxPooledBuffer is the capacity above which buffers are left to the
+// garbage collector instead of being pooled, so one huge request doesn't
+// pin its memory.
+const maxPooledBuffer = 1 << 20
+
+var bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxPooledBuffer {
+		return
+	}
+	b.Reset()
+	bufferPool.Put(b)
+}
+
+var gzipWriterPool = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+
+// pooledBody is a request body backed by a pooled buffer. The buffer is
+// returned to the pool once the call released it and the transport
+// closed every reader of it, including those from GetBody for redirects
+// and retries. Transports that don't close bodies leave the buffer to
+// the garbage collector.
+type pooledBody struct {
+	buf  *bytes.Buffer
+	refs int32
+}
+
+func newPooledBody(b *bytes.Buffer) *pooledBody {
+	return &pooledBody{buf: b, refs: 1}
+}
+
+// reader returns a new reader of the body.
+func (p *pooledBody) reader() io.ReadCloser {
+	atomic.AddInt32(&p.refs, 1)
+	return &pooledReader{Reader: bytes.NewReader(p.buf.Bytes()), body: p}
+}
+
+func (p *pooledBody) release() {
+	if atomic.AddInt32(&p.refs, -1) == 0 {
+		putBuffer(p.buf)
+	}
+}
+
+type pooledReader struct {
+	*bytes.Reader
+	body *pooledBody
+	once sync.Once
+}
+
+func (r *pooledReader) Close() error {
+	r.once.Do(r.body.release)
+	return nil
+}
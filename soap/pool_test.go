@@ -0,0 +1,96 @@
+package soap
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPooledBody(t *testing.T) {
+	b := getBuffer()
+	b.WriteString("hello")
+	body := newPooledBody(b)
+	r1, r2 := body.reader(), body.reader()
+	body.release()
+	for _, r := range []io.ReadCloser{r1, r2} {
+		p, _ := io.ReadAll(r)
+		if string(p) != "hello" {
+			t.Fatalf("unexpected body %q", p)
+		}
+	}
+	r1.Close()
+	r1.Close()
+	if b.Len() == 0 {
+		t.Fatal("buffer released while a reader is open")
+	}
+	r2.Close()
+	if b.Len() != 0 {
+		t.Fatal("buffer not released")
+	}
+}
+
+type benchRequest struct {
+	XMLName xml.Name `xml:"Echo"`
+	Items   []benchItem
+}
+
+type benchItem struct {
+	Name  string
+	Value int
+	Tags  []string
+}
+
+func BenchmarkRoundTrip(b *testing.B) {
+	req := &benchRequest{}
+	for i := 0; i < 50; i++ {
+		req.Items = append(req.Items, benchItem{Name: "item", Value: i, Tags: []string{"a", "b"}})
+	}
+	resp := `<Envelope><Body><EchoResponse><Data>ok</Data></EchoResponse></Body></Envelope>`
+	tr := TransportFunc(func(r *http.Request) (*http.Response, error) {
+		io.Copy(io.Discard, r.Body)
+		r.Body.Close()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(resp)),
+		}, nil
+	})
+	for _, compress := range []bool{false, true} {
+		name := "plain"
+		if compress {
+			name = "gzip"
+		}
+		b.Run(name, func(b *testing.B) {
+			c := NewClient("http://localhost/", WithNamespace("urn:test"), WithTransport(tr))
+			c.Compress = compress
+			ctx := context.Background()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var out struct {
+					Data string `xml:"EchoResponse>Data"`
+				}
+				if err := c.RoundTripWithActionContext(ctx, "Echo", req, &out); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGzipBody(b *testing.B) {
+	p := bytes.Repeat([]byte("<Item>hello</Item>"), 500)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := getBuffer()
+		buf.Write(p)
+		z, err := gzipBody(buf)
+		putBuffer(buf)
+		if err != nil {
+			b.Fatal(err)
+		}
+		putBuffer(z)
+	}
+}
@@ -0,0 +1,143 @@
+package soap
+
+import (
+	"encoding/xml"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Prefixes holds the namespace prefixes used in request envelopes.
+// Empty fields use the defaults soapenv, tns, urn and xsi.
+//
+// Note that types generated by wsdl2go and the wsse package refer to
+// the xsi and soapenv prefixes in their struct tags.
+type Prefixes struct {
+	Envelope string // prefix of the Envelope, Header and Body elements
+	This     string // prefix declared for TNSAttr
+	URN      string // prefix declared for URNAttr
+	XSI      string // prefix declared for XSIAttr
+}
+
+func (p Prefixes) withDefaults() Prefixes {
+	if p.Envelope == "" {
+		p.Envelope = "soapenv"
+	}
+	if p.This == "" {
+		p.This = "tns"
+	}
+	if p.URN == "" {
+		p.URN = "urn"
+	}
+	if p.XSI == "" {
+		p.XSI = "xsi"
+	}
+	return p
+}
+
+// MarshalXML implements the xml.Marshaler interface, using the
+// envelope's Prefixes and adding its Declarations, and those of the
+// prefixes of the QName attributes and lists of the header and body.
+func (env *Envelope) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	p := env.Prefixes.withDefaults()
+	start := xml.StartElement{
+		Name: xml.Name{Local: p.Envelope + ":Envelope"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "xmlns:" + p.Envelope}, Value: env.EnvelopeAttr},
+			{Name: xml.Name{Local: "xmlns"}, Value: env.NSAttr},
+		},
+	}
+	for _, a := range []struct{ prefix, ns string }{
+		{p.This, env.TNSAttr},
+		{p.URN, env.URNAttr},
+		{p.XSI, env.XSIAttr},
+	} {
+		if a.ns != "" {
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns:" + a.prefix}, Value: a.ns})
+		}
+	}
+	prefixes := make([]string, 0, len(env.Declarations))
+	for prefix := range env.Declarations {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns:" + prefix}, Value: env.Declarations[prefix]})
+	}
+	declared := make(map[string]string, len(start.Attr))
+	for _, a := range start.Attr {
+		if prefix, ok := strings.CutPrefix(a.Name.Local, "xmlns:"); ok {
+			declared[prefix] = a.Value
+		}
+	}
+	msgs := []any{env.Header, env.Body}
+	if b, ok := env.Body.(*serverBody); ok {
+		msgs = []any{env.Header, b.header, b.msg}
+	}
+	decls, err := qnameDeclarations(declared, msgs...)
+	if err != nil {
+		return err
+	}
+	prefixes = prefixes[:0]
+	for prefix := range decls {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns:" + prefix}, Value: decls[prefix]})
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := encodePart(e, env.Header, p.Envelope+":Header"); err != nil {
+		return err
+	}
+	body := env.Body
+	if body == nil {
+		// Operations without input, such as keepalives, send an empty
+		// body, which SOAP requires.
+		body = struct{}{}
+	}
+	if err := encodePart(e, body, p.Envelope+":Body"); err != nil {
+		return err
+	}
+	return e.EncodeToken(start.End())
+}
+
+// encodePart encodes v as the named element, unless v has an element
+// name of its own, like encoding/xml does for struct fields.
+func encodePart(e *xml.Encoder, v Message, name string) error {
+	if v == nil {
+		return nil
+	}
+	if hasXMLName(reflect.ValueOf(v)) {
+		return e.Encode(v)
+	}
+	return e.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: name}})
+}
+
+// hasXMLName reports whether v is a struct whose XMLName field, by tag or
+// value, names the element.
+func hasXMLName(v reflect.Value) bool {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+	f, ok := v.Type().FieldByName("XMLName")
+	if !ok || f.Type != reflect.TypeOf(xml.Name{}) {
+		return false
+	}
+	tag, _, _ := strings.Cut(f.Tag.Get("xml"), ",")
+	if i := strings.LastIndex(tag, " "); i >= 0 {
+		tag = tag[i+1:]
+	}
+	if tag != "" {
+		return true
+	}
+	return v.FieldByIndex(f.Index).Interface().(xml.Name).Local != ""
+}
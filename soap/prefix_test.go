@@ -0,0 +1,70 @@
+package soap
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+// taggedEnvelope is the envelope with the default prefixes in its tags.
+type taggedEnvelope struct {
+	XMLName      xml.Name `xml:"soapenv:Envelope"`
+	EnvelopeAttr string   `xml:"xmlns:soapenv,attr"`
+	NSAttr       string   `xml:"xmlns,attr"`
+	TNSAttr      string   `xml:"xmlns:tns,attr,omitempty"`
+	URNAttr      string   `xml:"xmlns:urn,attr,omitempty"`
+	XSIAttr      string   `xml:"xmlns:xsi,attr,omitempty"`
+	Header       Message  `xml:"soapenv:Header"`
+	Body         Message  `xml:"soapenv:Body"`
+}
+
+func TestEnvelopePrefixes(t *testing.T) {
+	type plainT struct{ A string }
+	type namedT struct {
+		XMLName xml.Name `xml:"urn:x Named"`
+		A       string
+	}
+	type valueT struct {
+		XMLName xml.Name
+		A       string
+	}
+	var nilHeader *AuthHeader
+	cases := []struct {
+		Header, Body Message
+	}{
+		{Body: &plainT{A: "a"}},
+		{Body: &namedT{A: "a"}},
+		{Body: &valueT{A: "a"}},
+		{Body: &valueT{XMLName: xml.Name{Local: "V"}, A: "a"}},
+		{Header: &AuthHeader{Namespace: "urn:auth", Username: "u"}, Body: struct{ M plainT }{}},
+		{Header: nilHeader, Body: &plainT{}},
+	}
+	for i, tc := range cases {
+		env := Envelope{EnvelopeAttr: EnvelopeNamespace11, NSAttr: "urn:test", XSIAttr: XSINamespace, Header: tc.Header, Body: tc.Body}
+		want, err := xml.Marshal(&taggedEnvelope{EnvelopeAttr: EnvelopeNamespace11, NSAttr: "urn:test", XSIAttr: XSINamespace, Header: tc.Header, Body: tc.Body})
+		if err != nil {
+			t.Fatal(err)
+		}
+		have, err := xml.Marshal(&env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(have) != string(want) {
+			t.Errorf("test %d: envelope mismatch\nwant: %s\nhave: %s", i, want, have)
+		}
+	}
+
+	env := Envelope{
+		EnvelopeAttr: EnvelopeNamespace11,
+		TNSAttr:      "urn:this",
+		Body:         &plainT{A: "a"},
+		Prefixes:     Prefixes{Envelope: "s", This: "t"},
+	}
+	have, err := xml.Marshal(&env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" xmlns="" xmlns:t="urn:this"><s:Body><A>a</A></s:Body></s:Envelope>`
+	if string(have) != want {
+		t.Errorf("envelope mismatch\nwant: %s\nhave: %s", want, have)
+	}
+}
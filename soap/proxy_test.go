@@ -0,0 +1,116 @@
+package soap
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+// testProxy is an HTTP proxy that forwards plain requests and tunnels
+// CONNECT requests, recording the headers it received.
+type testProxy struct {
+	mu      sync.Mutex
+	methods []string
+	headers []http.Header
+}
+
+func (p *testProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	p.methods = append(p.methods, r.Method)
+	p.headers = append(p.headers, r.Header.Clone())
+	p.mu.Unlock()
+	if r.Method != http.MethodConnect {
+		r.RequestURI = ""
+		resp, err := http.DefaultTransport.RoundTrip(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+		return
+	}
+	dst, err := net.Dial("tcp", r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	src, buf, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		dst.Close()
+		return
+	}
+	go func() {
+		io.Copy(dst, buf)
+		dst.Close()
+	}()
+	io.Copy(src, dst)
+	src.Close()
+}
+
+func TestProxy(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Body><msgT><A>ok</A></msgT></Body></Envelope>`)
+	})
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	secure := httptest.NewTLSServer(handler)
+	defer secure.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(secure.Certificate())
+
+	p := &testProxy{}
+	ps := httptest.NewServer(p)
+	defer ps.Close()
+	pu, _ := url.Parse(ps.URL)
+	pu.User = url.UserPassword("user", "secret")
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:secret"))
+
+	type msgT struct{ A string }
+	call := func(c *Client) {
+		t.Helper()
+		var out struct {
+			Msg msgT `xml:"msgT"`
+		}
+		if err := c.RoundTrip(&msgT{}, &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.Msg.A != "ok" {
+			t.Errorf("unexpected response %+v", out)
+		}
+	}
+	call(NewClient(plain.URL, WithProxy(pu)))
+	call(NewClient(secure.URL, WithRootCAs(roots), WithProxy(pu), WithProxyConnectHeader(http.Header{"x-proxy-tenant": {"acme"}})))
+	if len(p.methods) != 2 || p.methods[0] != http.MethodPost || p.methods[1] != http.MethodConnect {
+		t.Fatalf("unexpected proxied requests %v", p.methods)
+	}
+	for i, h := range p.headers {
+		if h.Get("Proxy-Authorization") != basic {
+			t.Errorf("request %d: unexpected Proxy-Authorization %q", i, h.Get("Proxy-Authorization"))
+		}
+	}
+	if h := p.headers[1].Get("X-Proxy-Tenant"); h != "acme" {
+		t.Errorf("unexpected CONNECT header %q", h)
+	}
+
+	// Proxies are per client, and can be turned off again.
+	call(NewClient(plain.URL, WithProxy(pu), WithNoProxy()))
+	if len(p.methods) != 2 {
+		t.Errorf("request sent through disabled proxy")
+	}
+	c := NewClient(plain.URL, WithProxyFunc(func(r *http.Request) (*url.URL, error) {
+		return nil, nil
+	}))
+	call(c)
+	if len(p.methods) != 2 {
+		t.Errorf("request sent through proxy")
+	}
+}
@@ -0,0 +1,313 @@
+package soap
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/net/html/charset"
+)
+
+// xmlNamespace is the namespace bound to the xml prefix.
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// QName is a value of xsd:QName, a local name qualified by the namespace
+// its prefix is bound to, such as tns:Order in an element or attribute
+// in the scope of xmlns:tns="http://example.com/orders".
+//
+// Decoded, Prefix is the prefix of the value, and Space the namespace of
+// the prefix declared on the element of the value or, for the messages
+// decoded by a Client or a Server, anywhere in the envelope if it binds
+// the prefix to a single namespace; it is empty if the prefix is not
+// declared. Encoded, the element of a QName declares its prefix, Prefix
+// or one derived from Space such as orders, and the envelopes of Client
+// requests and Server responses those of QName attributes and lists.
+type QName struct {
+	Space  string
+	Local  string
+	Prefix string // optional when encoded
+}
+
+// NewQName returns the QName of the local name in the namespace space.
+func NewQName(space, local string) QName {
+	return QName{Space: space, Local: local}
+}
+
+// Name returns the xml.Name of q.
+func (q QName) Name() xml.Name {
+	return xml.Name{Space: q.Space, Local: q.Local}
+}
+
+// String returns the text of q, its local name qualified by its prefix.
+func (q QName) String() string {
+	if p := q.prefix(); p != "" {
+		return p + ":" + q.Local
+	}
+	return q.Local
+}
+
+// prefix returns the prefix of q when encoded: Prefix, or one derived
+// from Space, the last segment of its path, or none if q is of no
+// namespace and was not decoded unresolved.
+func (q QName) prefix() string {
+	switch {
+	case q.Space == "":
+		return q.Prefix
+	case q.Space == xmlNamespace:
+		return "xml"
+	case q.Prefix != "":
+		return q.Prefix
+	}
+	s := strings.TrimRight(q.Space, "/#:")
+	s = s[strings.LastIndexAny(s, "/#:")+1:]
+	s = strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.') {
+			return r
+		}
+		return -1
+	}, s)
+	if s == "" || !unicode.IsLetter(rune(s[0])) || strings.HasPrefix(strings.ToLower(s), "xml") {
+		return "ns"
+	}
+	return s
+}
+
+// MarshalText implements the encoding.TextMarshaler interface, without
+// declaring the prefix.
+func (q QName) MarshalText() ([]byte, error) {
+	return []byte(q.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface,
+// setting the prefix and local name of q, but not its namespace.
+func (q *QName) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	prefix, local, ok := strings.Cut(s, ":")
+	if !ok {
+		prefix, local = "", s
+	}
+	if local == "" || strings.Contains(local, ":") || ok && prefix == "" {
+		return fmt.Errorf("soap: invalid QName %q", s)
+	}
+	*q = QName{Local: local, Prefix: prefix}
+	if prefix == "xml" {
+		q.Space = xmlNamespace
+	}
+	return nil
+}
+
+// MarshalXML implements the xml.Marshaler interface, declaring the
+// prefix of q on its element.
+func (q QName) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if p := q.prefix(); q.Space != "" && p != "xml" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns:" + p}, Value: q.Space})
+	}
+	return e.EncodeElement(q.String(), start)
+}
+
+// UnmarshalXML implements the xml.Unmarshaler interface, resolving the
+// prefix of q against the namespaces declared on its element.
+func (q *QName) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var s string
+	if err := d.DecodeElement(&s, &start); err != nil {
+		return err
+	}
+	if err := q.UnmarshalText([]byte(s)); err != nil {
+		return err
+	}
+	for _, a := range start.Attr {
+		if q.Prefix == "" && a.Name.Space == "" && a.Name.Local == "xmlns" ||
+			q.Prefix != "" && a.Name.Space == "xmlns" && a.Name.Local == q.Prefix {
+			q.Space = a.Value
+		}
+	}
+	return nil
+}
+
+// MarshalXMLAttr implements the xml.MarshalerAttr interface, omitting
+// the attribute of the zero QName. Its prefix is declared by the
+// envelope of the message.
+func (q QName) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if q == (QName{}) {
+		return xml.Attr{}, nil
+	}
+	return xml.Attr{Name: name, Value: q.String()}, nil
+}
+
+var qnameType = reflect.TypeOf(QName{})
+
+// hasQNames tells whether values of the type t may hold QName values,
+// those of interfaces excepted, of the cache of the types seen.
+func hasQNames(t reflect.Type) bool {
+	if v, ok := qnameTypes.Load(t); ok {
+		return v.(bool)
+	}
+	var walk func(t reflect.Type, seen map[reflect.Type]bool) bool
+	walk = func(t reflect.Type, seen map[reflect.Type]bool) bool {
+		if t == qnameType {
+			return true
+		}
+		if seen[t] {
+			return false
+		}
+		seen[t] = true
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array:
+			return walk(t.Elem(), seen)
+		case reflect.Struct:
+			for i := 0; i < t.NumField(); i++ {
+				if f := t.Field(i); f.IsExported() && walk(f.Type, seen) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	has := walk(t, make(map[reflect.Type]bool))
+	qnameTypes.Store(t, has)
+	return has
+}
+
+var qnameTypes sync.Map // of reflect.Type, to bool
+
+// messagesHaveQNames tells whether the dynamic values of the messages
+// vs may hold QName values.
+func messagesHaveQNames(vs ...any) bool {
+	for _, v := range vs {
+		if v != nil && hasQNames(reflect.TypeOf(v)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveQNames sets the namespaces of the QName values of v left
+// unresolved, such as those of attributes, which encoding/xml decodes
+// apart from their element, to those the XML document doc binds their
+// prefix to, if it binds it to a single namespace.
+func resolveQNames(v any, doc []byte) {
+	if !messagesHaveQNames(v) {
+		return
+	}
+	var bindings map[string]string
+	walkValues(v, func(x any, path string) error {
+		q, ok := x.(*QName)
+		if !ok || q.Space != "" || q.Prefix == "" {
+			return nil
+		}
+		if bindings == nil {
+			bindings = documentPrefixes(doc)
+		}
+		q.Space = bindings[q.Prefix]
+		return nil
+	})
+}
+
+// documentPrefixes returns the namespaces of the prefixes of the XML
+// document doc bound to a single one, by prefix.
+func documentPrefixes(doc []byte) map[string]string {
+	bindings := make(map[string]string)
+	d := xml.NewDecoder(bytes.NewReader(doc))
+	d.CharsetReader = charset.NewReaderLabel
+	for {
+		t, err := d.RawToken()
+		if err != nil {
+			break
+		}
+		start, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		for _, a := range start.Attr {
+			if a.Name.Space != "xmlns" {
+				continue
+			}
+			if ns, ok := bindings[a.Name.Local]; ok && ns != a.Value {
+				bindings[a.Name.Local] = "" // ambiguous
+				continue
+			}
+			bindings[a.Name.Local] = a.Value
+		}
+	}
+	return bindings
+}
+
+// qnameDeclarations returns the xmlns declarations of the prefixes of
+// the QName values of the messages vs encoded as text, by prefix, those of declared
+// excepted, or an error if a prefix is bound to several namespaces.
+func qnameDeclarations(declared map[string]string, vs ...any) (map[string]string, error) {
+	decls := make(map[string]string)
+	var err error
+	for _, v := range vs {
+		if v == nil || !hasQNames(reflect.TypeOf(v)) {
+			continue
+		}
+		walkQNameAttrs(reflect.ValueOf(v), make(map[uintptr]bool), false, func(q QName) {
+			p := q.prefix()
+			if q.Space == "" || p == "xml" || err != nil {
+				return
+			}
+			ns, ok := declared[p]
+			if !ok {
+				ns, ok = decls[p]
+			}
+			switch {
+			case !ok:
+				decls[p] = q.Space
+			case ns != q.Space:
+				err = fmt.Errorf("soap: prefix %q of QName %s is bound to %q, set its Prefix to another", p, q.Local, ns)
+			}
+		})
+	}
+	return decls, err
+}
+
+// walkQNameAttrs calls fn with the QName values of v and the values it
+// refers to that are encoded as text, those of attributes and lists, or
+// all of them if text is set.
+func walkQNameAttrs(v reflect.Value, seen map[uintptr]bool, text bool, fn func(QName)) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || seen[v.Pointer()] {
+			return
+		}
+		seen[v.Pointer()] = true
+		walkQNameAttrs(v.Elem(), seen, text, fn)
+	case reflect.Interface:
+		if !v.IsNil() {
+			walkQNameAttrs(v.Elem(), seen, text, fn)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		text = text || v.Type().Implements(textMarshalerType)
+		for i := 0; i < v.Len(); i++ {
+			walkQNameAttrs(v.Index(i), seen, text, fn)
+		}
+	case reflect.Struct:
+		if v.Type() == qnameType {
+			if text {
+				fn(v.Interface().(QName))
+			}
+			return
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			tag := sf.Tag.Get("xml")
+			if !sf.IsExported() || tag == "-" {
+				continue
+			}
+			_, flags, _ := strings.Cut(tag, ",")
+			walkQNameAttrs(v.Field(i), seen, text || strings.Contains(","+flags+",", ",attr,"), fn)
+		}
+	}
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
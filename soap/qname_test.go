@@ -0,0 +1,157 @@
+package soap
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestQName(t *testing.T) {
+	cases := []struct {
+		Text   string
+		Prefix string
+		Local  string
+	}{
+		{"tns:Order", "tns", "Order"},
+		{" Order ", "", "Order"},
+		{"xml:lang", "xml", "lang"},
+		{":Order", "", ""},
+		{"tns:", "", ""},
+		{"a:b:c", "", ""},
+		{"", "", ""},
+	}
+	for i, tc := range cases {
+		var q QName
+		err := q.UnmarshalText([]byte(tc.Text))
+		if tc.Local == "" {
+			if err == nil {
+				t.Errorf("test %d: %q accepted", i, tc.Text)
+			}
+			continue
+		}
+		if err != nil || q.Prefix != tc.Prefix || q.Local != tc.Local {
+			t.Errorf("test %d: want %s:%s, have %+v, %v", i, tc.Prefix, tc.Local, q, err)
+		}
+	}
+	for space, want := range map[string]string{
+		"http://example.com/orders/": "orders:Order",
+		"urn:example:billing":        "billing:Order",
+		"http://example.com/2024":    "ns:Order",
+		"urn:xmlstuff":               "ns:Order",
+		"":                           "Order",
+	} {
+		if have := NewQName(space, "Order").String(); have != want {
+			t.Errorf("%s: want %s, have %s", space, want, have)
+		}
+	}
+}
+
+func TestQNameXML(t *testing.T) {
+	type item struct {
+		XMLName xml.Name `xml:"item"`
+		Type    QName    `xml:"type"`
+		Kind    QName    `xml:"kind,attr"`
+		Base    *QName   `xml:"base,attr,omitempty"`
+	}
+	b, err := xml.Marshal(item{Type: QName{Space: "urn:orders", Local: "Order", Prefix: "o"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<item><type xmlns:o="urn:orders">o:Order</type></item>`; string(b) != want {
+		t.Fatalf("want %s, have %s", want, b)
+	}
+	var v item
+	doc := `<item xmlns:k="urn:kinds" kind="k:Purchase"><type xmlns:p="urn:orders">p:Order</type></item>`
+	if err := xml.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := (QName{"urn:orders", "Order", "p"}); v.Type != want {
+		t.Errorf("want %+v, have %+v", want, v.Type)
+	}
+	if want := (QName{"", "Purchase", "k"}); v.Kind != want {
+		t.Errorf("want the attribute unresolved %+v, have %+v", want, v.Kind)
+	}
+	if have := v.Kind.String(); have != "k:Purchase" {
+		t.Errorf("want the prefix of the unresolved k:Purchase kept, have %s", have)
+	}
+	resolveQNames(&v, []byte(doc))
+	if v.Kind.Space != "urn:kinds" {
+		t.Errorf("attribute not resolved: %+v", v.Kind)
+	}
+	if err := xml.Unmarshal([]byte(`<item><type>a:b:c</type></item>`), &v); err == nil {
+		t.Error("invalid QName accepted")
+	}
+}
+
+func TestQNameDocumentPrefixes(t *testing.T) {
+	have := documentPrefixes([]byte(`<a xmlns:x="urn:x" xmlns:y="urn:y1"><b xmlns:x="urn:x" xmlns:y="urn:y2"/></a>`))
+	if have["x"] != "urn:x" || have["y"] != "" {
+		t.Errorf("want x bound and y ambiguous, have %v", have)
+	}
+}
+
+type qnameList []QName
+
+func (v qnameList) MarshalText() ([]byte, error) { return MarshalList(v) }
+
+func (v *qnameList) UnmarshalText(text []byte) error { return UnmarshalList(text, v) }
+
+type qnameRequest struct {
+	XMLName xml.Name  `xml:"urn:registry lookup"`
+	Type    QName     `xml:"type"`
+	Related qnameList `xml:"related"`
+	Scope   QName     `xml:"scope,attr"`
+}
+
+type qnameResponse struct {
+	XMLName xml.Name `xml:"urn:registry lookupResponse"`
+	Entry   struct {
+		Type QName `xml:"type"`
+		Base QName `xml:"base,attr"`
+	} `xml:"entry"`
+}
+
+func TestRoundTripQNames(t *testing.T) {
+	srv := NewServer()
+	srv.Namespace = "urn:registry"
+	var req qnameRequest
+	srv.HandleFunc("lookup", "", xml.Name{Space: "urn:registry", Local: "lookup"}, func(ctx context.Context, r *Request) (Message, error) {
+		if err := r.Decode(&req); err != nil {
+			return nil, err
+		}
+		var resp qnameResponse
+		resp.Entry.Type = NewQName("urn:types", "Invoice")
+		resp.Entry.Base = QName{Space: "urn:types", Local: "Document", Prefix: "t"}
+		return &resp, nil
+	})
+	s := httptest.NewServer(srv)
+	defer s.Close()
+
+	var out struct {
+		R qnameResponse
+	}
+	err := NewClient(s.URL).RoundTrip(&struct{ R qnameRequest }{qnameRequest{
+		Type:    NewQName("urn:types", "Invoice"),
+		Related: qnameList{NewQName("urn:types", "Receipt"), NewQName("urn:legacy", "Bill")},
+		Scope:   QName{Space: "urn:scopes", Local: "Global", Prefix: "sc"},
+	}}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Type != (QName{"urn:types", "Invoice", "types"}) || req.Scope != (QName{"urn:scopes", "Global", "sc"}) ||
+		len(req.Related) != 2 || req.Related[1] != (QName{"urn:legacy", "Bill", "legacy"}) {
+		t.Errorf("unexpected request %+v", req)
+	}
+	if out.R.Entry.Type != (QName{"urn:types", "Invoice", "types"}) || out.R.Entry.Base != (QName{"urn:types", "Document", "t"}) {
+		t.Errorf("unexpected response %+v", out.R.Entry)
+	}
+
+	err = NewClient(s.URL).RoundTrip(&struct{ R qnameRequest }{qnameRequest{
+		Scope: QName{Space: "urn:scopes", Local: "Global", Prefix: "soapenv"},
+	}}, &out)
+	if err == nil || !strings.Contains(err.Error(), `prefix "soapenv"`) {
+		t.Errorf("want the conflict of prefixes, have %v", err)
+	}
+}
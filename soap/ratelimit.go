@@ -0,0 +1,118 @@
+package soap
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket that limits calls to Rate per second,
+// allowing bursts of up to Burst calls. It is safe for concurrent use.
+type RateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewRateLimiter returns a RateLimiter of rate calls per second with
+// bursts of burst calls. The bucket starts full. A rate of 0 or less
+// does not limit calls at all, rather than blocking them once the burst
+// is spent.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+// Wait blocks until a call is allowed or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	d := l.reserve()
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token and returns how long to wait until it is
+// available.
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if l.now != nil {
+		now = l.now()
+	}
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 || l.rate <= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel returns the token of a reservation that was not used.
+func (l *RateLimiter) cancel() {
+	l.mu.Lock()
+	l.tokens++
+	l.mu.Unlock()
+}
+
+// RateLimitMiddleware returns a Middleware that waits for l before every
+// call.
+func RateLimitMiddleware(l *RateLimiter) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(ctx context.Context, call *Call) error {
+			if err := l.Wait(ctx); err != nil {
+				return err
+			}
+			return next(ctx, call)
+		}
+	}
+}
+
+// OperationRateLimitMiddleware returns a Middleware that waits for the
+// limiter of the call's operation (see OperationName) in limits. The
+// limiter with the empty key, if any, applies to the other operations.
+func OperationRateLimitMiddleware(limits map[string]*RateLimiter) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(ctx context.Context, call *Call) error {
+			l, ok := limits[OperationName(call.Action)]
+			if !ok {
+				l = limits[""]
+			}
+			if l != nil {
+				if err := l.Wait(ctx); err != nil {
+					return err
+				}
+			}
+			return next(ctx, call)
+		}
+	}
+}
+
+// WithRateLimit limits the calls of the client to rate per second, with
+// bursts of burst calls. A rate of 0 or less sets no limit.
+func WithRateLimit(rate float64, burst int) Option {
+	return func(c *Client) {
+		WithMiddleware(RateLimitMiddleware(NewRateLimiter(rate, burst)))(c)
+	}
+}
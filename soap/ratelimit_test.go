@@ -0,0 +1,65 @@
+package soap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	l := NewRateLimiter(2, 2)
+	l.now = func() time.Time { return now }
+	testCases := []struct {
+		advance time.Duration
+		want    time.Duration
+	}{
+		{0, 0},
+		{0, 0},
+		{0, 500 * time.Millisecond},
+		{500 * time.Millisecond, 500 * time.Millisecond},
+		{2 * time.Second, 0},
+		{0, 0},
+		{0, 500 * time.Millisecond},
+	}
+	for i, tc := range testCases {
+		now = now.Add(tc.advance)
+		if have := l.reserve(); have != tc.want {
+			t.Errorf("test %d: want wait %v, have %v", i, tc.want, have)
+		}
+	}
+	l = NewRateLimiter(0, 1)
+	for i := 0; i < 3; i++ {
+		if have := l.reserve(); have != 0 {
+			t.Errorf("rate 0, call %d: want no wait, have %v", i, have)
+		}
+	}
+}
+
+func TestOperationRateLimitMiddleware(t *testing.T) {
+	limits := map[string]*RateLimiter{
+		"Search": NewRateLimiter(0.001, 1),
+		"":       NewRateLimiter(1000, 10),
+	}
+	var calls int
+	rt := OperationRateLimitMiddleware(limits)(func(ctx context.Context, call *Call) error {
+		calls++
+		return nil
+	})
+	ctx := context.Background()
+	for _, action := range []string{"urn:test/Search", "urn:test/Get", "urn:test/Get"} {
+		if err := rt(ctx, &Call{Action: action}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	err := rt(ctx, &Call{Action: "urn:test/Search"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want deadline exceeded, have %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("want 3 calls, have %d", calls)
+	}
+}
@@ -0,0 +1,89 @@
+package soap
+
+import (
+	"bytes"
+	"encoding/xml"
+)
+
+// RawXML is an element of an undefined type, of xsd:any or xs:anyType,
+// kept as is: decoding it captures its name, attributes and inner XML,
+// and encoding it writes them back verbatim, named as the field holding
+// it if XMLName is empty.
+//
+// Prefixes in Content resolve against the namespaces declared in Attr
+// only, not those of the ancestors of the element.
+type RawXML struct {
+	XMLName xml.Name
+	Attr    []xml.Attr // including the namespace declarations
+	Content []byte     // inner XML
+}
+
+// NewRawXML returns the RawXML of v encoded as XML, for sending values
+// of known types as elements of undefined types.
+func NewRawXML(v any) (*RawXML, error) {
+	b, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var r RawXML
+	if err := xml.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// Decode decodes the element r into v, as xml.Unmarshal.
+func (r *RawXML) Decode(v any) error {
+	b, err := xml.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(b, v)
+}
+
+// UnmarshalXML implements the xml.Unmarshaler interface.
+func (r *RawXML) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var v struct {
+		Content []byte `xml:",innerxml"`
+	}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	r.XMLName = start.Name
+	r.Attr = append([]xml.Attr(nil), start.Attr...)
+	r.Content = bytes.Clone(v.Content)
+	return nil
+}
+
+// MarshalXML implements the xml.Marshaler interface.
+func (r RawXML) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if r.XMLName.Local != "" {
+		start.Name = r.XMLName
+	}
+	start.Attr = nil
+	prefixes := make(map[string]string)
+	for _, a := range r.Attr {
+		if a.Name.Space == "xmlns" {
+			prefixes[a.Value] = a.Name.Local
+		}
+	}
+	for _, a := range r.Attr {
+		switch {
+		case a.Name.Space == "" && a.Name.Local == "xmlns":
+			// The encoder declares the namespace of the element.
+			if start.Name.Space == "" {
+				start.Attr = append(start.Attr, a)
+			}
+			continue
+		case a.Name.Space == "xmlns":
+			a.Name = xml.Name{Local: "xmlns:" + a.Name.Local}
+		case prefixes[a.Name.Space] != "":
+			// Keep the prefix declared for the attribute.
+			a.Name = xml.Name{Local: prefixes[a.Name.Space] + ":" + a.Name.Local}
+		}
+		start.Attr = append(start.Attr, a)
+	}
+	return e.EncodeElement(struct {
+		Content []byte `xml:",innerxml"`
+	}{r.Content}, start)
+}
@@ -0,0 +1,85 @@
+package soap
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestRawXML(t *testing.T) {
+	doc := `<order><id>1</id>` +
+		`<x:note xmlns:x="urn:test:notes" x:lang="en"><x:text>Ring twice</x:text></x:note>` +
+		`<extra kind="a"><b>c</b></extra></order>`
+	var order struct {
+		XMLName xml.Name `xml:"order"`
+		ID      int      `xml:"id"`
+		Any     []RawXML `xml:",any"`
+	}
+	if err := xml.Unmarshal([]byte(doc), &order); err != nil {
+		t.Fatal(err)
+	}
+	if len(order.Any) != 2 {
+		t.Fatalf("unexpected elements %#v", order.Any)
+	}
+	note := order.Any[0]
+	if want := (xml.Name{Space: "urn:test:notes", Local: "note"}); note.XMLName != want {
+		t.Errorf("unexpected name %v", note.XMLName)
+	}
+	if want := `<x:text>Ring twice</x:text>`; string(note.Content) != want {
+		t.Errorf("unexpected content\nwant: %s\nhave: %s", want, note.Content)
+	}
+
+	var text struct {
+		Lang string `xml:"urn:test:notes lang,attr"`
+		Text string `xml:"urn:test:notes text"`
+	}
+	if err := note.Decode(&text); err != nil {
+		t.Fatal(err)
+	}
+	if text.Lang != "en" || text.Text != "Ring twice" {
+		t.Errorf("unexpected decoded note %#v", text)
+	}
+
+	b, err := xml.Marshal(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<order><id>1</id>` +
+		`<note xmlns="urn:test:notes" xmlns:x="urn:test:notes" x:lang="en"><x:text>Ring twice</x:text></note>` +
+		`<extra kind="a"><b>c</b></extra></order>`
+	if string(b) != want {
+		t.Errorf("unexpected marshal\nwant: %s\nhave: %s", want, b)
+	}
+
+	type item struct {
+		XMLName xml.Name `xml:"urn:test:items item"`
+		Name    string   `xml:"name"`
+	}
+	r, err := NewRawXML(item{Name: "pen"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.XMLName.Local != "item" || string(r.Content) != "<name>pen</name>" {
+		t.Errorf("unexpected raw %#v", r)
+	}
+	var v item
+	if err := r.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "pen" {
+		t.Errorf("unexpected item %#v", v)
+	}
+}
+
+func TestRawXMLFieldName(t *testing.T) {
+	v := struct {
+		XMLName xml.Name `xml:"event"`
+		Payload *RawXML  `xml:"payload"`
+	}{Payload: &RawXML{Content: []byte("<a>1</a>")}}
+	b, err := xml.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<event><payload><a>1</a></payload></event>`; string(b) != want {
+		t.Errorf("unexpected marshal\nwant: %s\nhave: %s", want, b)
+	}
+}
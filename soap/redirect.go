@@ -0,0 +1,97 @@
+package soap
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// RedirectPolicy controls how the client handles HTTP redirects of
+// requests.
+type RedirectPolicy int
+
+// Redirect policies.
+const (
+	// RedirectDefault leaves redirects to the transport. http.Client
+	// follows 307 and 308 with the request body, but turns POSTs into
+	// GETs on 301, 302 and 303, which SOAP servers reject.
+	RedirectDefault RedirectPolicy = iota
+
+	// RedirectFollow follows all redirects, resending the POST with its
+	// body to the new location. Credentials and cookies are only sent
+	// along to the same host.
+	RedirectFollow
+
+	// RedirectNone doesn't follow redirects, and fails the call with a
+	// *RedirectError with the new location.
+	RedirectNone
+)
+
+// maxRedirects is the number of redirects RedirectFollow follows.
+const maxRedirects = 10
+
+// RedirectError is returned for redirects the client doesn't follow.
+type RedirectError struct {
+	StatusCode int
+	Location   string // Location of the redirect, resolved against the request URL
+}
+
+func (e *RedirectError) Error() string {
+	return fmt.Sprintf("soap: redirected with status %d to %s", e.StatusCode, e.Location)
+}
+
+// send sends r with the transport of the client, applying Redirect.
+func (c *Client) send(r *http.Request) (*http.Response, error) {
+	tr := c.transport()
+	if c.Redirect == RedirectDefault {
+		return tr.Do(r)
+	}
+	if hc, ok := tr.(*http.Client); ok {
+		cp := *hc
+		cp.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+		tr = &cp
+	}
+	for hops := 0; ; hops++ {
+		resp, err := tr.Do(r)
+		if err != nil || !isRedirect(resp.StatusCode) {
+			return resp, err
+		}
+		loc, err := resp.Location()
+		if errors.Is(err, http.ErrNoLocation) {
+			return resp, nil
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if c.Redirect != RedirectFollow || hops == maxRedirects {
+			return nil, &RedirectError{StatusCode: resp.StatusCode, Location: loc.String()}
+		}
+		next := r.Clone(r.Context())
+		next.URL, next.Host = loc, ""
+		if r.GetBody != nil {
+			if next.Body, err = r.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		if loc.Host != r.URL.Host {
+			for _, k := range []string{"Authorization", "Www-Authenticate", "Cookie", "Cookie2"} {
+				next.Header.Del(k)
+			}
+		}
+		r = next
+	}
+}
+
+func isRedirect(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package soap
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirect(t *testing.T) {
+	type msgT struct{ A, B string }
+	type envT struct{ msgT }
+	var auth []string
+	regional := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		auth = append(auth, r.Header.Get("Authorization"))
+		io.Copy(w, r.Body)
+	}))
+	defer regional.Close()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/local", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved", http.StatusFound)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, regional.URL, http.StatusFound)
+	})
+	global := httptest.NewServer(mux)
+	defer global.Close()
+
+	testCases := []struct {
+		policy RedirectPolicy
+		ok     bool
+	}{
+		{RedirectDefault, false},
+		{RedirectFollow, true},
+		{RedirectNone, false},
+	}
+	for i, tc := range testCases {
+		auth = nil
+		c := NewClient(global.URL+"/local", WithNamespace("urn:test"), WithRedirect(tc.policy),
+			WithHTTPHeader(http.Header{"Authorization": {"Bearer secret"}}))
+		out := &envT{}
+		err := c.RoundTrip(&msgT{A: "hello"}, out)
+		if tc.ok != (err == nil) {
+			t.Fatalf("test %d: unexpected error %v", i, err)
+		}
+		if tc.ok && (out.A != "hello" || len(auth) != 1 || auth[0] != "") {
+			t.Errorf("test %d: unexpected response %#v, authorization %q", i, out, auth)
+		}
+		var rerr *RedirectError
+		if tc.policy == RedirectNone && (!errors.As(err, &rerr) || rerr.Location != global.URL+"/moved") {
+			t.Errorf("test %d: want RedirectError, have %v", i, err)
+		}
+	}
+}
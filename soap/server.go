@@ -0,0 +1,657 @@
+package soap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html/charset"
+)
+
+// DefaultMaxRequestBytes is the limit on the size of request bodies of
+// servers without MaxRequestBytes.
+const DefaultMaxRequestBytes = 10 << 20
+
+// Request is a SOAP request received by a Server.
+type Request struct {
+	Action      string        // SOAPAction, or the SOAP 1.2 action parameter
+	Version     Version       // SOAP version of the envelope
+	Element     xml.Name      // first element in the envelope Body
+	Operation   string        // Name of the operation the request is dispatched to
+	Envelope    []byte        // request envelope, decompressed, with MTOM attachments inlined
+	MTOM        bool          // whether the request is an MTOM message
+	HTTPRequest *http.Request // HTTP request, whose body has been read
+}
+
+// Decode unmarshals the body element of the request onto v. Namespace
+// prefixes declared on the envelope are resolved.
+func (r *Request) Decode(v any) error {
+	d, start, err := r.element("Body")
+	if err != nil || start == nil {
+		return err
+	}
+	return r.decodeElement(d, v, start)
+}
+
+// DecodeBody unmarshals the SOAP Body of the request onto v, whose
+// fields match the elements in the body, as the operation wrappers of
+// generated code do.
+func (r *Request) DecodeBody(v any) error {
+	d := r.decoder()
+	for depth := 0; ; {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 && t.Name.Local == "Body" {
+				return r.decodeElement(d, v, &t)
+			}
+			if depth == 2 {
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				depth--
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+// DecodeHeader unmarshals the SOAP Header of the request onto v, whose
+// fields match the header elements. Requests without a header leave v
+// unchanged.
+func (r *Request) DecodeHeader(v any) error {
+	d := r.decoder()
+	for depth := 0; ; {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 && t.Name.Local == "Header" {
+				return r.decodeElement(d, v, &t)
+			}
+			if depth == 2 {
+				return nil
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+// decodeElement decodes the element start onto v, resolving the QName
+// values of v left unresolved against the whole envelope.
+func (r *Request) decodeElement(d *xml.Decoder, v any, start *xml.StartElement) error {
+	if err := d.DecodeElement(v, start); err != nil {
+		return err
+	}
+	resolveQNames(v, r.Envelope)
+	return nil
+}
+
+func (r *Request) decoder() *xml.Decoder {
+	d := xml.NewDecoder(bytes.NewReader(r.Envelope))
+	d.CharsetReader = charset.NewReaderLabel
+	return d
+}
+
+// element returns a decoder positioned after the start of the first
+// child of the envelope part name, and that start element, or nil if the
+// part is empty or missing.
+func (r *Request) element(name string) (*xml.Decoder, *xml.StartElement, error) {
+	d := r.decoder()
+	depth := 0
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil, nil, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case depth == 2 && t.Name.Local != name:
+				if err := d.Skip(); err != nil {
+					return nil, nil, err
+				}
+				depth--
+			case depth == 3:
+				return d, &t, nil
+			}
+		case xml.EndElement:
+			depth--
+			if depth == 1 {
+				return nil, nil, nil
+			}
+		}
+	}
+}
+
+// OperationHandler serves the requests of an operation. The returned
+// message is sent as the content of the response Body, along with a
+// SOAP Header if it is a *HeaderMessage; returning a FaultError, such
+// as a *Fault, sends its SOAP fault, and other errors are sent as
+// server faults with their message.
+type OperationHandler func(ctx context.Context, r *Request) (Message, error)
+
+// HeaderMessage is a response message of an OperationHandler sent with
+// the SOAP Header element Header, such as the soap:header parts of the
+// outputs of generated operations.
+type HeaderMessage struct {
+	Header Message
+	Body   Message
+}
+
+// Operation is an operation served by a Server.
+type Operation struct {
+	Name     string           // Name of the operation, for faults
+	Action   string           // Optional SOAPAction of requests
+	Request  xml.Name         // Body element of requests; an empty namespace matches any
+	Response xml.Name         // Optional element of responses (default the XMLName or type name of the message)
+	Handler  OperationHandler // Handler of the requests
+
+	// ResponseBody sends the fields of response messages as the
+	// content of the Body, rather than the message as an element in
+	// it, for the operation wrappers of generated code.
+	ResponseBody bool
+}
+
+// Server is an http.Handler serving SOAP 1.1 and 1.2 endpoints, such as
+// the generated service interfaces implemented by the application:
+//
+//	srv := soap.NewServer()
+//	srv.Namespace = example.Namespace
+//	if err := srv.Register(&echoService{}); err != nil {
+//		log.Fatal(err)
+//	}
+//	http.Handle("/echo", srv)
+//
+// Requests are dispatched to operations by their SOAP action, or else
+// the element in their Body, and are answered with an envelope of the
+// same SOAP version. Servers with a WSDL answer GET requests for ?wsdl
+// with it, as clients expect for discovery. A Server is safe for
+// concurrent use.
+type Server struct {
+	Namespace       string            // Optional default namespace of response bodies
+	MaxRequestBytes int64             // Optional limit on the size of requests (default DefaultMaxRequestBytes)
+	WSDL            []byte            // Optional WSDL document served at ?wsdl
+	Documents       map[string][]byte // Optional documents imported by the WSDL, by their location in it, served at ?xsd=location
+	MTOM            bool              // Optional MTOM encoding of all responses, not only those to MTOM requests
+
+	mu         sync.RWMutex
+	ops        []*Operation
+	middleware []ServerMiddleware
+}
+
+// NewServer returns a Server without operations.
+func NewServer() *Server {
+	return &Server{}
+}
+
+// Handle adds the operation op to the server.
+func (s *Server) Handle(op Operation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ops = append(s.ops, &op)
+}
+
+// HandleFunc adds the operation name to the server, for requests with
+// the SOAP action action, if not empty, or the body element request.
+func (s *Server) HandleFunc(name, action string, request xml.Name, h OperationHandler) {
+	s.Handle(Operation{Name: name, Action: action, Request: request, Handler: h})
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Register adds an operation for each exported method of svc with the
+// signature
+//
+//	func(in *T) (out R, err error)
+//	func(ctx context.Context, in *T) (out R, err error)
+//
+// as the methods of generated service interfaces have. Requests are
+// dispatched to the method by their body element, which must be named
+// after the struct type T or its XMLName tag, and decoded onto in; out
+// is sent as the response.
+func (s *Server) Register(svc any) error {
+	v := reflect.ValueOf(svc)
+	t := v.Type()
+	n := 0
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		if !m.IsExported() {
+			continue
+		}
+		h, in, ok := methodHandler(v.Method(i))
+		if !ok {
+			continue
+		}
+		s.Handle(Operation{Name: m.Name, Request: elementName(in), Handler: h})
+		n++
+	}
+	if n == 0 {
+		return fmt.Errorf("soap: %s has no operation methods", t)
+	}
+	return nil
+}
+
+// methodHandler returns the handler calling the method fn, and the type
+// of its input, if fn has the signature of an operation.
+func methodHandler(fn reflect.Value) (OperationHandler, reflect.Type, bool) {
+	ft := fn.Type()
+	withCtx := ft.NumIn() == 2 && ft.In(0) == contextType
+	if ft.NumIn() != 1 && !withCtx || ft.NumOut() != 2 || ft.Out(1) != errorType {
+		return nil, nil, false
+	}
+	in := ft.In(ft.NumIn() - 1)
+	if in.Kind() != reflect.Pointer || in.Elem().Kind() != reflect.Struct {
+		return nil, nil, false
+	}
+	h := func(ctx context.Context, r *Request) (Message, error) {
+		msg := reflect.New(in.Elem())
+		if err := r.Decode(msg.Interface()); err != nil {
+			return nil, &Fault{Code: "soapenv:Client", String: err.Error()}
+		}
+		args := []reflect.Value{msg}
+		if withCtx {
+			args = []reflect.Value{reflect.ValueOf(ctx), msg}
+		}
+		res := fn.Call(args)
+		if err, _ := res[1].Interface().(error); err != nil {
+			return nil, err
+		}
+		out := res[0]
+		if (out.Kind() == reflect.Pointer || out.Kind() == reflect.Interface) && out.IsNil() {
+			return nil, nil
+		}
+		return out.Interface(), nil
+	}
+	return h, in.Elem(), true
+}
+
+// elementName returns the element name of values of the struct type t,
+// from its XMLName tag or else its name.
+func elementName(t reflect.Type) xml.Name {
+	if f, ok := t.FieldByName("XMLName"); ok {
+		tag, _, _ := strings.Cut(f.Tag.Get("xml"), ",")
+		if tag != "" {
+			if space, local, ok := strings.Cut(tag, " "); ok {
+				return xml.Name{Space: space, Local: local}
+			}
+			return xml.Name{Local: tag}
+		}
+	}
+	return xml.Name{Local: t.Name()}
+}
+
+// operation returns the operation of requests with the SOAP action
+// action and body element el.
+func (s *Server) operation(action string, el xml.Name) *Operation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if action != "" {
+		for _, op := range s.ops {
+			if op.Action == action {
+				return op
+			}
+		}
+	}
+	for _, op := range s.ops {
+		if op.Request == el {
+			return op
+		}
+	}
+	for _, op := range s.ops {
+		if op.Request.Space == "" && op.Request.Local == el.Local {
+			return op
+		}
+	}
+	return nil
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && s.serveDocument(w, r) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		allow := http.MethodPost
+		if s.WSDL != nil {
+			allow = "GET, POST"
+		}
+		w.Header().Set("Allow", allow)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	req, err := s.readRequest(w, r)
+	if err != nil {
+		var fault *Fault
+		if !errors.As(err, &fault) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		s.writeResponse(w, req.Version, false, nil, fault)
+		return
+	}
+	op := s.operation(req.Action, req.Element)
+	if op == nil {
+		s.writeResponse(w, req.Version, false, nil, &Fault{
+			Code:   "soapenv:Client",
+			String: fmt.Sprintf("no operation for action %q and element %s", req.Action, qualifiedName(req.Element)),
+		})
+		return
+	}
+	req.Operation = op.Name
+	msg, err := s.handler(op)(r.Context(), req)
+	if err != nil {
+		s.writeResponse(w, req.Version, false, nil, faultOf(err))
+		return
+	}
+	body := &serverBody{msg: msg, name: op.Response, body: op.ResponseBody}
+	if hm, ok := msg.(*HeaderMessage); ok {
+		body.msg, body.header = hm.Body, hm.Header
+	}
+	s.writeResponse(w, req.Version, req.MTOM || s.MTOM, body, nil)
+}
+
+// readRequest reads the envelope of r. Malformed envelopes fail with a
+// *Fault, and requests exceeding MaxRequestBytes with another error.
+func (s *Server) readRequest(w http.ResponseWriter, r *http.Request) (*Request, error) {
+	req := &Request{Action: requestAction(r), HTTPRequest: r}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/soap+xml") {
+		req.Version = SOAP12
+	}
+	limit := s.MaxRequestBytes
+	if limit <= 0 {
+		limit = DefaultMaxRequestBytes
+	}
+	var body io.Reader = http.MaxBytesReader(w, r.Body, limit)
+	if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return req, &Fault{Code: "soapenv:Client", String: err.Error()}
+		}
+		body = &maxBytesReader{r: zr, n: limit}
+	}
+	env, err := io.ReadAll(body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || errors.Is(err, ErrResponseTooLarge) {
+		return req, fmt.Errorf("soap: request exceeds %d bytes", limit)
+	}
+	if err != nil {
+		return req, &Fault{Code: "soapenv:Client", String: err.Error()}
+	}
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "multipart/related" {
+		env, err = decodeMTOM(r.Header.Get("Content-Type"), env)
+		if err != nil {
+			return req, &Fault{Code: "soapenv:Client", String: "malformed MTOM message: " + err.Error()}
+		}
+		req.MTOM = true
+	}
+	req.Envelope = env
+	if err := req.parse(); err != nil {
+		return req, err
+	}
+	return req, nil
+}
+
+// parse sets the version and body element of the request from its
+// envelope.
+func (r *Request) parse() error {
+	d := r.decoder()
+	for depth := 0; ; {
+		tok, err := d.Token()
+		if err != nil {
+			return &Fault{Code: "soapenv:Client", String: "malformed envelope: " + err.Error()}
+		}
+		switch t := tok.(type) {
+		case xml.Directive:
+			return &Fault{Code: "soapenv:Client", String: "DTD directives are not allowed"}
+		case xml.StartElement:
+			depth++
+			switch depth {
+			case 1:
+				switch t.Name {
+				case xml.Name{Space: EnvelopeNamespace11, Local: "Envelope"}:
+					r.Version = SOAP11
+				case xml.Name{Space: EnvelopeNamespace12, Local: "Envelope"}:
+					r.Version = SOAP12
+				default:
+					return &Fault{Code: "soapenv:VersionMismatch", String: "not a SOAP envelope: " + qualifiedName(t.Name)}
+				}
+			case 2:
+				if t.Name.Local != "Body" {
+					if err := d.Skip(); err != nil {
+						return &Fault{Code: "soapenv:Client", String: "malformed envelope: " + err.Error()}
+					}
+					depth--
+				}
+			case 3:
+				r.Element = t.Name
+				return nil
+			}
+		case xml.EndElement:
+			depth--
+			if depth == 1 && t.Name.Local == "Body" {
+				return nil // empty body
+			}
+		}
+	}
+}
+
+// qualifiedName returns name in the {namespace}local notation.
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return "{" + name.Space + "}" + name.Local
+}
+
+// serverBody is the content of the Body of responses.
+type serverBody struct {
+	msg    Message
+	name   xml.Name
+	body   bool    // msg is the Body
+	header Message // optional SOAP Header of the response
+}
+
+// MarshalXML implements the xml.Marshaler interface.
+func (b *serverBody) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if b.body && b.msg != nil {
+		return e.EncodeElement(b.msg, start)
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if b.msg != nil {
+		var err error
+		if b.name.Local != "" {
+			err = e.EncodeElement(b.msg, xml.StartElement{Name: b.name})
+		} else {
+			err = e.Encode(b.msg)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// writeResponse writes the envelope of SOAP version v with body, or
+// fault if not nil, as an MTOM message with the Binary values of body
+// attached if mtom is set.
+func (s *Server) writeResponse(w http.ResponseWriter, v Version, mtom bool, body *serverBody, fault *Fault) {
+	env := &Envelope{EnvelopeAttr: EnvelopeNamespace11, NSAttr: s.Namespace}
+	soapType := "text/xml"
+	if v == SOAP12 {
+		env.EnvelopeAttr = EnvelopeNamespace12
+		soapType = "application/soap+xml"
+	}
+	ct := soapType + "; charset=utf-8"
+	status := http.StatusOK
+	if fault != nil {
+		var f Message
+		f, status = serverFault(fault, v)
+		body = &serverBody{msg: f}
+	}
+	env.Body = body
+	if body != nil && body.header != nil {
+		env.Header = body.header
+	}
+	var b []byte
+	var err error
+	if mtom {
+		b, ct, err = encodeMTOM(env, soapType)
+	} else {
+		var buf bytes.Buffer
+		buf.WriteString(xml.Header)
+		err = xml.NewEncoder(&buf).Encode(env)
+		b = buf.Bytes()
+	}
+	if err != nil {
+		if fault != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.writeResponse(w, v, false, nil, &Fault{Code: "soapenv:Server", String: "cannot encode response: " + err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", ct)
+	w.WriteHeader(status)
+	w.Write(b)
+}
+
+// fault11 is the encoding of SOAP 1.1 faults sent by servers; the
+// default namespace is reset for the unqualified fault elements.
+type fault11 struct {
+	XMLName xml.Name   `xml:"soapenv:Fault"`
+	NSAttr  string     `xml:"xmlns,attr"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Code    string     `xml:"faultcode"`
+	String  string     `xml:"faultstring"`
+	Actor   string     `xml:"faultactor,omitempty"`
+	Detail  *Detail    `xml:"detail,omitempty"`
+}
+
+// fault12 is the encoding of SOAP 1.2 faults sent by servers.
+type fault12 struct {
+	XMLName xml.Name      `xml:"soapenv:Fault"`
+	Attrs   []xml.Attr    `xml:",any,attr"`
+	Code    faultValue12  `xml:"soapenv:Code"`
+	Reason  faultReason12 `xml:"soapenv:Reason"`
+	Node    string        `xml:"soapenv:Node,omitempty"`
+	Role    string        `xml:"soapenv:Role,omitempty"`
+	Detail  *Detail       `xml:"soapenv:Detail,omitempty"`
+}
+
+type faultValue12 struct {
+	Value   string        `xml:"soapenv:Value"`
+	Subcode *faultValue12 `xml:"soapenv:Subcode,omitempty"`
+}
+
+type faultReason12 struct {
+	Text struct {
+		Lang  string `xml:"xml:lang,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"soapenv:Text"`
+}
+
+// faultCodes maps the SOAP 1.1 fault codes to those of SOAP 1.2.
+var faultCodes = map[string]string{
+	"Client":          "Sender",
+	"Server":          "Receiver",
+	"VersionMismatch": "VersionMismatch",
+	"MustUnderstand":  "MustUnderstand",
+}
+
+// faultOf returns the fault the handler error err is sent as: that of
+// the first FaultError in its chain, or else a server fault with its
+// message. Faults whose detail cannot be encoded are server faults.
+func faultOf(err error) *Fault {
+	var fe FaultError
+	if !errors.As(err, &fe) || fe.SOAPFault() == nil {
+		return &Fault{Code: FaultServer, String: err.Error()}
+	}
+	f := fe.SOAPFault()
+	if f.err != nil {
+		return &Fault{Code: FaultServer, String: "cannot encode fault detail: " + f.err.Error()}
+	}
+	return f
+}
+
+// serverFault returns the encoding of f for SOAP version v, and the HTTP
+// status of the response. The standard fault codes, with or without
+// prefix, are sent in the terms of v.
+func serverFault(f *Fault, v Version) (Message, int) {
+	code := f.Code
+	local := code
+	if i := strings.IndexByte(code, ':'); i >= 0 {
+		local = code[i+1:]
+	}
+	if v != SOAP12 {
+		for c11, c12 := range faultCodes {
+			if local == c11 || local == c12 {
+				code = "soapenv:" + c11
+			}
+		}
+		return &fault11{Code: code, Attrs: codeNamespace(f), String: f.String, Actor: f.Actor, Detail: f.Detail}, http.StatusInternalServerError
+	}
+	status := http.StatusInternalServerError
+	for c11, c12 := range faultCodes {
+		if local == c11 || local == c12 {
+			code = "soapenv:" + c12
+			if c12 == "Sender" {
+				status = http.StatusBadRequest
+			}
+		}
+	}
+	f12 := &fault12{Code: faultValue12{Value: code}, Attrs: codeNamespace(f), Node: f.Node, Role: f.Actor, Detail: f.Detail}
+	if f.Subcode != "" {
+		f12.Code.Subcode = &faultValue12{Value: f.Subcode}
+	}
+	f12.Reason.Text.Lang = "en"
+	f12.Reason.Text.Value = f.String
+	return f12, status
+}
+
+// codeNamespace returns the declaration of the CodeNamespace of f, for
+// the prefix of its Code, or else Subcode.
+func codeNamespace(f *Fault) []xml.Attr {
+	if f.CodeNamespace == "" {
+		return nil
+	}
+	for _, c := range []string{f.Code, f.Subcode} {
+		if prefix, _, ok := strings.Cut(c, ":"); ok && prefix != "soapenv" {
+			return []xml.Attr{{Name: xml.Name{Local: "xmlns:" + prefix}, Value: f.CodeNamespace}}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package soap
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type echoRequest struct {
+	Text string `xml:"text"`
+}
+
+type echoResponse struct {
+	Text string `xml:"text"`
+}
+
+type failRequest struct{}
+
+type echoService struct{}
+
+func (echoService) Echo(in *echoRequest) (*echoResponse, error) {
+	return &echoResponse{Text: in.Text}, nil
+}
+
+func (echoService) Fail(ctx context.Context, in *failRequest) (*echoResponse, error) {
+	if ctx == nil {
+		return nil, errors.New("no context")
+	}
+	return nil, &Fault{Code: "Client", String: "failed", Detail: &Detail{Content: []byte("<reason>test</reason>")}}
+}
+
+func (echoService) Helper(s string) string { return s }
+
+func TestServer(t *testing.T) {
+	srv := NewServer()
+	srv.Namespace = "urn:echo"
+	if err := srv.Register(echoService{}); err != nil {
+		t.Fatal(err)
+	}
+	srv.HandleFunc("Boom", "urn:echo/Boom", xml.Name{}, func(ctx context.Context, r *Request) (Message, error) {
+		return nil, errors.New("boom")
+	})
+	s := httptest.NewServer(srv)
+	defer s.Close()
+
+	for _, v := range []Version{SOAP11, SOAP12} {
+		c := NewClient(s.URL, WithNamespace("urn:echo"), WithVersion(v))
+		var out struct {
+			Msg echoResponse `xml:"echoResponse"`
+		}
+		if err := c.RoundTrip(&struct {
+			M echoRequest `xml:"echoRequest"`
+		}{echoRequest{Text: "hello"}}, &out); err != nil {
+			t.Fatalf("version %d: %v", v, err)
+		}
+		if out.Msg.Text != "hello" {
+			t.Fatalf("version %d: unexpected response %+v", v, out)
+		}
+
+		err := c.RoundTrip(&struct {
+			M failRequest `xml:"failRequest"`
+		}{}, nil)
+		var fault *Fault
+		if !errors.As(err, &fault) || fault.String != "failed" || fault.Detail == nil ||
+			!strings.Contains(string(fault.Detail.Content), "<reason>test</reason>") {
+			t.Fatalf("version %d: unexpected error %v", v, err)
+		}
+		wantCode := map[Version]string{SOAP11: "soapenv:Client", SOAP12: "soapenv:Sender"}[v]
+		if fault.Code != wantCode {
+			t.Fatalf("version %d: fault code %q, want %q", v, fault.Code, wantCode)
+		}
+
+		err = c.RoundTripWithSOAPAction("urn:echo/Boom", &struct {
+			M struct{} `xml:"anything"`
+		}{}, nil)
+		if !errors.As(err, &fault) || fault.String != "boom" || !strings.HasSuffix(fault.Code, map[Version]string{SOAP11: "Server", SOAP12: "Receiver"}[v]) {
+			t.Fatalf("version %d: unexpected error %v", v, err)
+		}
+
+		err = c.RoundTrip(&struct {
+			M struct{} `xml:"unknown"`
+		}{}, nil)
+		if !errors.As(err, &fault) || !strings.Contains(fault.String, "no operation") {
+			t.Fatalf("version %d: unexpected error %v", v, err)
+		}
+	}
+}
+
+func TestServerRequests(t *testing.T) {
+	srv := NewServer()
+	var header struct {
+		Token string `xml:"urn:auth Token"`
+	}
+	var req struct {
+		XMLName xml.Name `xml:"urn:echo echoRequest"`
+		Text    string   `xml:"urn:echo text"`
+	}
+	srv.HandleFunc("Echo", "", xml.Name{Space: "urn:echo", Local: "echoRequest"}, func(ctx context.Context, r *Request) (Message, error) {
+		if err := r.DecodeHeader(&header); err != nil {
+			return nil, err
+		}
+		if err := r.Decode(&req); err != nil {
+			return nil, err
+		}
+		return &echoResponse{Text: req.Text}, nil
+	})
+	srv.MaxRequestBytes = 1024
+	s := httptest.NewServer(srv)
+	defer s.Close()
+
+	cases := []struct {
+		Name   string
+		Method string
+		Body   string
+		Status int
+		Want   string
+	}{
+		{
+			Name: "prefixes",
+			Body: `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" xmlns:e="urn:echo" xmlns:a="urn:auth">` +
+				`<s:Header><a:Token>t1</a:Token></s:Header><s:Body><e:echoRequest><e:text>hi</e:text></e:echoRequest></s:Body></s:Envelope>`,
+			Status: http.StatusOK,
+			Want:   "<echoResponse><text>hi</text></echoResponse>",
+		},
+		{
+			Name:   "element namespace",
+			Body:   `<Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Body><echoRequest xmlns="urn:other"/></Body></Envelope>`,
+			Status: http.StatusInternalServerError,
+			Want:   "no operation",
+		},
+		{
+			Name:   "not soap",
+			Body:   `<Envelope><Body/></Envelope>`,
+			Status: http.StatusInternalServerError,
+			Want:   "<faultcode>soapenv:VersionMismatch</faultcode>",
+		},
+		{
+			Name:   "malformed",
+			Body:   `<Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Body>`,
+			Status: http.StatusInternalServerError,
+			Want:   "malformed envelope",
+		},
+		{
+			Name:   "too large",
+			Body:   `<Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Body>` + strings.Repeat(" ", 1024) + `</Body></Envelope>`,
+			Status: http.StatusRequestEntityTooLarge,
+		},
+		{
+			Name:   "get",
+			Method: http.MethodGet,
+			Status: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			method := tc.Method
+			if method == "" {
+				method = http.MethodPost
+			}
+			r, err := http.NewRequest(method, s.URL, strings.NewReader(tc.Body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.Header.Set("Content-Type", "text/xml")
+			resp, err := http.DefaultClient.Do(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			b, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tc.Status || !strings.Contains(string(b), tc.Want) {
+				t.Fatalf("unexpected response %d: %s", resp.StatusCode, b)
+			}
+		})
+	}
+	if header.Token != "t1" {
+		t.Fatalf("header not decoded: %+v", header)
+	}
+}
+
+func TestServerRegister(t *testing.T) {
+	if err := NewServer().Register(struct{}{}); err == nil {
+		t.Fatal("registered a service without operations")
+	}
+	type named struct {
+		XMLName xml.Name `xml:"urn:x Op"`
+	}
+	cases := []struct {
+		V    any
+		Want xml.Name
+	}{
+		{echoRequest{}, xml.Name{Local: "echoRequest"}},
+		{named{}, xml.Name{Space: "urn:x", Local: "Op"}},
+	}
+	for _, tc := range cases {
+		if have := elementName(reflect.TypeOf(tc.V)); have != tc.Want {
+			t.Errorf("elementName(%T) = %v, want %v", tc.V, have, tc.Want)
+		}
+	}
+}
+
+func TestServerResponseBody(t *testing.T) {
+	type wrapper struct {
+		Request *echoRequest `xml:"echoRequest"`
+	}
+	srv := NewServer()
+	srv.Handle(Operation{
+		Name:         "Echo",
+		Request:      xml.Name{Local: "echoRequest"},
+		ResponseBody: true,
+		Handler: func(ctx context.Context, r *Request) (Message, error) {
+			var in wrapper
+			if err := r.DecodeBody(&in); err != nil {
+				return nil, err
+			}
+			if in.Request == nil {
+				return nil, errors.New("no request")
+			}
+			detail, err := NewDetail(xml.Name{Space: "urn:echo", Local: "reason"}, in.Request.Text)
+			if err != nil {
+				return nil, err
+			}
+			if in.Request.Text == "fail" {
+				return nil, &Fault{Code: "Server", String: "failed", Detail: detail}
+			}
+			return &struct {
+				Response *echoResponse `xml:"echoResponse"`
+			}{&echoResponse{Text: in.Request.Text}}, nil
+		},
+	})
+	s := httptest.NewServer(srv)
+	defer s.Close()
+
+	c := NewClient(s.URL)
+	var out struct {
+		Msg echoResponse `xml:"echoResponse"`
+	}
+	if err := c.RoundTrip(&struct {
+		M echoRequest `xml:"echoRequest"`
+	}{echoRequest{Text: "hello"}}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Msg.Text != "hello" {
+		t.Fatalf("unexpected response %+v", out)
+	}
+	err := c.RoundTrip(&struct {
+		M echoRequest `xml:"echoRequest"`
+	}{echoRequest{Text: "fail"}}, nil)
+	var fault *Fault
+	if !errors.As(err, &fault) || fault.Detail == nil ||
+		string(fault.Detail.Content) != `<reason xmlns="urn:echo">fail</reason>` {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestServerHeaders(t *testing.T) {
+	type session struct {
+		ID string `xml:"urn:echo Session"`
+	}
+	type headers struct {
+		Session *session `xml:"urn:echo Session"`
+	}
+	srv := NewServer()
+	srv.Handle(Operation{
+		Name:         "Echo",
+		Request:      xml.Name{Local: "echoRequest"},
+		ResponseBody: true,
+		Handler: func(ctx context.Context, r *Request) (Message, error) {
+			var in headers
+			if err := r.DecodeHeader(&in); err != nil {
+				return nil, err
+			}
+			if in.Session == nil {
+				return nil, errors.New("no session")
+			}
+			return &HeaderMessage{
+				Header: &headers{&session{ID: in.Session.ID + "-renewed"}},
+				Body: &struct {
+					Response *echoResponse `xml:"echoResponse"`
+				}{&echoResponse{Text: "hello"}},
+			}, nil
+		},
+	})
+	s := httptest.NewServer(srv)
+	defer s.Close()
+
+	c := NewClient(s.URL)
+	var hout headers
+	var out struct {
+		Msg echoResponse `xml:"echoResponse"`
+	}
+	err := c.WithHeaders(&headers{&session{ID: "s1"}}, &hout).RoundTrip(&struct {
+		M echoRequest `xml:"echoRequest"`
+	}{echoRequest{Text: "hello"}}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Msg.Text != "hello" || hout.Session == nil || hout.Session.ID != "s1-renewed" {
+		t.Fatalf("unexpected response %+v, header %+v", out, hout.Session)
+	}
+	if c.Header != nil {
+		t.Fatal("WithHeaders changed the header of the client")
+	}
+}
@@ -0,0 +1,116 @@
+package soap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+// ServerMiddleware wraps the handlers of the operations of a Server with
+// additional behavior, such as authentication, logging, panic recovery
+// or metrics. The Operation of the request is set, and its SOAP headers
+// can be decoded with DecodeHeader; returning a *Fault, without calling
+// next, rejects the request.
+type ServerMiddleware func(next OperationHandler) OperationHandler
+
+// Use adds middleware to the server. The first middleware added is the
+// outermost, and sees requests first.
+func (s *Server) Use(mw ...ServerMiddleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.middleware = append(s.middleware, mw...)
+}
+
+// handler returns the handler of op wrapped in the server middleware.
+func (s *Server) handler(op *Operation) OperationHandler {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	h := op.Handler
+	for i := len(s.middleware) - 1; i >= 0; i-- {
+		h = s.middleware[i](h)
+	}
+	return h
+}
+
+// RecoverMiddleware returns a ServerMiddleware that turns panics of
+// handlers into server faults, without their message, and logs them
+// with their stack to logger.
+func RecoverMiddleware(logger *slog.Logger) ServerMiddleware {
+	return func(next OperationHandler) OperationHandler {
+		return func(ctx context.Context, r *Request) (msg Message, err error) {
+			defer func() {
+				if v := recover(); v != nil {
+					logger.LogAttrs(ctx, slog.LevelError, "soap handler panic",
+						slog.String("operation", r.Operation),
+						slog.String("panic", fmt.Sprint(v)),
+						slog.String("stack", string(debug.Stack())))
+					msg, err = nil, &Fault{Code: "soapenv:Server", String: "internal server error"}
+				}
+			}()
+			return next(ctx, r)
+		}
+	}
+}
+
+// ServerLoggingMiddleware returns a ServerMiddleware that logs every
+// request to logger: a summary at Info level, or Error level when the
+// handler fails, and the request envelope at Debug level.
+func ServerLoggingMiddleware(logger *slog.Logger) ServerMiddleware {
+	return func(next OperationHandler) OperationHandler {
+		return func(ctx context.Context, r *Request) (Message, error) {
+			if logger.Enabled(ctx, slog.LevelDebug) {
+				logger.LogAttrs(ctx, slog.LevelDebug, "soap request",
+					slog.String("operation", r.Operation),
+					slog.String("envelope", string(r.Envelope)))
+			}
+			start := time.Now()
+			msg, err := next(ctx, r)
+			attrs := []slog.Attr{
+				slog.String("operation", r.Operation),
+				slog.String("action", r.Action),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if r.HTTPRequest != nil {
+				attrs = append(attrs, slog.String("remote", r.HTTPRequest.RemoteAddr))
+			}
+			if err != nil {
+				logger.LogAttrs(ctx, slog.LevelError, "soap request failed",
+					append(attrs, slog.String("error", err.Error()))...)
+				return nil, err
+			}
+			logger.LogAttrs(ctx, slog.LevelInfo, "soap request", attrs...)
+			return msg, nil
+		}
+	}
+}
+
+// ServerMetricsMiddleware returns a ServerMiddleware that reports the
+// requests of operations to m, as MetricsMiddleware does for calls.
+// BytesIn is the size of the request envelope; BytesOut and StatusCode
+// are not known to handlers, and are zero.
+func ServerMetricsMiddleware(m Metrics) ServerMiddleware {
+	return func(next OperationHandler) OperationHandler {
+		return func(ctx context.Context, r *Request) (Message, error) {
+			start := time.Now()
+			msg, err := next(ctx, r)
+			o := OutcomeSuccess
+			var fault FaultError
+			switch {
+			case errors.As(err, &fault):
+				o = OutcomeFault
+			case err != nil:
+				o = OutcomeError
+			}
+			m.ObserveCall(ctx, &CallMetrics{
+				Operation: r.Operation,
+				Duration:  time.Since(start),
+				BytesIn:   int64(len(r.Envelope)),
+				Outcome:   o,
+			})
+			return msg, err
+		}
+	}
+}
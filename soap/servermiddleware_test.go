@@ -0,0 +1,99 @@
+package soap
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestServerMiddleware(t *testing.T) {
+	srv := NewServer()
+	if err := srv.Register(echoService{}); err != nil {
+		t.Fatal(err)
+	}
+	srv.HandleFunc("Panic", "urn:echo/Panic", xml.Name{}, func(ctx context.Context, r *Request) (Message, error) {
+		panic("bad input")
+	})
+	var order []string
+	var logs bytes.Buffer
+	var mu sync.Mutex
+	var metrics []*CallMetrics
+	auth := func(next OperationHandler) OperationHandler {
+		return func(ctx context.Context, r *Request) (Message, error) {
+			order = append(order, "auth "+r.Operation)
+			var h struct {
+				Token string `xml:"Token"`
+			}
+			if err := r.DecodeHeader(&h); err != nil {
+				return nil, err
+			}
+			if h.Token != "secret" {
+				return nil, &Fault{Code: "Client", String: "unauthorized"}
+			}
+			return next(ctx, r)
+		}
+	}
+	srv.Use(
+		ServerMetricsMiddleware(MetricsFunc(func(ctx context.Context, m *CallMetrics) {
+			mu.Lock()
+			defer mu.Unlock()
+			metrics = append(metrics, m)
+		})),
+		ServerLoggingMiddleware(slog.New(slog.NewTextHandler(&logs, nil))),
+		RecoverMiddleware(slog.New(slog.NewTextHandler(&logs, nil))),
+		auth,
+	)
+	s := httptest.NewServer(srv)
+	defer s.Close()
+
+	type token struct {
+		Token string `xml:"Token"`
+	}
+	c := NewClient(s.URL, WithSOAPHeader(&token{"secret"}))
+	var out struct {
+		Msg echoResponse `xml:"echoResponse"`
+	}
+	if err := c.RoundTrip(&struct {
+		M echoRequest `xml:"echoRequest"`
+	}{echoRequest{Text: "hello"}}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Msg.Text != "hello" {
+		t.Fatalf("unexpected response %+v", out)
+	}
+
+	err := NewClient(s.URL).RoundTrip(&struct {
+		M echoRequest `xml:"echoRequest"`
+	}{}, nil)
+	var fault *Fault
+	if !errors.As(err, &fault) || fault.String != "unauthorized" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	err = c.RoundTripWithSOAPAction("urn:echo/Panic", &struct {
+		M struct{} `xml:"panic"`
+	}{}, nil)
+	if !errors.As(err, &fault) || fault.String != "internal server error" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if strings.Join(order, ",") != "auth Echo,auth Echo,auth Panic" {
+		t.Fatalf("unexpected order %q", order)
+	}
+	if !strings.Contains(logs.String(), "panic=\"bad input\"") || !strings.Contains(logs.String(), "operation=Echo") {
+		t.Fatalf("unexpected logs:\n%s", logs.String())
+	}
+	var outcomes []string
+	for _, m := range metrics {
+		outcomes = append(outcomes, m.Operation+" "+string(m.Outcome))
+	}
+	if strings.Join(outcomes, ",") != "Echo success,Echo fault,Panic fault" {
+		t.Fatalf("unexpected metrics %q", outcomes)
+	}
+}
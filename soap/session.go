@@ -0,0 +1,65 @@
+package soap
+
+import (
+	"net/http"
+	"sync"
+)
+
+// A Session carries server-side session state across calls of a
+// stateful conversation. Inject is called on every outbound request,
+// Extract on every inbound response, including error responses.
+type Session interface {
+	Inject(r *http.Request)
+	Extract(resp *http.Response)
+}
+
+// HeaderSession is a Session that captures the value of an HTTP header
+// from responses and echoes it back on subsequent requests, as done by
+// servers that keep session IDs in headers rather than cookies.
+//
+// It is safe for concurrent use.
+type HeaderSession struct {
+	Name string // HTTP header carrying the session ID
+
+	mu    sync.Mutex
+	value string
+}
+
+// Inject implements the Session interface.
+func (s *HeaderSession) Inject(r *http.Request) {
+	if v := s.Value(); v != "" {
+		r.Header.Set(s.Name, v)
+	}
+}
+
+// Extract implements the Session interface.
+func (s *HeaderSession) Extract(resp *http.Response) {
+	v := resp.Header.Get(s.Name)
+	if v == "" {
+		return
+	}
+	s.mu.Lock()
+	s.value = v
+	s.mu.Unlock()
+}
+
+// Value returns the current session ID.
+func (s *HeaderSession) Value() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.value
+}
+
+// Reset discards the session ID, starting a new session on the next call.
+func (s *HeaderSession) Reset() {
+	s.mu.Lock()
+	s.value = ""
+	s.mu.Unlock()
+}
+
+// injectCookies adds the cookies stored in jar to r.
+func injectCookies(jar http.CookieJar, r *http.Request) {
+	for _, cookie := range jar.Cookies(r.URL) {
+		r.AddCookie(cookie)
+	}
+}
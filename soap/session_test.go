@@ -0,0 +1,49 @@
+package soap
+
+import (
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRoundTripSession(t *testing.T) {
+	type msgT struct{ A, B string }
+	type envT struct{ msgT }
+	n := 0
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		if n == 1 {
+			http.SetCookie(w, &http.Cookie{Name: "JSESSIONID", Value: "c1"})
+			w.Header().Set("sap-contextid", "s1")
+		} else {
+			if cookie, err := r.Cookie("JSESSIONID"); err != nil || cookie.Value != "c1" {
+				http.Error(w, "missing session cookie", http.StatusBadRequest)
+				return
+			}
+			if r.Header.Get("sap-contextid") != "s1" {
+				http.Error(w, "missing session header", http.StatusBadRequest)
+				return
+			}
+		}
+		io.Copy(w, r.Body)
+	})
+	s := httptest.NewServer(echo)
+	defer s.Close()
+	jar, _ := cookiejar.New(nil)
+	session := &HeaderSession{Name: "sap-contextid"}
+	c := &Client{URL: s.URL, Jar: jar, Session: session}
+	for i := 0; i < 2; i++ {
+		if err := c.RoundTrip(&msgT{A: "hello"}, &envT{}); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	if session.Value() != "s1" {
+		t.Fatalf("unexpected session %q", session.Value())
+	}
+	session.Reset()
+	if err := c.RoundTrip(&msgT{A: "hello"}, &envT{}); err == nil {
+		t.Fatal("expected error after session reset")
+	}
+}
@@ -0,0 +1,64 @@
+package soap
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// sniffLen is the number of bytes of a response inspected to tell XML
+// from other content, and the size of the snippet of NotXMLError.
+const sniffLen = 512
+
+// NotXMLError is returned when a successful response is not an XML
+// document, such as the HTML error page of a gateway or proxy.
+type NotXMLError struct {
+	StatusCode  int
+	ContentType string
+	Snippet     string // Start of the response body
+}
+
+func (e *NotXMLError) Error() string {
+	return fmt.Sprintf("soap: response is not XML (status %d, content type %q): %q",
+		e.StatusCode, e.ContentType, e.Snippet)
+}
+
+// sniffXML returns body if it looks like an XML document, or a
+// *NotXMLError. Bodies that can't be told apart, such as empty or
+// binary ones, are left to the decoder.
+func sniffXML(resp *http.Response, body io.Reader) (io.Reader, error) {
+	br := bufio.NewReaderSize(body, sniffLen)
+	head, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	ct := resp.Header.Get("Content-Type")
+	mt, _, _ := mime.ParseMediaType(ct)
+	if mt == "text/html" || !looksLikeXML(head) {
+		return nil, &NotXMLError{
+			StatusCode:  resp.StatusCode,
+			ContentType: ct,
+			Snippet:     strings.TrimSpace(string(head)),
+		}
+	}
+	return br, nil
+}
+
+// looksLikeXML reports whether b, the start of a document, may be XML:
+// it doesn't start with text or an HTML element.
+func looksLikeXML(b []byte) bool {
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	b = bytes.TrimLeft(b, " \t\r\n")
+	if len(b) == 0 {
+		return true
+	}
+	if b[0] != '<' {
+		return b[0] < 0x21 || b[0] > 0x7e
+	}
+	lower := bytes.ToLower(b[:min(len(b), 14)])
+	return !bytes.HasPrefix(lower, []byte("<html")) && !bytes.HasPrefix(lower, []byte("<!doctype html"))
+}
@@ -0,0 +1,59 @@
+package soap
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLooksLikeXML(t *testing.T) {
+	testCases := []struct {
+		in   string
+		want bool
+	}{
+		{"", true},
+		{"<Envelope/>", true},
+		{"\xef\xbb\xbf<?xml version=\"1.0\"?><Envelope/>", true},
+		{"\n  <soap:Envelope/>", true},
+		{"\xff\xfe<\x00", true},
+		{"<!DOCTYPE html><html></html>", false},
+		{"<HTML><body>Bad Gateway</body></HTML>", false},
+		{"Service Unavailable", false},
+		{"{\"error\": \"quota\"}", false},
+	}
+	for i, tc := range testCases {
+		if have := looksLikeXML([]byte(tc.in)); have != tc.want {
+			t.Errorf("test %d: %q: want %v, have %v", i, tc.in, tc.want, have)
+		}
+	}
+}
+
+func TestNotXMLError(t *testing.T) {
+	type msgT struct{ A, B string }
+	type envT struct{ msgT }
+	page := "<html><body><h1>Maintenance</h1>" + strings.Repeat(".", 1000) + "</body></html>"
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("SOAPAction") == "urn:test/Echo" {
+			io.Copy(w, r.Body)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, page)
+	}))
+	defer s.Close()
+	c := NewClient(s.URL, WithNamespace("urn:test"))
+	if err := c.RoundTripWithAction("Echo", &msgT{A: "hello"}, &envT{}); err != nil {
+		t.Fatal(err)
+	}
+	err := c.RoundTripWithAction("Other", &msgT{A: "hello"}, &envT{})
+	var nerr *NotXMLError
+	if !errors.As(err, &nerr) {
+		t.Fatalf("want NotXMLError, have %v", err)
+	}
+	if nerr.ContentType != "text/html; charset=utf-8" || nerr.Snippet != page[:sniffLen] {
+		t.Fatalf("unexpected error %#v", nerr)
+	}
+}
@@ -0,0 +1,225 @@
+// Package soaptest provides a fake SOAP server for testing code that
+// uses soap.Client.
+//
+// The server is primed with a response envelope per SOAP action, checks
+// that incoming requests carry a known action and a well-formed
+// envelope, and records every call for later assertions:
+//
+//	srv := soaptest.NewServer()
+//	defer srv.Close()
+//	srv.Handle("urn:example/Echo", "EchoRequest", soaptest.Envelope(`<EchoResponse>hi</EchoResponse>`))
+//	cli := &soap.Client{URL: srv.URL, Namespace: "urn:example"}
+//	...
+//	calls := srv.Calls()
+package soaptest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Envelope wraps body in a SOAP 1.1 envelope.
+func Envelope(body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/">` +
+		`<soapenv:Body>` + body + `</soapenv:Body></soapenv:Envelope>`
+}
+
+// FaultEnvelope returns a SOAP 1.1 envelope with a fault.
+func FaultEnvelope(code, msg string) string {
+	var b strings.Builder
+	b.WriteString("<soapenv:Fault><faultcode>")
+	xml.EscapeText(&b, []byte(code))
+	b.WriteString("</faultcode><faultstring>")
+	xml.EscapeText(&b, []byte(msg))
+	b.WriteString("</faultstring></soapenv:Fault>")
+	return Envelope(b.String())
+}
+
+// Call is a request received by the Server.
+type Call struct {
+	Action   string      // SOAP action of the request
+	Header   http.Header // HTTP headers of the request
+	Element  xml.Name    // first element in the envelope Body
+	Envelope []byte      // request envelope, decompressed
+	Err      error       // validation error, if the request was rejected
+}
+
+type handler struct {
+	element  string
+	status   int
+	envelope string
+}
+
+// Server is a fake SOAP server. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	handlers map[string]handler
+	calls    []*Call
+}
+
+// NewServer starts and returns a new Server. The caller should call
+// Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{handlers: make(map[string]handler)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Handle primes the server to respond to action with envelope. If
+// element is not empty, the local name of the first element in the
+// request Body must match it.
+func (s *Server) Handle(action, element, envelope string) {
+	s.HandleStatus(action, element, http.StatusOK, envelope)
+}
+
+// HandleFault primes the server to respond to action with a SOAP fault.
+func (s *Server) HandleFault(action, element, code, msg string) {
+	s.HandleStatus(action, element, http.StatusInternalServerError, FaultEnvelope(code, msg))
+}
+
+// HandleStatus is like Handle but responds with the given HTTP status.
+func (s *Server) HandleStatus(action, element string, status int, envelope string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[action] = handler{element: element, status: status, envelope: envelope}
+}
+
+// Calls returns the requests received so far, in order.
+func (s *Server) Calls() []*Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Call(nil), s.calls...)
+}
+
+// Errors returns the validation errors of rejected requests.
+func (s *Server) Errors() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	for _, c := range s.calls {
+		if c.Err != nil {
+			errs = append(errs, c.Err)
+		}
+	}
+	return errs
+}
+
+// Reset forgets recorded calls. Primed responses are kept.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	c := &Call{Action: action(r), Header: r.Header.Clone()}
+	s.mu.Lock()
+	s.calls = append(s.calls, c)
+	h, ok := s.handlers[c.Action]
+	s.mu.Unlock()
+
+	status, envelope := h.status, h.envelope
+	c.Envelope, c.Element, c.Err = readEnvelope(r)
+	switch {
+	case c.Err != nil:
+	case r.Method != http.MethodPost:
+		c.Err = fmt.Errorf("soaptest: unexpected method %s", r.Method)
+	case !ok:
+		c.Err = fmt.Errorf("soaptest: unexpected action %q", c.Action)
+	case h.element != "" && c.Element.Local != h.element:
+		c.Err = fmt.Errorf("soaptest: action %q: unexpected body element %q, want %q",
+			c.Action, c.Element.Local, h.element)
+	}
+	if c.Err != nil {
+		status, envelope = http.StatusBadRequest, FaultEnvelope("soapenv:Client", c.Err.Error())
+	}
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.WriteHeader(status)
+	io.WriteString(w, envelope)
+}
+
+// action returns the SOAP action of r, from the SOAPAction header or the
+// action parameter of a SOAP 1.2 Content-Type.
+func action(r *http.Request) string {
+	if v := r.Header.Get("SOAPAction"); v != "" {
+		return strings.Trim(v, `"`)
+	}
+	for _, p := range strings.Split(r.Header.Get("Content-Type"), ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+		if ok && strings.EqualFold(k, "action") {
+			return strings.Trim(v, `"`)
+		}
+	}
+	return ""
+}
+
+// readEnvelope reads the request envelope and returns the name of the
+// first element in its Body.
+func readEnvelope(r *http.Request) ([]byte, xml.Name, error) {
+	var body io.Reader = r.Body
+	if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, xml.Name{}, err
+		}
+		body = zr
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, xml.Name{}, err
+	}
+	name, err := bodyElement(b)
+	return b, name, err
+}
+
+func bodyElement(b []byte) (xml.Name, error) {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	var depth int
+	var envelope xml.Name
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return xml.Name{}, fmt.Errorf("soaptest: no Body element in envelope")
+		}
+		if err != nil {
+			return xml.Name{}, fmt.Errorf("soaptest: malformed envelope: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case depth == 1:
+				if t.Name.Local != "Envelope" {
+					return xml.Name{}, fmt.Errorf("soaptest: unexpected root element %q", t.Name.Local)
+				}
+				envelope = t.Name
+			case depth == 2 && t.Name.Local != "Body" && t.Name.Local != "Header":
+				// Messages with their own XMLName are sent
+				// without a Body element.
+				return t.Name, nil
+			case depth == 3:
+				return t.Name, nil
+			case depth == 2 && t.Name.Local == "Header":
+				if err := d.Skip(); err != nil {
+					return xml.Name{}, fmt.Errorf("soaptest: malformed envelope: %w", err)
+				}
+				depth--
+			}
+		case xml.EndElement:
+			depth--
+			if depth == 1 && t.Name.Local == "Body" {
+				return xml.Name{}, fmt.Errorf("soaptest: empty Body in %s", envelope.Local)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package soaptest
+
+import (
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"github.com/YapealAG/wsdl2go/soap"
+)
+
+type echoRequest struct {
+	Data string `xml:"Data"`
+}
+
+type echoResponse struct {
+	Data string `xml:"Data"`
+}
+
+func TestServer(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Handle("urn:test/Echo", "Echo", Envelope(`<EchoResponse><Data>hi</Data></EchoResponse>`))
+	srv.HandleFault("urn:test/Fail", "", "soapenv:Server", "broken")
+
+	cli := soap.NewClient(srv.URL, soap.WithNamespace("urn:test"), soap.WithCompression())
+	in := struct {
+		M echoRequest `xml:"Echo"`
+	}{echoRequest{Data: "hello"}}
+	out := struct {
+		M echoResponse `xml:"EchoResponse"`
+	}{}
+	if err := cli.RoundTripWithAction("Echo", &in, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.M.Data != "hi" {
+		t.Fatalf("unexpected response %q", out.M.Data)
+	}
+
+	var fault *soap.Fault
+	err := cli.RoundTripWithAction("Fail", &in, &out)
+	if !errors.As(err, &fault) || fault.String != "broken" {
+		t.Fatalf("unexpected error %v", err)
+	}
+	err = cli.RoundTripWithAction("Other", &in, &out)
+	if !errors.As(err, &fault) || fault.Code != "soapenv:Client" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	calls := srv.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("unexpected calls: %d", len(calls))
+	}
+	if calls[0].Action != "urn:test/Echo" || calls[0].Element != (xml.Name{Space: "urn:test", Local: "Echo"}) {
+		t.Fatalf("unexpected call %#v", calls[0])
+	}
+	if errs := srv.Errors(); len(errs) != 1 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+	srv.Reset()
+	if len(srv.Calls()) != 0 {
+		t.Fatal("calls not reset")
+	}
+}
+
+func TestServerValidation(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Handle("urn:test/Echo", "Echo", Envelope(`<EchoResponse/>`))
+	cli := soap.NewClient(srv.URL, soap.WithNamespace("urn:test"))
+
+	in := struct {
+		M echoRequest `xml:"Ping"`
+	}{}
+	err := cli.RoundTripWithAction("Echo", &in, &struct{}{})
+	if err == nil {
+		t.Fatal("expected error for unexpected body element")
+	}
+
+	for _, tc := range []struct {
+		Envelope string
+		Ok       bool
+	}{
+		{Envelope: Envelope(`<Echo/>`), Ok: true},
+		{Envelope: `<Envelope><Header><x/></Header><Body><Echo/></Body></Envelope>`, Ok: true},
+		{Envelope: `<Envelope><Echo/></Envelope>`, Ok: true},
+		{Envelope: `<Envelope><Body></Body></Envelope>`},
+		{Envelope: `<Other/>`},
+		{Envelope: `<Envelope><Body>`},
+	} {
+		name, err := bodyElement([]byte(tc.Envelope))
+		if tc.Ok && (err != nil || name.Local != "Echo") {
+			t.Errorf("%s: unexpected result %v, %v", tc.Envelope, name, err)
+		}
+		if !tc.Ok && err == nil {
+			t.Errorf("%s: expected error", tc.Envelope)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package soap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// UnknownElementsError is returned by clients with StrictDecode set when
+// a response contains elements that do not map to any field of the
+// response message. The response is still decoded.
+type UnknownElementsError struct {
+	Paths []string // slash separated paths of the unknown elements
+}
+
+func (e *UnknownElementsError) Error() string {
+	return "soap: unknown elements in response: " + strings.Join(e.Paths, ", ")
+}
+
+var unmarshalerType = reflect.TypeOf((*xml.Unmarshaler)(nil)).Elem()
+
+// elemSpec describes the child elements a Go type accepts when decoded
+// by encoding/xml.
+type elemSpec struct {
+	fields map[string]*fieldSpec // by local name
+	any    bool                  // an ",any" or ",innerxml" field accepts all
+}
+
+type fieldSpec struct {
+	space string
+	typ   reflect.Type
+	sub   *elemSpec // intermediate element of an "a>b" tag path
+}
+
+func (s *elemSpec) add(local string) *fieldSpec {
+	if s.fields == nil {
+		s.fields = make(map[string]*fieldSpec)
+	}
+	f, ok := s.fields[local]
+	if !ok {
+		f = &fieldSpec{}
+		s.fields[local] = f
+	}
+	return f
+}
+
+func structSpec(t reflect.Type) *elemSpec {
+	s := &elemSpec{}
+	addStructFields(s, t)
+	return s
+}
+
+func addStructFields(s *elemSpec, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		tag := f.Tag.Get("xml")
+		if tag == "-" || f.Name == "XMLName" {
+			continue
+		}
+		name, flags, _ := strings.Cut(tag, ",")
+		switch {
+		case strings.Contains(flags, "any"), strings.Contains(flags, "innerxml"):
+			s.any = true
+			continue
+		case flags != "" && flags != "omitempty":
+			// attr, chardata, cdata and comment fields
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addStructFields(s, ft)
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		var space string
+		if i := strings.LastIndex(name, " "); i >= 0 {
+			space, name = name[:i], name[i+1:]
+		}
+		path := strings.Split(name, ">")
+		cur := s
+		for _, p := range path[:len(path)-1] {
+			fs := cur.add(p)
+			if fs.sub == nil {
+				fs.sub = &elemSpec{}
+			}
+			cur = fs.sub
+		}
+		fs := cur.add(path[len(path)-1])
+		fs.space, fs.typ = space, f.Type
+	}
+}
+
+// unknownElements returns the paths of the elements in the Body of the
+// envelope b that do not map to any field of out.
+func unknownElements(b []byte, out Message) ([]string, error) {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	d.CharsetReader = charset.NewReaderLabel
+	d.Strict = false
+	var unknown []string
+	depth := 0
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return unknown, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 && t.Name.Local == "Body" && out != nil {
+				if err := checkElement(d, reflect.TypeOf(out), "Body", &unknown); err != nil {
+					return nil, err
+				}
+				depth--
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+// checkElement consumes the element just started, decoded into a value
+// of type t, recording the unknown elements within.
+func checkElement(d *xml.Decoder, t reflect.Type, path string, unknown *[]string) error {
+	for {
+		if t.Implements(unmarshalerType) || reflect.PointerTo(t).Implements(unmarshalerType) {
+			return d.Skip()
+		}
+		if t.Kind() == reflect.Pointer || (t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8) {
+			t = t.Elem()
+			continue
+		}
+		break
+	}
+	if t.Kind() != reflect.Struct {
+		return d.Skip()
+	}
+	return checkChildren(d, structSpec(t), path, unknown)
+}
+
+func checkChildren(d *xml.Decoder, s *elemSpec, path string, unknown *[]string) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			p := path + "/" + t.Name.Local
+			f, ok := s.fields[t.Name.Local]
+			if ok && f.space != "" && f.space != t.Name.Space {
+				ok = false
+			}
+			switch {
+			case ok && f.sub != nil && f.typ == nil:
+				err = checkChildren(d, f.sub, p, unknown)
+			case ok:
+				err = checkElement(d, f.typ, p, unknown)
+			case s.any:
+				err = d.Skip()
+			default:
+				*unknown = append(*unknown, p)
+				err = d.Skip()
+			}
+			if err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package soap
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type strictItem struct {
+	ID   string `xml:"id,attr"`
+	Name string `xml:"name"`
+}
+
+type strictEmbedded struct {
+	Total int `xml:"total"`
+}
+
+type strictResponse struct {
+	strictEmbedded
+	Items []*strictItem `xml:"items>item"`
+	Note  string        `xml:"urn:notes note"`
+	Extra *struct {
+		Raw []byte `xml:",innerxml"`
+	} `xml:"extra"`
+	Custom Polymorphic `xml:"custom"`
+}
+
+func TestUnknownElements(t *testing.T) {
+	doc := `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+<soap:Header><h>ignored</h></soap:Header>
+<soap:Body><Response>
+<total>2</total>
+<items><item id="1"><name>a</name><color>red</color></item><item id="2"><name>b</name></item><count>2</count></items>
+<note xmlns="urn:notes">n</note>
+<note xmlns="urn:other">wrong namespace</note>
+<extra><anything><goes/></anything></extra>
+<custom><opaque/></custom>
+<added>new</added>
+</Response></soap:Body></soap:Envelope>`
+	out := &struct {
+		R strictResponse `xml:"Response"`
+	}{}
+	have, err := unknownElements([]byte(doc), out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"Body/Response/items/item/color",
+		"Body/Response/items/count",
+		"Body/Response/note",
+		"Body/Response/added",
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("unexpected elements\nwant: %q\nhave: %q", want, have)
+	}
+}
+
+func TestRoundTripStrictDecode(t *testing.T) {
+	type msgT struct {
+		XMLName xml.Name `xml:"msgT"`
+		A       string
+	}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<Envelope><Body><A>a</A><B>b</B></Body></Envelope>`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	out := &struct{ A string }{}
+	if err := NewClient(s.URL).RoundTrip(&msgT{}, out); err != nil {
+		t.Fatal(err)
+	}
+	err := NewClient(s.URL, WithStrictDecode()).RoundTrip(&msgT{}, out)
+	var uerr *UnknownElementsError
+	if !errors.As(err, &uerr) || !reflect.DeepEqual(uerr.Paths, []string{"Body/B"}) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if out.A != "a" {
+		t.Fatalf("response not decoded: %#v", out)
+	}
+}
@@ -0,0 +1,66 @@
+package soap
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// DefaultElements maps the names of the elements of substitution groups
+// to their Go types, for decoding Substitution values.
+var DefaultElements = &TypeRegistry{}
+
+// RegisterElement registers the type of v, which may be a pointer, under
+// the element name in DefaultElements.
+func RegisterElement(name xml.Name, v any) {
+	DefaultElements.Register(name, v)
+}
+
+// Substitution is an element of a substitution group, whose Go types
+// implement T. It is decoded into the type registered in DefaultTypes
+// for its xsi:type attribute or, failing that, in DefaultElements for
+// its name. Other elements are skipped, leaving it unchanged; in slices
+// they are Substitution values with a nil Value, which are not encoded.
+//
+// Fields of Substitution values match any element, as those of xsd:any
+// wildcards: only the first field of a struct that does is decoded.
+type Substitution[T any] struct {
+	XMLName xml.Name // of the element, that of the type of Value if empty
+	Value   T
+}
+
+// UnmarshalXML implements the xml.Unmarshaler interface.
+func (s *Substitution[T]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	v, ok := any(nil), false
+	if name, typed := xsiType(start); typed {
+		v, ok = DefaultTypes.New(name)
+	}
+	if !ok {
+		v, ok = DefaultElements.New(start.Name)
+	}
+	t, member := v.(T)
+	if !ok || !member {
+		return d.Skip()
+	}
+	if err := d.DecodeElement(v, &start); err != nil {
+		return err
+	}
+	s.XMLName, s.Value = start.Name, t
+	return nil
+}
+
+// MarshalXML implements the xml.Marshaler interface.
+func (s Substitution[T]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	v := any(s.Value)
+	if v == nil {
+		return nil
+	}
+	start = xml.StartElement{Name: s.XMLName}
+	if start.Name.Local == "" {
+		name, ok := DefaultElements.Name(v)
+		if !ok {
+			return fmt.Errorf("soap: no element name for %T", v)
+		}
+		start.Name = name
+	}
+	return e.EncodeElement(v, start)
+}
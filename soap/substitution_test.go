@@ -0,0 +1,71 @@
+package soap
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+type shape interface {
+	isShape()
+}
+
+type circle struct {
+	Radius int `xml:"radius"`
+}
+
+func (*circle) isShape() {}
+
+type square struct {
+	Side int `xml:"side"`
+}
+
+func (*square) isShape() {}
+
+func TestSubstitution(t *testing.T) {
+	RegisterElement(xml.Name{Space: "urn:test:shapes", Local: "circle"}, (*circle)(nil))
+	RegisterElement(xml.Name{Space: "urn:test:shapes", Local: "square"}, square{})
+	RegisterType(xml.Name{Space: "urn:test:shapes", Local: "Square"}, square{})
+	doc := `<drawing xmlns="urn:test:shapes" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` +
+		`<title>Plan</title>` +
+		`<circle><radius>2</radius></circle>` +
+		`<triangle><side>3</side></triangle>` +
+		`<shape xsi:type="Square"><side>4</side></shape>` +
+		`</drawing>`
+	var drawing struct {
+		XMLName xml.Name              `xml:"urn:test:shapes drawing"`
+		Title   string                `xml:"title"`
+		Shapes  []Substitution[shape] `xml:",any"`
+	}
+	if err := xml.Unmarshal([]byte(doc), &drawing); err != nil {
+		t.Fatal(err)
+	}
+	if len(drawing.Shapes) != 3 {
+		t.Fatalf("unexpected shapes %#v", drawing.Shapes)
+	}
+	if v, ok := drawing.Shapes[0].Value.(*circle); !ok || v.Radius != 2 {
+		t.Errorf("unexpected circle %#v", drawing.Shapes[0].Value)
+	}
+	if drawing.Shapes[1].Value != nil {
+		t.Errorf("unexpected triangle %#v", drawing.Shapes[1].Value)
+	}
+	if v, ok := drawing.Shapes[2].Value.(*square); !ok || v.Side != 4 {
+		t.Errorf("unexpected square %#v", drawing.Shapes[2].Value)
+	}
+	if drawing.Shapes[2].XMLName.Local != "shape" {
+		t.Errorf("unexpected name %v", drawing.Shapes[2].XMLName)
+	}
+
+	type pen struct {
+		Shape Substitution[shape] `xml:",any"`
+	}
+	b, err := xml.Marshal(pen{Substitution[shape]{Value: &square{5}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<pen><square xmlns="urn:test:shapes"><side>5</side></square></pen>`; string(b) != want {
+		t.Errorf("unexpected marshal\nwant: %s\nhave: %s", want, b)
+	}
+	if b, err = xml.Marshal(pen{}); err != nil || string(b) != "<pen></pen>" {
+		t.Errorf("unexpected marshal of no shape: %s, %v", b, err)
+	}
+}
@@ -0,0 +1,128 @@
+package soap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+)
+
+// TimeoutPhase is the phase of a round trip in which it timed out.
+type TimeoutPhase int32
+
+// Phases of round trips.
+const (
+	TimeoutDial     TimeoutPhase = iota // Resolving and connecting to the server
+	TimeoutTLS                          // TLS handshake with the server
+	TimeoutResponse                     // Sending the request and waiting for the response
+	TimeoutRead                         // Reading and decoding the response
+)
+
+func (p TimeoutPhase) String() string {
+	switch p {
+	case TimeoutDial:
+		return "connecting"
+	case TimeoutTLS:
+		return "in TLS handshake"
+	case TimeoutResponse:
+		return "waiting for the response"
+	case TimeoutRead:
+		return "reading the response"
+	}
+	return fmt.Sprintf("TimeoutPhase(%d)", int32(p))
+}
+
+// TimeoutError is returned for round trips that exceed the timeout of
+// the client or their operation. It matches context.DeadlineExceeded
+// with errors.Is.
+type TimeoutError struct {
+	Phase TimeoutPhase
+	Limit time.Duration // Timeout of the round trip
+	Err   error         // Error of the round trip
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("soap: timeout after %v %s: %v", e.Limit, e.Phase, e.Err)
+}
+
+// Unwrap returns the error of the round trip.
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is context.DeadlineExceeded, which the
+// round trip may have failed with in other terms, e.g. as a read error.
+func (e *TimeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+// Timeout reports true, like the timeout errors of package net.
+func (e *TimeoutError) Timeout() bool {
+	return true
+}
+
+// TimeoutMiddleware returns a Middleware that limits each round trip
+// through it to d, layered on the deadline of the context. Round trips
+// that time out fail with a *TimeoutError.
+func TimeoutMiddleware(d time.Duration) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(ctx context.Context, call *Call) error {
+			return roundTripTimeout(ctx, call, next, d)
+		}
+	}
+}
+
+// OperationTimeoutMiddleware returns a Middleware that limits round
+// trips to the timeout of their operation (see OperationName) in
+// timeouts. The timeout with the empty key, if any, applies to the other
+// operations.
+func OperationTimeoutMiddleware(timeouts map[string]time.Duration) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(ctx context.Context, call *Call) error {
+			d, ok := timeouts[OperationName(call.Action)]
+			if !ok {
+				d = timeouts[""]
+			}
+			return roundTripTimeout(ctx, call, next, d)
+		}
+	}
+}
+
+// WithTimeout limits each round trip of the client to d.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) { c.Timeout = d }
+}
+
+// WithOperationTimeouts limits the round trips of operations, as
+// OperationTimeoutMiddleware.
+func WithOperationTimeouts(timeouts map[string]time.Duration) Option {
+	return func(c *Client) {
+		WithMiddleware(OperationTimeoutMiddleware(timeouts))(c)
+	}
+}
+
+// roundTripTimeout calls next with a context limited to d, tracing the
+// HTTP exchange to tell in which phase it times out.
+func roundTripTimeout(ctx context.Context, call *Call, next RoundTripFunc, d time.Duration) error {
+	if d <= 0 {
+		return next(ctx, call)
+	}
+	tctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	var phase atomic.Int32
+	set := func(p TimeoutPhase) { phase.Store(int32(p)) }
+	tctx = httptrace.WithClientTrace(tctx, &httptrace.ClientTrace{
+		TLSHandshakeStart:    func() { set(TimeoutTLS) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { set(TimeoutResponse) },
+		GotConn:              func(httptrace.GotConnInfo) { set(TimeoutResponse) },
+		GotFirstResponseByte: func() { set(TimeoutRead) },
+	})
+	err := next(tctx, call)
+	if err != nil && errors.Is(tctx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return &TimeoutError{Phase: TimeoutPhase(phase.Load()), Limit: d, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package soap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		switch r.URL.Path {
+		case "/slow":
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		case "/partial":
+			io.WriteString(w, `<Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Body>`)
+			w.(http.Flusher).Flush()
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}
+		io.WriteString(w, `<Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Body><msgT><A>ok</A></msgT></Body></Envelope>`)
+	}))
+	defer s.Close()
+	defer close(release)
+
+	// A listener that never completes TLS handshakes.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				<-release
+				conn.Close()
+			}()
+		}
+	}()
+	blockingDial := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}}
+
+	type msgT struct{ A string }
+	cases := []struct {
+		c    *Client
+		want TimeoutPhase
+	}{
+		{NewClient(s.URL, WithTimeout(50*time.Millisecond), WithHTTPClient(blockingDial)), TimeoutDial},
+		{NewClient("https://"+l.Addr().String(), WithTimeout(50*time.Millisecond)), TimeoutTLS},
+		{NewClient(s.URL+"/slow", WithTimeout(50*time.Millisecond)), TimeoutResponse},
+		{NewClient(s.URL+"/partial", WithTimeout(50*time.Millisecond)), TimeoutRead},
+		{NewClient(s.URL+"/slow", WithOperationTimeouts(map[string]time.Duration{"msgT": 50 * time.Millisecond})), TimeoutResponse},
+		{NewClient(s.URL+"/slow", WithOperationTimeouts(map[string]time.Duration{"": 50 * time.Millisecond})), TimeoutResponse},
+	}
+	for i, tc := range cases {
+		var out struct {
+			Msg msgT `xml:"msgT"`
+		}
+		err := tc.c.RoundTrip(&msgT{}, &out)
+		var te *TimeoutError
+		if !errors.As(err, &te) {
+			t.Errorf("test %d: want timeout error, have %v", i, err)
+			continue
+		}
+		if te.Phase != tc.want || te.Limit != 50*time.Millisecond {
+			t.Errorf("test %d: want timeout %s, have %v", i, tc.want, err)
+		}
+		if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), tc.want.String()) {
+			t.Errorf("test %d: unexpected error %v", i, err)
+		}
+	}
+
+	// Other operations and calls within the timeout succeed.
+	var out struct {
+		Msg msgT `xml:"msgT"`
+	}
+	c := NewClient(s.URL, WithTimeout(time.Minute), WithOperationTimeouts(map[string]time.Duration{"Other": time.Nanosecond}))
+	if err := c.RoundTrip(&msgT{}, &out); err != nil || out.Msg.A != "ok" {
+		t.Errorf("unexpected result %+v, %v", out, err)
+	}
+
+	// The deadline of the context is not the client's timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	c = NewClient(s.URL+"/slow", WithTimeout(time.Minute))
+	err = c.RoundTripContext(ctx, &msgT{}, &out)
+	var te *TimeoutError
+	if err == nil || errors.As(err, &te) {
+		t.Errorf("want context error, have %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package soap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultReloadInterval is how often a CertificateReloader checks its
+// files for changes by default.
+const DefaultReloadInterval = time.Minute
+
+// CertificateReloader loads a TLS certificate and its key from PEM files,
+// and reloads them when the files change, so that rotated certificates
+// are picked up without restarting the program.
+//
+// The files are checked at most every Interval, during TLS handshakes.
+// Connections that are already established keep the certificate they
+// were opened with. If reloading fails, e.g. while the files are being
+// replaced, the previous certificate is used until the files are valid
+// again.
+type CertificateReloader struct {
+	CertFile string        // PEM encoded certificate chain
+	KeyFile  string        // PEM encoded private key
+	Interval time.Duration // Optional time between checks of the files (default DefaultReloadInterval)
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	stamp   [2]fileStamp
+	checked time.Time
+	now     func() time.Time
+}
+
+// fileStamp identifies the version of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewCertificateReloader creates a CertificateReloader of the files and
+// loads the certificate, so that invalid files are reported right away.
+func NewCertificateReloader(certFile, keyFile string) (*CertificateReloader, error) {
+	r := &CertificateReloader{CertFile: certFile, KeyFile: keyFile}
+	if _, err := r.Certificate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Certificate returns the current certificate, reloading it if the
+// files changed since it was loaded.
+func (r *CertificateReloader) Certificate() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now
+	if r.now != nil {
+		now = r.now
+	}
+	interval := r.Interval
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	t := now()
+	if r.cert != nil && t.Sub(r.checked) < interval {
+		return r.cert, nil
+	}
+	r.checked = t
+	stamp, err := r.stamps()
+	if err == nil && r.cert != nil && stamp == r.stamp {
+		return r.cert, nil
+	}
+	var cert tls.Certificate
+	if err == nil {
+		cert, err = tls.LoadX509KeyPair(r.CertFile, r.KeyFile)
+	}
+	if err != nil {
+		if r.cert != nil {
+			return r.cert, nil
+		}
+		return nil, fmt.Errorf("soap: cannot load client certificate: %w", err)
+	}
+	r.cert, r.stamp = &cert, stamp
+	return r.cert, nil
+}
+
+// GetClientCertificate returns the current certificate; it can be used
+// as tls.Config.GetClientCertificate.
+func (r *CertificateReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.Certificate()
+}
+
+func (r *CertificateReloader) stamps() ([2]fileStamp, error) {
+	var stamp [2]fileStamp
+	for i, name := range []string{r.CertFile, r.KeyFile} {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return stamp, err
+		}
+		stamp[i] = fileStamp{fi.ModTime(), fi.Size()}
+	}
+	return stamp, nil
+}
+
+// WithTLSConfig sets the TLS configuration of the HTTP transport.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *Client) { c.httpTransport().TLSClientConfig = cfg.Clone() }
+}
+
+// WithRootCAs sets the certificate authorities that server certificates
+// are verified against, instead of the system roots.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return configureTLS(func(tc *tls.Config) { tc.RootCAs = pool })
+}
+
+// WithClientCertificate authenticates with the TLS client certificate
+// cert.
+func WithClientCertificate(cert tls.Certificate) Option {
+	return configureTLS(func(tc *tls.Config) {
+		tc.Certificates = []tls.Certificate{cert}
+		tc.GetClientCertificate = nil
+	})
+}
+
+// WithClientCertificateFiles authenticates with the TLS client
+// certificate in the PEM files certFile and keyFile, reloading it when
+// the files change. The files are first read when connecting; use
+// NewCertificateReloader and WithClientCertificateFunc to check them
+// upfront.
+func WithClientCertificateFiles(certFile, keyFile string) Option {
+	r := &CertificateReloader{CertFile: certFile, KeyFile: keyFile}
+	return WithClientCertificateFunc(r.GetClientCertificate)
+}
+
+// WithClientCertificateFunc authenticates with the TLS client
+// certificates returned by fn, which is called on each handshake.
+func WithClientCertificateFunc(fn func(*tls.CertificateRequestInfo) (*tls.Certificate, error)) Option {
+	return configureTLS(func(tc *tls.Config) {
+		tc.Certificates = nil
+		tc.GetClientCertificate = fn
+	})
+}
+
+// configureTLS returns an Option that modifies the TLS configuration of
+// the HTTP transport.
+func configureTLS(fn func(*tls.Config)) Option {
+	return func(c *Client) {
+		tr := c.httpTransport()
+		if tr.TLSClientConfig == nil {
+			tr.TLSClientConfig = &tls.Config{}
+		}
+		fn(tr.TLSClientConfig)
+	}
+}
@@ -0,0 +1,195 @@
+package soap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeClientCertificate writes a client certificate named name, issued
+// by ca, to the files cert.pem and key.pem in dir.
+func writeClientCertificate(t *testing.T, dir, name string, ca *x509.Certificate, caKey *ecdsa.PrivateKey) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, key.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func testCA(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca, key
+}
+
+func TestCertificateReloader(t *testing.T) {
+	ca, caKey := testCA(t)
+	dir := t.TempDir()
+	certFile, keyFile := writeClientCertificate(t, dir, "first", ca, caKey)
+
+	if _, err := NewCertificateReloader(filepath.Join(dir, "missing.pem"), keyFile); err == nil {
+		t.Error("want error for missing certificate file")
+	}
+	r, err := NewCertificateReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	r.now = func() time.Time { return now }
+	subject := func() string {
+		t.Helper()
+		cert, err := r.Certificate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert.Leaf.Subject.CommonName
+	}
+
+	writeClientCertificate(t, dir, "second-cert", ca, caKey)
+	if s := subject(); s != "first" {
+		t.Errorf("reloaded before the interval: %s", s)
+	}
+	now = now.Add(DefaultReloadInterval)
+	if s := subject(); s != "second-cert" {
+		t.Errorf("want rotated certificate, have %s", s)
+	}
+
+	// A broken file keeps the previous certificate.
+	if err := os.WriteFile(certFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(DefaultReloadInterval)
+	if s := subject(); s != "second-cert" {
+		t.Errorf("want previous certificate, have %s", s)
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	ca, caKey := testCA(t)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Body><msgT><A>`+
+			r.TLS.PeerCertificates[0].Subject.CommonName+`</A></msgT></Body></Envelope>`)
+	}))
+	s.Config.ErrorLog = log.New(io.Discard, "", 0)
+	s.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	s.StartTLS()
+	defer s.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(s.Certificate())
+
+	dir := t.TempDir()
+	certFile, keyFile := writeClientCertificate(t, dir, "first", ca, caKey)
+	r, err := NewCertificateReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	type msgT struct{ A string }
+	call := func(c *Client) (string, error) {
+		var out struct {
+			Msg msgT `xml:"msgT"`
+		}
+		err := c.RoundTrip(&msgT{}, &out)
+		return out.Msg.A, err
+	}
+	c := NewClient(s.URL, WithRootCAs(roots), WithClientCertificateFunc(r.GetClientCertificate))
+	if a, err := call(c); err != nil || a != "first" {
+		t.Fatalf("have %q, %v", a, err)
+	}
+
+	// New connections use the rotated certificate.
+	writeClientCertificate(t, dir, "second-cert", ca, caKey)
+	now = now.Add(DefaultReloadInterval)
+	c.Config.Transport.(*http.Transport).CloseIdleConnections()
+	if a, err := call(c); err != nil || a != "second-cert" {
+		t.Fatalf("have %q, %v", a, err)
+	}
+
+	c = NewClient(s.URL, WithRootCAs(roots), WithClientCertificateFiles(certFile, keyFile))
+	if a, err := call(c); err != nil || a != "second-cert" {
+		t.Fatalf("have %q, %v", a, err)
+	}
+	c = NewClient(s.URL, WithRootCAs(roots))
+	if _, err := call(c); err == nil {
+		t.Error("want handshake error without client certificate")
+	}
+	c = NewClient(s.URL, WithRootCAs(roots), WithClientCertificateFiles(filepath.Join(dir, "missing.pem"), keyFile))
+	if _, err := call(c); err == nil || !strings.Contains(err.Error(), "cannot load client certificate") {
+		t.Errorf("want load error, have %v", err)
+	}
+}
+
+func TestTLSOptionsCopy(t *testing.T) {
+	cli := &http.Client{Timeout: time.Second}
+	c := NewClient("", WithHTTPClient(cli), WithTLSConfig(&tls.Config{ServerName: "example.com"}), WithRootCAs(x509.NewCertPool()))
+	if cli.Transport != nil || c.Config == cli || c.Config.Timeout != time.Second {
+		t.Fatal("HTTP client not copied")
+	}
+	tc := c.Config.Transport.(*http.Transport).TLSClientConfig
+	if tc.ServerName != "example.com" || tc.RootCAs == nil {
+		t.Errorf("unexpected TLS config %+v", tc)
+	}
+	if def := http.DefaultTransport.(*http.Transport).TLSClientConfig; def == tc || def != nil && def.RootCAs != nil {
+		t.Error("default transport modified")
+	}
+}
@@ -0,0 +1,55 @@
+package soap
+
+import "net/http"
+
+// A Transport sends SOAP requests and returns their responses, such as
+// over HTTP, unix sockets or message queues. *http.Client implements it.
+type Transport interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// TransportFunc is an adapter to use ordinary functions as Transport.
+type TransportFunc func(*http.Request) (*http.Response, error)
+
+// Do calls f(r).
+func (f TransportFunc) Do(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// RoundTripperTransport returns a Transport that sends requests with rt
+// directly, without the redirect and cookie handling of http.Client.
+func RoundTripperTransport(rt http.RoundTripper) Transport {
+	return TransportFunc(rt.RoundTrip)
+}
+
+// transport returns the Transport of the client: Transport if set,
+// otherwise Config or http.DefaultClient.
+func (c *Client) transport() Transport {
+	if c.Transport != nil {
+		return c.Transport
+	}
+	if c.Config != nil {
+		return c.Config
+	}
+	return http.DefaultClient
+}
+
+// httpTransport installs a copy of the HTTP client in Config and of its
+// transport, and returns the transport, so that shared ones such as
+// http.DefaultClient are left alone. A transport other than
+// *http.Transport is replaced by a copy of http.DefaultTransport.
+func (c *Client) httpTransport() *http.Transport {
+	cli := &http.Client{}
+	if c.Config != nil {
+		*cli = *c.Config
+	}
+	tr, ok := cli.Transport.(*http.Transport)
+	if ok {
+		tr = tr.Clone()
+	} else {
+		tr = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	cli.Transport = tr
+	c.Config = cli
+	return tr
+}
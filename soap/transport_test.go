@@ -0,0 +1,63 @@
+package soap
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	type echo struct {
+		Data string `xml:"Data"`
+	}
+	respond := func(r *http.Request) (*http.Response, error) {
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Contains(b, []byte("<Data>hello</Data>")) {
+			t.Errorf("unexpected request %s", b)
+		}
+		body := `<Envelope><Body><echo><Data>world</Data></echo></Body></Envelope>`
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"text/xml"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	}
+	testCases := []struct {
+		name string
+		opt  Option
+	}{
+		{"TransportFunc", WithTransport(TransportFunc(respond))},
+		{"RoundTripper", WithRoundTripper(roundTripFunc(respond))},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewClient("unix:///var/run/soap.sock", tc.opt, WithHTTPClient(&http.Client{
+				Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+					t.Fatal("HTTP client used instead of transport")
+					return nil, nil
+				}),
+			}))
+			var out struct {
+				Echo echo `xml:"echo"`
+			}
+			if err := c.RoundTrip(&echo{Data: "hello"}, &out); err != nil {
+				t.Fatal(err)
+			}
+			if out.Echo.Data != "world" {
+				t.Fatalf("unexpected response %q", out.Echo.Data)
+			}
+		})
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,210 @@
+package soap
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// TypeRegistry maps xsi:type names to Go types, for decoding elements
+// declared as an abstract base type into their derived types. It is
+// safe for concurrent use.
+type TypeRegistry struct {
+	mu    sync.RWMutex
+	types map[xml.Name]reflect.Type
+}
+
+// DefaultTypes is the registry used by Polymorphic values without one.
+var DefaultTypes = &TypeRegistry{}
+
+// RegisterType registers the type of v, which may be a pointer, under
+// name in DefaultTypes.
+func RegisterType(name xml.Name, v any) {
+	DefaultTypes.Register(name, v)
+}
+
+// Register registers the type of v, which may be a pointer, under name.
+func (r *TypeRegistry) Register(name xml.Name, v any) {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.types == nil {
+		r.types = make(map[xml.Name]reflect.Type)
+	}
+	r.types[name] = t
+}
+
+// New returns a pointer to a new value of the type registered under
+// name. If the namespace of name is empty, a type registered with the
+// same local name is used, provided there is only one.
+func (r *TypeRegistry) New(name xml.Name) (any, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if t, ok := r.types[name]; ok {
+		return reflect.New(t).Interface(), true
+	}
+	if name.Space != "" {
+		return nil, false
+	}
+	var found reflect.Type
+	for n, t := range r.types {
+		if n.Local != name.Local {
+			continue
+		}
+		if found != nil {
+			return nil, false
+		}
+		found = t
+	}
+	if found == nil {
+		return nil, false
+	}
+	return reflect.New(found).Interface(), true
+}
+
+// Name returns the name the type of v, which may be a pointer, is
+// registered under, provided there is only one.
+func (r *TypeRegistry) Name(v any) (xml.Name, bool) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var name xml.Name
+	found := false
+	for n, rt := range r.types {
+		if rt != t {
+			continue
+		}
+		if found {
+			return xml.Name{}, false
+		}
+		name, found = n, true
+	}
+	return name, found
+}
+
+// Polymorphic is an element decoded into the Go type registered for
+// its xsi:type attribute. Elements without xsi:type, or with a type
+// that is not registered, are decoded into Default if set, or skipped.
+type Polymorphic struct {
+	Value   any           // decoded value, a pointer to the registered type
+	Types   *TypeRegistry // Optional registry (default DefaultTypes)
+	Default any           // Optional pointer to decode unregistered types into
+}
+
+// UnmarshalXML implements the xml.Unmarshaler interface.
+func (p *Polymorphic) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	types := p.Types
+	if types == nil {
+		types = DefaultTypes
+	}
+	if name, ok := xsiType(start); ok {
+		if v, ok := types.New(name); ok {
+			p.Value = v
+			return d.DecodeElement(v, &start)
+		}
+	}
+	if p.Default != nil {
+		p.Value = p.Default
+		return d.DecodeElement(p.Default, &start)
+	}
+	p.Value = nil
+	return d.Skip()
+}
+
+// MarshalXML implements the xml.Marshaler interface.
+func (p Polymorphic) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if p.Value == nil {
+		return nil
+	}
+	return e.EncodeElement(p.Value, start)
+}
+
+// baseTypes maps the reflect.Type of the T of Derived values to the type
+// they decode elements of an unknown xsi:type into.
+var baseTypes sync.Map
+
+// RegisterBaseType registers the type of v, which may be a pointer, as
+// the one Derived[T] values decode elements without a registered
+// xsi:type into: the base type of the hierarchy of T, unless abstract.
+func RegisterBaseType[T any](v T) {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	baseTypes.Store(reflect.TypeOf((*T)(nil)).Elem(), t)
+}
+
+// Derived is an element of a type of a hierarchy of complex types, whose
+// Go types implement T. It is decoded into the type registered in
+// DefaultTypes for its xsi:type attribute, or into the base type of
+// RegisterBaseType; if there is none, it is skipped. Values are encoded
+// with their xsi:type, set by SetXMLType.
+type Derived[T any] struct {
+	Value T
+}
+
+// UnmarshalXML implements the xml.Unmarshaler interface.
+func (v *Derived[T]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var p any
+	if name, ok := xsiType(start); ok {
+		p, _ = DefaultTypes.New(name)
+	}
+	if _, ok := p.(T); !ok {
+		p = nil
+		if t, ok := baseTypes.Load(reflect.TypeOf((*T)(nil)).Elem()); ok {
+			p = reflect.New(t.(reflect.Type)).Interface()
+		}
+	}
+	t, ok := p.(T)
+	if !ok {
+		return d.Skip()
+	}
+	if err := d.DecodeElement(p, &start); err != nil {
+		return err
+	}
+	v.Value = t
+	return nil
+}
+
+// MarshalXML implements the xml.Marshaler interface.
+func (v Derived[T]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	p := any(v.Value)
+	if p == nil {
+		return nil
+	}
+	if t, ok := p.(XMLTyper); ok {
+		t.SetXMLType()
+	}
+	return e.EncodeElement(p, start)
+}
+
+// xsiType returns the name in the xsi:type attribute of start. The
+// prefix of the QName is resolved against the namespaces declared on
+// start; when it cannot be resolved the namespace is left empty.
+func xsiType(start xml.StartElement) (xml.Name, bool) {
+	for _, a := range start.Attr {
+		if a.Name.Space != XSINamespace || a.Name.Local != "type" {
+			continue
+		}
+		prefix, local, ok := strings.Cut(a.Value, ":")
+		if !ok {
+			prefix, local = "", a.Value
+		}
+		name := xml.Name{Local: local}
+		for _, ns := range start.Attr {
+			if (prefix == "" && ns.Name.Space == "" && ns.Name.Local == "xmlns") ||
+				(prefix != "" && ns.Name.Space == "xmlns" && ns.Name.Local == prefix) {
+				name.Space = ns.Value
+			}
+		}
+		return name, true
+	}
+	return xml.Name{}, false
+}
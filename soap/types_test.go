@@ -0,0 +1,134 @@
+package soap
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+type animal struct {
+	Name string `xml:"name"`
+}
+
+type dog struct {
+	animal
+	Barks bool `xml:"barks"`
+}
+
+type cat struct {
+	animal
+	Lives int `xml:"lives"`
+}
+
+func TestPolymorphic(t *testing.T) {
+	RegisterType(xml.Name{Space: "urn:test:zoo", Local: "Dog"}, (*dog)(nil))
+	RegisterType(xml.Name{Space: "urn:test:zoo", Local: "Cat"}, cat{})
+	doc := `<zoo xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+<animal xsi:type="z:Dog" xmlns:z="urn:test:zoo"><name>Rex</name><barks>true</barks></animal>
+<animal xsi:type="a:Cat"><name>Tom</name><lives>9</lives></animal>
+<animal xsi:type="z:Fish" xmlns:z="urn:test:zoo"><name>Nemo</name></animal>
+<animal><name>Plain</name></animal>
+</zoo>`
+	var zoo struct {
+		Animals []Polymorphic `xml:"animal"`
+	}
+	if err := xml.Unmarshal([]byte(doc), &zoo); err != nil {
+		t.Fatal(err)
+	}
+	if len(zoo.Animals) != 4 {
+		t.Fatalf("unexpected animals %#v", zoo.Animals)
+	}
+	if v, ok := zoo.Animals[0].Value.(*dog); !ok || v.Name != "Rex" || !v.Barks {
+		t.Errorf("unexpected dog %#v", zoo.Animals[0].Value)
+	}
+	if v, ok := zoo.Animals[1].Value.(*cat); !ok || v.Name != "Tom" || v.Lives != 9 {
+		t.Errorf("unexpected cat %#v", zoo.Animals[1].Value)
+	}
+	for _, i := range []int{2, 3} {
+		if zoo.Animals[i].Value != nil {
+			t.Errorf("unexpected value %#v", zoo.Animals[i].Value)
+		}
+	}
+
+	types := &TypeRegistry{}
+	types.Register(xml.Name{Space: "urn:test:zoo", Local: "Dog"}, dog{})
+	single := struct {
+		Animal Polymorphic `xml:"animal"`
+	}{Polymorphic{Types: types, Default: &animal{}}}
+	one := `<zoo><animal xsi:type="z:Dog" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:z="urn:test:zoo"><name>Rex</name></animal></zoo>`
+	if err := xml.Unmarshal([]byte(one), &single); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := single.Animal.Value.(*dog); !ok {
+		t.Errorf("unexpected value %#v", single.Animal.Value)
+	}
+
+	type pen struct {
+		Animal Polymorphic `xml:"animal"`
+	}
+	b, err := xml.Marshal(pen{Polymorphic{Value: &cat{animal{"Tom"}, 9}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<pen><animal><name>Tom</name><lives>9</lives></animal></pen>`; string(b) != want {
+		t.Errorf("unexpected marshal\nwant: %s\nhave: %s", want, b)
+	}
+}
+
+type vehicle interface {
+	isVehicle()
+}
+
+type car struct {
+	Wheels int `xml:"wheels"`
+}
+
+func (*car) isVehicle() {}
+
+type truck struct {
+	car
+	Load        int    `xml:"load"`
+	TypeAttrXSI string `xml:"xsi:type,attr,omitempty"`
+}
+
+func (t *truck) SetXMLType() { t.TypeAttrXSI = "v:Truck" }
+
+func TestDerived(t *testing.T) {
+	RegisterType(xml.Name{Space: "urn:test:vehicles", Local: "Truck"}, (*truck)(nil))
+	RegisterBaseType[vehicle]((*car)(nil))
+	doc := `<garage xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:v="urn:test:vehicles">
+<vehicle xsi:type="v:Truck"><wheels>6</wheels><load>9</load></vehicle>
+<vehicle><wheels>4</wheels></vehicle>
+<vehicle xsi:type="v:Bus"><wheels>8</wheels></vehicle>
+</garage>`
+	var garage struct {
+		Vehicles []Derived[vehicle] `xml:"vehicle"`
+	}
+	if err := xml.Unmarshal([]byte(doc), &garage); err != nil {
+		t.Fatal(err)
+	}
+	if len(garage.Vehicles) != 3 {
+		t.Fatalf("unexpected vehicles %#v", garage.Vehicles)
+	}
+	if v, ok := garage.Vehicles[0].Value.(*truck); !ok || v.Wheels != 6 || v.Load != 9 {
+		t.Errorf("unexpected truck %#v", garage.Vehicles[0].Value)
+	}
+	for _, i := range []int{1, 2} {
+		if _, ok := garage.Vehicles[i].Value.(*car); !ok {
+			t.Errorf("unexpected car %#v", garage.Vehicles[i].Value)
+		}
+	}
+
+	type lot struct {
+		Vehicle Derived[vehicle] `xml:"vehicle"`
+	}
+	b, err := xml.Marshal(lot{Derived[vehicle]{&truck{car: car{6}, Load: 9}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<lot><vehicle xsi:type="v:Truck"><wheels>6</wheels><load>9</load></vehicle></lot>`; string(b) != want {
+		t.Errorf("unexpected marshal\nwant: %s\nhave: %s", want, b)
+	}
+	if b, err = xml.Marshal(lot{}); err != nil || string(b) != "<lot></lot>" {
+		t.Errorf("unexpected marshal of no vehicle: %s, %v", b, err)
+	}
+}
@@ -0,0 +1,52 @@
+package soap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+
+	"golang.org/x/net/html/charset"
+)
+
+// A Validator checks response messages, such as against the XML schema
+// of the service. *wsdl.Schema implements it.
+type Validator interface {
+	// ValidateElement validates the element that starts with start,
+	// reading its content from d.
+	ValidateElement(d *xml.Decoder, start xml.StartElement) error
+}
+
+// validateBody validates each element in the Body of the envelope b.
+func validateBody(v Validator, b []byte) error {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	d.CharsetReader = charset.NewReaderLabel
+	d.Strict = false
+	depth := 0
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 && t.Name.Local != "Body" {
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				depth--
+			}
+			if depth == 3 {
+				if err := v.ValidateElement(d, t); err != nil {
+					return err
+				}
+				depth--
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package soap
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+func TestRoundTripValidator(t *testing.T) {
+	schema, err := wsdl.ParseSchema(strings.NewReader(`<schema>
+<element name="EchoResponse"><complexType><sequence>
+<element name="count" type="xsd:int"/>
+</sequence></complexType></element>
+</schema>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, resp)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	c := NewClient(s.URL)
+	c.Validator = schema
+	out := &struct {
+		R struct {
+			Count string `xml:"count"`
+		} `xml:"EchoResponse"`
+	}{}
+
+	resp = `<Envelope><Header><x/></Header><Body><EchoResponse><count>1</count></EchoResponse></Body></Envelope>`
+	if err := c.RoundTrip(&struct{}{}, out); err != nil {
+		t.Fatal(err)
+	}
+	resp = `<Envelope><Body><EchoResponse><count>many</count></EchoResponse></Body></Envelope>`
+	err = c.RoundTrip(&struct{}{}, out)
+	var verrs wsdl.ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs) != 1 || verrs[0].Path != "/EchoResponse/count" {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if out.R.Count != "many" {
+		t.Fatalf("response not decoded: %#v", out)
+	}
+}
+
+func TestRoundTripRequestValidator(t *testing.T) {
+	schema, err := wsdl.ParseSchema(strings.NewReader(`<schema>
+<element name="Echo"><complexType><sequence>
+<element name="code" minOccurs="1"><simpleType><restriction base="xsd:string">
+<pattern value="[A-Z]+"/><maxLength value="3"/>
+</restriction></simpleType></element>
+</sequence></complexType></element>
+</schema>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sent int
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent++
+		io.WriteString(w, `<Envelope><Body/></Envelope>`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	c := NewClient(s.URL, WithRequestValidator(schema))
+	type echo struct {
+		Code string `xml:"code,omitempty"`
+	}
+	type body struct {
+		Echo echo `xml:"Echo"`
+	}
+	if err := c.RoundTrip(&body{echo{Code: "ABC"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	err = c.RoundTrip(&body{echo{Code: "abcd"}}, nil)
+	var verrs wsdl.ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs) != 2 {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "soap: invalid request: ") {
+		t.Fatalf("unexpected error %q", err)
+	}
+	err = c.RoundTrip(&body{}, nil)
+	if !errors.As(err, &verrs) || len(verrs) != 1 || verrs[0].Path != "/Echo/code" {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if sent != 1 {
+		t.Fatalf("%d requests sent, want 1", sent)
+	}
+}
@@ -0,0 +1,354 @@
+// Package wsrm implements the client side of WS-ReliableMessaging 1.1:
+// sequences created with CreateSequence, Sequence headers carrying
+// message numbers, acknowledgements and retransmission of messages that
+// were not delivered.
+//
+// http://docs.oasis-open.org/ws-rx/wsrm/v1.1/wsrm.html
+package wsrm
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/YapealAG/wsdl2go/soap"
+)
+
+// WS-ReliableMessaging and related namespaces and actions.
+const (
+	Namespace           = "http://docs.oasis-open.org/ws-rx/wsrm/200702"
+	AddressingNamespace = "http://www.w3.org/2005/08/addressing"
+
+	CreateSequenceAction         = Namespace + "/CreateSequence"
+	CreateSequenceResponseAction = Namespace + "/CreateSequenceResponse"
+	TerminateSequenceAction      = Namespace + "/TerminateSequence"
+	CloseSequenceAction          = Namespace + "/CloseSequence"
+
+	// AnonymousURI is the WS-Addressing address of the back channel of
+	// the request, used for AcksTo and ReplyTo.
+	AnonymousURI = AddressingNamespace + "/anonymous"
+)
+
+// Client creates reliable messaging sequences.
+type Client struct {
+	// SOAP is the client configured for the RM destination. Its URL and
+	// transport settings are used; its Header, if any, is sent along
+	// with the RM headers and must have an element name of its own, like
+	// *wsse.Security.
+	SOAP *soap.Client
+
+	AcksTo string // Endpoint acknowledgements are sent to (default AnonymousURI)
+
+	MaxRetries    int           // Retransmissions of undelivered messages (default 3)
+	RetryInterval time.Duration // Initial delay between retransmissions, doubled each time (default 1s)
+
+	// Retryable reports whether a message that failed with err may not
+	// have been delivered, and is retransmitted. The default retries
+	// connection errors and HTTP 502, 503 and 504 responses.
+	Retryable func(err error) bool
+}
+
+// Sequence is a WS-RM sequence. Its messages are numbered in the order
+// they are sent. It is safe for concurrent use.
+type Sequence struct {
+	ID      string
+	Expires time.Time // Zero if the sequence doesn't expire
+
+	c    *Client
+	mu   sync.Mutex
+	last uint64
+	acks []AcknowledgementRange
+}
+
+type attributedURI struct {
+	NS    string `xml:"xmlns:wsa,attr"`
+	Value string `xml:",chardata"`
+}
+
+type endpointReference struct {
+	NS      string `xml:"xmlns:wsa,attr"`
+	Address string `xml:"wsa:Address"`
+}
+
+type sequenceHeader struct {
+	NS             string `xml:"xmlns:wsrm,attr"`
+	MustUnderstand string `xml:"soapenv:mustUnderstand,attr"`
+	Identifier     string `xml:"wsrm:Identifier"`
+	MessageNumber  uint64 `xml:"wsrm:MessageNumber"`
+}
+
+type ackRequested struct {
+	NS         string `xml:"xmlns:wsrm,attr"`
+	Identifier string `xml:"wsrm:Identifier"`
+}
+
+// rmHeader is the SOAP header of RM messages.
+type rmHeader struct {
+	Action       attributedURI      `xml:"wsa:Action"`
+	To           attributedURI      `xml:"wsa:To"`
+	MessageID    attributedURI      `xml:"wsa:MessageID"`
+	ReplyTo      *endpointReference `xml:"wsa:ReplyTo,omitempty"`
+	Sequence     *sequenceHeader    `xml:"wsrm:Sequence,omitempty"`
+	AckRequested *ackRequested      `xml:"wsrm:AckRequested,omitempty"`
+	Extra        soap.Header        `xml:",omitempty"`
+}
+
+type createSequence struct {
+	Request struct {
+		NS     string            `xml:"xmlns:wsrm,attr"`
+		AcksTo endpointReference `xml:"wsrm:AcksTo"`
+	} `xml:"wsrm:CreateSequence"`
+}
+
+type createSequenceResponse struct {
+	Response *struct {
+		Identifier string `xml:"Identifier"`
+		Expires    string `xml:"Expires"`
+	} `xml:"CreateSequenceResponse"`
+}
+
+type terminateSequence struct {
+	Request struct {
+		NS            string `xml:"xmlns:wsrm,attr"`
+		Identifier    string `xml:"wsrm:Identifier"`
+		LastMsgNumber uint64 `xml:"wsrm:LastMsgNumber,omitempty"`
+	} `xml:"wsrm:TerminateSequence"`
+}
+
+// AcknowledgementRange is a range of acknowledged message numbers.
+type AcknowledgementRange struct {
+	Lower uint64 `xml:"Lower,attr"`
+	Upper uint64 `xml:"Upper,attr"`
+}
+
+type sequenceAcknowledgement struct {
+	Identifier string                 `xml:"Identifier"`
+	Ranges     []AcknowledgementRange `xml:"AcknowledgementRange"`
+}
+
+type responseHeader struct {
+	Header struct {
+		Acks []sequenceAcknowledgement `xml:"SequenceAcknowledgement"`
+	} `xml:"Header"`
+}
+
+// CreateSequence creates a new sequence at the RM destination.
+func (c *Client) CreateSequence(ctx context.Context) (*Sequence, error) {
+	if c.SOAP == nil {
+		return nil, errors.New("wsrm: missing SOAP client")
+	}
+	acksTo := c.AcksTo
+	if acksTo == "" {
+		acksTo = AnonymousURI
+	}
+	req := &createSequence{}
+	req.Request.NS = Namespace
+	req.Request.AcksTo = endpointReference{NS: AddressingNamespace, Address: acksTo}
+	var resp createSequenceResponse
+	if _, err := c.send(ctx, c.header(CreateSequenceAction), req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Response == nil || resp.Response.Identifier == "" {
+		return nil, errors.New("wsrm: response has no CreateSequenceResponse")
+	}
+	s := &Sequence{ID: resp.Response.Identifier, c: c}
+	if d := resp.Response.Expires; d != "" && d != "PT0S" {
+		ttl, err := parseDuration(d)
+		if err != nil {
+			return nil, fmt.Errorf("wsrm: invalid expiry: %v", err)
+		}
+		s.Expires = time.Now().Add(ttl)
+	}
+	return s, nil
+}
+
+// RoundTrip sends in as the next message of the sequence, with the
+// WS-Addressing action, and decodes the response into out. Messages
+// that may not have been delivered are retransmitted with the same
+// message number, as configured by the Client.
+func (s *Sequence) RoundTrip(ctx context.Context, action string, in, out soap.Message) error {
+	s.mu.Lock()
+	s.last++
+	n := s.last
+	s.mu.Unlock()
+
+	retryable := s.c.Retryable
+	if retryable == nil {
+		retryable = defaultRetryable
+	}
+	retries := s.c.MaxRetries
+	if retries <= 0 {
+		retries = 3
+	}
+	delay := s.c.RetryInterval
+	if delay <= 0 {
+		delay = time.Second
+	}
+	for attempt := 0; ; attempt++ {
+		hdr := s.c.header(action)
+		hdr.Sequence = &sequenceHeader{NS: Namespace, MustUnderstand: "1", Identifier: s.ID, MessageNumber: n}
+		hdr.AckRequested = &ackRequested{NS: Namespace, Identifier: s.ID}
+		env, err := s.c.send(ctx, hdr, in, out)
+		if err == nil {
+			s.ack(env)
+			return nil
+		}
+		if attempt == retries || !retryable(err) {
+			return err
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+// Acknowledged reports whether the destination acknowledged the message
+// with number n.
+func (s *Sequence) Acknowledged(n uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.acks {
+		if r.Lower <= n && n <= r.Upper {
+			return true
+		}
+	}
+	return false
+}
+
+// Acknowledgements returns the acknowledged ranges of message numbers,
+// as last reported by the destination.
+func (s *Sequence) Acknowledgements() []AcknowledgementRange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]AcknowledgementRange(nil), s.acks...)
+}
+
+// Terminate terminates the sequence at the destination.
+func (s *Sequence) Terminate(ctx context.Context) error {
+	s.mu.Lock()
+	last := s.last
+	s.mu.Unlock()
+	req := &terminateSequence{}
+	req.Request.NS = Namespace
+	req.Request.Identifier = s.ID
+	req.Request.LastMsgNumber = last
+	_, err := s.c.send(ctx, s.c.header(TerminateSequenceAction), req, &struct{}{})
+	return err
+}
+
+// ack records the SequenceAcknowledgement of the sequence in the
+// response envelope env, if any.
+func (s *Sequence) ack(env []byte) {
+	var resp responseHeader
+	if err := xml.Unmarshal(env, &resp); err != nil {
+		return
+	}
+	for _, a := range resp.Header.Acks {
+		if a.Identifier == s.ID {
+			s.mu.Lock()
+			s.acks = a.Ranges
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (c *Client) header(action string) *rmHeader {
+	return &rmHeader{
+		Action:    attributedURI{NS: AddressingNamespace, Value: action},
+		To:        attributedURI{NS: AddressingNamespace, Value: c.SOAP.URL},
+		MessageID: attributedURI{NS: AddressingNamespace, Value: "urn:uuid:" + soap.NewCorrelationID()},
+		ReplyTo:   &endpointReference{NS: AddressingNamespace, Address: AnonymousURI},
+		Extra:     c.SOAP.Header,
+	}
+}
+
+// send performs the call with hdr as the SOAP header, returning the
+// response envelope.
+func (c *Client) send(ctx context.Context, hdr *rmHeader, in, out soap.Message) ([]byte, error) {
+	cli := c.SOAP.Clone()
+	cli.Header = hdr
+	cli.ExcludeActionNamespace = true
+	var env []byte
+	soap.WithMiddleware(soap.CaptureMiddleware(func(ctx context.Context, call *soap.Call, err error) {
+		env = call.ResponseEnvelope
+	}))(cli)
+	action := hdr.Action.Value
+	var err error
+	if cli.Envelope == soap.EnvelopeNamespace12 && cli.Version != soap.SOAP12 {
+		err = cli.RoundTripSoap12Context(ctx, action, in, out)
+	} else {
+		err = cli.RoundTripWithActionContext(ctx, action, in, out)
+	}
+	return env, err
+}
+
+func defaultRetryable(err error) bool {
+	var fault *soap.Fault
+	if errors.As(err, &fault) {
+		return false
+	}
+	var herr *soap.HTTPError
+	if errors.As(err, &herr) {
+		switch herr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var uerr *url.Error
+	var nerr net.Error
+	return errors.As(err, &uerr) || errors.As(err, &nerr)
+}
+
+// parseDuration parses the xs:duration values of sequence expiries, of
+// days, hours, minutes and seconds.
+func parseDuration(s string) (time.Duration, error) {
+	if len(s) < 2 || s[0] != 'P' {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	var d time.Duration
+	inTime := false
+	num := ""
+	for _, r := range s[1:] {
+		switch {
+		case r >= '0' && r <= '9' || r == '.':
+			num += string(r)
+			continue
+		case r == 'T':
+			inTime = true
+			continue
+		}
+		var v float64
+		if _, err := fmt.Sscanf(num, "%g", &v); err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		num = ""
+		switch {
+		case r == 'D' && !inTime:
+			d += time.Duration(v * float64(24*time.Hour))
+		case r == 'H' && inTime:
+			d += time.Duration(v * float64(time.Hour))
+		case r == 'M' && inTime:
+			d += time.Duration(v * float64(time.Minute))
+		case r == 'S' && inTime:
+			d += time.Duration(v * float64(time.Second))
+		default:
+			return 0, fmt.Errorf("unsupported duration %q", s)
+		}
+	}
+	if num != "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
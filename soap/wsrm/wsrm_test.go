@@ -0,0 +1,131 @@
+package wsrm
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YapealAG/wsdl2go/soap"
+)
+
+type rmRequest struct {
+	Header struct {
+		Action   string `xml:"Action"`
+		To       string `xml:"To"`
+		Sequence *struct {
+			Identifier    string `xml:"Identifier"`
+			MessageNumber uint64 `xml:"MessageNumber"`
+		} `xml:"Sequence"`
+	} `xml:"Header"`
+	Body struct {
+		Inner []byte `xml:",innerxml"`
+	} `xml:"Body"`
+}
+
+type echo struct {
+	Echo struct {
+		NS   string `xml:"xmlns,attr"`
+		Data string `xml:"Data"`
+	} `xml:"Echo"`
+}
+
+func TestSequence(t *testing.T) {
+	var numbers []uint64
+	var terminated string
+	fail := map[uint64]bool{2: true}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		var req rmRequest
+		if err := xml.Unmarshal(b, &req); err != nil {
+			t.Error(err)
+		}
+		switch req.Header.Action {
+		case CreateSequenceAction:
+			if !strings.Contains(string(req.Body.Inner), "<wsa:Address>"+AnonymousURI+"</wsa:Address>") {
+				t.Errorf("unexpected CreateSequence %s", req.Body.Inner)
+			}
+			io.WriteString(w, `<Envelope><Body><CreateSequenceResponse xmlns="`+Namespace+`">
+<Identifier>urn:seq:1</Identifier><Expires>PT1H</Expires></CreateSequenceResponse></Body></Envelope>`)
+		case "urn:test/Echo":
+			n := req.Header.Sequence.MessageNumber
+			numbers = append(numbers, n)
+			if fail[n] {
+				delete(fail, n)
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			io.WriteString(w, `<Envelope><Header><SequenceAcknowledgement xmlns="`+Namespace+`">
+<Identifier>urn:seq:1</Identifier><AcknowledgementRange Lower="1" Upper="`+
+				strconv.FormatUint(n, 10)+`"/></SequenceAcknowledgement></Header>
+<Body><Echo xmlns="urn:test"><Data>ok</Data></Echo></Body></Envelope>`)
+		case TerminateSequenceAction:
+			terminated = string(req.Body.Inner)
+			io.WriteString(w, `<Envelope><Body/></Envelope>`)
+		default:
+			t.Errorf("unexpected action %q", req.Header.Action)
+		}
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	c := &Client{SOAP: soap.NewClient(s.URL), RetryInterval: time.Millisecond}
+	ctx := context.Background()
+	seq, err := c.CreateSequence(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seq.ID != "urn:seq:1" || time.Until(seq.Expires) < 59*time.Minute {
+		t.Fatalf("unexpected sequence %+v", seq)
+	}
+	for i := 0; i < 3; i++ {
+		var in, out echo
+		in.Echo.NS, in.Echo.Data = "urn:test", "hello"
+		if err := seq.RoundTrip(ctx, "urn:test/Echo", &in, &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.Echo.Data != "ok" {
+			t.Fatalf("unexpected response %+v", out)
+		}
+	}
+	if want := []uint64{1, 2, 2, 3}; !reflect.DeepEqual(numbers, want) {
+		t.Fatalf("want message numbers %v, have %v", want, numbers)
+	}
+	if !seq.Acknowledged(3) || seq.Acknowledged(4) {
+		t.Fatalf("unexpected acknowledgements %v", seq.Acknowledgements())
+	}
+	if err := seq.Terminate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(terminated, "<wsrm:LastMsgNumber>3</wsrm:LastMsgNumber>") {
+		t.Fatalf("unexpected TerminateSequence %s", terminated)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	testCases := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"PT1H", time.Hour},
+		{"P1DT30M", 24*time.Hour + 30*time.Minute},
+		{"PT1.5S", 1500 * time.Millisecond},
+	}
+	for i, tc := range testCases {
+		have, err := parseDuration(tc.in)
+		if err != nil || have != tc.want {
+			t.Errorf("test %d: %q: want %v, have %v (%v)", i, tc.in, tc.want, have, err)
+		}
+	}
+	for _, in := range []string{"", "1H", "P1Y", "PT1"} {
+		if _, err := parseDuration(in); err == nil {
+			t.Errorf("%q: want error", in)
+		}
+	}
+}
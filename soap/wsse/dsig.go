@@ -0,0 +1,368 @@
+package wsse
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha1" // registers crypto.SHA1
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/YapealAG/wsdl2go/soap"
+	"github.com/YapealAG/wsdl2go/soap/c14n"
+)
+
+// XML Signature namespaces and algorithm URIs.
+const (
+	DSigNamespace = "http://www.w3.org/2000/09/xmldsig#"
+
+	ExcC14N            = c14n.ExclusiveC14N10
+	EnvelopedSignature = DSigNamespace + "enveloped-signature"
+
+	SHA1   = DSigNamespace + "sha1"
+	SHA256 = "http://www.w3.org/2001/04/xmlenc#sha256"
+	SHA512 = "http://www.w3.org/2001/04/xmlenc#sha512"
+
+	RSASHA1     = DSigNamespace + "rsa-sha1"
+	RSASHA256   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	RSASHA512   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512"
+	ECDSASHA256 = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha256"
+	ECDSASHA512 = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha512"
+
+	X509TokenType        = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-x509-token-profile-1.0#X509v3"
+	X509SubjectKeyIDType = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-x509-token-profile-1.0#X509SubjectKeyIdentifier"
+	ThumbprintSHA1Type   = "http://docs.oasis-open.org/wss/oasis-wss-soap-message-security-1.1#ThumbprintSHA1"
+	Base64BinaryEncoding = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-soap-message-security-1.0#Base64Binary"
+)
+
+var digestMethods = map[string]crypto.Hash{
+	SHA1:   crypto.SHA1,
+	SHA256: crypto.SHA256,
+	SHA512: crypto.SHA512,
+}
+
+var signatureMethods = map[string]struct {
+	hash  crypto.Hash
+	ecdsa bool
+}{
+	RSASHA1:     {crypto.SHA1, false},
+	RSASHA256:   {crypto.SHA256, false},
+	RSASHA512:   {crypto.SHA512, false},
+	ECDSASHA256: {crypto.SHA256, true},
+	ECDSASHA512: {crypto.SHA512, true},
+}
+
+// SignatureError is returned for responses whose XML signature is
+// missing, malformed, does not match the signed content or was made
+// with an untrusted certificate.
+type SignatureError struct {
+	Reason string
+}
+
+func (e *SignatureError) Error() string {
+	return "wsse: invalid response signature: " + e.Reason
+}
+
+// Verifier verifies the ds:Signature in the Security header of response
+// envelopes, made with the key of an X.509 certificate:
+//
+//	v := &wsse.Verifier{Certificates: []*x509.Certificate{partnerCert}}
+//	cli.Middleware = append(cli.Middleware, v.Middleware())
+//
+// The signature must sign the Body
+// and, if present, the wsu:Timestamp of the response. The certificate is
+// taken from a BinarySecurityToken or the KeyInfo of the signature, or
+// matched among Certificates by subject key identifier or thumbprint.
+type Verifier struct {
+	// Certificates are trusted signing certificates, such as the one of
+	// a partner.
+	Certificates []*x509.Certificate
+
+	// Roots are the CAs trusting the other signing certificates, with
+	// optional Intermediates.
+	Roots         *x509.CertPool
+	Intermediates *x509.CertPool
+
+	now func() time.Time
+}
+
+// Middleware returns the soap.Middleware that verifies the signatures
+// of responses. Faults are not verified.
+func (v *Verifier) Middleware() soap.Middleware {
+	return func(next soap.RoundTripFunc) soap.RoundTripFunc {
+		return func(ctx context.Context, call *soap.Call) error {
+			call.Capture = true
+			if err := next(ctx, call); err != nil {
+				return err
+			}
+			if len(call.ResponseEnvelope) == 0 {
+				return nil
+			}
+			_, err := v.Verify(call.ResponseEnvelope)
+			return err
+		}
+	}
+}
+
+// Verify verifies the signature of the envelope env and returns the
+// certificate it was made with.
+func (v *Verifier) Verify(env []byte) (*x509.Certificate, error) {
+	fail := func(format string, args ...any) (*x509.Certificate, error) {
+		return nil, &SignatureError{Reason: fmt.Sprintf(format, args...)}
+	}
+	envelope, err := c14n.Parse(env)
+	if err != nil {
+		return fail("%v", err)
+	}
+	if ns := envelope.Name().Space; envelope.Name().Local != "Envelope" || ns != soap.EnvelopeNamespace11 && ns != soap.EnvelopeNamespace12 {
+		return fail("not a SOAP envelope")
+	}
+	var header, body *c14n.Element
+	for _, el := range envelope.Elements() {
+		switch {
+		case el.Is(envelope.Name().Space, "Header") && header == nil:
+			header = el
+		case el.Is(envelope.Name().Space, "Body") && body == nil:
+			body = el
+		default:
+			return fail("unexpected element %s in envelope", el.QName())
+		}
+	}
+	sec := header.Child(Namespace, "Security")
+	sig := sec.Child(DSigNamespace, "Signature")
+	if sig == nil || body == nil {
+		return fail("no signature")
+	}
+
+	ids := make(map[string]*c14n.Element)
+	var dup string
+	envelope.Walk(func(n *c14n.Element) {
+		for _, id := range elementIDs(n) {
+			if _, ok := ids[id]; ok {
+				dup = id
+			}
+			ids[id] = n
+		}
+	})
+	if dup != "" {
+		return fail("duplicate ID %q", dup)
+	}
+
+	si := sig.Child(DSigNamespace, "SignedInfo")
+	cm := si.Child(DSigNamespace, "CanonicalizationMethod")
+	canon, ok := canonicalizer(cm)
+	if !ok {
+		return fail("unsupported canonicalization %q", algorithm(cm))
+	}
+	signed := make(map[*c14n.Element]bool)
+	for _, ref := range si.Elements() {
+		if !ref.Is(DSigNamespace, "Reference") {
+			continue
+		}
+		uri, _ := ref.Attr("", "URI")
+		n := ids[strings.TrimPrefix(uri, "#")]
+		if !strings.HasPrefix(uri, "#") || n == nil {
+			return fail("unresolved reference %q", uri)
+		}
+		// Without a canonicalization transform, the referenced element
+		// is canonicalized with Canonical XML 1.0.
+		var rc c14n.Canonicalizer
+		var skip *c14n.Element
+		for _, t := range ref.Child(DSigNamespace, "Transforms").Elements() {
+			if algorithm(t) == EnvelopedSignature {
+				skip = sig
+				continue
+			}
+			if rc, ok = canonicalizer(t); !ok {
+				return fail("unsupported transform %q", algorithm(t))
+			}
+		}
+		rc.Omit = skip
+		alg := algorithm(ref.Child(DSigNamespace, "DigestMethod"))
+		h, ok := digestMethods[alg]
+		if !ok {
+			return fail("unsupported digest method %q", alg)
+		}
+		want, err := base64.StdEncoding.DecodeString(compactBase64(ref.Child(DSigNamespace, "DigestValue").Text()))
+		if err != nil {
+			return fail("malformed digest of %q", uri)
+		}
+		d := h.New()
+		d.Write(rc.Canonicalize(n))
+		if subtle.ConstantTimeCompare(d.Sum(nil), want) != 1 {
+			return fail("digest mismatch of %q", uri)
+		}
+		signed[n] = true
+	}
+	if !signed[body] {
+		return fail("body is not signed")
+	}
+	if ts := sec.Child(UtilityNamespace, "Timestamp"); ts != nil && !signed[ts] {
+		return fail("timestamp is not signed")
+	}
+
+	alg := algorithm(si.Child(DSigNamespace, "SignatureMethod"))
+	method, ok := signatureMethods[alg]
+	if !ok {
+		return fail("unsupported signature method %q", alg)
+	}
+	value, err := base64.StdEncoding.DecodeString(compactBase64(sig.Child(DSigNamespace, "SignatureValue").Text()))
+	if err != nil {
+		return fail("malformed signature value")
+	}
+	cert, err := v.certificate(sig.Child(DSigNamespace, "KeyInfo"), ids)
+	if err != nil {
+		return fail("%v", err)
+	}
+	h := method.hash.New()
+	h.Write(canon.Canonicalize(si))
+	digest := h.Sum(nil)
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if method.ecdsa || rsa.VerifyPKCS1v15(pub, method.hash, digest, value) != nil {
+			return fail("signature mismatch")
+		}
+	case *ecdsa.PublicKey:
+		n := len(value) / 2
+		if !method.ecdsa || len(value)%2 != 0 ||
+			!ecdsa.Verify(pub, digest, new(big.Int).SetBytes(value[:n]), new(big.Int).SetBytes(value[n:])) {
+			return fail("signature mismatch")
+		}
+	default:
+		return fail("unsupported key type %T", pub)
+	}
+	if err := v.trust(cert); err != nil {
+		return fail("%v", err)
+	}
+	return cert, nil
+}
+
+// certificate returns the certificate identified by the KeyInfo ki.
+func (v *Verifier) certificate(ki *c14n.Element, ids map[string]*c14n.Element) (*x509.Certificate, error) {
+	if x := ki.Child(DSigNamespace, "X509Data").Child(DSigNamespace, "X509Certificate"); x != nil {
+		return parseCertificate(x.Text())
+	}
+	str := ki.Child(Namespace, "SecurityTokenReference")
+	if ref := str.Child(Namespace, "Reference"); ref != nil {
+		uri, _ := ref.Attr("", "URI")
+		bst := ids[strings.TrimPrefix(uri, "#")]
+		if bst == nil || !bst.Is(Namespace, "BinarySecurityToken") {
+			return nil, fmt.Errorf("unresolved token reference %q", uri)
+		}
+		if vt, _ := bst.Attr("", "ValueType"); vt != X509TokenType {
+			return nil, fmt.Errorf("unsupported token type %q", vt)
+		}
+		return parseCertificate(bst.Text())
+	}
+	if kid := str.Child(Namespace, "KeyIdentifier"); kid != nil {
+		id, err := base64.StdEncoding.DecodeString(compactBase64(kid.Text()))
+		if err != nil {
+			return nil, fmt.Errorf("malformed key identifier")
+		}
+		vt, _ := kid.Attr("", "ValueType")
+		for _, cert := range v.Certificates {
+			switch vt {
+			case X509SubjectKeyIDType:
+				if bytes.Equal(cert.SubjectKeyId, id) {
+					return cert, nil
+				}
+			case ThumbprintSHA1Type:
+				sum := crypto.SHA1.New()
+				sum.Write(cert.Raw)
+				if bytes.Equal(sum.Sum(nil), id) {
+					return cert, nil
+				}
+			default:
+				return nil, fmt.Errorf("unsupported key identifier %q", vt)
+			}
+		}
+		return nil, fmt.Errorf("no trusted certificate with key identifier %x", id)
+	}
+	if ki == nil && len(v.Certificates) == 1 {
+		return v.Certificates[0], nil
+	}
+	return nil, fmt.Errorf("unsupported key info")
+}
+
+// trust checks that cert is trusted by the verifier.
+func (v *Verifier) trust(cert *x509.Certificate) error {
+	for _, c := range v.Certificates {
+		if c.Equal(cert) {
+			return nil
+		}
+	}
+	if v.Roots == nil {
+		return fmt.Errorf("untrusted certificate %q", cert.Subject)
+	}
+	now := time.Now()
+	if v.now != nil {
+		now = v.now()
+	}
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:         v.Roots,
+		Intermediates: v.Intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return fmt.Errorf("untrusted certificate %q: %v", cert.Subject, err)
+	}
+	return nil
+}
+
+// elementIDs returns the values of the ID attributes of n: wsu:Id, and
+// the unqualified Id and ID of XML Signature and SAML.
+func elementIDs(n *c14n.Element) []string {
+	var ids []string
+	if id, ok := n.Attr(UtilityNamespace, "Id"); ok {
+		ids = append(ids, id)
+	}
+	for _, name := range []string{"Id", "ID"} {
+		if id, ok := n.Attr("", name); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// algorithm returns the Algorithm attribute of n.
+func algorithm(n *c14n.Element) string {
+	if n == nil {
+		return ""
+	}
+	alg, _ := n.Attr("", "Algorithm")
+	return alg
+}
+
+// canonicalizer returns the Canonicalizer of the canonicalization
+// method or transform n, with the PrefixList of its InclusiveNamespaces
+// parameter.
+func canonicalizer(n *c14n.Element) (c14n.Canonicalizer, bool) {
+	c, ok := c14n.Algorithm(algorithm(n))
+	if list, ok := n.Child(ExcC14N, "InclusiveNamespaces").Attr("", "PrefixList"); ok && c.Exclusive {
+		c.InclusiveNamespaces = strings.Fields(list)
+	}
+	return c, ok
+}
+
+func parseCertificate(b64 string) (*x509.Certificate, error) {
+	der, err := base64.StdEncoding.DecodeString(compactBase64(b64))
+	if err != nil {
+		return nil, fmt.Errorf("malformed certificate")
+	}
+	return x509.ParseCertificate(der)
+}
+
+// compactBase64 removes the line breaks of base64 encoded values.
+func compactBase64(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
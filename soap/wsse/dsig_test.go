@@ -0,0 +1,203 @@
+package wsse
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YapealAG/wsdl2go/soap"
+	"github.com/YapealAG/wsdl2go/soap/c14n"
+)
+
+// testCertificate returns a self-signed certificate for key, or one
+// issued by parent with parentKey.
+func testCertificate(t *testing.T, name string, key crypto.Signer, parent *x509.Certificate, parentKey crypto.Signer) *x509.Certificate {
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		SubjectKeyId:          []byte(name),
+		IsCA:                  parent == nil,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+const signedEnvelope = `<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:wsu="` + UtilityNamespace + `">
+<soapenv:Header><wsse:Security xmlns:wsse="` + Namespace + `">
+<wsu:Timestamp wsu:Id="TS-1"><wsu:Created>2024-05-01T12:00:00Z</wsu:Created></wsu:Timestamp>
+<wsse:BinarySecurityToken wsu:Id="X509-1" ValueType="` + X509TokenType + `" EncodingType="` + Base64BinaryEncoding + `">{{cert}}</wsse:BinarySecurityToken>
+<ds:Signature xmlns:ds="` + DSigNamespace + `"><ds:SignedInfo>
+<ds:CanonicalizationMethod Algorithm="` + ExcC14N + `"><ec:InclusiveNamespaces xmlns:ec="` + ExcC14N + `" PrefixList="soapenv"/></ds:CanonicalizationMethod>
+<ds:SignatureMethod Algorithm="{{alg}}"/>
+<ds:Reference URI="#Body-1"><ds:Transforms><ds:Transform Algorithm="` + ExcC14N + `"/></ds:Transforms><ds:DigestMethod Algorithm="` + SHA256 + `"/><ds:DigestValue>{{digest:Body-1}}</ds:DigestValue></ds:Reference>
+<ds:Reference URI="#TS-1"><ds:Transforms><ds:Transform Algorithm="` + ExcC14N + `"/></ds:Transforms><ds:DigestMethod Algorithm="` + SHA1 + `"/><ds:DigestValue>{{digest:TS-1}}</ds:DigestValue></ds:Reference>
+</ds:SignedInfo><ds:SignatureValue>{{signature}}</ds:SignatureValue>
+<ds:KeyInfo>{{keyinfo}}</ds:KeyInfo></ds:Signature>
+</wsse:Security></soapenv:Header>
+<soapenv:Body wsu:Id="Body-1"><m:EchoResponse xmlns:m="urn:test"><m:A>ok</m:A></m:EchoResponse></soapenv:Body></soapenv:Envelope>`
+
+const tokenKeyInfo = `<wsse:SecurityTokenReference><wsse:Reference URI="#X509-1" ValueType="` + X509TokenType + `"/></wsse:SecurityTokenReference>`
+
+// signEnvelope fills in the digests and signature of the template tmpl.
+func signEnvelope(t *testing.T, tmpl, keyInfo string, key crypto.Signer, cert *x509.Certificate) string {
+	alg, h := RSASHA256, crypto.SHA256
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		alg = ECDSASHA256
+	}
+	env := strings.NewReplacer("{{cert}}", base64.StdEncoding.EncodeToString(cert.Raw),
+		"{{alg}}", alg, "{{keyinfo}}", keyInfo).Replace(tmpl)
+	for _, ref := range []struct {
+		id   string
+		hash crypto.Hash
+	}{{"Body-1", crypto.SHA256}, {"TS-1", crypto.SHA1}} {
+		root, err := c14n.Parse([]byte(env))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var n *c14n.Element
+		root.Walk(func(el *c14n.Element) {
+			if id, _ := el.Attr(UtilityNamespace, "Id"); id == ref.id {
+				n = el
+			}
+		})
+		d := ref.hash.New()
+		d.Write(c14n.Canonicalizer{Exclusive: true}.Canonicalize(n))
+		env = strings.Replace(env, "{{digest:"+ref.id+"}}", base64.StdEncoding.EncodeToString(d.Sum(nil)), 1)
+	}
+	root, err := c14n.Parse([]byte(env))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var si *c14n.Element
+	root.Walk(func(el *c14n.Element) {
+		if el.Is(DSigNamespace, "SignedInfo") {
+			si = el
+		}
+	})
+	d := h.New()
+	d.Write(c14n.Canonicalizer{Exclusive: true, InclusiveNamespaces: []string{"soapenv"}}.Canonicalize(si))
+	var sig []byte
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key, h, d.Sum(nil))
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, key, d.Sum(nil))
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Replace(env, "{{signature}}", base64.StdEncoding.EncodeToString(sig), 1)
+}
+
+func TestVerifier(t *testing.T) {
+	caKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ca := testCertificate(t, "ca", caKey, nil, nil)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rsaCert := testCertificate(t, "partner", rsaKey, ca, caKey)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecCert := testCertificate(t, "ec", ecKey, nil, nil)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	embedded := `<ds:X509Data><ds:X509Certificate>` + base64.StdEncoding.EncodeToString(ecCert.Raw) + `</ds:X509Certificate></ds:X509Data>`
+	ski := `<wsse:SecurityTokenReference><wsse:KeyIdentifier ValueType="` + X509SubjectKeyIDType + `">` +
+		base64.StdEncoding.EncodeToString(ecCert.SubjectKeyId) + `</wsse:KeyIdentifier></wsse:SecurityTokenReference>`
+	valid := signEnvelope(t, signedEnvelope, tokenKeyInfo, rsaKey, rsaCert)
+	cases := []struct {
+		v    *Verifier
+		env  string
+		want string
+	}{
+		{&Verifier{Roots: roots}, valid, ""},
+		{&Verifier{Certificates: []*x509.Certificate{rsaCert}}, valid, ""},
+		{&Verifier{Certificates: []*x509.Certificate{ecCert}}, valid, "untrusted certificate"},
+		{&Verifier{Certificates: []*x509.Certificate{ecCert}}, signEnvelope(t, signedEnvelope, embedded, ecKey, ecCert), ""},
+		{&Verifier{Certificates: []*x509.Certificate{ecCert}}, signEnvelope(t, signedEnvelope, ski, ecKey, ecCert), ""},
+		{&Verifier{Roots: roots}, signEnvelope(t, signedEnvelope, ski, ecKey, ecCert), "no trusted certificate"},
+		{&Verifier{Roots: roots}, strings.Replace(valid, "<m:A>ok", "<m:A>ko", 1), `digest mismatch of "#Body-1"`},
+		{&Verifier{Roots: roots}, strings.Replace(valid, "12:00:00Z", "13:00:00Z", 1), `digest mismatch of "#TS-1"`},
+		{&Verifier{Roots: roots}, strings.Replace(valid, `URI="#TS-1"`, `URI="#TS-1" Id="x"`, 1), "signature mismatch"},
+		{&Verifier{Roots: roots}, strings.Replace(valid, "</soapenv:Body>", `</soapenv:Body><soapenv:Body wsu:Id="Body-2"/>`, 1), "unexpected element soapenv:Body"},
+		{&Verifier{Roots: roots}, strings.Replace(valid, `<m:A>ok`, `<m:A wsu:Id="TS-1">ok`, 1), `duplicate ID "TS-1"`},
+		{&Verifier{Roots: roots}, `<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/"><soapenv:Body/></soapenv:Envelope>`, "no signature"},
+		{&Verifier{Roots: roots}, `<Envelope/>`, "not a SOAP envelope"},
+	}
+	for i, tc := range cases {
+		_, err := tc.v.Verify([]byte(tc.env))
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("test %d: unexpected error: %v", i, err)
+			}
+			continue
+		}
+		var se *SignatureError
+		if !errors.As(err, &se) || !strings.Contains(se.Reason, tc.want) {
+			t.Errorf("test %d: want error %q, have %v", i, tc.want, err)
+		}
+	}
+
+	// A signature of another element than the body is not enough.
+	unsignedBody := strings.Replace(signedEnvelope, `<soapenv:Body wsu:Id="Body-1">`, `<soapenv:Body><x wsu:Id="Body-1"/>`, 1)
+	_, err := (&Verifier{Roots: roots}).Verify([]byte(signEnvelope(t, unsignedBody, tokenKeyInfo, rsaKey, rsaCert)))
+	if err == nil || !strings.Contains(err.Error(), "body is not signed") {
+		t.Errorf("want unsigned body error, have %v", err)
+	}
+}
+
+func TestVerifierMiddleware(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	cert := testCertificate(t, "partner", key, nil, nil)
+	env := signEnvelope(t, signedEnvelope, tokenKeyInfo, key, cert)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, env)
+	}))
+	defer s.Close()
+
+	type msgT struct{ A string }
+	var out struct {
+		Msg msgT `xml:"EchoResponse"`
+	}
+	v := &Verifier{Certificates: []*x509.Certificate{cert}}
+	c := soap.NewClient(s.URL)
+	c.Middleware = append(c.Middleware, v.Middleware())
+	if err := c.RoundTrip(&msgT{A: "hello"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Msg.A != "ok" {
+		t.Errorf("unexpected response %+v", out)
+	}
+	env = strings.Replace(env, "<m:A>ok", "<m:A>ko", 1)
+	var se *SignatureError
+	if err := c.RoundTrip(&msgT{A: "hello"}, &out); !errors.As(err, &se) {
+		t.Fatalf("want signature error, have %v", err)
+	}
+}
@@ -0,0 +1,420 @@
+package wsse
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/YapealAG/wsdl2go/soap"
+	"github.com/YapealAG/wsdl2go/soap/c14n"
+)
+
+// XML Encryption namespaces and algorithm URIs.
+const (
+	EncryptionNamespace = "http://www.w3.org/2001/04/xmlenc#"
+
+	AES128CBC = EncryptionNamespace + "aes128-cbc"
+	AES256CBC = EncryptionNamespace + "aes256-cbc"
+	AES128GCM = "http://www.w3.org/2009/xmlenc11#aes128-gcm"
+	AES256GCM = "http://www.w3.org/2009/xmlenc11#aes256-gcm"
+
+	RSAOAEP = EncryptionNamespace + "rsa-oaep-mgf1p"
+	RSA15   = EncryptionNamespace + "rsa-1_5"
+
+	EncryptedContent = EncryptionNamespace + "Content"
+	EncryptedElement = EncryptionNamespace + "Element"
+)
+
+var dataEncryptions = map[string]struct {
+	keySize int
+	gcm     bool
+}{
+	AES128CBC: {16, false},
+	AES256CBC: {32, false},
+	AES128GCM: {16, true},
+	AES256GCM: {32, true},
+}
+
+// DecryptionError is returned for responses that cannot be decrypted,
+// or are not encrypted although Encryption requires it.
+type DecryptionError struct {
+	Reason string
+}
+
+func (e *DecryptionError) Error() string {
+	return "wsse: cannot decrypt response: " + e.Reason
+}
+
+// Encryption encrypts the body of requests for the service, with an
+// AES key sent in an xenc:EncryptedKey of the Security header, and
+// decrypts the encrypted data of responses with the private key of the
+// client:
+//
+//	enc := &wsse.Encryption{Recipient: serviceCert, Key: clientKey}
+//	cli.Middleware = append(cli.Middleware, enc.Middleware())
+//
+// Requests are encrypted only with a Recipient, responses decrypted only
+// with a Key. Encryption is applied to the encoded envelope, after other
+// middleware added their headers; it requires UTF-8 envelopes.
+type Encryption struct {
+	Recipient *x509.Certificate // Certificate of the service, with an RSA key
+	Key       crypto.Decrypter  // Private RSA key of the client
+
+	Algorithm    string // Data encryption of requests (default AES256GCM)
+	KeyTransport string // Key transport of requests (default RSAOAEP)
+
+	// Required rejects responses without encrypted data in their body.
+	Required bool
+}
+
+// Middleware returns the soap.Middleware that encrypts requests and
+// decrypts responses.
+func (enc *Encryption) Middleware() soap.Middleware {
+	return func(next soap.RoundTripFunc) soap.RoundTripFunc {
+		return func(ctx context.Context, call *soap.Call) error {
+			if enc.Recipient != nil {
+				id := "ED-" + soap.NewCorrelationID()
+				key, ek, err := enc.newKey(id)
+				if err != nil {
+					return err
+				}
+				hdr := securityHeader(call.Header)
+				if hdr.Security == nil {
+					hdr.Security = NewSecurity()
+				}
+				hdr.Security.Tokens = append(append([]byte(nil), hdr.Security.Tokens...), ek...)
+				call.Header = hdr
+				alg := enc.algorithm()
+				call.RequestFilters = append(call.RequestFilters, func(env []byte) ([]byte, error) {
+					return encryptBody(env, alg, key, id)
+				})
+			}
+			if enc.Key != nil {
+				call.ResponseFilters = append(call.ResponseFilters, enc.Decrypt)
+			}
+			return next(ctx, call)
+		}
+	}
+}
+
+func (enc *Encryption) algorithm() string {
+	if enc.Algorithm == "" {
+		return AES256GCM
+	}
+	return enc.Algorithm
+}
+
+type encryptionMethod struct {
+	Algorithm string `xml:"Algorithm,attr"`
+}
+
+type keyIdentifier struct {
+	ValueType    string `xml:"ValueType,attr"`
+	EncodingType string `xml:"EncodingType,attr"`
+	Value        string `xml:",chardata"`
+}
+
+type encryptedKey struct {
+	XMLName xml.Name         `xml:"xenc:EncryptedKey"`
+	NS      string           `xml:"xmlns:xenc,attr"`
+	Method  encryptionMethod `xml:"xenc:EncryptionMethod"`
+	KeyInfo struct {
+		NS            string        `xml:"xmlns:ds,attr"`
+		KeyIdentifier keyIdentifier `xml:"wsse:SecurityTokenReference>wsse:KeyIdentifier"`
+	} `xml:"ds:KeyInfo"`
+	CipherValue string `xml:"xenc:CipherData>xenc:CipherValue"`
+	References  []struct {
+		URI string `xml:"URI,attr"`
+	} `xml:"xenc:ReferenceList>xenc:DataReference"`
+}
+
+type encryptedData struct {
+	XMLName     xml.Name         `xml:"xenc:EncryptedData"`
+	NS          string           `xml:"xmlns:xenc,attr"`
+	ID          string           `xml:"Id,attr"`
+	Type        string           `xml:"Type,attr"`
+	Method      encryptionMethod `xml:"xenc:EncryptionMethod"`
+	CipherValue string           `xml:"xenc:CipherData>xenc:CipherValue"`
+}
+
+// newKey generates a data encryption key and returns it with the
+// EncryptedKey element that transports it to the recipient, for the
+// encrypted data with the id.
+func (enc *Encryption) newKey(id string) (key, ek []byte, err error) {
+	method, ok := dataEncryptions[enc.algorithm()]
+	if !ok {
+		return nil, nil, fmt.Errorf("wsse: unsupported encryption %q", enc.algorithm())
+	}
+	pub, ok := enc.Recipient.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, nil, fmt.Errorf("wsse: unsupported recipient key %T", enc.Recipient.PublicKey)
+	}
+	key = make([]byte, method.keySize)
+	if _, err = rand.Read(key); err != nil {
+		return nil, nil, err
+	}
+	v := &encryptedKey{NS: EncryptionNamespace}
+	v.Method.Algorithm = enc.KeyTransport
+	var ct []byte
+	switch v.Method.Algorithm {
+	case "", RSAOAEP:
+		v.Method.Algorithm = RSAOAEP
+		ct, err = rsa.EncryptOAEP(sha1.New(), rand.Reader, pub, key, nil)
+	case RSA15:
+		ct, err = rsa.EncryptPKCS1v15(rand.Reader, pub, key)
+	default:
+		return nil, nil, fmt.Errorf("wsse: unsupported key transport %q", v.Method.Algorithm)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	v.KeyInfo.NS = DSigNamespace
+	v.KeyInfo.KeyIdentifier = keyIdentifier{ValueType: X509SubjectKeyIDType, EncodingType: Base64BinaryEncoding}
+	kid := enc.Recipient.SubjectKeyId
+	if len(kid) == 0 {
+		sum := sha1.Sum(enc.Recipient.Raw)
+		v.KeyInfo.KeyIdentifier.ValueType, kid = ThumbprintSHA1Type, sum[:]
+	}
+	v.KeyInfo.KeyIdentifier.Value = base64.StdEncoding.EncodeToString(kid)
+	v.CipherValue = base64.StdEncoding.EncodeToString(ct)
+	v.References = append(v.References, struct {
+		URI string `xml:"URI,attr"`
+	}{"#" + id})
+	ek, err = xml.Marshal(v)
+	return key, ek, err
+}
+
+// encryptBody replaces the content of the body of env by EncryptedData
+// with the id, encrypted with key.
+func encryptBody(env []byte, alg string, key []byte, id string) ([]byte, error) {
+	if !utf8.Valid(env) {
+		return nil, errors.New("wsse: encryption requires UTF-8 envelopes")
+	}
+	root, err := c14n.Parse(env)
+	if err != nil {
+		return nil, err
+	}
+	body := root.Child(root.Name().Space, "Body")
+	if len(body.Elements()) == 0 {
+		return nil, errors.New("wsse: request body is empty")
+	}
+	// The content is canonicalized with its namespace declarations, as
+	// it is decrypted out of the context of the envelope.
+	ct, err := encryptData(alg, key, c14n.Canonicalizer{}.CanonicalizeContent(body))
+	if err != nil {
+		return nil, err
+	}
+	ed, err := xml.Marshal(&encryptedData{
+		NS:          EncryptionNamespace,
+		ID:          id,
+		Type:        EncryptedContent,
+		Method:      encryptionMethod{alg},
+		CipherValue: base64.StdEncoding.EncodeToString(ct),
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, start, end, _ := body.Offsets()
+	b := make([]byte, 0, int64(len(env))-(end-start)+int64(len(ed)))
+	b = append(b, env[:start]...)
+	b = append(b, ed...)
+	return append(b, env[end:]...), nil
+}
+
+// Decrypt returns the envelope env with the EncryptedData referenced by
+// the EncryptedKeys of its Security header replaced by their plaintext.
+func (enc *Encryption) Decrypt(env []byte) ([]byte, error) {
+	fail := func(format string, args ...any) ([]byte, error) {
+		return nil, &DecryptionError{Reason: fmt.Sprintf(format, args...)}
+	}
+	if !utf8.Valid(env) {
+		return fail("not an UTF-8 envelope")
+	}
+	root, err := c14n.Parse(env)
+	if err != nil {
+		return fail("%v", err)
+	}
+	ids := make(map[string]*c14n.Element)
+	root.Walk(func(e *c14n.Element) {
+		for _, id := range elementIDs(e) {
+			ids[id] = e
+		}
+	})
+	type replacement struct {
+		start, end int64
+		plain      []byte
+	}
+	var reps []replacement
+	sec := root.Child(root.Name().Space, "Header").Child(Namespace, "Security")
+	for _, ek := range sec.Elements() {
+		if !ek.Is(EncryptionNamespace, "EncryptedKey") {
+			continue
+		}
+		key, err := enc.decryptKey(ek)
+		if err != nil {
+			return fail("%v", err)
+		}
+		for _, ref := range ek.Child(EncryptionNamespace, "ReferenceList").Elements() {
+			if !ref.Is(EncryptionNamespace, "DataReference") {
+				continue
+			}
+			uri, _ := ref.Attr("", "URI")
+			ed := ids[strings.TrimPrefix(uri, "#")]
+			if !ed.Is(EncryptionNamespace, "EncryptedData") {
+				return fail("unresolved data reference %q", uri)
+			}
+			ct, err := base64.StdEncoding.DecodeString(compactBase64(
+				ed.Child(EncryptionNamespace, "CipherData").Child(EncryptionNamespace, "CipherValue").Text()))
+			if err != nil {
+				return fail("malformed cipher value of %q", uri)
+			}
+			plain, err := decryptData(algorithm(ed.Child(EncryptionNamespace, "EncryptionMethod")), key, ct)
+			if err != nil {
+				return fail("%q: %v", uri, err)
+			}
+			start, _, _, end := ed.Offsets()
+			reps = append(reps, replacement{start, end, plain})
+		}
+	}
+	if enc.Required {
+		_, bodyStart, bodyEnd, _ := root.Child(root.Name().Space, "Body").Offsets()
+		encrypted := false
+		for _, r := range reps {
+			encrypted = encrypted || r.start >= bodyStart && r.end <= bodyEnd
+		}
+		if !encrypted {
+			return fail("body is not encrypted")
+		}
+	}
+	if len(reps) == 0 {
+		return env, nil
+	}
+	sort.Slice(reps, func(i, j int) bool { return reps[i].start < reps[j].start })
+	var b []byte
+	var last int64
+	for _, r := range reps {
+		if r.start < last {
+			return fail("overlapping encrypted data")
+		}
+		b = append(append(b, env[last:r.start]...), r.plain...)
+		last = r.end
+	}
+	return append(b, env[last:]...), nil
+}
+
+// decryptKey returns the key transported by the EncryptedKey ek.
+func (enc *Encryption) decryptKey(ek *c14n.Element) ([]byte, error) {
+	if enc.Key == nil {
+		return nil, errors.New("no private key")
+	}
+	ct, err := base64.StdEncoding.DecodeString(compactBase64(
+		ek.Child(EncryptionNamespace, "CipherData").Child(EncryptionNamespace, "CipherValue").Text()))
+	if err != nil {
+		return nil, errors.New("malformed encrypted key")
+	}
+	method := ek.Child(EncryptionNamespace, "EncryptionMethod")
+	var opts crypto.DecrypterOpts
+	switch alg := algorithm(method); alg {
+	case RSAOAEP:
+		h := crypto.SHA1
+		if dm := algorithm(method.Child(DSigNamespace, "DigestMethod")); dm != "" {
+			var ok bool
+			if h, ok = digestMethods[dm]; !ok {
+				return nil, fmt.Errorf("unsupported digest method %q", dm)
+			}
+		}
+		opts = &rsa.OAEPOptions{Hash: h}
+	case RSA15:
+		opts = &rsa.PKCS1v15DecryptOptions{}
+	default:
+		return nil, fmt.Errorf("unsupported key transport %q", alg)
+	}
+	key, err := enc.Key.Decrypt(rand.Reader, ct, opts)
+	if err != nil {
+		return nil, fmt.Errorf("encrypted key: %v", err)
+	}
+	return key, nil
+}
+
+func encryptData(alg string, key, plain []byte) ([]byte, error) {
+	method, ok := dataEncryptions[alg]
+	if !ok {
+		return nil, fmt.Errorf("wsse: unsupported encryption %q", alg)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if method.gcm {
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plain)+aead.Overhead())
+		if _, err = rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		return aead.Seal(nonce, nonce, plain, nil), nil
+	}
+	// XML Encryption pads to the block size with the padding length as
+	// the last byte; at least one byte of padding is added.
+	n := aes.BlockSize - len(plain)%aes.BlockSize
+	b := make([]byte, aes.BlockSize+len(plain)+n)
+	if _, err = rand.Read(b[:aes.BlockSize]); err != nil {
+		return nil, err
+	}
+	copy(b[aes.BlockSize:], plain)
+	b[len(b)-1] = byte(n)
+	cipher.NewCBCEncrypter(block, b[:aes.BlockSize]).CryptBlocks(b[aes.BlockSize:], b[aes.BlockSize:])
+	return b, nil
+}
+
+func decryptData(alg string, key, ct []byte) ([]byte, error) {
+	method, ok := dataEncryptions[alg]
+	if !ok {
+		return nil, fmt.Errorf("unsupported encryption %q", alg)
+	}
+	if len(key) != method.keySize {
+		return nil, errors.New("key size does not match encryption")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if method.gcm {
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		if len(ct) < aead.NonceSize()+aead.Overhead() {
+			return nil, errors.New("short cipher value")
+		}
+		plain, err := aead.Open(nil, ct[:aead.NonceSize()], ct[aead.NonceSize():], nil)
+		if err != nil {
+			return nil, errors.New("authentication failed")
+		}
+		return plain, nil
+	}
+	if len(ct) < 2*aes.BlockSize || len(ct)%aes.BlockSize != 0 {
+		return nil, errors.New("malformed cipher value")
+	}
+	plain := make([]byte, len(ct)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, ct[:aes.BlockSize]).CryptBlocks(plain, ct[aes.BlockSize:])
+	n := int(plain[len(plain)-1])
+	if n < 1 || n > aes.BlockSize {
+		return nil, errors.New("malformed padding")
+	}
+	return plain[:len(plain)-n], nil
+}
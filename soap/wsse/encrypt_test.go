@@ -0,0 +1,102 @@
+package wsse
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/YapealAG/wsdl2go/soap"
+)
+
+func TestEncryption(t *testing.T) {
+	serverKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	serverCert := testCertificate(t, "service", serverKey, nil, nil)
+	clientKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	clientCert := testCertificate(t, "client", clientKey, nil, nil)
+	clientCert.SubjectKeyId = nil
+
+	type msgT struct{ A string }
+	var out struct {
+		Msg msgT `xml:"msgT"`
+	}
+	var wire, plain []byte
+	var reply func() string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wire, _ = io.ReadAll(r.Body)
+		var err error
+		if plain, err = (&Encryption{Key: serverKey}).Decrypt(wire); err != nil {
+			t.Error(err)
+		}
+		io.WriteString(w, reply())
+	}))
+	defer s.Close()
+
+	// encryptedReply encrypts the response body for the client.
+	encryptedReply := func(alg, transport string) func() string {
+		return func() string {
+			enc := &Encryption{Recipient: clientCert, Algorithm: alg, KeyTransport: transport}
+			key, ek, err := enc.newKey("ED-r")
+			if err != nil {
+				t.Fatal(err)
+			}
+			env := `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Header><wsse:Security xmlns:wsse="` + Namespace + `">` +
+				string(ek) + `</wsse:Security></s:Header><s:Body><msgT xmlns="urn:test"><A>ok</A></msgT></s:Body></s:Envelope>`
+			b, err := encryptBody([]byte(env), enc.algorithm(), key, "ED-r")
+			if err != nil {
+				t.Fatal(err)
+			}
+			return string(b)
+		}
+	}
+
+	for _, alg := range []string{"", AES128CBC, AES256CBC, AES128GCM} {
+		for _, transport := range []string{"", RSA15} {
+			enc := &Encryption{Recipient: serverCert, Key: clientKey, Algorithm: alg, KeyTransport: transport, Required: true}
+			c := soap.NewClient(s.URL, soap.WithNamespace("urn:test"))
+			c.Middleware = append(c.Middleware, enc.Middleware())
+			reply = encryptedReply(alg, transport)
+			out.Msg.A = ""
+			if err := c.RoundTrip(&msgT{A: "hello"}, &out); err != nil {
+				t.Fatalf("%s %s: %v", alg, transport, err)
+			}
+			if out.Msg.A != "ok" {
+				t.Errorf("%s %s: unexpected response %+v", alg, transport, out)
+			}
+			if bytes.Contains(wire, []byte("hello")) || !bytes.Contains(wire, []byte("xenc:EncryptedData")) {
+				t.Errorf("%s %s: request is not encrypted: %s", alg, transport, wire)
+			}
+			if !bytes.Contains(plain, []byte(`<A xmlns="urn:test" xmlns:soapenv="`+soap.EnvelopeNamespace11+`">hello</A>`)) {
+				t.Errorf("%s %s: unexpected plaintext %s", alg, transport, plain)
+			}
+		}
+	}
+
+	c := soap.NewClient(s.URL, soap.WithNamespace("urn:test"))
+	enc := &Encryption{Key: clientKey, Required: true}
+	c.Middleware = append(c.Middleware, enc.Middleware())
+	reply = func() string { return `<Envelope><Body><msgT><A>ok</A></msgT></Body></Envelope>` }
+	var de *DecryptionError
+	if err := c.RoundTrip(&msgT{A: "hello"}, &out); !errors.As(err, &de) || !strings.Contains(de.Reason, "not encrypted") {
+		t.Errorf("want not encrypted error, have %v", err)
+	}
+	enc.Required = false
+	if err := c.RoundTrip(&msgT{A: "hello"}, &out); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	reply = func() string {
+		env := encryptedReply(AES256GCM, "")()
+		i := strings.Index(env, "<xenc:EncryptedData")
+		i += strings.Index(env[i:], "<xenc:CipherValue>") + len("<xenc:CipherValue>")
+		return env[:i] + "AAAA" + env[i+4:]
+	}
+	if err := c.RoundTrip(&msgT{A: "hello"}, &out); !errors.As(err, &de) || !strings.Contains(de.Reason, "authentication failed") {
+		t.Errorf("want authentication error, have %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package wsse
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/YapealAG/wsdl2go/soap"
+)
+
+// AddressingNamespace is the WS-Addressing 1.0 namespace.
+const AddressingNamespace = "http://www.w3.org/2005/08/addressing"
+
+// PolicyTimestampTTL is the lifetime of the timestamps sent for a Policy.
+const PolicyTimestampTTL = 5 * time.Minute
+
+// Policy is the security a service requires of requests, as declared by
+// the WS-Policy assertions of its WSDL. wsdl2go generates it as the
+// Policy variable of service packages:
+//
+//	cli := soap.NewClient(url, example.Policy.Option("user", "secret"))
+type Policy struct {
+	TLS           bool // Requests must be sent over HTTPS
+	UsernameToken bool // Requests carry a wsse:UsernameToken
+	Timestamp     bool // Requests carry a wsu:Timestamp
+	Addressing    bool // Requests carry WS-Addressing headers
+}
+
+// Option returns a soap.Option that adds the middleware of the policy
+// to the client.
+func (p Policy) Option(username, password string) soap.Option {
+	return func(c *soap.Client) {
+		soap.WithMiddleware(p.Middleware(username, password))(c)
+	}
+}
+
+// Middleware returns a soap.Middleware that secures calls as required by
+// the policy, sending username and password in the UsernameToken. Calls
+// to endpoints other than HTTPS fail if TLS is required. The SOAP header
+// of the client, if any, is sent along: a *Security header is extended,
+// other headers must have an element name of their own.
+func (p Policy) Middleware(username, password string) soap.Middleware {
+	return func(next soap.RoundTripFunc) soap.RoundTripFunc {
+		return func(ctx context.Context, call *soap.Call) error {
+			if p.TLS {
+				u, err := url.Parse(call.URL)
+				if err != nil {
+					return err
+				}
+				if u.Scheme != "https" {
+					return fmt.Errorf("wsse: policy requires TLS, endpoint is %s", call.URL)
+				}
+			}
+			hdr := securityHeader(call.Header)
+			if p.UsernameToken || p.Timestamp {
+				if hdr.Security == nil {
+					hdr.Security = NewSecurity()
+				}
+				hdr.Security.UtilityNS = UtilityNamespace
+				if p.Timestamp {
+					hdr.Security.Timestamp = NewTimestamp(PolicyTimestampTTL)
+				}
+				if p.UsernameToken {
+					hdr.Security.UsernameToken = NewUsernameToken(username, password)
+				}
+			}
+			if p.Addressing {
+				hdr.Action = &addressingURI{NS: AddressingNamespace, Value: call.Action}
+				hdr.To = &addressingURI{NS: AddressingNamespace, Value: call.URL}
+				hdr.MessageID = &addressingURI{NS: AddressingNamespace, Value: "urn:uuid:" + soap.NewCorrelationID()}
+				hdr.ReplyTo = &endpointReference{NS: AddressingNamespace, Address: AddressingNamespace + "/anonymous"}
+			}
+			call.Header = hdr
+			return next(ctx, call)
+		}
+	}
+}
+
+type addressingURI struct {
+	NS    string `xml:"xmlns:wsa,attr"`
+	Value string `xml:",chardata"`
+}
+
+type endpointReference struct {
+	NS      string `xml:"xmlns:wsa,attr"`
+	Address string `xml:"wsa:Address"`
+}
+
+// policyHeader is the SOAP header of requests secured by a Policy.
+type policyHeader struct {
+	Action    *addressingURI     `xml:"wsa:Action,omitempty"`
+	To        *addressingURI     `xml:"wsa:To,omitempty"`
+	MessageID *addressingURI     `xml:"wsa:MessageID,omitempty"`
+	ReplyTo   *endpointReference `xml:"wsa:ReplyTo,omitempty"`
+	Security  *Security
+	Extra     soap.Message `xml:",omitempty"`
+}
+
+// securityHeader returns a copy of the SOAP header h of a call that can
+// be extended with a Security element: a *Security or *policyHeader is
+// copied, other headers are sent after the security headers.
+func securityHeader(h soap.Message) *policyHeader {
+	switch h := h.(type) {
+	case *policyHeader:
+		cp := *h
+		if h.Security != nil {
+			sec := *h.Security
+			cp.Security = &sec
+		}
+		return &cp
+	case *Security:
+		cp := *h
+		return &policyHeader{Security: &cp}
+	}
+	return &policyHeader{Extra: h}
+}
@@ -0,0 +1,67 @@
+package wsse
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YapealAG/wsdl2go/soap"
+)
+
+func TestPolicy(t *testing.T) {
+	type msgT struct{ A, B string }
+	type envT struct{ msgT }
+	var header struct {
+		Action    string `xml:"Header>Action"`
+		To        string `xml:"Header>To"`
+		MessageID string `xml:"Header>MessageID"`
+		Security  struct {
+			Created  string `xml:"Timestamp>Created"`
+			Expires  string `xml:"Timestamp>Expires"`
+			Username string `xml:"UsernameToken>Username"`
+			Password string `xml:"UsernameToken>Password"`
+			Tokens   string `xml:",innerxml"`
+		} `xml:"Header>Security"`
+	}
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if err := xml.Unmarshal(b, &header); err != nil {
+			t.Error(err)
+		}
+		io.WriteString(w, `<Envelope><Body><msgT><A>ok</A></msgT></Body></Envelope>`)
+	}))
+	defer s.Close()
+
+	p := Policy{TLS: true, UsernameToken: true, Timestamp: true, Addressing: true}
+	c := soap.NewClient(s.URL, soap.WithNamespace("urn:test"), soap.WithHTTPClient(s.Client()),
+		soap.WithSOAPHeader(&Security{NS: Namespace, Tokens: []byte(`<saml:Assertion xmlns:saml="urn:saml"/>`)}),
+		p.Option("user", "secret"))
+	if err := c.RoundTripWithAction("Echo", &msgT{A: "hello"}, &envT{}); err != nil {
+		t.Fatal(err)
+	}
+	if header.Action != "urn:test/Echo" || header.To != s.URL || !strings.HasPrefix(header.MessageID, "urn:uuid:") {
+		t.Errorf("unexpected addressing headers %+v", header)
+	}
+	sec := header.Security
+	if sec.Username != "user" || sec.Password != "secret" || !strings.Contains(sec.Tokens, "<saml:Assertion") {
+		t.Errorf("unexpected security header %+v", sec)
+	}
+	created, err := time.Parse(time.RFC3339, sec.Created)
+	if err != nil || time.Since(created) > time.Minute {
+		t.Errorf("unexpected timestamp %q (%v)", sec.Created, err)
+	}
+	expires, err := time.Parse(time.RFC3339, sec.Expires)
+	if err != nil || expires.Sub(created) != PolicyTimestampTTL {
+		t.Errorf("unexpected expiry %q (%v)", sec.Expires, err)
+	}
+
+	c = c.WithURL(strings.Replace(s.URL, "https:", "http:", 1))
+	err = c.RoundTripWithAction("Echo", &msgT{A: "hello"}, &envT{})
+	if err == nil || !strings.Contains(err.Error(), "requires TLS") {
+		t.Fatalf("want TLS error, have %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package wsse
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+)
+
+// SAML namespaces and token profile URIs.
+const (
+	SAML11Namespace = "urn:oasis:names:tc:SAML:1.0:assertion"
+	SAML20Namespace = "urn:oasis:names:tc:SAML:2.0:assertion"
+
+	Secext11Namespace = "http://docs.oasis-open.org/wss/oasis-wss-wssecurity-secext-1.1.xsd"
+
+	SAML11TokenType = "http://docs.oasis-open.org/wss/oasis-wss-saml-token-profile-1.1#SAMLV1.1"
+	SAML20TokenType = "http://docs.oasis-open.org/wss/oasis-wss-saml-token-profile-1.1#SAMLV2.0"
+
+	SAML11AssertionIDType = "http://docs.oasis-open.org/wss/oasis-wss-saml-token-profile-1.0#SAMLAssertionID"
+	SAML20AssertionIDType = "http://docs.oasis-open.org/wss/oasis-wss-saml-token-profile-1.1#SAMLID"
+)
+
+// SAMLAssertion is a signed SAML 1.1 or 2.0 assertion, kept verbatim so
+// its signature remains valid.
+type SAMLAssertion struct {
+	Namespace string // SAML11Namespace or SAML20Namespace
+	ID        string // AssertionID (1.1) or ID (2.0)
+	Raw       []byte
+}
+
+// ParseSAMLAssertion inspects the root element of raw to determine the
+// SAML version and assertion ID. The assertion must declare its own
+// namespace, since it is embedded in the header as is.
+func ParseSAMLAssertion(raw []byte) (*SAMLAssertion, error) {
+	raw = bytes.TrimSpace(raw)
+	d := xml.NewDecoder(bytes.NewReader(raw))
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return nil, fmt.Errorf("wsse: invalid SAML assertion: %v", err)
+		}
+		start, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local != "Assertion" {
+			return nil, fmt.Errorf("wsse: unexpected SAML element %q", start.Name.Local)
+		}
+		a := &SAMLAssertion{Namespace: start.Name.Space, Raw: raw}
+		idAttr := "ID"
+		switch a.Namespace {
+		case SAML11Namespace:
+			idAttr = "AssertionID"
+		case SAML20Namespace:
+		default:
+			return nil, fmt.Errorf("wsse: SAML assertion has unknown namespace %q", a.Namespace)
+		}
+		for _, attr := range start.Attr {
+			if attr.Name.Space == "" && attr.Name.Local == idAttr {
+				a.ID = attr.Value
+			}
+		}
+		if a.ID == "" {
+			return nil, errors.New("wsse: SAML assertion has no ID")
+		}
+		return a, nil
+	}
+}
+
+// Reference returns a SecurityTokenReference to the assertion, as used
+// by the holder-of-key confirmation method to point signatures at it.
+func (a *SAMLAssertion) Reference() *SecurityTokenReference {
+	ref := &SecurityTokenReference{
+		NS11:      Secext11Namespace,
+		TokenType: SAML20TokenType,
+		KeyIdentifier: &KeyIdentifier{
+			ValueType: SAML20AssertionIDType,
+			Value:     a.ID,
+		},
+	}
+	if a.Namespace == SAML11Namespace {
+		ref.TokenType = SAML11TokenType
+		ref.KeyIdentifier.ValueType = SAML11AssertionIDType
+	}
+	return ref
+}
+
+// NewSAMLSecurity returns a Security header carrying the assertion. When
+// holderOfKey is set, a SecurityTokenReference to the assertion is added
+// with the wsu:Id "STR-<assertion id>".
+func NewSAMLSecurity(a *SAMLAssertion, holderOfKey bool) *Security {
+	sec := NewSecurity()
+	sec.Tokens = a.Raw
+	if holderOfKey {
+		sec.TokenReference = a.Reference()
+		sec.TokenReference.ID = "STR-" + a.ID
+	}
+	return sec
+}
+
+// SecurityTokenReference is the wsse:SecurityTokenReference element.
+type SecurityTokenReference struct {
+	ID            string         `xml:"wsu:Id,attr,omitempty"`
+	NS11          string         `xml:"xmlns:wsse11,attr,omitempty"`
+	TokenType     string         `xml:"wsse11:TokenType,attr,omitempty"`
+	KeyIdentifier *KeyIdentifier `xml:"wsse:KeyIdentifier,omitempty"`
+}
+
+// KeyIdentifier is the wsse:KeyIdentifier element.
+type KeyIdentifier struct {
+	ValueType    string `xml:"ValueType,attr"`
+	EncodingType string `xml:"EncodingType,attr,omitempty"`
+	Value        string `xml:",chardata"`
+}
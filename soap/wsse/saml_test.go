@@ -0,0 +1,74 @@
+package wsse
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestParseSAMLAssertion(t *testing.T) {
+	cases := []struct {
+		Raw  string
+		NS   string
+		ID   string
+		Fail bool
+	}{
+		{
+			Raw: `<saml2:Assertion xmlns:saml2="urn:oasis:names:tc:SAML:2.0:assertion" ID="_a2" Version="2.0"/>`,
+			NS:  SAML20Namespace,
+			ID:  "_a2",
+		},
+		{
+			Raw: `<saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:1.0:assertion" AssertionID="_a1" MajorVersion="1"/>`,
+			NS:  SAML11Namespace,
+			ID:  "_a1",
+		},
+		{Raw: `<saml:Assertion ID="_x"/>`, Fail: true},
+		{Raw: `<saml2:Assertion xmlns:saml2="urn:oasis:names:tc:SAML:2.0:assertion"/>`, Fail: true},
+		{Raw: `<foo/>`, Fail: true},
+	}
+	for i, tc := range cases {
+		a, err := ParseSAMLAssertion([]byte(tc.Raw))
+		if tc.Fail {
+			if err == nil {
+				t.Errorf("test %d: expected error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if a.Namespace != tc.NS || a.ID != tc.ID {
+			t.Errorf("test %d: want %q %q, have %q %q", i, tc.NS, tc.ID, a.Namespace, a.ID)
+		}
+	}
+}
+
+func TestNewSAMLSecurity(t *testing.T) {
+	raw := `<saml2:Assertion xmlns:saml2="urn:oasis:names:tc:SAML:2.0:assertion" ID="_a2"></saml2:Assertion>`
+	a, err := ParseSAMLAssertion([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := xml.Marshal(NewSAMLSecurity(a, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	have := string(b)
+	for _, want := range []string{
+		`xmlns:wsse="` + Namespace + `"`,
+		raw,
+		`<wsse:SecurityTokenReference wsu:Id="STR-_a2"`,
+		`wsse11:TokenType="` + SAML20TokenType + `"`,
+		`<wsse:KeyIdentifier ValueType="` + SAML20AssertionIDType + `">_a2</wsse:KeyIdentifier>`,
+	} {
+		if !strings.Contains(have, want) {
+			t.Errorf("missing %q in:\n%s", want, have)
+		}
+	}
+	b, _ = xml.Marshal(NewSAMLSecurity(a, false))
+	if strings.Contains(string(b), "SecurityTokenReference") {
+		t.Errorf("unexpected token reference:\n%s", b)
+	}
+}
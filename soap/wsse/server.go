@@ -0,0 +1,244 @@
+package wsse
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/YapealAG/wsdl2go/soap"
+)
+
+// PasswordDigest is the type of UsernameToken passwords sent as the
+// Base64 of SHA-1(nonce + created + password).
+const PasswordDigest = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordDigest"
+
+// WS-Security fault codes, in the wsse namespace, sent by servers.
+const (
+	FaultUnsupportedSecurityToken = "wsse:UnsupportedSecurityToken"
+	FaultInvalidSecurity          = "wsse:InvalidSecurity"
+	FaultInvalidSecurityToken     = "wsse:InvalidSecurityToken"
+	FaultFailedAuthentication     = "wsse:FailedAuthentication"
+	FaultMessageExpired           = "wsse:MessageExpired"
+)
+
+// RequestValidator validates the UsernameToken and Timestamp in the
+// Security header of the requests of a soap.Server:
+//
+//	v := &wsse.RequestValidator{
+//		Password:   lookupPassword,
+//		Timestamps: &wsse.Timestamps{Required: true},
+//	}
+//	srv.Use(v.Middleware())
+//
+// Requests are rejected with the WS-Security fault of the failure,
+// which doesn't tell unknown users from wrong passwords; the username
+// of accepted requests is available to handlers with
+// UsernameFromContext. RequestValidator is safe for concurrent use.
+type RequestValidator struct {
+	// Password returns the password of username, verifying the text
+	// and digest passwords of UsernameTokens.
+	Password func(ctx context.Context, username string) (string, error)
+
+	// VerifyPassword verifies the text password of username instead of
+	// Password, for passwords stored as hashes or checked by a
+	// directory. Digest passwords need Password.
+	VerifyPassword func(ctx context.Context, username, password string) error
+
+	// Timestamps validates the wsu:Timestamp of requests, if set. Its
+	// TTL is not used.
+	Timestamps *Timestamps
+
+	// MaxSkew is the tolerated age of digest passwords, and clock
+	// difference to clients (default DefaultMaxSkew).
+	MaxSkew time.Duration
+
+	mu     sync.Mutex
+	nonces map[string]time.Time // nonces of digest passwords, to when they expire
+	now    func() time.Time
+}
+
+type usernameKey struct{}
+
+// UsernameFromContext returns the username of the UsernameToken
+// validated by a RequestValidator, or "".
+func UsernameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(usernameKey{}).(string)
+	return name
+}
+
+// Middleware returns the soap.ServerMiddleware validating requests.
+// Without Password or VerifyPassword, requests need no UsernameToken.
+func (v *RequestValidator) Middleware() soap.ServerMiddleware {
+	return func(next soap.OperationHandler) soap.OperationHandler {
+		return func(ctx context.Context, r *soap.Request) (soap.Message, error) {
+			if v.Timestamps != nil {
+				if err := v.Timestamps.Validate(r.Envelope); err != nil {
+					var terr *TimestampError
+					if errors.Is(err, ErrTimestampExpired) {
+						return nil, securityFault(r, FaultMessageExpired, "the message has expired")
+					}
+					if errors.As(err, &terr) {
+						return nil, securityFault(r, FaultInvalidSecurity, "invalid timestamp: "+terr.Reason)
+					}
+					return nil, securityFault(r, FaultInvalidSecurity, "malformed security header")
+				}
+			}
+			if v.Password == nil && v.VerifyPassword == nil {
+				return next(ctx, r)
+			}
+			username, err := v.authenticate(ctx, r)
+			if err != nil {
+				return nil, err
+			}
+			return next(context.WithValue(ctx, usernameKey{}, username), r)
+		}
+	}
+}
+
+// requestToken is the UsernameToken of a request.
+type requestToken struct {
+	Username string `xml:"Username"`
+	Password struct {
+		Type  string `xml:"Type,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"Password"`
+	Nonce   string `xml:"Nonce"`
+	Created string `xml:"Created"`
+}
+
+// authenticate returns the username of the UsernameToken of r, or the
+// fault rejecting it.
+func (v *RequestValidator) authenticate(ctx context.Context, r *soap.Request) (string, error) {
+	var hdr struct {
+		Security *struct {
+			UsernameToken *requestToken `xml:"UsernameToken"`
+		} `xml:"Security"`
+	}
+	if err := r.DecodeHeader(&hdr); err != nil {
+		return "", securityFault(r, FaultInvalidSecurity, "malformed security header")
+	}
+	if hdr.Security == nil || hdr.Security.UsernameToken == nil {
+		return "", securityFault(r, FaultInvalidSecurityToken, "missing UsernameToken")
+	}
+	t := hdr.Security.UsernameToken
+	failed := securityFault(r, FaultFailedAuthentication, "the security token could not be authenticated")
+	switch t.Password.Type {
+	case "", PasswordText:
+		if v.VerifyPassword != nil {
+			if v.VerifyPassword(ctx, t.Username, t.Password.Value) != nil {
+				return "", failed
+			}
+			return t.Username, nil
+		}
+		want, err := v.Password(ctx, t.Username)
+		if err != nil || subtle.ConstantTimeCompare([]byte(want), []byte(t.Password.Value)) != 1 {
+			return "", failed
+		}
+		return t.Username, nil
+	case PasswordDigest:
+		if v.Password == nil {
+			return "", securityFault(r, FaultUnsupportedSecurityToken, "digest passwords are not supported")
+		}
+		created, err := v.checkCreated(t)
+		if err != nil {
+			return "", securityFault(r, FaultInvalidSecurityToken, err.Error())
+		}
+		nonce, err := base64.StdEncoding.DecodeString(t.Nonce)
+		if err != nil {
+			return "", securityFault(r, FaultInvalidSecurityToken, "malformed nonce")
+		}
+		want, err := v.Password(ctx, t.Username)
+		if err != nil {
+			return "", failed
+		}
+		if subtle.ConstantTimeCompare([]byte(passwordDigest(nonce, t.Created, want)), []byte(t.Password.Value)) != 1 {
+			return "", failed
+		}
+		// Nonces are only recorded for authenticated tokens, so that
+		// forged ones cannot fill the record or spend those of others.
+		if err := v.useNonce(t.Nonce, created); err != nil {
+			return "", securityFault(r, FaultInvalidSecurityToken, err.Error())
+		}
+		return t.Username, nil
+	default:
+		return "", securityFault(r, FaultUnsupportedSecurityToken, "unsupported password type")
+	}
+}
+
+// passwordDigest returns the digest of password with nonce and created.
+func passwordDigest(nonce []byte, created, password string) string {
+	h := sha1.New()
+	h.Write(nonce)
+	h.Write([]byte(created))
+	h.Write([]byte(password))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// checkCreated returns the creation time of the digest token t, or an
+// error if it has no nonce, or was created longer than MaxSkew ago or in
+// the future.
+func (v *RequestValidator) checkCreated(t *requestToken) (time.Time, error) {
+	if t.Nonce == "" || t.Created == "" {
+		return time.Time{}, errors.New("missing nonce or creation time")
+	}
+	created, err := time.Parse(time.RFC3339Nano, t.Created)
+	if err != nil {
+		return time.Time{}, errors.New("malformed creation time")
+	}
+	skew := v.maxSkew()
+	now := v.clock()
+	if created.Before(now.Add(-skew)) || created.After(now.Add(skew)) {
+		return time.Time{}, errors.New("creation time out of range")
+	}
+	return created, nil
+}
+
+// useNonce records the nonce of a digest token created at created, or
+// rejects it if it was seen before.
+func (v *RequestValidator) useNonce(nonce string, created time.Time) error {
+	skew := v.maxSkew()
+	now := v.clock()
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for n, exp := range v.nonces {
+		if now.After(exp) {
+			delete(v.nonces, n)
+		}
+	}
+	if _, ok := v.nonces[nonce]; ok {
+		return errors.New("replayed nonce")
+	}
+	if v.nonces == nil {
+		v.nonces = make(map[string]time.Time)
+	}
+	v.nonces[nonce] = created.Add(2 * skew)
+	return nil
+}
+
+func (v *RequestValidator) maxSkew() time.Duration {
+	if v.MaxSkew <= 0 {
+		return DefaultMaxSkew
+	}
+	return v.MaxSkew
+}
+
+func (v *RequestValidator) clock() time.Time {
+	if v.now != nil {
+		return v.now()
+	}
+	return time.Now()
+}
+
+// securityFault returns the WS-Security fault code for requests of r,
+// as a Sender subcode for SOAP 1.2.
+func securityFault(r *soap.Request, code, reason string) *soap.Fault {
+	f := &soap.Fault{Code: code, String: reason, CodeNamespace: Namespace}
+	if r.Version == soap.SOAP12 {
+		f.Code, f.Subcode = "soapenv:Sender", code
+	}
+	return f
+}
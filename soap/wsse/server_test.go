@@ -0,0 +1,92 @@
+package wsse
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/YapealAG/wsdl2go/soap"
+)
+
+type whoami struct {
+	Name string `xml:"name"`
+}
+
+func TestRequestValidator(t *testing.T) {
+	now := time.Now()
+	v := &RequestValidator{
+		Password: func(ctx context.Context, username string) (string, error) {
+			if username != "alice" {
+				return "", errors.New("unknown user")
+			}
+			return "secret", nil
+		},
+		Timestamps: &Timestamps{now: func() time.Time { return now }},
+		now:        func() time.Time { return now },
+	}
+	srv := soap.NewServer()
+	srv.HandleFunc("WhoAmI", "", xml.Name{Local: "whoami"}, func(ctx context.Context, r *soap.Request) (soap.Message, error) {
+		return &whoami{Name: UsernameFromContext(ctx)}, nil
+	})
+	srv.Use(v.Middleware())
+	s := httptest.NewServer(srv)
+	defer s.Close()
+
+	digest := NewUsernameTokenDigest("alice", "secret")
+	forged := NewUsernameTokenDigest("alice", "guess")
+	nonce, _ := base64.StdEncoding.DecodeString(forged.Nonce.Value)
+	retried := *forged
+	retried.Password.Value = passwordDigest(nonce, forged.Created, "secret")
+	stale := NewSecurity()
+	stale.UsernameToken = NewUsernameToken("alice", "secret")
+	stale.Timestamp = newTimestamp(now.Add(-time.Hour), time.Minute)
+	cases := []struct {
+		Name    string
+		Version soap.Version
+		Token   *UsernameToken
+		Header  *Security
+		Code    string
+		Subcode string
+	}{
+		{Name: "text", Token: NewUsernameToken("alice", "secret")},
+		{Name: "digest", Token: digest},
+		{Name: "replayed digest", Token: digest, Code: FaultInvalidSecurityToken},
+		{Name: "wrong password", Token: NewUsernameToken("alice", "guess"), Code: FaultFailedAuthentication},
+		{Name: "wrong digest", Token: forged, Code: FaultFailedAuthentication},
+		{Name: "digest of the nonce of a wrong one", Token: &retried},
+		{Name: "unknown user", Token: NewUsernameTokenDigest("bob", "secret"), Code: FaultFailedAuthentication},
+		{Name: "soap12", Version: soap.SOAP12, Token: NewUsernameToken("alice", "guess"), Code: "soapenv:Sender", Subcode: FaultFailedAuthentication},
+		{Name: "missing token", Code: FaultInvalidSecurityToken},
+		{Name: "expired", Header: stale, Code: FaultMessageExpired},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			hdr := tc.Header
+			if hdr == nil {
+				hdr = NewSecurity()
+				hdr.UsernameToken = tc.Token
+			}
+			c := soap.NewClient(s.URL, soap.WithSOAPHeader(&policyHeader{Security: hdr}), soap.WithVersion(tc.Version))
+			var out struct {
+				Msg whoami `xml:"whoami"`
+			}
+			err := c.RoundTrip(&struct {
+				M whoami `xml:"whoami"`
+			}{}, &out)
+			if tc.Code == "" {
+				if err != nil || out.Msg.Name != "alice" {
+					t.Fatalf("unexpected response %+v, %v", out, err)
+				}
+				return
+			}
+			var fault *soap.Fault
+			if !errors.As(err, &fault) || fault.Code != tc.Code || fault.Subcode != tc.Subcode {
+				t.Fatalf("want fault %s %s, have %v (%+v)", tc.Code, tc.Subcode, err, fault)
+			}
+		})
+	}
+}
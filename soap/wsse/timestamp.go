@@ -0,0 +1,224 @@
+package wsse
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/YapealAG/wsdl2go/soap"
+)
+
+// Timestamp is the wsu:Timestamp security element, stating when the
+// message was created and when it expires.
+type Timestamp struct {
+	ID      string `xml:"wsu:Id,attr,omitempty"`
+	Created string `xml:"wsu:Created"`
+	Expires string `xml:"wsu:Expires,omitempty"`
+}
+
+// timestampFormat is the UTC xs:dateTime format of timestamps.
+const timestampFormat = "2006-01-02T15:04:05.000Z"
+
+// NewTimestamp returns a Timestamp created now that expires after ttl,
+// or never if ttl is 0.
+func NewTimestamp(ttl time.Duration) *Timestamp {
+	return newTimestamp(time.Now(), ttl)
+}
+
+func newTimestamp(now time.Time, ttl time.Duration) *Timestamp {
+	now = now.UTC()
+	ts := &Timestamp{Created: now.Format(timestampFormat)}
+	if ttl > 0 {
+		ts.Expires = now.Add(ttl).Format(timestampFormat)
+	}
+	return ts
+}
+
+// DefaultMaxSkew is the clock difference to servers tolerated by
+// Timestamps by default.
+const DefaultMaxSkew = 5 * time.Minute
+
+// The kinds of TimestampError, matched with errors.Is.
+var (
+	ErrTimestampMissing   = errors.New("wsse: missing timestamp")
+	ErrTimestampMalformed = errors.New("wsse: malformed timestamp")
+	ErrTimestampFuture    = errors.New("wsse: timestamp from the future")
+	ErrTimestampExpired   = errors.New("wsse: expired timestamp")
+	ErrTimestampReplayed  = errors.New("wsse: replayed timestamp")
+)
+
+// TimestampError is returned for responses whose timestamp is missing,
+// malformed, stale, from the future or replayed.
+type TimestampError struct {
+	Created string
+	Expires string
+	Reason  string
+	Kind    error // one of the ErrTimestamp errors
+}
+
+func (e *TimestampError) Error() string {
+	return "wsse: invalid response timestamp: " + e.Reason
+}
+
+// Unwrap returns the kind of e.
+func (e *TimestampError) Unwrap() error {
+	return e.Kind
+}
+
+// Timestamps adds a wsu:Timestamp to the Security header of requests
+// and validates the timestamps of responses:
+//
+//	ts := &wsse.Timestamps{TTL: time.Minute, Required: true, DetectReplay: true}
+//	cli.Middleware = append(cli.Middleware, ts.Middleware())
+//
+// Responses are rejected with a *TimestampError if they were created
+// after now, expired before now or, with MaxAge, created longer ago;
+// each bound allows for MaxSkew of clock differences. Faults are not
+// validated. Timestamps is safe for concurrent use.
+type Timestamps struct {
+	TTL     time.Duration // Lifetime of request timestamps (default PolicyTimestampTTL, negative for none)
+	MaxSkew time.Duration // Tolerated clock difference (default DefaultMaxSkew, negative for none)
+	MaxAge  time.Duration // Maximum age of response timestamps, or 0 for no limit
+
+	// Required rejects responses without a timestamp.
+	Required bool
+
+	// DetectReplay rejects responses that repeat a timestamp received
+	// before. Timestamps are remembered until they expire, so responses
+	// must have an Expires element, or MaxAge must be set.
+	DetectReplay bool
+
+	mu   sync.Mutex
+	seen map[Timestamp]time.Time // Timestamps received, to when they expire
+	now  func() time.Time
+}
+
+// Middleware returns the soap.Middleware that adds and validates
+// timestamps. If the SOAP header of the client is a *Security header,
+// the timestamp is added to a copy of it; other headers are sent along
+// after a new Security header.
+func (ts *Timestamps) Middleware() soap.Middleware {
+	return func(next soap.RoundTripFunc) soap.RoundTripFunc {
+		return func(ctx context.Context, call *soap.Call) error {
+			hdr := securityHeader(call.Header)
+			if hdr.Security == nil {
+				hdr.Security = NewSecurity()
+			}
+			hdr.Security.UtilityNS = UtilityNamespace
+			ttl := ts.TTL
+			if ttl == 0 {
+				ttl = PolicyTimestampTTL
+			}
+			hdr.Security.Timestamp = newTimestamp(ts.clock(), ttl)
+			call.Header = hdr
+			call.Capture = true
+			if err := next(ctx, call); err != nil {
+				return err
+			}
+			if len(call.ResponseEnvelope) == 0 {
+				return nil
+			}
+			return ts.Validate(call.ResponseEnvelope)
+		}
+	}
+}
+
+// Validate checks the wsu:Timestamp in the Security header of the
+// envelope env.
+func (ts *Timestamps) Validate(env []byte) error {
+	var v struct {
+		Header struct {
+			Security struct {
+				Timestamp *struct {
+					ID      string `xml:"Id,attr"`
+					Created string `xml:"Created"`
+					Expires string `xml:"Expires"`
+				} `xml:"Timestamp"`
+			} `xml:"Security"`
+		} `xml:"Header"`
+	}
+	if err := xml.Unmarshal(env, &v); err != nil {
+		return err
+	}
+	t := v.Header.Security.Timestamp
+	if t == nil {
+		if ts.Required {
+			return &TimestampError{Reason: "missing", Kind: ErrTimestampMissing}
+		}
+		return nil
+	}
+	return ts.check(Timestamp{ID: t.ID, Created: t.Created, Expires: t.Expires})
+}
+
+func (ts *Timestamps) check(t Timestamp) error {
+	fail := func(kind error, format string, args ...interface{}) error {
+		return &TimestampError{Created: t.Created, Expires: t.Expires, Reason: fmt.Sprintf(format, args...), Kind: kind}
+	}
+	now := ts.clock()
+	skew := ts.MaxSkew
+	if skew == 0 {
+		skew = DefaultMaxSkew
+	} else if skew < 0 {
+		skew = 0
+	}
+	created, err := time.Parse(time.RFC3339Nano, t.Created)
+	if err != nil {
+		return fail(ErrTimestampMalformed, "malformed Created %q", t.Created)
+	}
+	if created.After(now.Add(skew)) {
+		return fail(ErrTimestampFuture, "created %v in the future", created.Sub(now).Round(time.Millisecond))
+	}
+	var until time.Time
+	if ts.MaxAge > 0 {
+		until = created.Add(ts.MaxAge)
+	}
+	if t.Expires != "" {
+		expires, err := time.Parse(time.RFC3339Nano, t.Expires)
+		if err != nil {
+			return fail(ErrTimestampMalformed, "malformed Expires %q", t.Expires)
+		}
+		if expires.Before(created) {
+			return fail(ErrTimestampMalformed, "expires before it was created")
+		}
+		if until.IsZero() || expires.Before(until) {
+			until = expires
+		}
+	}
+	if !until.IsZero() {
+		until = until.Add(skew)
+		if now.After(until) {
+			return fail(ErrTimestampExpired, "stale by %v", now.Sub(until).Round(time.Millisecond))
+		}
+	}
+	if !ts.DetectReplay {
+		return nil
+	}
+	if until.IsZero() {
+		return fail(ErrTimestampMalformed, "no expiry to detect replays")
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for k, exp := range ts.seen {
+		if now.After(exp) {
+			delete(ts.seen, k)
+		}
+	}
+	if _, ok := ts.seen[t]; ok {
+		return fail(ErrTimestampReplayed, "replayed")
+	}
+	if ts.seen == nil {
+		ts.seen = make(map[Timestamp]time.Time)
+	}
+	ts.seen[t] = until
+	return nil
+}
+
+func (ts *Timestamps) clock() time.Time {
+	if ts.now != nil {
+		return ts.now()
+	}
+	return time.Now()
+}
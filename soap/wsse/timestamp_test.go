@@ -0,0 +1,142 @@
+package wsse
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YapealAG/wsdl2go/soap"
+)
+
+func TestTimestampsValidate(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) string { return now.Add(d).Format(timestampFormat) }
+	env := func(created, expires string) string {
+		ts := `<wsu:Created>` + created + `</wsu:Created>`
+		if expires != "" {
+			ts += `<wsu:Expires>` + expires + `</wsu:Expires>`
+		}
+		return `<s:Envelope xmlns:s="urn:s" xmlns:wsu="urn:wsu"><s:Header><Security><wsu:Timestamp wsu:Id="TS-1">` +
+			ts + `</wsu:Timestamp></Security></s:Header><s:Body/></s:Envelope>`
+	}
+	cases := []struct {
+		ts   *Timestamps
+		env  string
+		want string
+		kind error
+	}{
+		{&Timestamps{}, env(at(-time.Minute), at(4*time.Minute)), "", nil},
+		{&Timestamps{}, `<Envelope><Body/></Envelope>`, "", nil},
+		{&Timestamps{Required: true}, `<Envelope><Body/></Envelope>`, "missing", ErrTimestampMissing},
+		{&Timestamps{}, env("yesterday", ""), "malformed Created", ErrTimestampMalformed},
+		{&Timestamps{}, env(at(0), "soon"), "malformed Expires", ErrTimestampMalformed},
+		{&Timestamps{}, env(at(0), at(-time.Second)), "expires before", ErrTimestampMalformed},
+		{&Timestamps{}, env(at(10*time.Minute), ""), "in the future", ErrTimestampFuture},
+		{&Timestamps{}, env(at(4*time.Minute), ""), "", nil},
+		{&Timestamps{MaxSkew: -1}, env(at(time.Second), ""), "in the future", ErrTimestampFuture},
+		{&Timestamps{}, env(at(-time.Hour), at(-10*time.Minute)), "stale by 5m0s", ErrTimestampExpired},
+		{&Timestamps{}, env(at(-time.Hour), at(-4*time.Minute)), "", nil},
+		{&Timestamps{MaxAge: time.Minute}, env(at(-time.Hour), ""), "stale by 54m0s", ErrTimestampExpired},
+		{&Timestamps{MaxAge: time.Minute}, env(at(-time.Hour), at(time.Hour)), "stale", ErrTimestampExpired},
+		{&Timestamps{DetectReplay: true}, env(at(0), ""), "no expiry", ErrTimestampMalformed},
+	}
+	for i, tc := range cases {
+		tc.ts.now = func() time.Time { return now }
+		err := tc.ts.Validate([]byte(tc.env))
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("test %d: unexpected error: %v", i, err)
+			}
+			continue
+		}
+		var te *TimestampError
+		if !errors.As(err, &te) || !strings.Contains(te.Reason, tc.want) || !errors.Is(err, tc.kind) {
+			t.Errorf("test %d: want error %q, have %v", i, tc.want, err)
+		}
+	}
+}
+
+func TestTimestampsReplay(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ts := &Timestamps{DetectReplay: true, now: func() time.Time { return now }}
+	env := func(created time.Time) []byte {
+		return []byte(fmt.Sprintf(`<Envelope><Header><Security><Timestamp><Created>%s</Created><Expires>%s</Expires></Timestamp></Security></Header></Envelope>`,
+			created.Format(timestampFormat), created.Add(time.Minute).Format(timestampFormat)))
+	}
+	first := env(now)
+	if err := ts.Validate(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.Validate(env(now.Add(time.Second))); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.Validate(first); err == nil || !strings.Contains(err.Error(), "replayed") {
+		t.Fatalf("want replay error, have %v", err)
+	}
+	now = now.Add(time.Hour)
+	ts.Validate(env(now))
+	if len(ts.seen) != 1 {
+		t.Fatalf("expired timestamps were not forgotten: %v", ts.seen)
+	}
+}
+
+func TestTimestampsMiddleware(t *testing.T) {
+	type msgT struct{ A string }
+	type envT struct {
+		Msg msgT `xml:"msgT"`
+	}
+	var sent struct {
+		Username string `xml:"Header>Security>UsernameToken>Username"`
+		Created  string `xml:"Header>Security>Timestamp>Created"`
+		Expires  string `xml:"Header>Security>Timestamp>Expires"`
+	}
+	var reply string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if err := xml.Unmarshal(b, &sent); err != nil {
+			t.Error(err)
+		}
+		io.WriteString(w, reply)
+	}))
+	defer s.Close()
+
+	ts := &Timestamps{TTL: time.Minute, Required: true}
+	sec := NewSecurity()
+	sec.UsernameToken = NewUsernameToken("user", "secret")
+	c := soap.NewClient(s.URL, soap.WithSOAPHeader(sec))
+	c.Middleware = append(c.Middleware, ts.Middleware())
+
+	now := time.Now().UTC()
+	reply = `<Envelope><Header><Security><Timestamp><Created>` + now.Format(timestampFormat) +
+		`</Created></Timestamp></Security></Header><Body><msgT><A>ok</A></msgT></Body></Envelope>`
+	var out envT
+	if err := c.RoundTrip(&msgT{A: "hello"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Msg.A != "ok" || sent.Username != "user" {
+		t.Errorf("unexpected response %+v or request %+v", out, sent)
+	}
+	created, err := time.Parse(time.RFC3339, sent.Created)
+	if err != nil || time.Since(created) > time.Minute {
+		t.Errorf("unexpected timestamp %q (%v)", sent.Created, err)
+	}
+	expires, err := time.Parse(time.RFC3339, sent.Expires)
+	if err != nil || expires.Sub(created) != time.Minute {
+		t.Errorf("unexpected expiry %q (%v)", sent.Expires, err)
+	}
+	if sec.Timestamp != nil {
+		t.Error("client header was modified")
+	}
+
+	reply = `<Envelope><Body><msgT><A>ok</A></msgT></Body></Envelope>`
+	var te *TimestampError
+	if err := c.RoundTrip(&msgT{A: "hello"}, &out); !errors.As(err, &te) {
+		t.Fatalf("want timestamp error, have %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+// Package wstrust implements a WS-Trust 1.3 client that acquires
+// security tokens (SAML assertions, security context tokens) from a
+// Security Token Service, such as ADFS.
+//
+// http://docs.oasis-open.org/ws-sx/ws-trust/v1.4/ws-trust.html
+package wstrust
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/YapealAG/wsdl2go/soap"
+	"github.com/YapealAG/wsdl2go/soap/wsse"
+)
+
+// WS-Trust and related namespaces and URIs.
+const (
+	Namespace           = "http://docs.oasis-open.org/ws-sx/ws-trust/200512"
+	AddressingNamespace = "http://www.w3.org/2005/08/addressing"
+	PolicyNamespace     = "http://schemas.xmlsoap.org/ws/2004/09/policy"
+
+	IssueAction = Namespace + "/RST/Issue"
+	IssueType   = Namespace + "/Issue"
+
+	BearerKey    = Namespace + "/Bearer"
+	SymmetricKey = Namespace + "/SymmetricKey"
+
+	SAML11TokenType = "http://docs.oasis-open.org/wss/oasis-wss-saml-token-profile-1.1#SAMLV1.1"
+	SAML20TokenType = "http://docs.oasis-open.org/wss/oasis-wss-saml-token-profile-1.1#SAMLV2.0"
+	SCTTokenType    = "http://docs.oasis-open.org/ws-sx/ws-secureconversation/200512/sct"
+)
+
+// Client requests tokens from a Security Token Service.
+type Client struct {
+	// SOAP is the client configured for the STS endpoint. Its URL and
+	// transport settings are used; the SOAP header is replaced for the
+	// issue request.
+	SOAP *soap.Client
+
+	// Username and Password, when set, are sent as a wsse:UsernameToken.
+	Username string
+	Password string
+
+	AppliesTo string // Relying party the token is requested for
+	TokenType string // Requested token type (default SAML20TokenType)
+	KeyType   string // Requested key type (default BearerKey)
+}
+
+// Token is a security token issued by an STS.
+type Token struct {
+	Type    string
+	Created time.Time
+	Expires time.Time
+
+	// Raw is the issued token XML, e.g. a saml:Assertion element.
+	Raw []byte
+}
+
+// Expired reports whether the token is expired at time t, allowing skew
+// for clock differences between the client and the STS.
+func (t *Token) Expired(now time.Time, skew time.Duration) bool {
+	return !t.Expires.IsZero() && !now.Add(skew).Before(t.Expires)
+}
+
+// Header returns a wsse:Security SOAP header that carries the token, to
+// be used as the soap.Client Header of requests to the relying party.
+func (t *Token) Header() *wsse.Security {
+	sec := wsse.NewSecurity()
+	sec.Tokens = t.Raw
+	return sec
+}
+
+type attributedURI struct {
+	NS    string `xml:"xmlns:wsa,attr"`
+	Value string `xml:",chardata"`
+}
+
+type issueHeader struct {
+	Action   attributedURI  `xml:"wsa:Action"`
+	To       attributedURI  `xml:"wsa:To"`
+	Security *wsse.Security `xml:",omitempty"`
+}
+
+type appliesTo struct {
+	NS      string `xml:"xmlns:wsp,attr"`
+	Address struct {
+		NS    string `xml:"xmlns:wsa,attr"`
+		Value string `xml:"wsa:Address"`
+	} `xml:"wsa:EndpointReference"`
+}
+
+type requestSecurityToken struct {
+	XMLName     xml.Name   `xml:"wst:RequestSecurityToken"`
+	NS          string     `xml:"xmlns:wst,attr"`
+	AppliesTo   *appliesTo `xml:"wsp:AppliesTo,omitempty"`
+	KeyType     string     `xml:"wst:KeyType"`
+	RequestType string     `xml:"wst:RequestType"`
+	TokenType   string     `xml:"wst:TokenType"`
+}
+
+type requestSecurityTokenResponse struct {
+	TokenType string `xml:"TokenType"`
+	Lifetime  struct {
+		Created string `xml:"Created"`
+		Expires string `xml:"Expires"`
+	} `xml:"Lifetime"`
+	RequestedSecurityToken struct {
+		Token []byte `xml:",innerxml"`
+	} `xml:"RequestedSecurityToken"`
+}
+
+// issueResponse accepts both the WS-Trust 1.3 collection and a bare RSTR.
+type issueResponse struct {
+	Collection *struct {
+		Responses []*requestSecurityTokenResponse `xml:"RequestSecurityTokenResponse"`
+	} `xml:"RequestSecurityTokenResponseCollection"`
+	Response *requestSecurityTokenResponse `xml:"RequestSecurityTokenResponse"`
+}
+
+// Issue requests a new token from the STS.
+func (c *Client) Issue() (*Token, error) {
+	if c.SOAP == nil {
+		return nil, errors.New("wstrust: missing SOAP client")
+	}
+	req := &requestSecurityToken{
+		NS:          Namespace,
+		KeyType:     c.KeyType,
+		RequestType: IssueType,
+		TokenType:   c.TokenType,
+	}
+	if req.KeyType == "" {
+		req.KeyType = BearerKey
+	}
+	if req.TokenType == "" {
+		req.TokenType = SAML20TokenType
+	}
+	if c.AppliesTo != "" {
+		req.AppliesTo = &appliesTo{NS: PolicyNamespace}
+		req.AppliesTo.Address.NS = AddressingNamespace
+		req.AppliesTo.Address.Value = c.AppliesTo
+	}
+
+	hdr := &issueHeader{
+		Action: attributedURI{NS: AddressingNamespace, Value: IssueAction},
+		To:     attributedURI{NS: AddressingNamespace, Value: c.SOAP.URL},
+	}
+	if c.Username != "" {
+		hdr.Security = wsse.NewSecurity()
+		hdr.Security.UsernameToken = wsse.NewUsernameToken(c.Username, c.Password)
+	}
+
+	cli := *c.SOAP
+	cli.Header = hdr
+	cli.ExcludeActionNamespace = true
+	var resp issueResponse
+	var err error
+	if cli.Envelope == soap.EnvelopeNamespace12 && cli.Version != soap.SOAP12 {
+		err = cli.RoundTripSoap12(IssueAction, req, &resp)
+	} else {
+		err = cli.RoundTripWithAction(IssueAction, req, &resp)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rstr := resp.Response
+	if resp.Collection != nil && len(resp.Collection.Responses) > 0 {
+		rstr = resp.Collection.Responses[0]
+	}
+	if rstr == nil {
+		return nil, errors.New("wstrust: response has no RequestSecurityTokenResponse")
+	}
+	tok := &Token{
+		Type: rstr.TokenType,
+		Raw:  bytes.TrimSpace(rstr.RequestedSecurityToken.Token),
+	}
+	if len(tok.Raw) == 0 {
+		return nil, errors.New("wstrust: response has no RequestedSecurityToken")
+	}
+	if v := rstr.Lifetime.Created; v != "" {
+		if tok.Created, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, fmt.Errorf("wstrust: invalid lifetime: %v", err)
+		}
+	}
+	if v := rstr.Lifetime.Expires; v != "" {
+		if tok.Expires, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, fmt.Errorf("wstrust: invalid lifetime: %v", err)
+		}
+	}
+	return tok, nil
+}
@@ -0,0 +1,103 @@
+package wstrust
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YapealAG/wsdl2go/soap"
+)
+
+const assertion = `<saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_1"></saml:Assertion>`
+
+const issueResponseXML = `<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+<s:Body>
+<trust:RequestSecurityTokenResponseCollection xmlns:trust="http://docs.oasis-open.org/ws-sx/ws-trust/200512">
+<trust:RequestSecurityTokenResponse>
+<trust:Lifetime>
+<wsu:Created xmlns:wsu="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd">2024-01-01T10:00:00Z</wsu:Created>
+<wsu:Expires xmlns:wsu="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd">2024-01-01T11:00:00Z</wsu:Expires>
+</trust:Lifetime>
+<trust:RequestedSecurityToken>
+` + assertion + `
+</trust:RequestedSecurityToken>
+<trust:TokenType>urn:oasis:names:tc:SAML:2.0:assertion</trust:TokenType>
+</trust:RequestSecurityTokenResponse>
+</trust:RequestSecurityTokenResponseCollection>
+</s:Body>
+</s:Envelope>`
+
+func TestIssue(t *testing.T) {
+	var request string
+	var contentType string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		request = string(b)
+		contentType = r.Header.Get("Content-Type")
+		io.WriteString(w, issueResponseXML)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	c := &Client{
+		SOAP: &soap.Client{
+			URL:      s.URL,
+			Envelope: "http://www.w3.org/2003/05/soap-envelope",
+		},
+		Username:  "user",
+		Password:  "secret",
+		AppliesTo: "urn:example:rp",
+	}
+	tok, err := c.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<wst:RequestSecurityToken",
+		"<wst:RequestType>" + IssueType + "</wst:RequestType>",
+		"<wsa:Address>urn:example:rp</wsa:Address>",
+		"<wsse:Username>user</wsse:Username>",
+		"<wsa:Action xmlns:wsa=\"" + AddressingNamespace + "\">" + IssueAction + "</wsa:Action>",
+	} {
+		if !strings.Contains(request, want) {
+			t.Errorf("request is missing %q:\n%s", want, request)
+		}
+	}
+	if !strings.Contains(contentType, `action="`+IssueAction+`"`) {
+		t.Errorf("unexpected content type %q", contentType)
+	}
+	if string(tok.Raw) != assertion {
+		t.Errorf("unexpected token:\n%s", tok.Raw)
+	}
+	want := time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)
+	if !tok.Expires.Equal(want) {
+		t.Errorf("want expiry %v, have %v", want, tok.Expires)
+	}
+	if tok.Expired(want.Add(-time.Hour), time.Minute) || !tok.Expired(want, 0) {
+		t.Error("unexpected expiry check")
+	}
+
+	b, err := xml.Marshal(tok.Header())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(b, []byte(assertion)) {
+		t.Errorf("security header is missing the assertion:\n%s", b)
+	}
+}
+
+func TestIssueNoToken(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<Envelope><Body><RequestSecurityTokenResponse/></Body></Envelope>`)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	c := &Client{SOAP: &soap.Client{URL: s.URL}}
+	if _, err := c.Issue(); err == nil {
+		t.Fatal("expected error")
+	}
+}
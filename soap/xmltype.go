@@ -0,0 +1,65 @@
+package soap
+
+import (
+	"reflect"
+	"sync"
+)
+
+// xmlTypePlan describes where setXMLType finds XMLTypers in values of
+// a type. Types without any have a nil plan.
+type xmlTypePlan struct {
+	typer  bool  // The type is a pointer that implements XMLTyper
+	fields []int // Struct fields that may contain XMLTypers
+}
+
+// xmlTypePlans caches the *xmlTypePlan of each reflect.Type.
+var xmlTypePlans sync.Map
+
+// xmlTypePlanOf returns the plan of t, or nil if values of t can't
+// contain an XMLTyper.
+func xmlTypePlanOf(t reflect.Type) *xmlTypePlan {
+	if p, ok := xmlTypePlans.Load(t); ok {
+		return p.(*xmlTypePlan)
+	}
+	var p *xmlTypePlan
+	if hasXMLTyper(t, map[reflect.Type]bool{}) {
+		p = &xmlTypePlan{typer: t.Kind() == reflect.Ptr && t.Implements(xmlTyperType)}
+		if t.Kind() == reflect.Struct {
+			for i := 0; i < t.NumField(); i++ {
+				if hasXMLTyper(t.Field(i).Type, map[reflect.Type]bool{}) {
+					p.fields = append(p.fields, i)
+				}
+			}
+		}
+	}
+	v, _ := xmlTypePlans.LoadOrStore(t, p)
+	return v.(*xmlTypePlan)
+}
+
+// hasXMLTyper reports whether values of type t, or their address, may
+// contain an XMLTyper. Interfaces and recursive types are assumed to.
+func hasXMLTyper(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if visiting[t] {
+		return true
+	}
+	if t.Kind() != reflect.Ptr && reflect.PointerTo(t).Implements(xmlTyperType) {
+		return true
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Ptr:
+		return t.Implements(xmlTyperType) || hasXMLTyper(t.Elem(), visiting)
+	case reflect.Slice:
+		return hasXMLTyper(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if hasXMLTyper(t.Field(i).Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package soap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestXMLTypePlan(t *testing.T) {
+	type plain struct {
+		A string
+		B []int
+	}
+	type recursive struct {
+		Next *recursive
+	}
+	testCases := []struct {
+		v    any
+		want bool
+	}{
+		{&plain{}, false},
+		{[]*plain{}, false},
+		{&SetXMLData{}, true},
+		{&struct{ F StructFieldSetXMLData }{}, true},
+		{&struct{ F []*StructFieldSetXMLData }{}, true},
+		{&struct{ F any }{}, true},
+		{&recursive{}, true},
+	}
+	for i, tc := range testCases {
+		if have := xmlTypePlanOf(reflect.TypeOf(tc.v)) != nil; have != tc.want {
+			t.Errorf("test %d: %T: want %v, have %v", i, tc.v, tc.want, have)
+		}
+	}
+
+	p := xmlTypePlanOf(reflect.TypeOf(SetXMLData{}))
+	if p == nil || p.typer || !reflect.DeepEqual(p.fields, []int{2, 3}) {
+		t.Fatalf("unexpected plan %#v", p)
+	}
+}
+
+type typedCode string
+
+func (c *typedCode) SetXMLType() { *c = "typed" }
+
+func TestSetXMLTypeNamedField(t *testing.T) {
+	v := &struct{ Code typedCode }{}
+	setXMLType(reflect.ValueOf(v))
+	if v.Code != "typed" {
+		t.Fatalf("SetXMLType not called: %#v", v)
+	}
+}
+
+func BenchmarkSetXMLType(b *testing.B) {
+	req := &benchRequest{}
+	for i := 0; i < 50; i++ {
+		req.Items = append(req.Items, benchItem{Name: "item", Tags: []string{"a"}})
+	}
+	v := reflect.ValueOf(req)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		setXMLType(v)
+	}
+}
+
+func BenchmarkSetXMLTypeTypers(b *testing.B) {
+	var req struct {
+		Items []*SetXMLData
+		Names []string
+	}
+	for i := 0; i < 50; i++ {
+		req.Items = append(req.Items, &SetXMLData{Pointer: &StructFieldSetXMLData{}})
+		req.Names = append(req.Names, "name")
+	}
+	v := reflect.ValueOf(&req)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		setXMLType(v)
+	}
+}
@@ -4,6 +4,7 @@
 package wsdl
 
 import (
+	"bytes"
 	"encoding/xml"
 	"io"
 
@@ -15,12 +16,17 @@ import (
 // The Definitions object it returns is an unmarshalled version of the
 // WSDL XML that can be introspected to generate the Web Services API.
 func Unmarshal(r io.Reader) (*Definitions, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
 	var d Definitions
-	decoder := xml.NewDecoder(r)
+	decoder := xml.NewDecoder(bytes.NewReader(b))
 	decoder.CharsetReader = charset.NewReaderLabel
-	err := decoder.Decode(&d)
+	err = decoder.Decode(&d)
 	if err != nil {
 		return nil, err
 	}
+	d.Source = b
 	return &d, nil
 }
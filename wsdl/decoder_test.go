@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -41,3 +42,70 @@ func TestUnmarshal(t *testing.T) {
 		}
 	}
 }
+
+func TestUnmarshalBindings(t *testing.T) {
+	d, err := Unmarshal(strings.NewReader(`<definitions xmlns="http://schemas.xmlsoap.org/wsdl/" xmlns:soap="http://schemas.xmlsoap.org/wsdl/soap/" xmlns:tns="urn:x">
+	<portType name="A"/>
+	<portType name="B"/>
+	<binding name="BSoap" type="tns:B"><soap:binding style="document"/></binding>
+	<binding name="AHttp" type="tns:A"/>
+	<service name="S"><port name="P" binding="tns:BSoap"/></service>
+</definitions>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.PortTypes) != 2 || len(d.Bindings) != 2 || len(d.Services) != 1 {
+		t.Fatalf("want 2 port types, 2 bindings and a service, have %d, %d and %d", len(d.PortTypes), len(d.Bindings), len(d.Services))
+	}
+	if d.Binding.Name != "BSoap" || d.PortType.Name != "A" || d.Service.Name != "S" {
+		t.Fatalf("unexpected first binding %q, port type %q or service %q", d.Binding.Name, d.PortType.Name, d.Service.Name)
+	}
+	if pt := d.BindingPortType(d.Bindings[0]); pt == nil || pt.Name != "B" {
+		t.Fatalf("unexpected port type of BSoap: %v", pt)
+	}
+	if !d.Bindings[0].IsSOAP() || d.Bindings[1].IsSOAP() {
+		t.Fatal("only BSoap is a SOAP binding")
+	}
+}
+
+func TestUnmarshalElementOccurs(t *testing.T) {
+	var seq Sequence
+	err := xml.Unmarshal([]byte(`<sequence>
+	<element name="a" type="string"/>
+	<element name="b" type="string" minOccurs="0"/>
+	<element name="c" type="string" minOccurs="2"/>
+</sequence>`), &seq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		Name     string
+		Min      int
+		Optional bool
+	}{{"a", 0, false}, {"b", 0, true}, {"c", 2, false}}
+	for i, el := range seq.Elements {
+		if el.Name != want[i].Name || el.Min != want[i].Min || el.Optional() != want[i].Optional {
+			t.Errorf("element %d: want %+v, have %s of Min %d, Optional %v", i, want[i], el.Name, el.Min, el.Optional())
+		}
+	}
+}
+
+func TestUnmarshalSchemas(t *testing.T) {
+	d, err := Unmarshal(strings.NewReader(`<definitions xmlns="http://schemas.xmlsoap.org/wsdl/" xmlns:xs="http://www.w3.org/2001/XMLSchema">
+	<types>
+		<xs:schema targetNamespace="urn:a"><xs:element name="A"/><xs:complexType name="AT"/></xs:schema>
+		<xs:schema targetNamespace="urn:b"><xs:element name="B"/><xs:simpleType name="BT"/></xs:schema>
+	</types>
+</definitions>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := d.Schema
+	if len(s.Elements) != 2 || len(s.ComplexTypes) != 1 || len(s.SimpleTypes) != 1 {
+		t.Fatalf("want the elements and types of both schemas, have %d, %d and %d", len(s.Elements), len(s.ComplexTypes), len(s.SimpleTypes))
+	}
+	if s.Elements[0].TargetNamespace != "urn:a" || s.ComplexTypes[0].TargetNamespace != "urn:a" ||
+		s.Elements[1].TargetNamespace != "urn:b" || s.SimpleTypes[0].TargetNamespace != "urn:b" {
+		t.Fatal("the elements and types do not keep the namespace of their schema")
+	}
+}
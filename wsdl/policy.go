@@ -0,0 +1,240 @@
+package wsdl
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// WS-Policy namespaces.
+const (
+	PolicyNamespace   = "http://schemas.xmlsoap.org/ws/2004/09/policy"
+	Policy15Namespace = "http://www.w3.org/ns/ws-policy"
+)
+
+// Policy is a WS-Policy expression in normal form: a list of
+// alternatives, any of which a client may satisfy. The wsp:All,
+// wsp:ExactlyOne and wsp:Optional operators are expanded while
+// decoding.
+type Policy struct {
+	ID           string // wsu:Id, referenced by "#ID"
+	Name         string
+	Alternatives []PolicyAlternative
+}
+
+// PolicyAlternative is a set of assertions that are all required.
+type PolicyAlternative []*PolicyAssertion
+
+// PolicyAssertion is a policy assertion, such as sp:TransportBinding,
+// with the alternatives of its nested policy. wsp:PolicyReference
+// elements are kept as assertions with the URI of the policy in Ref,
+// and resolved by Definitions.BindingPolicy.
+type PolicyAssertion struct {
+	Name   xml.Name
+	Ref    string
+	Policy []PolicyAlternative
+}
+
+// PolicyReference refers to a policy by URI, such as "#Policy1".
+type PolicyReference struct {
+	URI string `xml:"URI,attr"`
+}
+
+func isPolicyNamespace(ns string) bool {
+	return ns == PolicyNamespace || ns == Policy15Namespace
+}
+
+// UnmarshalXML implements the xml.Unmarshaler interface.
+func (p *Policy) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "Id":
+			p.ID = attr.Value
+		case "Name":
+			p.Name = attr.Value
+		}
+	}
+	alts, err := decodePolicyOperator(d, "All")
+	if err != nil {
+		return err
+	}
+	p.Alternatives = alts
+	return nil
+}
+
+// decodePolicyOperator decodes the children of a wsp:All (or wsp:Policy)
+// or wsp:ExactlyOne operator up to its end element, returning its
+// alternatives in normal form.
+func decodePolicyOperator(d *xml.Decoder, op string) ([]PolicyAlternative, error) {
+	var terms [][]PolicyAlternative
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := t.(type) {
+		case xml.EndElement:
+			if op == "ExactlyOne" {
+				var alts []PolicyAlternative
+				for _, term := range terms {
+					alts = append(alts, term...)
+				}
+				return alts, nil
+			}
+			return crossPolicies(terms), nil
+		case xml.StartElement:
+			term, err := decodePolicyTerm(d, t)
+			if err != nil {
+				return nil, err
+			}
+			terms = append(terms, term)
+		}
+	}
+}
+
+// decodePolicyTerm decodes an operator or assertion element.
+func decodePolicyTerm(d *xml.Decoder, start xml.StartElement) ([]PolicyAlternative, error) {
+	if isPolicyNamespace(start.Name.Space) {
+		switch start.Name.Local {
+		case "All", "Policy", "ExactlyOne":
+			op := start.Name.Local
+			if op == "Policy" {
+				op = "All"
+			}
+			return decodePolicyOperator(d, op)
+		case "PolicyReference":
+			a := &PolicyAssertion{Name: start.Name}
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "URI" {
+					a.Ref = attr.Value
+				}
+			}
+			return []PolicyAlternative{{a}}, d.Skip()
+		}
+	}
+	a := &PolicyAssertion{Name: start.Name}
+	optional := false
+	for _, attr := range start.Attr {
+		if isPolicyNamespace(attr.Name.Space) && attr.Name.Local == "Optional" {
+			optional = attr.Value == "true" || attr.Value == "1"
+		}
+	}
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := t.(type) {
+		case xml.EndElement:
+			alts := []PolicyAlternative{{a}}
+			if optional {
+				alts = append(alts, PolicyAlternative{})
+			}
+			return alts, nil
+		case xml.StartElement:
+			if isPolicyNamespace(t.Name.Space) && t.Name.Local == "Policy" {
+				if a.Policy, err = decodePolicyOperator(d, "All"); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			// assertion parameters are not interpreted
+			if err := d.Skip(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// crossPolicies returns the alternatives of the conjunction of terms:
+// one alternative for each combination of their alternatives.
+func crossPolicies(terms [][]PolicyAlternative) []PolicyAlternative {
+	alts := []PolicyAlternative{{}}
+	for _, term := range terms {
+		var next []PolicyAlternative
+		for _, alt := range alts {
+			for _, t := range term {
+				merged := append(append(PolicyAlternative(nil), alt...), t...)
+				next = append(next, merged)
+			}
+		}
+		alts = next
+	}
+	return alts
+}
+
+// BindingPolicy returns the alternatives of the policies attached to the
+// binding and its operations, with references to the policies of the
+// document resolved. It returns nil if no policy is attached.
+func (def *Definitions) BindingPolicy() ([]PolicyAlternative, error) {
+	var terms [][]PolicyAlternative
+	attach := func(policies []*Policy, refs []*PolicyReference, uris string) {
+		for _, p := range policies {
+			terms = append(terms, p.Alternatives)
+		}
+		for _, ref := range refs {
+			terms = append(terms, []PolicyAlternative{{{Ref: ref.URI}}})
+		}
+		for _, uri := range strings.Fields(uris) {
+			terms = append(terms, []PolicyAlternative{{{Ref: uri}}})
+		}
+	}
+	b := &def.Binding
+	attach(b.Policies, b.PolicyReferences, b.PolicyURIs)
+	for _, op := range b.Operations {
+		attach(op.Policies, op.PolicyReferences, op.PolicyURIs)
+	}
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	return def.resolvePolicy(crossPolicies(terms), map[string]bool{})
+}
+
+// resolvePolicy replaces the references in alts by the alternatives of
+// the policies they refer to.
+func (def *Definitions) resolvePolicy(alts []PolicyAlternative, resolving map[string]bool) ([]PolicyAlternative, error) {
+	var out []PolicyAlternative
+	for _, alt := range alts {
+		terms := make([][]PolicyAlternative, 0, len(alt))
+		for _, a := range alt {
+			if a.Ref == "" {
+				nested, err := def.resolvePolicy(a.Policy, resolving)
+				if err != nil {
+					return nil, err
+				}
+				terms = append(terms, []PolicyAlternative{{{Name: a.Name, Policy: nested}}})
+				continue
+			}
+			p := def.policy(a.Ref)
+			if p == nil {
+				return nil, fmt.Errorf("wsdl: unresolved policy reference %q", a.Ref)
+			}
+			if resolving[a.Ref] {
+				return nil, fmt.Errorf("wsdl: recursive policy reference %q", a.Ref)
+			}
+			resolving[a.Ref] = true
+			resolved, err := def.resolvePolicy(p.Alternatives, resolving)
+			delete(resolving, a.Ref)
+			if err != nil {
+				return nil, err
+			}
+			terms = append(terms, resolved)
+		}
+		out = append(out, crossPolicies(terms)...)
+	}
+	return out, nil
+}
+
+// policy returns the policy of the document that uri refers to, by its
+// wsu:Id ("#ID") or Name.
+func (def *Definitions) policy(uri string) *Policy {
+	for _, p := range def.Policies {
+		if id, ok := strings.CutPrefix(uri, "#"); ok && p.ID == id {
+			return p
+		}
+		if p.Name != "" && p.Name == uri {
+			return p
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package wsdl
+
+import (
+	"strings"
+	"testing"
+)
+
+const policyWSDL = `<definitions xmlns="http://schemas.xmlsoap.org/wsdl/"
+	xmlns:wsp="http://www.w3.org/ns/ws-policy"
+	xmlns:wsu="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd"
+	xmlns:sp="http://docs.oasis-open.org/ws-sx/ws-securitypolicy/200702"
+	xmlns:wsam="http://www.w3.org/2007/05/addressing/metadata">
+<wsp:Policy wsu:Id="Transport">
+	<wsp:ExactlyOne>
+		<wsp:All>
+			<sp:TransportBinding>
+				<wsp:Policy>
+					<sp:TransportToken><wsp:Policy><sp:HttpsToken/></wsp:Policy></sp:TransportToken>
+					<sp:IncludeTimestamp/>
+				</wsp:Policy>
+			</sp:TransportBinding>
+			<wsam:Addressing wsp:Optional="true"/>
+		</wsp:All>
+	</wsp:ExactlyOne>
+</wsp:Policy>
+<wsp:Policy wsu:Id="Username">
+	<sp:SignedSupportingTokens>
+		<wsp:Policy><sp:UsernameToken sp:IncludeToken="x"><sp:Issuer>ignored</sp:Issuer></sp:UsernameToken></wsp:Policy>
+	</sp:SignedSupportingTokens>
+</wsp:Policy>
+<binding name="B">
+	<wsp:PolicyReference URI="#Transport"/>
+	<operation name="Op">
+		<wsp:PolicyReference URI="#Username"/>
+	</operation>
+</binding>
+</definitions>`
+
+func names(alt PolicyAlternative) string {
+	var s []string
+	for _, a := range alt {
+		n := a.Name.Local
+		if len(a.Policy) > 0 {
+			var nested []string
+			for _, alt := range a.Policy {
+				nested = append(nested, names(alt))
+			}
+			n += "(" + strings.Join(nested, "|") + ")"
+		}
+		s = append(s, n)
+	}
+	return strings.Join(s, " ")
+}
+
+func TestBindingPolicy(t *testing.T) {
+	d, err := Unmarshal(strings.NewReader(policyWSDL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Policies) != 2 || d.Policies[0].ID != "Transport" {
+		t.Fatalf("unexpected policies %+v", d.Policies)
+	}
+	alts, err := d.BindingPolicy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"TransportBinding(TransportToken(HttpsToken) IncludeTimestamp) Addressing SignedSupportingTokens(UsernameToken)",
+		"TransportBinding(TransportToken(HttpsToken) IncludeTimestamp) SignedSupportingTokens(UsernameToken)",
+	}
+	if len(alts) != len(want) {
+		t.Fatalf("want %d alternatives, have %d", len(want), len(alts))
+	}
+	for i, alt := range alts {
+		if have := names(alt); have != want[i] {
+			t.Errorf("alternative %d: want %q, have %q", i, want[i], have)
+		}
+	}
+
+	d.Binding.Operations[0].PolicyReferences[0].URI = "#Missing"
+	if _, err := d.BindingPolicy(); err == nil || !strings.Contains(err.Error(), `"#Missing"`) {
+		t.Fatalf("want unresolved reference error, have %v", err)
+	}
+}
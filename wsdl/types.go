@@ -2,7 +2,10 @@ package wsdl
 
 // TODO: Add all types from the spec.
 
-import "encoding/xml"
+import (
+	"encoding/xml"
+	"strings"
+)
 
 // Definitions is the root element of a WSDL document.
 type Definitions struct {
@@ -12,12 +15,17 @@ type Definitions struct {
 	Namespaces      map[string]string `xml:"-"`
 	SOAPEnv         string            `xml:"SOAP-ENV,attr"`
 	SOAPEnc         string            `xml:"SOAP-ENC,attr"`
-	Service         Service           `xml:"service"`
+	Service         Service           `xml:"-"` // first of Services
+	Services        []*Service        `xml:"service"`
 	Imports         []*Import         `xml:"import"`
 	Schema          Schema            `xml:"types>schema"`
 	Messages        []*Message        `xml:"message"`
-	PortType        PortType          `xml:"portType"` // TODO: PortType slice?
-	Binding         Binding           `xml:"binding"`
+	PortType        PortType          `xml:"-"` // first of PortTypes
+	PortTypes       []*PortType       `xml:"portType"`
+	Binding         Binding           `xml:"-"` // first of Bindings
+	Bindings        []*Binding        `xml:"binding"`
+	Policies        []*Policy         `xml:"Policy"`
+	Source          []byte            `xml:"-"` // document decoded by Unmarshal
 }
 
 type definitionDup Definitions
@@ -32,11 +40,43 @@ func (def *Definitions) UnmarshalXML(d *xml.Decoder, start xml.StartElement) err
 			def.Namespaces[attr.Name.Local] = attr.Value
 		}
 	}
-	return d.DecodeElement((*definitionDup)(def), &start)
+	if err := d.DecodeElement((*definitionDup)(def), &start); err != nil {
+		return err
+	}
+	if len(def.Services) > 0 {
+		def.Service = *def.Services[0]
+	}
+	if len(def.PortTypes) > 0 {
+		def.PortType = *def.PortTypes[0]
+	}
+	if len(def.Bindings) > 0 {
+		def.Binding = *def.Bindings[0]
+	}
+	return nil
+}
+
+// BindingPortType returns the port type of the binding b, or nil if
+// it's not defined.
+func (def *Definitions) BindingPortType(b *Binding) *PortType {
+	for _, pt := range def.PortTypes {
+		if pt.Name == trimPrefix(b.Type) {
+			return pt
+		}
+	}
+	return nil
+}
+
+// trimPrefix returns the local part of the qualified name qname.
+func trimPrefix(qname string) string {
+	if i := strings.LastIndex(qname, ":"); i >= 0 {
+		return qname[i+1:]
+	}
+	return qname
 }
 
 // Service defines a WSDL service and with a location, like an HTTP server.
 type Service struct {
+	Name  string  `xml:"name,attr"`
 	Doc   string  `xml:"documentation"`
 	Ports []*Port `xml:"port"`
 }
@@ -62,9 +102,14 @@ type Schema struct {
 	Namespaces      map[string]string `xml:"-"`
 	Imports         []*ImportSchema   `xml:"import"`
 	Includes        []*IncludeSchema  `xml:"include"`
+	Redefines       []*Redefine       `xml:"redefine"`
+	Overrides       []*Redefine       `xml:"override"`
 	SimpleTypes     []*SimpleType     `xml:"simpleType"`
 	ComplexTypes    []*ComplexType    `xml:"complexType"`
 	Elements        []*Element        `xml:"element"`
+	Attributes      []*Attribute      `xml:"attribute"`
+	AttributeGroups []*AttributeGroup `xml:"attributeGroup"`
+	Groups          []*Group          `xml:"group"`
 }
 
 // Unmarshaling solution from Matt Harden (http://grokbase.com/t/gg/golang-nuts/14bk21xb7a/go-nuts-extending-encoding-xml-to-capture-unknown-attributes)
@@ -84,7 +129,31 @@ func (schema *Schema) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error
 			schema.Namespaces[attr.Name.Local] = attr.Value
 		}
 	}
-	return d.DecodeElement((*schemaDup)(schema), &start)
+	ncts, nsts, nels, ngroups := len(schema.ComplexTypes), len(schema.SimpleTypes), len(schema.Elements), len(schema.Groups)
+	if err := d.DecodeElement((*schemaDup)(schema), &start); err != nil {
+		return err
+	}
+	// The schemas of the types of a WSDL are decoded into one Schema,
+	// of the last target namespace: its types and elements keep theirs.
+	var ns string
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "" && attr.Name.Local == "targetNamespace" {
+			ns = attr.Value
+		}
+	}
+	for _, ct := range schema.ComplexTypes[ncts:] {
+		ct.TargetNamespace = ns
+	}
+	for _, st := range schema.SimpleTypes[nsts:] {
+		st.TargetNamespace = ns
+	}
+	for _, el := range schema.Elements[nels:] {
+		el.TargetNamespace = ns
+	}
+	for _, g := range schema.Groups[ngroups:] {
+		g.TargetNamespace = ns
+	}
+	return nil
 }
 
 // SimpleType describes a simple type, such as string.
@@ -92,23 +161,50 @@ type SimpleType struct {
 	XMLName         xml.Name     `xml:"simpleType"`
 	Name            string       `xml:"name,attr"`
 	Union           *Union       `xml:"union"`
+	List            *List        `xml:"list"`
 	Restriction     *Restriction `xml:"restriction"`
 	TargetNamespace string
 }
 
 // Union is a mix of multiple types in a union.
 type Union struct {
-	XMLName     xml.Name `xml:"union"`
-	MemberTypes string   `xml:"memberTypes,attr"`
+	XMLName     xml.Name      `xml:"union"`
+	MemberTypes string        `xml:"memberTypes,attr"`
+	SimpleTypes []*SimpleType `xml:"simpleType"` // anonymous members
+}
+
+// List is a list of values of the item type, separated by white space.
+type List struct {
+	XMLName    xml.Name    `xml:"list"`
+	ItemType   string      `xml:"itemType,attr"`
+	SimpleType *SimpleType `xml:"simpleType"` // anonymous item type
 }
 
 // Restriction describes the WSDL type of the simple type and
 // optionally its allowed values.
 type Restriction struct {
-	XMLName    xml.Name     `xml:"restriction"`
-	Base       string       `xml:"base,attr"`
-	Enum       []*Enum      `xml:"enumeration"`
-	Attributes []*Attribute `xml:"attribute"`
+	XMLName        xml.Name     `xml:"restriction"`
+	Base           string       `xml:"base,attr"`
+	Enum           []*Enum      `xml:"enumeration"`
+	Patterns       []*Facet     `xml:"pattern"`
+	Length         *Facet       `xml:"length"`
+	MinLength      *Facet       `xml:"minLength"`
+	MaxLength      *Facet       `xml:"maxLength"`
+	MinInclusive   *Facet       `xml:"minInclusive"`
+	MaxInclusive   *Facet       `xml:"maxInclusive"`
+	MinExclusive   *Facet       `xml:"minExclusive"`
+	MaxExclusive   *Facet       `xml:"maxExclusive"`
+	TotalDigits    *Facet       `xml:"totalDigits"`
+	FractionDigits *Facet       `xml:"fractionDigits"`
+	Attributes     []*Attribute `xml:"attribute"`
+	Sequence       *Sequence    `xml:"sequence"` // of complexContent
+	Choice         *Choice      `xml:"choice"`   // of complexContent
+}
+
+// Facet is a constraining facet of a Restriction, such as a pattern or
+// a maximum length.
+type Facet struct {
+	Value string `xml:"value,attr"`
 }
 
 // Enum describes one possible value for a Restriction.
@@ -119,16 +215,19 @@ type Enum struct {
 
 // ComplexType describes a complex type, such as a struct.
 type ComplexType struct {
-	XMLName         xml.Name        `xml:"complexType"`
-	Name            string          `xml:"name,attr"`
-	Abstract        bool            `xml:"abstract,attr"`
-	Doc             string          `xml:"annotation>documentation"`
-	AllElements     []*Element      `xml:"all>element"`
-	ComplexContent  *ComplexContent `xml:"complexContent"`
-	SimpleContent   *SimpleContent  `xml:"simpleContent"`
-	Sequence        *Sequence       `xml:"sequence"`
-	Choice          *Choice         `xml:"choice"`
-	Attributes      []*Attribute    `xml:"attribute"`
+	XMLName         xml.Name          `xml:"complexType"`
+	Name            string            `xml:"name,attr"`
+	Abstract        bool              `xml:"abstract,attr"`
+	Doc             string            `xml:"annotation>documentation"`
+	AllElements     []*Element        `xml:"all>element"`
+	ComplexContent  *ComplexContent   `xml:"complexContent"`
+	SimpleContent   *SimpleContent    `xml:"simpleContent"`
+	Sequence        *Sequence         `xml:"sequence"`
+	Choice          *Choice           `xml:"choice"`
+	Group           *Group            `xml:"group"`
+	Attributes      []*Attribute      `xml:"attribute"`
+	AttributeGroups []*AttributeGroup `xml:"attributeGroup"`
+	AnyAttribute    *AnyAttribute     `xml:"anyAttribute"`
 	TargetNamespace string
 }
 
@@ -149,11 +248,15 @@ type ComplexContent struct {
 
 // Extension describes a complex content extension.
 type Extension struct {
-	XMLName    xml.Name     `xml:"extension"`
-	Base       string       `xml:"base,attr"`
-	Sequence   *Sequence    `xml:"sequence"`
-	Choice     *Choice      `xml:"choice"`
-	Attributes []*Attribute `xml:"attribute"`
+	XMLName         xml.Name          `xml:"extension"`
+	Base            string            `xml:"base,attr"`
+	AllElements     []*Element        `xml:"all>element"`
+	Sequence        *Sequence         `xml:"sequence"`
+	Choice          *Choice           `xml:"choice"`
+	Group           *Group            `xml:"group"`
+	Attributes      []*Attribute      `xml:"attribute"`
+	AttributeGroups []*AttributeGroup `xml:"attributeGroup"`
+	AnyAttribute    *AnyAttribute     `xml:"anyAttribute"`
 }
 
 // Sequence describes a list of elements (parameters) of a type.
@@ -163,6 +266,7 @@ type Sequence struct {
 	Elements     []*Element     `xml:"element"`
 	Any          []*AnyElement  `xml:"any"`
 	Choices      []*Choice      `xml:"choice"`
+	Groups       []*Group       `xml:"group"`
 }
 
 // Choice describes a list of elements (parameters) of a type.
@@ -171,6 +275,21 @@ type Choice struct {
 	ComplexTypes []*ComplexType `xml:"complexType"`
 	Elements     []*Element     `xml:"element"`
 	Any          []*AnyElement  `xml:"any"`
+	Groups       []*Group       `xml:"group"`
+}
+
+// Group describes a named group of elements, or a reference to one, of
+// complex types.
+type Group struct {
+	XMLName         xml.Name   `xml:"group"`
+	Name            string     `xml:"name,attr"`
+	Ref             string     `xml:"ref,attr"`
+	Min             int        `xml:"minOccurs,attr"`
+	Max             string     `xml:"maxOccurs,attr"` // can be # or unbounded
+	Sequence        *Sequence  `xml:"sequence"`
+	Choice          *Choice    `xml:"choice"`
+	AllElements     []*Element `xml:"all>element"`
+	TargetNamespace string
 }
 
 // Attribute describes an attribute of a given type.
@@ -183,6 +302,26 @@ type Attribute struct {
 	Min       int      `xml:"minOccurs,attr"`
 	Max       string   `xml:"maxOccurs,attr"` // can be # or unbounded
 	Nillable  bool     `xml:"nillable,attr"`
+	Use       string   `xml:"use,attr"` // optional, required or prohibited
+	Default   string   `xml:"default,attr"`
+	Fixed     string   `xml:"fixed,attr"`
+}
+
+// AttributeGroup describes a group of attributes, or a reference to
+// one, of a complex type.
+type AttributeGroup struct {
+	XMLName         xml.Name          `xml:"attributeGroup"`
+	Name            string            `xml:"name,attr"`
+	Ref             string            `xml:"ref,attr"`
+	Attributes      []*Attribute      `xml:"attribute"`
+	AttributeGroups []*AttributeGroup `xml:"attributeGroup"`
+	AnyAttribute    *AnyAttribute     `xml:"anyAttribute"`
+}
+
+// AnyAttribute describes the attributes of undefined names of a type.
+type AnyAttribute struct {
+	XMLName   xml.Name `xml:"anyAttribute"`
+	Namespace string   `xml:"namespace,attr"`
 }
 
 // Element describes an element of a given type.
@@ -194,7 +333,33 @@ type Element struct {
 	Min         int          `xml:"minOccurs,attr"`
 	Max         string       `xml:"maxOccurs,attr"` // can be # or unbounded
 	Nillable    bool         `xml:"nillable,attr"`
+	Abstract    bool         `xml:"abstract,attr"`
+	Default     string       `xml:"default,attr"`
+	Fixed       string       `xml:"fixed,attr"`
 	ComplexType *ComplexType `xml:"complexType"`
+	SimpleType  *SimpleType  `xml:"simpleType"`
+
+	SubstitutionGroup string `xml:"substitutionGroup,attr"` // head element
+
+	MinSet          bool   `xml:"-"` // whether minOccurs is given, of a default of 1
+	TargetNamespace string `xml:"-"` // of the schema of a global element
+}
+
+type elementDup Element
+
+// UnmarshalXML implements the xml.Unmarshaler interface, setting MinSet.
+func (el *Element) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "minOccurs" {
+			el.MinSet = true
+		}
+	}
+	return d.DecodeElement((*elementDup)(el), &start)
+}
+
+// Optional reports whether el may be omitted, of minOccurs="0".
+func (el *Element) Optional() bool {
+	return el.MinSet && el.Min == 0
 }
 
 // AnyElement describes an element of an undefined type.
@@ -225,6 +390,20 @@ type IncludeSchema struct {
 	Location  string   `xml:"schemaLocation,attr"`
 }
 
+// Redefine includes the schema at Location, redefining its components
+// by those it declares, which extend or restrict the components of the
+// same names; or, for xs:override, replacing them.
+type Redefine struct {
+	XMLName         xml.Name
+	Location        string            `xml:"schemaLocation,attr"`
+	SimpleTypes     []*SimpleType     `xml:"simpleType"`
+	ComplexTypes    []*ComplexType    `xml:"complexType"`
+	Groups          []*Group          `xml:"group"`
+	AttributeGroups []*AttributeGroup `xml:"attributeGroup"`
+	Elements        []*Element        `xml:"element"`   // xs:override only
+	Attributes      []*Attribute      `xml:"attribute"` // xs:override only
+}
+
 // Message describes the data being communicated, such as functions
 // and their parameters.
 type Message struct {
@@ -255,28 +434,54 @@ type Operation struct {
 	Doc     string   `xml:"documentation"`
 	Input   *IO      `xml:"input"`
 	Output  *IO      `xml:"output"`
+	Faults  []*IO    `xml:"fault"`
 }
 
 // IO describes which message is linked to an operation, for input
-// or output parameters.
+// or output parameters, or faults.
 type IO struct {
 	XMLName xml.Name
+	Name    string `xml:"name,attr"`
 	Message string `xml:"message,attr"`
 }
 
 // Binding describes SOAP to WSDL binding.
 type Binding struct {
-	XMLName     xml.Name            `xml:"binding"`
-	Name        string              `xml:"name,attr"`
-	Type        string              `xml:"type,attr"`
-	BindingType *BindingType        `xml:"binding"`
-	Operations  []*BindingOperation `xml:"operation"`
+	XMLName          xml.Name            `xml:"binding"`
+	Name             string              `xml:"name,attr"`
+	Type             string              `xml:"type,attr"`
+	BindingType      *BindingType        `xml:"binding"`
+	Operations       []*BindingOperation `xml:"operation"`
+	Policies         []*Policy           `xml:"Policy"`
+	PolicyReferences []*PolicyReference  `xml:"PolicyReference"`
+	PolicyURIs       string              `xml:"PolicyURIs,attr"`
 }
 
+// SOAP12Namespace is the namespace of the SOAP 1.2 binding of WSDL 1.1.
+const SOAP12Namespace = "http://schemas.xmlsoap.org/wsdl/soap12/"
+
 // BindingType contains additional meta data on how to implement the binding.
+// Its XMLName is the soap:binding, soap12:binding or http:binding element.
 type BindingType struct {
+	XMLName   xml.Name
 	Style     string `xml:"style,attr"`
 	Transport string `xml:"transport,attr"`
+	Verb      string `xml:"verb,attr"` // HTTP method of http:binding, e.g. GET
+}
+
+// SOAP11Namespace is the namespace of the SOAP 1.1 binding of WSDL 1.1.
+const SOAP11Namespace = "http://schemas.xmlsoap.org/wsdl/soap/"
+
+// IsSOAP returns whether b is a SOAP binding, declared with soap:binding
+// or soap12:binding.
+func (b *Binding) IsSOAP() bool {
+	return b.BindingType != nil && (b.BindingType.XMLName.Space == SOAP11Namespace || b.IsSOAP12())
+}
+
+// IsSOAP12 returns whether b is a SOAP 1.2 binding, declared with
+// soap12:binding.
+func (b *Binding) IsSOAP12() bool {
+	return b.BindingType != nil && b.BindingType.XMLName.Space == SOAP12Namespace
 }
 
 // BindingOperation describes the requirement for binding SOAP to WSDL
@@ -286,8 +491,16 @@ type BindingOperation struct {
 	Name        string          `xml:"name,attr"`
 	Operation   SOAP12Operation `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ operation"`
 	Operation11 SOAP11Operation `xml:"http://schemas.xmlsoap.org/wsdl/soap/ operation"`
+	HTTP        *HTTPOperation  `xml:"http://schemas.xmlsoap.org/wsdl/http/ operation"`
 	Input       *BindingIO      `xml:"input>body"`
 	Output      *BindingIO      `xml:"output>body"`
+
+	InputHeaders  []*BindingHeader `xml:"input>header"`
+	OutputHeaders []*BindingHeader `xml:"output>header"`
+
+	Policies         []*Policy          `xml:"Policy"`
+	PolicyReferences []*PolicyReference `xml:"PolicyReference"`
+	PolicyURIs       string             `xml:"PolicyURIs,attr"`
 }
 
 // SOAP12Operation describes a SOAP 1.2 operation. The soap12 namespace is
@@ -306,8 +519,26 @@ type SOAP11Operation struct {
 	Action  string   `xml:"soapAction,attr"`
 }
 
+// HTTPOperation describes an operation of an HTTP binding, whose
+// location is relative to the http:address of the port.
+type HTTPOperation struct {
+	XMLName  xml.Name `xml:"http://schemas.xmlsoap.org/wsdl/http/ operation"`
+	Location string   `xml:"location,attr"`
+}
+
 // BindingIO describes the IO binding of SOAP operations. See IO for details.
 type BindingIO struct {
-	Parts string `xml:"parts,attr"`
-	Use   string `xml:"use,attr"`
+	Parts         string `xml:"parts,attr"`
+	Use           string `xml:"use,attr"`
+	Namespace     string `xml:"namespace,attr"`     // of the operation element of rpc bindings
+	EncodingStyle string `xml:"encodingStyle,attr"` // of encoded bodies
+}
+
+// BindingHeader is a soap:header of the input or output of a binding
+// operation: the part of a message sent in the SOAP Header.
+type BindingHeader struct {
+	Message   string `xml:"message,attr"`
+	Part      string `xml:"part,attr"`
+	Use       string `xml:"use,attr"`
+	Namespace string `xml:"namespace,attr"`
 }
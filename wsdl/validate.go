@@ -0,0 +1,524 @@
+package wsdl
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+// ValidationError describes an element that violates a schema constraint.
+type ValidationError struct {
+	Path       string // slash separated path of the element
+	Constraint string // the violated constraint
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Constraint)
+}
+
+// ValidationErrors is the list of schema violations found in a document.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "wsdl: schema validation failed: " + strings.Join(msgs, "; ")
+}
+
+// ParseSchema parses an XML schema document, such as the one embedded in
+// code generated by wsdl2go with the -schema flag.
+func ParseSchema(r io.Reader) (*Schema, error) {
+	var s Schema
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charset.NewReaderLabel
+	if err := decoder.Decode(&s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Validate validates the XML document read from r against the schema.
+// Its root element must be declared by a global element of the schema.
+func (s *Schema) Validate(r io.Reader) error {
+	d := xml.NewDecoder(r)
+	d.CharsetReader = charset.NewReaderLabel
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return s.ValidateElement(d, start)
+		}
+	}
+}
+
+// ValidateElement validates the element that starts with start, reading
+// its content from d, against the global element of the same name.
+//
+// The validation covers the element structure (unexpected elements and
+// explicit minOccurs/maxOccurs), built-in simple types, enumerations,
+// patterns and length facets.
+// Element order within sequences and attributes are not checked.
+// The returned error is a ValidationErrors, or a decoding error.
+func (s *Schema) ValidateElement(d *xml.Decoder, start xml.StartElement) error {
+	v := &validator{schema: s}
+	el := s.element(start.Name.Local)
+	path := "/" + start.Name.Local
+	if el == nil {
+		v.fail(path, "element is not declared in the schema")
+		if err := d.Skip(); err != nil {
+			return err
+		}
+	} else if err := v.element(d, start, el, path); err != nil {
+		return err
+	}
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+func (s *Schema) element(name string) *Element {
+	for _, el := range s.Elements {
+		if el.Name == name {
+			return el
+		}
+	}
+	return nil
+}
+
+func (s *Schema) complexType(name string) *ComplexType {
+	for _, ct := range s.ComplexTypes {
+		if ct.Name == name {
+			return ct
+		}
+	}
+	return nil
+}
+
+func (s *Schema) simpleType(name string) *SimpleType {
+	for _, st := range s.SimpleTypes {
+		if st.Name == name {
+			return st
+		}
+	}
+	return nil
+}
+
+type validator struct {
+	schema *Schema
+	errs   ValidationErrors
+}
+
+func (v *validator) fail(path, format string, args ...any) {
+	v.errs = append(v.errs, &ValidationError{Path: path, Constraint: fmt.Sprintf(format, args...)})
+}
+
+const maxValidationDepth = 256
+
+const xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+// element validates the content of the element start, declared by el.
+func (v *validator) element(d *xml.Decoder, start xml.StartElement, el *Element, path string) error {
+	if strings.Count(path, "/") > maxValidationDepth {
+		return d.Skip()
+	}
+	if el.Ref != "" {
+		if ref := v.schema.element(localName(el.Ref)); ref != nil {
+			el = ref
+		}
+	}
+	typ := localName(el.Type)
+	for _, a := range start.Attr {
+		if a.Name.Space != xsiNamespace {
+			continue
+		}
+		switch {
+		case a.Name.Local == "nil" && (a.Value == "true" || a.Value == "1"):
+			if !el.Nillable {
+				v.fail(path, "element is not nillable")
+			}
+			return d.Skip()
+		case a.Name.Local == "type":
+			typ = localName(a.Value)
+		}
+	}
+	if el.ComplexType != nil && typ == localName(el.Type) {
+		return v.complexContent(d, el.ComplexType, path)
+	}
+	if el.SimpleType != nil && typ == localName(el.Type) {
+		text, err := v.text(d, path)
+		if err != nil {
+			return err
+		}
+		v.restriction(el.SimpleType, text, path, 0)
+		return nil
+	}
+	if typ == "" || typ == "anyType" {
+		return d.Skip()
+	}
+	if ct := v.schema.complexType(typ); ct != nil {
+		return v.complexContent(d, ct, path)
+	}
+	text, err := v.text(d, path)
+	if err != nil {
+		return err
+	}
+	v.simpleValue(typ, text, path, 0)
+	return nil
+}
+
+// text reads the character data of a simple element.
+func (v *validator) text(d *xml.Decoder, path string) (string, error) {
+	var b strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			b.Write(t)
+		case xml.StartElement:
+			v.fail(path+"/"+t.Name.Local, "unexpected element in simple content")
+			if err := d.Skip(); err != nil {
+				return "", err
+			}
+		case xml.EndElement:
+			return b.String(), nil
+		}
+	}
+}
+
+// particles returns the child elements allowed by ct, including those of
+// its base types, and whether any element is allowed.
+func (v *validator) particles(ct *ComplexType, depth int) (els []*Element, any bool) {
+	if depth > 32 {
+		return nil, true
+	}
+	addSeq := func(seq *Sequence) {
+		if seq == nil {
+			return
+		}
+		els = append(els, seq.Elements...)
+		any = any || len(seq.Any) > 0
+		for _, c := range seq.Choices {
+			els = append(els, c.Elements...)
+			any = any || len(c.Any) > 0
+		}
+	}
+	addChoice := func(c *Choice) {
+		if c == nil {
+			return
+		}
+		els = append(els, c.Elements...)
+		any = any || len(c.Any) > 0
+	}
+	els = append(els, ct.AllElements...)
+	addSeq(ct.Sequence)
+	addChoice(ct.Choice)
+	if cc := ct.ComplexContent; cc != nil {
+		if ext := cc.Extension; ext != nil {
+			if base := v.schema.complexType(localName(ext.Base)); base != nil {
+				bels, bany := v.particles(base, depth+1)
+				els, any = append(bels, els...), any || bany
+			}
+			addSeq(ext.Sequence)
+			addChoice(ext.Choice)
+		}
+		if cc.Restriction != nil {
+			// Restrictions, such as SOAP encoded arrays, are not modeled.
+			any = true
+		}
+	}
+	return els, any
+}
+
+func (v *validator) complexContent(d *xml.Decoder, ct *ComplexType, path string) error {
+	if sc := ct.SimpleContent; sc != nil {
+		text, err := v.text(d, path)
+		if err != nil {
+			return err
+		}
+		if sc.Extension != nil {
+			v.simpleValue(localName(sc.Extension.Base), text, path, 0)
+		}
+		return nil
+	}
+	els, any := v.particles(ct, 0)
+	counts := make(map[*Element]int)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			p := path + "/" + t.Name.Local
+			var el *Element
+			for _, e := range els {
+				if e.Name == t.Name.Local || (e.Name == "" && localName(e.Ref) == t.Name.Local) {
+					el = e
+					break
+				}
+			}
+			if el == nil {
+				if !any {
+					v.fail(p, "unexpected element")
+				}
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			counts[el]++
+			if max := maxOccurs(el.Max); counts[el] == max+1 {
+				v.fail(p, "element occurs more than maxOccurs=%s times", el.Max)
+			}
+			if err := v.element(d, t, el, p); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			for _, e := range els {
+				if counts[e] < e.Min {
+					name := e.Name
+					if name == "" {
+						name = localName(e.Ref)
+					}
+					v.fail(path+"/"+name, "element occurs fewer than minOccurs=%d times", e.Min)
+				}
+			}
+			return nil
+		}
+	}
+}
+
+func maxOccurs(max string) int {
+	switch max {
+	case "":
+		return 1
+	case "unbounded":
+		return math.MaxInt
+	}
+	n, err := strconv.Atoi(max)
+	if err != nil {
+		return math.MaxInt
+	}
+	return n
+}
+
+// simpleValue validates text against the named simple or built-in type.
+func (v *validator) simpleValue(typ, text, path string, depth int) {
+	if depth > 32 {
+		return
+	}
+	if st := v.schema.simpleType(typ); st != nil {
+		v.restriction(st, text, path, depth)
+		return
+	}
+	if err := checkBuiltin(typ, strings.TrimSpace(text)); err != nil {
+		v.fail(path, "value %q is not a valid %s", text, typ)
+	}
+}
+
+// restriction validates text against the facets of the simple type st
+// and those of its base types.
+func (v *validator) restriction(st *SimpleType, text, path string, depth int) {
+	r := st.Restriction
+	if r == nil {
+		return // unions are not checked
+	}
+	typ := st.Name
+	if typ == "" {
+		typ = "the element type"
+	}
+	if len(r.Enum) > 0 {
+		ok := false
+		for _, e := range r.Enum {
+			if e.Value == text {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			v.fail(path, "value %q is not in the enumeration of %s", text, typ)
+			return
+		}
+	}
+	base := v.builtinBase(localName(r.Base), 0)
+	value := text
+	if base != "string" {
+		value = strings.TrimSpace(text)
+	}
+	if len(r.Patterns) > 0 {
+		ok := false
+		for _, p := range r.Patterns {
+			re := facetPattern(p.Value)
+			if re == nil || re.MatchString(value) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			v.fail(path, "value %q does not match the pattern of %s", text, typ)
+		}
+	}
+	n := valueLength(base, value)
+	check := func(f *Facet, name string, fails func(limit int) bool) {
+		if f == nil {
+			return
+		}
+		if limit, err := strconv.Atoi(f.Value); err == nil && fails(limit) {
+			v.fail(path, "value %q violates %s=%s of %s", text, name, f.Value, typ)
+		}
+	}
+	check(r.Length, "length", func(limit int) bool { return n != limit })
+	check(r.MinLength, "minLength", func(limit int) bool { return n < limit })
+	check(r.MaxLength, "maxLength", func(limit int) bool { return n > limit })
+	v.simpleValue(localName(r.Base), text, path, depth+1)
+}
+
+// builtinBase returns the built-in type that the simple type typ is
+// derived from.
+func (v *validator) builtinBase(typ string, depth int) string {
+	st := v.schema.simpleType(typ)
+	if st == nil || st.Restriction == nil || depth > 32 {
+		return typ
+	}
+	return v.builtinBase(localName(st.Restriction.Base), depth+1)
+}
+
+// valueLength returns the length of value for the length facets: the
+// number of octets of binary types, and of characters of others.
+func valueLength(base, value string) int {
+	switch base {
+	case "hexBinary":
+		return len(value) / 2
+	case "base64Binary":
+		b, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+		if err != nil {
+			return len(value) * 3 / 4
+		}
+		return len(b)
+	}
+	return utf8.RuneCountInString(value)
+}
+
+// patterns caches the compiled pattern facets, or nil for patterns that
+// Go cannot compile.
+var patterns sync.Map
+
+// facetPattern returns the regular expression of the pattern facet p,
+// which must match whole values, or nil if p uses syntax beyond that of
+// package regexp, such as the \i and \c classes; such patterns are not
+// checked.
+func facetPattern(p string) *regexp.Regexp {
+	if re, ok := patterns.Load(p); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile(`^(?:` + p + `)$`)
+	if err != nil {
+		re = nil
+	}
+	patterns.Store(p, re)
+	return re
+}
+
+// checkBuiltin validates the lexical form of the XML schema built-in type
+// typ. Unknown types are accepted.
+func checkBuiltin(typ, s string) error {
+	var err error
+	switch typ {
+	case "boolean":
+		if s != "true" && s != "false" && s != "1" && s != "0" {
+			err = strconv.ErrSyntax
+		}
+	case "long", "int", "short", "byte":
+		_, err = strconv.ParseInt(s, 10, intBits[typ])
+	case "unsignedLong", "unsignedInt", "unsignedShort", "unsignedByte":
+		_, err = strconv.ParseUint(strings.TrimPrefix(s, "+"), 10, intBits[typ])
+	case "integer", "negativeInteger", "nonPositiveInteger", "nonNegativeInteger", "positiveInteger":
+		err = checkInteger(typ, s)
+	case "float", "double":
+		switch s {
+		case "INF", "-INF", "NaN":
+		default:
+			_, err = strconv.ParseFloat(s, 64)
+		}
+	case "decimal":
+		_, err = strconv.ParseFloat(s, 64)
+		if err == nil && strings.ContainsAny(s, "eE") {
+			err = strconv.ErrSyntax
+		}
+	case "date":
+		_, err = parseTimeZone("2006-01-02", s)
+	case "dateTime":
+		_, err = parseTimeZone("2006-01-02T15:04:05.999999999", s)
+	case "time":
+		_, err = parseTimeZone("15:04:05.999999999", s)
+	}
+	return err
+}
+
+// intBits are the sizes of the built-in integer types of bounded ranges.
+var intBits = map[string]int{
+	"long": 64, "int": 32, "short": 16, "byte": 8,
+	"unsignedLong": 64, "unsignedInt": 32, "unsignedShort": 16, "unsignedByte": 8,
+}
+
+// checkInteger validates s, an integer of any size, and the sign of the
+// integer type typ.
+func checkInteger(typ, s string) error {
+	x, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return strconv.ErrSyntax
+	}
+	var valid bool
+	switch sign := x.Sign(); typ {
+	case "negativeInteger":
+		valid = sign < 0
+	case "nonPositiveInteger":
+		valid = sign <= 0
+	case "nonNegativeInteger":
+		valid = sign >= 0
+	case "positiveInteger":
+		valid = sign > 0
+	default:
+		valid = true
+	}
+	if !valid {
+		return strconv.ErrRange
+	}
+	return nil
+}
+
+// parseTimeZone parses s with layout followed by an optional time zone.
+func parseTimeZone(layout, s string) (time.Time, error) {
+	for _, suffix := range []string{"Z07:00", ""} {
+		if t, err := time.Parse(layout+suffix, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, strconv.ErrSyntax
+}
+
+func localName(qname string) string {
+	if i := strings.LastIndex(qname, ":"); i >= 0 {
+		return qname[i+1:]
+	}
+	return qname
+}
@@ -0,0 +1,235 @@
+package wsdl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const validateSchema = `<xsd:schema xmlns:xsd="http://www.w3.org/2001/XMLSchema" xmlns:tns="urn:shop" targetNamespace="urn:shop">
+<xsd:simpleType name="Color">
+  <xsd:restriction base="xsd:string">
+    <xsd:enumeration value="red"/>
+    <xsd:enumeration value="blue"/>
+  </xsd:restriction>
+</xsd:simpleType>
+<xsd:complexType name="Item">
+  <xsd:sequence>
+    <xsd:element name="name" type="xsd:string" minOccurs="1"/>
+    <xsd:element name="price" type="xsd:decimal"/>
+    <xsd:element name="color" type="tns:Color" minOccurs="0"/>
+    <xsd:element name="note" type="xsd:string" minOccurs="0" nillable="true"/>
+  </xsd:sequence>
+</xsd:complexType>
+<xsd:complexType name="Book">
+  <xsd:complexContent>
+    <xsd:extension base="tns:Item">
+      <xsd:sequence>
+        <xsd:element name="isbn" type="xsd:string"/>
+      </xsd:sequence>
+    </xsd:extension>
+  </xsd:complexContent>
+</xsd:complexType>
+<xsd:element name="OrderResponse">
+  <xsd:complexType>
+    <xsd:sequence>
+      <xsd:element name="id" type="xsd:int"/>
+      <xsd:element name="paid" type="xsd:boolean"/>
+      <xsd:element name="created" type="xsd:dateTime"/>
+      <xsd:element name="item" type="tns:Item" maxOccurs="2"/>
+      <xsd:element name="extra" minOccurs="0"/>
+    </xsd:sequence>
+  </xsd:complexType>
+</xsd:element>
+</xsd:schema>`
+
+func TestValidate(t *testing.T) {
+	s, err := ParseSchema(strings.NewReader(validateSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		Doc  string
+		Want []string
+	}{
+		{
+			Doc: `<OrderResponse xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+<id>1</id><paid>true</paid><created>2024-01-02T03:04:05Z</created>
+<item><name>pen</name><price>1.50</price><color>red</color><note xsi:nil="true"/></item>
+<item xsi:type="Book"><name>Go</name><price>30</price><isbn>123</isbn></item>
+<extra><anything/></extra>
+</OrderResponse>`,
+		},
+		{
+			Doc: `<OrderResponse>
+<id>one</id><paid>yes</paid><created>yesterday</created>
+<item><price>1e3</price><color>green</color><size>L</size></item>
+<item><name>a</name><price>1</price></item>
+<item><name>b</name><price>2</price></item>
+</OrderResponse>`,
+			Want: []string{
+				`/OrderResponse/id: value "one" is not a valid int`,
+				`/OrderResponse/paid: value "yes" is not a valid boolean`,
+				`/OrderResponse/created: value "yesterday" is not a valid dateTime`,
+				`/OrderResponse/item/price: value "1e3" is not a valid decimal`,
+				`/OrderResponse/item/color: value "green" is not in the enumeration of Color`,
+				`/OrderResponse/item/size: unexpected element`,
+				`/OrderResponse/item/name: element occurs fewer than minOccurs=1 times`,
+				`/OrderResponse/item: element occurs more than maxOccurs=2 times`,
+			},
+		},
+		{
+			Doc:  `<Unknown/>`,
+			Want: []string{`/Unknown: element is not declared in the schema`},
+		},
+	}
+	for i, tc := range cases {
+		err := s.Validate(strings.NewReader(tc.Doc))
+		var have []string
+		var verrs ValidationErrors
+		if errors.As(err, &verrs) {
+			for _, e := range verrs {
+				have = append(have, e.Error())
+			}
+		} else if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(have, tc.Want) {
+			t.Errorf("test %d: unexpected errors\nwant: %q\nhave: %q", i, tc.Want, have)
+		}
+	}
+}
+
+func TestValidateFacets(t *testing.T) {
+	s, err := ParseSchema(strings.NewReader(`<xsd:schema xmlns:xsd="http://www.w3.org/2001/XMLSchema" xmlns:tns="urn:shop">
+<xsd:simpleType name="SKU">
+  <xsd:restriction base="xsd:string">
+    <xsd:pattern value="[A-Z]{3}-\d+"/>
+    <xsd:maxLength value="8"/>
+  </xsd:restriction>
+</xsd:simpleType>
+<xsd:simpleType name="ShortSKU">
+  <xsd:restriction base="tns:SKU">
+    <xsd:minLength value="5"/>
+  </xsd:restriction>
+</xsd:simpleType>
+<xsd:element name="Order">
+  <xsd:complexType>
+    <xsd:sequence>
+      <xsd:element name="sku" type="tns:ShortSKU"/>
+      <xsd:element name="zip">
+        <xsd:simpleType>
+          <xsd:restriction base="xsd:string">
+            <xsd:length value="4"/>
+          </xsd:restriction>
+        </xsd:simpleType>
+      </xsd:element>
+      <xsd:element name="hash" minOccurs="0">
+        <xsd:simpleType>
+          <xsd:restriction base="xsd:hexBinary">
+            <xsd:length value="2"/>
+          </xsd:restriction>
+        </xsd:simpleType>
+      </xsd:element>
+      <xsd:element name="name" minOccurs="0">
+        <xsd:simpleType>
+          <xsd:restriction base="xsd:string">
+            <xsd:pattern value="\i\c*"/>
+          </xsd:restriction>
+        </xsd:simpleType>
+      </xsd:element>
+    </xsd:sequence>
+  </xsd:complexType>
+</xsd:element>
+</xsd:schema>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		Doc  string
+		Want []string
+	}{
+		{
+			Doc: `<Order><sku>ABC-12</sku><zip>8000</zip><hash>beef</hash><name>x</name></Order>`,
+		},
+		{
+			Doc: `<Order><sku>AB-1</sku><zip>800</zip><hash>be</hash></Order>`,
+			Want: []string{
+				`/Order/sku: value "AB-1" violates minLength=5 of ShortSKU`,
+				`/Order/sku: value "AB-1" does not match the pattern of SKU`,
+				`/Order/zip: value "800" violates length=4 of the element type`,
+				`/Order/hash: value "be" violates length=2 of the element type`,
+			},
+		},
+		{
+			Doc:  `<Order><sku>ABC-123456</sku><zip>8000</zip></Order>`,
+			Want: []string{`/Order/sku: value "ABC-123456" violates maxLength=8 of SKU`},
+		},
+	}
+	// The schema embedded by the generator is marshaled from the parsed
+	// one, which must keep the facets.
+	b, err := xml.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	embedded, err := ParseSchema(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tc := range cases {
+		err := s.Validate(strings.NewReader(tc.Doc))
+		var have []string
+		var verrs ValidationErrors
+		if errors.As(err, &verrs) {
+			for _, e := range verrs {
+				have = append(have, e.Error())
+			}
+		} else if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(have, tc.Want) {
+			t.Errorf("test %d: unexpected errors\nwant: %q\nhave: %q", i, tc.Want, have)
+		}
+		if err2 := embedded.Validate(strings.NewReader(tc.Doc)); fmt.Sprint(err2) != fmt.Sprint(err) {
+			t.Errorf("test %d: embedded schema: %v, want %v", i, err2, err)
+		}
+	}
+}
+
+func TestCheckBuiltin(t *testing.T) {
+	cases := []struct {
+		Type, Value string
+		Valid       bool
+	}{
+		{"integer", "-123456789012345678901234567890", true},
+		{"integer", "+12", true},
+		{"integer", "1.0", false},
+		{"integer", "1_000", false},
+		{"nonNegativeInteger", "123456789012345678901234567890", true},
+		{"nonNegativeInteger", "0", true},
+		{"nonNegativeInteger", "-1", false},
+		{"positiveInteger", "+98765432109876543210", true},
+		{"positiveInteger", "0", false},
+		{"negativeInteger", "-98765432109876543210", true},
+		{"negativeInteger", "0", false},
+		{"nonPositiveInteger", "0", true},
+		{"nonPositiveInteger", "1", false},
+		{"long", "9223372036854775807", true},
+		{"long", "9223372036854775808", false},
+		{"unsignedLong", "18446744073709551615", true},
+		{"unsignedLong", "18446744073709551616", false},
+		{"int", "2147483648", false},
+		{"unsignedByte", "256", false},
+	}
+	for _, tc := range cases {
+		if err := checkBuiltin(tc.Type, tc.Value); (err == nil) != tc.Valid {
+			t.Errorf("%s %q: want valid %v, have %v", tc.Type, tc.Value, tc.Valid, err)
+		}
+	}
+}
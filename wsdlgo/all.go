@@ -0,0 +1,16 @@
+package wsdlgo
+
+import "github.com/YapealAG/wsdl2go/wsdl"
+
+// allElements returns the field elements of the elements els of xs:all,
+// which occur at most once, in any order: encoding/xml decodes the
+// fields of a struct in the order of the document.
+func allElements(els []*wsdl.Element) []*wsdl.Element {
+	v := make([]*wsdl.Element, len(els))
+	for i, el := range els {
+		e := *el
+		e.Max = ""
+		v[i] = &e
+	}
+	return v
+}
@@ -0,0 +1,49 @@
+package wsdlgo
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+// typeAnys returns the xsd:any wildcards of the struct of ct, those of
+// the types it extends first.
+func (ge *goEncoder) typeAnys(ct *wsdl.ComplexType) []*wsdl.AnyElement {
+	var anys []*wsdl.AnyElement
+	seqAnys := func(seq *wsdl.Sequence, choice *wsdl.Choice) {
+		if seq != nil {
+			anys = append(anys, seq.Any...)
+			for _, c := range seq.Choices {
+				anys = append(anys, c.Any...)
+			}
+		}
+		if choice != nil {
+			anys = append(anys, choice.Any...)
+		}
+	}
+	if cc := ct.ComplexContent; cc != nil && cc.Extension != nil {
+		if base, ok := ge.complexType(trimns(cc.Extension.Base)); ok && base != ct {
+			anys = append(anys, ge.typeAnys(base)...)
+		}
+		seqAnys(cc.Extension.Sequence, cc.Extension.Choice)
+	}
+	seqAnys(ct.Sequence, ct.Choice)
+	return anys
+}
+
+// genAnyField writes, for the complex types with xsd:any wildcards, the
+// Any field keeping the elements they match as soap.RawXML: a slice
+// unless the type has a single wildcard of at most one element.
+func (ge *goEncoder) genAnyField(w io.Writer, ct *wsdl.ComplexType) {
+	anys := ge.typeAnys(ct)
+	if len(anys) == 0 {
+		return
+	}
+	ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+	typ := "[]soap.RawXML"
+	if len(anys) == 1 && (anys[0].Max == "" || anys[0].Max == "1") {
+		typ = "*soap.RawXML"
+	}
+	fmt.Fprintf(w, "Any %s `xml:\",any\" json:\"-\" yaml:\"-\"`\n", typ)
+}
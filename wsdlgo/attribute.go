@@ -0,0 +1,197 @@
+package wsdlgo
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+// cacheAttributes caches the global attributes and attribute groups of
+// s, which attributes and attribute groups of complex types refer to.
+func (ge *goEncoder) cacheAttributes(s *wsdl.Schema) {
+	ge.attributes = make(map[string]*wsdl.Attribute)
+	for _, attr := range s.Attributes {
+		ge.attributes[attr.Name] = attr
+	}
+	ge.attributeGroups = make(map[string]*wsdl.AttributeGroup)
+	for _, g := range s.AttributeGroups {
+		ge.attributeGroups[g.Name] = g
+	}
+}
+
+// attributeType returns the XSD type of attr, that of the global
+// attribute it refers to, string if it has none.
+func (ge *goEncoder) attributeType(attr *wsdl.Attribute) string {
+	typ := attr.Type
+	if attr.Name == "" && attr.Ref != "" {
+		if ref, ok := ge.attributes[trimns(attr.Ref)]; ok {
+			typ = ref.Type
+		}
+	}
+	if typ == "" {
+		return "string"
+	}
+	return typ
+}
+
+// attributeList returns the attributes attrs followed by those of the
+// attribute groups groups, those of the groups they refer to included.
+func (ge *goEncoder) attributeList(attrs []*wsdl.Attribute, groups []*wsdl.AttributeGroup) []*wsdl.Attribute {
+	list := append([]*wsdl.Attribute(nil), attrs...)
+	seen := make(map[string]bool)
+	var expand func(groups []*wsdl.AttributeGroup)
+	expand = func(groups []*wsdl.AttributeGroup) {
+		for _, g := range groups {
+			if g.Ref != "" {
+				name := trimns(g.Ref)
+				ref, ok := ge.attributeGroups[name]
+				if !ok || seen[name] {
+					continue
+				}
+				seen[name] = true
+				g = ref
+			}
+			list = append(list, g.Attributes...)
+			expand(g.AttributeGroups)
+		}
+	}
+	expand(groups)
+	return list
+}
+
+// typeAttributes returns the attributes of the struct of ct, those of
+// the types it extends first.
+func (ge *goEncoder) typeAttributes(ct *wsdl.ComplexType) []*wsdl.Attribute {
+	var attrs []*wsdl.Attribute
+	for _, content := range []*wsdl.Extension{extension(ct.ComplexContent), simpleExtension(ct.SimpleContent)} {
+		if content == nil {
+			continue
+		}
+		if base, ok := ge.complexType(trimns(content.Base)); ok && base != ct {
+			attrs = append(attrs, ge.typeAttributes(base)...)
+		}
+		attrs = append(attrs, ge.attributeList(content.Attributes, content.AttributeGroups)...)
+	}
+	return append(attrs, ge.attributeList(ct.Attributes, ct.AttributeGroups)...)
+}
+
+// extension returns the extension of cc, if any.
+func extension(cc *wsdl.ComplexContent) *wsdl.Extension {
+	if cc == nil {
+		return nil
+	}
+	return cc.Extension
+}
+
+// simpleExtension returns the extension of sc, if any.
+func simpleExtension(sc *wsdl.SimpleContent) *wsdl.Extension {
+	if sc == nil {
+		return nil
+	}
+	return sc.Extension
+}
+
+// requiredAttributeChecks returns the checks of Validate of the struct
+// of ct that its required attributes are set: those of string, binary
+// and QName types, whose zero value tells they are missing.
+func (ge *goEncoder) requiredAttributeChecks(ct *wsdl.ComplexType) []string {
+	var checks []string
+	typ := goSymbol(ct.Name)
+	for _, attr := range ge.typeAttributes(ct) {
+		if attr.Use != "required" {
+			continue
+		}
+		f := ge.attributeField(attr, "")
+		var missing string
+		switch {
+		case binaryType(f.typ):
+			missing = "len(v." + f.name + ") == 0"
+		case f.typ == "soap.QName":
+			missing = "v." + f.name + `.Local == ""`
+		case ge.stringType(ge.attributeType(attr)):
+			missing = "v." + f.name + ` == ""`
+		default:
+			continue
+		}
+		name := strings.TrimSuffix(f.tag, ",attr")
+		checks = append(checks, fmt.Sprintf("if %s {\nreturn errors.New(%q)\n}\n",
+			missing, typ+": missing required attribute "+name))
+	}
+	return checks
+}
+
+// stringType returns whether the Go type of the XSD type t is a string.
+func (ge *goEncoder) stringType(t string) bool {
+	if st, ok := ge.stypes[trimns(t)]; ok {
+		return st.Restriction != nil && trimns(st.Restriction.Base) != trimns(t) && ge.stringType(st.Restriction.Base)
+	}
+	switch ge.wsdl2goType(t) {
+	case "string":
+		return true
+	}
+	return false
+}
+
+// typeAnyAttribute returns whether the struct of ct has attributes of
+// undefined names, of its xs:anyAttribute or those of the types it
+// extends or the attribute groups it refers to.
+func (ge *goEncoder) typeAnyAttribute(ct *wsdl.ComplexType) bool {
+	for _, content := range []*wsdl.Extension{extension(ct.ComplexContent), simpleExtension(ct.SimpleContent)} {
+		if content == nil {
+			continue
+		}
+		if base, ok := ge.complexType(trimns(content.Base)); ok && base != ct && ge.typeAnyAttribute(base) {
+			return true
+		}
+		if content.AnyAttribute != nil || ge.groupsAnyAttribute(content.AttributeGroups, map[string]bool{}) {
+			return true
+		}
+	}
+	return ct.AnyAttribute != nil || ge.groupsAnyAttribute(ct.AttributeGroups, map[string]bool{})
+}
+
+// groupsAnyAttribute returns whether the attribute groups groups, or
+// those they refer to, have an xs:anyAttribute.
+func (ge *goEncoder) groupsAnyAttribute(groups []*wsdl.AttributeGroup, seen map[string]bool) bool {
+	for _, g := range groups {
+		if g.Ref != "" {
+			name := trimns(g.Ref)
+			ref, ok := ge.attributeGroups[name]
+			if !ok || seen[name] {
+				continue
+			}
+			seen[name] = true
+			g = ref
+		}
+		if g.AnyAttribute != nil || ge.groupsAnyAttribute(g.AttributeGroups, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// genAnyAttrField writes, for the complex types with attributes of
+// undefined names, the AnyAttr field keeping them.
+func (ge *goEncoder) genAnyAttrField(w io.Writer, ct *wsdl.ComplexType) {
+	if !ge.typeAnyAttribute(ct) {
+		return
+	}
+	ge.needsStdPkg["encoding/xml"] = true
+	fmt.Fprint(w, "AnyAttr []xml.Attr `xml:\",any,attr\" json:\"-\" yaml:\"-\"`\n")
+}
+
+// genValueField writes the field of the character data of the struct of
+// ct, of simple content of the type base: Value, or Content if one of
+// the attributes of ct is named so.
+func (ge *goEncoder) genValueField(w io.Writer, ct *wsdl.ComplexType, base string) {
+	name := "Value"
+	for _, attr := range ge.typeAttributes(ct) {
+		if f := ge.attributeField(attr, ""); f != nil && f.name == name {
+			name = "Content"
+		}
+	}
+	typ := strings.TrimPrefix(ge.wsdl2goType(base), "*")
+	fmt.Fprintf(w, "%s %s `xml:\",chardata\" json:\"%s\" yaml:\"%s\"`\n", name, typ, name, name)
+}
@@ -0,0 +1,224 @@
+package wsdlgo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+// Batch generates the packages of several WSDLs at once. The schema
+// types they have in common, of the same namespace, name and definition,
+// are generated once in a shared package which the packages of the
+// WSDLs refer to, instead of conflicting copies in each package.
+//
+// Types of the messages of operations are not shared, as their XML name
+// depends on the WSDL, nor types referring to types that are not shared.
+type Batch struct {
+	shared   *nsPackage
+	encoders []*goEncoder
+	defs     []*wsdl.Definitions
+}
+
+// NewBatch returns a Batch generating the shared types in the package
+// of importPath.
+func NewBatch(importPath string) *Batch {
+	return &Batch{shared: &nsPackage{
+		path:   importPath,
+		name:   goPackageName(path.Base(importPath)),
+		shared: true,
+	}}
+}
+
+// Add adds the WSDL d, generated by enc, an Encoder of NewEncoder with
+// the options of the WSDL, such as SetLocation.
+func (b *Batch) Add(enc Encoder, d *wsdl.Definitions) error {
+	ge, ok := enc.(*goEncoder)
+	if !ok {
+		return errors.New("batch encoders must be created by NewEncoder")
+	}
+	b.encoders = append(b.encoders, ge)
+	b.defs = append(b.defs, d)
+	return nil
+}
+
+// EncodeDir generates the package of each WSDL in the directory named
+// after its package in root, as with Encoder.EncodeDir, and the shared
+// types in the directory named after the shared package.
+func (b *Batch) EncodeDir(root string) error {
+	dirs := make(map[string]bool)
+	for i, ge := range b.encoders {
+		if err := ge.load(b.defs[i]); err != nil {
+			return err
+		}
+		if ge.packageName == nil {
+			ge.packageName = BindingPackageName(b.defs[i].Binding)
+		}
+		name := ge.packageName.String()
+		if dirs[name] || name == b.shared.name {
+			return fmt.Errorf("WSDLs generated in the same package %s", name)
+		}
+		dirs[name] = true
+	}
+	shared := b.sharedTypes()
+	for {
+		unshared, err := b.encodeShared(root, shared)
+		if err != nil {
+			return err
+		}
+		if len(unshared) == 0 {
+			break
+		}
+		for name := range unshared {
+			delete(shared, name)
+		}
+	}
+	for i, ge := range b.encoders {
+		ge.pkg, ge.owned = nil, nil
+		if err := ge.EncodeDir(b.defs[i], filepath.Join(root, ge.packageName.String())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batchType is the definition of a type in a WSDL of a Batch.
+type batchType struct {
+	namespace string
+	def       any
+	count     int
+	conflict  bool
+}
+
+// sharedTypes returns the names of the types that more than one WSDL
+// has, with the same definition.
+func (b *Batch) sharedTypes() map[string]bool {
+	types := make(map[string]*batchType)
+	add := func(ge *goEncoder, name string, def any, excluded map[string]bool) {
+		ns := ge.typeNamespaces[name]
+		t, ok := types[name]
+		switch {
+		case !ok:
+			types[name] = &batchType{namespace: ns, def: def, count: 1, conflict: excluded[name]}
+		case t.namespace != ns || !reflect.DeepEqual(t.def, def) || excluded[name]:
+			t.conflict = true
+		default:
+			t.count++
+		}
+	}
+	for _, ge := range b.encoders {
+		excluded := ge.messageTypes()
+		for name, st := range ge.stypes {
+			add(ge, name, st, excluded)
+		}
+		for _, ct := range ge.ctypes {
+			add(ge, ct.Name, ct, excluded)
+		}
+	}
+	shared := make(map[string]bool)
+	for name, t := range types {
+		if t.count > 1 && !t.conflict {
+			shared[name] = true
+		}
+	}
+	return shared
+}
+
+// messageTypes returns the names of the types of the parts of the
+// messages of the WSDL, and of the types of other packages.
+func (ge *goEncoder) messageTypes() map[string]bool {
+	types := make(map[string]bool)
+	for _, m := range ge.messages {
+		for _, p := range m.Parts {
+			if p.Type != "" {
+				types[trimns(p.Type)] = true
+			}
+			if p.Element != "" {
+				name := trimns(p.Element)
+				types[name] = true
+				if el, ok := ge.elements[name]; ok && el.Type != "" {
+					types[trimns(el.Type)] = true
+				}
+			}
+		}
+	}
+	for name := range ge.typeNamespaces {
+		if ge.typePackage(name) != nil {
+			types[name] = true
+		}
+	}
+	return types
+}
+
+// encodeShared generates the shared package of the types shared in the
+// directory named after it in root, each by the first WSDL that has it.
+// It returns the shared types that refer to types of the WSDLs instead,
+// and must not be shared.
+func (b *Batch) encodeShared(root string, shared map[string]bool) (map[string]bool, error) {
+	w := &goEncoder{
+		packageName: PackageName(b.shared.name),
+		needsStdPkg: make(map[string]bool),
+		needsExtPkg: make(map[string]bool),
+		files:       make(map[string]*bytes.Buffer),
+	}
+	unshared := make(map[string]bool)
+	written := make(map[string]bool)
+	for i, ge := range b.encoders {
+		ge.typePackages = make(map[string]*nsPackage)
+		ge.owned = make(map[string]bool)
+		for name := range shared {
+			ge.typePackages[name] = b.shared
+			if !written[name] && ge.hasType(name) {
+				written[name] = true
+				ge.owned[name] = true
+			}
+		}
+		ge.pkg, ge.unshared = b.shared, make(map[string]bool)
+		ge.files = make(map[string]*bytes.Buffer)
+		if err := ge.writeGoTypes(ge.section(nil, "types.go"), b.defs[i]); err != nil {
+			return nil, err
+		}
+		for name := range ge.unshared {
+			unshared[name] = true
+		}
+		names := make([]string, 0, len(ge.files))
+		for name := range ge.files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			w.section(nil, name).Write(ge.files[name].Bytes())
+		}
+		for pkg := range ge.needsStdPkg {
+			w.needsStdPkg[pkg] = true
+		}
+		for pkg := range ge.needsExtPkg {
+			w.needsExtPkg[pkg] = true
+		}
+		if w.nsPackages == nil {
+			w.nsPackages = ge.nsPackages
+		}
+	}
+	if len(unshared) > 0 || len(shared) == 0 {
+		return unshared, nil
+	}
+	return nil, w.writeFiles(filepath.Join(root, b.shared.name))
+}
+
+// hasType returns whether the WSDL has the type name.
+func (ge *goEncoder) hasType(name string) bool {
+	if _, ok := ge.stypes[name]; ok {
+		return true
+	}
+	for _, ct := range ge.ctypes {
+		if ct.Name == name {
+			return true
+		}
+	}
+	return false
+}
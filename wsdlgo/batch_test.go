@@ -0,0 +1,37 @@
+package wsdlgo
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	// orders.wsdl and invoices.wsdl have the types of common.xsd, which
+	// are shared, Item and Line of their own namespaces, and Tag of
+	// their own tags.xsd, referring to a Kind of different enumerations.
+	root := t.TempDir()
+	b := NewBatch("example.com/gen/common")
+	for _, name := range []string{"orders.wsdl", "invoices.wsdl"} {
+		enc := NewEncoder(nil)
+		enc.SetLocation(filepath.Join("testdata", "batch", name))
+		if err := b.Add(enc, LoadDefinition(t, filepath.Join("batch", name), nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.EncodeDir(root); err != nil {
+		t.Fatal(err)
+	}
+	for _, pkg := range []string{"common", "orderssoap", "invoicessoap"} {
+		compareDir(t, filepath.Join(root, pkg), filepath.Join("testdata", "batch", pkg))
+	}
+
+	b = NewBatch("example.com/gen/common")
+	for i := 0; i < 2; i++ {
+		if err := b.Add(NewEncoder(nil), LoadDefinition(t, filepath.Join("batch", "orders.wsdl"), nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.EncodeDir(t.TempDir()); err == nil {
+		t.Fatal("WSDLs generated in the same package")
+	}
+}
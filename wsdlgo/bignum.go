@@ -0,0 +1,23 @@
+package wsdlgo
+
+import "strings"
+
+// bigTypes are the soap types of arbitrary precision of the decimal and
+// unbounded integer types of XML schemas, of their names in lower case.
+var bigTypes = map[string]string{
+	"decimal":            "Decimal",
+	"integer":            "Integer",
+	"nonnegativeinteger": "Integer",
+	"positiveinteger":    "Integer",
+	"nonpositiveinteger": "Integer",
+	"negativeinteger":    "Integer",
+}
+
+// bigType returns the soap type of arbitrary precision of the built-in
+// XSD type t if SetBigNumbers is enabled, or "".
+func (ge *goEncoder) bigType(t string) string {
+	if !ge.bigNumbers {
+		return ""
+	}
+	return bigTypes[strings.ToLower(t)]
+}
@@ -0,0 +1,47 @@
+package wsdlgo
+
+import (
+	"io"
+	"strings"
+	"text/template"
+)
+
+var binaryT = template.Must(template.New("binary").Parse(`// MarshalText implements the encoding.TextMarshaler interface.
+func (v {{.Name}}) MarshalText() ([]byte, error) {
+	return {{.Type}}(v).MarshalText()
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (v *{{.Name}}) UnmarshalText(text []byte) error {
+	return (*{{.Type}})(v).UnmarshalText(text)
+}
+
+`))
+
+// binaryType tells whether the Go type typ is a byte slice of a binary
+// XSD type, omitted when empty like []byte.
+func binaryType(typ string) bool {
+	switch strings.TrimPrefix(typ, "*") {
+	case "soap.Base64Binary", "soap.HexBinary", "soap.Binary":
+		return true
+	}
+	return false
+}
+
+// genBinaryMethods writes the text methods of the simple type name
+// restricting the binary type base, encoded as base in the text of
+// elements and attributes, whose methods a defined type of it would not
+// have.
+func (ge *goEncoder) genBinaryMethods(w io.Writer, name, base string) {
+	typ := ge.wsdl2goType(ge.builtinBase(base))
+	if typ != "soap.Base64Binary" && typ != "soap.HexBinary" {
+		return
+	}
+	binaryT.Execute(w, &struct{ Name, Type string }{name, typ})
+}
+
+// SetStreamBinary enables soap.Base64Stream fields for base64Binary
+// elements.
+func (ge *goEncoder) SetStreamBinary(stream bool) {
+	ge.streamBinary = stream
+}
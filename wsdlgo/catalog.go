@@ -0,0 +1,143 @@
+package wsdlgo
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Catalog maps the locations of imported documents to local copies, so
+// that WSDLs importing unreachable or slow remote resources can be
+// generated from a mirror, e.g. in air-gapped builds. It is read from an
+// OASIS XML catalog, whose uri and system entries map a location, and
+// rewriteURI and rewriteSystem entries a location prefix:
+//
+//	<catalog xmlns="urn:oasis:names:tc:entity:xmlns:xml:catalog">
+//		<uri name="http://example.com/types.xsd" uri="mirror/types.xsd"/>
+//		<rewriteURI uriStartString="http://example.com/schemas/" rewritePrefix="mirror/schemas/"/>
+//	</catalog>
+type Catalog struct {
+	uris     map[string]string
+	rewrites []catalogRewrite
+}
+
+// catalogRewrite maps the locations starting with prefix.
+type catalogRewrite struct {
+	prefix string
+	to     string
+}
+
+// catalogEntry is an entry of an OASIS XML catalog, or the catalog or a
+// group of entries.
+type catalogEntry struct {
+	XMLName        xml.Name
+	Name           string         `xml:"name,attr"`
+	SystemID       string         `xml:"systemId,attr"`
+	URI            string         `xml:"uri,attr"`
+	URIStartString string         `xml:"uriStartString,attr"`
+	SystemIDStart  string         `xml:"systemIdStartString,attr"`
+	RewritePrefix  string         `xml:"rewritePrefix,attr"`
+	Base           string         `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Entries        []catalogEntry `xml:",any"`
+}
+
+// NewCatalog returns an empty Catalog.
+func NewCatalog() *Catalog {
+	return &Catalog{uris: make(map[string]string)}
+}
+
+// ParseCatalog reads the OASIS XML catalog r. Relative locations in it
+// are relative to base, usually the directory of the catalog file.
+func ParseCatalog(r io.Reader, base string) (*Catalog, error) {
+	var doc catalogEntry
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	c := NewCatalog()
+	c.add(&doc, base)
+	return c, nil
+}
+
+// add adds the entry e of a catalog, relative to base, and the entries
+// it groups.
+func (c *Catalog) add(e *catalogEntry, base string) {
+	if e.Base != "" {
+		base = catalogPath(base, e.Base)
+	}
+	switch e.XMLName.Local {
+	case "uri":
+		c.Map(e.Name, catalogPath(base, e.URI))
+	case "system":
+		c.Map(e.SystemID, catalogPath(base, e.URI))
+	case "rewriteURI":
+		c.Rewrite(e.URIStartString, catalogPath(base, e.RewritePrefix))
+	case "rewriteSystem":
+		c.Rewrite(e.SystemIDStart, catalogPath(base, e.RewritePrefix))
+	}
+	for i := range e.Entries {
+		c.add(&e.Entries[i], base)
+	}
+}
+
+// LoadCatalog reads the OASIS XML catalog file name.
+func LoadCatalog(name string) (*Catalog, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseCatalog(f, filepath.Dir(name))
+}
+
+// catalogPath returns the location loc of a catalog entry, relative to
+// dir unless it is absolute or a URL.
+func catalogPath(dir, loc string) string {
+	if loc == "" || dir == "" || filepath.IsAbs(loc) || strings.Contains(loc, "://") {
+		return loc
+	}
+	// Keep the trailing slash of rewrite prefixes.
+	p := filepath.Join(dir, filepath.FromSlash(loc))
+	if strings.HasSuffix(loc, "/") {
+		p += string(filepath.Separator)
+	}
+	return p
+}
+
+// Map maps the location uri to the file or URL location.
+func (c *Catalog) Map(uri, location string) {
+	if uri != "" {
+		c.uris[uri] = location
+	}
+}
+
+// Rewrite maps the locations starting with prefix to those starting
+// with location instead.
+func (c *Catalog) Rewrite(prefix, location string) {
+	if prefix != "" {
+		c.rewrites = append(c.rewrites, catalogRewrite{prefix, location})
+	}
+}
+
+// Resolve returns the location uri is mapped to, and whether it is.
+// Locations mapped by Map take precedence over rewrites, of which the
+// one with the longest prefix applies.
+func (c *Catalog) Resolve(uri string) (string, bool) {
+	if c == nil {
+		return uri, false
+	}
+	if loc, ok := c.uris[uri]; ok {
+		return loc, true
+	}
+	var best *catalogRewrite
+	for i, rw := range c.rewrites {
+		if strings.HasPrefix(uri, rw.prefix) && (best == nil || len(rw.prefix) > len(best.prefix)) {
+			best = &c.rewrites[i]
+		}
+	}
+	if best == nil {
+		return uri, false
+	}
+	return best.to + uri[len(best.prefix):], true
+}
@@ -0,0 +1,54 @@
+package wsdlgo
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCatalogResolve(t *testing.T) {
+	c, err := ParseCatalog(strings.NewReader(`<catalog xmlns="urn:oasis:names:tc:entity:xmlns:xml:catalog">
+	<uri name="http://example.com/types.xsd" uri="types.xsd"/>
+	<system systemId="http://example.com/legacy.wsdl" uri="/srv/legacy.wsdl"/>
+	<rewriteURI uriStartString="http://example.com/" rewritePrefix="example/"/>
+	<rewriteURI uriStartString="http://example.com/schemas/" rewritePrefix="http://mirror/schemas/"/>
+	<group xml:base="other">
+		<uri name="http://other.com/a.xsd" uri="a.xsd"/>
+	</group>
+</catalog>`), "mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		uri    string
+		want   string
+		mapped bool
+	}{
+		{"http://example.com/types.xsd", filepath.Join("mirror", "types.xsd"), true},
+		{"http://example.com/legacy.wsdl", "/srv/legacy.wsdl", true},
+		{"http://example.com/x/y.xsd", filepath.Join("mirror", "example") + string(filepath.Separator) + "x/y.xsd", true},
+		{"http://example.com/schemas/s.xsd", "http://mirror/schemas/s.xsd", true},
+		{"http://other.com/a.xsd", filepath.Join("mirror", "other", "a.xsd"), true},
+		{"http://unknown.com/s.xsd", "http://unknown.com/s.xsd", false},
+	}
+	for _, test := range tests {
+		have, mapped := c.Resolve(test.uri)
+		if have != test.want || mapped != test.mapped {
+			t.Errorf("%s: want %q, %v, have %q, %v", test.uri, test.want, test.mapped, have, mapped)
+		}
+	}
+	if _, mapped := (*Catalog)(nil).Resolve("http://example.com/types.xsd"); mapped {
+		t.Error("nil catalog mapped location")
+	}
+}
+
+// setCatalog sets the catalog of testdata/catalog on enc, encoding the
+// WSDL file name of that directory.
+func setCatalog(enc Encoder, name string) {
+	c, err := LoadCatalog(filepath.Join("testdata", "catalog", "catalog.xml"))
+	if err != nil {
+		panic(err)
+	}
+	enc.SetCatalog(c)
+	enc.SetLocation(filepath.Join("testdata", "catalog", name))
+}
@@ -0,0 +1,166 @@
+package wsdlgo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+// choiceElement returns the field element of the alternative el of a
+// choice: optional, and never a soap.Nillable, so that only the
+// alternatives set are marshaled.
+func choiceElement(el *wsdl.Element) *wsdl.Element {
+	v := *el
+	v.Min, v.MinSet, v.Nillable = 0, true, false
+	return &v
+}
+
+// choiceElements returns the field elements of the alternatives els.
+func choiceElements(els []*wsdl.Element) []*wsdl.Element {
+	v := make([]*wsdl.Element, len(els))
+	for i, el := range els {
+		v[i] = choiceElement(el)
+	}
+	return v
+}
+
+// typeChoices returns the choices of the struct of ct, those of the
+// types it extends first.
+func (ge *goEncoder) typeChoices(ct *wsdl.ComplexType) []*wsdl.Choice {
+	var choices []*wsdl.Choice
+	if cc := ct.ComplexContent; cc != nil && cc.Extension != nil {
+		if base, ok := ge.complexType(trimns(cc.Extension.Base)); ok && base != ct {
+			choices = append(choices, ge.typeChoices(base)...)
+		}
+		if seq := cc.Extension.Sequence; seq != nil {
+			choices = append(choices, seq.Choices...)
+		}
+		if cc.Extension.Choice != nil {
+			choices = append(choices, cc.Extension.Choice)
+		}
+	}
+	if ct.Sequence != nil {
+		choices = append(choices, ct.Sequence.Choices...)
+	}
+	if ct.Choice != nil {
+		choices = append(choices, ct.Choice)
+	}
+	return choices
+}
+
+// choiceAlternative is an alternative of a choice, set if Set.
+type choiceAlternative struct {
+	Element string
+	Set     string
+}
+
+// choiceFunc is the Which method of a choice.
+type choiceFunc struct {
+	Name         string
+	Elements     string // of the alternatives, for the error of Validate
+	Alternatives []*choiceAlternative
+}
+
+var choiceFuncsT = template.Must(template.New("choiceFuncs").Parse(`
+{{- range .Funcs}}
+// {{.Name}} returns the name of the element of the choice of {{$.Type}}
+// set in v, the first one if several are, or "" if none is.
+func (v *{{$.Type}}) {{.Name}}() string {
+	switch {
+	{{- range .Alternatives}}
+	case {{.Set}}:
+		return {{printf "%q" .Element}}
+	{{- end}}
+	}
+	return ""
+}
+{{end}}
+`))
+
+var choiceCheckT = template.Must(template.New("choiceCheck").Parse(
+	`{{range $i, $f := .Funcs}}n {{if $i}}={{else}}:={{end}} 0
+{{range .Alternatives}}if {{.Set}} {
+n++
+}
+{{end}}if n > 1 {
+return errors.New({{printf "%q" (print $.Type ": more than one of " .Elements " set")}})
+}
+{{end}}`))
+
+// typeChoiceFuncs returns the Which methods of the choices of the struct
+// of ct.
+func (ge *goEncoder) typeChoiceFuncs(ct *wsdl.ComplexType) []*choiceFunc {
+	_, ok := ge.usedNameSpaceMap[ct.TargetNamespace]
+	var ns string
+	if ok {
+		ns = ct.TargetNamespace
+	}
+	var funcs []*choiceFunc
+	for _, choice := range ge.typeChoices(ct) {
+		fn := &choiceFunc{Name: "Which"}
+		if len(funcs) > 0 {
+			fn.Name = fmt.Sprintf("Which%d", len(funcs)+1)
+		}
+		var names []string
+		for _, el := range choice.Elements {
+			f := ge.elementField(choiceElement(el), ns)
+			if f == nil {
+				continue
+			}
+			set := "v." + f.name + " != nil"
+			switch {
+			case strings.HasPrefix(f.typ, "[]"):
+				set = "len(v." + f.name + ") > 0"
+			case strings.HasPrefix(f.typ, "soap.Substitution["), strings.HasPrefix(f.typ, "soap.Derived["):
+				set = "v." + f.name + ".Value != nil"
+			case strings.HasPrefix(f.typ, "soap.Optional["):
+				set = "v." + f.name + ".Present"
+			case !strings.HasPrefix(f.typ, "*"):
+				// A value of ValueOptional, omitted when zero.
+				set = "v." + f.name + " != " + zeroValue(f.typ)
+			}
+			name := el.Name
+			if name == "" {
+				name = trimns(el.Ref)
+			}
+			fn.Alternatives = append(fn.Alternatives, &choiceAlternative{Element: name, Set: set})
+			names = append(names, name)
+		}
+		if len(fn.Alternatives) < 2 {
+			continue
+		}
+		fn.Elements = strings.Join(names, ", ")
+		funcs = append(funcs, fn)
+	}
+	return funcs
+}
+
+// genChoiceFuncs writes, for the complex types with choices, a Which
+// method returning the element set of each choice.
+func (ge *goEncoder) genChoiceFuncs(w io.Writer, ct *wsdl.ComplexType) {
+	if funcs := ge.typeChoiceFuncs(ct); len(funcs) > 0 {
+		choiceFuncsT.Execute(w, &struct {
+			Type  string
+			Funcs []*choiceFunc
+		}{goSymbol(ct.Name), funcs})
+	}
+}
+
+// choiceChecks returns the check of Validate of the struct of ct that
+// at most one alternative of each of its choices is set.
+func (ge *goEncoder) choiceChecks(ct *wsdl.ComplexType) []string {
+	funcs := ge.typeChoiceFuncs(ct)
+	if len(funcs) == 0 {
+		return nil
+	}
+	var b bytes.Buffer
+	choiceCheckT.Execute(&b, &struct {
+		Type  string
+		Funcs []*choiceFunc
+	}{goSymbol(ct.Name), funcs})
+	return []string{b.String()}
+}
@@ -0,0 +1,130 @@
+package wsdlgo
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ClientOption configures the http.Client of NewHTTPClient.
+type ClientOption func(*clientConfig) error
+
+// clientConfig is the configuration of NewHTTPClient.
+type clientConfig struct {
+	header http.Header
+	hosts  []string
+	tls    *tls.Config
+}
+
+// WithBasicAuth sends the username and password with Basic Auth.
+func WithBasicAuth(username, password string) ClientOption {
+	return func(c *clientConfig) error {
+		r, _ := http.NewRequest("GET", "/", nil)
+		r.SetBasicAuth(username, password)
+		c.header.Set("Authorization", r.Header.Get("Authorization"))
+		return nil
+	}
+}
+
+// WithBearerToken sends the OAuth2 bearer token.
+func WithBearerToken(token string) ClientOption {
+	return func(c *clientConfig) error {
+		c.header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
+
+// WithHeader sends the HTTP headers h, such as API keys.
+func WithHeader(h http.Header) ClientOption {
+	return func(c *clientConfig) error {
+		for k, v := range h {
+			c.header[http.CanonicalHeaderKey(k)] = append(c.header[http.CanonicalHeaderKey(k)], v...)
+		}
+		return nil
+	}
+}
+
+// WithAuthHosts restricts the credentials and headers of the other
+// options to requests of the hosts, such as the host of the WSDL,
+// rather than sending them to all the hosts of imported documents.
+func WithAuthHosts(hosts ...string) ClientOption {
+	return func(c *clientConfig) error {
+		c.hosts = append(c.hosts, hosts...)
+		return nil
+	}
+}
+
+// WithClientCertificate authenticates with the TLS client certificate
+// and key of the PEM files certFile and keyFile.
+func WithClientCertificate(certFile, keyFile string) ClientOption {
+	return func(c *clientConfig) error {
+		if certFile == "" || keyFile == "" {
+			return errors.New("client certificates need a certificate and a key file")
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		c.tls.Certificates = append(c.tls.Certificates, cert)
+		c.tls.Renegotiation = tls.RenegotiateFreelyAsClient
+		return nil
+	}
+}
+
+// WithInsecureTLS accepts any TLS certificate of servers.
+func WithInsecureTLS() ClientOption {
+	return func(c *clientConfig) error {
+		c.tls.InsecureSkipVerify = true
+		return nil
+	}
+}
+
+// NewHTTPClient returns an http.Client for fetching remote WSDLs and
+// their imports, e.g. for Encoder.SetClient, configured with opts.
+func NewHTTPClient(opts ...ClientOption) (*http.Client, error) {
+	c := &clientConfig{header: make(http.Header), tls: &tls.Config{}}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = c.tls
+	if len(c.header) == 0 {
+		return &http.Client{Transport: transport}, nil
+	}
+	return &http.Client{Transport: &headerTransport{transport, c.header, c.hosts}}, nil
+}
+
+// headerTransport adds header to the requests of hosts, or of all hosts.
+type headerTransport struct {
+	next   http.RoundTripper
+	header http.Header
+	hosts  []string
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *headerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if !t.authorized(r.URL.Hostname()) {
+		return t.next.RoundTrip(r)
+	}
+	r = r.Clone(r.Context())
+	for k, v := range t.header {
+		r.Header[k] = append([]string(nil), v...)
+	}
+	return t.next.RoundTrip(r)
+}
+
+// authorized returns whether the headers are sent to host.
+func (t *headerTransport) authorized(host string) bool {
+	if len(t.hosts) == 0 {
+		return true
+	}
+	for _, h := range t.hosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
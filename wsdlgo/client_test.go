@@ -0,0 +1,76 @@
+package wsdlgo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	var have http.Header
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		have = r.Header
+	}))
+	defer s.Close()
+	local := strings.Replace(s.URL, "127.0.0.1", "localhost", 1)
+
+	tests := []struct {
+		name   string
+		url    string
+		opts   []ClientOption
+		header http.Header
+	}{
+		{
+			name:   "basic auth",
+			url:    s.URL,
+			opts:   []ClientOption{WithBasicAuth("user", "pass")},
+			header: http.Header{"Authorization": {"Basic dXNlcjpwYXNz"}},
+		},
+		{
+			name:   "bearer token and headers",
+			url:    s.URL,
+			opts:   []ClientOption{WithBearerToken("t0k3n"), WithHeader(http.Header{"x-api-key": {"secret"}})},
+			header: http.Header{"Authorization": {"Bearer t0k3n"}, "X-Api-Key": {"secret"}},
+		},
+		{
+			name:   "auth host",
+			url:    s.URL,
+			opts:   []ClientOption{WithBearerToken("t0k3n"), WithAuthHosts("127.0.0.1")},
+			header: http.Header{"Authorization": {"Bearer t0k3n"}},
+		},
+		{
+			name:   "other host",
+			url:    local,
+			opts:   []ClientOption{WithBearerToken("t0k3n"), WithAuthHosts("127.0.0.1")},
+			header: http.Header{"Authorization": nil},
+		},
+	}
+	for _, test := range tests {
+		cli, err := NewHTTPClient(append(test.opts, WithInsecureTLS())...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := cli.Get(test.url)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		resp.Body.Close()
+		for k, v := range test.header {
+			if strings.Join(have[k], ",") != strings.Join(v, ",") {
+				t.Errorf("%s: want %s %q, have %q", test.name, k, v, have[k])
+			}
+		}
+	}
+
+	cli, err := NewHTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Get(s.URL); err == nil {
+		t.Error("accepted the certificate of the test server")
+	}
+	if _, err := NewHTTPClient(WithClientCertificate("cert.pem", "")); err == nil {
+		t.Error("accepted a client certificate without key")
+	}
+}
@@ -0,0 +1,30 @@
+package wsdlgo
+
+import "strings"
+
+// timeTypes are the soap types of the date, time and duration types of
+// XML schemas, of their names in lower case.
+var timeTypes = map[string]string{
+	"datetime":      "DateTime",
+	"datetimestamp": "DateTime",
+	"date":          "Date",
+	"time":          "Time",
+	"gyear":         "GYear",
+	"gyearmonth":    "GYearMonth",
+	"gmonth":        "GMonth",
+	"gmonthday":     "GMonthDay",
+	"gday":          "GDay",
+	"duration":      "Duration",
+}
+
+// restrictionType returns the Go type of the simple types restricting
+// base: that of base, or a struct embedding it if it is a soap time,
+// number or QName type, whose methods a defined type of it would not
+// have.
+func (ge *goEncoder) restrictionType(base string) string {
+	typ := ge.wsdl2goType(base)
+	if _, ok := ge.stypes[trimns(base)]; !ok && (timeTypes[strings.ToLower(trimns(base))] != "" || ge.bigType(trimns(base)) != "" || strings.EqualFold(trimns(base), "qname")) {
+		return "struct{ " + typ + " }"
+	}
+	return typ
+}
@@ -0,0 +1,159 @@
+package wsdlgo
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+// fieldValue is the default or fixed value of the field of an element or
+// attribute.
+type fieldValue struct {
+	field *structField
+	name  string // of the element or attribute
+	value string // as in the schema
+	lit   string // Go literal of value
+	zero  string // Go literal of the zero value of the field
+	fixed bool
+}
+
+// fieldValues returns the default and fixed values of the fields of the
+// struct of ct, of its own elements and of its attributes, those of
+// types other than strings, numbers and booleans excepted.
+func (ge *goEncoder) fieldValues(ct *wsdl.ComplexType) []*fieldValue {
+	var values []*fieldValue
+	add := func(f *structField, name, typ, def, fixed string) {
+		value, isFixed := def, false
+		if fixed != "" {
+			value, isFixed = fixed, true
+		}
+		if f == nil || value == "" && !isFixed {
+			return
+		}
+		lit, zero, ok := ge.literal(typ, value)
+		t := strings.TrimPrefix(f.typ, "*")
+		if v, optional := optionalValue(t); optional {
+			t = v
+		}
+		if !ok || strings.HasPrefix(t, "[]") || strings.HasPrefix(t, "soap.") {
+			return
+		}
+		values = append(values, &fieldValue{f, name, value, lit, zero, isFixed})
+	}
+	for _, el := range fieldElements(ct) {
+		decl := el
+		if el.Ref != "" {
+			ref, ok := ge.elements[trimns(el.Ref)]
+			if !ok {
+				continue
+			}
+			decl = ref
+		}
+		if decl.ComplexType != nil {
+			continue
+		}
+		typ := decl.Type
+		if typ == "" {
+			typ = "string"
+			if st := decl.SimpleType; st != nil && st.Restriction != nil {
+				typ = st.Restriction.Base
+			}
+		}
+		add(ge.elementField(el, ""), decl.Name, typ, decl.Default, decl.Fixed)
+	}
+	for _, attr := range ge.typeAttributes(ct) {
+		decl := attr
+		if attr.Name == "" && attr.Ref != "" {
+			if ref, ok := ge.attributes[trimns(attr.Ref)]; ok && attr.Default == "" && attr.Fixed == "" {
+				decl = ref
+			}
+		}
+		f := ge.attributeField(attr, "")
+		if f == nil {
+			continue
+		}
+		add(f, strings.TrimSuffix(strings.TrimSuffix(f.tag, ",omitempty"), ",attr"), ge.attributeType(attr), decl.Default, decl.Fixed)
+	}
+	return values
+}
+
+// literal returns the Go literal of the value of the simple type typ,
+// and that of its zero value, or false if typ is no string, number or
+// boolean, or value is not one of its values.
+func (ge *goEncoder) literal(typ, value string) (lit, zero string, ok bool) {
+	builtin := ge.wsdl2goType(ge.builtinBase(typ))
+	switch builtin {
+	case "string":
+		return strconv.Quote(value), `""`, true
+	case "bool":
+		switch strings.TrimSpace(value) {
+		case "true", "1":
+			return "true", "false", true
+		case "false", "0":
+			return "false", "false", true
+		}
+		return "", "", false
+	}
+	value = strings.TrimSpace(value)
+	return value, "0", constant(builtin, value)
+}
+
+// genApplyDefaults writes the ApplyDefaults method of the struct of ct,
+// if it has fields of default or fixed values, setting those omitted to
+// them.
+func (ge *goEncoder) genApplyDefaults(w io.Writer, ct *wsdl.ComplexType) {
+	if ct.Abstract {
+		return
+	}
+	var b strings.Builder
+	for _, v := range ge.fieldValues(ct) {
+		f := v.field
+		if t, ok := strings.CutPrefix(f.typ, "*"); ok {
+			fmt.Fprintf(&b, "if v.%s == nil {\nx := %s(%s)\nv.%s = &x\n}\n", f.name, t, v.lit, f.name)
+		} else if t, ok := optionalValue(f.typ); ok {
+			fmt.Fprintf(&b, "if !v.%s.Present {\nv.%s = soap.NewOptional(%s(%s))\n}\n", f.name, f.name, t, v.lit)
+		} else if v.zero == "false" && v.lit == "true" {
+			fmt.Fprintf(&b, "if !v.%s {\nv.%s = true\n}\n", f.name, f.name)
+		} else if v.lit != v.zero {
+			fmt.Fprintf(&b, "if v.%s == %s {\nv.%s = %s\n}\n", f.name, v.zero, f.name, v.lit)
+		}
+	}
+	if b.Len() == 0 {
+		return
+	}
+	name := goSymbol(ct.Name)
+	ge.writeComments(w, "ApplyDefaults", "ApplyDefaults sets the omitted fields of v, nil or zero, to the default or fixed values of their elements and attributes.")
+	fmt.Fprintf(w, "func (v *%s) ApplyDefaults() {\n%s}\n\n", name, b.String())
+}
+
+// fixedChecks returns the checks of Validate of the struct of ct that
+// the fields of fixed values set have them.
+func (ge *goEncoder) fixedChecks(ct *wsdl.ComplexType) []string {
+	var checks []string
+	typ := goSymbol(ct.Name)
+	for _, v := range ge.fieldValues(ct) {
+		if !v.fixed {
+			continue
+		}
+		f := v.field
+		var differs string
+		switch {
+		case strings.HasPrefix(f.typ, "*"):
+			differs = fmt.Sprintf("v.%s != nil && *v.%s != %s", f.name, f.name, v.lit)
+		case strings.HasPrefix(f.typ, "soap.Optional["):
+			differs = fmt.Sprintf("v.%s.Present && v.%s.Value != %s", f.name, f.name, v.lit)
+		case v.lit == v.zero:
+			differs = fmt.Sprintf("v.%s != %s", f.name, v.lit)
+		case v.zero == "false":
+			continue // false is omitted, true the fixed value
+		default:
+			differs = fmt.Sprintf("v.%s != %s && v.%s != %s", f.name, v.zero, f.name, v.lit)
+		}
+		checks = append(checks, fmt.Sprintf("if %s {\nreturn errors.New(%q)\n}\n",
+			differs, typ+": "+v.name+" differs from its fixed value "+strconv.Quote(v.value)))
+	}
+	return checks
+}
@@ -0,0 +1,160 @@
+package wsdlgo
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+// typeHierarchy is the interface of the complex types deriving from the
+// Base type by complexContent extension, and of Base unless abstract.
+type typeHierarchy struct {
+	Base      string
+	Interface string
+	Abstract  bool
+	Types     []*substitute // implementing Interface, by xsi:type
+}
+
+var typeHierarchiesT = template.Must(template.New("typeHierarchies").Parse(`{{range .Hierarchies}}
+{{- if .Abstract}}
+// {{.Interface}} is an abstract type, implemented by the types deriving
+// from it, decoded by their xsi:type in soap.Derived.
+{{- else}}
+// {{.Interface}} is the type {{.Base}} or one deriving from it, decoded
+// by its xsi:type in soap.Derived.
+{{- end}}
+type {{.Interface}} interface {
+	is{{.Interface}}()
+}
+{{$h := .}}{{range .Types}}
+func (*{{.Type}}) is{{$h.Interface}}() {}
+{{end}}{{end}}
+func init() {
+{{- range .Types}}
+	soap.RegisterType(xml.Name{Space: {{printf "%q" .Space}}, Local: {{printf "%q" .Name}}}, (*{{.Type}})(nil))
+{{- end}}
+{{- range .Hierarchies}}{{if not .Abstract}}
+	soap.RegisterBaseType[{{.Interface}}]((*{{.Base}})(nil))
+{{- end}}{{end}}
+}
+`))
+
+// cacheDerivedTypes caches the complex types extending each complex type
+// by complexContent, by base type.
+func (ge *goEncoder) cacheDerivedTypes() {
+	ge.derivedTypes = make(map[string][]*wsdl.ComplexType)
+	for _, name := range ge.sortedComplexTypes() {
+		ct := ge.ctypes[name]
+		if ext := extension(ct.ComplexContent); ext != nil && ext.Base != "" {
+			base := trimns(ext.Base)
+			if b, ok := ge.complexType(base); ok && b != ct {
+				ge.derivedTypes[b.Name] = append(ge.derivedTypes[b.Name], ct)
+			}
+		}
+	}
+}
+
+// typeHierarchy returns the hierarchy of the complex type named name, or
+// nil if no type derives from it.
+func (ge *goEncoder) typeHierarchy(name string) *typeHierarchy {
+	if h, ok := ge.typeHierarchies[name]; ok {
+		return h
+	}
+	base, ok := ge.complexType(name)
+	if !ok || len(ge.derivedTypes[base.Name]) == 0 {
+		return nil
+	}
+	h := &typeHierarchy{Base: goSymbol(base.Name), Interface: "Any" + goSymbol(base.Name), Abstract: base.Abstract}
+	if base.Abstract {
+		h.Interface = goSymbol(base.Name)
+	}
+	types := []*wsdl.ComplexType{base}
+	seen := map[*wsdl.ComplexType]bool{base: true}
+	for i := 0; i < len(types); i++ {
+		ct := types[i]
+		for _, d := range ge.derivedTypes[ct.Name] {
+			if !seen[d] {
+				seen[d] = true
+				types = append(types, d)
+			}
+		}
+		if ct.Abstract || !ge.inPackage(ct.Name) {
+			continue
+		}
+		ns := ct.TargetNamespace
+		if ns == "" {
+			ns = ge.typeNamespaces[ct.Name]
+		}
+		h.Types = append(h.Types, &substitute{Space: ns, Name: ct.Name, Type: goSymbol(ct.Name)})
+	}
+	if ge.typeHierarchies == nil {
+		ge.typeHierarchies = make(map[string]*typeHierarchy)
+	}
+	ge.typeHierarchies[name] = h
+	return h
+}
+
+// derivedField returns the Go type of the fields of elements of the type
+// t, a soap.Derived of the interface of its hierarchy, or "" if no type
+// derives from it.
+func (ge *goEncoder) derivedField(t string) string {
+	name := trimns(t)
+	h := ge.typeHierarchy(name)
+	if h == nil {
+		return ""
+	}
+	ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+	return "soap.Derived[" + ge.qualifiedType(name, h.Interface) + "]"
+}
+
+// embeddedBase returns the Go type of the base of the complexContent
+// extension ext, embedded in the struct of the extension, or "" if the
+// base is abstract, its fields then being those of the struct.
+func (ge *goEncoder) embeddedBase(ext *wsdl.Extension) string {
+	base, ok := ge.complexType(trimns(ext.Base))
+	if !ok || base.Abstract {
+		return ""
+	}
+	return strings.TrimPrefix(ge.wsdl2goType(ext.Base), "*")
+}
+
+// writeTypeHierarchies writes the interfaces of the hierarchies of the
+// complex types of the package with derived types, and registers their
+// types for their xsi:type.
+func (ge *goEncoder) writeTypeHierarchies(w io.Writer) error {
+	var bases []string
+	for base := range ge.derivedTypes {
+		if ge.inPackage(base) {
+			bases = append(bases, base)
+		}
+	}
+	sort.Strings(bases)
+	var hierarchies []*typeHierarchy
+	var types []*substitute
+	registered := make(map[string]bool)
+	for _, base := range bases {
+		h := ge.typeHierarchy(base)
+		if h == nil {
+			continue
+		}
+		hierarchies = append(hierarchies, h)
+		for _, t := range h.Types {
+			if !registered[t.Type] {
+				registered[t.Type] = true
+				types = append(types, t)
+			}
+		}
+	}
+	if len(hierarchies) == 0 {
+		return nil
+	}
+	ge.needsStdPkg["encoding/xml"] = true
+	ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+	return typeHierarchiesT.Execute(w, &struct {
+		Hierarchies []*typeHierarchy
+		Types       []*substitute
+	}{hierarchies, types})
+}
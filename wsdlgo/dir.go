@@ -0,0 +1,88 @@
+package wsdlgo
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+func (ge *goEncoder) EncodeDir(d *wsdl.Definitions, dir string) error {
+	if d == nil {
+		return nil
+	}
+	if err := ge.load(d); err != nil {
+		return err
+	}
+	if ge.packageName == nil {
+		ge.packageName = BindingPackageName(d.Binding)
+	}
+	ge.files = make(map[string]*bytes.Buffer)
+	if err := ge.encode(nil, d); err != nil {
+		return err
+	}
+	if err := ge.writeFiles(dir); err != nil {
+		return err
+	}
+	return ge.encodeNamespacePackages(d, filepath.Dir(filepath.Clean(dir)))
+}
+
+// writeFiles writes the generated files to dir.
+func (ge *goEncoder) writeFiles(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(ge.files))
+	for name, b := range ge.files {
+		if b.Len() > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var src bytes.Buffer
+		ge.writeHeader(&src, nil)
+		src.Write(ge.files[name].Bytes())
+		used, err := usedPackages(src.Bytes())
+		if err != nil {
+			return fmt.Errorf("generated bad code in %s: %v", name, err)
+		}
+		src.Reset()
+		ge.writeHeader(&src, func(pkg string) bool { return used[ge.importName(pkg)] })
+		src.Write(ge.files[name].Bytes())
+		var out bytes.Buffer
+		if err := gofmt(&out, src.Bytes()); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), out.Bytes(), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// usedPackages returns the names of the packages the Go code src refers
+// to, as each file of a directory only imports the packages it uses.
+func usedPackages(src []byte) (map[string]bool, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+	return used, nil
+}
@@ -0,0 +1,93 @@
+package wsdlgo
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestEncoderDir(t *testing.T) {
+	dir := t.TempDir()
+	enc := NewEncoder(nil)
+	enc.SetServer(true)
+	if err := enc.EncodeDir(LoadDefinition(t, "split.wsdl", nil), dir); err != nil {
+		t.Fatal(err)
+	}
+	compareDir(t, dir, filepath.Join("testdata", "split"))
+}
+
+// compareDir compares the files of dir with the goldens of the same
+// name in golden.
+func compareDir(t *testing.T, dir, golden string) {
+	t.Helper()
+	goldens, err := filepath.Glob(filepath.Join(golden, "*.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	for _, g := range goldens {
+		want = append(want, strings.TrimSuffix(filepath.Base(g), ".golden")+".go")
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var have []string
+	for _, f := range files {
+		have = append(have, f.Name())
+	}
+	sort.Strings(want)
+	if strings.Join(have, " ") != strings.Join(want, " ") {
+		t.Fatalf("%s: want files %v, have %v", dir, want, have)
+	}
+	for i, name := range have {
+		a, err := os.ReadFile(goldens[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(a, b) {
+			err := Diff("_diff", "go", a, b)
+			t.Errorf("%s != %s: %v\ngenerated:\n%s\n", name, goldens[i], err, b)
+		}
+	}
+}
+
+func TestEncoderNamespacePackage(t *testing.T) {
+	root := t.TempDir()
+	enc := NewEncoder(nil)
+	enc.SetLocation(filepath.Join("testdata", "nspkg", "customers.wsdl"))
+	enc.SetNamespacePackage("http://example.com/common", "example.com/gen/common")
+	d := LoadDefinition(t, filepath.Join("nspkg", "customers.wsdl"), nil)
+	if err := enc.EncodeDir(d, filepath.Join(root, "customers")); err != nil {
+		t.Fatal(err)
+	}
+	for _, pkg := range []string{"customers", "common"} {
+		compareDir(t, filepath.Join(root, pkg), filepath.Join("testdata", "nspkg", pkg))
+	}
+
+	enc = NewEncoder(&bytes.Buffer{})
+	enc.SetNamespacePackage("http://example.com/common", "example.com/gen/common")
+	if err := enc.Encode(LoadDefinition(t, filepath.Join("nspkg", "customers.wsdl"), nil)); err == nil {
+		t.Fatal("namespace package generated in a single file")
+	}
+}
+
+func TestUsedPackages(t *testing.T) {
+	used, err := usedPackages([]byte(`package p
+
+var _ = fmt.Sprint(soap.Fault{}, x)
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !used["fmt"] || !used["soap"] || used["strings"] {
+		t.Fatalf("unexpected packages: %v", used)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"go/parser"
 	"go/token"
@@ -37,6 +38,11 @@ type Encoder interface {
 	// Encode generates Go code from d.
 	Encode(d *wsdl.Definitions) error
 
+	// EncodeDir generates Go code from d in the directory dir, split in
+	// files: client.go, types.go, enums.go and faults.go, and server.go
+	// and mock.go if enabled.
+	EncodeDir(d *wsdl.Definitions, dir string) error
+
 	// SetPackageName sets some fmt.Stringer that can produce package name
 	SetPackageName(packageName fmt.Stringer)
 
@@ -48,6 +54,78 @@ type Encoder interface {
 	// SetLocalNamespace allows overriding of the Namespace in XMLName instead
 	// of the one specified in wsdl
 	SetLocalNamespace(namespace string)
+
+	// SetEmbedSchema enables generating the Schema variable, holding
+	// the XML schema of the WSDL for validating requests and responses.
+	SetEmbedSchema(embed bool)
+
+	// SetIgnorePolicy disables generating the Policy variable from the
+	// WS-Policy of the binding, and failing on unsupported assertions.
+	SetIgnorePolicy(ignore bool)
+
+	// SetNillable enables generating soap.Nillable fields for nillable
+	// elements, which are sent as xsi:nil when nil instead of omitted.
+	SetNillable(nillable bool)
+
+	// SetBigNumbers enables generating soap.Decimal and soap.Integer
+	// fields of arbitrary precision for xsd:decimal and xsd:integer and
+	// its unbounded derived types, instead of float64 and int64.
+	SetBigNumbers(big bool)
+
+	// SetOptionalStyle sets how the fields of the optional and required
+	// elements of simple types map to Go: pointers, values, or values
+	// and soap.Optional wrappers.
+	SetOptionalStyle(s OptionalStyle)
+
+	// SetParameterStyle sets whether the methods of document/literal
+	// operations take the children of the wrapper elements of their
+	// messages, or the element structs.
+	SetParameterStyle(s ParameterStyle)
+
+	// SetServicePorts restricts the clients generated, one for each SOAP
+	// binding, to those of the ports or services of names.
+	SetServicePorts(names ...string)
+
+	// SetServer enables generating the Register function serving the
+	// port type interface with soap.Server, and fault constructors.
+	SetServer(server bool)
+
+	// SetMTOM enables generating soap.Binary fields for base64Binary
+	// elements, which servers answering with MTOM send as attachments.
+	SetMTOM(mtom bool)
+
+	// SetStreamBinary enables generating soap.Base64Stream fields for
+	// base64Binary elements, encoded from and decoded to an io.Reader
+	// instead of a []byte, unless SetMTOM is enabled.
+	SetStreamBinary(stream bool)
+
+	// SetLenientEnums disables failing the decoding of values of simple
+	// types other than those of their enumerations.
+	SetLenientEnums(lenient bool)
+
+	// SetMock enables generating a mock implementation of the port type
+	// interface, for tests of code using it.
+	SetMock(mock bool)
+
+	// SetContext enables generating operation methods taking a
+	// context.Context as first parameter, passed to the soap.Client.
+	SetContext(context bool)
+
+	// SetLocation sets the location of the WSDL, a file path or URL,
+	// which relative locations of imports are resolved against.
+	SetLocation(loc string)
+
+	// SetCatalog sets the catalog mapping the locations of imported
+	// documents to local copies.
+	SetCatalog(c *Catalog)
+
+	// SetCacheDir sets the directory caching the remote documents the
+	// WSDL imports, which are revalidated with their ETag.
+	SetCacheDir(dir string)
+
+	// SetNamespacePackage generates the types of the schemas of
+	// namespace in their own package of importPath, with EncodeDir.
+	SetNamespacePackage(namespace, importPath string)
 }
 
 type goEncoder struct {
@@ -67,6 +145,27 @@ type goEncoder struct {
 	// elements cache
 	elements map[string]*wsdl.Element
 
+	// global attributes and attribute groups, by name
+	attributes      map[string]*wsdl.Attribute
+	attributeGroups map[string]*wsdl.AttributeGroup
+
+	// global model groups, by name
+	groups map[string]*wsdl.Group
+
+	// names of the anonymous complex types of local elements
+	nestedTypes map[*wsdl.ComplexType]string
+
+	// heads and members of the substitution groups of the global
+	// elements, by head element, and the groups of the heads
+	substitutionHeads  map[string]*wsdl.Element
+	substitutions      map[string][]*wsdl.Element
+	substitutionGroups map[string]*substitutionGroup
+
+	// complex types extending each complex type, by base type, and the
+	// hierarchies of the bases
+	derivedTypes    map[string][]*wsdl.ComplexType
+	typeHierarchies map[string]*typeHierarchy
+
 	// funcs cache
 	funcs     map[string]*wsdl.Operation
 	funcnames []string
@@ -74,23 +173,117 @@ type goEncoder struct {
 	// messages cache
 	messages map[string]*wsdl.Message
 
+	// parts of the messages sent in the SOAP Header, by message
+	headerParts map[string]map[string]*wsdl.Part
+
 	// soap operations cache
 	soapOps map[string]*wsdl.BindingOperation
+	soap12  bool // whether the binding is a soap12:binding
+	encoded bool // whether operations of the binding are encoded
+	// whether operations of the binding are document style SOAP
+	// operations, which may be wrapped
+	document bool
+
+	// clients of the bindings, the one being generated, the ports of
+	// SetServicePorts, and the constants of the soapActions of the
+	// binding operations
+	clients     []*client
+	client      *client
+	portFilter  []string
+	actions     []*soapActionConst
+	actionNames map[*wsdl.BindingOperation]string
+
+	// error types of the fault messages of the operations, by message
+	// and in the order of the methods returning them
+	faultErrors map[string]*faultError
+	faultOrder  []*faultError
+
+	// operation wrappers written, as messages may be shared by operations
+	opTypes map[string]bool
 
 	// whether to add supporting types
-	needsDateType     bool
-	needsTimeType     bool
-	needsDateTimeType bool
-	needsDurationType bool
-	needsTag          map[string]string
-	needsStdPkg       map[string]bool
-	needsExtPkg       map[string]bool
-	importedSchemas   map[string]bool
-	usedNamespaces    map[string]string
-	usedNameSpaceMap  map[string]string
+	needsTag         map[string]string
+	needsStdPkg      map[string]bool
+	needsExtPkg      map[string]bool
+	importedSchemas  map[string]bool   // by location
+	schemaBases      map[string]string // documents declaring schema imports of imported WSDLs
+	documents        map[string][]byte // imported, by location
+	usedNamespaces   map[string]string
+	usedNameSpaceMap map[string]string
 
 	// localNamespace allows overriding of namespace in XMLName
 	localNamespace string
+
+	// location of the WSDL, which imports are relative to
+	location string
+
+	// local copies of imported documents
+	catalog *Catalog
+
+	// directory caching remote documents, and their downloads
+	cacheDir string
+	fetcher  *fetcher
+
+	// whether to generate the Schema variable
+	embedSchema bool
+
+	// whether to skip the WS-Policy of the binding
+	ignorePolicy bool
+
+	// whether to generate soap.Nillable fields for nillable elements
+	nillable bool
+
+	// whether to generate soap.Decimal and soap.Integer fields
+	bigNumbers bool
+
+	// how the fields of elements of simple types map to Go
+	optional OptionalStyle
+
+	// how the methods of document/literal operations take parameters
+	style ParameterStyle
+
+	// whether to generate the soap.Server glue
+	server bool
+
+	// whether to generate soap.Binary fields for base64Binary elements
+	mtom bool
+
+	// whether to generate soap.Base64Stream fields for base64Binary elements
+	streamBinary bool
+
+	// whether enumerations decode unknown values
+	lenientEnums bool
+
+	// names of the constants of enumerations
+	enumConsts map[string]bool
+
+	// whether to generate the mock of the port type interface
+	mock bool
+
+	// whether operation methods take a context.Context
+	context bool
+
+	// whether the documents of the WSDL are imported and cached
+	loaded bool
+
+	// code of the files generated by EncodeDir, by name
+	files map[string]*bytes.Buffer
+
+	// packages of the types of namespaces, by namespace, the namespaces
+	// of types by name, and the package being generated, or nil for the
+	// WSDL package
+	nsPackages     map[string]*nsPackage
+	typeNamespaces map[string]string
+	pkg            *nsPackage
+	pkgErr         error
+
+	// packages of the types shared with other WSDLs of a Batch, by name,
+	// the shared types generated by this WSDL, the type being generated,
+	// and the shared types that refer to types of the WSDL
+	typePackages map[string]*nsPackage
+	owned        map[string]bool
+	curType      string
+	unshared     map[string]bool
 }
 
 // NewEncoder creates and initializes an Encoder that generates code to w.
@@ -104,10 +297,13 @@ func NewEncoder(w io.Writer) Encoder {
 		funcs:           make(map[string]*wsdl.Operation),
 		messages:        make(map[string]*wsdl.Message),
 		soapOps:         make(map[string]*wsdl.BindingOperation),
+		opTypes:         make(map[string]bool),
 		needsTag:        make(map[string]string),
 		needsStdPkg:     make(map[string]bool),
 		needsExtPkg:     make(map[string]bool),
 		importedSchemas: make(map[string]bool),
+		schemaBases:     make(map[string]string),
+		documents:       make(map[string][]byte),
 	}
 }
 
@@ -143,11 +339,18 @@ func (ge *goEncoder) Encode(d *wsdl.Definitions) error {
 		return nil
 	}
 
-	// default mechanism to set package name
+	if len(ge.nsPackages) > 0 {
+		return errors.New("namespace packages are only generated with EncodeDir")
+	}
+	if err := ge.load(d); err != nil {
+		return err
+	}
+
+	// default mechanism to set package name, after the binding of the
+	// first client
 	if ge.packageName == nil {
 		ge.packageName = BindingPackageName(d.Binding)
 	}
-
 	var b bytes.Buffer
 	err := ge.encode(&b, d)
 	if err != nil {
@@ -156,12 +359,18 @@ func (ge *goEncoder) Encode(d *wsdl.Definitions) error {
 	if b.Len() == 0 {
 		return nil
 	}
+	return gofmt(ge.w, b.Bytes())
+}
+
+// gofmt checks that the generated code src parses, and writes it to w
+// formatted by gofmt.
+func gofmt(w io.Writer, src []byte) error {
 	var errb bytes.Buffer
-	input := b.String()
+	input := string(src)
 
 	// try to parse the generated code
 	fset := token.NewFileSet()
-	_, err = parser.ParseFile(fset, "", &b, parser.ParseComments)
+	_, err := parser.ParseFile(fset, "", src, parser.ParseComments)
 	if err != nil {
 		var src bytes.Buffer
 		s := bufio.NewScanner(strings.NewReader(input))
@@ -178,8 +387,8 @@ func (ge *goEncoder) Encode(d *wsdl.Definitions) error {
 	}
 	cmd := exec.Cmd{
 		Path:   path,
-		Stdin:  &b,
-		Stdout: ge.w,
+		Stdin:  bytes.NewReader(src),
+		Stdout: w,
 		Stderr: &errb,
 	}
 	err = cmd.Run()
@@ -196,6 +405,101 @@ func (ge *goEncoder) Encode(d *wsdl.Definitions) error {
 }
 
 func (ge *goEncoder) encode(w io.Writer, d *wsdl.Definitions) error {
+	err := ge.load(d)
+	if err != nil {
+		return err
+	}
+
+	var policy *policyRequirements
+	if !ge.ignorePolicy {
+		if policy, err = ge.bindingPolicy(d); err != nil {
+			return err
+		}
+		if policy != nil {
+			ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap/wsse"] = true
+		}
+	}
+
+	var b bytes.Buffer
+	type section struct {
+		file  string
+		write func(io.Writer, *wsdl.Definitions) error
+	}
+	var ff []section
+	if len(ge.soapOps) > 0 {
+		ff = append(ff,
+			section{"client.go", ge.writeInterfaceFuncs},
+			section{"types.go", ge.writeGoTypes},
+			section{"client.go", ge.writePortType},
+			section{"client.go", ge.writeGoFuncs},
+			section{"client.go", ge.writeClients},
+			section{"faults.go", ge.writeFaultErrors},
+		)
+		if ge.server {
+			ff = append(ff, section{"server.go", ge.writeServer})
+		}
+		if ge.mock {
+			ff = append(ff, section{"mock.go", ge.writeMock})
+		}
+	} else {
+		// TODO: probably faulty wsdl?
+		ff = append(ff,
+			section{"client.go", ge.writeGoFuncs},
+			section{"types.go", ge.writeGoTypes},
+		)
+	}
+	for _, f := range ff {
+		err := f.write(ge.section(&b, f.file), d)
+		if err != nil {
+			return err
+		}
+	}
+
+	var decls bytes.Buffer
+	if d.TargetNamespace != "" {
+		ge.writeComments(&decls, "Namespace", "")
+		fmt.Fprintf(&decls, "var Namespace = %q\n\n", d.TargetNamespace)
+	}
+	ge.writeEndpoints(&decls)
+	ge.writeSOAPActions(&decls)
+	if policy != nil {
+		ge.writePolicy(&decls, policy)
+	}
+	if ge.embedSchema {
+		if err := ge.writeSchema(&decls, d); err != nil {
+			return err
+		}
+	}
+
+	if len(ge.usedNameSpaceMap) > 0 {
+		ge.writeComments(&decls, "usedNameSpaceMap", "")
+
+		fmt.Fprint(&decls, "var UsedNamespaces = map[string]string{\n")
+		for k, v := range ge.usedNameSpaceMap {
+			fmt.Fprintf(&decls, "%q: %q,\n", v, k)
+		}
+		fmt.Fprint(&decls, "}\n\n")
+
+	}
+
+	if ge.files != nil {
+		client := ge.section(nil, "client.go").(*bytes.Buffer)
+		ge.files["client.go"] = bytes.NewBuffer(append(decls.Bytes(), client.Bytes()...))
+		return nil
+	}
+	ge.writeHeader(w, nil)
+	w.Write(decls.Bytes())
+	_, err = io.Copy(w, &b)
+	return err
+}
+
+// load imports the documents of d, and caches its types and operations,
+// once.
+func (ge *goEncoder) load(d *wsdl.Definitions) error {
+	if ge.loaded {
+		return nil
+	}
+	ge.loaded = true
 	ge.unionSchemasData(d, &d.Schema)
 	err := ge.importParts(d)
 
@@ -212,63 +516,60 @@ func (ge *goEncoder) encode(w io.Writer, d *wsdl.Definitions) error {
 	if err != nil {
 		return fmt.Errorf("wsdl import: %v", err)
 	}
+	if err := ge.cacheClients(d); err != nil {
+		return err
+	}
 	ge.cacheTypes(d)
 	ge.cacheFuncs(d)
 	ge.cacheMessages(d)
+	ge.cacheHeaders()
 	ge.cacheSOAPOperations(d)
+	return nil
+}
 
-	var b bytes.Buffer
-	var ff []func(io.Writer, *wsdl.Definitions) error
-	if len(ge.soapOps) > 0 {
-		ff = append(ff,
-			ge.writeInterfaceFuncs,
-			ge.writeGoTypes,
-			ge.writePortType,
-			ge.writeGoFuncs,
-		)
-	} else {
-		// TODO: probably faulty wsdl?
-		ff = append(ff,
-			ge.writeGoFuncs,
-			ge.writeGoTypes,
-		)
+// writeHeader writes the package clause and the imports of the
+// generated code, those for which use returns true if set.
+func (ge *goEncoder) writeHeader(w io.Writer, use func(pkg string) bool) {
+	fmt.Fprintf(w, "%s\n\npackage %s\n\n", fileHeader, ge.packageName)
+	var std, ext []string
+	for pkg := range ge.needsStdPkg {
+		if use == nil || use(pkg) {
+			std = append(std, pkg)
+		}
 	}
-	for _, f := range ff {
-		err := f(&b, d)
-		if err != nil {
-			return err
+	for pkg := range ge.needsExtPkg {
+		if use == nil || use(pkg) {
+			ext = append(ext, pkg)
 		}
 	}
-
-	fmt.Fprintf(w, "%s\n\npackage %s\n\nimport (\n", fileHeader, ge.packageName)
-	for pkg := range ge.needsStdPkg {
+	if use != nil && len(std)+len(ext) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "import (\n")
+	for _, pkg := range std {
 		fmt.Fprintf(w, "%q\n", pkg)
 	}
-	if len(ge.needsStdPkg) > 0 {
+	if len(std) > 0 {
 		fmt.Fprintf(w, "\n")
 	}
-	for pkg := range ge.needsExtPkg {
+	for _, pkg := range ext {
 		fmt.Fprintf(w, "%q\n", pkg)
 	}
 	fmt.Fprintf(w, ")\n\n")
-	if d.TargetNamespace != "" {
-		ge.writeComments(w, "Namespace", "")
-		fmt.Fprintf(w, "var Namespace = %q\n\n", d.TargetNamespace)
-	}
-
-	if len(ge.usedNameSpaceMap) > 0 {
-		ge.writeComments(w, "usedNameSpaceMap", "")
-
-		fmt.Fprint(w, "var UsedNamespaces = map[string]string{\n")
-		for k, v := range ge.usedNameSpaceMap {
-			fmt.Fprintf(w, "%q: %q,\n", v, k)
-		}
-		fmt.Fprint(w, "}\n\n")
+}
 
+// section returns the writer of the code of file, such as types.go, when
+// generating a directory, or else w.
+func (ge *goEncoder) section(w io.Writer, file string) io.Writer {
+	if ge.files == nil {
+		return w
 	}
-
-	_, err = io.Copy(w, &b)
-	return err
+	b, ok := ge.files[file]
+	if !ok {
+		b = new(bytes.Buffer)
+		ge.files[file] = b
+	}
+	return b
 }
 
 func (ge *goEncoder) importParts(d *wsdl.Definitions) error {
@@ -279,51 +580,157 @@ func (ge *goEncoder) importParts(d *wsdl.Definitions) error {
 	return ge.importSchema(d)
 }
 
+// importRoot imports the WSDL documents of wsdl:import onto d,
+// recursively: the imports of imported documents are resolved against
+// their location, and each document is imported once.
 func (ge *goEncoder) importRoot(d *wsdl.Definitions) error {
-	for _, imp := range d.Imports {
-		if imp.Location == "" {
+	bases := make([]string, len(d.Imports))
+	for i := range bases {
+		bases[i] = ge.location
+	}
+	for i := 0; i < len(d.Imports); i++ {
+		if d.Imports[i].Location == "" {
 			continue
 		}
-		err := ge.importRemote(imp.Location, &d)
+		if i == 0 || bases[i] != bases[i-1] {
+			ge.prefetchImports(bases[i], d.Imports[i:])
+		}
+		nimports, nschemas, nincludes := len(d.Imports), len(d.Schema.Imports), len(d.Schema.Includes)
+		nredefines, noverrides := len(d.Schema.Redefines), len(d.Schema.Overrides)
+		loc, err := ge.importRemote(bases[i], d.Imports[i].Location, &d)
 		if err != nil {
 			return err
 		}
+		for range d.Imports[nimports:] {
+			bases = append(bases, loc)
+		}
+		for _, imp := range d.Schema.Imports[nschemas:] {
+			ge.schemaBases[imp.Location] = loc
+		}
+		for _, inc := range d.Schema.Includes[nincludes:] {
+			ge.schemaBases[inc.Location] = loc
+		}
+		for _, r := range d.Schema.Redefines[nredefines:] {
+			ge.schemaBases[r.Location] = loc
+		}
+		for _, r := range d.Schema.Overrides[noverrides:] {
+			ge.schemaBases[r.Location] = loc
+		}
 	}
 	return nil
 }
 
+// importSchema imports the schemas imported, included, redefined and
+// overridden by the schema of d.
 func (ge *goEncoder) importSchema(d *wsdl.Definitions) error {
 	for _, imp := range d.Schema.Imports {
-		if imp.Location == "" {
-			continue
+		ge.prefetch(ge.schemaBase(imp.Location), []string{imp.Location})
+	}
+	for _, inc := range d.Schema.Includes {
+		ge.prefetch(ge.schemaBase(inc.Location), []string{inc.Location})
+	}
+	for _, r := range append(d.Schema.Redefines, d.Schema.Overrides...) {
+		ge.prefetch(ge.schemaBase(r.Location), []string{r.Location})
+	}
+	for _, imp := range d.Schema.Imports {
+		if err := ge.importSchemaDocument(d, ge.schemaBase(imp.Location), imp.Location); err != nil {
+			return err
 		}
+	}
+	for _, inc := range d.Schema.Includes {
+		if err := ge.importSchemaDocument(d, ge.schemaBase(inc.Location), inc.Location); err != nil {
+			return err
+		}
+	}
+	ns := d.Schema.TargetNamespace
+	if ns == "" {
+		ns = d.TargetNamespace
+	}
+	for _, r := range d.Schema.Redefines {
+		if err := ge.importRedefine(d, ge.schemaBase(r.Location), ns, r, false); err != nil {
+			return err
+		}
+	}
+	for _, r := range d.Schema.Overrides {
+		if err := ge.importRedefine(d, ge.schemaBase(r.Location), ns, r, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		schema := &wsdl.Schema{}
-		err := ge.importRemote(imp.Location, schema)
-		if err != nil {
+// schemaBase returns the location of the document declaring the schema
+// import or include of loc.
+func (ge *goEncoder) schemaBase(loc string) string {
+	if base, ok := ge.schemaBases[loc]; ok {
+		return base
+	}
+	return ge.location
+}
+
+// importSchemaDocument adds the types of the schema at loc, relative to
+// the document at base, to d, and those of the schemas it imports,
+// includes, redefines and overrides, recursively.
+func (ge *goEncoder) importSchemaDocument(d *wsdl.Definitions, base, loc string) error {
+	if loc == "" {
+		return nil
+	}
+	schema := &wsdl.Schema{}
+	loc, err := ge.importRemote(base, loc, schema)
+	if err != nil || loc == "" {
+		return err
+	}
+	ge.unionSchemasData(d, schema)
+	ge.prefetch(loc, schemaLocations(schema))
+	for _, imp := range schema.Imports {
+		if err := ge.importSchemaDocument(d, loc, imp.Location); err != nil {
 			return err
 		}
-		ge.unionSchemasData(d, schema)
-		for _, item := range schema.Imports {
-			schema = &wsdl.Schema{}
-			err := ge.importRemote(item.Location, schema)
-			if err != nil {
-				return err
-			}
-			ge.unionSchemasData(d, schema)
+	}
+	for _, inc := range schema.Includes {
+		if err := ge.importSchemaDocument(d, loc, inc.Location); err != nil {
+			return err
 		}
-		for _, item := range schema.Includes {
-			schema = &wsdl.Schema{}
-			err := ge.importRemote(item.Location, schema)
-			if err != nil {
-				return err
-			}
-			ge.unionSchemasData(d, schema)
+	}
+	for _, r := range schema.Redefines {
+		if err := ge.importRedefine(d, loc, schema.TargetNamespace, r, false); err != nil {
+			return err
+		}
+	}
+	for _, r := range schema.Overrides {
+		if err := ge.importRedefine(d, loc, schema.TargetNamespace, r, true); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// prefetchImports starts downloading the WSDL documents of imps, imported
+// by the document at base.
+func (ge *goEncoder) prefetchImports(base string, imps []*wsdl.Import) {
+	locs := make([]string, len(imps))
+	for i, imp := range imps {
+		locs[i] = imp.Location
+	}
+	ge.prefetch(base, locs)
+}
+
+// schemaLocations returns the locations of the schemas imported,
+// included, redefined and overridden by schema.
+func schemaLocations(schema *wsdl.Schema) []string {
+	var locs []string
+	for _, imp := range schema.Imports {
+		locs = append(locs, imp.Location)
+	}
+	for _, inc := range schema.Includes {
+		locs = append(locs, inc.Location)
+	}
+	for _, r := range append(schema.Redefines, schema.Overrides...) {
+		locs = append(locs, r.Location)
+	}
+	return locs
+}
+
 // TODO structure name names
 
 var nsCount int
@@ -349,43 +756,125 @@ func (ge *goEncoder) unionSchemasData(d *wsdl.Definitions, s *wsdl.Schema) {
 		addNameSpace(d.Namespaces, ns, s.Namespaces[ns])
 		// d.Namespaces[ns] = s.Namespaces[ns]
 	}
+	ge.addTypeNamespaces(s)
 	for _, ct := range s.ComplexTypes {
-		ct.TargetNamespace = s.TargetNamespace
+		ct.TargetNamespace = schemaNamespace(s, ct.TargetNamespace)
 	}
 	for _, st := range s.SimpleTypes {
-		st.TargetNamespace = s.TargetNamespace
+		st.TargetNamespace = schemaNamespace(s, st.TargetNamespace)
+	}
+	for _, g := range s.Groups {
+		g.TargetNamespace = schemaNamespace(s, g.TargetNamespace)
 	}
 	d.Schema.ComplexTypes = append(d.Schema.ComplexTypes, s.ComplexTypes...)
 	d.Schema.SimpleTypes = append(d.Schema.SimpleTypes, s.SimpleTypes...)
 	d.Schema.Elements = append(d.Schema.Elements, s.Elements...)
+	d.Schema.Attributes = append(d.Schema.Attributes, s.Attributes...)
+	d.Schema.AttributeGroups = append(d.Schema.AttributeGroups, s.AttributeGroups...)
+	d.Schema.Groups = append(d.Schema.Groups, s.Groups...)
 }
 
-// download xml from url, decode in v.
-func (ge *goEncoder) importRemote(loc string, v any) error {
-	_, alreadyImported := ge.importedSchemas[loc]
-	if alreadyImported {
-		return nil
+// resolveLocation returns the location of the document loc refers to
+// from the document at base: URLs relative to a base URL are resolved
+// against it, and relative paths against the directory of a base file.
+func resolveLocation(base, loc string) string {
+	u, err := url.Parse(loc)
+	if err != nil || u.Scheme != "" || base == "" || filepath.IsAbs(loc) {
+		return loc
 	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return loc
+	}
+	switch b.Scheme {
+	case "http", "https":
+		return b.ResolveReference(u).String()
+	case "file":
+		return filepath.Join(filepath.Dir(b.Path), filepath.FromSlash(u.Path))
+	}
+	return filepath.Join(filepath.Dir(base), filepath.FromSlash(u.Path))
+}
+
+// resolve returns the location of the document loc refers to from the
+// document at base, or of its copy in the catalog.
+func (ge *goEncoder) resolve(base, loc string) string {
+	resolved := resolveLocation(base, loc)
+	if mapped, ok := ge.catalog.Resolve(resolved); ok {
+		return mapped
+	}
+	if resolved != loc && !strings.Contains(resolved, "://") {
+		// Fall back to paths relative to the working directory,
+		// as they were before imports were resolved.
+		if _, err := os.Stat(resolved); err != nil {
+			if _, err := os.Stat(loc); err == nil {
+				return loc
+			}
+		}
+	}
+	return resolved
+}
 
-	u, err := url.Parse(loc)
+// remote returns the fetcher of remote documents.
+func (ge *goEncoder) remote() *fetcher {
+	if ge.fetcher == nil {
+		ge.fetcher = newFetcher(ge.http)
+		ge.fetcher.dir = ge.cacheDir
+	}
+	return ge.fetcher
+}
+
+// prefetch starts downloading the remote documents of the locations
+// locs, relative to the document at base, that were not imported yet.
+func (ge *goEncoder) prefetch(base string, locs []string) {
+	var urls []string
+	for _, loc := range locs {
+		if loc == "" {
+			continue
+		}
+		resolved := ge.resolve(base, loc)
+		if ge.importedSchemas[resolved] {
+			continue
+		}
+		if strings.HasPrefix(resolved, "http://") || strings.HasPrefix(resolved, "https://") {
+			urls = append(urls, resolved)
+		}
+	}
+	if len(urls) > 0 {
+		ge.remote().prefetch(urls)
+	}
+}
+
+// importRemote downloads the document at loc, relative to the document
+// at base, or its copy in the catalog, and decodes it in v. It returns
+// the location of the document, or "" if it was imported before.
+func (ge *goEncoder) importRemote(base, loc string, v any) (string, error) {
+	resolved := ge.resolve(base, loc)
+	if ge.importedSchemas[resolved] {
+		return "", nil
+	}
+	ge.importedSchemas[resolved] = true
+
+	u, err := url.Parse(resolved)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	var r io.Reader
 	switch u.Scheme {
 	case "http", "https":
-		resp, err := ge.http.Get(loc)
+		b, err := ge.remote().get(resolved)
 		if err != nil {
-			return err
+			return "", err
 		}
-		ge.importedSchemas[loc] = true
-		defer resp.Body.Close()
-		r = resp.Body
+		r = bytes.NewReader(b)
 	default:
-		file, err := os.Open(u.Path)
+		path := resolved
+		if u.Scheme == "file" {
+			path = u.Path
+		}
+		file, err := os.Open(path)
 		if err != nil {
-			return fmt.Errorf(
+			return "", fmt.Errorf(
 				"could not open file raw: %s path: %s escaped: %s : %v",
 				u.RawPath,
 				u.Path,
@@ -394,11 +883,17 @@ func (ge *goEncoder) importRemote(loc string, v any) error {
 			)
 		}
 
-		r = bufio.NewReader(file)
+		defer file.Close()
+		r = file
 	}
-	decoder := xml.NewDecoder(r)
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	ge.documents[loc] = b
+	decoder := xml.NewDecoder(bytes.NewReader(b))
 	decoder.CharsetReader = charset.NewReaderLabel
-	return decoder.Decode(&v)
+	return resolved, decoder.Decode(&v)
 }
 
 func (ge *goEncoder) cacheTypes(d *wsdl.Definitions) {
@@ -422,13 +917,19 @@ func (ge *goEncoder) cacheTypes(d *wsdl.Definitions) {
 
 		ge.ctypes[ctName] = v
 	}
+	ge.cacheAttributes(&d.Schema)
+	ge.cacheGroups(&d.Schema)
+	ge.expandGroups()
+	ge.cacheNestedTypes()
 	// cache elements from schema
 	ge.cacheElements(d.Schema.Elements)
+	ge.cacheSubstitutions(&d.Schema)
 	// cache elements from complex types
 
 	for _, ct := range ge.ctypes {
 		ge.cacheComplexTypeElements(ct)
 	}
+	ge.cacheDerivedTypes()
 }
 
 func (ge *goEncoder) cacheChoiceTypeElements(choice *wsdl.Choice) {
@@ -454,6 +955,9 @@ func (ge *goEncoder) cacheComplexTypeElements(ct *wsdl.ComplexType) {
 	cc := ct.ComplexContent
 	if cc != nil {
 		cce := cc.Extension
+		if cce != nil {
+			ge.cacheElements(cce.AllElements)
+		}
 		if cce != nil && cce.Sequence != nil {
 			seq := cce.Sequence
 			for _, cct := range seq.ComplexTypes {
@@ -519,25 +1023,55 @@ func (ge *goEncoder) cacheMessages(d *wsdl.Definitions) {
 	}
 }
 
+// cacheSOAPOperations caches the operations of the binding. Operations
+// of the port type missing from it, such as those of WSDLs without
+// binding, are called as document operations without soapAction, so
+// that every operation is a method of the interface and its client.
 func (ge *goEncoder) cacheSOAPOperations(d *wsdl.Definitions) {
+	ge.soap12 = d.Binding.IsSOAP12()
+	bt := d.Binding.BindingType
+	ge.document = bt == nil || bt.Style != "rpc" && bt.Verb == ""
 	for _, v := range d.Binding.Operations {
 		ge.soapOps[v.Name] = v
+		if _, ok := ge.encodedNamespace(v.Name); ok {
+			ge.encoded = true
+		}
+	}
+	if d.PortType.Name == "" {
+		return
+	}
+	for _, op := range d.PortType.Operations {
+		if _, exists := ge.soapOps[op.Name]; !exists {
+			ge.soapOps[op.Name] = &wsdl.BindingOperation{Name: op.Name}
+		}
 	}
 }
 
 var interfaceTypeT = template.Must(template.New("interfaceType").Parse(`
-// New{{.Name}} creates an initializes a {{.Name}}.
-func New{{.Name}}(cli *soap.Client) {{.Name}} {
+// New{{.Name}} creates an initializes a {{.Interface}}{{if .Binding}} of the
+// {{.Binding}} binding{{end}}.
+func New{{.Name}}(cli *soap.Client) {{.Interface}} {
 	return &{{.Impl}}{cli}
 }
 
-// {{.Name}} was auto-generated from WSDL
+// New{{.Name}}Client creates a {{.Interface}} for the service at endpoint,
+// with a soap.Client configured with opts, such as soap.WithTimeout or
+// soap.WithMiddleware{{if .Namespace}}, in Namespace{{end}}{{if .SOAP12}}, sending SOAP 1.2
+// envelopes as declared by the binding{{end}}{{if .Encoded}}, resolving the multiRef
+// elements of encoded responses{{end}}.
+func New{{.Name}}Client(endpoint string, opts ...soap.Option) {{.Interface}} {
+	return New{{.Name}}(soap.NewClient(endpoint, {{if .Options}}append([]soap.Option{ {{.Options}} }, opts...){{else}}opts{{end}}...))
+}
+{{- if not .Binding}}
+
+// {{.Interface}} was auto-generated from WSDL
 // and defines interface for the remote service. Useful for testing.
-type {{.Name}} interface {
+type {{.Interface}} interface {
 {{- range .Funcs }}
 {{.Doc}}{{.Name}}({{.Input}}) ({{.Output}})
 {{ end }}
 }
+{{- end}}
 `))
 
 type interfaceTypeFunc struct{ Doc, Name, Input, Output string }
@@ -563,26 +1097,51 @@ func (ge *goEncoder) writeInterfaceFuncs(w io.Writer, d *wsdl.Definitions) error
 		if err != nil {
 			return err
 		}
-		in, out := code(inParams), codeParams(outParams)
+		out := codeParams(outParams)
 		name := goSymbol(op.Name)
 		var doc bytes.Buffer
 		ge.writeComments(&doc, name, op.Doc)
 		funcs[i] = &interfaceTypeFunc{
 			Doc:    doc.String(),
 			Name:   name,
-			Input:  strings.Join(in, ","),
+			Input:  ge.methodParams(inParams),
 			Output: strings.Join(out, ","),
 		}
 		i++
 	}
-	n := d.PortType.Name
+	var opts []string
+	if d.TargetNamespace != "" {
+		opts = append(opts, "soap.WithNamespace(Namespace)")
+	}
+	if ge.soap12 {
+		opts = append(opts, "soap.WithVersion(soap.SOAP12)")
+	}
+	if ge.encoded {
+		opts = append(opts, "soap.WithResolveMultiRefs()")
+	}
+	var binding string // of the clients of other bindings of the port type
+	if !ge.client.primary {
+		binding = d.Binding.Name
+	}
 	return interfaceTypeT.Execute(w, &struct {
-		Name  string
-		Impl  string // private type that implements the interface
-		Funcs []*interfaceTypeFunc
+		Name      string
+		Interface string
+		Binding   string
+		Impl      string // private type that implements the interface
+		Namespace bool   // whether the Namespace variable is generated
+		SOAP12    bool
+		Encoded   bool
+		Options   string // default options of the soap.Client
+		Funcs     []*interfaceTypeFunc
 	}{
-		goSymbol(n),
-		strings.ToLower(n)[:1] + n[1:],
+		ge.client.name,
+		goSymbol(d.PortType.Name),
+		binding,
+		ge.client.impl,
+		d.TargetNamespace != "",
+		ge.soap12,
+		ge.encoded,
+		strings.Join(opts, ", "),
 		funcs[:i],
 	})
 }
@@ -599,13 +1158,12 @@ func (ge *goEncoder) writePortType(w io.Writer, d *wsdl.Definitions) error {
 	if len(ge.funcs) == 0 {
 		return nil
 	}
-	n := d.PortType.Name
 	return portTypeT.Execute(w, &struct {
 		Name      string
 		Interface string
 	}{
-		strings.ToLower(n)[:1] + n[1:],
-		goSymbol(n),
+		ge.client.impl,
+		goSymbol(d.PortType.Name),
 	})
 }
 
@@ -659,11 +1217,61 @@ func (ge *goEncoder) writeGoFuncs(w io.Writer, d *wsdl.Definitions) error {
 	return nil
 }
 
+// soapAction returns the soapAction declared in the binding of the
+// named operation, preferring SOAP 1.2, and the soap.Client method
+// to call with it. Operations of a soap12:binding are always called
+// with RoundTripSoap12.
+func (ge *goEncoder) soapAction(name string) (action, roundTrip string) {
+	bindingOp, exists := ge.soapOps[name]
+	if !exists {
+		return "", ""
+	}
+	if bindingOp.Operation.Action != "" {
+		return bindingOp.Operation.Action, "RoundTripSoap12"
+	}
+	if ge.soap12 {
+		return bindingOp.Operation11.Action, "RoundTripSoap12"
+	}
+	return bindingOp.Operation11.Action, "RoundTripWithSOAPAction"
+}
+
+// encodedNamespace returns the namespace of the operation element of
+// the named operation if its input is encoded, as with rpc/encoded
+// bindings.
+func (ge *goEncoder) encodedNamespace(name string) (string, bool) {
+	bindingOp, exists := ge.soapOps[name]
+	if !exists || bindingOp.Input == nil || bindingOp.Input.Use != "encoded" {
+		return "", false
+	}
+	return bindingOp.Input.Namespace, true
+}
+
+// soapActionName returns the name of the constant holding the
+// soapAction of the named operation.
+func soapActionName(name string) string {
+	return "SOAPAction" + goSymbol(name)
+}
+
+// writeSOAPActions writes a constant for the soapAction declared for
+// each binding operation.
+func (ge *goEncoder) writeSOAPActions(w io.Writer) {
+	if len(ge.actions) == 0 {
+		return
+	}
+	fmt.Fprint(w, "// SOAP actions declared in the WSDL binding.\nconst (\n")
+	for _, a := range ge.actions {
+		ge.writeComments(w, a.name, fmt.Sprintf(
+			"%s is the soapAction of the %s operation.", a.name, a.operation))
+		fmt.Fprintf(w, "%s = %q\n", a.name, a.action)
+	}
+	fmt.Fprint(w, ")\n\n")
+}
+
 var soapFuncT = template.Must(template.New("soapFunc").Parse(
 	`func (p *{{.PortType}}) {{.Name}}({{.Input}}) ({{.Output}}) {
-	α := struct {
+	{{.Call.Headers}}α := struct {
 		{{if .OpInputDataType}}
-			{{if .RPCStyle}}M{{end}} {{.OpInputDataType}} ` + "`xml:\"{{.OpName}}\"`" + `
+			{{if .RPCStyle}}M {{end}}{{.OpInputDataType}}{{if .OpElement}} ` + "`xml:\"{{.OpName}}\"`" + `{{end}}
 		{{end}}
 	}{
 		{{if .OpInputDataType}}{{.OpInputDataType}} {
@@ -674,21 +1282,21 @@ var soapFuncT = template.Must(template.New("soapFunc").Parse(
 
 	γ := struct {
 		{{if .OpResponseDataType}}
-			{{if .RPCStyle}}M {{end}}{{.OpResponseDataType}} ` + "`xml:\"{{.OpResponseName}}\"`" + `
+			{{if .RPCStyle}}M {{end}}{{.OpResponseDataType}}{{if .OpElement}} ` + "`xml:\"{{.OpResponseName}}\"`" + `{{end}}
 		{{end}}
 	}{}
-	if err := p.cli.RoundTripWithAction("{{.Name}}", α, &γ); err != nil {
+	if err := {{.Call.Client}}.RoundTripWithAction{{if .Context}}Context(ctx, {{else}}({{end}}"{{.Name}}", α, &γ); err != nil {
 		return {{.RetDef}}
 	}
-	return {{range $index, $element := .OpOutputNames}}{{index $.OpOutputPrefixes $index}}γ.{{if $.RPCStyle}}M.{{end}}{{$element}}, {{end}}nil
+	return {{range $index, $element := .OpOutputNames}}{{index $.OpOutputPrefixes $index}}γ.{{if $.RPCStyle}}M.{{end}}{{$element}}, {{end}}{{.Call.Results}}nil
 }
 `))
 
 var soapActionFuncT = template.Must(template.New("soapActionFunc").Parse(
 	`func (p *{{.PortType}}) {{.Name}}({{.Input}}) ({{.Output}}) {
-	α := struct {
+	{{.Call.Headers}}α := struct {
 		{{if .OpInputDataType}}
-			{{if .RPCStyle}}M{{end}} {{.OpInputDataType}} ` + "`xml:\"{{.OpName}}\"`" + `
+			{{if .RPCStyle}}M {{end}}{{.OpInputDataType}}{{if .OpElement}} ` + "`xml:\"{{.OpName}}\"`" + `{{end}}
 		{{end}}
 	}{
 		{{if .OpInputDataType}}{{.OpInputDataType}} {
@@ -699,22 +1307,60 @@ var soapActionFuncT = template.Must(template.New("soapActionFunc").Parse(
 
 	γ := struct {
 		{{if .OpResponseDataType}}
-			{{if .RPCStyle}}M {{end}}{{.OpResponseDataType}} ` + "`xml:\"{{.OpResponseName}}\"`" + `
+			{{if .RPCStyle}}M {{end}}{{.OpResponseDataType}}{{if .OpElement}} ` + "`xml:\"{{.OpResponseName}}\"`" + `{{end}}
 		{{end}}
 	}{}
-	if err := p.cli.{{.RoundTripType}}("{{.Action}}", α, &γ); err != nil {
+	if err := {{.Call.Client}}.{{.RoundTripType}}{{if .Context}}Context(ctx, {{else}}({{end}}{{.Action}}, α, &γ); err != nil {
+		return {{.RetDef}}
+	}
+	return {{range $index, $element := .OpOutputNames}}{{index $.OpOutputPrefixes $index}}γ.{{if $.RPCStyle}}M.{{end}}{{$element}}, {{end}}{{.Call.Results}}nil
+}
+`))
+
+var httpGetFuncT = template.Must(template.New("httpGetFunc").Parse(
+	`func (p *{{.PortType}}) {{.Name}}({{.Input}}) ({{.Output}}) {
+	α := struct {
+		{{if .OpInputDataType}}{{.OpInputDataType}}{{end}}
+	}{
+		{{if .OpInputDataType}}{{.OpInputDataType}} {
+			{{range $index, $element := .InputNames}}{{$element}},
+			{{end}}
+		},{{end}}
+	}
+
+	γ := struct {
+		{{if .OpResponseDataType}}{{.OpResponseDataType}}{{end}}
+	}{}
+	if err := p.cli.RoundTripHTTPGet{{if .Context}}Context(ctx, {{else}}({{end}}{{printf "%q" .Location}}, &α, &γ); err != nil {
 		return {{.RetDef}}
 	}
-	return {{range $index, $element := .OpOutputNames}}{{index $.OpOutputPrefixes $index}}γ.{{if $.RPCStyle}}M.{{end}}{{$element}}, {{end}}nil
+	return {{range $index, $element := .OpOutputNames}}{{index $.OpOutputPrefixes $index}}γ.{{$element}}, {{end}}nil
 }
 `))
 
+// httpGetLocation returns the location of the named operation if the
+// binding is an HTTP GET binding.
+func (ge *goEncoder) httpGetLocation(d *wsdl.Definitions, name string) (string, bool) {
+	bindingOp, exists := ge.soapOps[name]
+	if !exists || bindingOp.HTTP == nil || d.Binding.BindingType == nil ||
+		!strings.EqualFold(d.Binding.BindingType.Verb, "GET") {
+		return "", false
+	}
+	return bindingOp.HTTP.Location, true
+}
+
 func (ge *goEncoder) writeSOAPFunc(w io.Writer, d *wsdl.Definitions, op *wsdl.Operation, in, out []*parameter) bool {
 	if _, exists := ge.soapOps[op.Name]; !exists {
 		// TODO: probably faulty wsdl?
 		return false
 	}
 
+	// The soap:header parameters are sent apart from the Body.
+	params, results := in, out
+	in, hin := splitHeaders(params)
+	out, hout := splitHeaders(results)
+	call := newHeaderCall(hin, hout)
+
 	// Do we need to wrap into a operation element?
 	rpcStyle := false
 
@@ -727,18 +1373,9 @@ func (ge *goEncoder) writeSOAPFunc(w io.Writer, d *wsdl.Definitions, op *wsdl.Op
 	// inputNames describe the accessors to the input parameter names
 	inputNames := make([]string, len(in))
 	for index, name := range in {
-		returnVal := maskKeywordUsage(name.code)
-
-		if !strings.HasPrefix(name.dataType, "*") {
-			returnVal = "&" + returnVal
-		}
-
-		inputNames[index] = returnVal
+		inputNames[index] = name.partLiteral(maskKeywordUsage(name.code))
 	}
 
-	// outputDataTypes describe the data types which are returned by the func
-	outputDataTypes := make([]string, len(out))
-
 	// retDefaults describes the default return values in case of an error
 	retDefaults := make([]string, len(out))
 
@@ -748,32 +1385,43 @@ func (ge *goEncoder) writeSOAPFunc(w io.Writer, d *wsdl.Definitions, op *wsdl.Op
 	operationOutputPrefixes := make([]string, len(out)-1)
 
 	for index, name := range out {
-		outputDataTypes[index] = name.dataType
-
 		// operationOutputNames names will only be computed till len-1
 		if index == len(out)-1 {
 			continue
 		}
 
 		operationOutputNames[index] = strings.ToUpper(name.code[:1]) + name.code[1:]
+		if name.part != nil {
+			operationOutputNames[index] = name.part.name
+		}
 		operationOutputPrefixes[index] = ""
 		retDefaults[index] = "nil"
 
-		// If the output is >not< a pointer, we need to return the value of the response
-		if !strings.HasPrefix(name.dataType, "*") {
+		// If the field holds a pointer to the output, or a soap.Optional
+		// of it, we need to return the value of the response
+		switch pointer, optional := name.partAccess(); {
+		case pointer:
 			operationOutputPrefixes[index] = "*"
+		case optional:
+			operationOutputNames[index] += ".Value"
+		}
 
-			// Also - only resolve the default for non-pointer returns (otherwise nil suffices)
+		// Only resolve the default for non-pointer returns (otherwise nil suffices)
+		if !strings.HasPrefix(name.dataType, "*") {
 			retDefaults[index] = ge.wsdl2goDefault(name.dataType)
 		}
 	}
-	retDefaults[len(retDefaults)-1] = "err"
+	retDefaults[len(retDefaults)-1] = ge.faultReturn(op)
 
 	// Check if we need to prefix the op with a namespace
 	mInput := ge.funcs[op.Name].Input
 	namespacedOpName := op.Name
 
-	if mInput != nil {
+	if ns, ok := ge.encodedNamespace(op.Name); ok && ns != "" {
+		// Encoded operation elements are in the namespace of the
+		// binding, declared on them.
+		namespacedOpName = ns + " " + namespacedOpName
+	} else if mInput != nil {
 		nsSplit := strings.Split(mInput.Message, ":")
 		if len(nsSplit) > 1 {
 			namespacedOpName = nsSplit[0] + ":" + namespacedOpName
@@ -803,17 +1451,54 @@ func (ge *goEncoder) writeSOAPFunc(w io.Writer, d *wsdl.Definitions, op *wsdl.Op
 		operationInputDataType = "struct{}"
 	}
 
-	soapFunctionName := "RoundTripSoap12"
-	soapAction := ""
-	if bindingOp, exists := ge.soapOps[op.Name]; exists {
-		soapAction = bindingOp.Operation.Action
+	if location, ok := ge.httpGetLocation(d, op.Name); ok {
+		httpGetFuncT.Execute(w, &struct {
+			Location           string
+			PortType           string
+			Name               string
+			OpInputDataType    string
+			InputNames         []string
+			OpResponseDataType string
+			OpOutputNames      []string
+			OpOutputPrefixes   []string
+			Input              string
+			Output             string
+			RetDef             string
+			Context            bool
+		}{
+			location,
+			ge.client.impl,
+			goSymbol(op.Name),
+			operationInputDataType,
+			inputNames,
+			operationOutputDataType,
+			operationOutputNames,
+			operationOutputPrefixes,
+			ge.methodParams(params),
+			strings.Join(codeParams(results), ","),
+			strings.Join(headerDefaults(retDefaults, hout), ","),
+			ge.context,
+		})
+		return true
+	}
+
+	if win, wout, ok := ge.wrappedOperation(op); ok {
+		ge.writeWrappedFunc(w, d, op, params, results, win, wout)
+		return true
+	}
+
+	// The parts of bare document operations are the children of the
+	// Body, those declared with a type are wrapped in the operation.
+	opElement := rpcStyle || !ge.elementParts(op)
+
+	soapAction, soapFunctionName := ge.soapAction(op.Name)
+	if soapAction != "" || ge.soap12 {
+		action := ge.soapActionConst(op.Name)
 		if soapAction == "" {
-			soapFunctionName = "RoundTripWithAction"
-			soapAction = bindingOp.Operation11.Action
+			action = `""`
 		}
-	}
-	if soapAction != "" {
 		soapActionFuncT.Execute(w, &struct {
+			Call               *headerCall
 			RoundTripType      string
 			Action             string
 			PortType           string
@@ -829,10 +1514,13 @@ func (ge *goEncoder) writeSOAPFunc(w io.Writer, d *wsdl.Definitions, op *wsdl.Op
 			Output             string
 			RetDef             string
 			RPCStyle           bool
+			OpElement          bool
+			Context            bool
 		}{
+			call,
 			soapFunctionName,
-			soapAction,
-			strings.ToLower(d.PortType.Name[:1]) + d.PortType.Name[1:],
+			action,
+			ge.client.impl,
 			goSymbol(op.Name),
 			namespacedOpName,
 			operationInputDataType,
@@ -841,14 +1529,17 @@ func (ge *goEncoder) writeSOAPFunc(w io.Writer, d *wsdl.Definitions, op *wsdl.Op
 			operationOutputDataType,
 			operationOutputNames,
 			operationOutputPrefixes,
-			strings.Join(code(in), ","),
-			strings.Join(outputDataTypes, ","),
-			strings.Join(retDefaults, ","),
+			ge.methodParams(params),
+			strings.Join(codeParams(results), ","),
+			strings.Join(headerDefaults(retDefaults, hout), ","),
 			rpcStyle,
+			opElement,
+			ge.context,
 		})
 		return true
 	}
 	soapFuncT.Execute(w, &struct {
+		Call               *headerCall
 		PortType           string
 		Name               string
 		OpName             string
@@ -862,8 +1553,11 @@ func (ge *goEncoder) writeSOAPFunc(w io.Writer, d *wsdl.Definitions, op *wsdl.Op
 		Output             string
 		RetDef             string
 		RPCStyle           bool
+		OpElement          bool
+		Context            bool
 	}{
-		strings.ToLower(d.PortType.Name[:1]) + d.PortType.Name[1:],
+		call,
+		ge.client.impl,
 		goSymbol(op.Name),
 		namespacedOpName,
 		operationInputDataType,
@@ -872,10 +1566,12 @@ func (ge *goEncoder) writeSOAPFunc(w io.Writer, d *wsdl.Definitions, op *wsdl.Op
 		operationOutputDataType,
 		operationOutputNames,
 		operationOutputPrefixes,
-		strings.Join(code(in), ","),
-		strings.Join(outputDataTypes, ","),
-		strings.Join(retDefaults, ","),
+		ge.methodParams(params),
+		strings.Join(codeParams(results), ","),
+		strings.Join(headerDefaults(retDefaults, hout), ","),
 		rpcStyle,
+		opElement,
+		ge.context,
 	})
 	return true
 }
@@ -898,9 +1594,14 @@ func (ge *goEncoder) inputParams(op *wsdl.Operation) ([]*parameter, error) {
 	if !ok {
 		return nil, fmt.Errorf("operation %q wants input message %q but it's not defined", op.Name, im)
 	}
-
-	// TODO: I had to disable this for my use case - do other use cases still work with false?
-	return ge.genParams(req, false), nil
+	var in []*parameter
+	if win, _, ok := ge.wrappedOperation(op); ok {
+		in = win.params()
+	} else {
+		// TODO: I had to disable this for my use case - do other use cases still work with false?
+		in = ge.genParams(req, false)
+	}
+	return append(in, ge.inputHeaders(op.Name, in)...), nil
 }
 
 // returns list of function output parameters plus error.
@@ -915,7 +1616,13 @@ func (ge *goEncoder) outputParams(op *wsdl.Operation) ([]*parameter, error) {
 	if !ok {
 		return nil, fmt.Errorf("operation %q wants output message %q but it's not defined", op.Name, om)
 	}
-	return append(ge.genParams(resp, false), out[0]), nil
+	var params []*parameter
+	if _, wout, ok := ge.wrappedOperation(op); ok {
+		params = wout.params()
+	} else {
+		params = ge.genParams(resp, false)
+	}
+	return append(append(params, ge.outputHeaders(op.Name)...), out[0]), nil
 }
 
 var isGoKeyword = map[string]bool{
@@ -950,6 +1657,40 @@ type parameter struct {
 	code     string
 	dataType string
 	xmlToken string
+	field    string       // field of the wrapper element of wrapped operations
+	header   string       // xml tag of soap:header parameters, sent in the SOAP Header
+	part     *structField // field of the part in the struct of its message, if known
+}
+
+// partAccess reports how the field of the part of p holds the value of
+// p: by a pointer to it, or in a soap.Optional. Parameters of unknown
+// parts, or of fields of other types, are held by pointers, unless
+// pointers themselves.
+func (p *parameter) partAccess() (pointer, optional bool) {
+	if p.part != nil {
+		if v, ok := optionalValue(p.part.typ); ok && v == p.dataType {
+			return false, true
+		}
+		switch p.part.typ {
+		case p.dataType:
+			return false, false
+		case "*" + p.dataType:
+			return true, false
+		}
+	}
+	return !strings.HasPrefix(p.dataType, "*"), false
+}
+
+// partLiteral returns the value of the field of the part of p, of the
+// argument x.
+func (p *parameter) partLiteral(x string) string {
+	switch pointer, optional := p.partAccess(); {
+	case pointer:
+		return "&" + x
+	case optional:
+		return "soap.NewOptional(" + x + ")"
+	}
+	return x
 }
 
 func code(list []*parameter) []string {
@@ -998,10 +1739,11 @@ func (ge *goEncoder) genParams(m *wsdl.Message, needsTag bool) []*parameter {
 			}
 			token = trimns(param.Element)
 		}
-		params[i] = &parameter{code: code, dataType: t, xmlToken: token}
+		params[i] = &parameter{code: code, dataType: t, xmlToken: token, part: ge.elementField(ge.partElement(param), "")}
 		if needsTag {
 			ge.needsStdPkg["encoding/xml"] = true
-			ge.needsTag[strings.TrimPrefix(t, "*")] = elName
+			typ := strings.TrimPrefix(t, "*")
+			ge.needsTag[typ[strings.LastIndex(typ, ".")+1:]] = elName
 		}
 	}
 	return params
@@ -1052,7 +1794,11 @@ func (ge *goEncoder) wsdl2goType(t string) string {
 	// TODO: support other types.
 	v := trimns(t)
 	if _, exists := ge.stypes[v]; exists {
-		return goSymbol(v)
+		return ge.qualifiedType(v, goSymbol(v))
+	}
+	if typ := ge.bigType(v); typ != "" {
+		ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+		return "soap." + typ
 	}
 	switch strings.ToLower(v) {
 	case "byte", "unsignedbyte":
@@ -1067,16 +1813,23 @@ func (ge *goEncoder) wsdl2goType(t string) string {
 		return "float64"
 	case "boolean":
 		return "bool"
-	case "hexbinary", "base64binary":
-		return "[]byte"
-	case "string", "anyuri", "token", "nmtoken", "qname", "language", "id":
+	case "base64binary":
+		ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+		if ge.mtom {
+			return "soap.Binary"
+		}
+		return "soap.Base64Binary"
+	case "hexbinary":
+		ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+		return "soap.HexBinary"
+	case "qname":
+		ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+		return "soap.QName"
+	case "string", "anyuri", "token", "nmtoken", "language", "id":
 		return "string"
-	case "date":
-		ge.needsDateType = true
-		return "Date"
-	case "time":
-		ge.needsTimeType = true
-		return "Time"
+	case "date", "time", "datetime", "datetimestamp", "gyear", "gyearmonth", "gmonth", "gmonthday", "gday", "duration":
+		ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+		return "soap." + timeTypes[strings.ToLower(v)]
 	case "nonnegativeinteger":
 		return "uint"
 	case "positiveinteger":
@@ -1085,16 +1838,13 @@ func (ge *goEncoder) wsdl2goType(t string) string {
 		return "string"
 	case "unsignedint":
 		return "uint"
-	case "datetime":
-		ge.needsDateTimeType = true
-		return "DateTime"
-	case "duration":
-		ge.needsDurationType = true
-		return "Duration"
-	case "anysequence", "anytype", "anysimpletype":
+	case "anytype":
+		ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+		return "*soap.RawXML"
+	case "anysimpletype":
 		return "interface{}"
 	default:
-		return "*" + goSymbol(v)
+		return "*" + ge.qualifiedType(v, goSymbol(v))
 	}
 }
 
@@ -1136,47 +1886,88 @@ func (ge *goEncoder) renameType(old, name string) {
 	ge.ctypes[name] = ct
 }
 
+// complexType returns the complex type named name, unqualified: those
+// of the schemas are cached by namespace and name.
+func (ge *goEncoder) complexType(name string) (*wsdl.ComplexType, bool) {
+	if ct, ok := ge.ctypes[name]; ok {
+		return ct, true
+	}
+	if ct, ok := ge.ctypes[ge.typeNamespaces[name]+":"+name]; ok {
+		return ct, true
+	}
+	for _, ct := range ge.ctypes {
+		if ct.Name == name {
+			return ct, true
+		}
+	}
+	return nil, false
+}
+
 // writeGoTypes writes Go types from WSDL types to w.
 //
 // Types are written in this order, alphabetically: date types that we
 // generate, simple types, then complex types.
 func (ge *goEncoder) writeGoTypes(w io.Writer, d *wsdl.Definitions) error {
 	var b bytes.Buffer
+	enums := ge.section(&b, "enums.go")
 	for _, name := range ge.sortedSimpleTypes() {
 		st := ge.stypes[name]
+		if !ge.inPackage(st.Name) {
+			continue
+		}
+		ge.curType = st.Name
 		stname := goSymbol(st.Name)
 		if st.Restriction != nil {
-			ge.writeComments(&b, stname, "")
-			fmt.Fprintf(&b, "type %s %s\n\n", stname, ge.wsdl2goType(st.Restriction.Base))
-			ge.genValidator(&b, stname, st.Restriction)
-		} else if st.Union != nil {
-			types := strings.Split(st.Union.MemberTypes, " ")
-			ntypes := make([]string, len(types))
-			for i, t := range types {
-				t = strings.TrimSpace(t)
-				if t == "" {
-					continue
-				}
-				ntypes[i] = ge.wsdl2goType(t)
+			w := io.Writer(&b)
+			if len(st.Restriction.Enum) > 0 {
+				w = enums
 			}
-			doc := stname + " is a union of: " + strings.Join(ntypes, ", ")
-			ge.writeComments(&b, stname, doc)
-			fmt.Fprintf(&b, "type %s interface{}\n\n", stname)
+			ge.writeComments(w, stname, "")
+			fmt.Fprintf(w, "type %s %s\n", stname, ge.restrictionType(st.Restriction.Base))
+			enum := len(st.Restriction.Enum) > 0 && ge.genEnum(w, stname, st.Restriction)
+			fmt.Fprintln(w)
+			ge.genBinaryMethods(w, stname, st.Restriction.Base)
+			ge.genValidator(w, stname, st.Restriction, enum)
+		} else if st.Union != nil {
+			ge.genUnion(&b, st)
+		} else if st.List != nil {
+			ge.genList(&b, st)
 		}
 	}
 	var err error
+	faults := ge.faultTypes()
 	for _, name := range ge.sortedComplexTypes() {
 		ct := ge.ctypes[name]
-		err = ge.genGoStruct(&b, d, ct)
+		if !ge.inPackage(ct.Name) {
+			continue
+		}
+		ge.curType = ct.Name
+		w := io.Writer(&b)
+		if faults[name] {
+			w = ge.section(&b, "faults.go")
+		}
+		err = ge.genGoStruct(w, d, ct)
 		if err != nil {
 			return err
 		}
-		ge.genGoXMLTypeFunction(&b, ct)
+		ge.genGoXMLTypeFunction(w, ct)
+		ge.genChoiceFuncs(w, ct)
+		ge.genValidate(w, ct)
+		ge.genApplyDefaults(w, ct)
+	}
+	if err = ge.writeSubstitutionGroups(&b); err != nil {
+		return err
+	}
+	if err = ge.writeTypeHierarchies(&b); err != nil {
+		return err
 	}
 
 	// Operation wrappers - mainly used for rpc, not exclusively
 	for _, name := range ge.sortedOperations() {
 		ct := ge.soapOps[name]
+		if ge.pkg != nil {
+			break
+		}
 
 		err = ge.genGoOpStruct(&b, d, ct)
 		if err != nil {
@@ -1184,11 +1975,35 @@ func (ge *goEncoder) writeGoTypes(w io.Writer, d *wsdl.Definitions) error {
 		}
 	}
 
-	ge.genDateTypes(w) // must be called last
 	_, err = io.Copy(w, &b)
 	return err
 }
 
+// faultTypes returns the names of the complex types of the details of
+// the faults of the operations.
+func (ge *goEncoder) faultTypes() map[string]bool {
+	types := make(map[string]bool)
+	for _, op := range ge.funcs {
+		for _, f := range op.Faults {
+			m, ok := ge.messages[trimns(f.Message)]
+			if !ok {
+				continue
+			}
+			for _, p := range m.Parts {
+				name := trimns(p.Type)
+				if p.Element != "" {
+					name = trimns(p.Element)
+					if el, ok := ge.elements[name]; ok && el.Type != "" {
+						name = trimns(el.Type)
+					}
+				}
+				types[name] = true
+			}
+		}
+	}
+	return types
+}
+
 func (ge *goEncoder) sortedSimpleTypes() []string {
 	keys := make([]string, len(ge.stypes))
 	i := 0
@@ -1222,81 +2037,82 @@ func (ge *goEncoder) sortedOperations() []string {
 	return keys
 }
 
-func (ge *goEncoder) genDateTypes(w io.Writer) {
-	cases := []struct {
-		needs bool
-		name  string
-		code  string
-	}{
-		{
-			needs: ge.needsDateType,
-			name:  "Date",
-			code:  "type Date string\n\n",
-		},
-		{
-			needs: ge.needsTimeType,
-			name:  "Time",
-			code:  "type Time string\n\n",
-		},
-		{
-			needs: ge.needsDateTimeType,
-			name:  "DateTime",
-			code:  "type DateTime string\n\n",
-		},
-		{
-			needs: ge.needsDurationType,
-			name:  "Duration",
-			code:  "type Duration string\n\n",
-		},
-	}
-	for _, c := range cases {
-		if !c.needs {
-			continue
-		}
-		ge.writeComments(w, c.name, c.name+" in WSDL format.")
-		io.WriteString(w, c.code)
-	}
-}
-
 var validatorT = template.Must(template.New("validator").Parse(`
-// Validate validates {{.TypeName}}.
-func (v {{.TypeName}}) Validate() bool {
-	for _, vv := range []{{.Type}} {
-		{{range .Args}}{{.}},{{"\n"}}{{end}}
-	}{
-		if reflect.DeepEqual(v, vv) {
-			return true
-		}
+// Validate returns the error of the facet of {{.TypeName}} that v violates, if any.
+func (v {{.TypeName}}) Validate() error {
+	{{- if .Facets}}
+	s, err := soap.FormatSimple(v)
+	if err != nil {
+		return err
 	}
-	return false
+	if err := {{.Facets}}.Check(s); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- if .Base}}
+	if err := {{.Base}}(v).Validate(); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- if .Enum}}
+	if !v.IsValid() {
+		return soap.EnumerationError(v)
+	}
+	{{- end}}
+	return nil
 }
 `))
 
-func (ge *goEncoder) genValidator(w io.Writer, typeName string, r *wsdl.Restriction) {
-	if len(r.Enum) == 0 {
+// genValidator writes the Validate method of the simple type typeName
+// restricting r.Base by r, if r has facets or its base a Validate method:
+// it checks the facets of values, then their enumerations with IsValid
+// if enum, else as facets.
+func (ge *goEncoder) genValidator(w io.Writer, typeName string, r *wsdl.Restriction, enum bool) {
+	var base, facets string
+	if ge.hasValidator(trimns(r.Base)) && trimns(r.Base) != typeName {
+		base = ge.wsdl2goType(r.Base)
+	}
+	builtin := ge.builtinBase(r.Base)
+	if fields := ge.facets(r, builtin, !enum); len(fields) > 0 {
+		var b bytes.Buffer
+		facets = unexported(typeName) + "Facets"
+		ge.genFacets(&b, facets, typeName, fields)
+		b.WriteTo(w)
+	}
+	if !enum && base == "" && facets == "" {
 		return
 	}
-	args := make([]string, len(r.Enum))
-	t := ge.wsdl2goType(r.Base)
-	for i, v := range r.Enum {
-		if t == "string" {
-			args[i] = strconv.Quote(v.Value)
-		} else {
-			args[i] = v.Value
-		}
-	}
-	ge.needsStdPkg["reflect"] = true
 	validatorT.Execute(w, &struct {
 		TypeName string
-		Type     string
-		Args     []string
+		Facets   string
+		Base     string
+		Enum     bool
 	}{
 		typeName,
-		t,
-		args,
+		facets,
+		base,
+		enum,
 	})
 }
 
+// genArray writes the type name of a SOAP-ENC array of the items of
+// arrayType, such as xsd:float[], embedding soap.Array, and its
+// SetXMLType method declaring the type of the items.
+func (ge *goEncoder) genArray(w io.Writer, name, arrayType string) {
+	typ := strings.SplitN(trimns(arrayType), "[", 2)[0]
+	ns, ok := ge.typeNamespaces[typ]
+	if !ok {
+		ns = "http://www.w3.org/2001/XMLSchema"
+	}
+	ge.needsStdPkg["encoding/xml"] = true
+	ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+	fmt.Fprintf(w, "type %s struct {\nsoap.Array[%s]\n}\n\n", name, ge.wsdl2goType(typ))
+	ge.writeComments(w, "SetXMLType", "")
+	fmt.Fprintf(w, "func (t *%s) SetXMLType() {\n", name)
+	fmt.Fprintf(w, "t.ItemType = xml.Name{Space: %q, Local: %q}\n", ns, typ)
+	fmt.Fprintf(w, "}\n\n")
+}
+
 func (ge *goEncoder) genGoXMLTypeFunction(w io.Writer, ct *wsdl.ComplexType) {
 	if ct.ComplexContent == nil || ct.ComplexContent.Extension == nil || ct.TargetNamespace == "" {
 		return
@@ -1369,41 +2185,27 @@ func (ge *goEncoder) genGoStruct(w io.Writer, d *wsdl.Definitions, ct *wsdl.Comp
 	}
 
 	name := goSymbol(ct.Name)
+	if ct.Abstract && ge.typeHierarchy(ct.Name) != nil {
+		// The interface of its hierarchy.
+		return nil
+	}
 	ge.writeComments(w, name, ct.Doc)
 	if ct.Abstract {
 		fmt.Fprintf(w, "type %s interface{}\n\n", name)
 		return nil
 	}
-	if ct.Sequence != nil && ct.Sequence.Any != nil {
-		if len(ct.Sequence.Elements) == 0 {
-			fmt.Fprintf(w, "type %s []interface{}\n\n", name)
-			return nil
-		}
-	}
-	if ct.Choice != nil && ct.Choice.Any != nil {
-		if len(ct.Choice.Elements) == 0 {
-			fmt.Fprintf(w, "type %s []interface{}\n\n", name)
-			return nil
-		}
-	}
 	if ct.ComplexContent != nil {
 		restr := ct.ComplexContent.Restriction
 		if restr != nil && len(restr.Attributes) == 1 && restr.Attributes[0].ArrayType != "" {
-			fmt.Fprintf(w, "type %s struct {\n", name)
-			typ := strings.SplitN(trimns(restr.Attributes[0].ArrayType), "[", 2)[0]
-			fmt.Fprintf(
-				w,
-				"Items []%s `xml:\"item,omitempty\" json:\"item,omitempty\" yaml:\"item,omitempty\"`\n",
-				ge.wsdl2goType(typ),
-			)
-			fmt.Fprintf(w, "}\n\n")
+			ge.genArray(w, name, restr.Attributes[0].ArrayType)
 			return nil
 		}
 	}
 
-	if c > 2 && len(ct.Attributes) == 0 && ct.SimpleContent == nil {
+	if c > 2 && len(ct.Attributes) == 0 && len(ct.AttributeGroups) == 0 && ct.AnyAttribute == nil && ct.SimpleContent == nil {
 		fmt.Fprintf(w, "type %s struct {\n", name)
 		ge.genXMLName(w, d.TargetNamespace, name)
+		ge.genAnyField(w, ct)
 		fmt.Fprintf(w, "}\n\n")
 		return nil
 	}
@@ -1411,6 +2213,8 @@ func (ge *goEncoder) genGoStruct(w io.Writer, d *wsdl.Definitions, ct *wsdl.Comp
 	ge.genXMLName(w, d.TargetNamespace, name)
 
 	err := ge.genStructFields(w, d, ct)
+	ge.genAnyField(w, ct)
+	ge.genAnyAttrField(w, ct)
 
 	if ct.ComplexContent != nil && ct.ComplexContent.Extension != nil {
 		fmt.Fprint(w, "TypeAttrXSI   string `xml:\"xsi:type,attr,omitempty\"`\n")
@@ -1428,7 +2232,14 @@ func (ge *goEncoder) genGoStruct(w io.Writer, d *wsdl.Definitions, ct *wsdl.Comp
 
 func (ge *goEncoder) genGoOpStruct(w io.Writer, d *wsdl.Definitions, bo *wsdl.BindingOperation) error {
 	name := goSymbol(bo.Name)
-	function := ge.funcs[name]
+	function, ok := ge.funcs[bo.Name]
+	if !ok {
+		return nil
+	}
+	if _, _, ok := ge.wrappedOperation(function); ok {
+		// The methods take the children of the wrapper elements.
+		return nil
+	}
 
 	if function.Input == nil {
 		log.Printf("function input is nil! %v is %v", name, function)
@@ -1469,6 +2280,10 @@ func (ge *goEncoder) genStructFields(w io.Writer, d *wsdl.Definitions, ct *wsdl.
 
 func (ge *goEncoder) genOpStructMessage(w io.Writer, d *wsdl.Definitions, name string, message *wsdl.Message) {
 	sanitizedMessageName := ge.sanitizedOperationsType(message.Name)
+	if ge.opTypes[sanitizedMessageName] {
+		return
+	}
+	ge.opTypes[sanitizedMessageName] = true
 
 	ge.writeComments(w, sanitizedMessageName, "Operation wrapper for "+name+".")
 	ge.writeComments(w, sanitizedMessageName, "")
@@ -1479,34 +2294,40 @@ func (ge *goEncoder) genOpStructMessage(w io.Writer, d *wsdl.Definitions, name s
 	}
 
 	for _, part := range message.Parts {
-		wsdlType := part.Type
+		ge.genElementField(w, ge.partElement(part), "")
+	}
 
-		// Probably soap12
-		if wsdlType == "" {
-			wsdlType = part.Element
-		}
+	fmt.Fprintf(w, "}\n\n")
+}
 
-		partName := part.Name
-		if part.Element != "" {
-			elName := trimns(part.Element)
-			if el, ok := ge.elements[elName]; ok {
-				partName = trimns(el.Name)
-			} else if el, ok := ge.ctypes[elName]; ok {
-				partName = trimns(el.Name)
-			} else if el, ok := ge.stypes[elName]; ok {
-				partName = trimns(el.Name)
-			}
-		}
+// partElement returns the element of the field of part in the struct of
+// the parts of its message.
+func (ge *goEncoder) partElement(part *wsdl.Part) *wsdl.Element {
+	wsdlType := part.Type
 
-		ge.genElementField(w, &wsdl.Element{
-			XMLName: part.XMLName,
-			Name:    partName,
-			Type:    wsdlType,
-			// TODO: Maybe one could make guesses about nillable?
-		}, "")
+	// Probably soap12
+	if wsdlType == "" {
+		wsdlType = part.Element
 	}
 
-	fmt.Fprintf(w, "}\n\n")
+	partName := part.Name
+	if part.Element != "" {
+		elName := trimns(part.Element)
+		if el, ok := ge.elements[elName]; ok {
+			partName = trimns(el.Name)
+		} else if el, ok := ge.ctypes[elName]; ok {
+			partName = trimns(el.Name)
+		} else if el, ok := ge.stypes[elName]; ok {
+			partName = trimns(el.Name)
+		}
+	}
+
+	return &wsdl.Element{
+		XMLName: part.XMLName,
+		Name:    partName,
+		Type:    wsdlType,
+		// TODO: Maybe one could make guesses about nillable?
+	}
 }
 
 func (ge *goEncoder) genComplexContent(w io.Writer, d *wsdl.Definitions, ct *wsdl.ComplexType) error {
@@ -1515,8 +2336,10 @@ func (ge *goEncoder) genComplexContent(w io.Writer, d *wsdl.Definitions, ct *wsd
 	}
 	ext := ct.ComplexContent.Extension
 	if ext.Base != "" {
-		base, exists := ge.ctypes[trimns(ext.Base)]
-		if exists {
+		base, exists := ge.complexType(trimns(ext.Base))
+		if embedded := ge.embeddedBase(ext); embedded != "" {
+			fmt.Fprintf(w, "%s\n", embedded)
+		} else if exists {
 			err := ge.genStructFields(w, d, base)
 			if err != nil {
 				return err
@@ -1530,17 +2353,20 @@ func (ge *goEncoder) genComplexContent(w io.Writer, d *wsdl.Definitions, ct *wsd
 		ns = ct.TargetNamespace
 	}
 
-	for _, attr := range ext.Attributes {
+	for _, attr := range ge.attributeList(ext.Attributes, ext.AttributeGroups) {
 		ge.genAttributeField(w, attr, ns)
 	}
 
+	for _, el := range allElements(ext.AllElements) {
+		ge.genElementField(w, el, ns)
+	}
 	sequences := make([]*wsdl.Sequence, 0)
 	if ext.Sequence != nil {
 		sequences = append(sequences, ext.Sequence)
 		for _, choice := range ext.Sequence.Choices {
 			tmpSeq := &wsdl.Sequence{
 				ComplexTypes: choice.ComplexTypes,
-				Elements:     choice.Elements,
+				Elements:     choiceElements(choice.Elements),
 				Any:          choice.Any,
 			}
 			sequences = append(sequences, tmpSeq)
@@ -1549,7 +2375,7 @@ func (ge *goEncoder) genComplexContent(w io.Writer, d *wsdl.Definitions, ct *wsd
 	if ext.Choice != nil {
 		tmpSeq := &wsdl.Sequence{
 			ComplexTypes: ext.Choice.ComplexTypes,
-			Elements:     ext.Choice.Elements,
+			Elements:     choiceElements(ext.Choice.Elements),
 			Any:          ext.Choice.Any,
 		}
 		sequences = append(sequences, tmpSeq)
@@ -1570,7 +2396,7 @@ func (ge *goEncoder) genComplexContent(w io.Writer, d *wsdl.Definitions, ct *wsd
 }
 
 func (ge *goEncoder) genSimpleContent(w io.Writer, d *wsdl.Definitions, ct *wsdl.ComplexType) error {
-	if ct.SimpleContent == nil || ct.SimpleContent.Extension == nil {
+	if ct.SimpleContent == nil {
 		return nil
 	}
 
@@ -1580,24 +2406,35 @@ func (ge *goEncoder) genSimpleContent(w io.Writer, d *wsdl.Definitions, ct *wsdl
 		ns = ct.TargetNamespace
 	}
 
+	if restr := ct.SimpleContent.Restriction; restr != nil && restr.Base != "" {
+		// The restricted type has the attributes.
+		if base, exists := ge.complexType(trimns(restr.Base)); exists && base != ct {
+			return ge.genStructFields(w, d, base)
+		}
+		ge.genValueField(w, ct, restr.Base)
+		for _, attr := range restr.Attributes {
+			ge.genAttributeField(w, attr, ns)
+		}
+		return nil
+	}
 	ext := ct.SimpleContent.Extension
+	if ext == nil {
+		return nil
+	}
 	if ext.Base != "" {
-		baseComplex, exists := ge.ctypes[trimns(ext.Base)]
-		if exists {
+		baseComplex, exists := ge.complexType(trimns(ext.Base))
+		if exists && baseComplex != ct {
 			err := ge.genStructFields(w, d, baseComplex)
 			if err != nil {
 				return err
 			}
 		} else {
-			// otherwise it's a simple type
-			ge.genElementField(w, &wsdl.Element{
-				Type: trimns(ext.Base),
-				Name: "Content",
-			}, ns)
+			// otherwise it's a simple type, the character data
+			ge.genValueField(w, ct, ext.Base)
 		}
 	}
 
-	for _, attr := range ext.Attributes {
+	for _, attr := range ge.attributeList(ext.Attributes, ext.AttributeGroups) {
 		ge.genAttributeField(w, attr, ns)
 	}
 
@@ -1612,7 +2449,7 @@ func (ge *goEncoder) genElements(w io.Writer, ct *wsdl.ComplexType) error {
 		ns = ct.TargetNamespace
 	}
 
-	for _, el := range ct.AllElements {
+	for _, el := range allElements(ct.AllElements) {
 		ge.genElementField(w, el, ns)
 	}
 	if ct.Sequence != nil {
@@ -1621,41 +2458,59 @@ func (ge *goEncoder) genElements(w io.Writer, ct *wsdl.ComplexType) error {
 		}
 		for _, choice := range ct.Sequence.Choices {
 			for _, el := range choice.Elements {
-				ge.genElementField(w, el, ns)
+				ge.genElementField(w, choiceElement(el), ns)
 			}
 		}
 	}
 	if ct.Choice != nil {
 		for _, el := range ct.Choice.Elements {
-			ge.genElementField(w, el, ns)
+			ge.genElementField(w, choiceElement(el), ns)
 		}
 	}
-	for _, attr := range ct.Attributes {
+	for _, attr := range ge.attributeList(ct.Attributes, ct.AttributeGroups) {
 		ge.genAttributeField(w, attr, ns)
 	}
 	return nil
 }
 
 func (ge *goEncoder) genElementField(w io.Writer, el *wsdl.Element, ns string) {
+	f := ge.elementField(el, ns)
+	if f == nil {
+		return
+	}
+	if f.tag == ",any" {
+		fmt.Fprintf(w, "%s %s `xml:\",any\" json:\"-\" yaml:\"-\"`\n", f.name, f.typ)
+		return
+	}
+	fmt.Fprintf(w, "%s %s `xml:\"%s\" json:\"%s\" yaml:\"%s\"`\n",
+		f.name, f.typ, f.tag, f.tag, f.tag)
+}
+
+// structField is a field of the struct of a complex type.
+type structField struct {
+	name string
+	typ  string
+	tag  string
+}
+
+// elementField returns the field of the element el of a complex type in
+// the namespace ns, or nil if el refers to an unknown element.
+func (ge *goEncoder) elementField(el *wsdl.Element, ns string) *structField {
+	required := !el.Optional() // where el occurs, a reference to a global element included
 	if el.Ref != "" {
 		ref := trimns(el.Ref)
+		if g := ge.substitutionGroup(ref); g != nil {
+			return ge.substitutionField(el, g)
+		}
 		nel, ok := ge.elements[ref]
 		if !ok {
-			return
+			return nil
 		}
 		el = nel
 	}
 	var slicetype string
 	if el.Type == "" && el.ComplexType != nil {
-		seq := el.ComplexType.Sequence
-		if seq == nil && el.ComplexType.Choice != nil {
-			seq = &wsdl.Sequence{
-				ComplexTypes: el.ComplexType.Choice.ComplexTypes,
-				Elements:     el.ComplexType.Choice.Elements,
-				Any:          el.ComplexType.Choice.Any,
-			}
-		}
-		if seq != nil {
+		if seq := wrapperSequence(el.ComplexType); seq != nil {
 			if len(seq.Elements) == 1 {
 				n := el.Name
 				seqel := seq.Elements[0]
@@ -1663,25 +2518,38 @@ func (ge *goEncoder) genElementField(w io.Writer, el *wsdl.Element, ns string) {
 				*el = *seqel
 				slicetype = seqel.Name
 				el.Name = n
-			} else if len(seq.Any) == 1 {
+			} else {
+				// The element is kept as is, its content of any elements
+				// included.
 				el = &wsdl.Element{
-					Name: el.Name,
-					Type: "anysequence",
-					Min:  seq.Any[0].Min,
-					Max:  seq.Any[0].Max,
+					Name:     el.Name,
+					Type:     "anyType",
+					Min:      el.Min,
+					Max:      el.Max,
+					Nillable: el.Nillable,
+					MinSet:   el.MinSet,
 				}
-				slicetype = el.Name
 			}
 		}
 	}
+	if name, ok := ge.nestedTypes[el.ComplexType]; ok && el.Type == "" {
+		nel := *el
+		nel.Type = name
+		el = &nel
+	}
 	et := el.Type
 	if et == "" {
 		et = "string"
+		if _, ok := ge.ctypes[el.Name]; ok && ge.elements[el.Name] == el && el.ComplexType != nil {
+			// The global element of an anonymous type has its struct, of
+			// its name.
+			et = el.Name
+		}
 	}
 	tag := el.Name
-	fmt.Fprintf(w, "%s ", goSymbol(el.Name))
+	var slice string
 	if el.Max != "" && el.Max != "1" {
-		fmt.Fprintf(w, "[]")
+		slice = "[]"
 		if slicetype != "" {
 			tag = el.Name + ">" + slicetype
 		}
@@ -1690,8 +2558,22 @@ func (ge *goEncoder) genElementField(w io.Writer, el *wsdl.Element, ns string) {
 		tag = fmt.Sprintf("%s %s", ns, tag)
 	}
 
+	if typ := ge.derivedField(et); typ != "" {
+		return &structField{name: goSymbol(el.Name), typ: slice + typ, tag: tag}
+	}
 	typ := ge.wsdl2goType(et)
-	if el.Nillable || el.Min == 0 {
+	if typ == "soap.Base64Binary" && ge.streamBinary {
+		typ = "*soap.Base64Stream"
+	}
+	if ge.optional != PointerOptional {
+		if typ, tag, ok := ge.optionalField(el, required, slice, typ, tag); ok {
+			return &structField{name: goSymbol(el.Name), typ: typ, tag: tag}
+		}
+	}
+	if el.Nillable && ge.nillable {
+		ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+		typ = "soap.Nillable[" + strings.TrimPrefix(typ, "*") + "]"
+	} else if el.Nillable || el.Min == 0 {
 		tag += ",omitempty"
 		// since we add omitempty tag, we should add pointer to type.
 		// thus xmlencoder can differ not-initialized fields from zero-initialized values
@@ -1699,30 +2581,37 @@ func (ge *goEncoder) genElementField(w io.Writer, el *wsdl.Element, ns string) {
 			typ = "*" + typ
 		}
 	}
-	fmt.Fprintf(w, "%s `xml:\"%s\" json:\"%s\" yaml:\"%s\"`\n",
-		typ, tag, tag, tag)
+	return &structField{name: goSymbol(el.Name), typ: slice + typ, tag: tag}
 }
 
 func (ge *goEncoder) genAttributeField(w io.Writer, attr *wsdl.Attribute, ns string) {
-	if attr.Name == "" && attr.Ref != "" {
-		attr.Name = trimns(attr.Ref)
-	}
-	if attr.Type == "" {
-		attr.Type = "string"
+	f := ge.attributeField(attr, ns)
+	if f == nil {
+		return
 	}
-	fmt.Fprintf(w, "%s ", goSymbol(attr.Name))
+	fmt.Fprintf(w, "%s %s `xml:\"%s\" json:\"%s\" yaml:\"%s\"`\n",
+		f.name, f.typ, f.tag, f.tag, f.tag)
+}
 
-	tag := fmt.Sprintf("%s,attr", attr.Name)
+// attributeField returns the field of the attribute attr of a complex
+// type in the namespace ns, or nil if it is prohibited. Attributes are
+// optional unless required.
+func (ge *goEncoder) attributeField(attr *wsdl.Attribute, ns string) *structField {
+	if attr.Use == "prohibited" {
+		return nil
+	}
+	name := attr.Name
+	if name == "" {
+		name = trimns(attr.Ref)
+	}
+	tag := fmt.Sprintf("%s,attr", name)
 	if ns != "" {
 		tag = fmt.Sprintf("%s %s", ns, tag)
 	}
-
-	typ := ge.wsdl2goType(attr.Type)
-	if attr.Nillable || attr.Min == 0 {
+	if attr.Use != "required" {
 		tag += ",omitempty"
 	}
-	fmt.Fprintf(w, "%s `xml:\"%s\" json:\"%s\" yaml:\"%s\"`\n",
-		typ, tag, tag, tag)
+	return &structField{name: goSymbol(name), typ: ge.wsdl2goType(ge.attributeType(attr)), tag: tag}
 }
 
 // writeComments writes comments to w, capped at ~80 columns.
@@ -1757,3 +2646,85 @@ func (ge *goEncoder) writeComments(w io.Writer, typeName, comment string) {
 func (ge *goEncoder) SetLocalNamespace(s string) {
 	ge.localNamespace = s
 }
+
+func (ge *goEncoder) SetEmbedSchema(embed bool) {
+	ge.embedSchema = embed
+}
+
+// SetIgnorePolicy disables the WS-Policy of the binding.
+func (ge *goEncoder) SetIgnorePolicy(ignore bool) {
+	ge.ignorePolicy = ignore
+}
+
+// SetNillable enables soap.Nillable fields for nillable elements.
+func (ge *goEncoder) SetNillable(nillable bool) {
+	ge.nillable = nillable
+}
+
+// SetBigNumbers enables soap.Decimal and soap.Integer fields.
+func (ge *goEncoder) SetBigNumbers(big bool) {
+	ge.bigNumbers = big
+}
+
+// SetServer enables the soap.Server glue of the port type.
+func (ge *goEncoder) SetServer(server bool) {
+	ge.server = server
+}
+
+// SetMTOM enables soap.Binary fields for base64Binary elements.
+func (ge *goEncoder) SetMTOM(mtom bool) {
+	ge.mtom = mtom
+}
+
+// SetLenientEnums enables decoding unknown values of enumerations.
+func (ge *goEncoder) SetLenientEnums(lenient bool) {
+	ge.lenientEnums = lenient
+}
+
+// SetMock enables the mock of the port type interface.
+func (ge *goEncoder) SetMock(mock bool) {
+	ge.mock = mock
+}
+
+// SetContext enables context.Context parameters of operation methods.
+func (ge *goEncoder) SetContext(context bool) {
+	ge.context = context
+}
+
+// SetLocation sets the location of the WSDL.
+func (ge *goEncoder) SetLocation(loc string) {
+	ge.location = loc
+}
+
+// SetCatalog sets the catalog of imported documents.
+func (ge *goEncoder) SetCatalog(c *Catalog) {
+	ge.catalog = c
+}
+
+// SetCacheDir sets the directory caching remote documents.
+func (ge *goEncoder) SetCacheDir(dir string) {
+	ge.cacheDir = dir
+}
+
+// methodParams returns the parameters of the operation method with the
+// input parameters in, preceded by the context if enabled.
+func (ge *goEncoder) methodParams(in []*parameter) string {
+	params := code(in)
+	if ge.context {
+		ge.needsStdPkg["context"] = true
+		params = append([]string{"ctx context.Context"}, params...)
+	}
+	return strings.Join(params, ",")
+}
+
+// writeSchema writes the Schema variable with the schema of d, including
+// imported schemas.
+func (ge *goEncoder) writeSchema(w io.Writer, d *wsdl.Definitions) error {
+	b, err := xml.Marshal(&d.Schema)
+	if err != nil {
+		return err
+	}
+	ge.writeComments(w, "Schema", "Schema is the XML schema of the service, for validating requests and responses with wsdl.ParseSchema.")
+	fmt.Fprintf(w, "var Schema = %q\n\n", b)
+	return nil
+}
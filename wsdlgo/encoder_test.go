@@ -11,6 +11,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -41,6 +42,8 @@ var EncoderCases = []struct {
 	F string
 	G string
 	E error
+	O func(enc Encoder) // Options of the encoder, if any
+	C bool              // Compile the generated code, if true
 }{
 	{F: "broken.wsdl", E: io.EOF},
 	{F: "w3cexample1.wsdl", G: "w3cexample1.golden", E: nil},
@@ -49,13 +52,141 @@ var EncoderCases = []struct {
 	{F: "w3example2.wsdl", G: "w3example2.golden", E: nil},
 	{F: "soap12wcf.wsdl", G: "soap12wcf.golden", E: nil},
 	{F: "memcache.wsdl", G: "memcache.golden", E: nil},
-	{F: "importer.wsdl", G: "memcache.golden", E: nil},
+	{F: "importer.wsdl", G: "importer.golden", E: nil},
 	{F: "data.wsdl", G: "data.golden", E: nil},
 	{F: "data_withkeyword.wsdl", G: "data_withkeyword.golden", E: nil},
 	{F: "localimport.wsdl", G: "localimport.golden", E: nil},
 	{F: "localimport-url.wsdl", G: "localimport.golden", E: nil},
 	{F: "localimport_choice.wsdl", G: "localimport_choice.golden", E: nil},
 	{F: "arrayexample.wsdl", G: "arrayexample.golden", E: nil},
+	{F: "soap12.wsdl", G: "soap12.golden", E: nil},
+	{F: "rpcencoded.wsdl", G: "rpcencoded.golden", E: nil},
+	{F: "httpget.wsdl", G: "httpget.golden", E: nil},
+	// The Inventory port type bound with SOAP 1.1, SOAP 1.2 and HTTP, and
+	// the Orders port type at two ports.
+	{F: "services.wsdl", G: "services.golden", E: nil},
+	{F: "nillable.wsdl", G: "nillable.golden", E: nil, O: func(enc Encoder) { enc.SetNillable(true) }},
+	// headers.wsdl sends the auth part of the GetQuoteRequest message in
+	// the SOAP Header, and receives the Session header of SessionMessage,
+	// which the server decodes and answers.
+	{F: "headers.wsdl", G: "headers.golden", E: nil},
+	{F: "headers.wsdl", G: "headers_server.golden", E: nil, O: func(enc Encoder) { enc.SetServer(true) }},
+	// Payment has a choice of a required Card, a nillable IBAN or
+	// Vouchers, and another one of an Email or a Phone. The alternatives
+	// are never soap.Nillable, which is always marshaled.
+	{F: "choice.wsdl", G: "choice.golden", E: nil},
+	{F: "choice.wsdl", G: "choice.golden", E: nil, O: func(enc Encoder) { enc.SetNillable(true) }},
+	// Product has a required, a prohibited and a global attribute, and
+	// those of the Audit attribute group, which refers to Revision.
+	{F: "attributes.wsdl", G: "attributes.golden", E: nil},
+	// Event has an xs:anyType element, an element of any content, an
+	// xsd:any wildcard and an xs:anyAttribute; Extensions has only an
+	// unbounded wildcard, and the xs:anyAttribute of an attribute group.
+	{F: "any.wsdl", G: "any.golden", E: nil},
+	// Circle, Disc and Square substitute the abstract Shape, which Drawing
+	// refers to; Label, of a built-in type, can't implement ShapeElement.
+	{F: "substitution.wsdl", G: "substitution.golden", E: nil},
+	// Dog and Cat extend the abstract Animal, and Puppy extends Dog, which
+	// it embeds; Enclosure has fields of both hierarchies.
+	{F: "derived.wsdl", G: "derived.golden", E: nil},
+	// Amount extends xs:decimal with an attribute, TaxedAmount extends
+	// Amount, Code a simple type with an attribute named value, Label
+	// restricts xs:string; Note is a global element of simple content.
+	{F: "simplecontent.wsdl", G: "simplecontent.golden", E: nil},
+	// Size is a union of an enumeration and xs:int, Limit of a type and
+	// an anonymous enumeration; Sizes, Weights and Colors are lists of a
+	// union, of xs:decimal and of an anonymous enumeration.
+	{F: "simpletypes.wsdl", G: "simpletypes.golden", E: nil},
+	// Customer is the group Party, which refers to Address; Order has
+	// nested anonymous types, one named as the type OrderItem, and refers
+	// to an unbounded choice group; Place refers to Address.
+	{F: "groups.wsdl", G: "groups.golden", E: nil},
+	// The schema redefines the enumeration Status, the type Address, the
+	// group Audit and the attribute group Tracking of redefine/base.xsd,
+	// and overrides the type Phone of redefine/phone.xsd.
+	{F: "redefine.wsdl", G: "redefine.golden", E: nil},
+	// Settings has an xs:all, extended by UserSettings with another;
+	// Update, the wrapper element of the operation, and its anonymous
+	// element Flags have xs:all too.
+	{F: "all.wsdl", G: "all.golden", E: nil},
+	// Line has optional elements and attributes of default values, of an
+	// enumeration and an anonymous type among them, fixed attributes, one
+	// a reference, and Order a required element of a fixed value.
+	{F: "defaults.wsdl", G: "defaults.golden", E: nil},
+	// Booking has elements of all the date and time types, of a date
+	// restricted by a pattern, Holiday restricting it further, and of a
+	// list of dates, and attributes of such types.
+	{F: "datetime.wsdl", G: "datetime.golden", E: nil},
+	// Lease has elements of durations, optional ones and a slice of them,
+	// of a duration restricted by a pattern, and attributes of durations,
+	// one of a default value.
+	{F: "duration.wsdl", G: "duration.golden", E: nil},
+	// Lookup has elements of QNames, optional and repeated, and attributes
+	// of them, one required; LookupResponse has a restricted QName and a
+	// list of them.
+	{F: "qname.wsdl", G: "qname.golden", E: nil},
+	// Status has values that are no Go symbols, FinalStatus restricts it,
+	// Priority is an int of a negative value, and Ratio has INF, which is
+	// checked as text. Lenient enumerations decode unknown values.
+	{F: "enums.wsdl", G: "enums.golden", E: nil},
+	{F: "enums.wsdl", G: "enums_lenient.golden", E: nil, O: func(enc Encoder) { enc.SetLenientEnums(true) }},
+	// Invoice has elements of decimals, of restricted decimals, one of
+	// facets and one of an enumeration, and of integers, a list of
+	// decimals, a double and a long, and attributes of big numbers, which
+	// are floats and ints without -bignum.
+	{F: "bignum.wsdl", G: "bignum.golden", E: nil, O: func(enc Encoder) { enc.SetBigNumbers(true) }},
+	{F: "bignum.wsdl", G: "bignum_float.golden", E: nil},
+	// Upload has elements of base64Binary and hexBinary, of a restricted
+	// base64Binary type, a slice and a list of hexBinary items, and
+	// attributes of both types, one required.
+	{F: "binary.wsdl", G: "binary.golden", E: nil},
+	{F: "binary.wsdl", G: "binary_stream.golden", E: nil, O: func(enc Encoder) { enc.SetStreamBinary(true) }},
+	{F: "binary.wsdl", G: "binary_stream_mtom.golden", E: nil, O: func(enc Encoder) {
+		enc.SetStreamBinary(true)
+		enc.SetMTOM(true)
+	}},
+	{F: "binary.wsdl", G: "binary_value.golden", E: nil, O: func(enc Encoder) { enc.SetOptionalStyle(ValueOptional) }},
+	// SKU has a pattern, ShortSKU restricts it, Quantity and Price have
+	// bounds and digits, Size a length and enumerations, Hash the length
+	// of hexBinary, and Order elements of anonymous simple types.
+	{F: "facets.wsdl", G: "facets.golden", E: nil},
+	// Order has required elements, of minOccurs given or not, optional
+	// ones, a nillable one, a slice, a reference, one of a complex type
+	// and a choice.
+	{F: "optional.wsdl", G: "optional.golden", E: nil, O: func(enc Encoder) { enc.SetOptionalStyle(WrapperOptional) }},
+	{F: "optional.wsdl", G: "optional_pointer.golden", E: nil},
+	{F: "optional.wsdl", G: "optional_value.golden", E: nil, O: func(enc Encoder) { enc.SetOptionalStyle(ValueOptional) }},
+	// The parts of the messages of rpc operations are fields of the
+	// optional style too, which the calls fill in and return.
+	{F: "arrayexample.wsdl", G: "arrayexample_value.golden", E: nil, O: func(enc Encoder) { enc.SetOptionalStyle(ValueOptional) }, C: true},
+	{F: "arrayexample.wsdl", G: "arrayexample_wrapper.golden", E: nil, O: func(enc Encoder) { enc.SetOptionalStyle(WrapperOptional) }, C: true},
+	{F: "httpget.wsdl", G: "httpget_value.golden", E: nil, O: func(enc Encoder) { enc.SetOptionalStyle(ValueOptional) }, C: true},
+	{F: "httpget.wsdl", G: "httpget_wrapper.golden", E: nil, O: func(enc Encoder) { enc.SetOptionalStyle(WrapperOptional) }, C: true},
+	{F: "memcache.wsdl", G: "memcache_value.golden", E: nil, O: func(enc Encoder) { enc.SetOptionalStyle(ValueOptional) }, C: true},
+	{F: "memcache.wsdl", G: "memcache_wrapper.golden", E: nil, O: func(enc Encoder) { enc.SetOptionalStyle(WrapperOptional) }, C: true},
+	// server.wsdl is served by the server glue, implemented by the mock,
+	// and of a base64Binary element, soap.Binary of MTOM.
+	{F: "server.wsdl", G: "server.golden", E: nil, O: func(enc Encoder) { enc.SetServer(true) }, C: true},
+	{F: "server.wsdl", G: "mock.golden", E: nil, O: func(enc Encoder) { enc.SetMock(true) }, C: true},
+	{F: "server.wsdl", G: "context.golden", E: nil, O: func(enc Encoder) {
+		enc.SetServer(true)
+		enc.SetMock(true)
+		enc.SetContext(true)
+	}, C: true},
+	{F: "server.wsdl", G: "server_mtom.golden", E: nil, O: func(enc Encoder) { enc.SetMTOM(true) }, C: true},
+	// The server glue of rpc operations decodes and answers the fields
+	// of their parts.
+	{F: "arrayexample.wsdl", G: "arrayexample_server.golden", E: nil, O: func(enc Encoder) { enc.SetServer(true) }, C: true},
+	{F: "memcache.wsdl", G: "memcache_server.golden", E: nil, O: func(enc Encoder) { enc.SetServer(true) }, C: true},
+	{F: "memcache.wsdl", G: "memcache_server_value.golden", E: nil, O: func(enc Encoder) {
+		enc.SetServer(true)
+		enc.SetOptionalStyle(ValueOptional)
+	}, C: true},
+	// remote.wsdl imports chain.wsdl's documents from an unreachable
+	// host, and the rpc importer.wsdl importer-root.wsdl, importing a
+	// schema of the test server, which the catalog maps to testdata.
+	{F: "catalog/remote.wsdl", G: "chain.golden", E: nil, O: func(enc Encoder) { setCatalog(enc, "remote.wsdl") }},
+	{F: "catalog/importer.wsdl", G: "importer.golden", E: nil, O: func(enc Encoder) { setCatalog(enc, "importer.wsdl") }, C: true},
 }
 
 func NewTestServer(t *testing.T) *httptest.Server {
@@ -80,7 +211,11 @@ func TestEncoder(t *testing.T) {
 		var err error
 		var want []byte
 		var have bytes.Buffer
-		err = NewEncoder(&have).Encode(d)
+		enc := NewEncoder(&have)
+		if tc.O != nil {
+			tc.O(enc)
+		}
+		err = enc.Encode(d)
 		if err != nil {
 			t.Errorf("test %d, encoding %q: %v", i, tc.F, err)
 		}
@@ -96,6 +231,38 @@ func TestEncoder(t *testing.T) {
 			t.Errorf("test %d, %q != %q: %v\ngenerated:\n%s\n",
 				i, tc.F, tc.G, err, have.Bytes())
 		}
+		if tc.C {
+			if err := Compile(have.Bytes()); err != nil {
+				t.Errorf("test %d, %q does not compile: %v", i, tc.G, err)
+			}
+		}
+	}
+}
+
+func TestEncoderEmbedSchema(t *testing.T) {
+	d := LoadDefinition(t, "w3example2.wsdl", nil)
+	var have bytes.Buffer
+	enc := NewEncoder(&have)
+	enc.SetEmbedSchema(true)
+	if err := enc.Encode(d); err != nil {
+		t.Fatal(err)
+	}
+	i := bytes.Index(have.Bytes(), []byte("var Schema = "))
+	if i < 0 {
+		t.Fatalf("Schema not generated:\n%s", have.Bytes())
+	}
+	line := have.Bytes()[i+len("var Schema = "):]
+	line = line[:bytes.IndexByte(line, '\n')]
+	src, err := strconv.Unquote(string(line))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := wsdl.ParseSchema(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Elements) != len(d.Schema.Elements) || len(s.ComplexTypes) != len(d.Schema.ComplexTypes) {
+		t.Fatalf("schema mismatch: %d elements, %d complex types", len(s.Elements), len(s.ComplexTypes))
 	}
 }
 
@@ -130,3 +297,50 @@ func Diff(prefix, ext string, a, b []byte) error {
 	}
 	return nil
 }
+
+// Compile builds the generated code src as a package of the module, in
+// a directory that go tools otherwise ignore. The go command is
+// required.
+func Compile(src []byte) error {
+	gocmd, err := exec.LookPath("go")
+	if err != nil {
+		return fmt.Errorf("go: %v", err)
+	}
+	dir, err := ioutil.TempDir(".", "_compile")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	if err = ioutil.WriteFile(filepath.Join(dir, "compile.go"), src, 0600); err != nil {
+		return err
+	}
+	out, err := exec.Command(gocmd, "build", "./"+dir).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, out)
+	}
+	return nil
+}
+
+func TestEncoderImportChain(t *testing.T) {
+	// chain.wsdl imports a WSDL importing a schema, which includes a
+	// schema importing it back, by locations relative to each document.
+	want, err := ioutil.ReadFile(filepath.Join("testdata", "chain.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer s.Close()
+	for _, loc := range []string{filepath.Join("testdata", "chain", "chain.wsdl"), s.URL + "/chain/chain.wsdl"} {
+		var have bytes.Buffer
+		enc := NewEncoder(&have)
+		enc.SetClient(s.Client())
+		enc.SetLocation(loc)
+		if err := enc.Encode(LoadDefinition(t, filepath.Join("chain", "chain.wsdl"), nil)); err != nil {
+			t.Fatalf("%s: %v", loc, err)
+		}
+		if !bytes.Equal(have.Bytes(), want) {
+			err := Diff("_diff", "go", want, have.Bytes())
+			t.Fatalf("%s != chain.golden: %v\ngenerated:\n%s\n", loc, err, have.Bytes())
+		}
+	}
+}
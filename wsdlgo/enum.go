@@ -0,0 +1,145 @@
+package wsdlgo
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+// enumConst is a constant of a value of an enumeration.
+type enumConst struct {
+	Name  string
+	Value string // Go literal
+}
+
+var enumT = template.Must(template.New("enum").Parse(`
+// Values of {{.Name}}.
+const (
+	{{- range .Consts}}
+	{{.Name}} {{$.Name}} = {{.Value}}
+	{{- end}}
+)
+
+// IsValid tells whether v is one of the values of {{.Name}}.
+func (v {{.Name}}) IsValid() bool {
+	switch v {
+	case {{range $i, $c := .Consts}}{{if $i}}, {{end}}{{$c.Name}}{{end}}:
+		return true
+	}
+	return false
+}
+
+// String returns the text of v.
+func (v {{.Name}}) String() string {
+	{{- if .Text}}
+	return string(v)
+	{{- else}}
+	s, _ := soap.FormatSimple(v)
+	return s
+	{{- end}}
+}
+{{if .Strict}}
+// UnmarshalXML implements the xml.Unmarshaler interface, failing for
+// values other than those of {{.Name}}.
+func (v *{{.Name}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var s string
+	if err := d.DecodeElement(&s, &start); err != nil {
+		return err
+	}
+	return v.unmarshal(s)
+}
+
+// UnmarshalXMLAttr implements the xml.UnmarshalerAttr interface, failing
+// for values other than those of {{.Name}}.
+func (v *{{.Name}}) UnmarshalXMLAttr(attr xml.Attr) error {
+	return v.unmarshal(attr.Value)
+}
+
+func (v *{{.Name}}) unmarshal(s string) error {
+	{{- if .Text}}
+	x := {{.Name}}(s)
+	{{- else}}
+	y, err := soap.ParseSimple[{{.Base}}](s)
+	if err != nil {
+		return err
+	}
+	x := {{.Name}}(y)
+	{{- end}}
+	if !x.IsValid() {
+		return fmt.Errorf("{{.Name}}: unknown value %q", s)
+	}
+	*v = x
+	return nil
+}
+{{end}}`))
+
+// genEnum writes the constants of the enumerations of the simple type
+// typeName restricting r.Base by r, with its IsValid and String methods,
+// and UnmarshalXML and UnmarshalXMLAttr rejecting unknown values of
+// elements and attributes unless enums are lenient.
+// It returns false for types of bases other than strings, numbers and
+// booleans, and values that are no Go constants of theirs, which are
+// skipped.
+func (ge *goEncoder) genEnum(w io.Writer, typeName string, r *wsdl.Restriction) bool {
+	base := ge.wsdl2goType(r.Base)
+	builtin := ge.wsdl2goType(ge.builtinBase(r.Base))
+	values := ge.enumValues(builtin, r)
+	for _, v := range values {
+		if !constant(builtin, v) {
+			return false
+		}
+	}
+	consts := make([]*enumConst, len(values))
+	for i, v := range values {
+		name := typeName + goSymbol(r.Enum[i].Value)
+		if name == typeName || ge.typeExists(name) || ge.enumConsts[name] {
+			name = typeName + "Value" + strconv.Itoa(i+1)
+		}
+		if ge.enumConsts == nil {
+			ge.enumConsts = make(map[string]bool)
+		}
+		ge.enumConsts[name] = true
+		consts[i] = &enumConst{name, v}
+	}
+	text := ge.stringType(r.Base)
+	strict := !ge.lenientEnums
+	if strict {
+		ge.needsStdPkg["encoding/xml"] = true
+		ge.needsStdPkg["fmt"] = true
+	}
+	if !text {
+		ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+	}
+	enumT.Execute(w, &struct {
+		Name, Base   string
+		Consts       []*enumConst
+		Text, Strict bool
+	}{typeName, base, consts, text, strict})
+	return true
+}
+
+// constant tells whether the literal v is a constant of the built-in Go
+// type typ.
+func constant(typ, v string) bool {
+	var err error
+	switch typ {
+	case "string":
+	case "bool":
+		return v == "true" || v == "false"
+	case "int", "int64":
+		_, err = strconv.ParseInt(v, 10, 64)
+	case "byte", "uint", "uint64":
+		_, err = strconv.ParseUint(v, 10, 64)
+	case "float64":
+		_, err = strconv.ParseFloat(v, 64)
+		if strings.Trim(v, "+-0123456789.eE") != "" {
+			return false // such as INF
+		}
+	default:
+		return false
+	}
+	return err == nil
+}
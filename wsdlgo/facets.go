@@ -0,0 +1,200 @@
+package wsdlgo
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+var facetsT = template.Must(template.New("facets").Parse(`
+// {{.Var}} are the facets of {{.Of}}.
+var {{.Var}} = &soap.Facets{
+	{{- range .Fields}}
+	{{.}},
+	{{- end}}
+}
+`))
+
+// facets returns the fields of the soap.Facets literal of the facets of
+// r that values of the built-in XSD type base are checked against, the
+// enumerations included if enum, or nil if there are none. Patterns are
+// dropped if one uses syntax beyond that of package regexp, such as the
+// \i and \c classes, as any value might match it.
+func (ge *goEncoder) facets(r *wsdl.Restriction, base string, enum bool) []string {
+	switch typ := ge.wsdl2goType(base); {
+	case timeTypes[strings.ToLower(base)] != "":
+		// Checked as text, bounds excepted.
+	case ge.bigType(base) != "", binaryType(typ):
+		// Checked as text.
+	case strings.HasPrefix(typ, "*"), strings.HasPrefix(typ, "soap."), typ == "interface{}":
+		return nil
+	}
+	var fields []string
+	if enum && len(r.Enum) > 0 {
+		values := make([]string, len(r.Enum))
+		for i, e := range r.Enum {
+			values[i] = strconv.Quote(e.Value)
+		}
+		fields = append(fields, "Enumeration: []string{"+strings.Join(values, ", ")+"}")
+	}
+	var patterns []string
+	for _, p := range r.Patterns {
+		re := `^(?:` + p.Value + `)$`
+		if _, err := regexp.Compile(re); err != nil {
+			patterns = nil
+			break
+		}
+		patterns = append(patterns, "regexp.MustCompile("+goString(re)+")")
+	}
+	if len(patterns) > 0 {
+		fields = append(fields, "Patterns: []*regexp.Regexp{"+strings.Join(patterns, ", ")+"}")
+	}
+	for _, f := range []struct {
+		name  string
+		facet *wsdl.Facet
+	}{
+		{"Length", r.Length},
+		{"MinLength", r.MinLength},
+		{"MaxLength", r.MaxLength},
+		{"MinInclusive", r.MinInclusive},
+		{"MaxInclusive", r.MaxInclusive},
+		{"MinExclusive", r.MinExclusive},
+		{"MaxExclusive", r.MaxExclusive},
+		{"TotalDigits", r.TotalDigits},
+		{"FractionDigits", r.FractionDigits},
+	} {
+		if f.facet != nil {
+			fields = append(fields, f.name+": "+strconv.Quote(f.facet.Value))
+		}
+	}
+	if r.Length != nil || r.MinLength != nil || r.MaxLength != nil {
+		switch strings.ToLower(base) {
+		case "hexbinary":
+			fields = append(fields, `Binary: "hex"`)
+		case "base64binary":
+			fields = append(fields, `Binary: "base64"`)
+		}
+	}
+	return fields
+}
+
+// genFacets writes the variable v of the soap.Facets of fields, of the
+// type or field named of.
+func (ge *goEncoder) genFacets(w *bytes.Buffer, v, of string, fields []string) {
+	ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+	for _, f := range fields {
+		if strings.HasPrefix(f, "Patterns:") {
+			ge.needsStdPkg["regexp"] = true
+		}
+	}
+	facetsT.Execute(w, &struct {
+		Var, Of string
+		Fields  []string
+	}{v, of, fields})
+}
+
+// builtinBase returns the built-in XSD type the simple type t is derived
+// from by restriction.
+func (ge *goEncoder) builtinBase(t string) string {
+	for i := 0; i < 32; i++ {
+		st, ok := ge.stypes[trimns(t)]
+		if !ok || st.Restriction == nil || trimns(st.Restriction.Base) == trimns(t) {
+			break
+		}
+		t = st.Restriction.Base
+	}
+	return trimns(t)
+}
+
+// facetChecks returns the checks of Validate of the struct of ct that
+// the fields of its elements of anonymous simple types respect their
+// facets, writing the variables of the facets to w.
+func (ge *goEncoder) facetChecks(w *bytes.Buffer, ct *wsdl.ComplexType) []string {
+	var checks []string
+	typ := goSymbol(ct.Name)
+	for _, el := range fieldElements(ct) {
+		if el.Type != "" || el.Ref != "" || el.SimpleType == nil || el.SimpleType.Restriction == nil {
+			continue
+		}
+		r := el.SimpleType.Restriction
+		fields := ge.facets(r, ge.builtinBase(r.Base), true)
+		f := ge.elementField(el, "")
+		if len(fields) == 0 || f == nil {
+			continue
+		}
+		v := unexported(typ) + f.name + "Facets"
+		check := fmt.Sprintf("if err := %s.CheckElement(%%s, %q); err != nil {\nreturn err\n}\n",
+			v, typ+"."+f.name)
+		switch f.typ {
+		case "string":
+			check = fmt.Sprintf(check, "v."+f.name)
+			if strings.HasSuffix(f.tag, ",omitempty") {
+				// Omitted if empty.
+				check = fmt.Sprintf("if v.%s != \"\" {\n%s}\n", f.name, check)
+			}
+		case "*string":
+			check = fmt.Sprintf("if v.%s != nil {\n"+check+"}\n", f.name, "*v."+f.name)
+		case "[]string":
+			check = fmt.Sprintf("for _, x := range v.%s {\n"+check+"}\n", f.name, "x")
+		case "soap.Optional[string]":
+			check = fmt.Sprintf("if v.%s.Present {\n"+check+"}\n", f.name, "v."+f.name+".Value")
+		case "[]*string":
+			check = fmt.Sprintf("for _, x := range v.%s {\nif x != nil {\n"+check+"}\n}\n", f.name, "*x")
+		default:
+			continue
+		}
+		ge.genFacets(w, v, typ+"."+f.name, fields)
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// fieldElements returns the elements of the fields that the struct of
+// ct declares itself, those of the types it extends excepted.
+func fieldElements(ct *wsdl.ComplexType) []*wsdl.Element {
+	var els []*wsdl.Element
+	add := func(all []*wsdl.Element, seq *wsdl.Sequence, choice *wsdl.Choice) {
+		els = append(els, allElements(all)...)
+		if seq != nil {
+			els = append(els, seq.Elements...)
+			for _, c := range seq.Choices {
+				els = append(els, choiceElements(c.Elements)...)
+			}
+		}
+		if choice != nil {
+			els = append(els, choiceElements(choice.Elements)...)
+		}
+	}
+	if ext := extension(ct.ComplexContent); ext != nil {
+		add(ext.AllElements, ext.Sequence, ext.Choice)
+	}
+	add(ct.AllElements, ct.Sequence, ct.Choice)
+	return els
+}
+
+// unexported returns the Go symbol s with its leading capitals, those of
+// an initialism such as SKU included, in lower case.
+func unexported(s string) string {
+	r := []rune(s)
+	for i := range r {
+		if !unicode.IsUpper(r[i]) || i > 0 && i+1 < len(r) && unicode.IsLower(r[i+1]) {
+			break
+		}
+		r[i] = unicode.ToLower(r[i])
+	}
+	return string(r)
+}
+
+// goString returns the Go literal of s, raw unless s has a back quote.
+func goString(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
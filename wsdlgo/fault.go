@@ -0,0 +1,87 @@
+package wsdlgo
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+// faultError is the error type of the faults of a wsdl:fault message,
+// returned by the methods of the operations declaring it.
+type faultError struct {
+	Name    string // of the soap.DetailError alias
+	Message string
+	Detail  string // Go type of the detail element
+	Element string // local name of the detail element
+}
+
+var faultErrorsT = template.Must(template.New("faultErrors").Parse(`{{range .}}
+// {{.Name}} is the error of the {{.Message}} fault, with its detail,
+// returned by the methods of the operations declaring it.
+type {{.Name}} = soap.DetailError[{{.Detail}}]
+
+// decode{{.Name}} decodes the {{.Name}} of SOAP faults.
+var decode{{.Name}} = soap.DetailDecoder[{{.Detail}}]({{printf "%q" .Element}})
+{{end}}
+`))
+
+// faultReturn returns the error the methods of op return for the error
+// err of their call, decoding the faults declared by op.
+func (ge *goEncoder) faultReturn(op *wsdl.Operation) string {
+	var decoders []string
+	for _, f := range op.Faults {
+		if fe := ge.faultError(trimns(f.Message)); fe != nil {
+			decoders = append(decoders, "decode"+fe.Name)
+		}
+	}
+	if len(decoders) == 0 {
+		return "err"
+	}
+	return fmt.Sprintf("soap.DecodeFault(err, %s)", strings.Join(decoders, ", "))
+}
+
+// faultError returns the error type of the fault message named name,
+// caching it for writeFaultErrors, or nil if it has no detail part.
+func (ge *goEncoder) faultError(name string) *faultError {
+	if fe, ok := ge.faultErrors[name]; ok {
+		return fe
+	}
+	m, ok := ge.messages[name]
+	if !ok || len(m.Parts) == 0 {
+		return nil
+	}
+	p := ge.genParams(m, false)[0]
+	fe := &faultError{
+		Name:    strings.TrimSuffix(goSymbol(name), "Fault") + "Error",
+		Message: name,
+		Detail:  strings.TrimPrefix(p.dataType, "*"),
+		Element: m.Parts[0].Name,
+	}
+	if el := m.Parts[0].Element; el != "" {
+		fe.Element = trimns(el)
+	}
+	if _, ok := ge.ctypes[fe.Name]; ok || fe.Name == "Error" {
+		fe.Name = goSymbol(name) + "Error"
+	}
+	if _, ok := ge.stypes[fe.Name]; ok {
+		fe.Name = goSymbol(name) + "Error"
+	}
+	if ge.faultErrors == nil {
+		ge.faultErrors = make(map[string]*faultError)
+	}
+	ge.faultErrors[name] = fe
+	ge.faultOrder = append(ge.faultOrder, fe)
+	return fe
+}
+
+// writeFaultErrors writes the error types of the faults of the
+// operations of the clients.
+func (ge *goEncoder) writeFaultErrors(w io.Writer, d *wsdl.Definitions) error {
+	if len(ge.faultOrder) == 0 {
+		return nil
+	}
+	return faultErrorsT.Execute(w, ge.faultOrder)
+}
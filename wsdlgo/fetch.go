@@ -0,0 +1,145 @@
+package wsdlgo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// maxFetches is the number of documents downloaded concurrently.
+const maxFetches = 8
+
+// fetcher downloads the remote documents imported by a WSDL. Documents
+// are downloaded concurrently with prefetch, and each once; if dir is
+// set, they are cached on disk by URL and revalidated with their ETag.
+type fetcher struct {
+	http *http.Client
+	dir  string
+
+	mu      sync.Mutex
+	fetches map[string]*fetch
+	sem     chan struct{}
+}
+
+// fetch is the download of a document.
+type fetch struct {
+	done chan struct{}
+	b    []byte
+	err  error
+}
+
+func newFetcher(c *http.Client) *fetcher {
+	return &fetcher{
+		http:    c,
+		fetches: make(map[string]*fetch),
+		sem:     make(chan struct{}, maxFetches),
+	}
+}
+
+// prefetch starts downloading the documents at the URLs urls.
+func (f *fetcher) prefetch(urls []string) {
+	for _, u := range urls {
+		f.start(u)
+	}
+}
+
+// get returns the document at the URL u.
+func (f *fetcher) get(u string) ([]byte, error) {
+	ft := f.start(u)
+	<-ft.done
+	return ft.b, ft.err
+}
+
+// start starts downloading the document at the URL u, unless it is.
+func (f *fetcher) start(u string) *fetch {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if ft, ok := f.fetches[u]; ok {
+		return ft
+	}
+	ft := &fetch{done: make(chan struct{})}
+	f.fetches[u] = ft
+	go func() {
+		defer close(ft.done)
+		f.sem <- struct{}{}
+		defer func() { <-f.sem }()
+		ft.b, ft.err = f.download(u)
+	}()
+	return ft
+}
+
+// download downloads the document at the URL u, or returns its copy in
+// the cache if the server answers it is not modified.
+func (f *fetcher) download(u string) ([]byte, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	body, etag := f.cached(u)
+	if body != "" && etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := f.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && body != "" {
+		return os.ReadFile(body)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", u, resp.Status)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.store(u, b, resp.Header.Get("ETag"))
+	return b, nil
+}
+
+// cachePath returns the path of the cache files of the URL u, without
+// extension.
+func (f *fetcher) cachePath(u string) string {
+	sum := sha256.Sum256([]byte(u))
+	return filepath.Join(f.dir, hex.EncodeToString(sum[:]))
+}
+
+// cached returns the path of the cached copy of the document at the URL
+// u, and its ETag, or "" if it is not cached.
+func (f *fetcher) cached(u string) (body, etag string) {
+	if f.dir == "" {
+		return "", ""
+	}
+	p := f.cachePath(u)
+	b, err := os.ReadFile(p + ".etag")
+	if err != nil {
+		return "", ""
+	}
+	if _, err := os.Stat(p + ".body"); err != nil {
+		return "", ""
+	}
+	return p + ".body", strings.TrimSpace(string(b))
+}
+
+// store caches the document b at the URL u, if it has an ETag. Failures
+// to write the cache are not errors, as the document was downloaded.
+func (f *fetcher) store(u string, b []byte, etag string) {
+	if f.dir == "" || etag == "" {
+		return
+	}
+	if err := os.MkdirAll(f.dir, 0o755); err != nil {
+		return
+	}
+	p := f.cachePath(u)
+	if os.WriteFile(p+".body", b, 0o644) != nil {
+		return
+	}
+	os.WriteFile(p+".etag", []byte(etag), 0o644)
+}
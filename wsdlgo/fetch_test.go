@@ -0,0 +1,87 @@
+package wsdlgo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFetcherCache(t *testing.T) {
+	var mu sync.Mutex
+	downloads := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		mu.Lock()
+		downloads++
+		mu.Unlock()
+		fmt.Fprint(w, "<schema/>")
+	}))
+	defer s.Close()
+	dir := t.TempDir()
+	for i := 0; i < 2; i++ {
+		f := newFetcher(s.Client())
+		f.dir = dir
+		b, err := f.get(s.URL + "/a.xsd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "<schema/>" {
+			t.Fatalf("%d: want <schema/>, have %q", i, b)
+		}
+	}
+	if downloads != 1 {
+		t.Fatalf("want 1 download, have %d", downloads)
+	}
+}
+
+func TestFetcherPrefetch(t *testing.T) {
+	const n = 3
+	var wg sync.WaitGroup
+	wg.Add(n)
+	arrived := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(arrived)
+	}()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		wg.Done()
+		// Answer once all the documents are requested at once.
+		select {
+		case <-arrived:
+		case <-time.After(5 * time.Second):
+			http.Error(w, "not fetched concurrently", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	defer s.Close()
+	f := newFetcher(s.Client())
+	var urls []string
+	for i := 0; i < n; i++ {
+		urls = append(urls, fmt.Sprintf("%s/%d.xsd", s.URL, i))
+	}
+	f.prefetch(urls)
+	for i, u := range urls {
+		b, err := f.get(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("/%d.xsd", i); string(b) != want {
+			t.Fatalf("want %q, have %q", want, b)
+		}
+	}
+	if _, err := f.get(s.URL + "/missing"); err == nil {
+		t.Fatal("missing document fetched")
+	}
+}
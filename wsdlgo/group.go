@@ -0,0 +1,159 @@
+package wsdlgo
+
+import (
+	"sort"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+// cacheGroups caches the named model groups of s, which the sequences
+// and choices of complex types refer to.
+func (ge *goEncoder) cacheGroups(s *wsdl.Schema) {
+	ge.groups = make(map[string]*wsdl.Group)
+	for _, g := range s.Groups {
+		if g.Name != "" {
+			ge.groups[g.Name] = g
+		}
+	}
+}
+
+// expandGroups replaces the references to groups of the complex types by
+// the particles of the groups, after naming the anonymous types of their
+// elements after the groups. The decoded schema not keeping the order of
+// particles of different kinds, the elements of a group follow those of
+// the sequence or choice referring to it.
+func (ge *goEncoder) expandGroups() {
+	names := make([]string, 0, len(ge.groups))
+	for name := range ge.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g := ge.groups[name]
+		for _, el := range localElements(&wsdl.ComplexType{AllElements: g.AllElements, Sequence: g.Sequence, Choice: g.Choice}) {
+			ge.nameNestedType(g.Name, g.TargetNamespace, el)
+		}
+	}
+	for _, name := range ge.sortedComplexTypes() {
+		ge.expandTypeGroups(ge.ctypes[name])
+	}
+}
+
+// expandTypeGroups replaces the references to groups of ct by their
+// particles.
+func (ge *goEncoder) expandTypeGroups(ct *wsdl.ComplexType) {
+	if ct.Group != nil {
+		if ct.Sequence == nil {
+			ct.Sequence = &wsdl.Sequence{}
+		}
+		ct.Sequence.Groups = append(ct.Sequence.Groups, ct.Group)
+		ct.Group = nil
+	}
+	if ext := extension(ct.ComplexContent); ext != nil {
+		if ext.Group != nil {
+			if ext.Sequence == nil {
+				ext.Sequence = &wsdl.Sequence{}
+			}
+			ext.Sequence.Groups = append(ext.Sequence.Groups, ext.Group)
+			ext.Group = nil
+		}
+		ge.expandSequence(ext.Sequence, nil)
+		ge.expandChoice(ext.Choice, nil)
+	}
+	ge.expandSequence(ct.Sequence, nil)
+	ge.expandChoice(ct.Choice, nil)
+}
+
+// expandSequence appends the particles of the groups seq refers to to
+// its own, those of the groups seen excepted, which refer to themselves.
+func (ge *goEncoder) expandSequence(seq *wsdl.Sequence, seen map[string]bool) {
+	if seq == nil {
+		return
+	}
+	refs := seq.Groups
+	seq.Groups = nil
+	for _, ref := range refs {
+		g, ok := ge.group(ref, seen)
+		if !ok {
+			continue
+		}
+		if g.Sequence != nil {
+			seq.Elements = append(seq.Elements, groupElements(ref, g.Sequence.Elements)...)
+			for _, c := range g.Sequence.Choices {
+				seq.Choices = append(seq.Choices, groupChoice(ref, c))
+			}
+			seq.Any = append(seq.Any, g.Sequence.Any...)
+		}
+		if g.Choice != nil {
+			seq.Choices = append(seq.Choices, groupChoice(ref, g.Choice))
+		}
+		seq.Elements = append(seq.Elements, groupElements(ref, g.AllElements)...)
+	}
+	for _, c := range seq.Choices {
+		ge.expandChoice(c, seen)
+	}
+}
+
+// expandChoice appends the elements of the groups c refers to to its
+// alternatives, those of the groups seen excepted.
+func (ge *goEncoder) expandChoice(c *wsdl.Choice, seen map[string]bool) {
+	if c == nil {
+		return
+	}
+	refs := c.Groups
+	c.Groups = nil
+	for _, ref := range refs {
+		g, ok := ge.group(ref, seen)
+		if !ok {
+			continue
+		}
+		if g.Sequence != nil {
+			c.Elements = append(c.Elements, groupElements(ref, g.Sequence.Elements)...)
+			c.Any = append(c.Any, g.Sequence.Any...)
+		}
+		if g.Choice != nil {
+			c.Elements = append(c.Elements, groupElements(ref, g.Choice.Elements)...)
+			c.Any = append(c.Any, g.Choice.Any...)
+		}
+		c.Elements = append(c.Elements, groupElements(ref, g.AllElements)...)
+	}
+}
+
+// group returns the group ref refers to, its own references expanded,
+// unless unknown or one of the groups seen.
+func (ge *goEncoder) group(ref *wsdl.Group, seen map[string]bool) (*wsdl.Group, bool) {
+	name := trimns(ref.Ref)
+	g, ok := ge.groups[name]
+	if !ok || seen[name] {
+		return nil, false
+	}
+	inner := map[string]bool{name: true}
+	for n := range seen {
+		inner[n] = true
+	}
+	ge.expandSequence(g.Sequence, inner)
+	ge.expandChoice(g.Choice, inner)
+	return g, true
+}
+
+// groupElements returns copies of the elements els of the group ref
+// refers to, repeated as many times as the reference allows.
+func groupElements(ref *wsdl.Group, els []*wsdl.Element) []*wsdl.Element {
+	v := make([]*wsdl.Element, len(els))
+	for i, el := range els {
+		e := *el
+		if ref.Max != "" && ref.Max != "1" && (e.Max == "" || e.Max == "1") {
+			e.Max = ref.Max
+		}
+		v[i] = &e
+	}
+	return v
+}
+
+// groupChoice returns a copy of the choice c of the group ref refers to,
+// its alternatives repeated as many times as the reference allows.
+func groupChoice(ref *wsdl.Group, c *wsdl.Choice) *wsdl.Choice {
+	v := *c
+	v.Elements = groupElements(ref, c.Elements)
+	return &v
+}
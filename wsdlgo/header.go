@@ -0,0 +1,182 @@
+package wsdlgo
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+// cacheHeaders caches the message parts the bindings of the clients send
+// in the SOAP Header, by message and part name, and removes them from
+// the messages, whose other parts are the Body.
+func (ge *goEncoder) cacheHeaders() {
+	ge.headerParts = make(map[string]map[string]*wsdl.Part)
+	for _, c := range ge.clients {
+		for _, op := range c.binding.Operations {
+			for _, h := range append(op.InputHeaders, op.OutputHeaders...) {
+				name := trimns(h.Message)
+				m, ok := ge.messages[name]
+				if !ok {
+					continue
+				}
+				for _, p := range m.Parts {
+					if p.Name != h.Part {
+						continue
+					}
+					if ge.headerParts[name] == nil {
+						ge.headerParts[name] = make(map[string]*wsdl.Part)
+					}
+					ge.headerParts[name][p.Name] = p
+				}
+			}
+		}
+	}
+	for name, parts := range ge.headerParts {
+		m := *ge.messages[name]
+		m.Parts = nil
+		for _, p := range ge.messages[name].Parts {
+			if parts[p.Name] == nil {
+				m.Parts = append(m.Parts, p)
+			}
+		}
+		ge.messages[name] = &m
+	}
+}
+
+// inputHeaders returns the parameters of the soap:header parts of the
+// input of the named operation, named apart from the parameters in.
+func (ge *goEncoder) inputHeaders(name string, in []*parameter) []*parameter {
+	if op, ok := ge.soapOps[name]; ok {
+		return ge.headerParams(op.InputHeaders, in)
+	}
+	return nil
+}
+
+// outputHeaders returns the results of the soap:header parts of the
+// output of the named operation.
+func (ge *goEncoder) outputHeaders(name string) []*parameter {
+	if op, ok := ge.soapOps[name]; ok {
+		return ge.headerParams(op.OutputHeaders, nil)
+	}
+	return nil
+}
+
+// headerParams returns the parameters of the parts of the soap:headers
+// hs, named apart from params. They are pointers, nil if the header is
+// missing, to their element or, for type parts, to the part element in
+// the namespace of the soap:header.
+func (ge *goEncoder) headerParams(hs []*wsdl.BindingHeader, params []*parameter) []*parameter {
+	taken := map[string]bool{"p": true, "err": true, "ctx": true}
+	for _, p := range params {
+		taken[p.code] = true
+	}
+	var hps []*parameter
+	for _, h := range hs {
+		part, ok := ge.headerParts[trimns(h.Message)][h.Part]
+		if !ok {
+			continue
+		}
+		var name, t, tag string
+		switch {
+		case part.Element != "":
+			name = trimns(part.Element)
+			t, tag = ge.wsdl2goType(part.Element), name
+			if el, ok := ge.elements[name]; ok && el.Type != "" {
+				t = ge.wsdl2goType(trimns(el.Type))
+			}
+			if ns := ge.typeNamespaces[name]; ns != "" {
+				tag = ns + " " + name
+			}
+		default:
+			name, t, tag = part.Name, ge.wsdl2goType(part.Type), part.Name
+			if h.Namespace != "" {
+				tag = h.Namespace + " " + name
+			}
+		}
+		code := goSymbol(name)
+		code = strings.ToLower(code[:1]) + code[1:]
+		for taken[code] || isGoKeyword[code] {
+			code += "Header"
+		}
+		taken[code] = true
+		hps = append(hps, &parameter{code: code, dataType: "*" + strings.TrimPrefix(t, "*"), header: tag})
+	}
+	return hps
+}
+
+// splitHeaders returns the parameters of list sent in the Body, and the
+// soap:header ones.
+func splitHeaders(list []*parameter) (body, headers []*parameter) {
+	for _, p := range list {
+		if p.header != "" {
+			headers = append(headers, p)
+		} else {
+			body = append(body, p)
+		}
+	}
+	return body, headers
+}
+
+// headerField returns the field holding the soap:header parameter p.
+func headerField(p *parameter) string {
+	return strings.ToUpper(p.code[:1]) + p.code[1:]
+}
+
+// headerFields returns the fields of the struct of the SOAP Header of
+// the soap:header parameters list.
+func headerFields(list []*parameter) string {
+	var b bytes.Buffer
+	for _, p := range list {
+		fmt.Fprintf(&b, "%s %s `xml:%q`\n", headerField(p), p.dataType, p.header)
+	}
+	return b.String()
+}
+
+// headerCall is how a client method sends the soap:header parameters of
+// its operation in η, and returns those of the response from θ.
+type headerCall struct {
+	Headers string // declarations of η and θ
+	Client  string // the client calling the operation with them
+	Results string // results of θ, before the error
+}
+
+// newHeaderCall returns the headerCall of the soap:header parameters in
+// and results out of a method.
+func newHeaderCall(in, out []*parameter) *headerCall {
+	hc := &headerCall{Client: "p.cli"}
+	if len(in) == 0 && len(out) == 0 {
+		return hc
+	}
+	var b bytes.Buffer
+	hin, hout := "nil", "nil"
+	if len(in) > 0 {
+		var args []string
+		for _, p := range in {
+			args = append(args, headerField(p)+": "+p.code)
+		}
+		fmt.Fprintf(&b, "η := struct {\n%s}{%s}\n", headerFields(in), strings.Join(args, ", "))
+		hin = "&η"
+	}
+	if len(out) > 0 {
+		fmt.Fprintf(&b, "θ := struct {\n%s}{}\n", headerFields(out))
+		hout = "&θ"
+		for _, p := range out {
+			hc.Results += "θ." + headerField(p) + ", "
+		}
+	}
+	hc.Headers = b.String()
+	hc.Client = fmt.Sprintf("p.cli.WithHeaders(%s, %s)", hin, hout)
+	return hc
+}
+
+// headerDefaults returns the results ret of a failed call, ending with
+// the error, with those of the soap:header results out before it.
+func headerDefaults(ret []string, out []*parameter) []string {
+	def := append([]string(nil), ret[:len(ret)-1]...)
+	for range out {
+		def = append(def, "nil")
+	}
+	return append(def, ret[len(ret)-1])
+}
@@ -0,0 +1,120 @@
+package wsdlgo
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+var mockT = template.Must(template.New("mock").Parse(`
+// {{.Name}} is a mock implementation of the {{.Interface}}
+// interface, for tests of code using it. Its methods record their calls,
+// and return the results of their function field, or an error if it's
+// not set. It is safe for concurrent use once the fields are set.
+type {{.Name}} struct {
+{{- range .Funcs}}
+	{{.Name}}Func func({{.Input}}) ({{.Output}})
+{{- end}}
+
+	mu    sync.Mutex
+	calls []{{.Name}}Call
+}
+
+var _ {{.Interface}} = (*{{.Name}})(nil)
+
+// {{.Name}}Call is a call of a method of {{.Name}}.
+type {{.Name}}Call struct {
+	Method string // name of the method
+	Args   []any  // arguments of the call
+}
+
+// Calls returns the calls of the methods of mock, in order.
+func (mock *{{.Name}}) Calls() []{{.Name}}Call {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]{{.Name}}Call(nil), mock.calls...)
+}
+
+func (mock *{{.Name}}) record(method string, args ...any) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	mock.calls = append(mock.calls, {{.Name}}Call{Method: method, Args: args})
+}
+{{range .Funcs}}
+// {{.Name}} calls {{.Name}}Func.
+func (mock *{{$.Name}}) {{.Name}}({{.Input}}) ({{.Output}}) {
+	mock.record({{printf "%q" .Name}}{{range .Args}}, {{.}}{{end}})
+	if mock.{{.Name}}Func != nil {
+		return mock.{{.Name}}Func({{if $.Context}}ctx, {{end}}{{range .Args}}{{.}}, {{end}})
+	}
+	{{- range .Zero}}
+	var {{.}}
+	{{- end}}
+	return {{range .Results}}{{.}}, {{end}}errors.New("{{$.Name}}: {{.Name}}Func not set")
+}
+{{end}}
+`))
+
+// mockFunc is a method of the generated mock.
+type mockFunc struct {
+	Name    string
+	Input   string
+	Output  string
+	Args    []string
+	Zero    []string // declarations of the zero results
+	Results []string
+}
+
+// writeMock writes a mock implementation of the port type interface,
+// recording calls and returning the results of function fields.
+func (ge *goEncoder) writeMock(w io.Writer, d *wsdl.Definitions) error {
+	var funcs []*mockFunc
+	for _, fn := range ge.funcnames {
+		op := ge.funcs[fn]
+		if _, exists := ge.soapOps[op.Name]; !exists {
+			continue
+		}
+		in, err := ge.inputParams(op)
+		if err != nil {
+			return err
+		}
+		out, err := ge.outputParams(op)
+		if err != nil {
+			return err
+		}
+		mf := &mockFunc{
+			Name:   goSymbol(op.Name),
+			Input:  ge.methodParams(in),
+			Output: strings.Join(codeParams(out), ","),
+		}
+		for _, p := range in {
+			mf.Args = append(mf.Args, maskKeywordUsage(p.code))
+		}
+		for i, p := range out[:len(out)-1] {
+			name := fmt.Sprintf("out%d", i)
+			mf.Zero = append(mf.Zero, name+" "+p.dataType)
+			mf.Results = append(mf.Results, name)
+		}
+		funcs = append(funcs, mf)
+	}
+	if len(funcs) == 0 {
+		return nil
+	}
+	ge.needsStdPkg["errors"] = true
+	ge.needsStdPkg["sync"] = true
+	n := goSymbol(d.PortType.Name)
+	return mockT.Execute(w, &struct {
+		Name      string
+		Interface string
+		Funcs     []*mockFunc
+		Context   bool
+	}{
+		n + "Mock",
+		n,
+		funcs,
+		ge.context,
+	})
+}
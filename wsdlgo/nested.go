@@ -0,0 +1,114 @@
+package wsdlgo
+
+import (
+	"strconv"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+// cacheNestedTypes names the anonymous complex types of the local
+// elements of the complex types, and caches them as complex types of
+// those names, so that they get structs of their own.
+func (ge *goEncoder) cacheNestedTypes() {
+	for _, name := range ge.sortedComplexTypes() {
+		ct := ge.ctypes[name]
+		for _, el := range localElements(ct) {
+			ge.nameNestedType(ct.Name, ct.TargetNamespace, el)
+		}
+	}
+}
+
+// nameNestedType names the anonymous complex type of the element el of
+// the type or group named parent in the namespace ns after both, e.g.
+// OrderItem for the element Item of Order, numbered from 2 if another
+// type has the name, then those of its own elements after it. Wrapper
+// types, whose only element is generated in place, are not named.
+func (ge *goEncoder) nameNestedType(parent, ns string, el *wsdl.Element) {
+	if el.Type != "" || el.Ref != "" || el.ComplexType == nil {
+		return
+	}
+	if _, ok := ge.nestedTypes[el.ComplexType]; ok {
+		return
+	}
+	if seq := wrapperSequence(el.ComplexType); seq != nil {
+		if len(seq.Elements) == 1 {
+			ge.nameNestedType(parent, ns, seq.Elements[0])
+		}
+		return
+	}
+	base := goSymbol(parent) + goSymbol(el.Name)
+	name := base
+	for i := 2; ge.typeExists(name); i++ {
+		name = base + strconv.Itoa(i)
+	}
+	ct := *el.ComplexType
+	ct.Name, ct.TargetNamespace = name, ns
+	ge.ctypes[name] = &ct
+	if pns, ok := ge.typeNamespaces[parent]; ok {
+		ge.typeNamespaces[name] = pns
+	}
+	if ge.nestedTypes == nil {
+		ge.nestedTypes = make(map[*wsdl.ComplexType]string)
+	}
+	ge.nestedTypes[el.ComplexType] = name
+	ge.expandTypeGroups(&ct)
+	for _, nel := range localElements(&ct) {
+		ge.nameNestedType(name, ns, nel)
+	}
+}
+
+// typeExists tells whether a simple or complex type is named name.
+func (ge *goEncoder) typeExists(name string) bool {
+	if _, ok := ge.stypes[name]; ok {
+		return true
+	}
+	_, ok := ge.complexType(name)
+	return ok
+}
+
+// wrapperSequence returns the sequence of the anonymous type ct, or its
+// choice as one, if the element of the type is generated as its only
+// element, e.g. a slice of the items of an array, or as soap.RawXML if
+// it has xsd:any wildcards only, else nil.
+func wrapperSequence(ct *wsdl.ComplexType) *wsdl.Sequence {
+	if len(ct.Attributes) > 0 || len(ct.AttributeGroups) > 0 || ct.AnyAttribute != nil {
+		return nil
+	}
+	seq := ct.Sequence
+	if seq == nil && ct.Choice != nil {
+		seq = &wsdl.Sequence{
+			ComplexTypes: ct.Choice.ComplexTypes,
+			Elements:     ct.Choice.Elements,
+			Any:          ct.Choice.Any,
+		}
+	}
+	if seq == nil || len(seq.Choices) > 0 {
+		return nil
+	}
+	if len(seq.Elements) == 1 || len(seq.Any) > 0 && len(seq.Elements) == 0 {
+		return seq
+	}
+	return nil
+}
+
+// localElements returns the elements declared in ct.
+func localElements(ct *wsdl.ComplexType) []*wsdl.Element {
+	els := append([]*wsdl.Element(nil), ct.AllElements...)
+	seqElements := func(seq *wsdl.Sequence, choice *wsdl.Choice) {
+		if seq != nil {
+			els = append(els, seq.Elements...)
+			for _, c := range seq.Choices {
+				els = append(els, c.Elements...)
+			}
+		}
+		if choice != nil {
+			els = append(els, choice.Elements...)
+		}
+	}
+	if ext := extension(ct.ComplexContent); ext != nil {
+		els = append(els, ext.AllElements...)
+		seqElements(ext.Sequence, ext.Choice)
+	}
+	seqElements(ct.Sequence, ct.Choice)
+	return els
+}
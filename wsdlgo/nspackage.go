@@ -0,0 +1,154 @@
+package wsdlgo
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+// nsPackage is the Go package of the types of a schema namespace.
+type nsPackage struct {
+	namespace string
+	path      string // import path
+	name      string
+	shared    bool // types shared by the WSDLs of a Batch
+}
+
+// SetNamespacePackage generates the types of the schemas of namespace
+// in the package of importPath, generated by EncodeDir next to the
+// directory of the WSDL package, in the directory named after the
+// package. The WSDL package, and those of other namespaces, refer to
+// them as types of the package.
+func (ge *goEncoder) SetNamespacePackage(namespace, importPath string) {
+	if ge.nsPackages == nil {
+		ge.nsPackages = make(map[string]*nsPackage)
+	}
+	ge.nsPackages[namespace] = &nsPackage{
+		namespace: namespace,
+		path:      importPath,
+		name:      goPackageName(path.Base(importPath)),
+	}
+}
+
+// goPackageName returns the package name for the last element of an
+// import path, lowercase without the characters Go doesn't allow.
+func goPackageName(s string) string {
+	name := strings.ToLower(invalidGoSymbol.ReplaceAllString(s, ""))
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = fallbackPackageName + name
+	}
+	return name
+}
+
+// typePackage returns the namespace package of the type name, or nil if
+// it is generated in the WSDL package.
+func (ge *goEncoder) typePackage(name string) *nsPackage {
+	if p, ok := ge.typePackages[trimns(name)]; ok {
+		return p
+	}
+	ns, ok := ge.typeNamespaces[trimns(name)]
+	if !ok {
+		return nil
+	}
+	return ge.nsPackages[ns]
+}
+
+// inPackage returns whether the type name is generated in the package
+// being generated.
+func (ge *goEncoder) inPackage(name string) bool {
+	if ge.owned != nil && !ge.owned[name] {
+		return false
+	}
+	return ge.typePackage(name) == ge.pkg
+}
+
+// qualifiedType returns the Go type sym of the type name, qualified by
+// its package when it is generated in another.
+func (ge *goEncoder) qualifiedType(name, sym string) string {
+	p := ge.typePackage(name)
+	if p == ge.pkg {
+		return sym
+	}
+	if p == nil && ge.pkg.shared {
+		// Shared types can't refer to those of the WSDLs.
+		ge.unshared[ge.curType] = true
+		return sym
+	}
+	if p == nil {
+		ge.pkgErr = fmt.Errorf("type %s of namespace %s refers to %s of the WSDL package", sym, ge.pkg.namespace, name)
+		return sym
+	}
+	ge.needsExtPkg[p.path] = true
+	return p.name + "." + sym
+}
+
+// importName returns the name of the package of importPath.
+func (ge *goEncoder) importName(importPath string) string {
+	for _, p := range ge.nsPackages {
+		if p.path == importPath {
+			return p.name
+		}
+	}
+	return path.Base(importPath)
+}
+
+// addTypeNamespaces records the namespace of the types and elements of
+// the schema s.
+func (ge *goEncoder) addTypeNamespaces(s *wsdl.Schema) {
+	if ge.typeNamespaces == nil {
+		ge.typeNamespaces = make(map[string]string)
+	}
+	for _, ct := range s.ComplexTypes {
+		ge.typeNamespaces[ct.Name] = schemaNamespace(s, ct.TargetNamespace)
+	}
+	for _, st := range s.SimpleTypes {
+		ge.typeNamespaces[st.Name] = schemaNamespace(s, st.TargetNamespace)
+	}
+	for _, el := range s.Elements {
+		if _, ok := ge.typeNamespaces[el.Name]; !ok {
+			ge.typeNamespaces[el.Name] = schemaNamespace(s, el.TargetNamespace)
+		}
+	}
+}
+
+// schemaNamespace returns ns, the namespace of a type or element of s
+// decoded with it, or the target namespace of s if it has none, such as
+// that of a schema included.
+func schemaNamespace(s *wsdl.Schema, ns string) string {
+	if ns == "" {
+		return s.TargetNamespace
+	}
+	return ns
+}
+
+// encodeNamespacePackages generates the packages of the namespaces of
+// SetNamespacePackage that have types, in the directories named after
+// them in root.
+func (ge *goEncoder) encodeNamespacePackages(d *wsdl.Definitions, root string) error {
+	var pkgs []*nsPackage
+	for _, p := range ge.nsPackages {
+		pkgs = append(pkgs, p)
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].path < pkgs[j].path })
+	main := ge.packageName
+	defer func() { ge.pkg, ge.packageName = nil, main }()
+	for _, p := range pkgs {
+		ge.pkg, ge.packageName = p, PackageName(p.name)
+		ge.files = make(map[string]*bytes.Buffer)
+		if err := ge.writeGoTypes(ge.section(nil, "types.go"), d); err != nil {
+			return err
+		}
+		if ge.pkgErr != nil {
+			return ge.pkgErr
+		}
+		if err := ge.writeFiles(filepath.Join(root, p.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package wsdlgo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+// OptionalStyle is how the fields of the elements of simple types map to
+// Go, the optional ones of minOccurs="0" and the required ones.
+type OptionalStyle int
+
+const (
+	// PointerOptional maps the elements to pointers, omitted when nil,
+	// but those of an explicit minOccurs of 1 or more, which are values:
+	// the default minOccurs of 1 is taken as 0.
+	PointerOptional OptionalStyle = iota
+
+	// ValueOptional maps the elements to values, those of optional
+	// elements omitted when zero, so that an empty element is absent.
+	ValueOptional
+
+	// WrapperOptional maps the required elements to values, and the
+	// optional ones to soap.Optional values, omitted unless present
+	// however empty.
+	WrapperOptional
+)
+
+var optionalStyles = []string{"pointer", "value", "wrapper"}
+
+// String returns the name of the style, as accepted by Set.
+func (s OptionalStyle) String() string {
+	if int(s) < len(optionalStyles) {
+		return optionalStyles[s]
+	}
+	return fmt.Sprintf("OptionalStyle(%d)", int(s))
+}
+
+// Set sets the style of its name, pointer, value or wrapper,
+// implementing the flag.Value interface.
+func (s *OptionalStyle) Set(name string) error {
+	for i, v := range optionalStyles {
+		if v == name {
+			*s = OptionalStyle(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown optional style %q, want one of %s", name, strings.Join(optionalStyles, ", "))
+}
+
+// SetOptionalStyle sets how the fields of elements of simple types are
+// generated, PointerOptional by default.
+func (ge *goEncoder) SetOptionalStyle(s OptionalStyle) {
+	ge.optional = s
+}
+
+// optionalField returns the type and tag of the field of the element el,
+// required or not, of the Go type typ, of slices if slice is [], and tag,
+// in the optional style of ge other than PointerOptional. It returns
+// false for elements of complex types, pointers in any style, byte
+// slices of binary types excepted. Nillable
+// elements are pointers too, or soap.Nillable values with SetNillable.
+func (ge *goEncoder) optionalField(el *wsdl.Element, required bool, slice, typ, tag string) (string, string, bool) {
+	if strings.HasPrefix(typ, "*") || strings.HasPrefix(typ, "soap.") && !binaryType(typ) || typ == "interface{}" {
+		return "", "", false
+	}
+	switch {
+	case el.Nillable && ge.nillable:
+		ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+		typ = "soap.Nillable[" + typ + "]"
+	case el.Nillable:
+		return slice + "*" + typ, tag + ",omitempty", true
+	}
+	switch {
+	case required:
+		return slice + typ, tag, true
+	case slice != "":
+		return slice + typ, tag + ",omitempty", true
+	case ge.optional == WrapperOptional:
+		ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+		return "soap.Optional[" + typ + "]", tag + ",omitempty", true
+	case strings.HasPrefix(typ, "soap.Nillable["):
+		return "*" + typ, tag + ",omitempty", true
+	}
+	return typ, tag + ",omitempty", true
+}
+
+// optionalValue returns the Go type of the value of the soap.Optional
+// type typ, or false if typ is none.
+func optionalValue(typ string) (string, bool) {
+	v, ok := strings.CutPrefix(typ, "soap.Optional[")
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSuffix(v, "]"), true
+}
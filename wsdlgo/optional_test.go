@@ -0,0 +1,13 @@
+package wsdlgo
+
+import "testing"
+
+func TestOptionalStyleSet(t *testing.T) {
+	var s OptionalStyle
+	if err := s.Set("wrapper"); err != nil || s != WrapperOptional {
+		t.Fatalf("want wrapper, have %v, %v", s, err)
+	}
+	if err := s.Set("nullable"); err == nil {
+		t.Fatal("nullable style accepted")
+	}
+}
@@ -0,0 +1,155 @@
+package wsdlgo
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+// WS-SecurityPolicy and WS-Addressing metadata namespaces of the
+// assertions the generated client supports.
+var (
+	securityPolicyNamespaces = map[string]bool{
+		"http://schemas.xmlsoap.org/ws/2005/07/securitypolicy":      true,
+		"http://docs.oasis-open.org/ws-sx/ws-securitypolicy/200702": true,
+		"http://docs.oasis-open.org/ws-sx/ws-securitypolicy/200802": true,
+	}
+	addressingPolicyNamespaces = map[string]bool{
+		"http://www.w3.org/2006/05/addressing/wsdl":     true,
+		"http://www.w3.org/2007/05/addressing/metadata": true,
+		"http://www.w3.org/2007/02/addressing/metadata": true,
+	}
+)
+
+// policyRequirements are the wsse.Policy fields of the generated client.
+type policyRequirements struct {
+	TLS           bool
+	UsernameToken bool
+	Timestamp     bool
+	Addressing    bool
+}
+
+// unsupportedAssertionError reports a policy assertion the generated
+// client can't satisfy.
+type unsupportedAssertionError struct {
+	Binding   string
+	Assertion *wsdl.PolicyAssertion
+}
+
+func (e *unsupportedAssertionError) Error() string {
+	return fmt.Sprintf("binding %s requires unsupported WS-Policy assertion %s (namespace %s)",
+		e.Binding, e.Assertion.Name.Local, e.Assertion.Name.Space)
+}
+
+// assertionHandler applies an assertion to the requirements, or returns
+// an error if it is not supported.
+type assertionHandler func(r *policyRequirements, a *wsdl.PolicyAssertion) error
+
+// bindingPolicy returns the requirements of the policy of the binding,
+// from the first of its alternatives the generated client supports, or
+// nil if the binding has no policy.
+func (ge *goEncoder) bindingPolicy(d *wsdl.Definitions) (*policyRequirements, error) {
+	alts, err := d.BindingPolicy()
+	if err != nil || alts == nil {
+		return nil, err
+	}
+	unsupported := func(r *policyRequirements, a *wsdl.PolicyAssertion) error {
+		return &unsupportedAssertionError{Binding: d.Binding.Name, Assertion: a}
+	}
+	ignore := func(r *policyRequirements, a *wsdl.PolicyAssertion) error { return nil }
+	nested := func(h assertionHandler) assertionHandler {
+		return func(r *policyRequirements, a *wsdl.PolicyAssertion) error {
+			return r.satisfy(a.Policy, h)
+		}
+	}
+	security := func(handlers map[string]assertionHandler) assertionHandler {
+		return func(r *policyRequirements, a *wsdl.PolicyAssertion) error {
+			if h, ok := handlers[a.Name.Local]; ok && securityPolicyNamespaces[a.Name.Space] {
+				return h(r, a)
+			}
+			return unsupported(r, a)
+		}
+	}
+	usernameToken := security(map[string]assertionHandler{
+		"WssUsernameToken10": ignore,
+		"WssUsernameToken11": ignore,
+	})
+	supportingTokens := security(map[string]assertionHandler{
+		"UsernameToken": func(r *policyRequirements, a *wsdl.PolicyAssertion) error {
+			r.UsernameToken = true
+			return r.satisfy(a.Policy, usernameToken)
+		},
+	})
+	transportToken := security(map[string]assertionHandler{
+		"HttpsToken": func(r *policyRequirements, a *wsdl.PolicyAssertion) error {
+			return r.satisfy(a.Policy, unsupported)
+		},
+	})
+	transportBinding := security(map[string]assertionHandler{
+		"TransportToken": nested(transportToken),
+		"AlgorithmSuite": ignore,
+		"Layout":         ignore,
+		"IncludeTimestamp": func(r *policyRequirements, a *wsdl.PolicyAssertion) error {
+			r.Timestamp = true
+			return nil
+		},
+	})
+	top := func(r *policyRequirements, a *wsdl.PolicyAssertion) error {
+		if addressingPolicyNamespaces[a.Name.Space] {
+			switch a.Name.Local {
+			case "UsingAddressing", "Addressing":
+				r.Addressing = true
+				return nil
+			}
+		}
+		if !securityPolicyNamespaces[a.Name.Space] {
+			return unsupported(r, a)
+		}
+		switch a.Name.Local {
+		case "TransportBinding":
+			r.TLS = true
+			return r.satisfy(a.Policy, transportBinding)
+		case "SupportingTokens", "SignedSupportingTokens", "EncryptedSupportingTokens", "SignedEncryptedSupportingTokens":
+			return r.satisfy(a.Policy, supportingTokens)
+		case "Wss10", "Wss11", "Trust10", "Trust13":
+			return nil
+		}
+		return unsupported(r, a)
+	}
+	r := &policyRequirements{}
+	if err := r.satisfy(alts, top); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// satisfy adds the requirements of the first alternative of alts whose
+// assertions h supports, or returns the error of the first alternative.
+func (r *policyRequirements) satisfy(alts []wsdl.PolicyAlternative, h assertionHandler) error {
+	var first error
+	for _, alt := range alts {
+		try := *r
+		var err error
+		for _, a := range alt {
+			if err = h(&try, a); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			*r = try
+			return nil
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// writePolicy writes the Policy variable of the binding.
+func (ge *goEncoder) writePolicy(w io.Writer, r *policyRequirements) {
+	ge.writeComments(w, "Policy", "Policy is the security required by the WS-Policy of the binding.")
+	fmt.Fprintf(w, "var Policy = wsse.Policy{TLS: %t, UsernameToken: %t, Timestamp: %t, Addressing: %t}\n\n",
+		r.TLS, r.UsernameToken, r.Timestamp, r.Addressing)
+}
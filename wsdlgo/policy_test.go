@@ -0,0 +1,48 @@
+package wsdlgo
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncoderPolicy(t *testing.T) {
+	d := LoadDefinition(t, "policy.wsdl", nil)
+	var have bytes.Buffer
+	if err := NewEncoder(&have).Encode(d); err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join("testdata", "policy.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(have.Bytes(), want) {
+		err := Diff("_diff", "go", want, have.Bytes())
+		t.Fatalf("policy.wsdl != policy.golden: %v\ngenerated:\n%s\n", err, have.Bytes())
+	}
+	if err := Compile(have.Bytes()); err != nil {
+		t.Fatalf("policy.golden does not compile: %v", err)
+	}
+
+	// Without the TransportBinding alternative, only the unsupported
+	// AsymmetricBinding is left.
+	d = LoadDefinition(t, "policy.wsdl", nil)
+	d.Policies[0].Alternatives = d.Policies[0].Alternatives[:1]
+	err = NewEncoder(&bytes.Buffer{}).Encode(d)
+	var uerr *unsupportedAssertionError
+	if !errors.As(err, &uerr) || uerr.Assertion.Name.Local != "AsymmetricBinding" {
+		t.Fatalf("want unsupported AsymmetricBinding, have %v", err)
+	}
+
+	have.Reset()
+	enc := NewEncoder(&have)
+	enc.SetIgnorePolicy(true)
+	if err := enc.Encode(d); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(have.Bytes(), []byte("wsse.Policy")) {
+		t.Fatalf("Policy generated although ignored:\n%s", have.Bytes())
+	}
+}
@@ -0,0 +1,263 @@
+package wsdlgo
+
+import (
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+// importRedefine imports the schema redefined, or overridden, by r in
+// the namespace ns, relative to the document at base, then applies the
+// redefinitions to the components of d.
+func (ge *goEncoder) importRedefine(d *wsdl.Definitions, base, ns string, r *wsdl.Redefine, override bool) error {
+	if err := ge.importSchemaDocument(d, base, r.Location); err != nil {
+		return err
+	}
+	ge.redefine(&d.Schema, ns, r, override)
+	return nil
+}
+
+// redefine replaces the components of s in the namespace ns redefined,
+// or overridden, by r: redefinitions of types and groups refer to the
+// components they redefine, which they extend or restrict.
+func (ge *goEncoder) redefine(s *wsdl.Schema, ns string, r *wsdl.Redefine, override bool) {
+	for _, st := range r.SimpleTypes {
+		st.TargetNamespace = ns
+		for i, v := range s.SimpleTypes {
+			if v.Name == st.Name && (v.TargetNamespace == ns || v.TargetNamespace == "") {
+				s.SimpleTypes[i] = st
+				if !override {
+					s.SimpleTypes[i] = redefinedSimpleType(v, st)
+				}
+			}
+		}
+	}
+	for _, ct := range r.ComplexTypes {
+		ct.TargetNamespace = ns
+		for i, v := range s.ComplexTypes {
+			if v.Name == ct.Name && (v.TargetNamespace == ns || v.TargetNamespace == "") {
+				s.ComplexTypes[i] = ct
+				if !override {
+					s.ComplexTypes[i] = redefinedComplexType(v, ct)
+				}
+			}
+		}
+	}
+	for _, g := range r.Groups {
+		g.TargetNamespace = ns
+		for i, v := range s.Groups {
+			if v.Name == g.Name {
+				s.Groups[i] = g
+				if !override {
+					s.Groups[i] = redefinedGroup(v, g)
+				}
+			}
+		}
+	}
+	for _, g := range r.AttributeGroups {
+		for i, v := range s.AttributeGroups {
+			if v.Name == g.Name {
+				s.AttributeGroups[i] = g
+				if !override {
+					s.AttributeGroups[i] = redefinedAttributeGroup(v, g)
+				}
+			}
+		}
+	}
+	for _, el := range r.Elements {
+		for i, v := range s.Elements {
+			if v.Name == el.Name {
+				s.Elements[i] = el
+			}
+		}
+	}
+	for _, attr := range r.Attributes {
+		for i, v := range s.Attributes {
+			if v.Name == attr.Name {
+				s.Attributes[i] = attr
+			}
+		}
+	}
+}
+
+// redefinedSimpleType returns the simple type orig restricted by its
+// redefinition st: the facets st sets replace those of orig.
+func redefinedSimpleType(orig, st *wsdl.SimpleType) *wsdl.SimpleType {
+	restr := st.Restriction
+	if restr == nil || trimns(restr.Base) != orig.Name || orig.Restriction == nil {
+		return st
+	}
+	v := *orig
+	r := *orig.Restriction
+	if len(restr.Enum) > 0 {
+		r.Enum = restr.Enum
+	}
+	if len(restr.Patterns) > 0 {
+		r.Patterns = restr.Patterns
+	}
+	for _, f := range []struct{ v, orig **wsdl.Facet }{
+		{&restr.Length, &r.Length},
+		{&restr.MinLength, &r.MinLength},
+		{&restr.MaxLength, &r.MaxLength},
+		{&restr.MinInclusive, &r.MinInclusive},
+		{&restr.MaxInclusive, &r.MaxInclusive},
+		{&restr.MinExclusive, &r.MinExclusive},
+		{&restr.MaxExclusive, &r.MaxExclusive},
+		{&restr.TotalDigits, &r.TotalDigits},
+		{&restr.FractionDigits, &r.FractionDigits},
+	} {
+		if *f.v != nil {
+			*f.orig = *f.v
+		}
+	}
+	v.Restriction = &r
+	return &v
+}
+
+// redefinedComplexType returns the complex type orig extended, or
+// restricted, by its redefinition ct: the particles and attributes of
+// an extension follow those of orig, and those of a restriction replace
+// them.
+func redefinedComplexType(orig, ct *wsdl.ComplexType) *wsdl.ComplexType {
+	v := *orig
+	v.Doc = ct.Doc
+	if v.Doc == "" {
+		v.Doc = orig.Doc
+	}
+	var ext *wsdl.Extension
+	var restr *wsdl.Restriction
+	if ct.ComplexContent != nil {
+		ext, restr = ct.ComplexContent.Extension, ct.ComplexContent.Restriction
+	} else if ct.SimpleContent != nil {
+		ext = ct.SimpleContent.Extension
+	}
+	switch {
+	case ext != nil && trimns(ext.Base) == orig.Name:
+		// The extension of the base of orig, if any, has its content.
+		target := &v.Sequence
+		attrs, groups := &v.Attributes, &v.AttributeGroups
+		if oext := extension(orig.ComplexContent); oext != nil {
+			e := *oext
+			v.ComplexContent = &wsdl.ComplexContent{Extension: &e}
+			target = &e.Sequence
+			attrs, groups = &e.Attributes, &e.AttributeGroups
+		} else if oext := simpleExtension(orig.SimpleContent); oext != nil {
+			e := *oext
+			v.SimpleContent = &wsdl.SimpleContent{Extension: &e}
+			attrs, groups = &e.Attributes, &e.AttributeGroups
+		}
+		if ext.Sequence != nil || ext.Choice != nil || ext.Group != nil {
+			seq := &wsdl.Sequence{}
+			if *target != nil {
+				*seq = **target
+			} else if v.Choice != nil && target == &v.Sequence {
+				seq.Choices = []*wsdl.Choice{v.Choice}
+				v.Choice = nil
+			}
+			if ext.Sequence != nil {
+				seq.Elements = append(append([]*wsdl.Element(nil), seq.Elements...), ext.Sequence.Elements...)
+				seq.Choices = append(append([]*wsdl.Choice(nil), seq.Choices...), ext.Sequence.Choices...)
+				seq.Any = append(append([]*wsdl.AnyElement(nil), seq.Any...), ext.Sequence.Any...)
+				seq.Groups = append(append([]*wsdl.Group(nil), seq.Groups...), ext.Sequence.Groups...)
+			}
+			if ext.Choice != nil {
+				seq.Choices = append(append([]*wsdl.Choice(nil), seq.Choices...), ext.Choice)
+			}
+			if ext.Group != nil {
+				seq.Groups = append(append([]*wsdl.Group(nil), seq.Groups...), ext.Group)
+			}
+			*target = seq
+		}
+		*attrs = append(append([]*wsdl.Attribute(nil), *attrs...), ext.Attributes...)
+		*groups = append(append([]*wsdl.AttributeGroup(nil), *groups...), ext.AttributeGroups...)
+		if ext.AnyAttribute != nil {
+			v.AnyAttribute = ext.AnyAttribute
+		}
+	case restr != nil && trimns(restr.Base) == orig.Name:
+		v.Sequence, v.Choice, v.Group, v.AllElements = restr.Sequence, restr.Choice, nil, nil
+		v.Attributes = restrictedAttributes(orig.Attributes, restr.Attributes)
+	default:
+		return ct
+	}
+	return &v
+}
+
+// restrictedAttributes returns the attributes attrs, replaced by those
+// of restr of the same names, prohibited attributes included.
+func restrictedAttributes(attrs, restr []*wsdl.Attribute) []*wsdl.Attribute {
+	v := make([]*wsdl.Attribute, 0, len(attrs))
+	for _, attr := range attrs {
+		for _, r := range restr {
+			if r.Name == attr.Name && r.Name != "" || r.Ref == attr.Ref && r.Ref != "" {
+				attr = r
+			}
+		}
+		v = append(v, attr)
+	}
+	return v
+}
+
+// redefinedGroup returns the group g, its references to the group orig
+// it redefines replaced by the particles of orig.
+func redefinedGroup(orig, g *wsdl.Group) *wsdl.Group {
+	v := *g
+	self := func(refs []*wsdl.Group) ([]*wsdl.Group, bool) {
+		var others []*wsdl.Group
+		found := false
+		for _, ref := range refs {
+			if trimns(ref.Ref) == orig.Name {
+				found = true
+			} else {
+				others = append(others, ref)
+			}
+		}
+		return others, found
+	}
+	if g.Sequence != nil {
+		seq := *g.Sequence
+		if refs, ok := self(seq.Groups); ok {
+			seq.Groups = refs
+			if o := orig.Sequence; o != nil {
+				seq.Elements = append(append([]*wsdl.Element(nil), o.Elements...), seq.Elements...)
+				seq.Choices = append(append([]*wsdl.Choice(nil), o.Choices...), seq.Choices...)
+				seq.Any = append(append([]*wsdl.AnyElement(nil), o.Any...), seq.Any...)
+				seq.Groups = append(append([]*wsdl.Group(nil), o.Groups...), seq.Groups...)
+			}
+			if orig.Choice != nil {
+				seq.Choices = append([]*wsdl.Choice{orig.Choice}, seq.Choices...)
+			}
+			seq.Elements = append(append([]*wsdl.Element(nil), orig.AllElements...), seq.Elements...)
+		}
+		v.Sequence = &seq
+	}
+	if g.Choice != nil {
+		c := *g.Choice
+		if refs, ok := self(c.Groups); ok {
+			c.Groups = refs
+			if o := orig.Choice; o != nil {
+				c.Elements = append(append([]*wsdl.Element(nil), o.Elements...), c.Elements...)
+				c.Any = append(append([]*wsdl.AnyElement(nil), o.Any...), c.Any...)
+				c.Groups = append(append([]*wsdl.Group(nil), o.Groups...), c.Groups...)
+			}
+		}
+		v.Choice = &c
+	}
+	return &v
+}
+
+// redefinedAttributeGroup returns the attribute group g, its reference
+// to the group orig it redefines replaced by the attributes of orig.
+func redefinedAttributeGroup(orig, g *wsdl.AttributeGroup) *wsdl.AttributeGroup {
+	v := *g
+	v.AttributeGroups = nil
+	for _, ref := range g.AttributeGroups {
+		if trimns(ref.Ref) != orig.Name {
+			v.AttributeGroups = append(v.AttributeGroups, ref)
+			continue
+		}
+		v.Attributes = append(append([]*wsdl.Attribute(nil), orig.Attributes...), v.Attributes...)
+		v.AttributeGroups = append(v.AttributeGroups, orig.AttributeGroups...)
+		if v.AnyAttribute == nil {
+			v.AnyAttribute = orig.AnyAttribute
+		}
+	}
+	return &v
+}
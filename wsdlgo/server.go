@@ -0,0 +1,336 @@
+package wsdlgo
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+var serverT = template.Must(template.New("server").Parse(`
+{{- if .WSDL}}
+// WSDL is the WSDL document of the service, served at ?wsdl by the
+// servers of Register{{.Interface}}Server.
+var WSDL = []byte({{printf "%q" .WSDL}})
+{{end}}
+{{- if .Documents}}
+// WSDLDocuments are the documents imported by the WSDL, by their
+// location in it, served at ?xsd=location.
+var WSDLDocuments = map[string][]byte{
+{{- range .Documents}}
+	{{printf "%q" .Location}}: []byte({{printf "%q" .Content}}),
+{{- end}}
+}
+{{end}}
+// Register{{.Interface}}Server adds the operations of the {{.Interface}}
+// interface to srv, served by impl. Errors returned by impl are sent as
+// SOAP faults; a *soap.Fault, such as those of the fault constructors,
+// is sent as it is.
+func Register{{.Interface}}Server(srv *soap.Server, impl {{.Interface}}) {
+{{- if .WSDL}}
+	srv.WSDL = WSDL
+{{- end}}
+{{- if .Documents}}
+	srv.Documents = WSDLDocuments
+{{- end}}
+{{- range .Ops}}
+	srv.Handle(soap.Operation{
+		Name:         {{printf "%q" .Name}},
+		{{- if .Action}}
+		Action:       {{.Action}},
+		{{- end}}
+		Request:      xml.Name{Local: {{printf "%q" .Element}}},
+		ResponseBody: true,
+		Handler: func(ctx context.Context, r *soap.Request) (soap.Message, error) {
+			α := struct {
+				{{if .OpInputDataType}}
+					{{if .Wrapper}}M {{end}}{{.OpInputDataType}}{{if .OpElement}} ` + "`xml:\"{{.OpName}}\"`" + `{{end}}
+				{{end}}
+			}{}
+			if err := r.DecodeBody(&α); err != nil {
+				return nil, &soap.Fault{Code: "soapenv:Client", String: err.Error()}
+			}
+			{{- if .InHeaders}}
+			η := struct {
+				{{.InHeaders}}
+			}{}
+			if err := r.DecodeHeader(&η); err != nil {
+				return nil, &soap.Fault{Code: "soapenv:Client", String: err.Error()}
+			}
+			{{- end}}
+			{{- range .Inputs}}{{if .Deref}}
+			var {{.Arg}} {{.DataType}}
+			if α.{{.Field}} != nil {
+				{{.Arg}} = *α.{{.Field}}
+			}
+			{{- end}}{{end}}
+			{{range .Outputs}}{{.Arg}}, {{end}}err := impl.{{.Method}}({{if $.Context}}ctx, {{end}}{{range .Inputs}}{{if .Deref}}{{.Arg}}{{else}}{{.Var}}.{{.Field}}{{if .Optional}}.Value{{end}}{{end}}, {{end}})
+			if err != nil {
+				return nil, err
+			}
+			γ := struct {
+				{{if .OpResponseDataType}}
+					{{if .Wrapper}}M {{end}}{{.OpResponseDataType}}{{if .OpElement}} ` + "`xml:\"{{.OpResponseName}}\"`" + `{{end}}
+				{{end}}
+			}{}
+			{{- if .OutHeaders}}
+			θ := struct {
+				{{.OutHeaders}}
+			}{}
+			{{- end}}
+			{{- range .Outputs}}
+			{{.Var}}.{{.Field}} = {{if .Deref}}&{{.Arg}}{{else if .Optional}}soap.NewOptional({{.Arg}}){{else}}{{.Arg}}{{end}}
+			{{- end}}
+			{{- if .OutHeaders}}
+			return &soap.HeaderMessage{Header: &θ, Body: &γ}, nil
+			{{- else}}
+			return &γ, nil
+			{{- end}}
+		},
+	})
+{{- end}}
+}
+`))
+
+var serverFaultsT = template.Must(template.New("serverFaults").Parse(`{{range .Faults}}
+// New{{.Name}} returns the {{.Message}} fault with detail,
+// for implementations of {{$.Interface}} to return.
+func New{{.Name}}(reason string, detail {{.DataType}}) error {
+	d, err := soap.NewDetail(xml.Name{Space: {{.Space}}, Local: {{printf "%q" .Element}}}, detail)
+	if err != nil {
+		return err
+	}
+	return &soap.Fault{Code: "soapenv:Server", String: reason, Detail: d}
+}
+{{end}}
+`))
+
+// serverOp is an operation of the generated server glue.
+type serverOp struct {
+	Name               string
+	Action             string
+	Element            string
+	Method             string
+	OpName             string
+	OpInputDataType    string
+	OpResponseName     string
+	OpResponseDataType string
+	Inputs             []*serverArg
+	Outputs            []*serverArg
+	InHeaders          string // fields of the SOAP Header of requests
+	OutHeaders         string // fields of the SOAP Header of responses
+	Wrapper            bool   // the message is held in the M field of the operation element
+	OpElement          bool   // the message is held in the operation element
+}
+
+// serverArg is an argument or result of a method the server glue calls,
+// held by the field, a selector of the wrapper, of an operation wrapper,
+// or of the SOAP Header. Non-pointer values are held as pointers, and
+// dereferenced.
+type serverArg struct {
+	Arg      string
+	Var      string // holding the field
+	Field    string
+	DataType string
+	Deref    bool
+	Optional bool // the field is a soap.Optional of the parameter
+}
+
+// serverFault is a fault constructor of the generated server glue.
+type serverFault struct {
+	Name     string
+	Message  string
+	DataType string
+	Space    string
+	Element  string
+}
+
+// serverDocument is a document imported by the WSDL, by its location.
+type serverDocument struct {
+	Location string
+	Content  []byte
+}
+
+// writeServer writes the Register function serving the port type
+// interface with soap.Server, and a constructor for each fault message
+// of its operations. The WSDL it was decoded from, and the documents it
+// imports, are embedded for the server to serve.
+func (ge *goEncoder) writeServer(w io.Writer, d *wsdl.Definitions) error {
+	if len(ge.funcs) == 0 {
+		return nil
+	}
+	rpcStyle := d.Binding.BindingType != nil && d.Binding.BindingType.Style == "rpc"
+	var ops []*serverOp
+	var faults []*serverFault
+	seen := make(map[string]bool)
+	for _, fn := range ge.funcnames {
+		op := ge.funcs[fn]
+		if _, exists := ge.soapOps[op.Name]; !exists {
+			continue
+		}
+		if _, ok := ge.httpGetLocation(d, op.Name); ok {
+			continue
+		}
+		in, err := ge.inputParams(op)
+		if err != nil {
+			return err
+		}
+		out, err := ge.outputParams(op)
+		if err != nil {
+			return err
+		}
+		sop := &serverOp{
+			Name:           op.Name,
+			Element:        ge.requestElement(op, rpcStyle),
+			Method:         goSymbol(op.Name),
+			OpName:         op.Name,
+			OpResponseName: op.Name + "Response",
+			Wrapper:        rpcStyle,
+			OpElement:      rpcStyle || !ge.elementParts(op),
+		}
+		if action, _ := ge.soapAction(op.Name); action != "" {
+			sop.Action = ge.soapActionConst(op.Name)
+		}
+		body, hin := splitHeaders(in)
+		_, hout := splitHeaders(out)
+		sop.InHeaders, sop.OutHeaders = headerFields(hin), headerFields(hout)
+		if len(body) > 0 && op.Input != nil {
+			sop.OpInputDataType = ge.sanitizedOperationsType(ge.messages[trimns(op.Input.Message)].Name)
+		} else if rpcStyle {
+			sop.OpInputDataType = "struct{}"
+		}
+		if op.Output != nil {
+			sop.OpResponseDataType = ge.sanitizedOperationsType(ge.messages[trimns(op.Output.Message)].Name)
+		} else if rpcStyle {
+			sop.OpResponseDataType = "struct{}"
+		}
+		if win, wout, ok := ge.wrappedOperation(op); ok {
+			sop.Wrapper, sop.OpElement = true, true
+			sop.OpName, sop.OpInputDataType = win.element, win.typ
+			sop.OpResponseName, sop.OpResponseDataType = "", ""
+			if wout != nil {
+				sop.OpResponseName, sop.OpResponseDataType = wout.tag(true), wout.typ
+			}
+		}
+		for i, p := range in {
+			sop.Inputs = append(sop.Inputs, serverParam(fmt.Sprintf("in%d", i), p, sop.Wrapper, "α", "η"))
+		}
+		for i, p := range out[:len(out)-1] {
+			sop.Outputs = append(sop.Outputs, serverParam(fmt.Sprintf("out%d", i), p, sop.Wrapper, "γ", "θ"))
+		}
+		ops = append(ops, sop)
+
+		for _, f := range op.Faults {
+			name := trimns(f.Message)
+			m, ok := ge.messages[name]
+			if !ok || seen[name] || len(m.Parts) == 0 {
+				continue
+			}
+			seen[name] = true
+			p := ge.genParams(m, false)[0]
+			sf := &serverFault{
+				Name:     goSymbol(name),
+				Message:  name,
+				DataType: p.dataType,
+				Space:    `""`,
+				Element:  m.Parts[0].Name,
+			}
+			if !strings.HasSuffix(sf.Name, "Fault") {
+				sf.Name += "Fault"
+			}
+			if m.Parts[0].Element != "" {
+				sf.Element = trimns(m.Parts[0].Element)
+				if d.TargetNamespace != "" {
+					sf.Space = "Namespace"
+				}
+			}
+			faults = append(faults, sf)
+		}
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+	ge.needsStdPkg["context"] = true
+	ge.needsStdPkg["encoding/xml"] = true
+	ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+	var docs []*serverDocument
+	for loc, b := range ge.documents {
+		docs = append(docs, &serverDocument{loc, b})
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].Location < docs[j].Location })
+	data := &struct {
+		Interface string
+		WSDL      []byte
+		Documents []*serverDocument
+		Ops       []*serverOp
+		Faults    []*serverFault
+		Context   bool
+	}{
+		goSymbol(d.PortType.Name),
+		d.Source,
+		docs,
+		ops,
+		faults,
+		ge.context,
+	}
+	if err := serverT.Execute(w, data); err != nil {
+		return err
+	}
+	return serverFaultsT.Execute(ge.section(w, "faults.go"), data)
+}
+
+// serverParam returns the argument arg of the method parameter p, held
+// by body, in the M field of the operation element of rpc style and
+// wrapped operations, or by header for soap:header parameters, in the
+// field of its part. The children of wrapper elements have the type of
+// the parameters.
+func serverParam(arg string, p *parameter, wrapper bool, body, header string) *serverArg {
+	if p.header != "" {
+		return &serverArg{Arg: arg, Var: header, Field: headerField(p), DataType: p.dataType}
+	}
+	if p.field != "" {
+		return &serverArg{Arg: arg, Var: body, Field: "M." + p.field, DataType: p.dataType}
+	}
+	field := goSymbol(p.code)
+	if p.part != nil {
+		field = p.part.name
+	}
+	if wrapper {
+		field = "M." + field
+	}
+	pointer, optional := p.partAccess()
+	return &serverArg{
+		Arg:      arg,
+		Var:      body,
+		Field:    field,
+		DataType: p.dataType,
+		Deref:    pointer,
+		Optional: optional,
+	}
+}
+
+// requestElement returns the local name of the Body element of requests
+// of op: the operation itself for rpc style, or else the first part of
+// its input message.
+func (ge *goEncoder) requestElement(op *wsdl.Operation, rpcStyle bool) string {
+	if rpcStyle {
+		return op.Name
+	}
+	if op.Input == nil {
+		return ""
+	}
+	m, ok := ge.messages[trimns(op.Input.Message)]
+	if !ok || len(m.Parts) == 0 {
+		return ""
+	}
+	if el := m.Parts[0].Element; el != "" {
+		if e, ok := ge.elements[trimns(el)]; ok {
+			return trimns(e.Name)
+		}
+		return trimns(el)
+	}
+	return m.Parts[0].Name
+}
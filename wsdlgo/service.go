@@ -0,0 +1,249 @@
+package wsdlgo
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+// client is the client generated for a binding of the WSDL, implementing
+// the interface of its port type, called at the ports of the services
+// with the binding.
+type client struct {
+	binding  *wsdl.Binding
+	portType *wsdl.PortType
+	ports    []*servicePort
+	name     string // of the constructors New<name> and New<name>Client
+	impl     string // type implementing the interface
+	primary  bool   // whether it is the first client of its port type
+}
+
+// servicePort is a port of a service of the WSDL.
+type servicePort struct {
+	service string
+	port    *wsdl.Port
+	name    string // of the constant of its address
+}
+
+// soapActionConst is the constant of a soapAction declared in a binding.
+type soapActionConst struct {
+	name      string
+	operation string
+	action    string
+}
+
+// SetServicePorts generates only the clients of the bindings of the
+// ports named, or of the ports of the services named, by names.
+func (ge *goEncoder) SetServicePorts(names ...string) {
+	ge.portFilter = names
+}
+
+// cacheClients caches the clients of d: those of its SOAP bindings, in
+// the order of the WSDL, or of its first binding if none is. The first
+// client of each port type has the constructors named after it, those
+// of other bindings of the same port type are named after the binding.
+func (ge *goEncoder) cacheClients(d *wsdl.Definitions) error {
+	bindings := d.Bindings
+	if len(bindings) == 0 {
+		bindings = []*wsdl.Binding{&d.Binding}
+	}
+	var soap []*wsdl.Binding
+	for _, b := range bindings {
+		if b.IsSOAP() {
+			soap = append(soap, b)
+		}
+	}
+	if len(soap) == 0 {
+		soap = bindings[:1]
+	}
+	ports := ge.servicePorts(d)
+	ge.clients = nil
+	declared := make(map[string]bool)
+	for _, b := range soap {
+		c := &client{binding: b, portType: d.BindingPortType(b)}
+		if c.portType == nil {
+			c.portType = &d.PortType
+		}
+		for _, sp := range ports {
+			if trimns(sp.port.Binding) == b.Name {
+				c.ports = append(c.ports, sp)
+			}
+		}
+		if len(ge.portFilter) > 0 && len(c.ports) == 0 {
+			continue
+		}
+		c.primary, c.name = !declared[c.portType.Name], goSymbol(c.portType.Name)
+		if !c.primary {
+			c.name = goSymbol(b.Name)
+			if c.name == goSymbol(c.portType.Name) {
+				c.name += "Binding"
+			}
+		}
+		declared[c.portType.Name] = true
+		if c.name != "" {
+			c.impl = strings.ToLower(c.name[:1]) + c.name[1:]
+		}
+		ge.clients = append(ge.clients, c)
+	}
+	if len(ge.clients) == 0 {
+		return fmt.Errorf("no port or service named %s in the WSDL", strings.Join(ge.portFilter, ", "))
+	}
+	ge.client = ge.clients[0]
+	d.Binding, d.PortType = *ge.client.binding, *ge.client.portType
+	ge.cacheSOAPActions()
+	return nil
+}
+
+// servicePorts returns the ports of the services of d, those of
+// SetServicePorts only if set, with the names of the constants of their
+// addresses.
+func (ge *goEncoder) servicePorts(d *wsdl.Definitions) []*servicePort {
+	services := d.Services
+	if len(services) == 0 {
+		services = []*wsdl.Service{&d.Service}
+	}
+	var ports []*servicePort
+	names := make(map[string]bool)
+	for _, s := range services {
+		for _, p := range s.Ports {
+			if !ge.selectedPort(s.Name, p.Name) {
+				continue
+			}
+			name := goSymbol(p.Name)
+			if !strings.HasSuffix(name, "Endpoint") {
+				name += "Endpoint"
+			}
+			if names[name] {
+				name = goSymbol(s.Name) + name
+			}
+			names[name] = true
+			ports = append(ports, &servicePort{service: s.Name, port: p, name: name})
+		}
+	}
+	return ports
+}
+
+// selectedPort returns whether the port named port of the service
+// named service is generated.
+func (ge *goEncoder) selectedPort(service, port string) bool {
+	if len(ge.portFilter) == 0 {
+		return true
+	}
+	for _, name := range ge.portFilter {
+		if name == service || name == port {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheSOAPActions names the constants of the soapActions declared by
+// the operations of the bindings of the clients. The soapAction of an
+// operation a previous binding declares differently is named after the
+// binding.
+func (ge *goEncoder) cacheSOAPActions() {
+	ge.actions = nil
+	ge.actionNames = make(map[*wsdl.BindingOperation]string)
+	declared := make(map[string]string)
+	for _, c := range ge.clients {
+		ops := append([]*wsdl.BindingOperation(nil), c.binding.Operations...)
+		sort.SliceStable(ops, func(i, j int) bool { return ops[i].Name < ops[j].Name })
+		for _, op := range ops {
+			action := op.Operation.Action
+			if action == "" {
+				action = op.Operation11.Action
+			}
+			if action == "" {
+				continue
+			}
+			name := soapActionName(op.Name)
+			if v, ok := declared[name]; ok && v != action {
+				name = soapActionName(goSymbol(c.binding.Name) + goSymbol(op.Name))
+			}
+			if _, ok := declared[name]; !ok {
+				declared[name] = action
+				ge.actions = append(ge.actions, &soapActionConst{name, op.Name, action})
+			}
+			ge.actionNames[op] = name
+		}
+	}
+}
+
+// soapActionConst returns the name of the constant holding the
+// soapAction of the named operation of the binding of the client.
+func (ge *goEncoder) soapActionConst(name string) string {
+	return ge.actionNames[ge.soapOps[name]]
+}
+
+// selectClient caches the operations of the port type and binding of c,
+// the client being generated, and returns d with them.
+func (ge *goEncoder) selectClient(d *wsdl.Definitions, c *client) *wsdl.Definitions {
+	dc := *d
+	dc.Binding, dc.PortType = *c.binding, *c.portType
+	ge.client = c
+	ge.funcs = make(map[string]*wsdl.Operation)
+	ge.soapOps = make(map[string]*wsdl.BindingOperation)
+	ge.encoded = false
+	ge.cacheFuncs(&dc)
+	ge.cacheSOAPOperations(&dc)
+	return &dc
+}
+
+// writeClients writes the clients of the bindings after the first one,
+// with the interface of their port type if they are the first of it,
+// and the operation wrappers of their operations, to the client and
+// types sections of w.
+func (ge *goEncoder) writeClients(w io.Writer, d *wsdl.Definitions) error {
+	if len(ge.clients) < 2 {
+		return nil
+	}
+	defer ge.selectClient(d, ge.clients[0])
+	for _, c := range ge.clients[1:] {
+		dc := ge.selectClient(d, c)
+		cw := ge.section(w, "client.go")
+		if err := ge.writeInterfaceFuncs(cw, dc); err != nil {
+			return err
+		}
+		if err := ge.writePortType(cw, dc); err != nil {
+			return err
+		}
+		if err := ge.writeGoFuncs(cw, dc); err != nil {
+			return err
+		}
+		for _, name := range ge.sortedOperations() {
+			if err := ge.genGoOpStruct(ge.section(w, "types.go"), dc, ge.soapOps[name]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeEndpoints writes a constant for the address of each port of the
+// services of the clients.
+func (ge *goEncoder) writeEndpoints(w io.Writer) {
+	var ports []*servicePort
+	clients := make(map[*servicePort]*client)
+	for _, c := range ge.clients {
+		for _, sp := range c.ports {
+			if sp.port.Address.Location != "" {
+				ports = append(ports, sp)
+				clients[sp] = c
+			}
+		}
+	}
+	if len(ports) == 0 {
+		return
+	}
+	fmt.Fprint(w, "// Endpoints of the ports of the WSDL services.\nconst (\n")
+	for _, sp := range ports {
+		ge.writeComments(w, sp.name, fmt.Sprintf(
+			"%s is the address of the %s port of the %s service, for New%sClient.",
+			sp.name, sp.port.Name, sp.service, clients[sp].name))
+		fmt.Fprintf(w, "%s = %q\n", sp.name, sp.port.Address.Location)
+	}
+	fmt.Fprint(w, ")\n\n")
+}
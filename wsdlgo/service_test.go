@@ -0,0 +1,52 @@
+package wsdlgo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEncoderServices(t *testing.T) {
+	// The ports of services selected are generated, those missing are
+	// an error.
+	cases := []struct {
+		names   []string
+		want    []string
+		missing []string
+	}{
+		{
+			[]string{"OrdersSoapTest"},
+			[]string{"package orderssoap", "OrdersSoapTestEndpoint =", "func NewOrdersClient("},
+			[]string{"OrdersSoapEndpoint =", "Inventory"},
+		},
+		{
+			[]string{"InventoryService"},
+			[]string{"func NewInventoryClient(", "func NewInventorySoap12Client(", "SOAPActionInventorySoap12GetStock ="},
+			[]string{"Orders", "InventoryHttpPost"},
+		},
+	}
+	for _, tc := range cases {
+		var have bytes.Buffer
+		enc := NewEncoder(&have)
+		enc.SetServicePorts(tc.names...)
+		if err := enc.Encode(LoadDefinition(t, "services.wsdl", nil)); err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range tc.want {
+			if !strings.Contains(have.String(), s) {
+				t.Errorf("%s: missing %s in:\n%s", tc.names, s, have.Bytes())
+			}
+		}
+		for _, s := range tc.missing {
+			if strings.Contains(have.String(), s) {
+				t.Errorf("%s: unexpected %s in:\n%s", tc.names, s, have.Bytes())
+			}
+		}
+	}
+
+	enc := NewEncoder(&bytes.Buffer{})
+	enc.SetServicePorts("Missing")
+	if err := enc.Encode(LoadDefinition(t, "services.wsdl", nil)); err == nil {
+		t.Fatal("no error for a missing port")
+	}
+}
@@ -0,0 +1,220 @@
+package wsdlgo
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+// simpleMember is the item type of a list, or a member type of a union.
+type simpleMember struct {
+	Name      string   // of the accessor of the union
+	Type      string   // Go type
+	Validates bool     // whether Type has a Validate method
+	Enum      []string // values of an anonymous restriction
+}
+
+var listT = template.Must(template.New("list").Parse(`
+// MarshalText implements the encoding.TextMarshaler interface.
+func (v {{.Name}}) MarshalText() ([]byte, error) {
+	return soap.MarshalList(v)
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (v *{{.Name}}) UnmarshalText(text []byte) error {
+	return soap.UnmarshalList(text, v)
+}
+{{with .Item}}{{if or .Validates .Enum}}
+// Validate returns the error of the first item of {{$.Name}} that violates a facet, if any.
+func (v {{$.Name}}) Validate() error {
+	for _, x := range v {
+		{{- if .Validates}}
+		if err := x.Validate(); err != nil {
+			return err
+		}
+		{{- else}}
+		if !func() bool {
+			for _, vv := range []{{.Type}}{ {{- range .Enum}}{{.}}, {{end -}} } {
+				if x == vv {
+					return true
+				}
+			}
+			return false
+		}() {
+			return soap.EnumerationError(x)
+		}
+		{{- end}}
+	}
+	return nil
+}
+{{end}}{{end}}
+`))
+
+var unionT = template.Must(template.New("union").Parse(`{{range .Accessors}}
+// {{.Name}} parses {{$.Name}} as {{.Type}}.
+func (v {{$.Name}}) {{.Name}}() ({{.Type}}, error) {
+	return soap.ParseSimple[{{.Type}}](string(v))
+}
+{{end}}
+// Validate returns an error if v is a value of none of the member types of {{.Name}}.
+func (v {{.Name}}) Validate() error {
+	{{- if not .Text}}
+	{{- range .Members}}
+	{{- if eq .Type "string"}}
+	for _, vv := range []string{ {{- range .Enum}}{{.}}, {{end -}} } {
+		if string(v) == vv {
+			return nil
+		}
+	}
+	{{- else if .Validates}}
+	if x, err := v.{{.Name}}(); err == nil && x.Validate() == nil {
+		return nil
+	}
+	{{- else if .Enum}}
+	if x, err := v.{{.Name}}(); err == nil {
+		for _, vv := range []{{.Type}}{ {{- range .Enum}}{{.}}, {{end -}} } {
+			if x == vv {
+				return nil
+			}
+		}
+	}
+	{{- else}}
+	if _, err := v.{{.Name}}(); err == nil {
+		return nil
+	}
+	{{- end}}
+	{{- end}}
+	return soap.MemberTypesError(v)
+	{{- else}}
+	return nil
+	{{- end}}
+}
+`))
+
+// genList writes the slice type of the xsd:list simple type st, with
+// the methods encoding it as the text of its items separated by spaces.
+func (ge *goEncoder) genList(w io.Writer, st *wsdl.SimpleType) {
+	name := goSymbol(st.Name)
+	item := ge.simpleMember(st.List.ItemType, st.List.SimpleType)
+	if item == nil {
+		item = &simpleMember{Type: "string"}
+	}
+	ge.writeComments(w, name, name+" is a list of "+item.Type+", separated by spaces in XML.")
+	fmt.Fprintf(w, "type %s []%s\n", name, item.Type)
+	ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+	listT.Execute(w, &struct {
+		Name string
+		Item *simpleMember
+	}{name, item})
+	fmt.Fprintln(w)
+}
+
+// genUnion writes the type of the xsd:union simple type st, holding the
+// text of the value, with an accessor parsing it as each of its member
+// types other than string, and its Validate method.
+func (ge *goEncoder) genUnion(w io.Writer, st *wsdl.SimpleType) {
+	name := goSymbol(st.Name)
+	var members, accessors []*simpleMember
+	var types []string
+	seen := make(map[string]bool)
+	add := func(m *simpleMember) {
+		if m == nil {
+			return
+		}
+		members = append(members, m)
+		types = append(types, m.Type)
+		if m.Type != "string" && !seen[m.Name] {
+			seen[m.Name] = true
+			accessors = append(accessors, m)
+		}
+	}
+	for _, t := range strings.Fields(st.Union.MemberTypes) {
+		add(ge.simpleMember(t, nil))
+	}
+	for _, anon := range st.Union.SimpleTypes {
+		add(ge.simpleMember("", anon))
+	}
+	doc := name + " is a union of: " + strings.Join(types, ", ")
+	ge.writeComments(w, name, doc)
+	fmt.Fprintf(w, "type %s string\n", name)
+	if len(accessors) > 0 {
+		ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+	}
+	// Any text is valid for a member of type string without enumerations.
+	text := false
+	for _, m := range members {
+		text = text || m.Type == "string" && len(m.Enum) == 0
+	}
+	unionT.Execute(w, &struct {
+		Name      string
+		Accessors []*simpleMember
+		Members   []*simpleMember
+		Text      bool
+	}{name, accessors, members, text})
+	fmt.Fprintln(w)
+}
+
+// simpleMember returns the member of the named simple type t, or of the
+// anonymous one anon, or nil if none is a simple type.
+func (ge *goEncoder) simpleMember(t string, anon *wsdl.SimpleType) *simpleMember {
+	if anon != nil {
+		if anon.Restriction == nil || anon.Restriction.Base == "" {
+			return nil
+		}
+		m := ge.simpleMember(anon.Restriction.Base, nil)
+		if m != nil && !m.Validates {
+			m.Enum = ge.enumValues(m.Type, anon.Restriction)
+		}
+		return m
+	}
+	if t == "" {
+		return nil
+	}
+	typ := ge.wsdl2goType(t)
+	if strings.HasPrefix(typ, "*") || strings.HasPrefix(typ, "[]") || typ == "interface{}" {
+		return nil
+	}
+	return &simpleMember{Name: goSymbol(trimns(t)), Type: typ, Validates: ge.hasValidator(trimns(t))}
+}
+
+// enumValues returns the Go literals of the enumerations of r, of the
+// Go type typ: quoted unless of a numeric or boolean type.
+func (ge *goEncoder) enumValues(typ string, r *wsdl.Restriction) []string {
+	var values []string
+	for _, e := range r.Enum {
+		switch typ {
+		case "byte", "int", "int64", "uint", "uint64", "float64", "bool":
+			values = append(values, e.Value)
+		default:
+			values = append(values, strconv.Quote(e.Value))
+		}
+	}
+	return values
+}
+
+// hasValidator tells whether the Go type of the simple type named name
+// has a Validate method.
+func (ge *goEncoder) hasValidator(name string) bool {
+	st, ok := ge.stypes[name]
+	switch {
+	case !ok:
+		return false
+	case st.Union != nil:
+		return true
+	case st.List != nil:
+		item := ge.simpleMember(st.List.ItemType, st.List.SimpleType)
+		return item != nil && (item.Validates || len(item.Enum) > 0)
+	}
+	if st.Restriction == nil {
+		return false
+	}
+	r := st.Restriction
+	if base := trimns(r.Base); base != name && ge.hasValidator(base) {
+		return true
+	}
+	return len(r.Enum) > 0 || len(ge.facets(r, ge.builtinBase(r.Base), false)) > 0
+}
@@ -0,0 +1,271 @@
+package wsdlgo
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+// ParameterStyle is how the methods of document/literal operations take
+// their parameters.
+type ParameterStyle int
+
+const (
+	// AutoStyle unwraps the operations that follow the wrapped
+	// convention: a single element part named after the operation, of a
+	// sequence of elements.
+	AutoStyle ParameterStyle = iota
+
+	// WrappedStyle unwraps all the operations whose messages are a
+	// single element part of a sequence of elements, whatever the name
+	// of the element.
+	WrappedStyle
+
+	// BareStyle never unwraps operations: methods take and return the
+	// structs of the elements of the message parts.
+	BareStyle
+)
+
+var parameterStyles = []string{"auto", "wrapped", "bare"}
+
+// String returns the name of the style, as accepted by Set.
+func (s ParameterStyle) String() string {
+	if int(s) < len(parameterStyles) {
+		return parameterStyles[s]
+	}
+	return fmt.Sprintf("ParameterStyle(%d)", int(s))
+}
+
+// Set sets the style of its name, auto, wrapped or bare, implementing
+// the flag.Value interface.
+func (s *ParameterStyle) Set(name string) error {
+	for i, v := range parameterStyles {
+		if v == name {
+			*s = ParameterStyle(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown parameter style %q, want one of %s", name, strings.Join(parameterStyles, ", "))
+}
+
+// SetParameterStyle sets how document/literal operations are generated,
+// AutoStyle by default.
+func (ge *goEncoder) SetParameterStyle(s ParameterStyle) {
+	ge.style = s
+}
+
+// wrapper is the element of the single part of the message of a wrapped
+// operation, whose children are the parameters of the method.
+type wrapper struct {
+	element string // local name
+	space   string // namespace, if known
+	typ     string // Go type
+	fields  []*structField
+}
+
+// tag returns the tag of the field holding the wrapper, qualified with
+// its namespace if qualified is set.
+func (wr *wrapper) tag(qualified bool) string {
+	if qualified && wr.space != "" {
+		return wr.space + " " + wr.element
+	}
+	return wr.element
+}
+
+// wrappedOperation returns the wrappers of the input and output of op,
+// or false if its methods are not unwrapped. Only document style SOAP
+// operations are.
+func (ge *goEncoder) wrappedOperation(op *wsdl.Operation) (in, out *wrapper, ok bool) {
+	if ge.style == BareStyle || !ge.document || op.Input == nil {
+		return nil, nil, false
+	}
+	if in, ok = ge.messageWrapper(op.Input); !ok {
+		return nil, nil, false
+	}
+	if ge.style == AutoStyle && in.element != op.Name {
+		return nil, nil, false
+	}
+	if op.Output == nil {
+		return in, nil, true
+	}
+	if out, ok = ge.messageWrapper(op.Output); !ok {
+		return nil, nil, false
+	}
+	return in, out, true
+}
+
+// messageWrapper returns the wrapper of the message of msg, if it is a
+// single element part of a complex type of a sequence of elements.
+func (ge *goEncoder) messageWrapper(msg *wsdl.IO) (*wrapper, bool) {
+	m, ok := ge.messages[trimns(msg.Message)]
+	if !ok || len(m.Parts) != 1 || m.Parts[0].Element == "" {
+		return nil, false
+	}
+	name := trimns(m.Parts[0].Element)
+	typ := name
+	if el, ok := ge.elements[name]; ok && el.Type != "" {
+		typ = trimns(el.Type)
+	}
+	ct, ok := ge.ctypes[typ]
+	if !ok || len(ct.Attributes) > 0 || len(ct.AttributeGroups) > 0 || ct.AnyAttribute != nil || ct.Choice != nil ||
+		ct.ComplexContent != nil || ct.SimpleContent != nil {
+		return nil, false
+	}
+	wr := &wrapper{
+		element: name,
+		space:   ge.typeNamespaces[name],
+		typ:     strings.TrimPrefix(ge.wsdl2goType(typ), "*"),
+	}
+	// The elements of xs:all are 0..1, as those of a sequence of the
+	// same elements.
+	els := allElements(ct.AllElements)
+	if ct.Sequence != nil {
+		if len(ct.Sequence.Choices) > 0 || len(ct.Sequence.Any) > 0 || len(ct.Sequence.ComplexTypes) > 0 {
+			return nil, false
+		}
+		els = append(els, ct.Sequence.Elements...)
+	}
+	if len(els) == 0 {
+		return wr, true
+	}
+	_, used := ge.usedNameSpaceMap[ct.TargetNamespace]
+	var ns string
+	if used {
+		ns = ct.TargetNamespace
+	}
+	for _, el := range els {
+		f := ge.elementField(el, ns)
+		if f == nil {
+			return nil, false
+		}
+		wr.fields = append(wr.fields, f)
+	}
+	return wr, true
+}
+
+// elementParts returns whether the parts of the messages of op are all
+// elements.
+func (ge *goEncoder) elementParts(op *wsdl.Operation) bool {
+	for _, msg := range []*wsdl.IO{op.Input, op.Output} {
+		if msg == nil {
+			continue
+		}
+		for _, p := range ge.messages[trimns(msg.Message)].Parts {
+			if p.Element == "" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// params returns the parameters of the children of the wrapper.
+func (wr *wrapper) params() []*parameter {
+	params := make([]*parameter, len(wr.fields))
+	for i, f := range wr.fields {
+		code := strings.ToLower(f.name[:1]) + f.name[1:]
+		if code == "p" || code == "err" {
+			// The receiver and the error of the methods.
+			code = "_" + code
+		}
+		params[i] = &parameter{code: code, dataType: f.typ, field: f.name}
+	}
+	return params
+}
+
+var wrappedFuncT = template.Must(template.New("wrappedFunc").Parse(
+	`func (p *{{.PortType}}) {{.Name}}({{.Input}}) ({{.Output}}) {
+	{{.Call.Headers}}α := struct {
+		M {{.In.Type}} ` + "`xml:\"{{.In.Tag}}\"`" + `
+	}{
+		{{.In.Type}}{
+			{{range .In.Fields}}{{.Field}}: {{.Arg}},
+			{{end}}
+		},
+	}
+
+	γ := struct {
+		{{if .Out}}M {{.Out.Type}} ` + "`xml:\"{{.Out.Tag}}\"`" + `{{end}}
+	}{}
+	if err := {{.Call.Client}}.{{.RoundTripType}}{{if .Context}}Context(ctx, {{else}}({{end}}{{.Action}}, α, &γ); err != nil {
+		return {{.RetDef}}
+	}
+	return {{if .Out}}{{range .Out.Fields}}γ.M.{{.Field}}, {{end}}{{end}}{{.Call.Results}}nil
+}
+`))
+
+// wrappedFuncElement is the wrapper of the input or output of a method
+// of wrappedFuncT.
+type wrappedFuncElement struct {
+	Type   string
+	Tag    string
+	Fields []*serverArg
+}
+
+// writeWrappedFunc writes the method of the wrapped operation op, whose
+// input and output are held in the wrapper elements in and out.
+func (ge *goEncoder) writeWrappedFunc(w io.Writer, d *wsdl.Definitions, op *wsdl.Operation, params, results []*parameter, win, wout *wrapper) error {
+	in, hin := splitHeaders(params)
+	out, hout := splitHeaders(results)
+	roundTrip, action := "RoundTripWithAction", fmt.Sprintf("%q", op.Name)
+	if soapAction, fn := ge.soapAction(op.Name); soapAction != "" || ge.soap12 {
+		roundTrip, action = fn, `""`
+		if soapAction != "" {
+			action = ge.soapActionConst(op.Name)
+		}
+	}
+	data := &struct {
+		PortType      string
+		Name          string
+		Input         string
+		Output        string
+		In            *wrappedFuncElement
+		Out           *wrappedFuncElement
+		Call          *headerCall
+		RoundTripType string
+		Action        string
+		RetDef        string
+		Context       bool
+	}{
+		PortType:      ge.client.impl,
+		Name:          goSymbol(op.Name),
+		Input:         ge.methodParams(params),
+		Output:        strings.Join(codeParams(results), ","),
+		In:            &wrappedFuncElement{Type: win.typ, Tag: win.tag(true)},
+		Call:          newHeaderCall(hin, hout),
+		RoundTripType: roundTrip,
+		Action:        action,
+		Context:       ge.context,
+	}
+	for _, p := range in {
+		data.In.Fields = append(data.In.Fields, &serverArg{Arg: maskKeywordUsage(p.code), Field: p.field})
+	}
+	var ret []string
+	if wout != nil {
+		data.Out = &wrappedFuncElement{Type: wout.typ, Tag: wout.tag(false)}
+		for _, p := range out[:len(out)-1] {
+			data.Out.Fields = append(data.Out.Fields, &serverArg{Field: p.field})
+			ret = append(ret, zeroValue(p.dataType))
+		}
+	}
+	data.RetDef = strings.Join(headerDefaults(append(ret, ge.faultReturn(op)), hout), ", ")
+	return wrappedFuncT.Execute(w, data)
+}
+
+// zeroValue returns the zero value of the Go type typ.
+func zeroValue(typ string) string {
+	switch {
+	case strings.HasPrefix(typ, "*"), strings.HasPrefix(typ, "[]"), typ == "interface{}":
+		return "nil"
+	case typ == "bool":
+		return "false"
+	case typ == "string":
+		return `""`
+	case typ == "byte" || strings.HasPrefix(typ, "int") || strings.HasPrefix(typ, "uint") || strings.HasPrefix(typ, "float"):
+		return "0"
+	}
+	return "*new(" + typ + ")"
+}
@@ -0,0 +1,50 @@
+package wsdlgo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEncoderParameterStyle(t *testing.T) {
+	// GetPrice follows the wrapped convention, GetLastPrice takes the
+	// GetPrice element too, not named after it.
+	cases := []struct {
+		style ParameterStyle
+		want  []string
+	}{
+		{AutoStyle, []string{
+			"GetPrice(symbol *string) (*float64, error)",
+			"GetLastPrice(GetPrice *GetPrice) (*GetPriceResponse, error)",
+		}},
+		{WrappedStyle, []string{
+			"GetPrice(symbol *string) (*float64, error)",
+			"GetLastPrice(symbol *string) (*float64, error)",
+		}},
+		{BareStyle, []string{
+			"GetPrice(GetPrice *GetPrice) (*GetPriceResponse, error)",
+			"GetLastPrice(GetPrice *GetPrice) (*GetPriceResponse, error)",
+		}},
+	}
+	for _, tc := range cases {
+		var have bytes.Buffer
+		enc := NewEncoder(&have)
+		enc.SetParameterStyle(tc.style)
+		if err := enc.Encode(LoadDefinition(t, "soap12.wsdl", nil)); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(have.String(), want) {
+				t.Errorf("%s: missing %s in:\n%s", tc.style, want, have.Bytes())
+			}
+		}
+	}
+
+	var s ParameterStyle
+	if err := s.Set("bare"); err != nil || s != BareStyle {
+		t.Fatalf("want bare, have %v, %v", s, err)
+	}
+	if err := s.Set("literal"); err == nil {
+		t.Fatal("literal style accepted")
+	}
+}
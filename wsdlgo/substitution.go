@@ -0,0 +1,182 @@
+package wsdlgo
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+// substitute is an element of a substitution group.
+type substitute struct {
+	Space string
+	Name  string
+	Type  string // Go type, without the pointer
+}
+
+// substitutionGroup is the interface of the elements that may appear
+// for the Head element, implemented by their types.
+type substitutionGroup struct {
+	Head      string
+	Interface string
+	Types     []string
+	Elements  []*substitute
+}
+
+var substitutionGroupsT = template.Must(template.New("substitutionGroups").Parse(`{{range .Groups}}
+// {{.Interface}} is an element of the substitution group of {{.Head}},
+// decoded into the type registered for its name by soap.RegisterElement.
+type {{.Interface}} interface {
+	is{{.Interface}}()
+}
+{{$g := .}}{{range .Types}}
+func (*{{.}}) is{{$g.Interface}}() {}
+{{end}}{{end}}
+func init() {
+{{- range .Elements}}
+	soap.RegisterElement(xml.Name{Space: {{printf "%q" .Space}}, Local: {{printf "%q" .Name}}}, (*{{.Type}})(nil))
+{{- end}}
+}
+`))
+
+// cacheSubstitutions caches the heads and members of the substitution
+// groups of the global elements of s, by head element.
+func (ge *goEncoder) cacheSubstitutions(s *wsdl.Schema) {
+	ge.substitutionHeads = make(map[string]*wsdl.Element)
+	ge.substitutions = make(map[string][]*wsdl.Element)
+	for _, el := range s.Elements {
+		if el.SubstitutionGroup != "" {
+			head := trimns(el.SubstitutionGroup)
+			ge.substitutions[head] = append(ge.substitutions[head], el)
+		}
+	}
+	for _, el := range s.Elements {
+		if _, ok := ge.substitutions[el.Name]; ok && ge.substitutionHeads[el.Name] == nil {
+			ge.substitutionHeads[el.Name] = el
+		}
+	}
+}
+
+// substitutionField returns the field of the reference el to the head
+// of the substitution group g: a soap.Substitution of the interface of
+// g, matching any element.
+func (ge *goEncoder) substitutionField(el *wsdl.Element, g *substitutionGroup) *structField {
+	ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+	typ := "soap.Substitution[" + g.Interface + "]"
+	if el.Max != "" && el.Max != "1" {
+		typ = "[]" + typ
+	}
+	return &structField{name: goSymbol(g.Head), typ: typ, tag: ",any"}
+}
+
+// substitutionGroup returns the group of the head element named name,
+// or nil if no element substitutes it, or none of those that do are of
+// types of the package.
+func (ge *goEncoder) substitutionGroup(name string) *substitutionGroup {
+	if g, ok := ge.substitutionGroups[name]; ok {
+		return g
+	}
+	head, ok := ge.substitutionHeads[name]
+	if !ok {
+		return nil
+	}
+	g := &substitutionGroup{Head: name, Interface: goSymbol(name) + "Element"}
+	if _, ok := ge.ctypes[g.Interface]; ok {
+		g.Interface = goSymbol(name) + "SubstitutionGroup"
+	} else if _, ok := ge.stypes[g.Interface]; ok {
+		g.Interface = goSymbol(name) + "SubstitutionGroup"
+	}
+	members := []*wsdl.Element{head}
+	seen := map[string]bool{name: true}
+	types := make(map[string]bool)
+	for i := 0; i < len(members); i++ {
+		el := members[i]
+		for _, m := range ge.substitutions[el.Name] {
+			if !seen[m.Name] {
+				seen[m.Name] = true
+				members = append(members, m)
+			}
+		}
+		if el.Abstract {
+			continue
+		}
+		typ, ok := ge.substituteType(el)
+		if !ok {
+			continue
+		}
+		g.Elements = append(g.Elements, &substitute{Space: ge.typeNamespaces[el.Name], Name: el.Name, Type: typ})
+		if !types[typ] {
+			types[typ] = true
+			g.Types = append(g.Types, typ)
+		}
+	}
+	if len(g.Elements) == 0 {
+		g = nil
+	}
+	if ge.substitutionGroups == nil {
+		ge.substitutionGroups = make(map[string]*substitutionGroup)
+	}
+	ge.substitutionGroups[name] = g
+	return g
+}
+
+// substituteType returns the Go type of the element el of a substitution
+// group, which must be a named type of the package to implement the
+// interface of the group.
+func (ge *goEncoder) substituteType(el *wsdl.Element) (string, bool) {
+	name := trimns(el.Type)
+	if el.Type == "" {
+		if el.ComplexType == nil {
+			return "", false
+		}
+		name = el.Name
+	}
+	if ct, ok := ge.complexType(name); !ge.inPackage(name) || ok && ct.Abstract {
+		return "", false
+	}
+	typ := ge.wsdl2goType(name)
+	if !strings.HasPrefix(typ, "*") || strings.Contains(typ, ".") {
+		return "", false
+	}
+	return typ[1:], true
+}
+
+// writeSubstitutionGroups writes the interfaces of the substitution
+// groups of the head elements of the package, and registers the types
+// of their elements.
+func (ge *goEncoder) writeSubstitutionGroups(w io.Writer) error {
+	var heads []string
+	for head := range ge.substitutions {
+		if ge.inPackage(head) {
+			heads = append(heads, head)
+		}
+	}
+	sort.Strings(heads)
+	var groups []*substitutionGroup
+	var elements []*substitute
+	registered := make(map[string]bool)
+	for _, head := range heads {
+		g := ge.substitutionGroup(head)
+		if g == nil {
+			continue
+		}
+		groups = append(groups, g)
+		for _, el := range g.Elements {
+			if !registered[el.Space+" "+el.Name] {
+				registered[el.Space+" "+el.Name] = true
+				elements = append(elements, el)
+			}
+		}
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+	ge.needsStdPkg["encoding/xml"] = true
+	ge.needsExtPkg["github.com/YapealAG/wsdl2go/soap"] = true
+	return substitutionGroupsT.Execute(w, &struct {
+		Groups   []*substitutionGroup
+		Elements []*substitute
+	}{groups, elements})
+}
@@ -0,0 +1,60 @@
+package wsdlgo
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/YapealAG/wsdl2go/wsdl"
+)
+
+var validateT = template.Must(template.New("validate").Parse(`
+{{.Doc}}func (v *{{.Type}}) Validate() error {
+{{range .Checks}}{{.}}{{end}}	return nil
+}
+
+`))
+
+// genValidate writes the Validate method of the struct of ct, if it has
+// choices, required attributes, fields of fixed values or elements of
+// anonymous simple types of facets, returning an error if more than one
+// alternative of a choice is set, a required attribute is missing, or a
+// value differs from its fixed value or violates a facet.
+func (ge *goEncoder) genValidate(w io.Writer, ct *wsdl.ComplexType) {
+	if ct.Abstract {
+		return
+	}
+	var checks, doc []string
+	var facets bytes.Buffer
+	if c := ge.choiceChecks(ct); len(c) > 0 {
+		checks = append(checks, c...)
+		doc = append(doc, "more than one of the alternatives of a choice of "+goSymbol(ct.Name)+" is set in v")
+		ge.needsStdPkg["errors"] = true
+	}
+	if c := ge.requiredAttributeChecks(ct); len(c) > 0 {
+		checks = append(checks, c...)
+		doc = append(doc, "a required attribute is missing in v")
+		ge.needsStdPkg["errors"] = true
+	}
+	if c := ge.fixedChecks(ct); len(c) > 0 {
+		checks = append(checks, c...)
+		doc = append(doc, "a value of v differs from the fixed value of its element or attribute")
+		ge.needsStdPkg["errors"] = true
+	}
+	if c := ge.facetChecks(&facets, ct); len(c) > 0 {
+		checks = append(checks, c...)
+		doc = append(doc, "a value of v violates a facet of its element")
+	}
+	if len(checks) == 0 {
+		return
+	}
+	facets.WriteTo(w)
+	var b bytes.Buffer
+	ge.writeComments(&b, "Validate", "Validate returns an error if "+strings.Join(doc, ", or if ")+".")
+	validateT.Execute(w, &struct {
+		Type   string
+		Doc    string
+		Checks []string
+	}{goSymbol(ct.Name), b.String(), checks})
+}